@@ -0,0 +1,37 @@
+// Package admin serves the operator dashboard at /admin.
+//
+// The dashboard is a static page embedded in the binary. It signs in with
+// an admin's JWT and reads everything it shows from the /api/admin routes,
+// which are where the admin role is enforced.
+package admin
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/filesystem"
+)
+
+//go:embed static
+var static embed.FS
+
+// ContentSecurityPolicy lets the dashboard load its own assets and call the
+// API, and nothing else
+const ContentSecurityPolicy = "default-src 'self'; object-src 'none'; frame-ancestors 'none'; base-uri 'none'; form-action 'none'"
+
+// Dashboard returns a handler serving the dashboard's static assets
+func Dashboard() fiber.Handler {
+	root, err := fs.Sub(static, "static")
+	if err != nil {
+		// The embedded directory is fixed at build time
+		panic(err)
+	}
+
+	return filesystem.New(filesystem.Config{
+		Root:   http.FS(root),
+		Index:  "index.html",
+		MaxAge: 300,
+	})
+}
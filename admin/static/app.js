@@ -0,0 +1,189 @@
+"use strict";
+
+// How often the dashboard refreshes, in milliseconds
+const REFRESH_INTERVAL = 5000;
+
+const STAT_LABELS = {
+  users: "Users",
+  messages: "Messages",
+  groups: "Groups",
+  channels: "Channels",
+  blocks: "Blocks",
+  transactions: "Transactions",
+  blockchain_height: "Chain height",
+  open_reports: "Open reports",
+  connected_clients: "Connected clients",
+  secret_chat_clients: "Secret chat clients",
+};
+
+let timer = null;
+
+function token() {
+  return localStorage.getItem("piko_admin_token");
+}
+
+async function api(method, path, body) {
+  const options = { method, headers: { Authorization: "Bearer " + token() } };
+  if (body !== undefined) {
+    options.headers["Content-Type"] = "application/json";
+    options.body = JSON.stringify(body);
+  }
+
+  const response = await fetch(path, options);
+  const body = await response.json().catch(() => ({}));
+  if (!response.ok) {
+    const error = new Error(body.error || response.statusText);
+    error.status = response.status;
+    error.code = body.code;
+    throw error;
+  }
+  return body.data;
+}
+
+function cell(text, className) {
+  const td = document.createElement("td");
+  td.textContent = text;
+  if (className) {
+    td.className = className;
+  }
+  return td;
+}
+
+function fillTable(id, rows, columns, render) {
+  const tbody = document.getElementById(id);
+  tbody.replaceChildren();
+  if (rows.length === 0) {
+    const tr = document.createElement("tr");
+    const td = cell("Nothing here", "empty");
+    td.colSpan = columns;
+    tr.append(td);
+    tbody.append(tr);
+    return;
+  }
+  for (const row of rows) {
+    const tr = document.createElement("tr");
+    tr.append(...render(row));
+    tbody.append(tr);
+  }
+}
+
+function time(value) {
+  return value ? new Date(value).toLocaleString() : "";
+}
+
+function renderStats(stats) {
+  const dl = document.getElementById("stats");
+  dl.replaceChildren();
+  for (const [key, label] of Object.entries(STAT_LABELS)) {
+    const div = document.createElement("div");
+    const dt = document.createElement("dt");
+    const dd = document.createElement("dd");
+    dt.textContent = label;
+    dd.textContent = stats[key] ?? 0;
+    div.append(dt, dd);
+    dl.append(div);
+  }
+}
+
+function renderReports(reports) {
+  fillTable("reports", reports, 6, (report) => {
+    const actions = document.createElement("td");
+    for (const status of ["resolved", "dismissed"]) {
+      const button = document.createElement("button");
+      button.textContent = status === "resolved" ? "Resolve" : "Dismiss";
+      button.addEventListener("click", () => closeReport(report.id, status));
+      actions.append(button, " ");
+    }
+    return [
+      cell(report.id),
+      cell(time(report.created_at)),
+      cell(report.target_type + " " + report.target_id, "mono"),
+      cell(report.reason),
+      cell(report.reporter_address, "mono"),
+      actions,
+    ];
+  });
+}
+
+function renderClients(clients) {
+  fillTable("clients", clients, 3, (client) => [
+    cell(client.address, "mono"),
+    cell(client.encoding),
+    cell(time(client.connected_at)),
+  ]);
+}
+
+function renderBlocks(blocks) {
+  fillTable("blocks", blocks, 4, (block) => [
+    cell(block.height),
+    cell(block.id, "mono"),
+    cell(block.merkle_root, "mono"),
+    cell(time(block.timestamp)),
+  ]);
+}
+
+async function closeReport(id, status) {
+  try {
+    await api("PUT", "/api/admin/reports/" + id, { status });
+    await refresh();
+  } catch (error) {
+    showError(error);
+  }
+}
+
+function showError(error) {
+  if (error.status === 401 || error.status === 403) {
+    signOut(error.message);
+    return;
+  }
+  document.getElementById("error").textContent = error.message;
+}
+
+async function refresh() {
+  try {
+    const [stats, reports, clients, blocks] = await Promise.all([
+      api("GET", "/api/admin/stats"),
+      api("GET", "/api/admin/reports"),
+      api("GET", "/api/admin/clients"),
+      api("GET", "/api/admin/blocks?limit=10"),
+    ]);
+    renderStats(stats);
+    renderReports(reports);
+    renderClients(clients);
+    renderBlocks(blocks);
+    document.getElementById("error").textContent = "";
+  } catch (error) {
+    showError(error);
+  }
+}
+
+function signIn() {
+  document.getElementById("sign-in").hidden = true;
+  document.getElementById("dashboard").hidden = false;
+  document.getElementById("sign-out").hidden = false;
+  refresh();
+  timer = setInterval(refresh, REFRESH_INTERVAL);
+}
+
+function signOut(message) {
+  clearInterval(timer);
+  localStorage.removeItem("piko_admin_token");
+  document.getElementById("sign-in-error").textContent = message || "";
+  document.getElementById("sign-in").hidden = false;
+  document.getElementById("dashboard").hidden = true;
+  document.getElementById("sign-out").hidden = true;
+}
+
+document.getElementById("sign-in").addEventListener("submit", (event) => {
+  event.preventDefault();
+  localStorage.setItem("piko_admin_token", document.getElementById("token").value.trim());
+  signIn();
+});
+
+document.getElementById("sign-out").addEventListener("click", () => signOut());
+
+if (token()) {
+  signIn();
+} else {
+  signOut();
+}
@@ -0,0 +1,58 @@
+// Package alerts sends operator alerts, like a degraded delivery time, to
+// the webhook and email addresses in the configuration.
+package alerts
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/piko/piko/config"
+)
+
+// Alert is a problem operators should look at
+type Alert struct {
+	Title   string            `json:"title"`
+	Message string            `json:"message"`
+	Time    time.Time         `json:"time"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+// Sender delivers alerts to one destination
+type Sender interface {
+	Send(ctx context.Context, alert *Alert) error
+}
+
+// Service sends alerts to every configured destination
+type Service struct {
+	senders []Sender
+}
+
+// NewService creates an alert service from configuration. With nothing
+// configured, alerts are only logged.
+func NewService(cfg config.AlertsConfig) *Service {
+	service := &Service{}
+	if cfg.WebhookURL != "" {
+		service.senders = append(service.senders, NewWebhookSender(cfg.WebhookURL))
+	}
+	if cfg.Email.Enabled {
+		service.senders = append(service.senders, NewEmailSender(cfg.Email))
+	}
+	return service
+}
+
+// AddSender adds a destination
+func (s *Service) AddSender(sender Sender) {
+	s.senders = append(s.senders, sender)
+}
+
+// Send logs an alert and sends it to every destination. Failures are
+// logged, so one broken destination doesn't stop the others.
+func (s *Service) Send(ctx context.Context, alert *Alert) {
+	log.Printf("Alert: %s: %s", alert.Title, alert.Message)
+	for _, sender := range s.senders {
+		if err := sender.Send(ctx, alert); err != nil {
+			log.Printf("Error sending alert: %v", err)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/piko/piko/config"
+)
+
+// EmailSender emails alerts through an SMTP server
+type EmailSender struct {
+	cfg config.EmailConfig
+}
+
+// NewEmailSender creates an email sender
+func NewEmailSender(cfg config.EmailConfig) *EmailSender {
+	return &EmailSender{cfg: cfg}
+}
+
+// Send emails an alert to every configured recipient
+func (e *EmailSender) Send(ctx context.Context, alert *Alert) error {
+	if len(e.cfg.To) == 0 {
+		return fmt.Errorf("no alert email recipients")
+	}
+
+	var auth smtp.Auth
+	if e.cfg.Username != "" {
+		auth = smtp.PlainAuth("", e.cfg.Username, e.cfg.Password, e.cfg.Host)
+	}
+	addr := net.JoinHostPort(e.cfg.Host, strconv.Itoa(e.cfg.Port))
+	return smtp.SendMail(addr, auth, e.cfg.From, e.cfg.To, e.message(alert))
+}
+
+// message formats an alert as a plain text email
+func (e *EmailSender) message(alert *Alert) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", e.cfg.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(e.cfg.To, ", "))
+	fmt.Fprintf(&b, "Subject: [PIKO] %s\r\n", alert.Title)
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(alert.Message)
+	b.WriteString("\r\n")
+
+	keys := make([]string, 0, len(alert.Fields))
+	for key := range alert.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if len(keys) > 0 {
+		b.WriteString("\r\n")
+	}
+	for _, key := range keys {
+		fmt.Fprintf(&b, "%s: %s\r\n", key, alert.Fields[key])
+	}
+	return []byte(b.String())
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Field is a struct field as encoding/json writes it
+type Field struct {
+	Name      string // Go field name
+	JSONName  string
+	Type      reflect.Type
+	OmitEmpty bool
+}
+
+// JSONFields returns the fields encoding/json would write for a struct,
+// flattening embedded structs
+func JSONFields(t reflect.Type) []Field {
+	var fields []Field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+		if sf.Anonymous && name == "" {
+			embedded := sf.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				fields = append(fields, JSONFields(embedded)...)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+
+		fields = append(fields, Field{
+			Name:      sf.Name,
+			JSONName:  name,
+			Type:      sf.Type,
+			OmitEmpty: strings.Contains(options, "omitempty"),
+		})
+	}
+	return fields
+}
@@ -0,0 +1,356 @@
+package api
+
+import (
+	"crypto/sha256"
+	_ "embed"
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/types"
+	"github.com/piko/piko/utils"
+)
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	apiTimeType = reflect.TypeOf(types.Time{})
+	bytesType   = reflect.TypeOf([]byte(nil))
+)
+
+//go:embed swagger.html
+var swaggerPage []byte
+
+// swaggerUIOrigin serves the Swagger UI bundle loaded by swagger.html
+const swaggerUIOrigin = "https://unpkg.com"
+
+// swaggerContentSecurityPolicy lets the Swagger UI page load the bundle and
+// run its inline setup script, which is allowed by hash. Swagger UI sets
+// inline styles and shows data: images.
+var swaggerContentSecurityPolicy = fmt.Sprintf(
+	"default-src 'none'; script-src %s %s; style-src %s 'unsafe-inline'; img-src 'self' data:; connect-src 'self'; frame-ancestors 'none'; base-uri 'none'; form-action 'none'",
+	swaggerUIOrigin, inlineScriptHashes(swaggerPage), swaggerUIOrigin,
+)
+
+// inlineScriptPattern matches the body of script elements without a src
+var inlineScriptPattern = regexp.MustCompile(`(?s)<script>(.*?)</script>`)
+
+// inlineScriptHashes lists the CSP hash sources of a page's inline scripts
+func inlineScriptHashes(page []byte) string {
+	var sources []string
+	for _, match := range inlineScriptPattern.FindAllSubmatch(page, -1) {
+		sum := sha256.Sum256(match[1])
+		sources = append(sources, "'sha256-"+base64.StdEncoding.EncodeToString(sum[:])+"'")
+	}
+	return strings.Join(sources, " ")
+}
+
+// OpenAPI builds an OpenAPI 3 document describing Endpoints. Request and
+// response schemas come from the same structs the handlers decode and
+// encode, so the document can't drift from them.
+func OpenAPI() (map[string]interface{}, error) {
+	schemas := map[string]interface{}{
+		"Error": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"status": map[string]interface{}{"type": "string", "enum": []string{"error"}},
+				"code":   map[string]interface{}{"type": "string"},
+				"error":  map[string]interface{}{"type": "string"},
+			},
+			"required": []string{"status", "code", "error"},
+		},
+	}
+	builder := &schemaBuilder{schemas: schemas, types: map[string]reflect.Type{}}
+
+	paths := map[string]interface{}{}
+	for _, endpoint := range Endpoints {
+		operation, err := builder.operation(endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", endpoint.Name, err)
+		}
+
+		path := openAPIPath(endpoint.Path)
+		item, ok := paths[path].(map[string]interface{})
+		if !ok {
+			item = map[string]interface{}{}
+			paths[path] = item
+		}
+		item[strings.ToLower(endpoint.Method)] = operation
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "Piko API",
+			"description": `Decentralized messaging with blockchain-backed message proofs. Successful JSON responses wrap their data as {"status": "success", "data": ...}, and errors are {"status": "error", "code": ..., "error": ...} with a machine-readable code and a message.`,
+			"version":     "1.0.0",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{
+					"type":         "http",
+					"scheme":       "bearer",
+					"bearerFormat": "JWT",
+				},
+				"adminKey": map[string]interface{}{
+					"type": "apiKey",
+					"in":   "header",
+					"name": "X-Admin-Key",
+				},
+			},
+		},
+	}, nil
+}
+
+// schemaBuilder collects the component schemas of the structs reachable
+// from the endpoints
+type schemaBuilder struct {
+	schemas map[string]interface{}
+	types   map[string]reflect.Type
+}
+
+// operation describes one endpoint
+func (b *schemaBuilder) operation(endpoint Endpoint) (map[string]interface{}, error) {
+	operation := map[string]interface{}{
+		"operationId": endpoint.Name,
+		"tags":        []string{openAPITag(endpoint.Path)},
+	}
+
+	var parameters []interface{}
+	for _, segment := range strings.Split(endpoint.Path, "/") {
+		if strings.HasPrefix(segment, ":") {
+			parameters = append(parameters, map[string]interface{}{
+				"name":     segment[1:],
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]interface{}{"type": "string"},
+			})
+		}
+	}
+	if len(parameters) > 0 {
+		operation["parameters"] = parameters
+	}
+	if endpoint.Query {
+		operation["description"] = "Takes query parameters, described in API.md."
+	}
+	if endpoint.Auth {
+		security := []interface{}{map[string]interface{}{"bearerAuth": []string{}}}
+		if endpoint.AdminKey {
+			security = append(security, map[string]interface{}{"adminKey": []string{}})
+		}
+		operation["security"] = security
+	}
+
+	switch {
+	case endpoint.Kind == KindUpload:
+		operation["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"multipart/form-data":      map[string]interface{}{"schema": map[string]interface{}{"type": "object"}},
+				"application/octet-stream": map[string]interface{}{"schema": map[string]interface{}{"type": "string", "format": "binary"}},
+			},
+		}
+	case endpoint.Request != nil:
+		schema, err := b.schema(endpoint.Request)
+		if err != nil {
+			return nil, err
+		}
+		operation["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content":  map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}},
+		}
+	}
+
+	success := map[string]interface{}{"description": "Success"}
+	switch endpoint.Kind {
+	case KindDownload:
+		success["content"] = map[string]interface{}{
+			"application/octet-stream": map[string]interface{}{"schema": map[string]interface{}{"type": "string", "format": "binary"}},
+		}
+	case KindWebSocket:
+		success = map[string]interface{}{"description": "Upgrades to a WebSocket connection"}
+	default:
+		data := map[string]interface{}{"type": "object"}
+		if endpoint.Response != nil {
+			var err error
+			if data, err = b.schema(endpoint.Response); err != nil {
+				return nil, err
+			}
+		}
+		schema := map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"status": map[string]interface{}{"type": "string", "enum": []string{"success"}},
+				"data":   data,
+			},
+			"required": []string{"status", "data"},
+		}
+		success["content"] = map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}}
+	}
+
+	status := "2XX"
+	if endpoint.Kind == KindWebSocket {
+		status = "101"
+	}
+	operation["responses"] = map[string]interface{}{
+		status: success,
+		"default": map[string]interface{}{
+			"description": "Error",
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": map[string]interface{}{"$ref": "#/components/schemas/Error"}},
+			},
+		},
+	}
+	return operation, nil
+}
+
+// schema returns the schema of t, adding the structs it references to the
+// components
+func (b *schemaBuilder) schema(t reflect.Type) (map[string]interface{}, error) {
+	switch {
+	case t == timeType || t == apiTimeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}, nil
+	case t == bytesType:
+		return map[string]interface{}{"type": "string", "format": "byte"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema, err := b.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := schema["$ref"]; ok {
+			// Siblings of $ref are ignored in OpenAPI 3.0
+			return map[string]interface{}{"allOf": []interface{}{schema}, "nullable": true}, nil
+		}
+		schema["nullable"] = true
+		return schema, nil
+	case reflect.Slice, reflect.Array:
+		// Slices of pointers never hold nil
+		elem := t.Elem()
+		if elem.Kind() == reflect.Ptr {
+			elem = elem.Elem()
+		}
+		items, err := b.schema(elem)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "array", "items": items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("map key of %s must be a string", t)
+		}
+		values, err := b.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "object", "additionalProperties": values}, nil
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}, nil
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}, nil
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}, nil
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}, nil
+	case reflect.Interface:
+		return map[string]interface{}{}, nil
+	case reflect.Struct:
+		return b.component(t)
+	}
+	return nil, fmt.Errorf("%s can't be described", t)
+}
+
+// component adds a struct to the component schemas and returns a reference
+// to it
+func (b *schemaBuilder) component(t reflect.Type) (map[string]interface{}, error) {
+	name := t.Name()
+	if name == "" {
+		return nil, fmt.Errorf("anonymous struct %s can't be described, give it a name", t)
+	}
+	ref := map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	if existing, ok := b.types[name]; ok {
+		if existing != t {
+			return nil, fmt.Errorf("%s and %s would both be described as %s", existing, t, name)
+		}
+		return ref, nil
+	}
+	b.types[name] = t
+
+	properties := map[string]interface{}{}
+	var required []string
+	for _, f := range JSONFields(t) {
+		schema, err := b.schema(f.Type)
+		if err != nil {
+			return nil, err
+		}
+		properties[f.JSONName] = schema
+		if !f.OmitEmpty {
+			required = append(required, f.JSONName)
+		}
+	}
+
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	b.schemas[name] = schema
+	return ref, nil
+}
+
+// openAPIPath converts a route's :params to {params}
+func openAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// openAPITag groups an endpoint by the first segment of its path after /api
+func openAPITag(path string) string {
+	segments := strings.Split(strings.TrimPrefix(path, "/api"), "/")
+	if len(segments) < 2 || segments[1] == "" {
+		return "api"
+	}
+	return segments[1]
+}
+
+// ServeOpenAPI handles serving the OpenAPI document. It's built on first
+// use; sdk-gen -check makes sure it builds.
+func ServeOpenAPI() fiber.Handler {
+	var (
+		once     sync.Once
+		document map[string]interface{}
+		err      error
+	)
+	return func(c *fiber.Ctx) error {
+		once.Do(func() {
+			document, err = OpenAPI()
+		})
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to build the OpenAPI document")
+		}
+		// The document is served bare, as OpenAPI tools expect it
+		return c.JSON(document)
+	}
+}
+
+// ServeSwaggerUI handles serving Swagger UI for the OpenAPI document
+func ServeSwaggerUI() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Type("html")
+		return c.Send(swaggerPage)
+	}
+}
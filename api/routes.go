@@ -2,9 +2,12 @@ package api
 
 import (
 	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/admin"
 	"github.com/piko/piko/config"
 	"github.com/piko/piko/handlers"
 	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/store"
+	"github.com/piko/piko/utils"
 )
 
 // ErrorHandler handles API errors
@@ -20,36 +23,100 @@ func ErrorHandler(c *fiber.Ctx, err error) error {
 	}
 
 	// Return JSON response
-	return c.Status(code).JSON(fiber.Map{
-		"error": message,
-	})
+	return utils.ErrorResponse(c, code, utils.CodeForStatus(code), message)
+}
+
+// Server builds the API routes from the configuration main loaded and the
+// stores handlers read and write through
+type Server struct {
+	cfg    *config.Config
+	stores store.Stores
+}
+
+// NewServer creates a Server whose handlers use cfg and stores
+func NewServer(cfg *config.Config, stores store.Stores) *Server {
+	return &Server{cfg: cfg, stores: stores}
 }
 
 // RegisterRoutes registers all API routes
-func RegisterRoutes(app *fiber.App) {
-	// Load configuration
-	cfg := config.DefaultConfig()
+func (s *Server) RegisterRoutes(app *fiber.App) {
+	cfg := s.cfg
+	handlers.InitStores(s.stores)
+
+	// Rate limiters
+	authLimit := middleware.LimitAuth()
+	messageLimit := middleware.LimitMessages()
+	exportLimit := middleware.LimitExports()
+	reportLimit := middleware.LimitReports()
+	discoveryLimit := middleware.LimitContactDiscovery()
 
 	// Public routes
-	app.Post("/api/auth/register", handlers.Register(cfg))
-	app.Post("/api/auth/verify-register", handlers.VerifyRegister(cfg))
-	app.Post("/api/auth/login", handlers.Login(cfg))
-	app.Post("/api/auth/verify-login", handlers.VerifyLogin(cfg))
+	app.Get("/api/auth/register/challenge", authLimit, handlers.GetRegistrationChallenge())
+	app.Post("/api/auth/register", authLimit, handlers.Register(cfg))
+	app.Post("/api/auth/verify-register", authLimit, handlers.VerifyRegister(cfg))
+	app.Post("/api/auth/login", authLimit, handlers.Login(cfg))
+	app.Post("/api/auth/verify-login", authLimit, handlers.VerifyLogin(cfg))
+	app.Post("/api/auth/resend-otp", authLimit, handlers.ResendOTP(cfg))
+	app.Get("/api/auth/challenge", authLimit, handlers.GetChallenge(cfg))
+	app.Post("/api/auth/verify-signature", authLimit, handlers.VerifySignature(cfg))
+
+	app.Get("/api/policies", handlers.GetPolicies())
 
 	// Auth middleware for protected routes
 	authMiddleware := middleware.AuthRequired(cfg)
 
+	// Policy routes stay reachable while acceptance is pending
+	app.Post("/api/policies/accept", authMiddleware, handlers.AcceptPolicies())
+	app.Get("/api/policies/acceptances", authMiddleware, handlers.GetPolicyAcceptances())
+
 	// User routes
 	app.Get("/api/profile", authMiddleware, handlers.GetProfile())
 	app.Put("/api/profile", authMiddleware, handlers.UpdateProfile())
 	app.Put("/api/profile/username", authMiddleware, handlers.SetUsername())
+	app.Get("/api/profile/usage", authMiddleware, handlers.GetUsage())
 	app.Get("/api/users/search", authMiddleware, handlers.SearchUsers())
 	app.Get("/api/users/:address", authMiddleware, handlers.GetUser())
+	app.Get("/api/users/:address/avatar", authMiddleware, handlers.GetUserAvatar())
+
+	// Session routes
+	app.Get("/api/sessions", authMiddleware, handlers.GetSessions())
+	app.Delete("/api/sessions", authMiddleware, handlers.RevokeOtherSessions())
+	app.Delete("/api/sessions/:id", authMiddleware, handlers.RevokeSession())
+	app.Post("/api/sessions/:id/wipe", authMiddleware, handlers.WipeSession())
+	app.Get("/api/security/log", authMiddleware, handlers.GetSecurityLog())
+	app.Get("/api/security/pin", authMiddleware, handlers.GetPINStatus())
+	app.Put("/api/security/pin", authMiddleware, handlers.SetPIN(cfg))
+	app.Delete("/api/security/pin", authMiddleware, handlers.RemovePIN(cfg))
+	app.Post("/api/security/recovery-code", authMiddleware, handlers.CreateRecoveryCode())
+
+	// Contact routes
+	app.Post("/api/contacts", authMiddleware, handlers.SaveContact())
+	app.Get("/api/contacts", authMiddleware, handlers.GetContacts())
+	app.Post("/api/contacts/discover", authMiddleware, discoveryLimit, handlers.DiscoverContacts())
+	app.Delete("/api/contacts/:address", authMiddleware, handlers.DeleteContact())
+
+	// Abuse report routes
+	app.Post("/api/reports", authMiddleware, reportLimit, handlers.CreateReport())
+
+	// Support routes
+	app.Post("/api/support", authMiddleware, handlers.CreateSupportTicket())
+	app.Get("/api/support", authMiddleware, handlers.GetSupportTickets())
+
+	// Push notification device routes
+	app.Post("/api/devices", authMiddleware, handlers.RegisterDevice())
+	app.Delete("/api/devices/:token", authMiddleware, handlers.UnregisterDevice())
 
 	// User settings routes
 	app.Get("/api/settings", authMiddleware, handlers.GetUserSettings())
 	app.Put("/api/settings", authMiddleware, handlers.UpdateUserSettings())
 	app.Put("/api/settings/nickname", authMiddleware, handlers.UpdateNickname())
+	app.Put("/api/settings/dnd", authMiddleware, handlers.SetDoNotDisturb())
+	app.Delete("/api/settings/dnd", authMiddleware, handlers.ClearDoNotDisturb())
+
+	// Mute routes
+	app.Get("/api/mutes", authMiddleware, handlers.GetMutes())
+	app.Put("/api/mutes", authMiddleware, handlers.MuteConversation())
+	app.Delete("/api/mutes/:conversation_type/:conversation_id", authMiddleware, handlers.UnmuteConversation())
 
 	// User avatar routes
 	app.Post("/api/avatars", authMiddleware, handlers.UploadAvatar())
@@ -57,58 +124,226 @@ func RegisterRoutes(app *fiber.App) {
 	app.Get("/api/avatars/active", authMiddleware, handlers.GetActiveAvatar())
 	app.Put("/api/avatars/:id/active", authMiddleware, handlers.SetActiveAvatar())
 	app.Delete("/api/avatars/:id", authMiddleware, handlers.DeleteAvatar())
-	app.Get("/api/avatars/:id/file", handlers.ServeAvatar()) // Public route to serve avatar files
+	app.Get("/api/avatars/:id/file", handlers.ServeAvatar()) // Public route, authorized by the URL's signature
 
 	// Message routes
-	app.Post("/api/messages", authMiddleware, handlers.SendMessage())
+	app.Post("/api/messages", authMiddleware, messageLimit, handlers.SendMessage())
 	app.Get("/api/messages/inbox", authMiddleware, handlers.GetInbox())
 	app.Get("/api/messages/sent", authMiddleware, handlers.GetSentMessages())
+	app.Post("/api/messages/status", authMiddleware, handlers.UpdateMessageStatuses())
 	app.Get("/api/messages/:id", authMiddleware, handlers.GetMessage())
+	app.Put("/api/messages/:id", authMiddleware, handlers.EditMessage(cfg))
+	app.Get("/api/messages/:id/edits", authMiddleware, handlers.GetMessageEdits())
+	app.Get("/api/messages/:id/receipts", authMiddleware, handlers.GetMessageReceipts())
+	app.Post("/api/messages/:id/forward", authMiddleware, handlers.ForwardMessage())
 	app.Delete("/api/messages/:id", authMiddleware, handlers.DeleteMessage())
+	app.Get("/api/receipts", authMiddleware, handlers.GetReceipts())
+
+	// Broadcast list routes
+	app.Post("/api/broadcasts", authMiddleware, handlers.CreateBroadcastList())
+	app.Get("/api/broadcasts", authMiddleware, handlers.GetBroadcastLists())
+	app.Get("/api/broadcasts/:id", authMiddleware, handlers.GetBroadcastList())
+	app.Put("/api/broadcasts/:id", authMiddleware, handlers.RenameBroadcastList())
+	app.Delete("/api/broadcasts/:id", authMiddleware, handlers.DeleteBroadcastList())
+	app.Post("/api/broadcasts/:id/recipients", authMiddleware, handlers.AddBroadcastRecipients())
+	app.Delete("/api/broadcasts/:id/recipients/:address", authMiddleware, handlers.RemoveBroadcastRecipient())
+	app.Post("/api/broadcasts/:id/messages", authMiddleware, messageLimit, handlers.SendBroadcast())
+	app.Get("/api/broadcasts/:id/messages/:broadcast_id", authMiddleware, handlers.GetBroadcast())
+
+	// Collaborative document routes
+	app.Post("/api/docs", authMiddleware, handlers.CreateDoc())
+	app.Get("/api/docs/:id", authMiddleware, handlers.GetDoc())
+	app.Post("/api/docs/:id/updates", authMiddleware, messageLimit, handlers.AppendDocUpdate())
+	app.Get("/api/docs/:id/updates", authMiddleware, handlers.GetDocUpdates())
+
+	// Poll routes
+	app.Post("/api/polls", authMiddleware, handlers.CreatePoll())
+	app.Get("/api/polls/:id", authMiddleware, handlers.GetPoll())
+	app.Put("/api/polls/:id/vote", authMiddleware, handlers.VotePoll())
+	app.Delete("/api/polls/:id/vote", authMiddleware, handlers.RetractPollVote())
+	app.Post("/api/polls/:id/close", authMiddleware, handlers.ClosePoll())
+
+	// Sticker pack routes
+	app.Post("/api/sticker-packs", authMiddleware, handlers.CreateStickerPack())
+	app.Get("/api/sticker-packs", authMiddleware, handlers.GetStickerPacks())
+	app.Get("/api/sticker-packs/:id", authMiddleware, handlers.GetStickerPack())
+	app.Delete("/api/sticker-packs/:id", authMiddleware, handlers.DeleteStickerPack())
+	app.Post("/api/sticker-packs/:id/stickers", authMiddleware, handlers.AddSticker())
+	app.Delete("/api/sticker-packs/:id/stickers/:sticker_id", authMiddleware, handlers.DeleteSticker())
+	app.Put("/api/sticker-packs/:id/install", authMiddleware, handlers.InstallStickerPack())
+	app.Delete("/api/sticker-packs/:id/install", authMiddleware, handlers.UninstallStickerPack())
+	app.Get("/api/stickers/:id", authMiddleware, handlers.GetSticker())
+
+	// Conversation key verification routes
+	app.Get("/api/conversations/:address/safety-number", authMiddleware, handlers.GetSafetyNumber())
+	app.Put("/api/conversations/:address/safety-number", authMiddleware, handlers.VerifySafetyNumber())
+	app.Get("/api/conversations/:address/docs", authMiddleware, handlers.GetDirectDocs())
+
+	// Key directory routes
+	app.Post("/api/keys", authMiddleware, handlers.UploadKeys())
+	app.Get("/api/keys", authMiddleware, handlers.GetKeyStatus())
+	app.Get("/api/keys/rotation", authMiddleware, handlers.GetKeyRotation())
+	app.Get("/api/keys/rotations/pending", authMiddleware, handlers.GetPendingKeyRotations())
+	app.Post("/api/keys/rotations/:id/ack", authMiddleware, handlers.AcknowledgeKeyRotation())
+	app.Get("/api/keys/:address", authMiddleware, handlers.GetKeyBundle())
+	app.Get("/api/conversations/:address/export", authMiddleware, exportLimit, handlers.ExportConversation())
+
+	// Media routes
+	app.Post("/api/media", authMiddleware, handlers.UploadMedia())
+	app.Post("/api/media/uploads", authMiddleware, handlers.CreateMediaUpload())
+	app.Put("/api/media/uploads/:id", authMiddleware, handlers.UploadMediaChunk())
+	app.Get("/api/media/:id", authMiddleware, handlers.GetMedia())
+	app.Get("/api/media/:id/download", handlers.DownloadMedia()) // Public route, authorized by signed URL
 
 	// Channel routes
 	app.Post("/api/channels", authMiddleware, handlers.CreateChannel())
 	app.Get("/api/channels", authMiddleware, handlers.GetChannels())
+	app.Get("/api/channels/discover", authMiddleware, handlers.DiscoverChannels())
+	app.Post("/api/channels/join/:token", authMiddleware, handlers.JoinChannelByLink())
 	app.Get("/api/channels/:id", authMiddleware, handlers.GetChannel())
 	app.Put("/api/channels/:id", authMiddleware, handlers.UpdateChannel())
 	app.Delete("/api/channels/:id", authMiddleware, handlers.DeleteChannel())
 	app.Post("/api/channels/:id/members", authMiddleware, handlers.AddChannelMember())
+	app.Post("/api/channels/:id/invite-link", authMiddleware, handlers.CreateChannelInviteLink())
 	app.Get("/api/channels/:id/members", authMiddleware, handlers.GetChannelMembers())
+	app.Get("/api/channels/:id/audit-log", authMiddleware, handlers.GetChannelAuditLog())
 	app.Delete("/api/channels/:id/members/:address", authMiddleware, handlers.RemoveChannelMember())
-	app.Post("/api/channels/:id/messages", authMiddleware, handlers.SendChannelMessage())
+	app.Put("/api/channels/:id/members/:address/role", authMiddleware, handlers.UpdateChannelMemberRole())
+	app.Post("/api/channels/:id/messages", authMiddleware, messageLimit, handlers.SendChannelMessage())
 	app.Get("/api/channels/:id/messages", authMiddleware, handlers.GetChannelMessages())
+	app.Put("/api/channels/:id/messages/:message_id", authMiddleware, handlers.EditChannelMessage(cfg))
+	app.Get("/api/channels/:id/messages/:message_id/link", authMiddleware, handlers.GetChannelMessageLink())
+	app.Post("/api/channels/:id/crosspost", authMiddleware, messageLimit, handlers.CrosspostChannelMessage())
+	app.Post("/api/channels/:id/read", authMiddleware, handlers.MarkChannelRead())
+	app.Get("/api/channels/:id/docs", authMiddleware, handlers.GetChannelDocs())
+	app.Get("/api/channels/:id/polls", authMiddleware, handlers.GetChannelPolls())
+	app.Post("/api/channels/:id/ack", authMiddleware, handlers.AckChannelMessages())
+	app.Get("/api/channels/:id/stats", authMiddleware, handlers.GetChannelStats())
+	app.Get("/api/channels/:id/moderation", authMiddleware, handlers.GetChannelFlag())
+	app.Post("/api/channels/:id/appeal", authMiddleware, handlers.AppealChannelFlag())
+	app.Get("/api/channels/:id/web-view", authMiddleware, handlers.GetChannelWebView())
+	app.Put("/api/channels/:id/web-view", authMiddleware, handlers.SetChannelWebView())
+	app.Delete("/api/channels/:id/web-view", authMiddleware, handlers.DeleteChannelWebView())
 	app.Delete("/api/channels/:channel_id/messages/:message_id", authMiddleware, handlers.DeleteChannelMessage())
 
+	// Public channel web views, cached per page
+	webViewCache := handlers.WebViewCache()
+	app.Get("/api/web/channels/:slug", webViewCache, handlers.GetWebChannel())
+	app.Get("/c/:slug", middleware.ContentSecurityPolicy(handlers.WebViewContentSecurityPolicy), webViewCache, handlers.ServeChannelWebPage())
+
 	// Blockchain routes
+	app.Get("/api/blocks", authMiddleware, handlers.ListBlocks())
 	app.Get("/api/blocks/:id", authMiddleware, handlers.GetBlock())
 	app.Get("/api/blocks/height/:height", authMiddleware, handlers.GetBlockByHeight())
+	app.Get("/api/blocks/:id/transactions", authMiddleware, handlers.ListBlockTransactions())
+	app.Get("/api/transactions", authMiddleware, handlers.ListTransactions())
 	app.Get("/api/transactions/:hash", authMiddleware, handlers.GetTransaction())
 	app.Get("/api/explore/:address", authMiddleware, handlers.ExploreAddress())
 	app.Get("/api/proof/:message_id", authMiddleware, handlers.GetProof())
 	app.Get("/api/blockchain/stats", authMiddleware, handlers.GetBlockchainStats())
+	app.Get("/api/blockchain/params", authMiddleware, handlers.GetConsensusParams())
+	app.Get("/api/blockchain/verify", authMiddleware, middleware.AdminRequired(), handlers.VerifyChain())
 
-	// Secret Chat routes (no authentication required)
-	app.Post("/api/secret-chat/create", handlers.CreateSecretChat())
-	app.Post("/api/secret-chat/join", handlers.JoinSecretChat())
+	// Secret Chat routes (no authentication required, but tokens are
+	// checked when sent so restricted users can be kept out)
+	optionalAuth := middleware.OptionalAuth(cfg)
+	app.Get("/api/secret-chat/challenge", handlers.GetSecretChatChallenge())
+	app.Post("/api/secret-chat/create", optionalAuth, handlers.CreateSecretChat())
+	app.Post("/api/secret-chat/join", optionalAuth, handlers.JoinSecretChat())
 	app.Post("/api/secret-chat/send", handlers.SendSecretChatMessage())
 	app.Get("/api/secret-chat/messages/:channel_id", handlers.GetSecretChatMessages())
 	app.Delete("/api/secret-chat/:channel_id", handlers.DeleteSecretChat())
 
+	// Shared message links resolve for anyone, and show the message to
+	// signed-in members
+	app.Get("/api/links/channel/:id/:message_id", optionalAuth, handlers.ResolveMessageLink())
+
+	// Emergency freeze works signed in, or with the phone and recovery code
+	// of an account whose devices were lost
+	app.Post("/api/security/freeze", authLimit, optionalAuth, handlers.FreezeAccount(cfg))
+
 	// Secret Chat WebSocket route
 	app.Get("/ws/secret/:session_id", handlers.SecretChatWebSocketHandler())
 
 	// Regular WebSocket route
-	app.Get("/ws", handlers.WebSocketHandler())
+	app.Get("/ws", handlers.WebSocketHandler(cfg))
 
 	// Group chat routes
 	app.Post("/api/groups", authMiddleware, handlers.CreateGroup())
 	app.Get("/api/groups", authMiddleware, handlers.GetGroups())
+	app.Post("/api/groups/join/:token", authMiddleware, handlers.JoinGroupByInvite())
 	app.Get("/api/groups/:id", authMiddleware, handlers.GetGroup())
 	app.Put("/api/groups/:id", authMiddleware, handlers.UpdateGroup())
 	app.Delete("/api/groups/:id", authMiddleware, handlers.DeleteGroup())
+	app.Get("/api/groups/:id/export", authMiddleware, exportLimit, handlers.ExportGroup())
+	app.Get("/api/groups/:id/audit-log", authMiddleware, handlers.GetGroupAuditLog())
 	app.Get("/api/groups/:id/members", authMiddleware, handlers.GetGroupMembers())
 	app.Post("/api/groups/:id/members", authMiddleware, handlers.AddGroupMember())
 	app.Delete("/api/groups/:id/members/:address", authMiddleware, handlers.RemoveGroupMember())
-	app.Post("/api/groups/:id/messages", authMiddleware, handlers.SendGroupMessage())
+	app.Put("/api/groups/:id/members/:address/role", authMiddleware, handlers.UpdateGroupMemberRole())
+	app.Post("/api/groups/:id/transfer-ownership", authMiddleware, handlers.TransferGroupOwnership())
+	app.Put("/api/groups/:id/join-mode", authMiddleware, handlers.SetGroupJoinMode())
+	app.Post("/api/groups/:id/join", authMiddleware, handlers.RequestToJoinGroup())
+	app.Get("/api/groups/:id/join-requests", authMiddleware, handlers.GetGroupJoinRequests())
+	app.Post("/api/groups/:id/join-requests/:address/approve", authMiddleware, handlers.ApproveGroupJoinRequest())
+	app.Post("/api/groups/:id/join-requests/:address/reject", authMiddleware, handlers.RejectGroupJoinRequest())
+	app.Post("/api/groups/:id/guests", authMiddleware, handlers.GrantGuestPass())
+	app.Get("/api/groups/:id/guests", authMiddleware, handlers.GetGuestPasses())
+	app.Delete("/api/groups/:id/guests/:address", authMiddleware, handlers.RevokeGuestPass())
+	app.Post("/api/groups/:id/invites", authMiddleware, handlers.CreateGroupInvite())
+	app.Get("/api/groups/:id/invites", authMiddleware, handlers.GetGroupInvites())
+	app.Delete("/api/groups/:id/invites/:token", authMiddleware, handlers.RevokeGroupInvite())
+	app.Post("/api/groups/:id/messages", authMiddleware, messageLimit, handlers.SendGroupMessage())
 	app.Get("/api/groups/:id/messages", authMiddleware, handlers.GetGroupMessages())
+	app.Put("/api/groups/:id/messages/:message_id", authMiddleware, handlers.EditGroupMessage(cfg))
+	app.Post("/api/groups/:id/read", authMiddleware, handlers.MarkGroupRead())
+	app.Get("/api/groups/:id/docs", authMiddleware, handlers.GetGroupDocs())
+	app.Get("/api/groups/:id/polls", authMiddleware, handlers.GetGroupPolls())
+	app.Put("/api/groups/:id/topic-mode", authMiddleware, handlers.SetGroupTopicMode())
+	app.Post("/api/groups/:id/topics", authMiddleware, handlers.CreateGroupTopic())
+	app.Get("/api/groups/:id/topics", authMiddleware, handlers.GetGroupTopics())
+	app.Post("/api/groups/:id/topics/:topic_id/close", authMiddleware, handlers.CloseGroupTopic())
+	app.Post("/api/groups/:id/topics/:topic_id/reopen", authMiddleware, handlers.ReopenGroupTopic())
+	app.Post("/api/groups/:id/topics/:topic_id/read", authMiddleware, handlers.MarkGroupTopicRead())
+	app.Post("/api/groups/:id/events", authMiddleware, handlers.CreateGroupEvent())
+	app.Get("/api/groups/:id/events", authMiddleware, handlers.GetGroupEvents())
+	app.Get("/api/groups/:id/events/:event_id", authMiddleware, handlers.GetGroupEvent())
+	app.Delete("/api/groups/:id/events/:event_id", authMiddleware, handlers.DeleteGroupEvent())
+	app.Put("/api/groups/:id/events/:event_id/rsvp", authMiddleware, handlers.RSVPGroupEvent())
+	app.Delete("/api/groups/:id/events/:event_id/rsvp", authMiddleware, handlers.DeleteGroupEventRSVP())
+
+	// Admin routes. The operator routes also take an admin API key instead
+	// of an admin's token.
+	adminMiddleware := middleware.AdminRequired()
+	operatorMiddleware := middleware.OperatorRequired(cfg)
+	app.Get("/api/admin/stats", operatorMiddleware, handlers.GetAdminStats())
+	app.Get("/api/admin/blocks", operatorMiddleware, handlers.GetAdminBlocks())
+	app.Get("/api/admin/blockchain", operatorMiddleware, handlers.GetAdminBlockchain())
+	app.Get("/api/admin/clients", operatorMiddleware, handlers.GetAdminClients())
+	app.Get("/api/admin/connections", operatorMiddleware, handlers.GetAdminConnections())
+	app.Get("/api/admin/users/lookup", operatorMiddleware, handlers.LookupUser())
+	app.Put("/api/admin/users/:address/suspension", operatorMiddleware, handlers.SuspendAccount())
+	app.Delete("/api/admin/users/:address/suspension", operatorMiddleware, handlers.UnsuspendAccount())
+	app.Delete("/api/admin/users/:address/messages", operatorMiddleware, handlers.PurgeUserMessages())
+	app.Get("/api/admin/reports", authMiddleware, adminMiddleware, handlers.GetAdminReports())
+	app.Put("/api/admin/reports/:id", authMiddleware, adminMiddleware, handlers.ResolveReport())
+	app.Get("/api/admin/channel-flags", authMiddleware, adminMiddleware, handlers.GetChannelFlags())
+	app.Put("/api/admin/channel-flags/:id", authMiddleware, adminMiddleware, handlers.ReviewChannelFlag())
+	app.Get("/api/admin/delivery-sla", authMiddleware, adminMiddleware, handlers.GetDeliverySLA())
+	app.Get("/api/admin/support", authMiddleware, adminMiddleware, handlers.GetAdminSupportTickets())
+	app.Get("/api/admin/support/:id", authMiddleware, adminMiddleware, handlers.GetAdminSupportTicket())
+	app.Post("/api/admin/support/:id/replies", authMiddleware, adminMiddleware, handlers.ReplySupportTicket())
+	app.Post("/api/admin/support/:id/close", authMiddleware, adminMiddleware, handlers.CloseSupportTicket())
+	app.Post("/api/admin/policies", authMiddleware, adminMiddleware, handlers.PublishPolicy())
+	app.Get("/api/admin/legal-holds", authMiddleware, adminMiddleware, handlers.GetLegalHolds())
+	app.Put("/api/admin/legal-holds/:address", authMiddleware, adminMiddleware, handlers.PlaceLegalHold())
+	app.Delete("/api/admin/legal-holds/:address", authMiddleware, adminMiddleware, handlers.ReleaseLegalHold())
+	app.Get("/api/admin/legal-holds/:address/export", authMiddleware, adminMiddleware, handlers.ExportLegalHold())
+
+	// OpenAPI description of the routes above and Swagger UI to browse it
+	app.Get("/api/openapi.json", ServeOpenAPI())
+	app.Get("/api/docs", middleware.ContentSecurityPolicy(swaggerContentSecurityPolicy), ServeSwaggerUI())
+
+	// Admin dashboard, whose data comes from the admin routes above
+	app.Use("/admin", middleware.ContentSecurityPolicy(admin.ContentSecurityPolicy), admin.Dashboard())
 }
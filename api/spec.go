@@ -0,0 +1,356 @@
+package api
+
+import (
+	"reflect"
+
+	"github.com/piko/piko/blockchain"
+	"github.com/piko/piko/handlers"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/websocket"
+)
+
+//go:generate go run ../cmd/sdk-gen -out ../sdk
+
+// EndpointKind describes how an endpoint's request and response are carried
+type EndpointKind int
+
+const (
+	// KindJSON endpoints take an optional JSON body and return JSON
+	KindJSON EndpointKind = iota
+	// KindUpload endpoints take a multipart or raw body and return JSON
+	KindUpload
+	// KindDownload endpoints return a file
+	KindDownload
+	// KindWebSocket endpoints upgrade to a WebSocket connection
+	KindWebSocket
+)
+
+// Endpoint describes one route for client generation and the OpenAPI
+// document. Every route registered by RegisterRoutes must have an entry in
+// Endpoints.
+type Endpoint struct {
+	Name     string
+	Method   string
+	Path     string
+	Kind     EndpointKind
+	Auth     bool
+	AdminKey bool         // also accepts an admin API key instead of a token
+	Query    bool         // accepts query parameters
+	Request  reflect.Type // JSON request body, nil when there is none
+	Response reflect.Type // JSON response, nil for untyped objects
+}
+
+// WebSocketEvent describes a message type sent over the WebSocket
+type WebSocketEvent struct {
+	Type        string
+	Description string
+}
+
+// typeOf returns the reflect.Type of T
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// Endpoints describes the API surface that client SDKs are generated from
+var Endpoints = []Endpoint{
+	// Auth
+	{Name: "GetRegistrationChallenge", Method: "GET", Path: "/api/auth/register/challenge", Response: typeOf[handlers.RegistrationChallengeResponse]()},
+	{Name: "Register", Method: "POST", Path: "/api/auth/register", Request: typeOf[handlers.RegisterRequest]()},
+	{Name: "VerifyRegister", Method: "POST", Path: "/api/auth/verify-register", Request: typeOf[handlers.VerifyOTPRequest]()},
+	{Name: "Login", Method: "POST", Path: "/api/auth/login", Request: typeOf[handlers.LoginRequest]()},
+	{Name: "VerifyLogin", Method: "POST", Path: "/api/auth/verify-login", Request: typeOf[handlers.VerifyOTPRequest](), Response: typeOf[handlers.AuthResponse]()},
+	{Name: "ResendOTP", Method: "POST", Path: "/api/auth/resend-otp", Request: typeOf[handlers.ResendOTPRequest](), Response: typeOf[handlers.ResendOTPResponse]()},
+	{Name: "GetChallenge", Method: "GET", Path: "/api/auth/challenge", Response: typeOf[handlers.ChallengeResponse]()},
+	{Name: "VerifySignature", Method: "POST", Path: "/api/auth/verify-signature", Request: typeOf[handlers.VerifySignatureRequest](), Response: typeOf[handlers.AuthResponse]()},
+
+	// Terms of service and privacy policy
+	{Name: "GetPolicies", Method: "GET", Path: "/api/policies", Response: typeOf[[]models.Policy]()},
+	{Name: "AcceptPolicies", Method: "POST", Path: "/api/policies/accept", Auth: true, Request: typeOf[handlers.AcceptPoliciesRequest](), Response: typeOf[handlers.PendingPoliciesResponse]()},
+	{Name: "GetPolicyAcceptances", Method: "GET", Path: "/api/policies/acceptances", Auth: true, Response: typeOf[[]models.PolicyAcceptance]()},
+
+	// Users
+	{Name: "GetProfile", Method: "GET", Path: "/api/profile", Auth: true, Response: typeOf[models.User]()},
+	{Name: "UpdateProfile", Method: "PUT", Path: "/api/profile", Auth: true, Request: typeOf[handlers.UpdateProfileRequest](), Response: typeOf[models.User]()},
+	{Name: "SetUsername", Method: "PUT", Path: "/api/profile/username", Auth: true, Request: typeOf[handlers.SetUsernameRequest]()},
+	{Name: "GetUsage", Method: "GET", Path: "/api/profile/usage", Auth: true, Query: true, Response: typeOf[handlers.UsageResponse]()},
+	{Name: "SearchUsers", Method: "GET", Path: "/api/users/search", Auth: true, Query: true, Response: typeOf[[]handlers.UserResponse]()},
+	{Name: "GetUser", Method: "GET", Path: "/api/users/:address", Auth: true, Response: typeOf[handlers.UserResponse]()},
+	{Name: "GetUserAvatar", Method: "GET", Path: "/api/users/:address/avatar", Auth: true, Response: typeOf[handlers.AvatarResponse]()},
+
+	// Sessions
+	{Name: "GetSessions", Method: "GET", Path: "/api/sessions", Auth: true, Response: typeOf[[]handlers.SessionResponse]()},
+	{Name: "RevokeOtherSessions", Method: "DELETE", Path: "/api/sessions", Auth: true},
+	{Name: "RevokeSession", Method: "DELETE", Path: "/api/sessions/:id", Auth: true},
+	{Name: "WipeSession", Method: "POST", Path: "/api/sessions/:id/wipe", Auth: true},
+	{Name: "GetSecurityLog", Method: "GET", Path: "/api/security/log", Auth: true, Response: typeOf[handlers.SecurityLogResponse]()},
+	{Name: "GetPINStatus", Method: "GET", Path: "/api/security/pin", Auth: true, Response: typeOf[handlers.PINStatusResponse]()},
+	{Name: "SetPIN", Method: "PUT", Path: "/api/security/pin", Auth: true, Request: typeOf[handlers.SetPINRequest]()},
+	{Name: "RemovePIN", Method: "DELETE", Path: "/api/security/pin", Auth: true, Request: typeOf[handlers.RemovePINRequest]()},
+	{Name: "CreateRecoveryCode", Method: "POST", Path: "/api/security/recovery-code", Auth: true, Response: typeOf[handlers.RecoveryCodeResponse]()},
+	{Name: "FreezeAccount", Method: "POST", Path: "/api/security/freeze", Request: typeOf[handlers.FreezeAccountRequest](), Response: typeOf[handlers.FreezeAccountResponse]()},
+
+	// Contacts
+	{Name: "SaveContact", Method: "POST", Path: "/api/contacts", Auth: true, Request: typeOf[handlers.SaveContactRequest](), Response: typeOf[models.Contact]()},
+	{Name: "GetContacts", Method: "GET", Path: "/api/contacts", Auth: true, Query: true, Response: typeOf[[]models.Contact]()},
+	{Name: "DiscoverContacts", Method: "POST", Path: "/api/contacts/discover", Auth: true, Request: typeOf[handlers.DiscoverContactsRequest](), Response: typeOf[[]handlers.DiscoveredContact]()},
+	{Name: "DeleteContact", Method: "DELETE", Path: "/api/contacts/:address", Auth: true},
+
+	// Abuse reports
+	{Name: "CreateReport", Method: "POST", Path: "/api/reports", Auth: true, Request: typeOf[handlers.CreateReportRequest](), Response: typeOf[models.Report]()},
+
+	// Support
+	{Name: "CreateSupportTicket", Method: "POST", Path: "/api/support", Auth: true, Request: typeOf[handlers.CreateSupportTicketRequest](), Response: typeOf[handlers.CreateSupportTicketResponse]()},
+	{Name: "GetSupportTickets", Method: "GET", Path: "/api/support", Auth: true, Response: typeOf[[]models.SupportTicket]()},
+
+	// Push notification devices
+	{Name: "RegisterDevice", Method: "POST", Path: "/api/devices", Auth: true, Request: typeOf[handlers.RegisterDeviceRequest]()},
+	{Name: "UnregisterDevice", Method: "DELETE", Path: "/api/devices/:token", Auth: true},
+
+	// Settings
+	{Name: "GetUserSettings", Method: "GET", Path: "/api/settings", Auth: true, Response: typeOf[models.UserSettings]()},
+	{Name: "UpdateUserSettings", Method: "PUT", Path: "/api/settings", Auth: true, Request: typeOf[handlers.UpdateUserSettingsRequest](), Response: typeOf[models.UserSettings]()},
+	{Name: "UpdateNickname", Method: "PUT", Path: "/api/settings/nickname", Auth: true, Request: typeOf[handlers.UpdateNicknameRequest]()},
+	{Name: "SetDoNotDisturb", Method: "PUT", Path: "/api/settings/dnd", Auth: true, Request: typeOf[handlers.DoNotDisturbRequest](), Response: typeOf[models.DoNotDisturb]()},
+	{Name: "ClearDoNotDisturb", Method: "DELETE", Path: "/api/settings/dnd", Auth: true},
+
+	// Mutes
+	{Name: "GetMutes", Method: "GET", Path: "/api/mutes", Auth: true, Response: typeOf[handlers.MutesResponse]()},
+	{Name: "MuteConversation", Method: "PUT", Path: "/api/mutes", Auth: true, Request: typeOf[handlers.MuteRequest](), Response: typeOf[models.Mute]()},
+	{Name: "UnmuteConversation", Method: "DELETE", Path: "/api/mutes/:conversation_type/:conversation_id", Auth: true},
+
+	// Avatars
+	{Name: "UploadAvatar", Method: "POST", Path: "/api/avatars", Kind: KindUpload, Auth: true, Response: typeOf[handlers.AvatarResponse]()},
+	{Name: "GetUserAvatars", Method: "GET", Path: "/api/avatars", Auth: true, Response: typeOf[[]handlers.AvatarResponse]()},
+	{Name: "GetActiveAvatar", Method: "GET", Path: "/api/avatars/active", Auth: true, Response: typeOf[handlers.AvatarResponse]()},
+	{Name: "SetActiveAvatar", Method: "PUT", Path: "/api/avatars/:id/active", Auth: true},
+	{Name: "DeleteAvatar", Method: "DELETE", Path: "/api/avatars/:id", Auth: true},
+	{Name: "ServeAvatar", Method: "GET", Path: "/api/avatars/:id/file", Query: true, Kind: KindDownload},
+
+	// Messages
+	{Name: "SendMessage", Method: "POST", Path: "/api/messages", Auth: true, Request: typeOf[handlers.SendMessageRequest]()},
+	{Name: "GetInbox", Method: "GET", Path: "/api/messages/inbox", Auth: true, Query: true, Response: typeOf[handlers.MessagesResponse]()},
+	{Name: "GetSentMessages", Method: "GET", Path: "/api/messages/sent", Auth: true, Query: true, Response: typeOf[handlers.MessagesResponse]()},
+	{Name: "UpdateMessageStatuses", Method: "POST", Path: "/api/messages/status", Auth: true, Request: typeOf[handlers.UpdateMessageStatusesRequest](), Response: typeOf[handlers.UpdateMessageStatusesResponse]()},
+	{Name: "GetMessage", Method: "GET", Path: "/api/messages/:id", Auth: true, Response: typeOf[handlers.MessageResponse]()},
+	{Name: "EditMessage", Method: "PUT", Path: "/api/messages/:id", Auth: true, Request: typeOf[handlers.EditMessageRequest](), Response: typeOf[handlers.MessageResponse]()},
+	{Name: "GetMessageEdits", Method: "GET", Path: "/api/messages/:id/edits", Auth: true, Response: typeOf[[]handlers.MessageEditResponse]()},
+	{Name: "GetMessageReceipts", Method: "GET", Path: "/api/messages/:id/receipts", Auth: true, Response: typeOf[handlers.MessageReceiptsResponse]()},
+	{Name: "ForwardMessage", Method: "POST", Path: "/api/messages/:id/forward", Auth: true, Request: typeOf[handlers.ForwardMessageRequest](), Response: typeOf[handlers.ForwardMessageResponse]()},
+	{Name: "DeleteMessage", Method: "DELETE", Path: "/api/messages/:id", Auth: true},
+	{Name: "GetReceipts", Method: "GET", Path: "/api/receipts", Auth: true, Query: true, Response: typeOf[handlers.ReceiptsResponse]()},
+
+	// Broadcast lists
+	{Name: "CreateBroadcastList", Method: "POST", Path: "/api/broadcasts", Auth: true, Request: typeOf[handlers.CreateBroadcastListRequest](), Response: typeOf[models.BroadcastList]()},
+	{Name: "GetBroadcastLists", Method: "GET", Path: "/api/broadcasts", Auth: true, Response: typeOf[[]models.BroadcastList]()},
+	{Name: "GetBroadcastList", Method: "GET", Path: "/api/broadcasts/:id", Auth: true, Response: typeOf[models.BroadcastList]()},
+	{Name: "RenameBroadcastList", Method: "PUT", Path: "/api/broadcasts/:id", Auth: true, Request: typeOf[handlers.RenameBroadcastListRequest](), Response: typeOf[models.BroadcastList]()},
+	{Name: "DeleteBroadcastList", Method: "DELETE", Path: "/api/broadcasts/:id", Auth: true},
+	{Name: "AddBroadcastRecipients", Method: "POST", Path: "/api/broadcasts/:id/recipients", Auth: true, Request: typeOf[handlers.AddBroadcastRecipientsRequest]()},
+	{Name: "RemoveBroadcastRecipient", Method: "DELETE", Path: "/api/broadcasts/:id/recipients/:address", Auth: true},
+	{Name: "SendBroadcast", Method: "POST", Path: "/api/broadcasts/:id/messages", Auth: true, Request: typeOf[handlers.SendBroadcastRequest](), Response: typeOf[models.Broadcast]()},
+	{Name: "GetBroadcast", Method: "GET", Path: "/api/broadcasts/:id/messages/:broadcast_id", Auth: true, Response: typeOf[models.Broadcast]()},
+
+	// Collaborative documents
+	{Name: "CreateDoc", Method: "POST", Path: "/api/docs", Auth: true, Request: typeOf[handlers.CreateDocRequest](), Response: typeOf[models.CollabDoc]()},
+	{Name: "GetDoc", Method: "GET", Path: "/api/docs/:id", Auth: true, Response: typeOf[models.CollabDoc]()},
+	{Name: "AppendDocUpdate", Method: "POST", Path: "/api/docs/:id/updates", Auth: true, Request: typeOf[handlers.AppendDocUpdateRequest](), Response: typeOf[handlers.DocUpdateResponse]()},
+	{Name: "GetDocUpdates", Method: "GET", Path: "/api/docs/:id/updates", Auth: true, Query: true, Response: typeOf[handlers.DocUpdatesResponse]()},
+
+	// Polls
+	{Name: "CreatePoll", Method: "POST", Path: "/api/polls", Auth: true, Request: typeOf[handlers.CreatePollRequest](), Response: typeOf[handlers.PollResponse]()},
+	{Name: "GetPoll", Method: "GET", Path: "/api/polls/:id", Auth: true, Response: typeOf[handlers.PollResponse]()},
+	{Name: "VotePoll", Method: "PUT", Path: "/api/polls/:id/vote", Auth: true, Request: typeOf[handlers.VotePollRequest](), Response: typeOf[handlers.PollResponse]()},
+	{Name: "RetractPollVote", Method: "DELETE", Path: "/api/polls/:id/vote", Auth: true, Response: typeOf[handlers.PollResponse]()},
+	{Name: "ClosePoll", Method: "POST", Path: "/api/polls/:id/close", Auth: true, Response: typeOf[handlers.PollResponse]()},
+
+	// Sticker packs
+	{Name: "CreateStickerPack", Method: "POST", Path: "/api/sticker-packs", Auth: true, Request: typeOf[handlers.CreateStickerPackRequest](), Response: typeOf[handlers.StickerPackResponse]()},
+	{Name: "GetStickerPacks", Method: "GET", Path: "/api/sticker-packs", Auth: true, Response: typeOf[[]handlers.StickerPackResponse]()},
+	{Name: "GetStickerPack", Method: "GET", Path: "/api/sticker-packs/:id", Auth: true, Response: typeOf[handlers.StickerPackResponse]()},
+	{Name: "DeleteStickerPack", Method: "DELETE", Path: "/api/sticker-packs/:id", Auth: true},
+	{Name: "AddSticker", Method: "POST", Path: "/api/sticker-packs/:id/stickers", Auth: true, Request: typeOf[handlers.AddStickerRequest](), Response: typeOf[handlers.StickerResponse]()},
+	{Name: "DeleteSticker", Method: "DELETE", Path: "/api/sticker-packs/:id/stickers/:sticker_id", Auth: true, Response: typeOf[handlers.StickerPackResponse]()},
+	{Name: "InstallStickerPack", Method: "PUT", Path: "/api/sticker-packs/:id/install", Auth: true, Response: typeOf[handlers.StickerPackResponse]()},
+	{Name: "UninstallStickerPack", Method: "DELETE", Path: "/api/sticker-packs/:id/install", Auth: true, Response: typeOf[handlers.StickerPackResponse]()},
+	{Name: "GetSticker", Method: "GET", Path: "/api/stickers/:id", Auth: true, Response: typeOf[handlers.StickerResponse]()},
+
+	// Conversation key verification
+	{Name: "GetSafetyNumber", Method: "GET", Path: "/api/conversations/:address/safety-number", Auth: true, Response: typeOf[handlers.SafetyNumberResponse]()},
+	{Name: "GetDirectDocs", Method: "GET", Path: "/api/conversations/:address/docs", Auth: true, Response: typeOf[[]models.CollabDoc]()},
+	{Name: "VerifySafetyNumber", Method: "PUT", Path: "/api/conversations/:address/safety-number", Auth: true, Request: typeOf[handlers.VerifySafetyNumberRequest](), Response: typeOf[handlers.SafetyNumberResponse]()},
+	{Name: "UploadKeys", Method: "POST", Path: "/api/keys", Auth: true, Request: typeOf[handlers.UploadKeysRequest](), Response: typeOf[handlers.KeyStatusResponse]()},
+	{Name: "GetKeyStatus", Method: "GET", Path: "/api/keys", Auth: true, Response: typeOf[handlers.KeyStatusResponse]()},
+	{Name: "GetKeyRotation", Method: "GET", Path: "/api/keys/rotation", Auth: true, Response: typeOf[models.KeyRotation]()},
+	{Name: "GetPendingKeyRotations", Method: "GET", Path: "/api/keys/rotations/pending", Auth: true, Response: typeOf[[]models.KeyRotation]()},
+	{Name: "AcknowledgeKeyRotation", Method: "POST", Path: "/api/keys/rotations/:id/ack", Auth: true, Response: typeOf[models.KeyRotation]()},
+	{Name: "GetKeyBundle", Method: "GET", Path: "/api/keys/:address", Auth: true, Response: typeOf[handlers.KeyBundleResponse]()},
+	{Name: "ExportConversation", Method: "GET", Path: "/api/conversations/:address/export", Kind: KindDownload, Auth: true},
+
+	// Media
+	{Name: "UploadMedia", Method: "POST", Path: "/api/media", Kind: KindUpload, Auth: true, Response: typeOf[handlers.MediaResponse]()},
+	{Name: "CreateMediaUpload", Method: "POST", Path: "/api/media/uploads", Auth: true, Request: typeOf[handlers.CreateMediaUploadRequest](), Response: typeOf[handlers.MediaUploadResponse]()},
+	{Name: "UploadMediaChunk", Method: "PUT", Path: "/api/media/uploads/:id", Kind: KindUpload, Auth: true, Query: true},
+	{Name: "GetMedia", Method: "GET", Path: "/api/media/:id", Auth: true, Response: typeOf[handlers.MediaResponse]()},
+	{Name: "DownloadMedia", Method: "GET", Path: "/api/media/:id/download", Kind: KindDownload, Query: true},
+
+	// Channels
+	{Name: "CreateChannel", Method: "POST", Path: "/api/channels", Auth: true, Request: typeOf[handlers.CreateChannelRequest]()},
+	{Name: "GetChannels", Method: "GET", Path: "/api/channels", Auth: true, Response: typeOf[[]handlers.ChannelResponse]()},
+	{Name: "DiscoverChannels", Method: "GET", Path: "/api/channels/discover", Auth: true, Query: true, Response: typeOf[[]handlers.DiscoverChannelResponse]()},
+	{Name: "JoinChannelByLink", Method: "POST", Path: "/api/channels/join/:token", Auth: true, Response: typeOf[handlers.ChannelResponse]()},
+	{Name: "GetChannel", Method: "GET", Path: "/api/channels/:id", Auth: true, Response: typeOf[handlers.ChannelResponse]()},
+	{Name: "UpdateChannel", Method: "PUT", Path: "/api/channels/:id", Auth: true, Request: typeOf[handlers.UpdateChannelRequest](), Response: typeOf[handlers.ChannelResponse]()},
+	{Name: "DeleteChannel", Method: "DELETE", Path: "/api/channels/:id", Auth: true},
+	{Name: "AddChannelMember", Method: "POST", Path: "/api/channels/:id/members", Auth: true, Request: typeOf[handlers.AddChannelMemberRequest]()},
+	{Name: "GetChannelMembers", Method: "GET", Path: "/api/channels/:id/members", Auth: true, Response: typeOf[[]handlers.ChannelMemberResponse]()},
+	{Name: "GetChannelAuditLog", Method: "GET", Path: "/api/channels/:id/audit-log", Auth: true, Query: true, Response: typeOf[[]models.AuditEntry]()},
+	{Name: "CreateChannelInviteLink", Method: "POST", Path: "/api/channels/:id/invite-link", Auth: true, Response: typeOf[handlers.ChannelInviteLinkResponse]()},
+	{Name: "RemoveChannelMember", Method: "DELETE", Path: "/api/channels/:id/members/:address", Auth: true},
+	{Name: "UpdateChannelMemberRole", Method: "PUT", Path: "/api/channels/:id/members/:address/role", Auth: true, Request: typeOf[handlers.UpdateChannelMemberRoleRequest]()},
+	{Name: "SendChannelMessage", Method: "POST", Path: "/api/channels/:id/messages", Auth: true, Request: typeOf[handlers.ChannelMessageRequest]()},
+	{Name: "GetChannelMessages", Method: "GET", Path: "/api/channels/:id/messages", Auth: true, Query: true, Response: typeOf[[]handlers.ChannelMessageResponse]()},
+	{Name: "EditChannelMessage", Method: "PUT", Path: "/api/channels/:id/messages/:message_id", Auth: true, Request: typeOf[handlers.EditMessageRequest](), Response: typeOf[handlers.ChannelMessageResponse]()},
+	{Name: "GetChannelMessageLink", Method: "GET", Path: "/api/channels/:id/messages/:message_id/link", Auth: true, Response: typeOf[handlers.MessageLinkResponse]()},
+	{Name: "CrosspostChannelMessage", Method: "POST", Path: "/api/channels/:id/crosspost", Auth: true, Request: typeOf[handlers.CrosspostRequest](), Response: typeOf[handlers.CrosspostResponse]()},
+	{Name: "ResolveMessageLink", Method: "GET", Path: "/api/links/channel/:id/:message_id", Response: typeOf[handlers.ResolvedMessageLinkResponse]()},
+	{Name: "MarkChannelRead", Method: "POST", Path: "/api/channels/:id/read", Auth: true, Request: typeOf[handlers.MarkReadRequest]()},
+	{Name: "GetChannelDocs", Method: "GET", Path: "/api/channels/:id/docs", Auth: true, Response: typeOf[[]models.CollabDoc]()},
+	{Name: "GetChannelPolls", Method: "GET", Path: "/api/channels/:id/polls", Auth: true, Query: true, Response: typeOf[[]handlers.PollResponse]()},
+	{Name: "AckChannelMessages", Method: "POST", Path: "/api/channels/:id/ack", Auth: true, Request: typeOf[handlers.AckChannelMessagesRequest](), Response: typeOf[handlers.AckChannelMessagesResponse]()},
+	{Name: "GetChannelStats", Method: "GET", Path: "/api/channels/:id/stats", Auth: true, Query: true, Response: typeOf[models.ChannelStats]()},
+	{Name: "GetChannelFlag", Method: "GET", Path: "/api/channels/:id/moderation", Auth: true, Response: typeOf[models.ChannelFlag]()},
+	{Name: "AppealChannelFlag", Method: "POST", Path: "/api/channels/:id/appeal", Auth: true, Request: typeOf[handlers.AppealChannelFlagRequest](), Response: typeOf[models.ChannelFlag]()},
+	{Name: "GetChannelWebView", Method: "GET", Path: "/api/channels/:id/web-view", Auth: true, Response: typeOf[handlers.ChannelWebViewResponse]()},
+	{Name: "SetChannelWebView", Method: "PUT", Path: "/api/channels/:id/web-view", Auth: true, Request: typeOf[handlers.ChannelWebViewRequest](), Response: typeOf[handlers.ChannelWebViewResponse]()},
+	{Name: "DeleteChannelWebView", Method: "DELETE", Path: "/api/channels/:id/web-view", Auth: true},
+	{Name: "DeleteChannelMessage", Method: "DELETE", Path: "/api/channels/:channel_id/messages/:message_id", Auth: true},
+
+	// Public channel web views
+	{Name: "GetWebChannel", Method: "GET", Path: "/api/web/channels/:slug", Query: true, Response: typeOf[handlers.WebChannelResponse]()},
+	{Name: "GetChannelWebPage", Method: "GET", Path: "/c/:slug", Query: true, Kind: KindDownload},
+
+	// Blockchain
+	{Name: "ListBlocks", Method: "GET", Path: "/api/blocks", Auth: true, Query: true, Response: typeOf[handlers.BlocksResponse]()},
+	{Name: "GetBlock", Method: "GET", Path: "/api/blocks/:id", Auth: true, Response: typeOf[models.Block]()},
+	{Name: "GetBlockByHeight", Method: "GET", Path: "/api/blocks/height/:height", Auth: true, Response: typeOf[models.Block]()},
+	{Name: "ListBlockTransactions", Method: "GET", Path: "/api/blocks/:id/transactions", Auth: true, Query: true, Response: typeOf[handlers.TransactionsResponse]()},
+	{Name: "ListTransactions", Method: "GET", Path: "/api/transactions", Auth: true, Query: true, Response: typeOf[handlers.TransactionsResponse]()},
+	{Name: "GetTransaction", Method: "GET", Path: "/api/transactions/:hash", Auth: true, Response: typeOf[models.Transaction]()},
+	{Name: "ExploreAddress", Method: "GET", Path: "/api/explore/:address", Auth: true, Response: typeOf[[]models.Transaction]()},
+	{Name: "GetProof", Method: "GET", Path: "/api/proof/:message_id", Auth: true},
+	{Name: "GetBlockchainStats", Method: "GET", Path: "/api/blockchain/stats", Auth: true},
+	{Name: "GetConsensusParams", Method: "GET", Path: "/api/blockchain/params", Auth: true, Response: typeOf[handlers.ConsensusParamsResponse]()},
+	{Name: "VerifyChain", Method: "GET", Path: "/api/blockchain/verify", Auth: true, Response: typeOf[blockchain.ChainReport]()},
+
+	// Secret chats
+	{Name: "GetSecretChatChallenge", Method: "GET", Path: "/api/secret-chat/challenge", Response: typeOf[handlers.SecretChatChallengeResponse]()},
+	{Name: "CreateSecretChat", Method: "POST", Path: "/api/secret-chat/create", Request: typeOf[handlers.CreateSecretChatRequest](), Response: typeOf[handlers.CreateSecretChatResponse]()},
+	{Name: "JoinSecretChat", Method: "POST", Path: "/api/secret-chat/join", Request: typeOf[handlers.JoinSecretChatRequest](), Response: typeOf[handlers.JoinSecretChatResponse]()},
+	{Name: "SendSecretChatMessage", Method: "POST", Path: "/api/secret-chat/send", Request: typeOf[handlers.SecretChatMessageRequest]()},
+	{Name: "GetSecretChatMessages", Method: "GET", Path: "/api/secret-chat/messages/:channel_id", Query: true, Response: typeOf[[]handlers.SecretChatMessageResponse]()},
+	{Name: "DeleteSecretChat", Method: "DELETE", Path: "/api/secret-chat/:channel_id", Query: true},
+	{Name: "SecretChatWebSocket", Method: "GET", Path: "/ws/secret/:session_id", Kind: KindWebSocket},
+
+	// WebSocket
+	{Name: "WebSocket", Method: "GET", Path: "/ws", Kind: KindWebSocket, Query: true},
+
+	// Groups
+	{Name: "CreateGroup", Method: "POST", Path: "/api/groups", Auth: true, Request: typeOf[handlers.CreateGroupRequest]()},
+	{Name: "GetGroups", Method: "GET", Path: "/api/groups", Auth: true, Response: typeOf[[]handlers.GroupResponse]()},
+	{Name: "JoinGroupByInvite", Method: "POST", Path: "/api/groups/join/:token", Auth: true, Response: typeOf[handlers.GroupResponse]()},
+	{Name: "GetGroup", Method: "GET", Path: "/api/groups/:id", Auth: true, Response: typeOf[handlers.GroupResponse]()},
+	{Name: "UpdateGroup", Method: "PUT", Path: "/api/groups/:id", Auth: true, Request: typeOf[handlers.CreateGroupRequest](), Response: typeOf[handlers.GroupResponse]()},
+	{Name: "DeleteGroup", Method: "DELETE", Path: "/api/groups/:id", Auth: true},
+	{Name: "ExportGroup", Method: "GET", Path: "/api/groups/:id/export", Kind: KindDownload, Auth: true, Query: true},
+	{Name: "GetGroupAuditLog", Method: "GET", Path: "/api/groups/:id/audit-log", Auth: true, Query: true, Response: typeOf[[]models.AuditEntry]()},
+	{Name: "GetGroupMembers", Method: "GET", Path: "/api/groups/:id/members", Auth: true, Response: typeOf[[]handlers.GroupMemberResponse]()},
+	{Name: "AddGroupMember", Method: "POST", Path: "/api/groups/:id/members", Auth: true, Request: typeOf[handlers.AddGroupMemberRequest]()},
+	{Name: "RemoveGroupMember", Method: "DELETE", Path: "/api/groups/:id/members/:address", Auth: true},
+	{Name: "UpdateGroupMemberRole", Method: "PUT", Path: "/api/groups/:id/members/:address/role", Auth: true, Request: typeOf[handlers.UpdateGroupMemberRoleRequest]()},
+	{Name: "TransferGroupOwnership", Method: "POST", Path: "/api/groups/:id/transfer-ownership", Auth: true, Request: typeOf[handlers.TransferGroupOwnershipRequest]()},
+	{Name: "SetGroupJoinMode", Method: "PUT", Path: "/api/groups/:id/join-mode", Auth: true, Request: typeOf[handlers.SetGroupJoinModeRequest]()},
+	{Name: "RequestToJoinGroup", Method: "POST", Path: "/api/groups/:id/join", Auth: true, Request: typeOf[handlers.JoinGroupRequest](), Response: typeOf[models.GroupJoinRequest]()},
+	{Name: "GetGroupJoinRequests", Method: "GET", Path: "/api/groups/:id/join-requests", Auth: true, Response: typeOf[[]models.GroupJoinRequest]()},
+	{Name: "ApproveGroupJoinRequest", Method: "POST", Path: "/api/groups/:id/join-requests/:address/approve", Auth: true},
+	{Name: "RejectGroupJoinRequest", Method: "POST", Path: "/api/groups/:id/join-requests/:address/reject", Auth: true},
+	{Name: "GrantGuestPass", Method: "POST", Path: "/api/groups/:id/guests", Auth: true, Request: typeOf[handlers.GrantGuestPassRequest](), Response: typeOf[models.GuestPass]()},
+	{Name: "GetGuestPasses", Method: "GET", Path: "/api/groups/:id/guests", Auth: true, Response: typeOf[[]models.GuestPass]()},
+	{Name: "RevokeGuestPass", Method: "DELETE", Path: "/api/groups/:id/guests/:address", Auth: true},
+	{Name: "CreateGroupInvite", Method: "POST", Path: "/api/groups/:id/invites", Auth: true, Request: typeOf[handlers.CreateGroupInviteRequest](), Response: typeOf[handlers.GroupInviteResponse]()},
+	{Name: "GetGroupInvites", Method: "GET", Path: "/api/groups/:id/invites", Auth: true, Response: typeOf[[]handlers.GroupInviteResponse]()},
+	{Name: "RevokeGroupInvite", Method: "DELETE", Path: "/api/groups/:id/invites/:token", Auth: true},
+	{Name: "SendGroupMessage", Method: "POST", Path: "/api/groups/:id/messages", Auth: true, Request: typeOf[handlers.SendGroupMessageRequest]()},
+	{Name: "GetGroupMessages", Method: "GET", Path: "/api/groups/:id/messages", Auth: true, Query: true, Response: typeOf[[]handlers.GroupMessageResponse]()},
+	{Name: "EditGroupMessage", Method: "PUT", Path: "/api/groups/:id/messages/:message_id", Auth: true, Request: typeOf[handlers.EditGroupMessageRequest](), Response: typeOf[handlers.GroupMessageResponse]()},
+	{Name: "MarkGroupRead", Method: "POST", Path: "/api/groups/:id/read", Auth: true, Request: typeOf[handlers.MarkReadRequest]()},
+	{Name: "GetGroupDocs", Method: "GET", Path: "/api/groups/:id/docs", Auth: true, Response: typeOf[[]models.CollabDoc]()},
+	{Name: "GetGroupPolls", Method: "GET", Path: "/api/groups/:id/polls", Auth: true, Query: true, Response: typeOf[[]handlers.PollResponse]()},
+	{Name: "SetGroupTopicMode", Method: "PUT", Path: "/api/groups/:id/topic-mode", Auth: true, Request: typeOf[handlers.SetGroupTopicModeRequest]()},
+	{Name: "CreateGroupTopic", Method: "POST", Path: "/api/groups/:id/topics", Auth: true, Request: typeOf[handlers.CreateGroupTopicRequest](), Response: typeOf[models.GroupTopic]()},
+	{Name: "GetGroupTopics", Method: "GET", Path: "/api/groups/:id/topics", Auth: true, Response: typeOf[[]models.GroupTopic]()},
+	{Name: "CloseGroupTopic", Method: "POST", Path: "/api/groups/:id/topics/:topic_id/close", Auth: true, Response: typeOf[models.GroupTopic]()},
+	{Name: "ReopenGroupTopic", Method: "POST", Path: "/api/groups/:id/topics/:topic_id/reopen", Auth: true, Response: typeOf[models.GroupTopic]()},
+	{Name: "MarkGroupTopicRead", Method: "POST", Path: "/api/groups/:id/topics/:topic_id/read", Auth: true, Request: typeOf[handlers.MarkReadRequest]()},
+	{Name: "CreateGroupEvent", Method: "POST", Path: "/api/groups/:id/events", Auth: true, Request: typeOf[handlers.CreateGroupEventRequest](), Response: typeOf[handlers.GroupEventResponse]()},
+	{Name: "GetGroupEvents", Method: "GET", Path: "/api/groups/:id/events", Auth: true, Query: true, Response: typeOf[[]models.GroupEvent]()},
+	{Name: "GetGroupEvent", Method: "GET", Path: "/api/groups/:id/events/:event_id", Auth: true, Response: typeOf[handlers.GroupEventResponse]()},
+	{Name: "DeleteGroupEvent", Method: "DELETE", Path: "/api/groups/:id/events/:event_id", Auth: true},
+	{Name: "RSVPGroupEvent", Method: "PUT", Path: "/api/groups/:id/events/:event_id/rsvp", Auth: true, Request: typeOf[handlers.RSVPRequest](), Response: typeOf[handlers.GroupEventResponse]()},
+	{Name: "DeleteGroupEventRSVP", Method: "DELETE", Path: "/api/groups/:id/events/:event_id/rsvp", Auth: true, Response: typeOf[handlers.GroupEventResponse]()},
+
+	// Admin (requires the admin role)
+	{Name: "GetAdminStats", Method: "GET", Path: "/api/admin/stats", Auth: true, AdminKey: true, Response: typeOf[handlers.AdminStatsResponse]()},
+	{Name: "GetAdminBlocks", Method: "GET", Path: "/api/admin/blocks", Auth: true, AdminKey: true, Query: true, Response: typeOf[[]models.Block]()},
+	{Name: "GetAdminBlockchain", Method: "GET", Path: "/api/admin/blockchain", Auth: true, AdminKey: true},
+	{Name: "GetAdminClients", Method: "GET", Path: "/api/admin/clients", Auth: true, AdminKey: true, Response: typeOf[[]websocket.ClientInfo]()},
+	{Name: "GetAdminConnections", Method: "GET", Path: "/api/admin/connections", Auth: true, AdminKey: true, Response: typeOf[handlers.AdminConnectionsResponse]()},
+	{Name: "LookupUser", Method: "GET", Path: "/api/admin/users/lookup", Auth: true, AdminKey: true, Query: true, Response: typeOf[handlers.AdminUserResponse]()},
+	{Name: "SuspendAccount", Method: "PUT", Path: "/api/admin/users/:address/suspension", Auth: true, AdminKey: true, Request: typeOf[handlers.SuspendAccountRequest](), Response: typeOf[models.AccountSuspension]()},
+	{Name: "UnsuspendAccount", Method: "DELETE", Path: "/api/admin/users/:address/suspension", Auth: true, AdminKey: true},
+	{Name: "PurgeUserMessages", Method: "DELETE", Path: "/api/admin/users/:address/messages", Auth: true, AdminKey: true, Response: typeOf[models.PurgedMessages]()},
+	{Name: "GetAdminReports", Method: "GET", Path: "/api/admin/reports", Auth: true, Query: true, Response: typeOf[[]models.Report]()},
+	{Name: "ResolveReport", Method: "PUT", Path: "/api/admin/reports/:id", Auth: true, Request: typeOf[handlers.ResolveReportRequest]()},
+	{Name: "GetChannelFlags", Method: "GET", Path: "/api/admin/channel-flags", Auth: true, Query: true, Response: typeOf[[]models.ChannelFlag]()},
+	{Name: "ReviewChannelFlag", Method: "PUT", Path: "/api/admin/channel-flags/:id", Auth: true, Request: typeOf[handlers.ReviewChannelFlagRequest](), Response: typeOf[models.ChannelFlag]()},
+	{Name: "GetDeliverySLA", Method: "GET", Path: "/api/admin/delivery-sla", Auth: true, Query: true, Response: typeOf[[]models.DeliverySLA]()},
+	{Name: "GetAdminSupportTickets", Method: "GET", Path: "/api/admin/support", Auth: true, Query: true, Response: typeOf[[]models.SupportTicket]()},
+	{Name: "GetAdminSupportTicket", Method: "GET", Path: "/api/admin/support/:id", Auth: true, Response: typeOf[handlers.SupportTicketResponse]()},
+	{Name: "ReplySupportTicket", Method: "POST", Path: "/api/admin/support/:id/replies", Auth: true, Request: typeOf[handlers.SupportTicketReplyRequest](), Response: typeOf[models.SupportTicketReply]()},
+	{Name: "CloseSupportTicket", Method: "POST", Path: "/api/admin/support/:id/close", Auth: true, Response: typeOf[models.SupportTicket]()},
+	{Name: "PublishPolicy", Method: "POST", Path: "/api/admin/policies", Auth: true, Request: typeOf[handlers.PublishPolicyRequest](), Response: typeOf[models.Policy]()},
+	{Name: "GetLegalHolds", Method: "GET", Path: "/api/admin/legal-holds", Auth: true, Response: typeOf[[]models.LegalHold]()},
+	{Name: "PlaceLegalHold", Method: "PUT", Path: "/api/admin/legal-holds/:address", Auth: true, Request: typeOf[handlers.PlaceLegalHoldRequest](), Response: typeOf[models.LegalHold]()},
+	{Name: "ReleaseLegalHold", Method: "DELETE", Path: "/api/admin/legal-holds/:address", Auth: true},
+	{Name: "ExportLegalHold", Method: "GET", Path: "/api/admin/legal-holds/:address/export", Auth: true, Response: typeOf[models.ComplianceExport]()},
+
+	// API description
+	{Name: "GetOpenAPI", Method: "GET", Path: "/api/openapi.json"},
+	{Name: "GetAPIDocs", Method: "GET", Path: "/api/docs", Kind: KindDownload},
+}
+
+// WebSocketMessage is the envelope of every WebSocket message
+var WebSocketMessage = typeOf[websocket.Message]()
+
+// WebSocketEvents lists the message types exchanged over /ws
+var WebSocketEvents = []WebSocketEvent{
+	{websocket.MessageTypeNewMessage, "A direct message was received"},
+	{websocket.MessageTypeMessageStatus, "A message's delivery status changed"},
+	{websocket.MessageTypeNewChannelMessage, "A message was posted to a channel"},
+	{websocket.MessageTypeNewGroupMessage, "A message was posted to a group"},
+	{websocket.MessageTypeMessageEdited, "A message was edited by its sender"},
+	{websocket.MessageTypeMessageExpired, "Direct messages passed their expiration time and were deleted"},
+	{websocket.MessageTypeRemoteWipe, "This session was wiped from another device"},
+	{websocket.MessageTypeSafetyNumberChanged, "A contact's key changed"},
+	{websocket.MessageTypePrekeysLow, "You are running out of one-time prekeys"},
+	{websocket.MessageTypeGroupEventReminder, "A group event you haven't declined starts soon"},
+	{websocket.MessageTypePollUpdated, "A poll was created in one of your groups or channels, got or lost a vote, or was closed"},
+	{websocket.MessageTypeReconnectSoon, "The server is shutting down; reconnect shortly"},
+	{websocket.MessageTypeTyping, "A user is typing"},
+	{websocket.MessageTypePresence, "A user came online or went offline"},
+	{websocket.MessageTypeInboxPage, "A page of prefetched inbox messages"},
+	{websocket.MessageTypeInboxAck, "The client acknowledged a prefetched page"},
+	{websocket.MessageTypeInboxDone, "Inbox prefetch finished"},
+	{websocket.MessageTypeGroupReceived, "A group member acknowledged a group message"},
+	{websocket.MessageTypeGroupRead, "A group member read up to a message"},
+	{websocket.MessageTypeChannelRead, "A channel member read up to your message"},
+}
@@ -0,0 +1,96 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/piko/piko/config"
+	"github.com/piko/piko/database/dbtest"
+	"github.com/piko/piko/models"
+)
+
+// benchTimestamp keeps benchmark hashes, and so nonce searches, the same across runs
+var benchTimestamp = time.Unix(1700000000, 0)
+
+// benchTransactions builds n mempool transactions
+func benchTransactions(n int) []*MempoolTransaction {
+	transactions := make([]*MempoolTransaction, n)
+	for i := range transactions {
+		transactions[i] = &MempoolTransaction{
+			Type:      models.TransactionTypeMessage,
+			DataID:    fmt.Sprintf("bench-message-%d", i),
+			Timestamp: benchTimestamp,
+		}
+	}
+	return transactions
+}
+
+func BenchmarkCalculateMerkleRoot(b *testing.B) {
+	for _, n := range []int{1, 100, 1000} {
+		transactions := benchTransactions(n)
+		b.Run(fmt.Sprintf("transactions=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				calculateMerkleRoot(transactions)
+			}
+		})
+	}
+}
+
+func BenchmarkCalculateNonce(b *testing.B) {
+	merkleRoot := calculateMerkleRoot(benchTransactions(100))
+	previousHash := calculateBlockHash(nil, benchTimestamp, "genesis", 0)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		calculateNonce(previousHash, benchTimestamp, merkleRoot, 2)
+	}
+}
+
+// BenchmarkCreateBlock measures creating and storing a block of 100
+// message transactions, nonce search included
+func BenchmarkCreateBlock(b *testing.B) {
+	dbtest.Open(b)
+	ctx := context.Background()
+
+	cfg := config.DefaultConfig().Blockchain
+	if err := InitConsensus(ctx, cfg.Consensus); err != nil {
+		b.Fatal(err)
+	}
+
+	bc := NewBlockchain(&cfg)
+	latest, err := models.GetLatestBlock(ctx)
+	switch {
+	case errors.Is(err, models.ErrBlockNotFound):
+		if err := bc.createGenesisBlock(); err != nil {
+			b.Fatal(err)
+		}
+	case err != nil:
+		b.Fatal(err)
+	default:
+		bc.LatestBlock = latest
+	}
+
+	log.SetOutput(io.Discard)
+	b.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		for _, tx := range benchTransactions(100) {
+			bc.Mempool.AddTransaction(tx)
+		}
+		b.StartTimer()
+
+		if err := bc.createBlock(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/piko/piko/clock"
 	"github.com/piko/piko/config"
+	"github.com/piko/piko/metrics"
 	"github.com/piko/piko/models"
 )
 
@@ -14,6 +16,28 @@ var (
 	ErrEmptyMempool = errors.New("mempool is empty")
 )
 
+var (
+	mempoolSize = metrics.NewGauge(
+		"piko_blockchain_mempool_size",
+		"Transactions waiting in the mempool for the next block.",
+	)
+	blockCreationDuration = metrics.NewHistogramVec(
+		"piko_blockchain_block_creation_duration_seconds",
+		"Time spent creating and storing a block.",
+		metrics.DefaultBuckets,
+	)
+)
+
+// timestampPrecision is how precisely the database stores block and
+// transaction timestamps. Hashed timestamps are truncated to it so the chain
+// can be verified from what's stored.
+const timestampPrecision = time.Microsecond
+
+// now returns the current time at the precision timestamps are stored with
+func now() time.Time {
+	return clock.Now().Truncate(timestampPrecision)
+}
+
 // Blockchain represents the blockchain
 type Blockchain struct {
 	Config      *config.BlockchainConfig
@@ -0,0 +1,156 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/piko/piko/config"
+	"github.com/piko/piko/models"
+)
+
+var (
+	// ErrInvalidConsensusSchedule is returned when the configured schedule is malformed
+	ErrInvalidConsensusSchedule = errors.New("invalid consensus schedule")
+	// ErrConsensusHistoryChanged is returned when a schedule would change the
+	// rules of blocks that already exist
+	ErrConsensusHistoryChanged = errors.New("consensus schedule changes rules of existing blocks")
+	// ErrDifficultyNotMet is returned when a block hash doesn't meet its difficulty
+	ErrDifficultyNotMet = errors.New("block hash does not meet difficulty")
+	// ErrTransactionTypeNotActive is returned when a block includes a
+	// transaction type its rules don't allow yet
+	ErrTransactionTypeNotActive = errors.New("transaction type not active at block height")
+)
+
+// maxDifficulty is the number of hex digits in a block hash
+const maxDifficulty = 64
+
+// InitConsensus validates the configured consensus schedule and stores it.
+// Rules for heights the chain already reached must stay as they were, so a
+// deploy can only schedule changes for future blocks.
+func InitConsensus(ctx context.Context, rules []config.ConsensusRules) error {
+	schedule, err := consensusSchedule(rules)
+	if err != nil {
+		return err
+	}
+
+	latest, err := models.GetLatestBlock(ctx)
+	if err != nil && !errors.Is(err, models.ErrBlockNotFound) {
+		return err
+	}
+	if latest != nil {
+		stored, err := models.GetConsensusSchedule(ctx)
+		if err != nil {
+			return err
+		}
+		if !sameRulesUpTo(stored, schedule, latest.Height) {
+			return fmt.Errorf("%w (chain height %d)", ErrConsensusHistoryChanged, latest.Height)
+		}
+	}
+
+	if err := models.ReplaceConsensusSchedule(ctx, schedule); err != nil {
+		return err
+	}
+	initialized.Store(true)
+	return nil
+}
+
+// initialized is set once InitConsensus has stored the schedule
+var initialized atomic.Bool
+
+// Initialized reports whether InitConsensus has stored the consensus schedule
+func Initialized() bool {
+	return initialized.Load()
+}
+
+// consensusSchedule converts and validates the configured schedule
+func consensusSchedule(rules []config.ConsensusRules) ([]*models.ConsensusParams, error) {
+	schedule := make([]*models.ConsensusParams, 0, len(rules))
+	heights := map[int]bool{}
+	for _, rule := range rules {
+		if rule.ActivationHeight < 0 || heights[rule.ActivationHeight] {
+			return nil, fmt.Errorf("%w: activation height %d is negative or repeated", ErrInvalidConsensusSchedule, rule.ActivationHeight)
+		}
+		heights[rule.ActivationHeight] = true
+
+		if rule.Difficulty < 1 || rule.Difficulty > maxDifficulty {
+			return nil, fmt.Errorf("%w: difficulty %d at height %d must be between 1 and %d", ErrInvalidConsensusSchedule, rule.Difficulty, rule.ActivationHeight, maxDifficulty)
+		}
+
+		params := &models.ConsensusParams{
+			ActivationHeight: rule.ActivationHeight,
+			Difficulty:       rule.Difficulty,
+			Description:      rule.Description,
+		}
+		for _, name := range rule.TransactionTypes {
+			txType := models.TransactionType(name)
+			if !models.IsValidTransactionType(txType) {
+				return nil, fmt.Errorf("%w: unknown transaction type %q at height %d", ErrInvalidConsensusSchedule, name, rule.ActivationHeight)
+			}
+			params.TransactionTypes = append(params.TransactionTypes, txType)
+		}
+		schedule = append(schedule, params)
+	}
+
+	if !heights[0] {
+		return nil, fmt.Errorf("%w: no rules activate at height 0", ErrInvalidConsensusSchedule)
+	}
+	sort.Slice(schedule, func(i, j int) bool {
+		return schedule[i].ActivationHeight < schedule[j].ActivationHeight
+	})
+	return schedule, nil
+}
+
+// sameRulesUpTo checks that two schedules agree on every entry activating
+// at or below a height
+func sameRulesUpTo(a, b []*models.ConsensusParams, height int) bool {
+	a, b = activatedBy(a, height), activatedBy(b, height)
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// activatedBy returns the entries of a sorted schedule activating at or below a height
+func activatedBy(schedule []*models.ConsensusParams, height int) []*models.ConsensusParams {
+	i := sort.Search(len(schedule), func(i int) bool {
+		return schedule[i].ActivationHeight > height
+	})
+	return schedule[:i]
+}
+
+// meetsDifficulty checks if a block hash ends with enough zero hex digits
+func meetsDifficulty(hash string, difficulty int) bool {
+	return strings.HasSuffix(hash, strings.Repeat("0", difficulty))
+}
+
+// VerifyBlock checks a block against the consensus rules in force at its
+// height. The genesis block predates every rule and is always valid.
+func VerifyBlock(ctx context.Context, block *models.Block) error {
+	if block.Height == 0 {
+		return nil
+	}
+
+	params, err := models.GetConsensusParams(ctx, block.Height)
+	if err != nil {
+		return err
+	}
+
+	if !meetsDifficulty(block.ID, params.Difficulty) {
+		return fmt.Errorf("block %d: %w %d", block.Height, ErrDifficultyNotMet, params.Difficulty)
+	}
+	for _, tx := range block.Transactions {
+		if !params.AllowsTransactionType(tx.Type) {
+			return fmt.Errorf("block %d: %w: %s", block.Height, ErrTransactionTypeNotActive, tx.Type)
+		}
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/piko/piko/models"
@@ -32,17 +33,36 @@ func calculateTransactionHash(txType models.TransactionType, dataID string, bloc
 
 // calculateMerkleRoot calculates the merkle root of transactions
 func calculateMerkleRoot(transactions []*MempoolTransaction) string {
-	if len(transactions) == 0 {
-		return "0000000000000000000000000000000000000000000000000000000000000000"
+	leaves := make([]string, len(transactions))
+	for i, tx := range transactions {
+		leaves[i] = transactionLeaf(tx.Type, tx.DataID, tx.Timestamp)
 	}
+	return merkleRoot(leaves)
+}
 
-	// Calculate hashes of transactions
-	hashes := make([]string, len(transactions))
+// storedMerkleRoot recalculates the merkle root of a stored block's transactions
+func storedMerkleRoot(transactions []*models.Transaction) string {
+	leaves := make([]string, len(transactions))
 	for i, tx := range transactions {
-		data := fmt.Sprintf("%s%s%d", tx.Type, tx.DataID, tx.Timestamp.UnixNano())
-		hash := sha256.Sum256([]byte(data))
-		hashes[i] = hex.EncodeToString(hash[:])
+		leaves[i] = transactionLeaf(tx.Type, tx.DataID, tx.Timestamp.Time)
+	}
+	return merkleRoot(leaves)
+}
+
+// transactionLeaf hashes a transaction for the merkle tree
+func transactionLeaf(txType models.TransactionType, dataID string, timestamp time.Time) string {
+	data := fmt.Sprintf("%s%s%d", txType, dataID, timestamp.UnixNano())
+	hash := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(hash[:])
+}
+
+// merkleRoot builds a merkle tree over leaf hashes. The leaves are sorted
+// first so the root doesn't depend on the order transactions are stored in.
+func merkleRoot(hashes []string) string {
+	if len(hashes) == 0 {
+		return "0000000000000000000000000000000000000000000000000000000000000000"
 	}
+	sort.Strings(hashes)
 
 	// Calculate merkle root
 	for len(hashes) > 1 {
@@ -63,12 +83,12 @@ func calculateMerkleRoot(transactions []*MempoolTransaction) string {
 }
 
 // calculateNonce calculates a nonce for a block (simplified proof of work)
-func calculateNonce(previousHash string, timestamp time.Time, merkleRoot string) int64 {
+func calculateNonce(previousHash string, timestamp time.Time, merkleRoot string, difficulty int) int64 {
 	var nonce int64
 	for {
 		hash := calculateBlockHash(previousHash, timestamp, merkleRoot, nonce)
-		// Simplified proof of work: hash must end with "00"
-		if hash[len(hash)-2:] == "00" {
+		// Simplified proof of work: hash must end with difficulty zeros
+		if meetsDifficulty(hash, difficulty) {
 			return nonce
 		}
 		nonce++
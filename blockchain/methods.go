@@ -1,18 +1,21 @@
 package blockchain
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
 	"time"
 
 	"github.com/piko/piko/models"
+	"github.com/piko/piko/plugins"
+	"github.com/piko/piko/types"
 )
 
 // Initialize initializes the blockchain
 func (bc *Blockchain) Initialize() error {
 	// Get the latest block from the database
-	latestBlock, err := models.GetLatestBlock()
+	latestBlock, err := models.GetLatestBlock(context.Background())
 	if err != nil {
 		if errors.Is(err, models.ErrBlockNotFound) {
 			// Create genesis block
@@ -39,16 +42,17 @@ func (bc *Blockchain) createGenesisBlock() error {
 	log.Println("Creating genesis block...")
 
 	// Create genesis block
+	timestamp := now()
 	genesisBlock := &models.Block{
-		ID:         calculateBlockHash(nil, time.Now(), "genesis", 0),
-		Timestamp:  time.Now(),
+		ID:         calculateBlockHash(nil, timestamp, "genesis", 0),
+		Timestamp:  types.NewTime(timestamp),
 		MerkleRoot: "genesis",
 		Nonce:      0,
 		Height:     0,
 	}
 
 	// Save genesis block to database
-	if err := models.CreateBlock(genesisBlock); err != nil {
+	if err := models.CreateBlock(context.Background(), genesisBlock); err != nil {
 		return err
 	}
 
@@ -80,15 +84,20 @@ func (bc *Blockchain) startBlockCreation() {
 // createBlock creates a new block
 func (bc *Blockchain) createBlock() error {
 	// Get transactions from mempool
-	transactions, err := bc.Mempool.GetTransactions()
+	pending, err := bc.Mempool.GetTransactions()
 	if err != nil {
 		return err
 	}
 
-	if len(transactions) == 0 {
+	if len(pending) == 0 {
 		return ErrEmptyMempool
 	}
 
+	start := time.Now()
+	defer func() {
+		blockCreationDuration.Observe(time.Since(start).Seconds())
+	}()
+
 	// Get latest block
 	bc.mu.RLock()
 	latestBlock := bc.LatestBlock
@@ -97,50 +106,76 @@ func (bc *Blockchain) createBlock() error {
 	// Calculate new block height
 	height := latestBlock.Height + 1
 
+	// Apply the consensus rules in force at this height. Transactions of
+	// types that aren't active yet wait in the mempool.
+	params, err := models.GetConsensusParams(context.Background(), height)
+	if err != nil {
+		return err
+	}
+	var transactions, deferred []*MempoolTransaction
+	for _, tx := range pending {
+		if params.AllowsTransactionType(tx.Type) {
+			transactions = append(transactions, tx)
+		} else {
+			deferred = append(deferred, tx)
+		}
+	}
+	if len(transactions) == 0 {
+		return ErrEmptyMempool
+	}
+
 	// Calculate merkle root
 	merkleRoot := calculateMerkleRoot(transactions)
 
 	// Create new block
-	timestamp := time.Now()
-	nonce := calculateNonce(latestBlock.ID, timestamp, merkleRoot)
+	timestamp := now()
+	nonce := calculateNonce(latestBlock.ID, timestamp, merkleRoot, params.Difficulty)
 	blockID := calculateBlockHash(latestBlock.ID, timestamp, merkleRoot, nonce)
 
 	// Create block
 	block := &models.Block{
 		ID:           blockID,
 		PreviousHash: &latestBlock.ID,
-		Timestamp:    timestamp,
+		Timestamp:    types.NewTime(timestamp),
 		MerkleRoot:   merkleRoot,
 		Nonce:        nonce,
 		Height:       height,
 	}
+	for _, tx := range transactions {
+		block.Transactions = append(block.Transactions, &models.Transaction{
+			Hash:      calculateTransactionHash(tx.Type, tx.DataID, blockID),
+			BlockID:   blockID,
+			Type:      tx.Type,
+			DataID:    tx.DataID,
+			Timestamp: types.NewTime(tx.Timestamp),
+		})
+	}
+
+	// Never store a block the chain verifier would reject
+	if err := VerifyBlock(context.Background(), block); err != nil {
+		return err
+	}
 
 	// Save block to database
-	if err := models.CreateBlock(block); err != nil {
+	if err := models.CreateBlock(context.Background(), block); err != nil {
 		return err
 	}
 
 	// Create transactions in database
-	for _, tx := range transactions {
-		transaction := &models.Transaction{
-			Hash:    calculateTransactionHash(tx.Type, tx.DataID, blockID),
-			BlockID: blockID,
-			Type:    tx.Type,
-			DataID:  tx.DataID,
-		}
-		if err := models.CreateTransaction(transaction); err != nil {
+	for _, transaction := range block.Transactions {
+		if err := models.CreateTransaction(context.Background(), transaction); err != nil {
 			log.Printf("Failed to create transaction: %v", err)
 			continue
 		}
 
 		// Update message or channel message with block ID
-		switch tx.Type {
+		switch transaction.Type {
 		case models.TransactionTypeMessage:
-			if err := models.UpdateMessageBlockID(tx.DataID, blockID); err != nil {
+			if err := models.UpdateMessageBlockID(context.Background(), transaction.DataID, blockID); err != nil {
 				log.Printf("Failed to update message block ID: %v", err)
 			}
 		case models.TransactionTypeChannelMessage:
-			if err := models.UpdateChannelMessageBlockID(tx.DataID, blockID); err != nil {
+			if err := models.UpdateChannelMessageBlockID(context.Background(), transaction.DataID, blockID); err != nil {
 				log.Printf("Failed to update channel message block ID: %v", err)
 			}
 		}
@@ -151,10 +186,17 @@ func (bc *Blockchain) createBlock() error {
 	bc.LatestBlock = block
 	bc.mu.Unlock()
 
-	// Clear mempool
+	// Clear mempool, keeping transactions whose type isn't active yet
 	bc.Mempool.Clear()
+	for _, tx := range deferred {
+		if err := bc.Mempool.AddTransaction(tx); err != nil {
+			log.Printf("Failed to requeue transaction: %v", err)
+		}
+	}
 
 	log.Printf("Block created: %s (height: %d, transactions: %d)", blockID, height, len(transactions))
+
+	plugins.AfterBlockCommit(context.Background(), block)
 	return nil
 }
 
@@ -163,7 +205,7 @@ func (bc *Blockchain) AddToMempool(txType models.TransactionType, dataID string)
 	return bc.Mempool.AddTransaction(&MempoolTransaction{
 		Type:      txType,
 		DataID:    dataID,
-		Timestamp: time.Now(),
+		Timestamp: now(),
 	})
 }
 
@@ -195,6 +237,7 @@ func (m *Mempool) AddTransaction(tx *MempoolTransaction) error {
 
 	// Add transaction
 	m.Transactions = append(m.Transactions, tx)
+	mempoolSize.Set(float64(len(m.Transactions)))
 	return nil
 }
 
@@ -203,4 +246,5 @@ func (m *Mempool) Clear() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.Transactions = make([]*MempoolTransaction, 0)
+	mempoolSize.Set(0)
 } 
\ No newline at end of file
@@ -0,0 +1,116 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/piko/piko/models"
+)
+
+var (
+	// ErrBlockMissing is returned when no block is stored at a height below the chain tip
+	ErrBlockMissing = errors.New("block missing")
+	// ErrDuplicateHeight is returned when two blocks are stored at the same height
+	ErrDuplicateHeight = errors.New("another block has the same height")
+	// ErrPreviousHashMismatch is returned when a block doesn't link to the block before it
+	ErrPreviousHashMismatch = errors.New("previous hash does not match the block before")
+	// ErrMerkleRootMismatch is returned when a block's merkle root doesn't match its transactions
+	ErrMerkleRootMismatch = errors.New("merkle root does not match the block's transactions")
+	// ErrBlockHashMismatch is returned when a block's ID isn't the hash of its contents
+	ErrBlockHashMismatch = errors.New("block hash does not match its contents")
+	// ErrInvalidNonce is returned when a block's nonce is negative
+	ErrInvalidNonce = errors.New("invalid nonce")
+)
+
+// validateBatchSize is how many blocks ValidateChain loads at a time
+const validateBatchSize = 500
+
+// ChainReport is the result of validating the stored chain
+type ChainReport struct {
+	Valid         bool `json:"valid"`
+	BlocksChecked int  `json:"blocks_checked"`
+	// InvalidHeight, InvalidBlockID and Reason describe the first block
+	// that failed validation
+	InvalidHeight  *int   `json:"invalid_height,omitempty"`
+	InvalidBlockID string `json:"invalid_block_id,omitempty"`
+	Reason         string `json:"reason,omitempty"`
+}
+
+// ValidateChain walks the stored chain from the genesis block, checking
+// that every block links to the one before it, that its merkle root and
+// hash can be recalculated from what's stored, and that it meets the
+// consensus rules for its height. It stops at the first invalid block.
+// Errors are only returned when the chain can't be read.
+func ValidateChain(ctx context.Context) (*ChainReport, error) {
+	report := &ChainReport{Valid: true}
+
+	var previous *models.Block
+	for {
+		blocks, err := models.GetBlocksFrom(ctx, report.BlocksChecked, validateBatchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, block := range blocks {
+			if err := validateLink(ctx, block, previous, report.BlocksChecked); err != nil {
+				height := min(block.Height, report.BlocksChecked)
+				report.Valid = false
+				report.InvalidHeight = &height
+				report.InvalidBlockID = block.ID
+				report.Reason = err.Error()
+				return report, nil
+			}
+			previous = block
+			report.BlocksChecked++
+		}
+
+		if len(blocks) < validateBatchSize {
+			return report, nil
+		}
+	}
+}
+
+// validateLink checks a block expected at a height against the block
+// before it. Verification errors are wrapped with the height.
+func validateLink(ctx context.Context, block, previous *models.Block, height int) error {
+	switch {
+	case block.Height < height:
+		return fmt.Errorf("block %d: %w", block.Height, ErrDuplicateHeight)
+	case block.Height > height:
+		return fmt.Errorf("block %d: %w", height, ErrBlockMissing)
+	}
+
+	if height == 0 {
+		return validateGenesis(block)
+	}
+
+	if block.PreviousHash == nil || *block.PreviousHash != previous.ID {
+		return fmt.Errorf("block %d: %w", height, ErrPreviousHashMismatch)
+	}
+	if block.MerkleRoot != storedMerkleRoot(block.Transactions) {
+		return fmt.Errorf("block %d: %w", height, ErrMerkleRootMismatch)
+	}
+	if block.Nonce < 0 {
+		return fmt.Errorf("block %d: %w %d", height, ErrInvalidNonce, block.Nonce)
+	}
+	if block.ID != calculateBlockHash(*block.PreviousHash, block.Timestamp.Time, block.MerkleRoot, block.Nonce) {
+		return fmt.Errorf("block %d: %w", height, ErrBlockHashMismatch)
+	}
+	return VerifyBlock(ctx, block)
+}
+
+// validateGenesis checks the block at height 0, which has no previous
+// block or transactions
+func validateGenesis(block *models.Block) error {
+	if block.PreviousHash != nil {
+		return fmt.Errorf("block 0: %w", ErrPreviousHashMismatch)
+	}
+	if block.MerkleRoot != "genesis" || len(block.Transactions) > 0 {
+		return fmt.Errorf("block 0: %w", ErrMerkleRootMismatch)
+	}
+	if block.ID != calculateBlockHash(nil, block.Timestamp.Time, block.MerkleRoot, block.Nonce) {
+		return fmt.Errorf("block 0: %w", ErrBlockHashMismatch)
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+// Command benchcheck compares go test -bench output with a stored baseline
+// and fails if a benchmark got slower or allocates more than the threshold
+// allows.
+//
+// Run it through make:
+//
+//	make bench           # run the benchmarks and check them
+//	make bench-baseline  # run the benchmarks and record a new baseline
+//
+// Benchmarks that ran but aren't in the baseline, and baseline entries that
+// didn't run (database benchmarks skip without PIKO_TEST_DSN), are listed
+// but never fail the check. When a benchmark ran more than once, with
+// -count, its fastest run is used.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Result is one benchmark's measurements
+type Result struct {
+	NsPerOp     float64 `json:"ns_per_op"`
+	BytesPerOp  float64 `json:"bytes_per_op"`
+	AllocsPerOp float64 `json:"allocs_per_op"`
+}
+
+// Baseline is the stored set of results, keyed by package and benchmark name
+type Baseline struct {
+	Benchmarks map[string]Result `json:"benchmarks"`
+}
+
+// benchLine matches a result line; the -N suffix is GOMAXPROCS
+var benchLine = regexp.MustCompile(`^(Benchmark\S+?)(?:-\d+)?\s+\d+\s+(.*)$`)
+
+func main() {
+	baselinePath := flag.String("baseline", "bench/baseline.json", "baseline results file")
+	update := flag.Bool("update", false, "write the input as the new baseline instead of checking it")
+	threshold := flag.Float64("threshold", 0.25, "allowed relative increase in ns/op and allocs/op")
+	flag.Parse()
+
+	current, err := parse(os.Stdin)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(current) == 0 {
+		log.Fatal("no benchmark results in input")
+	}
+
+	if *update {
+		if err := writeBaseline(*baselinePath, current); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Recorded %d benchmarks in %s\n", len(current), *baselinePath)
+		return
+	}
+
+	baseline, err := readBaseline(*baselinePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !check(os.Stdout, baseline, current, *threshold) {
+		os.Exit(1)
+	}
+}
+
+// parse reads go test -bench output. Result lines are keyed by the package
+// named in the pkg: line before them.
+func parse(r io.Reader) (map[string]Result, error) {
+	results := map[string]Result{}
+	pkg := ""
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if name, ok := strings.CutPrefix(line, "pkg: "); ok {
+			pkg = strings.TrimSpace(name)
+			continue
+		}
+
+		match := benchLine.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		result, ok := parseMeasurements(match[2])
+		if !ok {
+			continue
+		}
+
+		key := pkg + "." + match[1]
+		if previous, seen := results[key]; seen && previous.NsPerOp <= result.NsPerOp {
+			continue
+		}
+		results[key] = result
+	}
+	return results, scanner.Err()
+}
+
+// parseMeasurements reads the value/unit pairs after the iteration count
+func parseMeasurements(s string) (Result, bool) {
+	var result Result
+	hasTime := false
+	fields := strings.Fields(s)
+	for i := 0; i+1 < len(fields); i += 2 {
+		value, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return result, false
+		}
+		switch fields[i+1] {
+		case "ns/op":
+			result.NsPerOp = value
+			hasTime = true
+		case "B/op":
+			result.BytesPerOp = value
+		case "allocs/op":
+			result.AllocsPerOp = value
+		}
+	}
+	return result, hasTime
+}
+
+// check prints a comparison table and reports whether nothing regressed
+func check(w io.Writer, baseline *Baseline, current map[string]Result, threshold float64) bool {
+	names := map[string]bool{}
+	for name := range baseline.Benchmarks {
+		names[name] = true
+	}
+	for name := range current {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	ok := true
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "BENCHMARK\tNS/OP\tDELTA\tALLOCS/OP\tDELTA\tSTATUS")
+	for _, name := range sorted {
+		base, inBaseline := baseline.Benchmarks[name]
+		cur, ran := current[name]
+		switch {
+		case !ran:
+			fmt.Fprintf(tw, "%s\t%.0f\t\t%.0f\t\tnot run\n", name, base.NsPerOp, base.AllocsPerOp)
+		case !inBaseline:
+			fmt.Fprintf(tw, "%s\t%.0f\t\t%.0f\t\tnew\n", name, cur.NsPerOp, cur.AllocsPerOp)
+		default:
+			timeDelta := delta(base.NsPerOp, cur.NsPerOp)
+			allocDelta := delta(base.AllocsPerOp, cur.AllocsPerOp)
+			status := "ok"
+			if timeDelta > threshold || allocDelta > threshold {
+				status = "REGRESSED"
+				ok = false
+			}
+			fmt.Fprintf(tw, "%s\t%.0f\t%+.1f%%\t%.0f\t%+.1f%%\t%s\n",
+				name, cur.NsPerOp, timeDelta*100, cur.AllocsPerOp, allocDelta*100, status)
+		}
+	}
+	tw.Flush()
+
+	if !ok {
+		fmt.Fprintf(w, "\nSome benchmarks regressed by more than %.0f%%\n", threshold*100)
+	}
+	return ok
+}
+
+// delta is the relative change from base to cur
+func delta(base, cur float64) float64 {
+	if base == 0 {
+		if cur == 0 {
+			return 0
+		}
+		return 1
+	}
+	return (cur - base) / base
+}
+
+func readBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline, record one with make bench-baseline: %w", err)
+	}
+	baseline := &Baseline{}
+	if err := json.Unmarshal(data, baseline); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline %s: %w", path, err)
+	}
+	return baseline, nil
+}
+
+func writeBaseline(path string, results map[string]Result) error {
+	data, err := json.MarshalIndent(Baseline{Benchmarks: results}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,396 @@
+// Command loadgen sends synthetic traffic to a Piko server and reports the
+// latency of each kind of operation, to check capacity before a launch.
+//
+// The target must reserve a phone prefix for load testing, see
+// auth.testPhonePrefix and auth.testPhoneCode, and should have its rate
+// limits raised or disabled. Then:
+//
+//	go run ./cmd/loadgen -target http://localhost:8080 -otp 123456 -duration 5m
+//
+// It signs up -users accounts, puts them in -groups groups, then until
+// -duration has passed sends direct messages, group messages, new
+// registrations and WebSocket connections at the configured rates per
+// second. Operations that would exceed -concurrency in flight are dropped
+// and counted rather than queued, so a slow server shows up as drops
+// instead of as a slower send rate.
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	mathrand "math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	fastws "github.com/fasthttp/websocket"
+	"github.com/piko/piko/sdk/pikosdk"
+)
+
+// options are the command's flags
+type options struct {
+	target       string
+	phonePrefix  string
+	otp          string
+	users        int
+	groups       int
+	duration     time.Duration
+	dmRate       float64
+	groupRate    float64
+	registerRate float64
+	wsRate       float64
+	wsHold       time.Duration
+	messageSize  int
+	concurrency  int
+	report       time.Duration
+}
+
+// user is a signed in load test account
+type user struct {
+	address string
+	client  *pikosdk.Client
+}
+
+// loadgen holds the state of one run
+type loadgen struct {
+	opts     options
+	stats    *Stats
+	http     *http.Client
+	policies []int
+	users    []*user
+	groups   []string
+
+	// runID keeps phone numbers unique across runs against the same server
+	runID     int64
+	mu        sync.Mutex
+	nextPhone int
+}
+
+func main() {
+	opts := options{}
+	flag.StringVar(&opts.target, "target", "http://localhost:8080", "server base URL")
+	flag.StringVar(&opts.phonePrefix, "phone-prefix", "+1555", "the server's auth.testPhonePrefix")
+	flag.StringVar(&opts.otp, "otp", "", "the server's auth.testPhoneCode")
+	flag.IntVar(&opts.users, "users", 50, "accounts to sign up before sending traffic")
+	flag.IntVar(&opts.groups, "groups", 5, "groups to create among the accounts")
+	flag.DurationVar(&opts.duration, "duration", time.Minute, "how long to send traffic")
+	flag.Float64Var(&opts.dmRate, "dm-rate", 20, "direct messages per second")
+	flag.Float64Var(&opts.groupRate, "group-rate", 10, "group messages per second")
+	flag.Float64Var(&opts.registerRate, "register-rate", 1, "new registrations per second")
+	flag.Float64Var(&opts.wsRate, "ws-rate", 5, "WebSocket connections per second")
+	flag.DurationVar(&opts.wsHold, "ws-hold", 10*time.Second, "how long each WebSocket connection stays open")
+	flag.IntVar(&opts.messageSize, "message-size", 256, "message payload size in bytes")
+	flag.IntVar(&opts.concurrency, "concurrency", 200, "maximum operations in flight")
+	flag.DurationVar(&opts.report, "report", 10*time.Second, "progress report interval, 0 to disable")
+	flag.Parse()
+
+	if opts.otp == "" {
+		log.Fatal("-otp is required: set it to the target's auth.testPhoneCode")
+	}
+	if opts.users < 2 {
+		log.Fatal("-users must be at least 2")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	lg := &loadgen{
+		opts:  opts,
+		stats: NewStats(),
+		http: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &http.Transport{MaxIdleConnsPerHost: opts.concurrency},
+		},
+		runID: time.Now().Unix() % 10000,
+	}
+	if err := lg.setup(ctx); err != nil {
+		log.Fatal(err)
+	}
+	lg.run(ctx)
+
+	fmt.Println()
+	lg.stats.Report(os.Stdout)
+}
+
+// setup signs up the accounts and creates the groups
+func (lg *loadgen) setup(ctx context.Context) error {
+	policies, err := pikosdk.NewClient(lg.opts.target).GetPolicies(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get policies: %w", err)
+	}
+	for _, policy := range policies {
+		lg.policies = append(lg.policies, policy.ID)
+	}
+
+	log.Printf("Signing up %d accounts", lg.opts.users)
+	for len(lg.users) < lg.opts.users {
+		u, err := lg.signUp(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to sign up account %d: %w", len(lg.users)+1, err)
+		}
+		lg.users = append(lg.users, u)
+	}
+
+	log.Printf("Creating %d groups", lg.opts.groups)
+	for i := 0; i < lg.opts.groups; i++ {
+		groupID, err := lg.createGroup(ctx, i)
+		if err != nil {
+			log.Printf("Failed to create group, skipping group messages: %v", err)
+			lg.groups = nil
+			break
+		}
+		lg.groups = append(lg.groups, groupID)
+	}
+	return nil
+}
+
+// signUp registers a new test phone and signs it in, timing both steps
+// together as one register operation
+func (lg *loadgen) signUp(ctx context.Context) (*user, error) {
+	client := lg.newClient()
+	phone := lg.newPhone()
+
+	var auth map[string]interface{}
+	err := lg.stats.Time("register", func() error {
+		_, err := client.Register(ctx, &pikosdk.RegisterRequest{Phone: phone})
+		var apiErr *pikosdk.Error
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict {
+			// Left over from an earlier run, sign in instead
+			_, err = client.Login(ctx, &pikosdk.LoginRequest{Phone: phone})
+		}
+		if err != nil {
+			return ignoreCancel(ctx, err)
+		}
+
+		auth, err = client.VerifyRegister(ctx, &pikosdk.VerifyOTPRequest{
+			Phone:            phone,
+			Code:             lg.opts.otp,
+			AcceptedPolicies: lg.policies,
+			Birthdate:        "1990-01-01",
+		})
+		return ignoreCancel(ctx, err)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	token, _ := auth["token"].(string)
+	address, _ := auth["address"].(string)
+	if token == "" || address == "" {
+		return nil, errors.New("verify-register response has no token or address")
+	}
+	client.Token = token
+	return &user{address: address, client: client}, nil
+}
+
+// createGroup creates a group owned by one account with a few others as
+// members
+func (lg *loadgen) createGroup(ctx context.Context, n int) (string, error) {
+	owner := lg.users[n%len(lg.users)]
+	out, err := owner.client.CreateGroup(ctx, &pikosdk.CreateGroupRequest{
+		Name:        fmt.Sprintf("Load test %d", n),
+		Description: "Created by loadgen",
+	})
+	if err != nil {
+		return "", err
+	}
+	groupID, _ := out["id"].(string)
+	if groupID == "" {
+		return "", errors.New("create group response has no id")
+	}
+
+	members := len(lg.users) / lg.opts.groups
+	if members < 2 {
+		members = 2
+	}
+	for i := 1; i < members; i++ {
+		member := lg.users[(n+i*lg.opts.groups)%len(lg.users)]
+		if member == owner {
+			continue
+		}
+		_, err := owner.client.AddGroupMember(ctx, groupID, &pikosdk.AddGroupMemberRequest{UserAddress: member.address})
+		if err != nil {
+			return "", err
+		}
+	}
+	return groupID, nil
+}
+
+// run sends traffic until the duration passes or ctx is cancelled
+func (lg *loadgen) run(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, lg.opts.duration)
+	defer cancel()
+
+	log.Printf("Sending traffic for %s", lg.opts.duration)
+	sem := make(chan struct{}, lg.opts.concurrency)
+	var wg sync.WaitGroup
+
+	spawn := func(rate float64, op func(context.Context)) {
+		if rate <= 0 {
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ticker := time.NewTicker(time.Duration(float64(time.Second) / rate))
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+				select {
+				case sem <- struct{}{}:
+				default:
+					lg.stats.Drop()
+					continue
+				}
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					defer func() { <-sem }()
+					op(ctx)
+				}()
+			}
+		}()
+	}
+
+	spawn(lg.opts.dmRate, lg.sendDirectMessage)
+	if len(lg.groups) > 0 {
+		spawn(lg.opts.groupRate, lg.sendGroupMessage)
+	}
+	spawn(lg.opts.registerRate, func(ctx context.Context) { lg.signUp(ctx) })
+	spawn(lg.opts.wsRate, lg.connectWebSocket)
+
+	if lg.opts.report > 0 {
+		go func() {
+			ticker := time.NewTicker(lg.opts.report)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					lg.stats.Progress(os.Stdout)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+// sendDirectMessage sends a message between two random accounts
+func (lg *loadgen) sendDirectMessage(ctx context.Context) {
+	sender := lg.users[mathrand.Intn(len(lg.users))]
+	recipient := lg.users[mathrand.Intn(len(lg.users))]
+	for recipient == sender {
+		recipient = lg.users[mathrand.Intn(len(lg.users))]
+	}
+
+	lg.stats.Time("dm", func() error {
+		_, err := sender.client.SendMessage(ctx, &pikosdk.SendMessageRequest{
+			RecipientAddress: recipient.address,
+			EncryptedContent: lg.payload(),
+		})
+		return ignoreCancel(ctx, err)
+	})
+}
+
+// sendGroupMessage sends a message to a random group from its owner
+func (lg *loadgen) sendGroupMessage(ctx context.Context) {
+	n := mathrand.Intn(len(lg.groups))
+	owner := lg.users[n%len(lg.users)]
+
+	lg.stats.Time("group_message", func() error {
+		_, err := owner.client.SendGroupMessage(ctx, lg.groups[n], &pikosdk.SendGroupMessageRequest{
+			Content: lg.payload(),
+		})
+		return ignoreCancel(ctx, err)
+	})
+}
+
+// connectWebSocket connects as a random account, times the connection up
+// to the welcome message, then holds it open counting events
+func (lg *loadgen) connectWebSocket(ctx context.Context) {
+	u := lg.users[mathrand.Intn(len(lg.users))]
+	wsURL := u.client.WebSocketURL(url.Values{
+		"address": {u.address},
+		"token":   {u.client.Token},
+	})
+
+	var conn *fastws.Conn
+	err := lg.stats.Time("ws_connect", func() error {
+		var err error
+		conn, _, err = fastws.DefaultDialer.DialContext(ctx, wsURL, nil)
+		if err != nil {
+			return ignoreCancel(ctx, err)
+		}
+		// The server sends a welcome message once the client is registered
+		conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+		_, _, err = conn.ReadMessage()
+		return err
+	})
+	if conn == nil {
+		return
+	}
+	defer conn.Close()
+	if err != nil {
+		return
+	}
+
+	hold, cancel := context.WithTimeout(ctx, lg.opts.wsHold)
+	defer cancel()
+	go func() {
+		<-hold.Done()
+		conn.Close()
+	}()
+
+	conn.SetReadDeadline(time.Time{})
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+		lg.stats.WebSocketEvent()
+	}
+}
+
+// newClient creates an API client sharing the run's connection pool
+func (lg *loadgen) newClient() *pikosdk.Client {
+	client := pikosdk.NewClient(lg.opts.target)
+	client.HTTPClient = lg.http
+	return client
+}
+
+// newPhone returns a test phone number not used before in this run
+func (lg *loadgen) newPhone() string {
+	lg.mu.Lock()
+	defer lg.mu.Unlock()
+	lg.nextPhone++
+	return fmt.Sprintf("%s%04d%06d", lg.opts.phonePrefix, lg.runID, lg.nextPhone)
+}
+
+// payload returns random base64 message content of the configured size
+func (lg *loadgen) payload() string {
+	content := make([]byte, lg.opts.messageSize)
+	rand.Read(content)
+	return base64.StdEncoding.EncodeToString(content)
+}
+
+// ignoreCancel turns errors caused by the run ending mid-request into
+// errSkipped, so they aren't counted as failures
+func ignoreCancel(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() != nil {
+		return errSkipped
+	}
+	return err
+}
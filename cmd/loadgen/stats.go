@@ -0,0 +1,150 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// errSkipped is recorded for operations cut short by the end of the run;
+// they count neither as successes nor as errors
+var errSkipped = errors.New("skipped")
+
+// Stats collects the latency and outcome of every operation, by operation name
+type Stats struct {
+	mu        sync.Mutex
+	ops       map[string]*opStats
+	dropped   int
+	wsEvents  int
+	startedAt time.Time
+}
+
+// opStats are the results of one kind of operation
+type opStats struct {
+	latencies []time.Duration
+	errors    int
+	lastError string
+}
+
+// NewStats creates an empty set of results
+func NewStats() *Stats {
+	return &Stats{ops: map[string]*opStats{}, startedAt: time.Now()}
+}
+
+// op returns the results of an operation, creating them on first use.
+// Callers must hold s.mu.
+func (s *Stats) op(name string) *opStats {
+	op, ok := s.ops[name]
+	if !ok {
+		op = &opStats{}
+		s.ops[name] = op
+	}
+	return op
+}
+
+// Record adds the outcome of one operation. Only successful operations
+// count toward latency percentiles.
+func (s *Stats) Record(name string, latency time.Duration, err error) {
+	if errors.Is(err, errSkipped) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	op := s.op(name)
+	if err != nil {
+		op.errors++
+		op.lastError = err.Error()
+		return
+	}
+	op.latencies = append(op.latencies, latency)
+}
+
+// Time runs fn and records how long it took
+func (s *Stats) Time(name string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	s.Record(name, time.Since(start), err)
+	return err
+}
+
+// Drop counts an operation skipped because too many were already in flight
+func (s *Stats) Drop() {
+	s.mu.Lock()
+	s.dropped++
+	s.mu.Unlock()
+}
+
+// WebSocketEvent counts an event received over a WebSocket
+func (s *Stats) WebSocketEvent() {
+	s.mu.Lock()
+	s.wsEvents++
+	s.mu.Unlock()
+}
+
+// Progress writes a one-line summary of the run so far
+func (s *Stats) Progress(w io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	total, errors := 0, 0
+	for _, op := range s.ops {
+		total += len(op.latencies) + op.errors
+		errors += op.errors
+	}
+	fmt.Fprintf(w, "[%s] %d ops, %d errors, %d dropped, %d websocket events\n",
+		time.Since(s.startedAt).Truncate(time.Second), total, errors, s.dropped, s.wsEvents)
+}
+
+// Report writes the latency percentiles and error counts of every operation
+func (s *Stats) Report(w io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elapsed := time.Since(s.startedAt)
+	names := make([]string, 0, len(s.ops))
+	for name := range s.ops {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "OPERATION\tOK\tERRORS\tRATE/S\tP50\tP90\tP99\tMAX")
+	for _, name := range names {
+		op := s.ops[name]
+		sorted := append([]time.Duration(nil), op.latencies...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f\t%s\t%s\t%s\t%s\n",
+			name, len(sorted), op.errors, float64(len(sorted))/elapsed.Seconds(),
+			percentile(sorted, 0.50), percentile(sorted, 0.90), percentile(sorted, 0.99), percentile(sorted, 1))
+	}
+	tw.Flush()
+
+	fmt.Fprintf(w, "\n%d operations dropped, %d websocket events received in %s\n",
+		s.dropped, s.wsEvents, elapsed.Truncate(time.Second))
+	for _, name := range names {
+		if op := s.ops[name]; op.lastError != "" {
+			fmt.Fprintf(w, "last %s error: %s\n", name, op.lastError)
+		}
+	}
+}
+
+// percentile returns the p-th quantile of sorted latencies, rounded for display
+func percentile(sorted []time.Duration, p float64) string {
+	if len(sorted) == 0 {
+		return "-"
+	}
+	i := int(p*float64(len(sorted))+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return sorted[i].Round(10 * time.Microsecond).String()
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"go/format"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/piko/piko/api"
+)
+
+// goGenerator writes the Go client, tracking which imports it needs
+type goGenerator struct {
+	types   *typeSet
+	body    strings.Builder
+	imports map[string]bool
+}
+
+// generateGo produces the generated half of the pikosdk package
+func generateGo(endpoints []api.Endpoint, events []api.WebSocketEvent, types *typeSet) ([]byte, error) {
+	g := &goGenerator{types: types, imports: map[string]bool{}}
+
+	g.printf("// WebSocket event types\nconst (\n")
+	for _, event := range events {
+		g.printf("\t// Event%s: %s\n", pascalCase(event.Type), event.Description)
+		g.printf("\tEvent%s = %q\n", pascalCase(event.Type), event.Type)
+	}
+	g.printf(")\n\n")
+
+	for _, st := range types.sorted() {
+		g.writeStruct(st)
+	}
+	for _, endpoint := range endpoints {
+		g.writeMethod(endpoint)
+	}
+
+	var imports []string
+	for path := range g.imports {
+		imports = append(imports, path)
+	}
+	sort.Strings(imports)
+
+	var file strings.Builder
+	file.WriteString("// Code generated by sdk-gen. DO NOT EDIT.\n\npackage pikosdk\n\n")
+	if len(imports) > 0 {
+		file.WriteString("import (\n")
+		for _, path := range imports {
+			fmt.Fprintf(&file, "\t%q\n", path)
+		}
+		file.WriteString(")\n\n")
+	}
+	file.WriteString(g.body.String())
+
+	source, err := format.Source([]byte(file.String()))
+	if err != nil {
+		return nil, fmt.Errorf("generated Go client doesn't compile: %w", err)
+	}
+	return source, nil
+}
+
+func (g *goGenerator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.body, format, args...)
+}
+
+// writeStruct writes a struct with the same JSON encoding as the server's
+func (g *goGenerator) writeStruct(st *structType) {
+	g.printf("// %s is the %s object of the Piko API\n", st.Name, st.Name)
+	g.printf("type %s struct {\n", st.Name)
+	for _, f := range st.Fields {
+		tag := f.JSONName
+		if f.OmitEmpty {
+			tag += ",omitempty"
+		}
+		g.printf("\t%s %s `json:%q`\n", f.Name, g.goType(f.Type), tag)
+	}
+	g.printf("}\n\n")
+}
+
+// goType returns the Go type expression for t in the generated package
+func (g *goGenerator) goType(t reflect.Type) string {
+	switch {
+	case t == timeType || t == apiTimeType:
+		g.imports["time"] = true
+		return "time.Time"
+	case t == bytesType:
+		return "[]byte"
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return "*" + g.goType(t.Elem())
+	case reflect.Slice, reflect.Array:
+		return "[]" + g.goType(t.Elem())
+	case reflect.Map:
+		return "map[string]" + g.goType(t.Elem())
+	case reflect.Interface:
+		return "interface{}"
+	case reflect.Struct:
+		return g.types.nameOf(t)
+	default:
+		// Named scalars such as models.PrivacyType become their underlying kind
+		return t.Kind().String()
+	}
+}
+
+// writeMethod writes the client method for an endpoint
+func (g *goGenerator) writeMethod(endpoint api.Endpoint) {
+	params := pathParams(endpoint.Path)
+
+	var args []string
+	if endpoint.Kind != api.KindWebSocket {
+		g.imports["context"] = true
+		args = append(args, "ctx context.Context")
+	}
+	for _, param := range params {
+		args = append(args, camelCase(param)+" string")
+	}
+	if endpoint.Request != nil {
+		args = append(args, "req *"+g.goType(endpoint.Request))
+	}
+	if endpoint.Kind == api.KindUpload {
+		g.imports["io"] = true
+		args = append(args, "contentType string", "body io.Reader")
+	}
+	query := "nil"
+	if endpoint.Query {
+		g.imports["net/url"] = true
+		args = append(args, "query url.Values")
+		query = "query"
+	}
+
+	path := g.pathExpr(endpoint.Path)
+	name := endpoint.Name
+	auth := ""
+	if endpoint.Auth {
+		auth = " It requires a token."
+	}
+
+	switch endpoint.Kind {
+	case api.KindWebSocket:
+		g.printf("// %sURL returns the URL of the %s WebSocket.%s\n", name, endpoint.Path, auth)
+		g.printf("func (c *Client) %sURL(%s) string {\n", name, strings.Join(args, ", "))
+		g.printf("\treturn c.webSocketURL(%s, %s)\n}\n\n", path, query)
+		return
+	case api.KindDownload:
+		g.imports["net/http"] = true
+		g.printf("// %s calls %s %s and returns the raw response. The caller must close its body.%s\n", name, endpoint.Method, endpoint.Path, auth)
+		g.printf("func (c *Client) %s(%s) (*http.Response, error) {\n", name, strings.Join(args, ", "))
+		g.printf("\treturn c.download(ctx, %q, %s, %s)\n}\n\n", endpoint.Method, path, query)
+		return
+	}
+
+	result, declare, ret := g.result(endpoint.Response)
+	g.printf("// %s calls %s %s.%s\n", name, endpoint.Method, endpoint.Path, auth)
+	g.printf("func (c *Client) %s(%s) (%s, error) {\n", name, strings.Join(args, ", "), result)
+	g.printf("\t%s\n", declare)
+	if endpoint.Kind == api.KindUpload {
+		g.printf("\tif err := c.upload(ctx, %q, %s, %s, contentType, body, &out); err != nil {\n", endpoint.Method, path, query)
+	} else {
+		body := "nil"
+		if endpoint.Request != nil {
+			body = "req"
+		}
+		g.printf("\tif err := c.do(ctx, %q, %s, %s, %s, &out); err != nil {\n", endpoint.Method, path, query, body)
+	}
+	g.printf("\t\treturn nil, err\n\t}\n\treturn %s, nil\n}\n\n", ret)
+}
+
+// result returns the result type of a method, the declaration of the value
+// decoded into and the expression returned
+func (g *goGenerator) result(t reflect.Type) (string, string, string) {
+	if t == nil {
+		return "map[string]interface{}", "var out map[string]interface{}", "out"
+	}
+	if t.Kind() == reflect.Struct {
+		name := g.goType(t)
+		return "*" + name, "var out " + name, "&out"
+	}
+	typ := g.goType(t)
+	return typ, "var out " + typ, "out"
+}
+
+// pathExpr returns a Go expression building a route path, escaping params
+func (g *goGenerator) pathExpr(path string) string {
+	params := pathParams(path)
+	if len(params) == 0 {
+		return fmt.Sprintf("%q", path)
+	}
+
+	g.imports["net/url"] = true
+	var parts []string
+	literal := ""
+	for _, segment := range strings.SplitAfter(path, "/") {
+		if strings.HasPrefix(segment, ":") {
+			param := strings.TrimSuffix(segment[1:], "/")
+			parts = append(parts, fmt.Sprintf("%q", literal), "url.PathEscape("+camelCase(param)+")")
+			literal = strings.TrimPrefix(segment, ":"+param)
+			continue
+		}
+		literal += segment
+	}
+	if literal != "" {
+		parts = append(parts, fmt.Sprintf("%q", literal))
+	}
+	return strings.Join(parts, " + ")
+}
@@ -0,0 +1,125 @@
+// Command sdk-gen generates typed Go and TypeScript API clients from the
+// endpoint descriptions in the api package.
+//
+// Run it through go generate:
+//
+//	go generate ./api
+//
+// With -check it writes nothing and exits non-zero if the generated clients
+// are out of date, a registered route is missing from api.Endpoints or the
+// OpenAPI document served at /api/openapi.json can't be built.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/api"
+	"github.com/piko/piko/config"
+	"github.com/piko/piko/store"
+)
+
+// generatedFile is a generated file's path relative to the output directory
+type generatedFile struct {
+	path    string
+	content []byte
+}
+
+func main() {
+	out := flag.String("out", "sdk", "output directory")
+	check := flag.Bool("check", false, "verify the generated clients are up to date instead of writing them")
+	flag.Parse()
+
+	if err := checkRoutes(); err != nil {
+		log.Fatal(err)
+	}
+	if _, err := api.OpenAPI(); err != nil {
+		log.Fatalf("OpenAPI document: %v", err)
+	}
+
+	types, err := collectTypes(api.Endpoints)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	goSource, err := generateGo(api.Endpoints, api.WebSocketEvents, types)
+	if err != nil {
+		log.Fatal(err)
+	}
+	files := []generatedFile{
+		{path: filepath.Join("pikosdk", "api_gen.go"), content: goSource},
+		{path: filepath.Join("typescript", "piko.ts"), content: generateTypeScript(api.Endpoints, api.WebSocketEvents, types)},
+	}
+
+	stale := false
+	for _, file := range files {
+		path := filepath.Join(*out, file.path)
+		if *check {
+			existing, err := os.ReadFile(path)
+			if err != nil || !bytes.Equal(existing, file.content) {
+				fmt.Fprintf(os.Stderr, "%s is out of date, run go generate ./api\n", path)
+				stale = true
+			}
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			log.Fatal(err)
+		}
+		if err := os.WriteFile(path, file.content, 0644); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if stale {
+		os.Exit(1)
+	}
+}
+
+// checkRoutes makes sure api.Endpoints describes exactly the routes the
+// server registers, so the clients can't silently fall behind
+func checkRoutes() error {
+	app := fiber.New()
+	api.NewServer(config.DefaultConfig(), store.Memory(nil, nil)).RegisterRoutes(app)
+
+	registered := map[string]bool{}
+	for _, route := range app.GetRoutes(true) {
+		if route.Method == fiber.MethodHead {
+			continue
+		}
+		registered[route.Method+" "+route.Path] = true
+	}
+
+	described := map[string]bool{}
+	for _, endpoint := range api.Endpoints {
+		key := endpoint.Method + " " + endpoint.Path
+		if described[key] {
+			return fmt.Errorf("%s is described twice in api.Endpoints", key)
+		}
+		described[key] = true
+	}
+
+	var missing, unknown []string
+	for key := range registered {
+		if !described[key] {
+			missing = append(missing, key)
+		}
+	}
+	for key := range described {
+		if !registered[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(unknown)
+
+	if len(missing) > 0 || len(unknown) > 0 {
+		return fmt.Errorf("api.Endpoints is out of sync with RegisterRoutes: missing %v, not registered %v", missing, unknown)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/piko/piko/api"
+	"github.com/piko/piko/types"
+)
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	apiTimeType = reflect.TypeOf(types.Time{})
+	bytesType   = reflect.TypeOf([]byte(nil))
+)
+
+// structType is a named struct emitted into the generated clients
+type structType struct {
+	Name   string
+	Type   reflect.Type
+	Fields []api.Field
+}
+
+// typeSet holds every struct reachable from the endpoints, by generated name
+type typeSet struct {
+	byName map[string]*structType
+	names  map[reflect.Type]string
+}
+
+// sorted returns the structs ordered by name
+func (s *typeSet) sorted() []*structType {
+	structs := make([]*structType, 0, len(s.byName))
+	for _, st := range s.byName {
+		structs = append(structs, st)
+	}
+	sort.Slice(structs, func(i, j int) bool { return structs[i].Name < structs[j].Name })
+	return structs
+}
+
+// collectTypes walks the request and response types of every endpoint plus
+// the WebSocket message envelope
+func collectTypes(endpoints []api.Endpoint) (*typeSet, error) {
+	set := &typeSet{
+		byName: map[string]*structType{},
+		names:  map[reflect.Type]string{api.WebSocketMessage: "Event"},
+	}
+
+	roots := []reflect.Type{api.WebSocketMessage}
+	for _, endpoint := range endpoints {
+		if endpoint.Request != nil {
+			roots = append(roots, endpoint.Request)
+		}
+		if endpoint.Response != nil {
+			roots = append(roots, endpoint.Response)
+		}
+	}
+
+	for _, root := range roots {
+		if err := set.add(root); err != nil {
+			return nil, err
+		}
+	}
+	return set, nil
+}
+
+// add registers t and every struct it references
+func (s *typeSet) add(t reflect.Type) error {
+	switch {
+	case t == timeType || t == apiTimeType || t == bytesType:
+		return nil
+	case t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		return s.add(t.Elem())
+	case t.Kind() == reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return fmt.Errorf("map key of %s must be a string", t)
+		}
+		return s.add(t.Elem())
+	case t.Kind() != reflect.Struct:
+		return nil
+	}
+
+	name := s.nameOf(t)
+	if name == "" {
+		return fmt.Errorf("anonymous struct %s can't be generated, give it a name", t)
+	}
+	if existing, ok := s.byName[name]; ok {
+		if existing.Type != t {
+			return fmt.Errorf("%s and %s would both be generated as %s", existing.Type, t, name)
+		}
+		return nil
+	}
+
+	st := &structType{Name: name, Type: t}
+	s.byName[name] = st
+	s.names[t] = name
+
+	st.Fields = api.JSONFields(t)
+	for _, f := range st.Fields {
+		if err := s.add(f.Type); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nameOf returns the generated name of a struct type
+func (s *typeSet) nameOf(t reflect.Type) string {
+	if name, ok := s.names[t]; ok {
+		return name
+	}
+	return t.Name()
+}
+
+// pathParams returns the names of the :params in a route path, in order
+func pathParams(path string) []string {
+	var params []string
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, ":") {
+			params = append(params, segment[1:])
+		}
+	}
+	return params
+}
+
+// camelCase converts a snake_case name to lowerCamelCase, treating "id" as
+// an initialism after the first word
+func camelCase(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool { return r == '_' || r == '-' })
+	for i, word := range words {
+		if i == 0 {
+			continue
+		}
+		if word == "id" {
+			words[i] = "ID"
+			continue
+		}
+		words[i] = strings.ToUpper(word[:1]) + word[1:]
+	}
+	return strings.Join(words, "")
+}
+
+// pascalCase converts a snake_case name to UpperCamelCase
+func pascalCase(name string) string {
+	camel := camelCase(name)
+	if camel == "id" {
+		return "ID"
+	}
+	return strings.ToUpper(camel[:1]) + camel[1:]
+}
+
+// lowerFirst lower-cases the first letter of a Go identifier
+func lowerFirst(name string) string {
+	return strings.ToLower(name[:1]) + name[1:]
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/piko/piko/api"
+)
+
+// tsRuntime is the hand-written part of the TypeScript client
+const tsRuntime = `/** Error thrown when the API responds with an error status */
+export class PikoError extends Error {
+  /** What went wrong, e.g. "OTP_EXPIRED". Check it rather than the message, which is translated. */
+  constructor(public readonly status: number, message: string, public readonly code?: string) {
+    super(message);
+    this.name = "PikoError";
+  }
+}
+
+/** Query string parameters */
+export type Query = Record<string, string>;
+
+/** Client for the Piko API */
+export class PikoClient {
+  /** JWT sent with every request. Set it after logging in. */
+  token?: string;
+
+  constructor(public readonly baseURL: string, private readonly fetcher: typeof fetch = fetch) {
+    this.baseURL = baseURL.replace(/\/$/, "");
+  }
+
+  private url(path: string, query?: Query): string {
+    const search = query ? new URLSearchParams(query).toString() : "";
+    return this.baseURL + path + (search ? "?" + search : "");
+  }
+
+  private async send(method: string, path: string, query?: Query, body?: BodyInit, contentType?: string): Promise<Response> {
+    const headers: Record<string, string> = {};
+    if (contentType) {
+      headers["Content-Type"] = contentType;
+    }
+    if (this.token) {
+      headers["Authorization"] = "Bearer " + this.token;
+    }
+
+    const response = await this.fetcher(this.url(path, query), { method, headers, body });
+    if (!response.ok) {
+      let message = response.statusText;
+      let code: string | undefined;
+      try {
+        const error = await response.json();
+        if (error && typeof error.error === "string") {
+          message = error.error;
+        }
+        if (error && typeof error.code === "string") {
+          code = error.code;
+        }
+      } catch {
+        // Not a JSON error body
+      }
+      throw new PikoError(response.status, message, code);
+    }
+    return response;
+  }
+
+  private async request<T>(method: string, path: string, query?: Query, body?: unknown): Promise<T> {
+    const response = body === undefined
+      ? await this.send(method, path, query)
+      : await this.send(method, path, query, JSON.stringify(body), "application/json");
+    return (await response.json()).data as T;
+  }
+
+  private async upload<T>(method: string, path: string, body: BodyInit, contentType?: string, query?: Query): Promise<T> {
+    const response = await this.send(method, path, query, body, contentType);
+    return (await response.json()).data as T;
+  }
+
+  private wsURL(path: string, query?: Query): string {
+    return this.url(path, query).replace(/^http/, "ws");
+  }
+`
+
+// generateTypeScript produces the TypeScript client
+func generateTypeScript(endpoints []api.Endpoint, events []api.WebSocketEvent, types *typeSet) []byte {
+	var b strings.Builder
+	b.WriteString("// Code generated by sdk-gen. DO NOT EDIT.\n\n")
+
+	b.WriteString("/** WebSocket event types */\nexport const Events = {\n")
+	for _, event := range events {
+		fmt.Fprintf(&b, "  /** %s */\n  %s: %q,\n", event.Description, pascalCase(event.Type), event.Type)
+	}
+	b.WriteString("} as const;\n\nexport type EventType = (typeof Events)[keyof typeof Events];\n\n")
+
+	for _, st := range types.sorted() {
+		fmt.Fprintf(&b, "export interface %s {\n", st.Name)
+		for _, f := range st.Fields {
+			typ := tsType(types, f.Type)
+			optional := ""
+			switch {
+			case f.OmitEmpty:
+				optional = "?"
+				if f.Type.Kind() == reflect.Ptr {
+					typ = tsType(types, f.Type.Elem())
+				}
+			case f.Type.Kind() == reflect.Ptr:
+				typ += " | null"
+			}
+			fmt.Fprintf(&b, "  %s%s: %s;\n", f.JSONName, optional, typ)
+		}
+		b.WriteString("}\n\n")
+	}
+
+	b.WriteString(tsRuntime)
+	for _, endpoint := range endpoints {
+		b.WriteString("\n")
+		writeTSMethod(&b, types, endpoint)
+	}
+	b.WriteString("}\n")
+	return []byte(b.String())
+}
+
+// writeTSMethod writes the client method for an endpoint
+func writeTSMethod(b *strings.Builder, types *typeSet, endpoint api.Endpoint) {
+	var args []string
+	for _, param := range pathParams(endpoint.Path) {
+		args = append(args, camelCase(param)+": string")
+	}
+	if endpoint.Request != nil {
+		args = append(args, "req: "+tsType(types, endpoint.Request))
+	}
+	if endpoint.Kind == api.KindUpload {
+		args = append(args, "body: BodyInit", "contentType?: string")
+	}
+	query := "undefined"
+	if endpoint.Query {
+		args = append(args, "query?: Query")
+		query = "query"
+	}
+
+	path := tsPath(endpoint.Path)
+	name := lowerFirst(endpoint.Name)
+	response := "Record<string, unknown>"
+	if endpoint.Response != nil {
+		response = tsType(types, endpoint.Response)
+	}
+
+	fmt.Fprintf(b, "  /** %s %s */\n", endpoint.Method, endpoint.Path)
+	switch endpoint.Kind {
+	case api.KindWebSocket:
+		fmt.Fprintf(b, "  %sURL(%s): string {\n", name, strings.Join(args, ", "))
+		if endpoint.Query {
+			fmt.Fprintf(b, "    return this.wsURL(%s, query);\n  }\n", path)
+		} else {
+			fmt.Fprintf(b, "    return this.wsURL(%s);\n  }\n", path)
+		}
+	case api.KindDownload:
+		fmt.Fprintf(b, "  %s(%s): Promise<Response> {\n", name, strings.Join(args, ", "))
+		if endpoint.Query {
+			fmt.Fprintf(b, "    return this.send(%q, %s, query);\n  }\n", endpoint.Method, path)
+		} else {
+			fmt.Fprintf(b, "    return this.send(%q, %s);\n  }\n", endpoint.Method, path)
+		}
+	case api.KindUpload:
+		fmt.Fprintf(b, "  %s(%s): Promise<%s> {\n", name, strings.Join(args, ", "), response)
+		fmt.Fprintf(b, "    return this.upload(%q, %s, body, contentType, %s);\n  }\n", endpoint.Method, path, query)
+	default:
+		callArgs := []string{fmt.Sprintf("%q", endpoint.Method), path}
+		if endpoint.Request != nil {
+			callArgs = append(callArgs, query, "req")
+		} else if endpoint.Query {
+			callArgs = append(callArgs, query)
+		}
+		fmt.Fprintf(b, "  %s(%s): Promise<%s> {\n", name, strings.Join(args, ", "), response)
+		fmt.Fprintf(b, "    return this.request(%s);\n  }\n", strings.Join(callArgs, ", "))
+	}
+}
+
+// tsType returns the TypeScript type for t
+func tsType(types *typeSet, t reflect.Type) string {
+	switch {
+	case t == timeType || t == apiTimeType:
+		return "string" // RFC 3339
+	case t == bytesType:
+		return "string" // Base64
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return tsType(types, t.Elem())
+	case reflect.Slice, reflect.Array:
+		elem := tsType(types, t.Elem())
+		if strings.ContainsAny(elem, " |") {
+			elem = "(" + elem + ")"
+		}
+		return elem + "[]"
+	case reflect.Map:
+		return "Record<string, " + tsType(types, t.Elem()) + ">"
+	case reflect.Interface:
+		return "unknown"
+	case reflect.Struct:
+		return types.nameOf(t)
+	case reflect.Bool:
+		return "boolean"
+	case reflect.String:
+		return "string"
+	default:
+		return "number"
+	}
+}
+
+// tsPath returns a TypeScript expression building a route path
+func tsPath(path string) string {
+	if len(pathParams(path)) == 0 {
+		return fmt.Sprintf("%q", path)
+	}
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "${encodeURIComponent(" + camelCase(segment[1:]) + ")}"
+		}
+	}
+	return "`" + strings.Join(segments, "/") + "`"
+}
@@ -2,19 +2,45 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
 	"os"
 	"time"
 )
 
 // Config represents the application configuration
 type Config struct {
-	Server     ServerConfig     `json:"server"`
-	Database   DatabaseConfig   `json:"database"`
-	Auth       AuthConfig       `json:"auth"`
-	CORS       CORSConfig       `json:"cors"`
-	Crypto     CryptoConfig     `json:"crypto"`
-	Blockchain BlockchainConfig `json:"blockchain"`
-	SMS        SMSConfig        `json:"sms"`
+	// Environment is "development" or "production". Production refuses to
+	// start with missing or default secrets.
+	Environment   string              `json:"environment"`
+	Server        ServerConfig        `json:"server"`
+	Database      DatabaseConfig      `json:"database"`
+	Auth          AuthConfig          `json:"auth"`
+	CORS          CORSConfig          `json:"cors"`
+	Crypto        CryptoConfig        `json:"crypto"`
+	Blockchain    BlockchainConfig    `json:"blockchain"`
+	SMS           SMSConfig           `json:"sms"`
+	Mailer        MailerConfig        `json:"mailer"`
+	Messaging     MessagingConfig     `json:"messaging"`
+	Notifications NotificationsConfig `json:"notifications"`
+	Storage       StorageConfig       `json:"storage"`
+	Media         MediaConfig         `json:"media"`
+	Redis         RedisConfig         `json:"redis"`
+	RateLimit     RateLimitConfig     `json:"rateLimit"`
+	IPFilter      IPFilterConfig      `json:"ipFilter"`
+	Metrics       MetricsConfig       `json:"metrics"`
+	IDs           IDConfig            `json:"ids"`
+	Admin         AdminConfig         `json:"admin"`
+	AgeGate       AgeGateConfig       `json:"ageGate"`
+	SecretChat    SecretChatConfig    `json:"secretChat"`
+	Keys          KeysConfig          `json:"keys"`
+	Quotas        QuotaConfig         `json:"quotas"`
+	Moderation    ModerationConfig    `json:"moderation"`
+	WebView       WebViewConfig       `json:"webView"`
+	GroupEvents   GroupEventConfig    `json:"groupEvents"`
+	DeliverySLA   DeliverySLAConfig   `json:"deliverySla"`
+	Alerts        AlertsConfig        `json:"alerts"`
+	Plugins       []PluginConfig      `json:"plugins"`
 }
 
 // ServerConfig represents server-specific configuration
@@ -24,6 +50,26 @@ type ServerConfig struct {
 	ReadTimeout     time.Duration `json:"readTimeout"`
 	WriteTimeout    time.Duration `json:"writeTimeout"`
 	ShutdownTimeout time.Duration `json:"shutdownTimeout"`
+	// DrainTimeout is how long to wait after asking WebSocket clients to
+	// reconnect before the server stops
+	DrainTimeout time.Duration `json:"drainTimeout"`
+	// ReconnectJitter is the window over which reconnect hints are spread
+	ReconnectJitter time.Duration `json:"reconnectJitter"`
+	// WebSocketSendBuffer is how many messages can wait to be written to a
+	// WebSocket client before it is disconnected as too slow
+	WebSocketSendBuffer int `json:"webSocketSendBuffer"`
+	// WebSocketPingInterval is how often WebSocket clients are pinged, and
+	// WebSocketPongTimeout how long they may stay silent before being
+	// disconnected
+	WebSocketPingInterval time.Duration `json:"webSocketPingInterval"`
+	WebSocketPongTimeout  time.Duration `json:"webSocketPongTimeout"`
+	// WebSocketWriteTimeout bounds each write to a WebSocket client
+	WebSocketWriteTimeout time.Duration `json:"webSocketWriteTimeout"`
+	// WebSocketBroker is "local" for a single instance, or "redis" to relay
+	// broadcasts, presence and receipts between instances through the Redis
+	// server, on channels named after WebSocketBrokerChannel
+	WebSocketBroker        string `json:"webSocketBroker"`
+	WebSocketBrokerChannel string `json:"webSocketBrokerChannel"`
 }
 
 // DatabaseConfig represents database-specific configuration
@@ -33,6 +79,37 @@ type DatabaseConfig struct {
 	MaxOpenConns     int    `json:"maxOpenConns"`
 	MaxIdleConns     int    `json:"maxIdleConns"`
 	ConnMaxLifetime  int    `json:"connMaxLifetime"`
+	// QueryTimeout bounds the database queries made while serving a request
+	QueryTimeout time.Duration `json:"queryTimeout"`
+	// CounterReconcileInterval is how often denormalized member and message
+	// counters are checked against their source tables
+	CounterReconcileInterval time.Duration `json:"counterReconcileInterval"`
+	// Partitioning splits the message tables into monthly partitions (MySQL only)
+	Partitioning PartitioningConfig `json:"partitioning"`
+	// Retry is how long startup waits for the database to come up
+	Retry DatabaseRetryConfig `json:"retry"`
+}
+
+// DatabaseRetryConfig represents how connecting to the database at startup
+// is retried
+type DatabaseRetryConfig struct {
+	// Attempts is how many times connecting is tried before giving up; 0 or
+	// 1 tries once
+	Attempts int `json:"attempts"`
+	// InitialDelay is the wait after the first failed attempt, doubled after
+	// each further one up to MaxDelay
+	InitialDelay time.Duration `json:"initialDelay"`
+	MaxDelay     time.Duration `json:"maxDelay"`
+}
+
+// PartitioningConfig represents monthly partitioning of the message tables
+type PartitioningConfig struct {
+	Enabled bool `json:"enabled"`
+	// MonthsAhead is how many future months always have a partition ready
+	MonthsAhead int `json:"monthsAhead"`
+	// RetentionMonths drops partitions older than this many months; 0 keeps everything
+	RetentionMonths     int           `json:"retentionMonths"`
+	MaintenanceInterval time.Duration `json:"maintenanceInterval"`
 }
 
 // AuthConfig represents authentication-specific configuration
@@ -45,6 +122,64 @@ type AuthConfig struct {
 	Argon2Threads        uint8         `json:"argon2Threads"`
 	Argon2KeyLength      uint32        `json:"argon2KeyLength"`
 	OTPExpiryMinutes     int           `json:"otpExpiryMinutes"`
+	ChallengeExpiry      time.Duration `json:"challengeExpiry"`
+	// OTPLength is how many characters OTP codes have, from 4 to 16
+	OTPLength int `json:"otpLength"`
+	// OTPCharset is "numeric" for digit codes or "alphanumeric" for codes
+	// of digits and upper case letters
+	OTPCharset string `json:"otpCharset"`
+	// OTPResendCooldown is how long POST /api/auth/resend-otp makes users
+	// wait between codes, and OTPMaxResends how many times one code may be
+	// replaced before a new one has to be requested
+	OTPResendCooldown time.Duration `json:"otpResendCooldown"`
+	OTPMaxResends     int           `json:"otpMaxResends"`
+	// Phones starting with TestPhonePrefix get TestPhoneCode as their OTP
+	// and no SMS, so load tests can sign up accounts. Leave both empty in
+	// production.
+	TestPhonePrefix string `json:"testPhonePrefix"`
+	TestPhoneCode   string `json:"testPhoneCode"`
+	// After PINMaxAttempts wrong account PINs in a row the PIN is locked
+	// for PINLockout
+	PINMaxAttempts int           `json:"pinMaxAttempts"`
+	PINLockout     time.Duration `json:"pinLockout"`
+	// FreezeCooldown is how long an emergency freeze blocks new logins
+	FreezeCooldown time.Duration `json:"freezeCooldown"`
+	// RegistrationChallenge keeps bots from signing up, and spending the SMS
+	// budget
+	RegistrationChallenge RegistrationChallengeConfig `json:"registrationChallenge"`
+}
+
+// Registration challenge types
+const (
+	RegistrationChallengeNone    = "none"
+	RegistrationChallengePoW     = "pow"
+	RegistrationChallengeCaptcha = "captcha"
+)
+
+// RegistrationChallengeConfig sets what a client has to pass before an OTP
+// is sent to register a phone number
+type RegistrationChallengeConfig struct {
+	// Type is "none", "pow" for a proof-of-work or "captcha" for a CAPTCHA
+	// verified with an external provider
+	Type string `json:"type"`
+	// ProofOfWorkDifficulty is how many leading zero bits the hash of a
+	// solution must have when Type is "pow"
+	ProofOfWorkDifficulty int `json:"proofOfWorkDifficulty"`
+	// ChallengeExpiry is how long a client has to solve a proof-of-work
+	ChallengeExpiry time.Duration `json:"challengeExpiry"`
+	Captcha         CaptchaConfig `json:"captcha"`
+}
+
+// CaptchaConfig represents an external CAPTCHA provider. hCaptcha, reCAPTCHA
+// and Turnstile all verify tokens the same way.
+type CaptchaConfig struct {
+	// VerifyURL is the provider's siteverify endpoint
+	VerifyURL string `json:"verifyUrl"`
+	// SiteKey is handed to clients to render the CAPTCHA
+	SiteKey string `json:"siteKey"`
+	Secret  string `json:"secret"`
+	// Timeout bounds each verification request
+	Timeout time.Duration `json:"timeout"`
 }
 
 // CORSConfig represents CORS-specific configuration
@@ -69,6 +204,35 @@ type BlockchainConfig struct {
 	DataDir         string        `json:"dataDir"`
 	StorageType     string        `json:"storageType"`
 	MempoolCapacity int           `json:"mempoolCapacity"`
+
+	// Consensus schedules rule changes by block height, so every node
+	// switches rules at the same block instead of whenever it is deployed.
+	// It must have an entry activating at height 0.
+	Consensus []ConsensusRules `json:"consensus"`
+
+	// Shadow mirrors direct message writes into the StorageType backend
+	// under DataDir while MySQL stays the source of truth, so a migration
+	// can be validated before cutover
+	Shadow ShadowConfig `json:"shadow"`
+}
+
+// ShadowConfig represents dual-writing messages to a second backend
+type ShadowConfig struct {
+	Enabled bool `json:"enabled"`
+	// ReadSampleRate is the fraction of message reads, from 0 to 1, that are
+	// also read from the shadow backend and compared
+	ReadSampleRate float64 `json:"readSampleRate"`
+}
+
+// ConsensusRules are the block rules in force from ActivationHeight until
+// the next scheduled change
+type ConsensusRules struct {
+	ActivationHeight int `json:"activationHeight"`
+	// Difficulty is the number of trailing zero hex digits a block hash needs
+	Difficulty int `json:"difficulty"`
+	// TransactionTypes are the transaction types blocks may include
+	TransactionTypes []string `json:"transactionTypes"`
+	Description      string   `json:"description"`
 }
 
 // SMSConfig represents SMS service configuration
@@ -79,6 +243,345 @@ type SMSConfig struct {
 	BaseURL     string `json:"baseUrl"`
 	IsEnabled   bool   `json:"isEnabled"`
 	PatternCode string `json:"patternCode"`
+	// OTPTemplate is the text of OTP messages for providers without
+	// patterns. {code} is replaced by the code.
+	OTPTemplate string `json:"otpTemplate"`
+	// SNS holds the settings of the "sns" provider
+	SNS SNSConfig `json:"sns"`
+}
+
+// SNSConfig represents Amazon SNS SMS configuration
+type SNSConfig struct {
+	Region          string `json:"region"`
+	AccessKeyID     string `json:"accessKeyId"`
+	SecretAccessKey string `json:"secretAccessKey"`
+	// SMSType is Transactional or Promotional
+	SMSType string `json:"smsType"`
+}
+
+// MailerConfig represents email delivery configuration, used for OTPs
+// requested with the "email" channel
+type MailerConfig struct {
+	Provider  string `json:"provider"`
+	IsEnabled bool   `json:"isEnabled"`
+	Host      string `json:"host"`
+	Port      int    `json:"port"`
+	Username  string `json:"username"`
+	Password  string `json:"password"`
+	From      string `json:"from"`
+	// OTPSubject and OTPTemplate are the subject and text of OTP emails.
+	// {code} is replaced by the code.
+	OTPSubject  string `json:"otpSubject"`
+	OTPTemplate string `json:"otpTemplate"`
+}
+
+// MessagingConfig represents message handling configuration
+type MessagingConfig struct {
+	// EditWindow is how long after sending a message its sender may edit it
+	EditWindow time.Duration `json:"editWindow"`
+
+	// MaxContentSize is the largest decoded message content, in bytes, that
+	// is stored inline. Larger payloads must be uploaded as media.
+	MaxContentSize int `json:"maxContentSize"`
+
+	// MaxAttachments is the most media objects one message may reference
+	MaxAttachments int `json:"maxAttachments"`
+
+	// ChannelReachWindow is how long after posting a channel message member
+	// acks still count toward its reach. The rows that keep one member from
+	// being counted twice are deleted once they are older than this.
+	ChannelReachWindow time.Duration `json:"channelReachWindow"`
+
+	// ExpiryInterval is how often direct messages past their expiration
+	// time are purged; 0 turns the purge off
+	ExpiryInterval time.Duration `json:"expiryInterval"`
+}
+
+// AgeGateConfig represents age checks at registration
+type AgeGateConfig struct {
+	// RequireBirthdate rejects registrations without a birthdate
+	RequireBirthdate bool `json:"requireBirthdate"`
+	// MinimumAge is the youngest age allowed to register; 0 allows any age
+	MinimumAge int `json:"minimumAge"`
+	// RestrictedAge is the age below which users are in restricted mode:
+	// they can't search for users or be found, can't use secret chats, and
+	// media isn't downloaded automatically by default
+	RestrictedAge int `json:"restrictedAge"`
+}
+
+// KeysConfig represents the end-to-end key directory configuration
+type KeysConfig struct {
+	// MaxOneTimePrekeys caps how many one-time prekeys a user may have stored
+	MaxOneTimePrekeys int `json:"maxOneTimePrekeys"`
+	// LowPrekeyThreshold is how few one-time prekeys a user has left before
+	// they are asked to upload more
+	LowPrekeyThreshold int `json:"lowPrekeyThreshold"`
+	// SignedPrekeyMaxAge is how old a signed prekey gets before its owner
+	// is asked to rotate it
+	SignedPrekeyMaxAge time.Duration `json:"signedPrekeyMaxAge"`
+}
+
+// QuotaConfig represents per-conversation and per-user limits. Responses
+// report how much of a limit is left and warn before it is reached.
+type QuotaConfig struct {
+	// WarnAt is the fraction of a limit, between 0 and 1, past which
+	// responses carry a warning
+	WarnAt float64 `json:"warnAt"`
+	// MaxGroupMembers caps the members of a group, 0 for no limit
+	MaxGroupMembers int `json:"maxGroupMembers"`
+	// MaxChannelMembers caps the members of a channel, 0 for no limit
+	MaxChannelMembers int `json:"maxChannelMembers"`
+	// MaxBroadcastRecipients caps the recipients of a broadcast list, 0 for
+	// no limit
+	MaxBroadcastRecipients int `json:"maxBroadcastRecipients"`
+	// StoragePerUser caps the bytes of media a user may store, counting
+	// uploads in progress, 0 for no limit
+	StoragePerUser int64 `json:"storagePerUser"`
+}
+
+// ModerationConfig represents the automatic throttling of reported public
+// channels
+type ModerationConfig struct {
+	// ThrottleAt is how many people reporting a public channel throttle it
+	// and flag it for review, 0 to never throttle
+	ThrottleAt int `json:"throttleAt"`
+	// ReleaseAt is how few reporters must be left for a throttle to lift
+	// without review. Keep it below ThrottleAt.
+	ReleaseAt int `json:"releaseAt"`
+	// ReportWindow is how long a report counts towards throttling
+	ReportWindow time.Duration `json:"reportWindow"`
+	// MinReporterAge is how old an account must be for its reports to count
+	MinReporterAge time.Duration `json:"minReporterAge"`
+}
+
+// WebViewConfig represents the public web pages of channels that opt in
+type WebViewConfig struct {
+	// BaseURL is the server's public address, used for OpenGraph links.
+	// Pages leave their og:url out when it is empty.
+	BaseURL string `json:"baseUrl"`
+	// PostsPerPage is how many posts a page lists
+	PostsPerPage int `json:"postsPerPage"`
+	// CacheTTL is how long a page is served from cache and may be cached by
+	// browsers and proxies
+	CacheTTL time.Duration `json:"cacheTtl"`
+}
+
+// GroupEventConfig represents group event reminders
+type GroupEventConfig struct {
+	// ReminderLead is how long before an event starts its reminder is sent
+	ReminderLead time.Duration `json:"reminderLead"`
+	// CheckInterval is how often due reminders and starting events are
+	// looked for, 0 to turn reminders and start messages off
+	CheckInterval time.Duration `json:"checkInterval"`
+}
+
+// DeliverySLAConfig represents message delivery time tracking. Delivery
+// time runs from a message being stored to the recipient acknowledging it.
+type DeliverySLAConfig struct {
+	// Enabled records delivery times and stores their percentiles per hour
+	Enabled bool `json:"enabled"`
+	// RollupInterval is how often finished hours are summarized
+	RollupInterval time.Duration `json:"rollupInterval"`
+	// P95Threshold raises an alert when an hour's 95th percentile delivery
+	// time exceeds it, 0 for no alerts
+	P95Threshold time.Duration `json:"p95Threshold"`
+	// MinSamples is how many deliveries an hour needs to raise an alert
+	MinSamples int `json:"minSamples"`
+	// Retention is how long hourly percentiles are kept
+	Retention time.Duration `json:"retention"`
+}
+
+// AlertsConfig represents where operator alerts are sent. Alerts go to
+// every destination that is configured.
+type AlertsConfig struct {
+	// WebhookURL receives alerts as JSON POST requests
+	WebhookURL string      `json:"webhookUrl"`
+	Email      EmailConfig `json:"email"`
+}
+
+// EmailConfig represents alert emails sent through an SMTP server
+type EmailConfig struct {
+	Enabled  bool     `json:"enabled"`
+	Host     string   `json:"host"`
+	Port     int      `json:"port"`
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+}
+
+// SecretChatConfig represents anonymous secret chat configuration
+type SecretChatConfig struct {
+	// ProofOfWorkDifficulty is how many leading zero bits the hash of a
+	// creation challenge must have; each extra bit doubles the client's work.
+	// 0 lets anyone create secret chats without a challenge.
+	ProofOfWorkDifficulty int `json:"proofOfWorkDifficulty"`
+	// ChallengeExpiry is how long a client has to solve a challenge
+	ChallengeExpiry time.Duration `json:"challengeExpiry"`
+	// DefaultTTL is how long a secret chat lasts when its creator doesn't ask
+	DefaultTTL time.Duration `json:"defaultTTL"`
+	// MinTTL and MaxTTL bound the lifetime a creator may ask for
+	MinTTL time.Duration `json:"minTTL"`
+	MaxTTL time.Duration `json:"maxTTL"`
+	// MaxParticipants caps how many participants a secret chat may have,
+	// and is the limit of chats created without one; 0 means no cap
+	MaxParticipants int `json:"maxParticipants"`
+}
+
+// PluginConfig enables one of the plugins compiled into the server.
+// Plugins run in the order they are listed.
+type PluginConfig struct {
+	Name string `json:"name"`
+	// Settings are passed to the plugin as they appear in the file
+	Settings map[string]interface{} `json:"settings,omitempty"`
+}
+
+// AdminConfig represents server operator configuration
+type AdminConfig struct {
+	// Phones are the phone numbers of users given the admin role, which
+	// grants access to the dashboard at /admin and the admin API
+	Phones []string `json:"phones"`
+	// APIKeys let operator tooling call the operator endpoints of the admin
+	// API without signing in, with the key in the X-Admin-Key header
+	APIKeys []string `json:"apiKeys"`
+}
+
+// NotificationsConfig represents push notification configuration
+type NotificationsConfig struct {
+	FCM  FCMConfig  `json:"fcm"`
+	APNs APNsConfig `json:"apns"`
+}
+
+// FCMConfig represents Firebase Cloud Messaging configuration
+type FCMConfig struct {
+	Enabled            bool   `json:"enabled"`
+	ServiceAccountFile string `json:"serviceAccountFile"`
+}
+
+// APNsConfig represents Apple Push Notification service configuration
+type APNsConfig struct {
+	Enabled    bool   `json:"enabled"`
+	KeyFile    string `json:"keyFile"`
+	KeyID      string `json:"keyId"`
+	TeamID     string `json:"teamId"`
+	Topic      string `json:"topic"`
+	Production bool   `json:"production"`
+}
+
+// StorageConfig represents object storage configuration
+type StorageConfig struct {
+	// Backend is "local" or "s3"
+	Backend  string   `json:"backend"`
+	LocalDir string   `json:"localDir"`
+	S3       S3Config `json:"s3"`
+}
+
+// S3Config represents S3-compatible storage configuration
+type S3Config struct {
+	Endpoint        string `json:"endpoint"`
+	Region          string `json:"region"`
+	Bucket          string `json:"bucket"`
+	AccessKeyID     string `json:"accessKeyId"`
+	SecretAccessKey string `json:"secretAccessKey"`
+	UsePathStyle    bool   `json:"usePathStyle"`
+	// ServerSideEncryption has S3 encrypt stored objects: "" leaves it to
+	// the bucket's default, "AES256" uses S3-managed keys and "aws:kms"
+	// uses KMSKeyID, or the account's default KMS key if it's empty
+	ServerSideEncryption string `json:"serverSideEncryption"`
+	KMSKeyID             string `json:"kmsKeyId"`
+	// PartSize is the size of the parts objects larger than it are
+	// uploaded in, at least 5 MiB
+	PartSize int64 `json:"partSize"`
+}
+
+// MediaConfig represents media attachment configuration
+type MediaConfig struct {
+	MaxSize      int64    `json:"maxSize"`
+	AllowedTypes []string `json:"allowedTypes"`
+	ChunkSize    int64    `json:"chunkSize"`
+	TempDir      string   `json:"tempDir"`
+	// SigningSecret signs download URLs
+	SigningSecret string        `json:"signingSecret"`
+	URLExpiry     time.Duration `json:"urlExpiry"`
+	// ExternalDomains are the hosts, along with their subdomains, that group
+	// photo URLs may point at. Other photos must be media uploaded here.
+	ExternalDomains []string `json:"externalDomains"`
+	// RehostExternal copies images from external domains into media
+	// storage, so members' clients never contact the external host
+	RehostExternal bool `json:"rehostExternal"`
+	// RehostTimeout bounds fetching an external image to rehost
+	RehostTimeout time.Duration `json:"rehostTimeout"`
+}
+
+// RedisConfig represents the connection to an optional Redis server
+type RedisConfig struct {
+	Addr     string        `json:"addr"`
+	Password string        `json:"password"`
+	DB       int           `json:"db"`
+	Timeout  time.Duration `json:"timeout"`
+}
+
+// RateLimitConfig represents request rate limiting configuration
+type RateLimitConfig struct {
+	Enabled bool `json:"enabled"`
+	// Backend is "memory" or "redis"
+	Backend string `json:"backend"`
+	// AuthPerPhone and AuthPerIP limit the /api/auth endpoints
+	AuthPerPhone RateLimitRule `json:"authPerPhone"`
+	AuthPerIP    RateLimitRule `json:"authPerIp"`
+	// MessagesPerAddress limits direct, group and channel message sends
+	MessagesPerAddress RateLimitRule `json:"messagesPerAddress"`
+	// ExportsPerAddress limits conversation and group exports, which read
+	// a whole conversation at once
+	ExportsPerAddress RateLimitRule `json:"exportsPerAddress"`
+	// ReportsPerAddress limits abuse reports
+	ReportsPerAddress RateLimitRule `json:"reportsPerAddress"`
+	// ThrottledChannelMessages limits the messages sent to each channel
+	// throttled by moderation, across all its admins
+	ThrottledChannelMessages RateLimitRule `json:"throttledChannelMessages"`
+	// ContactDiscoveryPerAddress limits address book lookups, each of up
+	// to 500 phone hashes
+	ContactDiscoveryPerAddress RateLimitRule `json:"contactDiscoveryPerAddress"`
+}
+
+// RateLimitRule allows a burst of Requests, refilled evenly over Interval
+type RateLimitRule struct {
+	Requests int           `json:"requests"`
+	Interval time.Duration `json:"interval"`
+}
+
+// IPFilterConfig represents client IP allow and deny lists, and temporary
+// bans of IPs that keep failing to authenticate or hitting rate limits
+type IPFilterConfig struct {
+	Enabled bool `json:"enabled"`
+	// Allow lists CIDRs or single IPs that are never denied or banned
+	Allow []string `json:"allow"`
+	// Deny lists CIDRs or single IPs that are refused outright
+	Deny []string `json:"deny"`
+	// BanThreshold is how many 401 and 429 responses an IP may get within
+	// BanWindow before it is banned for BanDuration. 0 turns bans off.
+	BanThreshold int           `json:"banThreshold"`
+	BanWindow    time.Duration `json:"banWindow"`
+	BanDuration  time.Duration `json:"banDuration"`
+	// Persist saves bans to the database so they survive restarts and are
+	// picked up by other instances when they start
+	Persist bool `json:"persist"`
+}
+
+// MetricsConfig represents the Prometheus metrics endpoint
+type MetricsConfig struct {
+	// Enabled serves metrics at /metrics
+	Enabled bool `json:"enabled"`
+	// Token, when set, must be sent as a bearer token to read the metrics
+	Token string `json:"token"`
+}
+
+// IDConfig represents how record IDs are generated
+type IDConfig struct {
+	// Strategy is "random" (64-char hex), "ulid" or "snowflake"
+	Strategy string `json:"strategy"`
+	// NodeID distinguishes instances when using Snowflake IDs (0-1023)
+	NodeID int `json:"nodeId"`
 }
 
 // LoadConfig loads the configuration from the specified file path
@@ -94,6 +597,9 @@ func LoadConfig(path string) (*Config, error) {
 	if err := decoder.Decode(&config); err != nil {
 		return nil, err
 	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after the configuration in %s", path)
+	}
 
 	return &config, nil
 }
@@ -101,19 +607,40 @@ func LoadConfig(path string) (*Config, error) {
 // DefaultConfig returns a default configuration
 func DefaultConfig() *Config {
 	return &Config{
+		Environment: EnvironmentDevelopment,
 		Server: ServerConfig{
-			Host:            "0.0.0.0",
-			Port:            8080,
-			ReadTimeout:     time.Second * 15,
-			WriteTimeout:    time.Second * 15,
-			ShutdownTimeout: time.Second * 30,
+			Host:                   "0.0.0.0",
+			Port:                   8080,
+			ReadTimeout:            time.Second * 15,
+			WriteTimeout:           time.Second * 15,
+			ShutdownTimeout:        time.Second * 30,
+			DrainTimeout:           time.Second * 5,
+			ReconnectJitter:        time.Second * 30,
+			WebSocketSendBuffer:    256,
+			WebSocketPingInterval:  time.Second * 30,
+			WebSocketPongTimeout:   time.Second * 60,
+			WebSocketWriteTimeout:  time.Second * 10,
+			WebSocketBroker:        "local",
+			WebSocketBrokerChannel: "piko:websocket",
 		},
 		Database: DatabaseConfig{
-			Driver:           "mysql",
-			ConnectionString: "root@tcp(localhost:3306)/piko?parseTime=true",
-			MaxOpenConns:     25,
-			MaxIdleConns:     25,
-			ConnMaxLifetime:  300,
+			Driver:                   "mysql",
+			ConnectionString:         "root@tcp(localhost:3306)/piko?parseTime=true",
+			MaxOpenConns:             25,
+			MaxIdleConns:             25,
+			ConnMaxLifetime:          300,
+			QueryTimeout:             time.Second * 10,
+			CounterReconcileInterval: time.Hour,
+			Partitioning: PartitioningConfig{
+				Enabled:             false,
+				MonthsAhead:         3,
+				MaintenanceInterval: time.Hour * 24,
+			},
+			Retry: DatabaseRetryConfig{
+				Attempts:     10,
+				InitialDelay: time.Second,
+				MaxDelay:     time.Second * 30,
+			},
 		},
 		Auth: AuthConfig{
 			JWTSecret:            "change-me-in-production",
@@ -124,6 +651,23 @@ func DefaultConfig() *Config {
 			Argon2Threads:        4,
 			Argon2KeyLength:      32,
 			OTPExpiryMinutes:     5,
+			ChallengeExpiry:      time.Minute * 2,
+			OTPLength:            6,
+			OTPCharset:           "numeric",
+			OTPResendCooldown:    time.Minute,
+			OTPMaxResends:        3,
+			PINMaxAttempts:       5,
+			PINLockout:           time.Hour,
+			FreezeCooldown:       24 * time.Hour,
+			RegistrationChallenge: RegistrationChallengeConfig{
+				Type:                  RegistrationChallengeNone,
+				ProofOfWorkDifficulty: 20,
+				ChallengeExpiry:       2 * time.Minute,
+				Captcha: CaptchaConfig{
+					VerifyURL: "https://hcaptcha.com/siteverify",
+					Timeout:   5 * time.Second,
+				},
+			},
 		},
 		CORS: CORSConfig{
 			AllowOrigins:     "*",
@@ -142,6 +686,18 @@ func DefaultConfig() *Config {
 			DataDir:         "./data",
 			StorageType:     "badger",
 			MempoolCapacity: 10000,
+			Consensus: []ConsensusRules{
+				{
+					ActivationHeight: 0,
+					Difficulty:       2,
+					TransactionTypes: []string{"message", "channel_message", "channel_create", "channel_join"},
+					Description:      "Initial rules",
+				},
+			},
+			Shadow: ShadowConfig{
+				Enabled:        false,
+				ReadSampleRate: 0.01,
+			},
 		},
 		SMS: SMSConfig{
 			Provider:    "ippanel",
@@ -150,6 +706,159 @@ func DefaultConfig() *Config {
 			BaseURL:     "https://edge.ippanel.com/v1",
 			IsEnabled:   true,
 			PatternCode: "9muuwhyyw2s1ag5",
+			OTPTemplate: "Your PIKO verification code is: {code}",
+			SNS: SNSConfig{
+				SMSType: "Transactional",
+			},
+		},
+		Mailer: MailerConfig{
+			Provider:    "smtp",
+			IsEnabled:   false,
+			Port:        587,
+			OTPSubject:  "Your PIKO verification code",
+			OTPTemplate: "Your PIKO verification code is: {code}",
+		},
+		Messaging: MessagingConfig{
+			EditWindow:         time.Minute * 15,
+			MaxContentSize:     64 * 1024,
+			MaxAttachments:     10,
+			ChannelReachWindow: time.Hour * 24 * 7,
+			ExpiryInterval:     time.Minute,
+		},
+		Notifications: NotificationsConfig{
+			FCM: FCMConfig{
+				Enabled:            false,
+				ServiceAccountFile: "./config/fcm-service-account.json",
+			},
+			APNs: APNsConfig{
+				Enabled: false,
+				KeyFile: "./config/apns-key.p8",
+			},
+		},
+		Storage: StorageConfig{
+			Backend:  "local",
+			LocalDir: "./uploads/media",
+			S3: S3Config{
+				PartSize: 16 * 1024 * 1024,
+			},
+		},
+		Media: MediaConfig{
+			MaxSize:         100 * 1024 * 1024,
+			AllowedTypes:    []string{"image/", "video/", "audio/", "application/pdf", "application/octet-stream"},
+			ChunkSize:       5 * 1024 * 1024,
+			TempDir:         "./uploads/tmp",
+			SigningSecret:   "change-me-in-production",
+			URLExpiry:       time.Hour,
+			ExternalDomains: []string{},
+			RehostExternal:  true,
+			RehostTimeout:   time.Second * 10,
+		},
+		Redis: RedisConfig{
+			Addr:    "localhost:6379",
+			Timeout: time.Second * 5,
+		},
+		RateLimit: RateLimitConfig{
+			Enabled: true,
+			Backend: "memory",
+			AuthPerPhone: RateLimitRule{
+				Requests: 5,
+				Interval: time.Minute * 15,
+			},
+			AuthPerIP: RateLimitRule{
+				Requests: 20,
+				Interval: time.Minute * 15,
+			},
+			MessagesPerAddress: RateLimitRule{
+				Requests: 60,
+				Interval: time.Minute,
+			},
+			ExportsPerAddress: RateLimitRule{
+				Requests: 3,
+				Interval: time.Hour,
+			},
+			ReportsPerAddress: RateLimitRule{
+				Requests: 10,
+				Interval: time.Hour,
+			},
+			ThrottledChannelMessages: RateLimitRule{
+				Requests: 10,
+				Interval: time.Hour,
+			},
+			ContactDiscoveryPerAddress: RateLimitRule{
+				Requests: 20,
+				Interval: time.Hour,
+			},
+		},
+		IPFilter: IPFilterConfig{
+			Enabled:      true,
+			Allow:        []string{},
+			Deny:         []string{},
+			BanThreshold: 50,
+			BanWindow:    10 * time.Minute,
+			BanDuration:  15 * time.Minute,
+		},
+		Metrics: MetricsConfig{
+			Enabled: true,
+		},
+		IDs: IDConfig{
+			Strategy: "random",
+		},
+		Admin: AdminConfig{
+			Phones:  []string{},
+			APIKeys: []string{},
+		},
+		AgeGate: AgeGateConfig{
+			RequireBirthdate: false,
+			MinimumAge:       13,
+			RestrictedAge:    18,
+		},
+		SecretChat: SecretChatConfig{
+			ProofOfWorkDifficulty: 18,
+			ChallengeExpiry:       time.Minute * 2,
+			DefaultTTL:            time.Hour * 24,
+			MinTTL:                time.Minute * 5,
+			MaxTTL:                time.Hour * 24 * 7,
+			MaxParticipants:       0,
+		},
+		Keys: KeysConfig{
+			MaxOneTimePrekeys:  100,
+			LowPrekeyThreshold: 10,
+			SignedPrekeyMaxAge: time.Hour * 24 * 7,
+		},
+		Quotas: QuotaConfig{
+			WarnAt:                 0.8,
+			MaxGroupMembers:        1000,
+			MaxChannelMembers:      100000,
+			MaxBroadcastRecipients: 256,
+			StoragePerUser:         1024 * 1024 * 1024,
+		},
+		Moderation: ModerationConfig{
+			ThrottleAt:     10,
+			ReleaseAt:      3,
+			ReportWindow:   time.Hour * 24 * 7,
+			MinReporterAge: time.Hour * 24,
+		},
+		WebView: WebViewConfig{
+			PostsPerPage: 20,
+			CacheTTL:     time.Minute,
+		},
+		GroupEvents: GroupEventConfig{
+			ReminderLead:  time.Hour,
+			CheckInterval: time.Minute,
+		},
+		DeliverySLA: DeliverySLAConfig{
+			Enabled:        true,
+			RollupInterval: time.Minute * 5,
+			P95Threshold:   time.Second * 5,
+			MinSamples:     100,
+			Retention:      time.Hour * 24 * 30,
+		},
+		Alerts: AlertsConfig{
+			Email: EmailConfig{
+				Port: 587,
+				To:   []string{},
+			},
 		},
+		Plugins: []PluginConfig{},
 	}
 }
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// FuzzLoadConfig checks that any config file is either rejected or loads
+// into a configuration that survives being written back out
+func FuzzLoadConfig(f *testing.F) {
+	if data, err := os.ReadFile("config.json"); err == nil {
+		f.Add(data)
+	}
+	f.Add([]byte(`{}`))
+	f.Add([]byte(`{"server":{"port":"8080"}} {}`))
+	f.Add([]byte(`{"auth":{"challengeExpiry":-1}}`))
+	f.Add([]byte(`{"database":null,"cors":[]}`))
+	f.Add([]byte(`{"rateLimit":{"authPerPhone":{"requests":1e100}}}`))
+	f.Add([]byte("\xef\xbb\xbf{}"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		path := filepath.Join(t.TempDir(), "config.json")
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		cfg, err := LoadConfig(path)
+		if err != nil {
+			return
+		}
+
+		encoded, err := json.Marshal(cfg)
+		if err != nil {
+			t.Fatalf("loaded config doesn't encode: %v", err)
+		}
+		if err := os.WriteFile(path, encoded, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		again, err := LoadConfig(path)
+		if err != nil {
+			t.Fatalf("re-encoded config doesn't load: %v\n%s", err, encoded)
+		}
+		if !reflect.DeepEqual(cfg, again) {
+			t.Fatalf("config changed after a round trip:\n%s", encoded)
+		}
+	})
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Environments the server can run in
+const (
+	EnvironmentDevelopment = "development"
+	EnvironmentProduction  = "production"
+)
+
+// placeholderSecret is the value secrets have in the default configuration
+const placeholderSecret = "change-me-in-production"
+
+// minJWTSecretLength is the shortest JWT secret accepted in production
+const minJWTSecretLength = 32
+
+// minAdminAPIKeyLength is the shortest admin API key accepted in production
+const minAdminAPIKeyLength = 32
+
+// IsProduction reports whether the server runs in production
+func (c *Config) IsProduction() bool {
+	return c.Environment == EnvironmentProduction
+}
+
+// Validate checks the configuration. In production the secrets have to be
+// set and can't be left at their defaults; every problem found is returned.
+func (c *Config) Validate() error {
+	switch c.Environment {
+	case "", EnvironmentDevelopment, EnvironmentProduction:
+	default:
+		return fmt.Errorf("environment must be %q or %q", EnvironmentDevelopment, EnvironmentProduction)
+	}
+	switch c.Auth.RegistrationChallenge.Type {
+	case "", RegistrationChallengeNone, RegistrationChallengePoW, RegistrationChallengeCaptcha:
+	default:
+		return fmt.Errorf("auth.registrationChallenge.type must be %q, %q or %q",
+			RegistrationChallengeNone, RegistrationChallengePoW, RegistrationChallengeCaptcha)
+	}
+	if !c.IsProduction() {
+		return nil
+	}
+
+	var errs []error
+	missing := func(setting string) {
+		errs = append(errs, fmt.Errorf("%s is required in production (%s%s)", setting, EnvPrefix, settingEnvName(setting)))
+	}
+
+	switch {
+	case c.Auth.JWTSecret == "" || c.Auth.JWTSecret == placeholderSecret:
+		missing("auth.jwtSecret")
+	case len(c.Auth.JWTSecret) < minJWTSecretLength:
+		errs = append(errs, fmt.Errorf("auth.jwtSecret must be at least %d characters in production", minJWTSecretLength))
+	}
+	if c.Database.ConnectionString == "" {
+		missing("database.connectionString")
+	}
+	if c.Media.SigningSecret == "" || c.Media.SigningSecret == placeholderSecret {
+		missing("media.signingSecret")
+	}
+	if c.Auth.RegistrationChallenge.Type == RegistrationChallengeCaptcha {
+		if c.Auth.RegistrationChallenge.Captcha.VerifyURL == "" {
+			missing("auth.registrationChallenge.captcha.verifyUrl")
+		}
+		if c.Auth.RegistrationChallenge.Captcha.Secret == "" {
+			missing("auth.registrationChallenge.captcha.secret")
+		}
+	}
+	for _, key := range c.Admin.APIKeys {
+		if len(key) < minAdminAPIKeyLength {
+			errs = append(errs, fmt.Errorf("admin.apiKeys must be at least %d characters in production", minAdminAPIKeyLength))
+			break
+		}
+	}
+
+	if c.SMS.IsEnabled && c.SMS.Provider != "mock" {
+		if c.SMS.Provider == "sns" {
+			if c.SMS.SNS.AccessKeyID == "" {
+				missing("sms.sns.accessKeyId")
+			}
+			if c.SMS.SNS.SecretAccessKey == "" {
+				missing("sms.sns.secretAccessKey")
+			}
+		} else if c.SMS.APIKey == "" {
+			missing("sms.apiKey")
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// settingEnvName returns the variable name, without EnvPrefix, of a
+// dotted config.json path
+func settingEnvName(setting string) string {
+	parts := strings.Split(setting, ".")
+	for i, part := range parts {
+		parts[i] = envName(part)
+	}
+	return strings.Join(parts, "_")
+}
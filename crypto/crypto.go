@@ -2,12 +2,14 @@ package crypto
 
 import (
 	"crypto/ed25519"
-	"crypto/rand"
 	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/base64"
+	"encoding/binary"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/mr-tron/base58"
 	"golang.org/x/crypto/argon2"
@@ -22,6 +24,8 @@ var (
 	ErrInvalidSignature = errors.New("invalid signature")
 	// ErrInvalidAddress is returned when an address is invalid
 	ErrInvalidAddress = errors.New("invalid address")
+	// ErrInvalidAddressLength is returned when an address length isn't positive
+	ErrInvalidAddressLength = errors.New("invalid address length")
 )
 
 // KeyPair represents a public/private key pair
@@ -32,7 +36,7 @@ type KeyPair struct {
 
 // GenerateKeyPair generates a new Ed25519 key pair
 func GenerateKeyPair() (*KeyPair, error) {
-	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	publicKey, privateKey, err := ed25519.GenerateKey(Rand())
 	if err != nil {
 		return nil, err
 	}
@@ -63,6 +67,9 @@ func GenerateAddress(publicKey []byte, length int) (string, error) {
 	if len(publicKey) != ed25519.PublicKeySize {
 		return "", ErrInvalidPublicKey
 	}
+	if length <= 0 {
+		return "", ErrInvalidAddressLength
+	}
 
 	// Hash the public key using SHA-256
 	hash := sha256.Sum256(publicKey)
@@ -75,10 +82,7 @@ func GenerateAddress(publicKey []byte, length int) (string, error) {
 		address = address[:length]
 	} else if len(address) < length {
 		// This should not happen with SHA-256 and Base58, but just in case
-		padding := length - len(address)
-		for i := 0; i < padding; i++ {
-			address += "1" // Use "1" for padding (common in Base58)
-		}
+		address += strings.Repeat("1", length-len(address)) // Use "1" for padding (common in Base58)
 	}
 
 	return address, nil
@@ -101,7 +105,7 @@ func HashPassword(password string, salt []byte, time, memory uint32, threads uin
 	if salt == nil {
 		var err error
 		salt = make([]byte, 16)
-		if _, err = rand.Read(salt); err != nil {
+		if err = ReadRandom(salt); err != nil {
 			return nil, err
 		}
 	}
@@ -143,7 +147,7 @@ func VerifyPassword(password string, encodedHash string, time, memory uint32, th
 // GenerateRandomBytes generates random bytes of the specified length
 func GenerateRandomBytes(length int) ([]byte, error) {
 	bytes := make([]byte, length)
-	_, err := rand.Read(bytes)
+	err := ReadRandom(bytes)
 	if err != nil {
 		return nil, err
 	}
@@ -170,6 +174,16 @@ func DecodeBase64(str string) ([]byte, error) {
 	return base64.StdEncoding.DecodeString(str)
 }
 
+// EncodeBase64URL encodes bytes to an unpadded URL-safe Base64 string
+func EncodeBase64URL(bytes []byte) string {
+	return base64.RawURLEncoding.EncodeToString(bytes)
+}
+
+// DecodeBase64URL decodes a URL-safe Base64 string, with or without padding, to bytes
+func DecodeBase64URL(str string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(str, "="))
+}
+
 // HashSHA256 computes the SHA-256 hash of data
 func HashSHA256(data []byte) []byte {
 	hash := sha256.Sum256(data)
@@ -181,3 +195,40 @@ func HashToHex(data []byte) string {
 	hash := sha256.Sum256(data)
 	return hex.EncodeToString(hash[:])
 }
+
+// safetyNumberIterations is how many times each party's key is hashed
+const safetyNumberIterations = 5200
+
+// KeyFingerprint returns a hex fingerprint of a public key
+func KeyFingerprint(publicKey []byte) string {
+	return HashToHex(publicKey)
+}
+
+// SafetyNumber computes a 60-digit number two users can compare out of band
+// to confirm they hold each other's real keys. The result is the same no
+// matter which side computes it.
+func SafetyNumber(localAddress string, localKey []byte, remoteAddress string, remoteKey []byte) string {
+	local := safetyNumberHalf(localAddress, localKey)
+	remote := safetyNumberHalf(remoteAddress, remoteKey)
+	if localAddress < remoteAddress {
+		return local + " " + remote
+	}
+	return remote + " " + local
+}
+
+// safetyNumberHalf derives one party's 30 digits as six groups of five
+func safetyNumberHalf(address string, publicKey []byte) string {
+	hash := append(append([]byte{}, publicKey...), address...)
+	for i := 0; i < safetyNumberIterations; i++ {
+		sum := sha512.Sum512(append(hash, publicKey...))
+		hash = sum[:]
+	}
+
+	groups := make([]string, 6)
+	for i := range groups {
+		chunk := make([]byte, 8)
+		copy(chunk[3:], hash[i*5:i*5+5])
+		groups[i] = fmt.Sprintf("%05d", binary.BigEndian.Uint64(chunk)%100000)
+	}
+	return strings.Join(groups, " ")
+}
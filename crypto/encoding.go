@@ -0,0 +1,73 @@
+package crypto
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Encoding is a binary-to-text encoding used for encrypted content on the wire
+type Encoding string
+
+const (
+	// EncodingBase64 is standard padded Base64, used unless a client asks otherwise
+	EncodingBase64 Encoding = "base64"
+	// EncodingBase64URL is unpadded URL-safe Base64
+	EncodingBase64URL Encoding = "base64url"
+)
+
+// ParseEncoding returns the encoding with the given name
+func ParseEncoding(name string) (Encoding, bool) {
+	switch Encoding(strings.ToLower(strings.TrimSpace(name))) {
+	case EncodingBase64:
+		return EncodingBase64, true
+	case EncodingBase64URL:
+		return EncodingBase64URL, true
+	default:
+		return "", false
+	}
+}
+
+// NegotiateEncoding picks an encoding from an Accept-Encoding style list such
+// as "base64url, base64;q=0.5". The supported encoding with the highest
+// q-value wins, earlier entries breaking ties. Base64 is used when nothing
+// listed is supported.
+func NegotiateEncoding(accept string) Encoding {
+	best := EncodingBase64
+	bestQ := 0.0
+	for _, part := range strings.Split(accept, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		encoding, ok := ParseEncoding(name)
+		if !ok {
+			continue
+		}
+
+		q := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
+
+// Encode encodes bytes using the encoding
+func (e Encoding) Encode(bytes []byte) string {
+	if e == EncodingBase64URL {
+		return EncodeBase64URL(bytes)
+	}
+	return EncodeBase64(bytes)
+}
+
+// Decode decodes a string using the encoding
+func (e Encoding) Decode(str string) ([]byte, error) {
+	if e == EncodingBase64URL {
+		return DecodeBase64URL(str)
+	}
+	return DecodeBase64(str)
+}
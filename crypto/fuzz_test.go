@@ -0,0 +1,127 @@
+package crypto
+
+import (
+	"bytes"
+	"testing"
+)
+
+// FuzzValidateAddress checks that address validation never panics and only
+// accepts strings of the right length that decode as Base58
+func FuzzValidateAddress(f *testing.F) {
+	f.Add("1BoatSLRHtKNngkdXEeobR76b53LETtpyT", 34)
+	f.Add("PikoAddress0OIl", 15)
+	f.Add("", 0)
+	f.Add("\xff\xfe", 2)
+
+	f.Fuzz(func(t *testing.T, address string, length int) {
+		if !ValidateAddress(address, length) {
+			return
+		}
+		if len(address) != length {
+			t.Fatalf("accepted %q with length %d", address, length)
+		}
+		if _, err := DecodeBase58(address); err != nil {
+			t.Fatalf("accepted %q that doesn't decode: %v", address, err)
+		}
+	})
+}
+
+// FuzzGenerateAddress checks that generated addresses always validate
+func FuzzGenerateAddress(f *testing.F) {
+	f.Add(make([]byte, 32), 46)
+	f.Add([]byte{}, 1)
+	f.Add([]byte{0}, 0)
+
+	f.Fuzz(func(t *testing.T, publicKey []byte, length int) {
+		if length > 1024 {
+			t.Skip("address length too large to allocate")
+		}
+		address, err := GenerateAddress(publicKey, length)
+		if err != nil {
+			return
+		}
+		if !ValidateAddress(address, len(address)) {
+			t.Fatalf("generated address %q doesn't validate", address)
+		}
+	})
+}
+
+// FuzzDecodeBase58 checks that anything Base58 decodes re-encodes to the
+// same bytes
+func FuzzDecodeBase58(f *testing.F) {
+	f.Add("")
+	f.Add("1")
+	f.Add("111z")
+	f.Add("3mJr7AoUXx2Wqd")
+	f.Add("0OIl")
+
+	f.Fuzz(func(t *testing.T, s string) {
+		decoded, err := DecodeBase58(s)
+		if err != nil {
+			return
+		}
+		again, err := DecodeBase58(EncodeBase58(decoded))
+		if err != nil {
+			t.Fatalf("re-encoding of %q doesn't decode: %v", s, err)
+		}
+		if !bytes.Equal(decoded, again) {
+			t.Fatalf("%q decoded to %x, then round-tripped to %x", s, decoded, again)
+		}
+	})
+}
+
+// FuzzEncodingDecode checks both wire encodings against arbitrary content,
+// as clients send it in message bodies
+func FuzzEncodingDecode(f *testing.F) {
+	f.Add("aGVsbG8=")
+	f.Add("aGVsbG8")
+	f.Add("_-8=")
+	f.Add("====")
+	f.Add("a")
+
+	f.Fuzz(func(t *testing.T, s string) {
+		for _, encoding := range []Encoding{EncodingBase64, EncodingBase64URL} {
+			decoded, err := encoding.Decode(s)
+			if err != nil {
+				continue
+			}
+			again, err := encoding.Decode(encoding.Encode(decoded))
+			if err != nil {
+				t.Fatalf("%s re-encoding of %q doesn't decode: %v", encoding, s, err)
+			}
+			if !bytes.Equal(decoded, again) {
+				t.Fatalf("%s: %q decoded to %x, then round-tripped to %x", encoding, s, decoded, again)
+			}
+		}
+	})
+}
+
+// FuzzNegotiateEncoding checks that any Accept-Encoding style header picks
+// a supported encoding
+func FuzzNegotiateEncoding(f *testing.F) {
+	f.Add("base64url, base64;q=0.5")
+	f.Add("BASE64URL;q=NaN")
+	f.Add(";q=,,;")
+	f.Add("base64;q=1e999")
+
+	f.Fuzz(func(t *testing.T, accept string) {
+		encoding := NegotiateEncoding(accept)
+		if _, ok := ParseEncoding(string(encoding)); !ok {
+			t.Fatalf("negotiated unsupported encoding %q from %q", encoding, accept)
+		}
+	})
+}
+
+// FuzzVerifyPassword checks that malformed stored hashes are rejected
+// without panicking
+func FuzzVerifyPassword(f *testing.F) {
+	f.Add("password", "")
+	f.Add("password", "c2hvcnQ=")
+	f.Add("password", "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=")
+	f.Add("", "not base64!")
+
+	f.Fuzz(func(t *testing.T, password, encodedHash string) {
+		// Cheap Argon2 parameters keep each iteration fast
+		VerifyPassword(password, encodedHash, 1, 8, 1, 16)
+	})
+}
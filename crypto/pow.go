@@ -0,0 +1,41 @@
+package crypto
+
+import (
+	"crypto/sha256"
+	"math/bits"
+	"strconv"
+)
+
+// proofOfWorkHash hashes a challenge nonce together with a candidate solution
+func proofOfWorkHash(nonce, solution string) [32]byte {
+	return sha256.Sum256([]byte(nonce + ":" + solution))
+}
+
+// leadingZeroBits counts the zero bits at the start of a hash
+func leadingZeroBits(hash [32]byte) int {
+	zeros := 0
+	for _, b := range hash {
+		if b != 0 {
+			return zeros + bits.LeadingZeros8(b)
+		}
+		zeros += 8
+	}
+	return zeros
+}
+
+// VerifyProofOfWork checks that SHA-256(nonce + ":" + solution) starts with
+// at least difficulty zero bits
+func VerifyProofOfWork(nonce, solution string, difficulty int) bool {
+	return leadingZeroBits(proofOfWorkHash(nonce, solution)) >= difficulty
+}
+
+// SolveProofOfWork finds a solution for a challenge by counting up from 0.
+// It is what clients are expected to run; the server only verifies.
+func SolveProofOfWork(nonce string, difficulty int) string {
+	for i := uint64(0); ; i++ {
+		solution := strconv.FormatUint(i, 10)
+		if VerifyProofOfWork(nonce, solution, difficulty) {
+			return solution
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"io"
+	"sync"
+)
+
+// RandSource supplies the random bytes behind keys, IDs, OTP codes and
+// session tokens
+type RandSource interface {
+	Read(p []byte) (n int, err error)
+}
+
+// SystemRand is the operating system's cryptographically secure source
+var SystemRand RandSource = rand.Reader
+
+var (
+	randMu     sync.RWMutex
+	randSource = SystemRand
+)
+
+// SetRandSource replaces the source used for random bytes. Anything but
+// SystemRand is for tests only.
+func SetRandSource(r RandSource) {
+	randMu.Lock()
+	defer randMu.Unlock()
+	randSource = r
+}
+
+// Rand returns the configured random source
+func Rand() io.Reader {
+	randMu.RLock()
+	defer randMu.RUnlock()
+	return randSource
+}
+
+// ReadRandom fills b with random bytes from the configured source
+func ReadRandom(b []byte) error {
+	_, err := io.ReadFull(Rand(), b)
+	return err
+}
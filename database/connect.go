@@ -0,0 +1,105 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/piko/piko/config"
+)
+
+// ready is set once Initialize has connected and brought the schema up to
+// date
+var ready atomic.Bool
+
+// Ready reports whether the database is connected and its schema is ready
+func Ready() bool {
+	return ready.Load()
+}
+
+// connectWithRetry connects to the database, waiting with exponential
+// backoff between attempts so the server can start before MySQL is up, as
+// it often does under docker-compose
+func connectWithRetry(cfg config.DatabaseConfig, connString string) error {
+	delay := cfg.Retry.InitialDelay
+	for attempt := 1; ; attempt++ {
+		err := connect(cfg, connString)
+		if err == nil {
+			return nil
+		}
+		if attempt >= cfg.Retry.Attempts {
+			return err
+		}
+
+		log.Printf("Database not ready (attempt %d of %d): %v; retrying in %s", attempt, cfg.Retry.Attempts, err, delay)
+		time.Sleep(delay)
+		delay *= 2
+		if cfg.Retry.MaxDelay > 0 && delay > cfg.Retry.MaxDelay {
+			delay = cfg.Retry.MaxDelay
+		}
+	}
+}
+
+// connect opens and pings the database, setting DB once it answers
+func connect(cfg config.DatabaseConfig, connString string) error {
+	// For MySQL, try to create the database first if it doesn't exist
+	if cfg.Driver == "mysql" {
+		createMySQLDatabase(cfg.ConnectionString)
+	}
+
+	db, err := sql.Open(cfg.Driver, connString)
+	if err != nil {
+		return fmt.Errorf("failed to open database connection: %w", err)
+	}
+
+	// Set connection pool settings
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetime) * time.Second)
+
+	// Verify the connection
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	DB = db
+	return nil
+}
+
+// createMySQLDatabase creates the database named in a MySQL connection
+// string if it doesn't exist. Failing is only a warning: the ping after it
+// tells whether the server is reachable.
+func createMySQLDatabase(connString string) {
+	// Format: username:password@protocol(address)/dbname?param=value
+	dbNameStart := strings.LastIndex(connString, "/")
+	if dbNameStart == -1 {
+		return
+	}
+
+	dbNameEnd := strings.Index(connString[dbNameStart+1:], "?")
+	var dbName string
+	if dbNameEnd == -1 {
+		dbName = connString[dbNameStart+1:]
+	} else {
+		dbName = connString[dbNameStart+1 : dbNameStart+1+dbNameEnd]
+	}
+
+	// Connect without specifying a database
+	tempDB, err := sql.Open("mysql", connString[:dbNameStart+1])
+	if err != nil {
+		fmt.Printf("Warning: Could not connect to MySQL server: %v\n", err)
+		return
+	}
+	defer tempDB.Close()
+
+	// Create the database if it doesn't exist
+	if _, err := tempDB.Exec(fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci", dbName)); err != nil {
+		fmt.Printf("Warning: Could not create database: %v\n", err)
+		return
+	}
+	fmt.Printf("Database '%s' created or already exists\n", dbName)
+}
@@ -4,10 +4,9 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"strings"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/piko/piko/config"
 )
@@ -20,64 +19,39 @@ var (
 	ErrNotInitialized = errors.New("database not initialized")
 )
 
-// Initialize initializes the database connection
+// utcConnectionString makes every MySQL session use UTC, so TIMESTAMP
+// columns, NOW() and the times the driver parses all agree whatever the
+// server's or the host's time zone is
+func utcConnectionString(connString string) (string, error) {
+	dsn, err := mysql.ParseDSN(connString)
+	if err != nil {
+		return "", err
+	}
+	dsn.ParseTime = true
+	dsn.Loc = time.UTC
+	if dsn.Params == nil {
+		dsn.Params = map[string]string{}
+	}
+	dsn.Params["time_zone"] = "'+00:00'"
+	return dsn.FormatDSN(), nil
+}
+
+// Initialize connects to the database, retrying with backoff while it
+// isn't reachable yet, and brings the schema up to date. Ready reports true
+// once it has returned without error.
 func Initialize(cfg config.DatabaseConfig) error {
-	var err error
+	ready.Store(false)
 
-	// For MySQL, try to create the database first if it doesn't exist
+	// Connect to the database
+	connString := cfg.ConnectionString
 	if cfg.Driver == "mysql" {
-		// Parse MySQL connection string to extract database name
-		// Format: username:password@protocol(address)/dbname?param=value
-		connString := cfg.ConnectionString
-
-		// Find the database name
-		dbNameStart := strings.LastIndex(connString, "/")
-		if dbNameStart == -1 {
-			return fmt.Errorf("invalid MySQL connection string format")
-		}
-
-		dbNameEnd := strings.Index(connString[dbNameStart+1:], "?")
-		var dbName string
-		if dbNameEnd == -1 {
-			dbName = connString[dbNameStart+1:]
-		} else {
-			dbName = connString[dbNameStart+1 : dbNameStart+1+dbNameEnd]
+		var err error
+		if connString, err = utcConnectionString(connString); err != nil {
+			return fmt.Errorf("invalid MySQL connection string: %w", err)
 		}
-
-		// Create connection string without database name
-		baseConnString := connString[:dbNameStart+1]
-
-		// Connect without specifying a database
-		tempDB, tempErr := sql.Open(cfg.Driver, baseConnString)
-		if tempErr == nil {
-			defer tempDB.Close()
-
-			// Create the database if it doesn't exist
-			_, execErr := tempDB.Exec(fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci", dbName))
-			if execErr != nil {
-				fmt.Printf("Warning: Could not create database: %v\n", execErr)
-			} else {
-				fmt.Printf("Database '%s' created or already exists\n", dbName)
-			}
-		} else {
-			fmt.Printf("Warning: Could not connect to MySQL server: %v\n", tempErr)
-		}
-	}
-
-	// Connect to the database
-	DB, err = sql.Open(cfg.Driver, cfg.ConnectionString)
-	if err != nil {
-		return fmt.Errorf("failed to open database connection: %w", err)
 	}
-
-	// Set connection pool settings
-	DB.SetMaxOpenConns(cfg.MaxOpenConns)
-	DB.SetMaxIdleConns(cfg.MaxIdleConns)
-	DB.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetime) * time.Second)
-
-	// Verify the connection
-	if err := DB.Ping(); err != nil {
-		return fmt.Errorf("failed to ping database: %w", err)
+	if err := connectWithRetry(cfg, connString); err != nil {
+		return err
 	}
 
 	// Initialize database schema
@@ -85,6 +59,24 @@ func Initialize(cfg config.DatabaseConfig) error {
 		return fmt.Errorf("failed to initialize database schema: %w", err)
 	}
 
+	// Widen content columns left as BLOB by older schemas
+	if cfg.Driver == "mysql" {
+		if err := migrateBlobColumns(); err != nil {
+			return fmt.Errorf("failed to migrate content columns: %w", err)
+		}
+	}
+
+	// Split the message tables into monthly partitions
+	if cfg.Partitioning.Enabled {
+		if cfg.Driver != "mysql" {
+			return fmt.Errorf("message partitioning requires mysql, not %s", cfg.Driver)
+		}
+		if err := initPartitions(cfg.Partitioning); err != nil {
+			return fmt.Errorf("failed to partition message tables: %w", err)
+		}
+	}
+
+	ready.Store(true)
 	return nil
 }
 
@@ -96,7 +88,8 @@ func Close() error {
 	return DB.Close()
 }
 
-// dropTables drops all tables if they exist
+// dropTables drops all tables if they exist, except ip_bans: persisted IP
+// bans have to survive restarts
 func dropTables() error {
 	if DB == nil {
 		return ErrNotInitialized
@@ -106,17 +99,74 @@ func dropTables() error {
 	tables := []string{
 		"transactions",
 		"blocks",
+		"consensus_params",
+		"group_topic_reads",
+		"group_message_reads",
+		"channel_message_reads",
+		"group_message_deliveries",
 		"group_messages",
+		"group_topics",
+		"group_event_rsvps",
+		"group_events",
+		"group_invites",
+		"guest_members",
+		"group_join_requests",
 		"group_members",
 		"chat_groups",
+		"channel_flags",
+		"channel_reach_acks",
 		"channel_messages",
 		"channel_members",
 		"channels",
+		"broadcast_deliveries",
+		"broadcast_messages",
+		"broadcast_recipients",
+		"broadcast_lists",
+		"collab_doc_updates",
+		"collab_docs",
+		"poll_votes",
+		"poll_options",
+		"polls",
+		"sticker_pack_installs",
+		"stickers",
+		"sticker_packs",
+		"message_attachments",
+		"media_uploads",
+		"media",
+		"audit_log",
+		"delivery_sla",
+		"delivery_samples",
+		"legal_holds",
+		"trusted_devices",
+		"account_pins",
+		"do_not_disturb",
+		"mutes",
+		"account_suspensions",
+		"account_freezes",
+		"recovery_codes",
+		"sessions",
+		"devices",
+		"contact_labels",
+		"contacts",
+		"support_ticket_replies",
+		"support_tickets",
+		"reports",
+		"policy_acceptances",
+		"policies",
+		"one_time_prekeys",
+		"key_rotation_peers",
+		"key_rotations",
+		"key_bundles",
+		"conversation_keys",
+		"message_receipts",
+		"message_edits",
 		"messages",
 		"user_avatars",
 		"user_settings",
 		"users",
 		"otp",
+		"auth_challenges",
+		"pow_challenges",
 		"secret_chat_messages",
 		"secret_chat_participants",
 		"secret_chats",
@@ -154,12 +204,17 @@ func initSchema() error {
 		CREATE TABLE IF NOT EXISTS users (
 			id INT AUTO_INCREMENT PRIMARY KEY,
 			phone VARCHAR(20) UNIQUE NOT NULL,
+			phone_hash CHAR(64) NOT NULL,
 			username VARCHAR(30) UNIQUE,
 			password_hash VARCHAR(255) NOT NULL,
 			public_key BLOB NOT NULL,
 			address VARCHAR(46) UNIQUE NOT NULL,
+			role VARCHAR(20) NOT NULL DEFAULT 'user',
+			birthdate DATE NULL,
+			email VARCHAR(254) NOT NULL DEFAULT '',
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			INDEX (phone_hash)
 		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
 	`)
 	if err != nil {
@@ -171,11 +226,14 @@ func initSchema() error {
 		CREATE TABLE IF NOT EXISTS otp (
 			id INT AUTO_INCREMENT PRIMARY KEY,
 			phone VARCHAR(20) NOT NULL,
-			code VARCHAR(6) NOT NULL,
+			code VARCHAR(16) NOT NULL,
+			email VARCHAR(254) NOT NULL DEFAULT '',
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			sent_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			expires_at TIMESTAMP NOT NULL,
 			verified BOOLEAN DEFAULT FALSE,
 			failed_attempts INT DEFAULT 0,
+			resend_count INT NOT NULL DEFAULT 0,
 			INDEX (phone)
 		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
 	`)
@@ -183,19 +241,54 @@ func initSchema() error {
 		return err
 	}
 
+	// Create auth challenges table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS auth_challenges (
+			nonce VARCHAR(64) PRIMARY KEY,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			expires_at TIMESTAMP NOT NULL,
+			used BOOLEAN DEFAULT FALSE,
+			INDEX (expires_at)
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create proof-of-work challenges table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS pow_challenges (
+			nonce VARCHAR(64) PRIMARY KEY,
+			purpose VARCHAR(32) NOT NULL DEFAULT 'secret_chat',
+			difficulty TINYINT UNSIGNED NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			expires_at TIMESTAMP NOT NULL,
+			used BOOLEAN DEFAULT FALSE,
+			INDEX (expires_at)
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
 	// Create messages table
 	_, err = DB.Exec(`
 		CREATE TABLE IF NOT EXISTS messages (
 			id VARCHAR(64) PRIMARY KEY,
 			sender_address VARCHAR(46) NOT NULL,
 			recipient_address VARCHAR(46) NOT NULL,
-			encrypted_content BLOB NOT NULL,
+			encrypted_content MEDIUMBLOB NOT NULL,
 			timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			status ENUM('pending', 'delivered', 'read') DEFAULT 'pending',
 			expiration_time TIMESTAMP NULL,
 			block_id VARCHAR(64) NULL,
-			INDEX (sender_address(32)),
-			INDEX (recipient_address(32)),
+			edited_at TIMESTAMP NULL,
+			reply_to_message_id VARCHAR(64) NULL,
+			forwarded_from VARCHAR(64) NULL,
+			sender_session_id VARCHAR(64) NULL,
+			sticker_id VARCHAR(64) NULL,
+			INDEX (sender_address(32), timestamp, id),
+			INDEX (recipient_address(32), timestamp, id),
 			INDEX (block_id(32))
 		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
 	`)
@@ -203,14 +296,53 @@ func initSchema() error {
 		return err
 	}
 
+	// Create message_edits table for the edit history of direct messages
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS message_edits (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			message_id VARCHAR(64) NOT NULL,
+			encrypted_content MEDIUMBLOB NOT NULL,
+			edited_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			INDEX (message_id(32))
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create message_receipts table for the status history of direct messages
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS message_receipts (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			message_id VARCHAR(64) NOT NULL,
+			sender_address VARCHAR(46) NOT NULL,
+			recipient_address VARCHAR(46) NOT NULL,
+			status ENUM('delivered', 'read') NOT NULL,
+			timestamp TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+			INDEX (message_id(32)),
+			INDEX (sender_address(32), timestamp)
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
 	// Create channels table
 	_, err = DB.Exec(`
 		CREATE TABLE IF NOT EXISTS channels (
 			id VARCHAR(64) PRIMARY KEY,
 			name VARCHAR(255) NOT NULL,
 			admin_address VARCHAR(46) NOT NULL,
+			is_public BOOLEAN NOT NULL DEFAULT FALSE,
+			invite_token VARCHAR(64) NULL,
+			web_slug VARCHAR(32) NULL,
+			member_count INT NOT NULL DEFAULT 0,
+			message_count INT NOT NULL DEFAULT 0,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			INDEX (admin_address(32))
+			INDEX (admin_address(32)),
+			UNIQUE INDEX (invite_token),
+			UNIQUE INDEX (web_slug),
+			INDEX (is_public, member_count)
 		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
 	`)
 	if err != nil {
@@ -222,6 +354,7 @@ func initSchema() error {
 		CREATE TABLE IF NOT EXISTS channel_members (
 			channel_id VARCHAR(64) NOT NULL,
 			user_address VARCHAR(46) NOT NULL,
+			role ENUM('owner', 'admin', 'member') NOT NULL DEFAULT 'member',
 			joined_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			PRIMARY KEY (channel_id(32), user_address(32)),
 			INDEX (user_address(32))
@@ -237,9 +370,16 @@ func initSchema() error {
 			id VARCHAR(64) PRIMARY KEY,
 			channel_id VARCHAR(64) NOT NULL,
 			sender_address VARCHAR(46) NOT NULL,
-			encrypted_content BLOB NOT NULL,
+			encrypted_content MEDIUMBLOB NOT NULL,
 			timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			block_id VARCHAR(64) NULL,
+			reply_to_message_id VARCHAR(64) NULL,
+			forwarded_from VARCHAR(64) NULL,
+			forwarded_from_channel VARCHAR(64) NULL,
+			sender_session_id VARCHAR(64) NULL,
+			edited_at TIMESTAMP NULL,
+			sticker_id VARCHAR(64) NULL,
+			reach_count INT NOT NULL DEFAULT 0,
 			INDEX (channel_id(32)),
 			INDEX (sender_address(32)),
 			INDEX (block_id(32))
@@ -249,12 +389,27 @@ func initSchema() error {
 		return err
 	}
 
+	// Create channel_reach_acks table; rows only live long enough to stop a
+	// member's repeated acks from being counted twice
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS channel_reach_acks (
+			message_id VARCHAR(64) NOT NULL,
+			reader_hash CHAR(64) NOT NULL,
+			acked_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (message_id, reader_hash),
+			INDEX (acked_at)
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
 	// Create blocks table
 	_, err = DB.Exec(`
 		CREATE TABLE IF NOT EXISTS blocks (
 			id VARCHAR(64) PRIMARY KEY,
 			previous_hash VARCHAR(64) NULL,
-			timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			timestamp TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
 			merkle_root VARCHAR(64) NOT NULL,
 			nonce BIGINT NOT NULL,
 			height INT NOT NULL,
@@ -265,6 +420,20 @@ func initSchema() error {
 		return err
 	}
 
+	// Create consensus params table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS consensus_params (
+			activation_height INT PRIMARY KEY,
+			difficulty INT NOT NULL,
+			transaction_types VARCHAR(255) NOT NULL,
+			description VARCHAR(255) NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
 	// Create transactions table
 	_, err = DB.Exec(`
 		CREATE TABLE IF NOT EXISTS transactions (
@@ -272,9 +441,11 @@ func initSchema() error {
 			block_id VARCHAR(64) NOT NULL,
 			type ENUM('message', 'channel_message', 'channel_create', 'channel_join', 'group_message', 'group_create', 'group_join') NOT NULL,
 			data_id VARCHAR(64) NOT NULL,
-			timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			timestamp TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
 			INDEX (block_id(32)),
-			INDEX (data_id(32))
+			INDEX (data_id(32)),
+			INDEX (timestamp),
+			INDEX (type, timestamp)
 		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
 	`)
 	if err != nil {
@@ -287,6 +458,7 @@ func initSchema() error {
 			channel_id VARCHAR(12) PRIMARY KEY,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			expires_at TIMESTAMP NOT NULL,
+			max_participants INT NOT NULL DEFAULT 0,
 			INDEX (expires_at)
 		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
 	`)
@@ -317,7 +489,7 @@ func initSchema() error {
 			channel_id VARCHAR(12) NOT NULL,
 			session_id VARCHAR(32) NOT NULL,
 			display_name VARCHAR(64) NOT NULL,
-			encrypted_content BLOB NOT NULL,
+			encrypted_content MEDIUMBLOB NOT NULL,
 			timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			INDEX (channel_id),
 			INDEX (session_id)
@@ -335,6 +507,10 @@ func initSchema() error {
 			description TEXT,
 			creator_address VARCHAR(46) NOT NULL,
 			photo_url VARCHAR(255),
+			member_count INT NOT NULL DEFAULT 0,
+			message_count INT NOT NULL DEFAULT 0,
+			topics_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+			join_requests_enabled BOOLEAN NOT NULL DEFAULT FALSE,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
 			INDEX (creator_address)
@@ -360,16 +536,133 @@ func initSchema() error {
 		return err
 	}
 
+	// Create group_invites table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS group_invites (
+			token VARCHAR(64) PRIMARY KEY,
+			group_id VARCHAR(64) NOT NULL,
+			created_by VARCHAR(46) NOT NULL,
+			max_uses INT NULL,
+			uses INT NOT NULL DEFAULT 0,
+			expires_at TIMESTAMP NULL,
+			revoked_at TIMESTAMP NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			INDEX (group_id),
+			FOREIGN KEY (group_id) REFERENCES chat_groups(id) ON DELETE CASCADE
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create group_join_requests table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS group_join_requests (
+			group_id VARCHAR(64) NOT NULL,
+			user_address VARCHAR(46) NOT NULL,
+			message VARCHAR(255),
+			status ENUM('pending', 'approved', 'rejected') NOT NULL DEFAULT 'pending',
+			decided_by VARCHAR(46) NULL,
+			decided_at TIMESTAMP NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (group_id, user_address),
+			INDEX (group_id, status, created_at),
+			FOREIGN KEY (group_id) REFERENCES chat_groups(id) ON DELETE CASCADE
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create guest_members table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS guest_members (
+			group_id VARCHAR(64) NOT NULL,
+			user_address VARCHAR(46) NOT NULL,
+			access ENUM('read', 'write') NOT NULL DEFAULT 'read',
+			granted_by VARCHAR(46) NOT NULL,
+			expires_at TIMESTAMP NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (group_id, user_address),
+			INDEX (expires_at),
+			FOREIGN KEY (group_id) REFERENCES chat_groups(id) ON DELETE CASCADE
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create group_events table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS group_events (
+			id VARCHAR(64) PRIMARY KEY,
+			group_id VARCHAR(64) NOT NULL,
+			creator_address VARCHAR(46) NOT NULL,
+			title VARCHAR(100) NOT NULL,
+			description TEXT,
+			starts_at TIMESTAMP NOT NULL,
+			reminded_at TIMESTAMP NULL,
+			started_at TIMESTAMP NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			INDEX (group_id, starts_at),
+			INDEX (starts_at),
+			FOREIGN KEY (group_id) REFERENCES chat_groups(id) ON DELETE CASCADE
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create group_event_rsvps table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS group_event_rsvps (
+			event_id VARCHAR(64) NOT NULL,
+			user_address VARCHAR(46) NOT NULL,
+			status VARCHAR(16) NOT NULL,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			PRIMARY KEY (event_id, user_address),
+			FOREIGN KEY (event_id) REFERENCES group_events(id) ON DELETE CASCADE
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create group_topics table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS group_topics (
+			id VARCHAR(64) PRIMARY KEY,
+			group_id VARCHAR(64) NOT NULL,
+			name VARCHAR(128) NOT NULL,
+			creator_address VARCHAR(46) NOT NULL,
+			closed_at TIMESTAMP NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			INDEX (group_id, created_at),
+			FOREIGN KEY (group_id) REFERENCES chat_groups(id) ON DELETE CASCADE
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
 	// Create group_messages table
 	_, err = DB.Exec(`
 		CREATE TABLE IF NOT EXISTS group_messages (
 			id VARCHAR(64) PRIMARY KEY,
 			group_id VARCHAR(64) NOT NULL,
 			sender_address VARCHAR(46) NOT NULL,
-			content BLOB NOT NULL,
+			content MEDIUMBLOB NOT NULL,
 			timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			block_id VARCHAR(64) NULL,
+			reply_to_message_id VARCHAR(64) NULL,
+			forwarded_from VARCHAR(64) NULL,
+			sender_session_id VARCHAR(64) NULL,
+			is_system BOOLEAN NOT NULL DEFAULT FALSE,
+			edited_at TIMESTAMP NULL,
+			topic_id VARCHAR(64) NULL,
+			sticker_id VARCHAR(64) NULL,
 			INDEX (group_id),
+			INDEX (topic_id, timestamp),
 			INDEX (sender_address),
 			INDEX (block_id),
 			FOREIGN KEY (group_id) REFERENCES chat_groups(id) ON DELETE CASCADE
@@ -379,6 +672,65 @@ func initSchema() error {
 		return err
 	}
 
+	// Create group_message_reads table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS group_message_reads (
+			group_id VARCHAR(64) NOT NULL,
+			user_address VARCHAR(46) NOT NULL,
+			last_read_message_id VARCHAR(64) NOT NULL,
+			last_read_timestamp TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			PRIMARY KEY (group_id, user_address)
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create group_topic_reads table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS group_topic_reads (
+			topic_id VARCHAR(64) NOT NULL,
+			user_address VARCHAR(46) NOT NULL,
+			last_read_message_id VARCHAR(64) NOT NULL,
+			last_read_timestamp TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			PRIMARY KEY (topic_id, user_address),
+			FOREIGN KEY (topic_id) REFERENCES group_topics(id) ON DELETE CASCADE
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create channel_message_reads table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS channel_message_reads (
+			channel_id VARCHAR(64) NOT NULL,
+			user_address VARCHAR(46) NOT NULL,
+			last_read_message_id VARCHAR(64) NOT NULL,
+			last_read_timestamp TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			PRIMARY KEY (channel_id, user_address)
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create group_message_deliveries table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS group_message_deliveries (
+			message_id VARCHAR(64) NOT NULL,
+			user_address VARCHAR(46) NOT NULL,
+			delivered_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (message_id, user_address)
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
 	// Create user_settings table
 	_, err = DB.Exec(`
 		CREATE TABLE IF NOT EXISTS user_settings (
@@ -422,5 +774,678 @@ func initSchema() error {
 		return err
 	}
 
+	// Create contacts table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS contacts (
+			owner_address VARCHAR(46) NOT NULL,
+			contact_address VARCHAR(46) NOT NULL,
+			alias VARCHAR(100) NOT NULL DEFAULT '',
+			blocked BOOLEAN NOT NULL DEFAULT FALSE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			PRIMARY KEY (owner_address, contact_address),
+			INDEX (contact_address),
+			FOREIGN KEY (owner_address) REFERENCES users(address) ON DELETE CASCADE,
+			FOREIGN KEY (contact_address) REFERENCES users(address) ON DELETE CASCADE
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create contact_labels table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS contact_labels (
+			owner_address VARCHAR(46) NOT NULL,
+			contact_address VARCHAR(46) NOT NULL,
+			label VARCHAR(32) NOT NULL,
+			PRIMARY KEY (owner_address, contact_address, label),
+			FOREIGN KEY (owner_address, contact_address) REFERENCES contacts(owner_address, contact_address) ON DELETE CASCADE
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create policies table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS policies (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			kind ENUM('terms', 'privacy') NOT NULL,
+			version VARCHAR(32) NOT NULL,
+			url VARCHAR(255) NOT NULL,
+			published_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE KEY (kind, version)
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create policy_acceptances table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS policy_acceptances (
+			user_id INT NOT NULL,
+			policy_id INT NOT NULL,
+			accepted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, policy_id),
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+			FOREIGN KEY (policy_id) REFERENCES policies(id)
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create legal_holds table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS legal_holds (
+			user_address VARCHAR(46) PRIMARY KEY,
+			reason VARCHAR(500) NOT NULL,
+			placed_by VARCHAR(46) NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create reports table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS reports (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			reporter_address VARCHAR(46) NOT NULL,
+			target_type ENUM('user', 'message', 'group', 'channel') NOT NULL,
+			target_id VARCHAR(64) NOT NULL,
+			reason VARCHAR(500) NOT NULL,
+			status ENUM('open', 'resolved', 'dismissed') NOT NULL DEFAULT 'open',
+			resolved_by VARCHAR(46) NULL,
+			resolved_at TIMESTAMP NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			INDEX (status),
+			INDEX (target_type, target_id)
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create channel flags table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS channel_flags (
+			channel_id VARCHAR(64) PRIMARY KEY,
+			throttled BOOLEAN NOT NULL DEFAULT TRUE,
+			upheld BOOLEAN NOT NULL DEFAULT FALSE,
+			reporter_count INT NOT NULL DEFAULT 0,
+			flagged_at TIMESTAMP NOT NULL,
+			released_at TIMESTAMP NULL,
+			reviewed_by VARCHAR(46) NULL,
+			reviewed_at TIMESTAMP NULL,
+			appeal VARCHAR(1000) NULL,
+			appealed_at TIMESTAMP NULL,
+			INDEX (throttled, flagged_at),
+			FOREIGN KEY (channel_id) REFERENCES channels(id) ON DELETE CASCADE
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create support tickets table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS support_tickets (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			user_address VARCHAR(46) NOT NULL,
+			category ENUM('bug', 'feedback', 'account', 'other') NOT NULL,
+			message TEXT NOT NULL,
+			logs_media_id VARCHAR(64) NULL,
+			status ENUM('open', 'closed') NOT NULL DEFAULT 'open',
+			closed_by VARCHAR(46) NULL,
+			closed_at TIMESTAMP NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			INDEX (status),
+			INDEX (user_address)
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create support ticket replies table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS support_ticket_replies (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			ticket_id INT NOT NULL,
+			admin_address VARCHAR(46) NOT NULL,
+			message TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (ticket_id) REFERENCES support_tickets(id) ON DELETE CASCADE
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create devices table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS devices (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			user_address VARCHAR(46) NOT NULL,
+			session_id VARCHAR(64) NULL,
+			platform ENUM('fcm', 'apns') NOT NULL,
+			token VARCHAR(255) NOT NULL UNIQUE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			INDEX (user_address),
+			INDEX (session_id)
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create sessions table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS sessions (
+			id VARCHAR(64) PRIMARY KEY,
+			user_address VARCHAR(46) NOT NULL,
+			device_name VARCHAR(100) NOT NULL DEFAULT '',
+			user_agent VARCHAR(255) NOT NULL DEFAULT '',
+			ip_address VARCHAR(45) NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			last_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			revoked_at TIMESTAMP NULL,
+			revoke_reason VARCHAR(32) NULL,
+			INDEX (user_address)
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create account_pins table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS account_pins (
+			user_address VARCHAR(46) PRIMARY KEY,
+			pin_hash VARCHAR(255) NOT NULL,
+			failed_attempts INT NOT NULL DEFAULT 0,
+			locked_until TIMESTAMP NULL,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_address) REFERENCES users(address) ON DELETE CASCADE
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create account_freezes table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS account_freezes (
+			user_address VARCHAR(46) PRIMARY KEY,
+			frozen_until TIMESTAMP NOT NULL,
+			frozen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_address) REFERENCES users(address) ON DELETE CASCADE
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create account_suspensions table for accounts blocked by operators
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS account_suspensions (
+			user_address VARCHAR(46) PRIMARY KEY,
+			reason VARCHAR(500) NOT NULL,
+			suspended_by VARCHAR(64) NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_address) REFERENCES users(address) ON DELETE CASCADE
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create ip_bans table for client IPs banned for repeated auth failures
+	// or rate limiting
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS ip_bans (
+			ip VARCHAR(45) PRIMARY KEY,
+			banned_until TIMESTAMP NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			INDEX idx_ip_bans_banned_until (banned_until)
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create recovery_codes table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS recovery_codes (
+			user_address VARCHAR(46) PRIMARY KEY,
+			code_hash CHAR(64) NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_address) REFERENCES users(address) ON DELETE CASCADE
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create trusted_devices table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS trusted_devices (
+			token_hash CHAR(64) PRIMARY KEY,
+			user_address VARCHAR(46) NOT NULL,
+			session_id VARCHAR(64) NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			INDEX (user_address),
+			FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create audit_log table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS audit_log (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			actor_address VARCHAR(46) NOT NULL,
+			action VARCHAR(64) NOT NULL,
+			target VARCHAR(128) NOT NULL DEFAULT '',
+			ip_address VARCHAR(45) NOT NULL DEFAULT '',
+			details TEXT,
+			conversation_type ENUM('group', 'channel') NULL,
+			conversation_id VARCHAR(64) NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			INDEX (actor_address),
+			INDEX (action),
+			INDEX (conversation_type, conversation_id, id)
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create delivery_samples table, holding delivery times until their hour
+	// is summarized
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS delivery_samples (
+			id BIGINT AUTO_INCREMENT PRIMARY KEY,
+			kind ENUM('direct', 'group') NOT NULL,
+			hour TIMESTAMP NOT NULL,
+			latency_ms INT UNSIGNED NOT NULL,
+			INDEX (hour, kind, latency_ms)
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create delivery_sla table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS delivery_sla (
+			hour TIMESTAMP NOT NULL,
+			kind ENUM('direct', 'group') NOT NULL,
+			samples INT UNSIGNED NOT NULL,
+			p50_ms INT UNSIGNED NOT NULL,
+			p95_ms INT UNSIGNED NOT NULL,
+			p99_ms INT UNSIGNED NOT NULL,
+			max_ms INT UNSIGNED NOT NULL,
+			alerted BOOLEAN NOT NULL DEFAULT FALSE,
+			PRIMARY KEY (hour, kind)
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create media table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS media (
+			id VARCHAR(64) PRIMARY KEY,
+			owner_address VARCHAR(46) NOT NULL,
+			storage_key VARCHAR(255) NOT NULL,
+			file_name VARCHAR(255) NOT NULL,
+			mime_type VARCHAR(100) NOT NULL,
+			size BIGINT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			INDEX (owner_address)
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create media_uploads table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS media_uploads (
+			id VARCHAR(64) PRIMARY KEY,
+			owner_address VARCHAR(46) NOT NULL,
+			file_name VARCHAR(255) NOT NULL,
+			mime_type VARCHAR(100) NOT NULL,
+			size BIGINT NOT NULL,
+			received BIGINT NOT NULL DEFAULT 0,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			INDEX (owner_address)
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create message_attachments table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS message_attachments (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			kind ENUM('direct', 'group', 'channel') NOT NULL,
+			message_id VARCHAR(64) NOT NULL,
+			media_id VARCHAR(64) NOT NULL,
+			INDEX (kind, message_id),
+			INDEX (media_id),
+			FOREIGN KEY (media_id) REFERENCES media(id) ON DELETE CASCADE
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create sticker_packs table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS sticker_packs (
+			id VARCHAR(64) PRIMARY KEY,
+			name VARCHAR(64) NOT NULL,
+			kind ENUM('stickers', 'emoji') NOT NULL DEFAULT 'stickers',
+			creator_address VARCHAR(46) NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			INDEX (creator_address)
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create stickers table. Each sticker's image is a media object
+	// uploaded by the pack's creator.
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS stickers (
+			id VARCHAR(64) PRIMARY KEY,
+			pack_id VARCHAR(64) NOT NULL,
+			media_id VARCHAR(64) NOT NULL,
+			emoji VARCHAR(32) NOT NULL DEFAULT '',
+			created_at TIMESTAMP(6) DEFAULT CURRENT_TIMESTAMP(6),
+			INDEX (pack_id, created_at),
+			INDEX (media_id),
+			FOREIGN KEY (pack_id) REFERENCES sticker_packs(id) ON DELETE CASCADE,
+			FOREIGN KEY (media_id) REFERENCES media(id) ON DELETE CASCADE
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create sticker_pack_installs table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS sticker_pack_installs (
+			user_address VARCHAR(46) NOT NULL,
+			pack_id VARCHAR(64) NOT NULL,
+			installed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_address, pack_id),
+			INDEX (pack_id),
+			FOREIGN KEY (pack_id) REFERENCES sticker_packs(id) ON DELETE CASCADE
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create conversation_keys table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS conversation_keys (
+			owner_address VARCHAR(46) NOT NULL,
+			peer_address VARCHAR(46) NOT NULL,
+			peer_key_fingerprint VARCHAR(64) NOT NULL,
+			verified BOOLEAN DEFAULT FALSE,
+			verified_at TIMESTAMP NULL,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			PRIMARY KEY (owner_address, peer_address),
+			INDEX (peer_address)
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create key_bundles table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS key_bundles (
+			user_address VARCHAR(46) PRIMARY KEY,
+			identity_key VARBINARY(32) NOT NULL,
+			signed_prekey_id INT UNSIGNED NOT NULL,
+			signed_prekey VARBINARY(32) NOT NULL,
+			signed_prekey_signature VARBINARY(64) NOT NULL,
+			signed_prekey_updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_address) REFERENCES users(address) ON DELETE CASCADE
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create one_time_prekeys table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS one_time_prekeys (
+			user_address VARCHAR(46) NOT NULL,
+			key_id INT UNSIGNED NOT NULL,
+			public_key VARBINARY(32) NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_address, key_id),
+			FOREIGN KEY (user_address) REFERENCES key_bundles(user_address) ON DELETE CASCADE
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create key_rotations table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS key_rotations (
+			id VARCHAR(64) PRIMARY KEY,
+			user_address VARCHAR(46) NOT NULL,
+			identity_fingerprint VARCHAR(64) NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			INDEX (user_address)
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create key_rotation_peers table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS key_rotation_peers (
+			rotation_id VARCHAR(64) NOT NULL,
+			peer_address VARCHAR(46) NOT NULL,
+			acknowledged_at TIMESTAMP NULL,
+			PRIMARY KEY (rotation_id, peer_address),
+			INDEX (peer_address),
+			FOREIGN KEY (rotation_id) REFERENCES key_rotations(id) ON DELETE CASCADE
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create broadcast_lists table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS broadcast_lists (
+			id VARCHAR(64) PRIMARY KEY,
+			owner_address VARCHAR(46) NOT NULL,
+			name VARCHAR(100) NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			INDEX (owner_address)
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create broadcast_recipients table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS broadcast_recipients (
+			list_id VARCHAR(64) NOT NULL,
+			recipient_address VARCHAR(46) NOT NULL,
+			added_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (list_id, recipient_address),
+			FOREIGN KEY (list_id) REFERENCES broadcast_lists(id) ON DELETE CASCADE
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create broadcast_messages table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS broadcast_messages (
+			id VARCHAR(64) PRIMARY KEY,
+			list_id VARCHAR(64) NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			INDEX (list_id, created_at),
+			FOREIGN KEY (list_id) REFERENCES broadcast_lists(id) ON DELETE CASCADE
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create broadcast_deliveries table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS broadcast_deliveries (
+			broadcast_id VARCHAR(64) NOT NULL,
+			recipient_address VARCHAR(46) NOT NULL,
+			message_id VARCHAR(64) NULL,
+			error VARCHAR(255) NULL,
+			PRIMARY KEY (broadcast_id, recipient_address),
+			FOREIGN KEY (broadcast_id) REFERENCES broadcast_messages(id) ON DELETE CASCADE
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create collab_docs table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS collab_docs (
+			id VARCHAR(64) PRIMARY KEY,
+			conversation_type ENUM('direct', 'group', 'channel') NOT NULL,
+			conversation_id VARCHAR(64) NOT NULL,
+			creator_address VARCHAR(46) NOT NULL,
+			version BIGINT NOT NULL DEFAULT 0,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			INDEX (conversation_type, conversation_id),
+			INDEX (creator_address)
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create collab_doc_updates table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS collab_doc_updates (
+			doc_id VARCHAR(64) NOT NULL,
+			version BIGINT NOT NULL,
+			sender_address VARCHAR(46) NOT NULL,
+			content MEDIUMBLOB NOT NULL,
+			created_at TIMESTAMP(6) DEFAULT CURRENT_TIMESTAMP(6),
+			PRIMARY KEY (doc_id, version),
+			FOREIGN KEY (doc_id) REFERENCES collab_docs(id) ON DELETE CASCADE
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create polls table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS polls (
+			id VARCHAR(64) PRIMARY KEY,
+			conversation_type ENUM('group', 'channel') NOT NULL,
+			conversation_id VARCHAR(64) NOT NULL,
+			message_id VARCHAR(64) NOT NULL UNIQUE,
+			creator_address VARCHAR(46) NOT NULL,
+			question VARCHAR(300) NOT NULL,
+			anonymous BOOLEAN NOT NULL DEFAULT FALSE,
+			closed_at TIMESTAMP NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			INDEX (conversation_type, conversation_id, created_at)
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create poll_options table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS poll_options (
+			poll_id VARCHAR(64) NOT NULL,
+			position INT NOT NULL,
+			text VARCHAR(100) NOT NULL,
+			PRIMARY KEY (poll_id, position),
+			FOREIGN KEY (poll_id) REFERENCES polls(id) ON DELETE CASCADE
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create poll_votes table. The primary key allows one vote per member.
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS poll_votes (
+			poll_id VARCHAR(64) NOT NULL,
+			voter_address VARCHAR(46) NOT NULL,
+			option_position INT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (poll_id, voter_address),
+			FOREIGN KEY (poll_id) REFERENCES polls(id) ON DELETE CASCADE
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create mutes table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS mutes (
+			user_address VARCHAR(46) NOT NULL,
+			conversation_type ENUM('direct', 'group', 'channel') NOT NULL,
+			conversation_id VARCHAR(64) NOT NULL,
+			muted_until TIMESTAMP NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_address, conversation_type, conversation_id),
+			INDEX (conversation_type, conversation_id),
+			FOREIGN KEY (user_address) REFERENCES users(address) ON DELETE CASCADE
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create do_not_disturb table
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS do_not_disturb (
+			user_address VARCHAR(46) PRIMARY KEY,
+			dnd_until TIMESTAMP NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_address) REFERENCES users(address) ON DELETE CASCADE
+		) ENGINE=InnoDB ROW_FORMAT=DYNAMIC
+	`)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -0,0 +1,43 @@
+// Package dbtest connects tests and benchmarks to a scratch MySQL database.
+// Initializing it drops and recreates every table, so never point it at a
+// database whose data matters.
+package dbtest
+
+import (
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/piko/piko/config"
+	"github.com/piko/piko/database"
+)
+
+// EnvDSN names the environment variable holding the scratch database's
+// connection string, e.g. root:secret@tcp(127.0.0.1:3306)/piko_test?parseTime=true
+const EnvDSN = "PIKO_TEST_DSN"
+
+var (
+	once    sync.Once
+	initErr error
+)
+
+// Open initializes database.DB against the scratch database, or skips tb if
+// PIKO_TEST_DSN isn't set. The schema is recreated once per test binary, so
+// data written by earlier tests in the same package is still there.
+func Open(tb testing.TB) {
+	tb.Helper()
+
+	dsn := os.Getenv(EnvDSN)
+	if dsn == "" {
+		tb.Skipf("%s is not set", EnvDSN)
+	}
+
+	once.Do(func() {
+		cfg := config.DefaultConfig().Database
+		cfg.ConnectionString = dsn
+		initErr = database.Initialize(cfg)
+	})
+	if initErr != nil {
+		tb.Fatalf("Failed to initialize test database: %v", initErr)
+	}
+}
@@ -31,7 +31,7 @@ CREATE TABLE IF NOT EXISTS messages (
     id VARCHAR(64) PRIMARY KEY,
     sender_address VARCHAR(46) NOT NULL,
     recipient_address VARCHAR(46) NOT NULL,
-    encrypted_content BLOB NOT NULL,
+    encrypted_content MEDIUMBLOB NOT NULL,
     timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     status ENUM('pending', 'delivered', 'read') DEFAULT 'pending',
     expiration_time TIMESTAMP NULL,
@@ -64,7 +64,7 @@ CREATE TABLE IF NOT EXISTS channel_messages (
     id VARCHAR(64) PRIMARY KEY,
     channel_id VARCHAR(64) NOT NULL,
     sender_address VARCHAR(46) NOT NULL,
-    encrypted_content BLOB NOT NULL,
+    encrypted_content MEDIUMBLOB NOT NULL,
     timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     block_id VARCHAR(64) NULL,
     INDEX (channel_id),
@@ -99,7 +99,7 @@ CREATE TABLE IF NOT EXISTS group_messages (
     id VARCHAR(64) PRIMARY KEY,
     group_id VARCHAR(64) NOT NULL,
     sender_address VARCHAR(46) NOT NULL,
-    content BLOB NOT NULL,
+    content MEDIUMBLOB NOT NULL,
     timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     block_id VARCHAR(64) NULL,
     INDEX (group_id),
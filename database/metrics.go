@@ -0,0 +1,37 @@
+package database
+
+import (
+	"database/sql"
+
+	"github.com/piko/piko/metrics"
+)
+
+func init() {
+	metrics.NewGaugeFunc("piko_db_open_connections", "Open connections to the database, in use or idle.", func() float64 {
+		return float64(poolStats().OpenConnections)
+	})
+	metrics.NewGaugeFunc("piko_db_in_use_connections", "Database connections currently in use.", func() float64 {
+		return float64(poolStats().InUse)
+	})
+	metrics.NewGaugeFunc("piko_db_idle_connections", "Idle database connections.", func() float64 {
+		return float64(poolStats().Idle)
+	})
+	metrics.NewGaugeFunc("piko_db_max_open_connections", "Maximum number of open database connections.", func() float64 {
+		return float64(poolStats().MaxOpenConnections)
+	})
+	metrics.NewCounterFunc("piko_db_wait_count_total", "Times a query waited for a free database connection.", func() float64 {
+		return float64(poolStats().WaitCount)
+	})
+	metrics.NewCounterFunc("piko_db_wait_duration_seconds_total", "Time spent waiting for a free database connection.", func() float64 {
+		return poolStats().WaitDuration.Seconds()
+	})
+}
+
+// poolStats returns the connection pool statistics, which are all zero
+// before the database is initialized
+func poolStats() sql.DBStats {
+	if DB == nil {
+		return sql.DBStats{}
+	}
+	return DB.Stats()
+}
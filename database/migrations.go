@@ -0,0 +1,46 @@
+package database
+
+import (
+	"fmt"
+)
+
+// blobColumn is a column holding encrypted content
+type blobColumn struct {
+	table  string
+	column string
+}
+
+// contentColumns hold encrypted message content. BLOB caps at 64KB, which
+// base64-decoded message payloads can exceed, so they are MEDIUMBLOB (16MB).
+var contentColumns = []blobColumn{
+	{"messages", "encrypted_content"},
+	{"message_edits", "encrypted_content"},
+	{"channel_messages", "encrypted_content"},
+	{"group_messages", "content"},
+	{"secret_chat_messages", "encrypted_content"},
+}
+
+// migrateBlobColumns widens any content column still created as BLOB to
+// MEDIUMBLOB, for databases set up from older schemas
+func migrateBlobColumns() error {
+	for _, col := range contentColumns {
+		var dataType string
+		err := DB.QueryRow(
+			`SELECT DATA_TYPE FROM information_schema.COLUMNS
+			WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND COLUMN_NAME = ?`,
+			col.table, col.column,
+		).Scan(&dataType)
+		if err != nil {
+			return fmt.Errorf("failed to inspect %s.%s: %w", col.table, col.column, err)
+		}
+		if dataType != "blob" {
+			continue
+		}
+
+		_, err = DB.Exec(fmt.Sprintf("ALTER TABLE %s MODIFY %s MEDIUMBLOB NOT NULL", col.table, col.column))
+		if err != nil {
+			return fmt.Errorf("failed to widen %s.%s: %w", col.table, col.column, err)
+		}
+	}
+	return nil
+}
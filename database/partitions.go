@@ -0,0 +1,326 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/piko/piko/config"
+)
+
+// PartitionedTables are the message tables split into monthly partitions
+// when partitioning is enabled
+var PartitionedTables = []string{"messages", "channel_messages", "group_messages"}
+
+const (
+	// partitionPrefix starts every monthly partition name, e.g. p202406
+	partitionPrefix = "p"
+
+	// partitionOverflow catches rows newer than the last monthly partition
+	partitionOverflow = "pmax"
+)
+
+var (
+	// partitionsMu guards partitions
+	partitionsMu sync.RWMutex
+
+	// partitions records the monthly partitions that exist for each table.
+	// It is nil when partitioning is disabled.
+	partitions map[string]map[string]bool
+)
+
+// PartitionFor returns the name of the monthly partition holding rows
+// timestamped at t
+func PartitionFor(t time.Time) string {
+	return partitionPrefix + t.UTC().Format("200601")
+}
+
+// PartitionsBetween returns the monthly partitions covering [from, to]
+func PartitionsBetween(from, to time.Time) []string {
+	from, to = monthStart(from), monthStart(to)
+	names := []string{}
+	for month := from; !month.After(to); month = month.AddDate(0, 1, 0) {
+		names = append(names, PartitionFor(month))
+	}
+	return names
+}
+
+// PartitionSelection returns a PARTITION clause restricting a query on table
+// to the partitions covering [from, to], for use right after the table name.
+// A zero from leaves the range open towards the past. It returns an empty
+// string when the table isn't partitioned or none of those partitions exist,
+// so callers can always append it.
+func PartitionSelection(table string, from, to time.Time) string {
+	partitionsMu.RLock()
+	defer partitionsMu.RUnlock()
+
+	existing, ok := partitions[table]
+	if !ok {
+		return ""
+	}
+
+	// The oldest partition also holds the rows from before partitioning
+	// started, so it is selected for any range reaching into it
+	first, last := "", PartitionFor(to)
+	if !from.IsZero() {
+		first = PartitionFor(from)
+	}
+	names := []string{}
+	for name := range existing {
+		if name >= first && name <= last {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+	// Rows past the newest monthly partition live in the overflow partition
+	if !to.Before(lastPartitionEnd(existing)) {
+		names = append(names, partitionOverflow)
+	}
+	return fmt.Sprintf(" PARTITION (%s)", strings.Join(names, ", "))
+}
+
+// SetPartitions records the monthly partitions that exist for a table, as
+// read from MySQL when partitioning is enabled. Without names the table is
+// treated as not partitioned.
+func SetPartitions(table string, names []string) {
+	partitionsMu.Lock()
+	defer partitionsMu.Unlock()
+
+	if len(names) == 0 {
+		delete(partitions, table)
+		return
+	}
+	existing := make(map[string]bool, len(names))
+	for _, name := range names {
+		existing[name] = true
+	}
+	if partitions == nil {
+		partitions = make(map[string]map[string]bool)
+	}
+	partitions[table] = existing
+}
+
+// initPartitions converts the message tables to monthly range partitioning.
+// Partitioned InnoDB tables can't have foreign keys and need the partition
+// column in the primary key, so both are adjusted first.
+func initPartitions(cfg config.PartitioningConfig) error {
+	partitionsMu.Lock()
+	partitions = make(map[string]map[string]bool)
+	partitionsMu.Unlock()
+
+	now := time.Now()
+	for _, table := range PartitionedTables {
+		if err := dropForeignKeys(table); err != nil {
+			return fmt.Errorf("failed to drop foreign keys on %s: %w", table, err)
+		}
+
+		_, err := DB.Exec(fmt.Sprintf(
+			"ALTER TABLE %s MODIFY timestamp TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, DROP PRIMARY KEY, ADD PRIMARY KEY (id, timestamp), ADD INDEX (id)",
+			table,
+		))
+		if err != nil {
+			return fmt.Errorf("failed to prepare %s for partitioning: %w", table, err)
+		}
+
+		months := PartitionsBetween(now, now.AddDate(0, cfg.MonthsAhead, 0))
+		definitions := make([]string, 0, len(months)+1)
+		for _, name := range months {
+			definitions = append(definitions, partitionDefinition(name))
+		}
+		definitions = append(definitions, fmt.Sprintf("PARTITION %s VALUES LESS THAN MAXVALUE", partitionOverflow))
+
+		_, err = DB.Exec(fmt.Sprintf(
+			"ALTER TABLE %s PARTITION BY RANGE (UNIX_TIMESTAMP(timestamp)) (%s)",
+			table, strings.Join(definitions, ", "),
+		))
+		if err != nil {
+			return fmt.Errorf("failed to partition %s: %w", table, err)
+		}
+
+		if err := loadPartitions(table); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MaintainPartitions makes sure every partitioned table has partitions for
+// the configured number of months ahead and, when a retention period is set,
+// drops partitions older than it
+func MaintainPartitions(cfg config.PartitioningConfig) error {
+	if DB == nil {
+		return ErrNotInitialized
+	}
+
+	now := time.Now()
+	for _, table := range PartitionedTables {
+		if err := loadPartitions(table); err != nil {
+			return err
+		}
+
+		partitionsMu.RLock()
+		existing := partitions[table]
+		missing := []string{}
+		for _, name := range PartitionsBetween(now, now.AddDate(0, cfg.MonthsAhead, 0)) {
+			if !existing[name] {
+				missing = append(missing, name)
+			}
+		}
+		expired := []string{}
+		if cfg.RetentionMonths > 0 {
+			cutoff := PartitionFor(now.AddDate(0, -cfg.RetentionMonths, 0))
+			for name := range existing {
+				if name < cutoff {
+					expired = append(expired, name)
+				}
+			}
+		}
+		partitionsMu.RUnlock()
+
+		if len(missing) > 0 {
+			// Split the new months off the overflow partition
+			definitions := make([]string, 0, len(missing)+1)
+			for _, name := range missing {
+				definitions = append(definitions, partitionDefinition(name))
+			}
+			definitions = append(definitions, fmt.Sprintf("PARTITION %s VALUES LESS THAN MAXVALUE", partitionOverflow))
+
+			_, err := DB.Exec(fmt.Sprintf(
+				"ALTER TABLE %s REORGANIZE PARTITION %s INTO (%s)",
+				table, partitionOverflow, strings.Join(definitions, ", "),
+			))
+			if err != nil {
+				return fmt.Errorf("failed to add partitions to %s: %w", table, err)
+			}
+		}
+
+		if len(expired) > 0 {
+			sort.Strings(expired)
+			_, err := DB.Exec(fmt.Sprintf("ALTER TABLE %s DROP PARTITION %s", table, strings.Join(expired, ", ")))
+			if err != nil {
+				return fmt.Errorf("failed to drop expired partitions from %s: %w", table, err)
+			}
+		}
+
+		if len(missing) > 0 || len(expired) > 0 {
+			if err := loadPartitions(table); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// RunPartitionMaintenance is a background task that periodically calls
+// MaintainPartitions
+func RunPartitionMaintenance(cfg config.PartitioningConfig) {
+	if !cfg.Enabled || cfg.MaintenanceInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(cfg.MaintenanceInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := MaintainPartitions(cfg); err != nil {
+			log.Printf("Partition maintenance failed: %v", err)
+		}
+	}
+}
+
+// loadPartitions refreshes the recorded monthly partitions of a table
+func loadPartitions(table string) error {
+	rows, err := DB.Query(
+		`SELECT PARTITION_NAME FROM information_schema.PARTITIONS
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND PARTITION_NAME IS NOT NULL`,
+		table,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to list partitions of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		if name != partitionOverflow {
+			names = append(names, name)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	SetPartitions(table, names)
+	return nil
+}
+
+// dropForeignKeys removes every foreign key declared on a table
+func dropForeignKeys(table string) error {
+	rows, err := DB.Query(
+		`SELECT CONSTRAINT_NAME FROM information_schema.TABLE_CONSTRAINTS
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND CONSTRAINT_TYPE = 'FOREIGN KEY'`,
+		table,
+	)
+	if err != nil {
+		return err
+	}
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if _, err := DB.Exec(fmt.Sprintf("ALTER TABLE %s DROP FOREIGN KEY %s", table, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// partitionDefinition returns the definition of a monthly partition, which
+// holds rows up to the start of the following month
+func partitionDefinition(name string) string {
+	month, _ := time.Parse("200601", strings.TrimPrefix(name, partitionPrefix))
+	end := month.AddDate(0, 1, 0)
+	return fmt.Sprintf("PARTITION %s VALUES LESS THAN (%d)", name, end.Unix())
+}
+
+// lastPartitionEnd returns the time at which the newest monthly partition ends
+func lastPartitionEnd(existing map[string]bool) time.Time {
+	last := ""
+	for name := range existing {
+		if name > last {
+			last = name
+		}
+	}
+	month, _ := time.Parse("200601", strings.TrimPrefix(last, partitionPrefix))
+	return month.AddDate(0, 1, 0)
+}
+
+// monthStart truncates t to the first instant of its month in UTC
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
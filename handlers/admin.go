@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/config"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/utils"
+	"github.com/piko/piko/websocket"
+)
+
+// adminConfig holds the phone numbers of server operators
+var adminConfig = config.DefaultConfig().Admin
+
+// InitAdmin configures which users get the admin role
+func InitAdmin(cfg config.AdminConfig) {
+	adminConfig = cfg
+}
+
+// isAdminPhone checks if a phone number belongs to a configured operator
+func isAdminPhone(phone string) bool {
+	for _, adminPhone := range adminConfig.Phones {
+		if adminPhone == phone {
+			return true
+		}
+	}
+	return false
+}
+
+// AdminStatsResponse represents the live stats shown on the admin dashboard
+type AdminStatsResponse struct {
+	models.ServerStats
+	ConnectedClients  int `json:"connected_clients"`
+	SecretChatClients int `json:"secret_chat_clients"`
+	BlockchainHeight  int `json:"blockchain_height"`
+}
+
+// ResolveReportRequest represents an admin's decision on a report
+type ResolveReportRequest struct {
+	Status models.ReportStatus `json:"status"`
+}
+
+// GetAdminStats handles getting live server stats
+func GetAdminStats() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		stats, err := models.GetServerStats(c.UserContext())
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get server stats")
+		}
+
+		response := AdminStatsResponse{
+			ServerStats:       *stats,
+			ConnectedClients:  websocket.ClientCount(WebSocketPool),
+			SecretChatClients: websocket.ClientCount(SecretChatPool),
+		}
+		if latest, err := models.GetLatestBlock(c.UserContext()); err == nil {
+			response.BlockchainHeight = latest.Height
+		}
+
+		return utils.OKResponse(c, response)
+	}
+}
+
+// AdminConnectionsResponse counts the clients connected to this instance
+type AdminConnectionsResponse struct {
+	Clients           int  `json:"clients"`
+	SecretChatClients int  `json:"secret_chat_clients"`
+	Draining          bool `json:"draining"`
+}
+
+// GetAdminConnections handles counting the WebSocket connections of this
+// instance
+func GetAdminConnections() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return utils.OKResponse(c, AdminConnectionsResponse{
+			Clients:           websocket.ClientCount(WebSocketPool),
+			SecretChatClients: websocket.ClientCount(SecretChatPool),
+			Draining:          WebSocketPool.IsDraining(),
+		})
+	}
+}
+
+// GetAdminBlockchain handles getting blockchain stats along with the
+// height and ID of the latest block
+func GetAdminBlockchain() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		stats, err := models.GetBlockchainStats(c.UserContext())
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get blockchain stats")
+		}
+
+		latest, err := models.GetLatestBlock(c.UserContext())
+		switch {
+		case err == nil:
+			stats["height"] = latest.Height
+			stats["latest_block_id"] = latest.ID
+		case !errors.Is(err, models.ErrBlockNotFound):
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get latest block")
+		}
+
+		return utils.OKResponse(c, stats)
+	}
+}
+
+// GetAdminBlocks handles getting the most recent blocks
+func GetAdminBlocks() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		pagination := utils.GetPaginationParams(c)
+
+		blocks, err := models.GetRecentBlocks(c.UserContext(), pagination.Limit)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get blocks")
+		}
+
+		return utils.OKResponse(c, blocks)
+	}
+}
+
+// GetAdminClients handles listing the clients connected over WebSocket.
+// Secret chat clients are only counted, never listed.
+func GetAdminClients() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return utils.OKResponse(c, websocket.ConnectedClients(WebSocketPool))
+	}
+}
+
+// GetAdminReports handles listing the moderation queue
+func GetAdminReports() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		status := models.ReportStatus(c.Query("status", string(models.ReportStatusOpen)))
+		switch status {
+		case models.ReportStatusOpen, models.ReportStatusResolved, models.ReportStatusDismissed:
+		default:
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid report status")
+		}
+		pagination := utils.GetPaginationParams(c)
+
+		reports, err := models.GetReports(c.UserContext(), status, pagination.Limit)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get reports")
+		}
+
+		return utils.OKResponse(c, reports)
+	}
+}
+
+// ResolveReport handles closing a report from the moderation queue
+func ResolveReport() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		adminAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		id, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid report ID")
+		}
+
+		req := new(ResolveReportRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+		if req.Status != models.ReportStatusResolved && req.Status != models.ReportStatusDismissed {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Status must be resolved or dismissed")
+		}
+
+		if err := models.CloseReport(c.UserContext(), id, req.Status, adminAddress); err != nil {
+			if errors.Is(err, models.ErrReportNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeReportNotFound, "Open report not found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to update report")
+		}
+
+		return utils.OKResponse(c, fiber.Map{
+			"message": localized(c, "Report updated successfully"),
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/types"
+	"github.com/piko/piko/utils"
+	"github.com/piko/piko/websocket"
+)
+
+// AdminUserResponse is what operators see of an account
+type AdminUserResponse struct {
+	ID             int         `json:"id"`
+	Address        string      `json:"address"`
+	Phone          string      `json:"phone"`
+	Username       string      `json:"username,omitempty"`
+	Role           string      `json:"role"`
+	CreatedAt      types.Time  `json:"created_at"`
+	LastSeenAt     *types.Time `json:"last_seen_at,omitempty"`
+	Online         bool        `json:"online"`
+	ActiveSessions int         `json:"active_sessions"`
+	FrozenUntil    *types.Time `json:"frozen_until,omitempty"`
+	// Suspension is set while the account is suspended
+	Suspension *models.AccountSuspension `json:"suspension,omitempty"`
+	LegalHold  bool                      `json:"legal_hold"`
+}
+
+// SuspendAccountRequest represents a request to suspend an account
+type SuspendAccountRequest struct {
+	Reason string `json:"reason"`
+}
+
+// maxSuspensionReasonLength matches the reason column
+const maxSuspensionReasonLength = 500
+
+// recordOperatorAudit records an action taken through the operator
+// endpoints of the admin API
+func recordOperatorAudit(c *fiber.Ctx, action, target, details string) {
+	if err := models.RecordAudit(c.UserContext(), &models.AuditEntry{
+		ActorAddress: middleware.GetOperator(c),
+		Action:       action,
+		Target:       target,
+		IPAddress:    c.IP(),
+		Details:      details,
+	}); err != nil {
+		log.Printf("Error recording audit entry: %v", err)
+	}
+}
+
+// LookupUser handles an operator finding an account by exactly one of its
+// address, phone number or username
+func LookupUser() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		address, phone, username := c.Query("address"), c.Query("phone"), c.Query("username")
+
+		var user *models.User
+		var err error
+		switch {
+		case address != "" && phone == "" && username == "":
+			user, err = stores.Users.GetByAddress(c.UserContext(), address)
+		case phone != "" && address == "" && username == "":
+			user, err = stores.Users.GetByPhone(c.UserContext(), phone)
+		case username != "" && address == "" && phone == "":
+			user, err = stores.Users.GetByUsername(c.UserContext(), username)
+		default:
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Exactly one of address, phone or username is required")
+		}
+		if err != nil {
+			if errors.Is(err, models.ErrUserNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeUserNotFound, "User not found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get user")
+		}
+
+		response, err := adminUserResponse(c, user)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get user")
+		}
+
+		recordOperatorAudit(c, models.AuditActionUserLookup, user.Address, "")
+		return utils.OKResponse(c, response)
+	}
+}
+
+// adminUserResponse collects what operators see of an account
+func adminUserResponse(c *fiber.Ctx, user *models.User) (*AdminUserResponse, error) {
+	ctx := c.UserContext()
+	response := &AdminUserResponse{
+		ID:        user.ID,
+		Address:   user.Address,
+		Phone:     user.Phone,
+		Username:  user.Username,
+		Role:      string(user.Role),
+		CreatedAt: user.CreatedAt,
+		Online:    websocket.IsUserOnline(WebSocketPool, user.Address),
+	}
+
+	var err error
+	if response.LastSeenAt, err = models.GetLastSeen(ctx, user.Address); err != nil {
+		return nil, err
+	}
+	sessions, err := models.GetUserSessions(ctx, user.Address)
+	if err != nil {
+		return nil, err
+	}
+	response.ActiveSessions = len(sessions)
+	frozenUntil, err := models.GetAccountFrozenUntil(ctx, user.Address)
+	if err != nil {
+		return nil, err
+	}
+	if frozenUntil != nil {
+		until := types.NewTime(*frozenUntil)
+		response.FrozenUntil = &until
+	}
+	if response.Suspension, err = models.GetAccountSuspension(ctx, user.Address); err != nil {
+		return nil, err
+	}
+	switch err := stores.LegalHolds.Check(ctx, user.Address); {
+	case errors.Is(err, models.ErrUnderLegalHold):
+		response.LegalHold = true
+	case err != nil:
+		return nil, err
+	}
+	return response, nil
+}
+
+// SuspendAccount handles an operator suspending an account. Every session
+// is signed out and new logins are refused until the suspension is lifted.
+func SuspendAccount() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		address := c.Params("address")
+		if address == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "User address is required")
+		}
+		if operatorAddress, ok := middleware.GetUserAddress(c); ok && operatorAddress == address {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "You cannot suspend your own account")
+		}
+
+		req := new(SuspendAccountRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+		if req.Reason == "" || len(req.Reason) > maxSuspensionReasonLength {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, fmt.Sprintf("Reason is required and must be at most %d characters", maxSuspensionReasonLength))
+		}
+
+		if _, err := stores.Users.GetByAddress(c.UserContext(), address); err != nil {
+			if errors.Is(err, models.ErrUserNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeUserNotFound, "User not found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get user")
+		}
+
+		err := models.SuspendAccount(c.UserContext(), &models.AccountSuspension{
+			UserAddress: address,
+			Reason:      req.Reason,
+			SuspendedBy: middleware.GetOperator(c),
+		})
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to suspend account")
+		}
+
+		revoked, err := models.RevokeOtherSessions(c.UserContext(), address, "", models.SessionRevokedSuspension)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to revoke sessions")
+		}
+
+		recordOperatorAudit(c, models.AuditActionAccountSuspend, address, req.Reason)
+
+		// Tell a connected device and disconnect it
+		websocket.NotifyAccountSuspended(WebSocketPool, address, req.Reason)
+		go forgetSessionDevices(revoked)
+
+		suspension, err := models.GetAccountSuspension(c.UserContext(), address)
+		if err != nil || suspension == nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get suspension")
+		}
+		return utils.OKResponse(c, suspension)
+	}
+}
+
+// UnsuspendAccount handles an operator lifting an account's suspension
+func UnsuspendAccount() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		address := c.Params("address")
+		if address == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "User address is required")
+		}
+
+		if err := models.LiftAccountSuspension(c.UserContext(), address); err != nil {
+			if errors.Is(err, models.ErrAccountSuspensionNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeSuspensionNotFound, "Account is not suspended")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to lift suspension")
+		}
+
+		recordOperatorAudit(c, models.AuditActionAccountUnsuspend, address, "")
+
+		return utils.OKResponse(c, fiber.Map{
+			"message": localized(c, "Suspension lifted"),
+		})
+	}
+}
+
+// PurgeUserMessages handles an operator deleting every message an account
+// sent. Accounts under legal hold can't be purged.
+func PurgeUserMessages() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		address := c.Params("address")
+		if address == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "User address is required")
+		}
+		if rejected, err := rejectLegalHold(c, address); rejected {
+			return err
+		}
+
+		purged, err := models.PurgeUserMessages(c.UserContext(), address)
+		if err != nil {
+			// A hold may have been placed since the check
+			if errors.Is(err, models.ErrUnderLegalHold) {
+				return utils.ErrorResponse(c, fiber.StatusLocked, utils.CodeLegalHold, "This data is under legal hold and cannot be deleted")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to purge messages")
+		}
+
+		recordOperatorAudit(c, models.AuditActionMessagePurge, address,
+			fmt.Sprintf("%d direct, %d group, %d channel", purged.Direct, purged.Group, purged.Channel))
+
+		return utils.OKResponse(c, purged)
+	}
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/config"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/utils"
+)
+
+// ageGateConfig holds the registration age checks
+var ageGateConfig = config.DefaultConfig().AgeGate
+
+// InitAgeGate configures the registration age checks and restricted mode
+func InitAgeGate(cfg config.AgeGateConfig) {
+	ageGateConfig = cfg
+}
+
+// parseBirthdate checks a registering user's birthdate (YYYY-MM-DD) against
+// the age gate, writing a 400 or 403 response when it fails
+func parseBirthdate(c *fiber.Ctx, value string) (*time.Time, bool, error) {
+	if value == "" {
+		if ageGateConfig.RequireBirthdate {
+			return nil, true, utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Birthdate is required")
+		}
+		return nil, false, nil
+	}
+
+	birthdate, err := time.Parse("2006-01-02", value)
+	if err != nil || birthdate.After(clock.Now()) {
+		return nil, true, utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Birthdate must be a past date in YYYY-MM-DD format")
+	}
+
+	if ageGateConfig.MinimumAge > 0 && models.AgeOn(birthdate, clock.Now()) < ageGateConfig.MinimumAge {
+		return nil, true, utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeAgeRequirementNotMet, fmt.Sprintf("You must be at least %d years old to register", ageGateConfig.MinimumAge))
+	}
+	return &birthdate, false, nil
+}
+
+// isRestricted checks if a user is young enough to be in restricted mode.
+// Users who didn't give a birthdate aren't restricted.
+func isRestricted(user *models.User) bool {
+	age, known := user.AgeOn(clock.Now())
+	return known && age < ageGateConfig.RestrictedAge
+}
+
+// rejectRestricted writes a 403 response when the signed-in user is in
+// restricted mode. Anonymous requests are let through.
+func rejectRestricted(c *fiber.Ctx) (bool, error) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return false, nil
+	}
+
+	user, err := stores.Users.GetByID(c.UserContext(), userID)
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return false, nil
+		}
+		return true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get user")
+	}
+	if isRestricted(user) {
+		return true, utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeRestrictedMode, "This feature isn't available in restricted mode")
+	}
+	return false, nil
+}
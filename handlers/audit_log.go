@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/utils"
+)
+
+// recordConversationAudit records an administrative action taken in a
+// group or channel. Failures are logged, since the action already happened.
+func recordConversationAudit(c *fiber.Ctx, conversationType models.ConversationType, conversationID, action, target, details string) {
+	actorAddress, _ := middleware.GetUserAddress(c)
+	if err := models.RecordAudit(c.UserContext(), &models.AuditEntry{
+		ActorAddress:     actorAddress,
+		Action:           action,
+		Target:           target,
+		IPAddress:        c.IP(),
+		Details:          details,
+		ConversationType: conversationType,
+		ConversationID:   conversationID,
+	}); err != nil {
+		log.Printf("Error recording audit entry: %v", err)
+	}
+}
+
+// GetGroupAuditLog handles a group admin reviewing the administrative
+// actions taken in the group, newest first
+func GetGroupAuditLog() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		groupID := c.Params("id")
+		isAdmin, rejected, err := rejectNonGroupMember(c, groupID, userAddress)
+		if rejected {
+			return err
+		}
+		if !isAdmin {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotGroupAdmin, "You are not an admin of this group")
+		}
+
+		return conversationAuditLog(c, models.ConversationGroup, groupID)
+	}
+}
+
+// GetChannelAuditLog handles a channel owner or admin reviewing the
+// administrative actions taken in the channel, newest first
+func GetChannelAuditLog() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		channelID := c.Params("id")
+		role, err := models.GetChannelRole(c.UserContext(), channelID, userAddress)
+		if err != nil {
+			if errors.Is(err, models.ErrUserNotInChannel) {
+				return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotChannelMember, "You are not a member of this channel")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check channel membership")
+		}
+		if !role.CanManage() {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotChannelAdmin, "Only channel owners and admins can view the audit log")
+		}
+
+		return conversationAuditLog(c, models.ConversationChannel, channelID)
+	}
+}
+
+// conversationAuditLog responds with a page of a group's or channel's audit log
+func conversationAuditLog(c *fiber.Ctx, conversationType models.ConversationType, conversationID string) error {
+	pagination := utils.GetPaginationParams(c)
+
+	entries, err := models.GetConversationAuditLog(c.UserContext(), conversationType, conversationID, pagination.Limit, pagination.CalculateOffset())
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get audit log")
+	}
+
+	return utils.OKResponse(c, entries)
+}
@@ -1,39 +1,78 @@
 package handlers
 
 import (
+	"bytes"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/clock"
 	"github.com/piko/piko/config"
 	"github.com/piko/piko/crypto"
 	"github.com/piko/piko/middleware"
 	"github.com/piko/piko/models"
+	"github.com/piko/piko/types"
 	"github.com/piko/piko/utils"
 )
 
+// UpdateProfileRequest represents a request to update the user's profile
+type UpdateProfileRequest struct {
+	Phone string `json:"phone,omitempty"`
+	Email string `json:"email,omitempty"`
+}
+
 // RegisterRequest represents a registration request
 type RegisterRequest struct {
 	Phone string `json:"phone"`
+	// Channel is how the OTP is sent: "sms" (the default) or "email"
+	Channel string `json:"channel,omitempty"`
+	// Email receives the OTP with the "email" channel, and is saved on the
+	// account once verified
+	Email string `json:"email,omitempty"`
+	// Challenge and Solution answer a proof-of-work registration challenge
+	Challenge string `json:"challenge,omitempty"`
+	Solution  string `json:"solution,omitempty"`
+	// CaptchaToken answers a CAPTCHA registration challenge
+	CaptchaToken string `json:"captcha_token,omitempty"`
 }
 
 // VerifyOTPRequest represents an OTP verification request
 type VerifyOTPRequest struct {
 	Phone string `json:"phone"`
 	Code  string `json:"code"`
+	// AcceptedPolicies are the IDs of the current policies a new user
+	// accepts when registering
+	AcceptedPolicies []int `json:"accepted_policies,omitempty"`
+	// Birthdate (YYYY-MM-DD) is optional unless the server requires it, and
+	// only used when registering
+	Birthdate string `json:"birthdate,omitempty"`
+	// PIN is the account PIN, needed when the account has one and the
+	// device hasn't signed in before
+	PIN string `json:"pin,omitempty"`
+	// DeviceToken is the token returned by an earlier sign in from this
+	// device
+	DeviceToken string `json:"device_token,omitempty"`
 }
 
 // LoginRequest represents a login request
 type LoginRequest struct {
 	Phone string `json:"phone"`
+	// Channel is how the OTP is sent: "sms" (the default) or "email", to
+	// the account's email address
+	Channel string `json:"channel,omitempty"`
 }
 
 // AuthResponse represents an authentication response
 type AuthResponse struct {
-	Token   string `json:"token"`
-	Address string `json:"address"`
+	Token     string `json:"token"`
+	Address   string `json:"address"`
+	SessionID string `json:"session_id"`
+	// DeviceToken lets the device skip the account PIN when it signs in
+	// again with a phone code
+	DeviceToken string `json:"device_token,omitempty"`
 }
 
 // Register handles user registration - Step 1: Send OTP
@@ -45,9 +84,7 @@ func Register(cfg *config.Config) fiber.Handler {
 		req := new(RegisterRequest)
 		if err := c.BodyParser(req); err != nil {
 			fmt.Printf("Error parsing request body: %v\n", err)
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid request body",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
 		}
 
 		fmt.Printf("Register request: phone=%s\n", req.Phone)
@@ -55,60 +92,64 @@ func Register(cfg *config.Config) fiber.Handler {
 		// Validate request
 		if req.Phone == "" {
 			fmt.Println("Phone number is required")
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Phone number is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Phone number is required")
 		}
 
 		// Validate phone number format
 		if !utils.IsValidPhone(req.Phone) {
 			fmt.Printf("Invalid phone number format: %s\n", req.Phone)
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid phone number format",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid phone number format")
+		}
+
+		if rejected, err := rejectInvalidOTPChannel(c, req.Channel); rejected {
+			return err
+		}
+		var email string
+		if req.Channel == otpChannelEmail {
+			var ok bool
+			if email, ok = normalizeEmail(req.Email); !ok {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "A valid email address is required")
+			}
+		}
+
+		// Keep bots from spending the SMS budget
+		if rejected, err := rejectUnverifiedRegistration(c, req); rejected {
+			return err
 		}
 
 		// Check if phone number already exists
-		_, err := models.GetUserByPhone(req.Phone)
+		_, err := stores.Users.GetByPhone(c.UserContext(), req.Phone)
 		if err == nil {
 			// User already exists, we'll let them log in instead
 			fmt.Printf("Phone number already registered: %s\n", req.Phone)
-			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
-				"error":  "Phone number already registered",
+			return utils.ErrorResponseWith(c, fiber.StatusConflict, utils.CodePhoneTaken, "Phone number already registered", fiber.Map{
 				"action": "login",
 			})
 		} else if !errors.Is(err, models.ErrUserNotFound) {
 			// Database error
 			fmt.Printf("Database error checking phone: %v\n", err)
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to check phone number",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check phone number")
 		}
 
 		// Generate OTP
 		fmt.Printf("Generating OTP for phone: %s\n", req.Phone)
-		otp, err := models.GenerateOTP(req.Phone, cfg.Auth.OTPExpiryMinutes)
+		otp, err := newOTP(c, cfg, req.Phone, email)
 		if err != nil {
 			fmt.Printf("Failed to generate OTP: %v\n", err)
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to generate OTP",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to generate OTP")
 		}
 
-		// Send OTP via SMS
+		// Send OTP via SMS or email, except to test phones
 		fmt.Printf("Sending OTP to phone: %s, code: %s\n", req.Phone, otp.Code)
-		err = utils.SendOTP(utils.FromConfigSMS(&cfg.SMS), req.Phone, otp.Code)
-		if err != nil {
+		if err := sendOTP(cfg, otp); err != nil {
 			fmt.Printf("Failed to send OTP: %v\n", err)
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to send OTP",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to send OTP")
 		}
 
 		fmt.Printf("OTP sent successfully to: %s\n", req.Phone)
 		// Return success
-		return c.Status(fiber.StatusOK).JSON(fiber.Map{
-			"message":    "OTP sent to your phone",
+		return utils.OKResponse(c, fiber.Map{
+			"message":    otpSentMessage(c, otp),
 			"expires_in": cfg.Auth.OTPExpiryMinutes,
 		})
 	}
@@ -120,129 +161,172 @@ func VerifyRegister(cfg *config.Config) fiber.Handler {
 		// Parse request body
 		req := new(VerifyOTPRequest)
 		if err := c.BodyParser(req); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid request body",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
 		}
 
 		// Validate request
 		if req.Phone == "" || req.Code == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Phone number and verification code are required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Phone number and verification code are required")
+		}
+
+		// New users must pass the age gate and accept the current policies.
+		// Checked before the OTP so the code isn't used up by a request that
+		// can't succeed.
+		var birthdate *time.Time
+		if _, err := stores.Users.GetByPhone(c.UserContext(), req.Phone); errors.Is(err, models.ErrUserNotFound) {
+			var rejected bool
+			if birthdate, rejected, err = parseBirthdate(c, req.Birthdate); rejected {
+				return err
+			}
+
+			missing, err := missingPolicies(c, req.AcceptedPolicies)
+			if err != nil {
+				return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get policies")
+			}
+			if len(missing) > 0 {
+				return utils.ErrorResponseWith(c, fiber.StatusUnavailableForLegalReasons, utils.CodePolicyAcceptanceRequired, middleware.ErrPolicyAcceptanceRequired.Error(), fiber.Map{
+					"policies": missing,
+				})
+			}
+		}
+
+		// Existing users signing in here need their PIN on new devices too,
+		// and can't sign in while their account is frozen or suspended
+		if rejected, err := rejectBlockedPhone(c, req.Phone); rejected {
+			return err
+		}
+		pin, rejected, err := rejectMissingPIN(c, req)
+		if rejected {
+			return err
 		}
 
 		// Verify OTP
-		verified, err := models.VerifyOTP(req.Phone, req.Code)
+		verified, err := models.VerifyOTP(c.UserContext(), req.Phone, req.Code)
 		if err != nil {
 			if errors.Is(err, models.ErrOTPNotFound) || errors.Is(err, models.ErrOTPExpired) || errors.Is(err, models.ErrOTPInvalid) {
-				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"error": err.Error(),
-				})
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, otpErrorCode(err), err.Error())
 			}
 			if errors.Is(err, models.ErrOTPMaxAttempts) {
-				return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-					"error": "Maximum verification attempts reached. Please request a new OTP.",
-				})
+				return utils.ErrorResponse(c, fiber.StatusTooManyRequests, utils.CodeOTPAttemptsExceeded, "Maximum verification attempts reached. Please request a new OTP.")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to verify OTP",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to verify OTP")
 		}
 
 		// If OTP verification failed, return an error and don't create the user
 		if !verified {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid verification code",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidVerificationCode, "Invalid verification code")
 		}
 
 		// OTP verification successful, now check if user already exists
-		existingUser, err := models.GetUserByPhone(req.Phone)
+		existingUser, err := stores.Users.GetByPhone(c.UserContext(), req.Phone)
 		if err == nil {
+			if pin != nil {
+				if rejected, err := rejectWrongPIN(c, cfg, pin, req.PIN); rejected {
+					return err
+				}
+			}
+
 			// User already exists, generate token and return
-			token, err := middleware.GenerateJWT(existingUser, cfg.Auth.JWTSecret, cfg.Auth.JWTExpirationTime)
+			token, sessionID, err := issueSessionToken(c, cfg, existingUser)
 			if err != nil {
-				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-					"error": "Failed to generate token",
-				})
+				return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to generate token")
+			}
+			deviceToken, err := issueDeviceToken(c, existingUser.Address, sessionID)
+			if err != nil {
+				return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to generate token")
 			}
 
-			return c.Status(fiber.StatusOK).JSON(AuthResponse{
-				Token:   token,
-				Address: existingUser.Address,
+			return utils.OKResponse(c, AuthResponse{
+				Token:       token,
+				Address:     existingUser.Address,
+				SessionID:   sessionID,
+				DeviceToken: deviceToken,
 			})
 		} else if !errors.Is(err, models.ErrUserNotFound) {
 			// Database error
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to check user",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check user")
 		}
 
 		// Generate key pair
 		keyPair, err := crypto.GenerateKeyPair()
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to generate key pair",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to generate key pair")
 		}
 
 		// Generate user address
 		address, err := crypto.GenerateAddress(keyPair.PublicKey, cfg.Crypto.AddressLength)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to generate address",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to generate address")
 		}
 
 		// Create a random password hash (not used for authentication, but needed for DB schema)
 		randomBytes, err := crypto.GenerateRandomBytes(16)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to generate random bytes",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to generate random bytes")
 		}
 		passwordHash := base64.StdEncoding.EncodeToString(randomBytes)
 
+		// An email the code was sent to has been verified with it
+		email, err := models.GetOTPEmail(c.UserContext(), req.Phone)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to verify OTP")
+		}
+
 		// Create user
 		user := &models.User{
 			Phone:        req.Phone,
 			PasswordHash: passwordHash,
 			PublicKey:    keyPair.PublicKey,
 			Address:      address,
+			Birthdate:    types.NewTimePtr(birthdate),
+			Email:        email,
+		}
+		if isAdminPhone(req.Phone) {
+			user.Role = models.UserRoleAdmin
 		}
-		err = models.CreateUser(user)
+		err = models.CreateUser(c.UserContext(), user)
 		if err != nil {
 			if errors.Is(err, models.ErrPhoneAlreadyExists) {
-				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
-					"error": "Phone number already exists",
-				})
+				return utils.ErrorResponse(c, fiber.StatusConflict, utils.CodePhoneTaken, "Phone number already exists")
 			}
 			if errors.Is(err, models.ErrAddressAlreadyExists) {
-				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
-					"error": "Address already exists",
-				})
+				return utils.ErrorResponse(c, fiber.StatusConflict, utils.CodeAddressTaken, "Address already exists")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to create user",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to create user")
+		}
+
+		// Restricted users don't download media automatically by default
+		if isRestricted(user) {
+			if err := models.SetAutoDownloadMedia(c.UserContext(), user.ID, false); err != nil {
+				return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to apply restricted mode settings")
+			}
+		}
+
+		// Record the acceptance of the current policies, all of which were
+		// checked to be in req.AcceptedPolicies
+		if err := acceptCurrentPolicies(c, user.ID); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to record policy acceptance")
 		}
 
 		// Generate JWT token
-		token, err := middleware.GenerateJWT(user, cfg.Auth.JWTSecret, cfg.Auth.JWTExpirationTime)
+		token, sessionID, err := issueSessionToken(c, cfg, user)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to generate token",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to generate token")
+		}
+		deviceToken, err := issueDeviceToken(c, user.Address, sessionID)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to generate token")
 		}
 
 		// Return private key and token
 		// IMPORTANT: Private key is only returned once during registration
 		// Client must store it securely
-		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-			"token":       token,
-			"address":     address,
-			"private_key": base64.StdEncoding.EncodeToString(keyPair.PrivateKey),
+		return utils.CreatedResponse(c, fiber.Map{
+			"token":        token,
+			"address":      address,
+			"session_id":   sessionID,
+			"device_token": deviceToken,
+			"private_key":  base64.StdEncoding.EncodeToString(keyPair.PrivateKey),
 		})
 	}
 }
@@ -253,51 +337,56 @@ func Login(cfg *config.Config) fiber.Handler {
 		// Parse request body
 		req := new(LoginRequest)
 		if err := c.BodyParser(req); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid request body",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
 		}
 
 		// Validate request
 		if req.Phone == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Phone number is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Phone number is required")
 		}
 
-		// Check if user exists
-		_, err := models.GetUserByPhone(req.Phone)
+		// Check if user exists. Bot accounts have no login.
+		user, err := stores.Users.GetByPhone(c.UserContext(), req.Phone)
+		if err == nil && user.Role == models.UserRoleBot {
+			err = models.ErrUserNotFound
+		}
 		if err != nil {
 			if errors.Is(err, models.ErrUserNotFound) {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"error":  "User not found",
+				return utils.ErrorResponseWith(c, fiber.StatusNotFound, utils.CodeUserNotFound, "User not found", fiber.Map{
 					"action": "register",
 				})
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to find user",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to find user")
+		}
+		if rejected, err := rejectBlockedAccount(c, user.Address); rejected {
+			return err
+		}
+
+		if rejected, err := rejectInvalidOTPChannel(c, req.Channel); rejected {
+			return err
+		}
+		var email string
+		if req.Channel == otpChannelEmail {
+			if user.Email == "" {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeEmailNotSet, "No email address on this account")
+			}
+			email = user.Email
 		}
 
 		// Generate OTP
-		otp, err := models.GenerateOTP(req.Phone, cfg.Auth.OTPExpiryMinutes)
+		otp, err := newOTP(c, cfg, req.Phone, email)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to generate OTP",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to generate OTP")
 		}
 
-		// Send OTP via SMS
-		err = utils.SendOTP(utils.FromConfigSMS(&cfg.SMS), req.Phone, otp.Code)
-		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to send OTP",
-			})
+		// Send OTP via SMS or email, except to test phones
+		if err := sendOTP(cfg, otp); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to send OTP")
 		}
 
 		// Return success
-		return c.Status(fiber.StatusOK).JSON(fiber.Map{
-			"message":    "OTP sent to your phone",
+		return utils.OKResponse(c, fiber.Map{
+			"message":    otpSentMessage(c, otp),
 			"expires_in": cfg.Auth.OTPExpiryMinutes,
 		})
 	}
@@ -309,67 +398,211 @@ func VerifyLogin(cfg *config.Config) fiber.Handler {
 		// Parse request body
 		req := new(VerifyOTPRequest)
 		if err := c.BodyParser(req); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid request body",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
 		}
 
 		// Validate request
 		if req.Phone == "" || req.Code == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Phone number and verification code are required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Phone number and verification code are required")
+		}
+
+		// Accounts with a PIN need it on devices that haven't signed in
+		// before. Checked before the OTP so the code isn't used up, as is
+		// the account being frozen or suspended.
+		if rejected, err := rejectBlockedPhone(c, req.Phone); rejected {
+			return err
+		}
+		pin, rejected, err := rejectMissingPIN(c, req)
+		if rejected {
+			return err
 		}
 
 		// Verify OTP
-		verified, err := models.VerifyOTP(req.Phone, req.Code)
+		verified, err := models.VerifyOTP(c.UserContext(), req.Phone, req.Code)
 		if err != nil {
 			if errors.Is(err, models.ErrOTPNotFound) || errors.Is(err, models.ErrOTPExpired) || errors.Is(err, models.ErrOTPInvalid) {
-				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"error": err.Error(),
-				})
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, otpErrorCode(err), err.Error())
 			}
 			if errors.Is(err, models.ErrOTPMaxAttempts) {
-				return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-					"error": "Maximum verification attempts reached. Please request a new OTP.",
-				})
+				return utils.ErrorResponse(c, fiber.StatusTooManyRequests, utils.CodeOTPAttemptsExceeded, "Maximum verification attempts reached. Please request a new OTP.")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to verify OTP",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to verify OTP")
 		}
 
 		if !verified {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid verification code",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidVerificationCode, "Invalid verification code")
 		}
 
 		// Find user by phone
-		user, err := models.GetUserByPhone(req.Phone)
+		user, err := stores.Users.GetByPhone(c.UserContext(), req.Phone)
 		if err != nil {
 			if errors.Is(err, models.ErrUserNotFound) {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"error": "User not found",
-				})
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeUserNotFound, "User not found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to find user")
+		}
+
+		if pin != nil {
+			if rejected, err := rejectWrongPIN(c, cfg, pin, req.PIN); rejected {
+				return err
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to find user",
-			})
 		}
 
 		// Generate JWT token with extended expiration for persistent login
-		token, err := middleware.GenerateJWT(user, cfg.Auth.JWTSecret, cfg.Auth.JWTExpirationTime)
+		token, sessionID, err := issueSessionToken(c, cfg, user)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to generate token",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to generate token")
+		}
+		deviceToken, err := issueDeviceToken(c, user.Address, sessionID)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to generate token")
 		}
 
 		// Return token and address
-		return c.Status(fiber.StatusOK).JSON(AuthResponse{
-			Token:   token,
-			Address: user.Address,
+		return utils.OKResponse(c, AuthResponse{
+			Token:       token,
+			Address:     user.Address,
+			SessionID:   sessionID,
+			DeviceToken: deviceToken,
+		})
+	}
+}
+
+// ChallengeResponse represents a login challenge
+type ChallengeResponse struct {
+	Nonce     string     `json:"nonce"`
+	ExpiresAt types.Time `json:"expires_at"`
+}
+
+// VerifySignatureRequest represents a signed login challenge
+type VerifySignatureRequest struct {
+	PublicKey string `json:"public_key"`
+	Nonce     string `json:"nonce"`
+	Signature string `json:"signature"`
+	// PIN is the account PIN, needed when the account has one and the
+	// device hasn't signed in before
+	PIN string `json:"pin,omitempty"`
+	// DeviceToken is the token returned by an earlier sign in from this
+	// device
+	DeviceToken string `json:"device_token,omitempty"`
+}
+
+// GetChallenge handles key login - Step 1: Issue a nonce to sign
+func GetChallenge(cfg *config.Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		nonceBytes, err := crypto.GenerateRandomBytes(32)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to generate challenge")
+		}
+
+		challenge := &models.AuthChallenge{
+			Nonce:     hex.EncodeToString(nonceBytes),
+			ExpiresAt: types.NewTime(clock.Now().Add(cfg.Auth.ChallengeExpiry)),
+		}
+		if err := models.CreateAuthChallenge(c.UserContext(), challenge); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to create challenge")
+		}
+
+		return utils.OKResponse(c, ChallengeResponse{
+			Nonce:     challenge.Nonce,
+			ExpiresAt: challenge.ExpiresAt,
+		})
+	}
+}
+
+// loginChallengeMessagePrefix separates key login signatures from anything
+// else the key signs
+const loginChallengeMessagePrefix = "piko-login-v1:"
+
+// loginChallengeMessage is what key login signs: the challenge nonce bound
+// to the address and key signing in, so a signature the key made over the
+// same bytes elsewhere isn't a login proof
+func loginChallengeMessage(address string, publicKey []byte, nonce string) []byte {
+	return []byte(loginChallengeMessagePrefix + address + ":" + crypto.KeyFingerprint(publicKey) + ":" + nonce)
+}
+
+// VerifySignature handles key login - Step 2: Verify the signed nonce
+func VerifySignature(cfg *config.Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Parse request body
+		req := new(VerifySignatureRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+
+		// Validate request
+		if req.PublicKey == "" || req.Nonce == "" || req.Signature == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Public key, nonce and signature are required")
+		}
+
+		publicKey, err := crypto.DecodeBase64(req.PublicKey)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid public key")
+		}
+		signature, err := crypto.DecodeBase64(req.Signature)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidSignature, "Invalid signature")
+		}
+
+		address, err := crypto.GenerateAddress(publicKey, cfg.Crypto.AddressLength)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid public key")
+		}
+
+		// The signature must be over the login message for this address and
+		// key, with the nonce exactly as it was issued
+		valid, err := crypto.Verify(publicKey, loginChallengeMessage(address, publicKey, req.Nonce), signature)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid public key")
+		}
+		if !valid {
+			return utils.ErrorResponse(c, fiber.StatusUnauthorized, utils.CodeInvalidSignature, "Invalid signature")
+		}
+
+		// Find the user owning the key
+		user, err := stores.Users.GetByAddress(c.UserContext(), address)
+		if err != nil {
+			if errors.Is(err, models.ErrUserNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusUnauthorized, utils.CodeUnknownPublicKey, "Unknown public key")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to find user")
+		}
+		if !bytes.Equal(user.PublicKey, publicKey) {
+			return utils.ErrorResponse(c, fiber.StatusUnauthorized, utils.CodeUnknownPublicKey, "Unknown public key")
+		}
+		if rejected, err := rejectBlockedAccount(c, user.Address); rejected {
+			return err
+		}
+
+		// Burn the nonce so the signature can't be replayed
+		if err := models.ConsumeAuthChallenge(c.UserContext(), req.Nonce); err != nil {
+			if errors.Is(err, models.ErrChallengeInvalid) {
+				return utils.ErrorResponse(c, fiber.StatusUnauthorized, utils.CodeChallengeInvalid, "Challenge invalid or expired")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to verify challenge")
+		}
+
+		// Holding the key isn't enough on a new device when the account has
+		// a PIN. Checked after the nonce is burnt, so a captured signature
+		// can't be replayed to guess the PIN.
+		if rejected, err := rejectUntrustedDevice(c, cfg, user.Address, req.PIN, req.DeviceToken); rejected {
+			return err
+		}
+
+		token, sessionID, err := issueSessionToken(c, cfg, user)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to generate token")
+		}
+		deviceToken, err := issueDeviceToken(c, user.Address, sessionID)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to generate token")
+		}
+
+		return utils.OKResponse(c, AuthResponse{
+			Token:       token,
+			Address:     user.Address,
+			SessionID:   sessionID,
+			DeviceToken: deviceToken,
 		})
 	}
 }
@@ -380,26 +613,20 @@ func GetProfile() fiber.Handler {
 		// Get user ID from context
 		userID, ok := middleware.GetUserID(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
 		}
 
 		// Get user from database
-		user, err := models.GetUserByID(userID)
+		user, err := stores.Users.GetByID(c.UserContext(), userID)
 		if err != nil {
 			if errors.Is(err, models.ErrUserNotFound) {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"error": "User not found",
-				})
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeUserNotFound, "User not found")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to get user",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get user")
 		}
 
 		// Return user profile
-		return c.Status(fiber.StatusOK).JSON(user)
+		return utils.OKResponse(c, user)
 	}
 }
 
@@ -409,92 +636,79 @@ func UpdateProfile() fiber.Handler {
 		// Get user ID from context
 		userID, ok := middleware.GetUserID(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
 		}
 
 		// Get user from database
-		user, err := models.GetUserByID(userID)
+		user, err := stores.Users.GetByID(c.UserContext(), userID)
 		if err != nil {
 			if errors.Is(err, models.ErrUserNotFound) {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"error": "User not found",
-				})
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeUserNotFound, "User not found")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to get user",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get user")
 		}
 
 		// Parse request body
-		var updateReq struct {
-			Phone string `json:"phone,omitempty"`
-		}
-		if err := c.BodyParser(&updateReq); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid request body",
-			})
+		updateReq := new(UpdateProfileRequest)
+		if err := c.BodyParser(updateReq); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
 		}
 
 		// Update user fields
 		if updateReq.Phone != "" {
 			user.Phone = updateReq.Phone
 		}
+		if updateReq.Email != "" {
+			email, ok := normalizeEmail(updateReq.Email)
+			if !ok {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "A valid email address is required")
+			}
+			user.Email = email
+		}
 
 		// Save changes
-		if err := models.UpdateUser(user); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to update user",
-			})
+		if err := models.UpdateUser(c.UserContext(), user); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to update user")
 		}
 
 		// Return updated user
-		return c.Status(fiber.StatusOK).JSON(user)
+		return utils.OKResponse(c, user)
 	}
 }
 
 // RequestOTP handles requests for OTP verification
-func RequestOTP() fiber.Handler {
+func RequestOTP(cfg *config.Config) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// Parse request body
 		var req struct {
 			Phone string `json:"phone"`
 		}
 		if err := c.BodyParser(&req); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid request body",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
 		}
 
 		// Validate phone number
 		if req.Phone == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Phone number is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Phone number is required")
 		}
 
 		// Generate OTP code
-		code, err := utils.GenerateOTP(6)
+		code, err := utils.GenerateOTP(cfg.Auth.OTPLength, cfg.Auth.OTPCharset)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to generate OTP",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to generate OTP")
 		}
 
 		// Set expiration time (5 minutes)
-		expiresAt := time.Now().Add(5 * time.Minute)
+		expiresAt := clock.Now().Add(5 * time.Minute)
 
 		// Save OTP to database
 		otp := &models.OTP{
 			Phone:     req.Phone,
 			Code:      code,
-			ExpiresAt: expiresAt,
+			ExpiresAt: types.NewTime(expiresAt),
 		}
-		if err := models.SaveOTP(otp); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to save OTP",
-			})
+		if err := models.SaveOTP(c.UserContext(), otp); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to save OTP")
 		}
 
 		// Get SMS config
@@ -502,13 +716,11 @@ func RequestOTP() fiber.Handler {
 
 		// Send OTP via SMS
 		if err := utils.SendOTP(smsConfig, req.Phone, code); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to send OTP",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to send OTP")
 		}
 
-		return c.Status(fiber.StatusOK).JSON(fiber.Map{
-			"message": "OTP sent successfully",
+		return utils.OKResponse(c, fiber.Map{
+			"message": localized(c, "OTP sent successfully"),
 		})
 	}
 }
@@ -522,54 +734,38 @@ func VerifyOTP() fiber.Handler {
 			Code  string `json:"verfication-code"`
 		}
 		if err := c.BodyParser(&req); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid request body",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
 		}
 
 		// Validate request
 		if req.Phone == "" || req.Code == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Phone number and verification code are required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Phone number and verification code are required")
 		}
 
 		// Verify OTP
-		verified, err := models.VerifyOTP(req.Phone, req.Code)
+		verified, err := models.VerifyOTP(c.UserContext(), req.Phone, req.Code)
 		if err != nil {
 			if errors.Is(err, models.ErrOTPNotFound) {
-				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"error": "OTP not found",
-				})
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeOTPNotFound, "OTP not found")
 			}
 			if errors.Is(err, models.ErrOTPExpired) {
-				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"error": "OTP expired",
-				})
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeOTPExpired, "OTP expired")
 			}
 			if errors.Is(err, models.ErrOTPInvalid) {
-				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"error": "Invalid OTP",
-				})
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidOTP, "Invalid OTP")
 			}
 			if errors.Is(err, models.ErrOTPMaxAttempts) {
-				return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-					"error": "Maximum verification attempts reached. Please request a new OTP.",
-				})
+				return utils.ErrorResponse(c, fiber.StatusTooManyRequests, utils.CodeOTPAttemptsExceeded, "Maximum verification attempts reached. Please request a new OTP.")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to verify OTP",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to verify OTP")
 		}
 
 		if !verified {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Invalid or expired verification code",
-			})
+			return utils.ErrorResponse(c, fiber.StatusUnauthorized, utils.CodeInvalidVerificationCode, "Invalid or expired verification code")
 		}
 
-		return c.Status(fiber.StatusOK).JSON(fiber.Map{
-			"message": "Phone number verified successfully",
+		return utils.OKResponse(c, fiber.Map{
+			"message": localized(c, "Phone number verified successfully"),
 		})
 	}
 }
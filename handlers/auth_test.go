@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/piko/piko/config"
+	"github.com/piko/piko/crypto"
+	"github.com/piko/piko/utils"
+)
+
+// signedChallengeBody is a verify-signature request for a message signed
+// with keyPair
+func signedChallengeBody(t *testing.T, keyPair *crypto.KeyPair, nonce string, message []byte) string {
+	t.Helper()
+
+	signature, err := crypto.Sign(keyPair.PrivateKey, message)
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	body, err := json.Marshal(VerifySignatureRequest{
+		PublicKey: crypto.EncodeBase64(keyPair.PublicKey),
+		Nonce:     nonce,
+		Signature: crypto.EncodeBase64(signature),
+	})
+	if err != nil {
+		t.Fatalf("encoding request: %v", err)
+	}
+	return string(body)
+}
+
+func TestVerifySignatureRejectsUnboundSignatures(t *testing.T) {
+	cfg := config.DefaultConfig()
+	const nonce = "3b5d2f9a0c"
+
+	keyPair, err := crypto.GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("GenerateKeyPair: %v", err)
+	}
+	other, err := crypto.GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("GenerateKeyPair: %v", err)
+	}
+	address, err := crypto.GenerateAddress(keyPair.PublicKey, cfg.Crypto.AddressLength)
+	if err != nil {
+		t.Fatalf("GenerateAddress: %v", err)
+	}
+	otherAddress, err := crypto.GenerateAddress(other.PublicKey, cfg.Crypto.AddressLength)
+	if err != nil {
+		t.Fatalf("GenerateAddress: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		message []byte
+	}{
+		{"bare nonce", []byte(nonce)},
+		{"other protocol", []byte("piko-login-v0:" + address + ":" + crypto.KeyFingerprint(keyPair.PublicKey) + ":" + nonce)},
+		{"other address", loginChallengeMessage(otherAddress, keyPair.PublicKey, nonce)},
+		{"other key", loginChallengeMessage(address, other.PublicKey, nonce)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := signedChallengeBody(t, keyPair, nonce, tt.message)
+			status, resp := serve(t, nil, http.MethodPost, "/auth/verify-signature", "/auth/verify-signature", body, VerifySignature(cfg))
+			if status != http.StatusUnauthorized || resp.Code != utils.CodeInvalidSignature {
+				t.Errorf("got %d %s, want %d %s", status, resp.Code, http.StatusUnauthorized, utils.CodeInvalidSignature)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/privacy"
+	"github.com/piko/piko/types"
+	"github.com/piko/piko/utils"
+)
+
+// AvatarResponse represents an avatar with a time-limited URL for its file
+type AvatarResponse struct {
+	*models.UserAvatar
+	URL          string     `json:"url"`
+	URLExpiresAt types.Time `json:"url_expires_at"`
+}
+
+// avatarFilePath returns the path an avatar's file is served at
+func avatarFilePath(avatarID int) string {
+	return fmt.Sprintf("/api/avatars/%d/file", avatarID)
+}
+
+// avatarSignedPath returns what an avatar URL's signature covers: the file
+// and the user it was issued to, so a link can't be moved to another
+// avatar or outlive the viewer's access to the photo
+func avatarSignedPath(avatarID int, viewerAddress string) string {
+	return avatarFilePath(avatarID) + "?viewer=" + url.QueryEscape(viewerAddress)
+}
+
+// avatarResponse converts an avatar to its response with a URL signed for
+// the viewer
+func avatarResponse(avatar *models.UserAvatar, viewerAddress string) AvatarResponse {
+	expiresAt := clock.Now().Add(mediaConfig.URLExpiry)
+	signature := utils.SignURL(mediaConfig.SigningSecret, avatarSignedPath(avatar.ID, viewerAddress), expiresAt.Unix())
+
+	query := url.Values{}
+	query.Set("viewer", viewerAddress)
+	query.Set("expires", fmt.Sprint(expiresAt.Unix()))
+	query.Set("signature", signature)
+
+	return AvatarResponse{
+		UserAvatar:   avatar,
+		URL:          avatarFilePath(avatar.ID) + "?" + query.Encode(),
+		URLExpiresAt: types.NewTime(expiresAt),
+	}
+}
+
+// avatarResponses converts avatars to their responses
+func avatarResponses(avatars []*models.UserAvatar, viewerAddress string) []AvatarResponse {
+	responses := make([]AvatarResponse, 0, len(avatars))
+	for _, avatar := range avatars {
+		responses = append(responses, avatarResponse(avatar, viewerAddress))
+	}
+	return responses
+}
+
+// profilePhotoVisible reports whether the owner's privacy settings let the
+// viewer see their avatar
+func profilePhotoVisible(ctx context.Context, owner *models.User, viewerAddress string) (bool, error) {
+	policy, err := privacy.For(ctx, owner)
+	if err != nil {
+		return false, err
+	}
+	return policy.Allows(ctx, privacy.ProfilePhoto, viewerAddress)
+}
+
+// GetUserAvatar handles getting another user's active avatar, if their
+// privacy settings let the requester see it
+func GetUserAvatar() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		owner, err := stores.Users.GetByAddress(c.UserContext(), c.Params("address"))
+		if err != nil {
+			if errors.Is(err, models.ErrUserNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeUserNotFound, "User not found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get user")
+		}
+
+		// A hidden photo looks the same as no photo
+		visible, err := profilePhotoVisible(c.UserContext(), owner, userAddress)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get active avatar")
+		}
+		if !visible {
+			return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeAvatarNotFound, "No active avatar found")
+		}
+
+		avatar, err := models.GetActiveAvatarForUser(c.UserContext(), owner.ID)
+		if err != nil {
+			if errors.Is(err, models.ErrAvatarNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeAvatarNotFound, "No active avatar found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get active avatar")
+		}
+
+		return utils.OKResponse(c, avatarResponse(avatar, userAddress))
+	}
+}
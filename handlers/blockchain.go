@@ -7,6 +7,7 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/piko/piko/middleware"
 	"github.com/piko/piko/models"
+	"github.com/piko/piko/utils"
 )
 
 // GetBlock handles retrieving a block by its ID
@@ -15,26 +16,20 @@ func GetBlock() fiber.Handler {
 		// Get block ID from URL parameter
 		blockID := c.Params("id")
 		if blockID == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Block ID is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Block ID is required")
 		}
 
 		// Get block from database
-		block, err := models.GetBlockByID(blockID)
+		block, err := models.GetBlockByID(c.UserContext(), blockID)
 		if err != nil {
 			if errors.Is(err, models.ErrBlockNotFound) {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"error": "Block not found",
-				})
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeBlockNotFound, "Block not found")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to get block",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get block")
 		}
 
 		// Return block
-		return c.Status(fiber.StatusOK).JSON(block)
+		return utils.OKResponse(c, block)
 	}
 }
 
@@ -44,34 +39,26 @@ func GetBlockByHeight() fiber.Handler {
 		// Get block height from URL parameter
 		heightStr := c.Params("height")
 		if heightStr == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Block height is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Block height is required")
 		}
 
 		// Parse height
 		height, err := strconv.Atoi(heightStr)
 		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid block height",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid block height")
 		}
 
 		// Get block from database
-		block, err := models.GetBlockByHeight(height)
+		block, err := models.GetBlockByHeight(c.UserContext(), height)
 		if err != nil {
 			if errors.Is(err, models.ErrBlockNotFound) {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"error": "Block not found",
-				})
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeBlockNotFound, "Block not found")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to get block",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get block")
 		}
 
 		// Return block
-		return c.Status(fiber.StatusOK).JSON(block)
+		return utils.OKResponse(c, block)
 	}
 }
 
@@ -81,26 +68,20 @@ func GetTransaction() fiber.Handler {
 		// Get transaction hash from URL parameter
 		hash := c.Params("hash")
 		if hash == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Transaction hash is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Transaction hash is required")
 		}
 
 		// Get transaction from database
-		transaction, err := models.GetTransactionByHash(hash)
+		transaction, err := models.GetTransactionByHash(c.UserContext(), hash)
 		if err != nil {
 			if errors.Is(err, models.ErrTransactionNotFound) {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"error": "Transaction not found",
-				})
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeTransactionNotFound, "Transaction not found")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to get transaction",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get transaction")
 		}
 
 		// Return transaction
-		return c.Status(fiber.StatusOK).JSON(transaction)
+		return utils.OKResponse(c, transaction)
 	}
 }
 
@@ -110,21 +91,17 @@ func ExploreAddress() fiber.Handler {
 		// Get address from URL parameter
 		address := c.Params("address")
 		if address == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Address is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Address is required")
 		}
 
 		// Get transactions from database
-		transactions, err := models.GetTransactionsByAddress(address)
+		transactions, err := models.GetTransactionsByAddress(c.UserContext(), address)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to get transactions",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get transactions")
 		}
 
 		// Return transactions
-		return c.Status(fiber.StatusOK).JSON(transactions)
+		return utils.OKResponse(c, transactions)
 	}
 }
 
@@ -134,49 +111,37 @@ func GetProof() fiber.Handler {
 		// Get user address from context
 		userAddress, ok := middleware.GetUserAddress(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
 		}
 
 		// Get message ID from URL parameter
 		messageID := c.Params("message_id")
 		if messageID == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Message ID is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Message ID is required")
 		}
 
 		// Get message from database
-		message, err := models.GetMessageByID(messageID)
+		message, err := stores.Messages.Get(c.UserContext(), messageID)
 		if err != nil {
 			if errors.Is(err, models.ErrMessageNotFound) {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"error": "Message not found",
-				})
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeMessageNotFound, "Message not found")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to get message",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get message")
 		}
 
 		// Check if user is sender or recipient
 		if message.SenderAddress != userAddress && message.RecipientAddress != userAddress {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"error": "Access denied",
-			})
+			return utils.ForbiddenResponse(c)
 		}
 
 		// Check if message is in a block
 		if message.BlockID == nil {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"error": "Message is not in a block yet",
-			})
+			return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeMessageNotInBlock, "Message is not in a block yet")
 		}
 
 		// TODO: Implement Merkle proof generation
 		// For now, return a placeholder
-		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		return utils.OKResponse(c, fiber.Map{
 			"message_id": messageID,
 			"block_id":   *message.BlockID,
 			"proof":      []string{"placeholder_proof"},
@@ -189,14 +154,12 @@ func GetProof() fiber.Handler {
 func GetBlockchainStats() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// Get blockchain stats from database
-		stats, err := models.GetBlockchainStats()
+		stats, err := models.GetBlockchainStats(c.UserContext())
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to get blockchain stats",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get blockchain stats")
 		}
 
 		// Return stats
-		return c.Status(fiber.StatusOK).JSON(stats)
+		return utils.OKResponse(c, stats)
 	}
 } 
\ No newline at end of file
@@ -0,0 +1,473 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/notifications"
+	"github.com/piko/piko/plugins"
+	"github.com/piko/piko/types"
+	"github.com/piko/piko/utils"
+	"github.com/piko/piko/websocket"
+)
+
+// CreateBroadcastListRequest represents a request to create a broadcast list
+type CreateBroadcastListRequest struct {
+	Name       string   `json:"name"`
+	Recipients []string `json:"recipients,omitempty"`
+}
+
+// RenameBroadcastListRequest represents a request to rename a broadcast list
+type RenameBroadcastListRequest struct {
+	Name string `json:"name"`
+}
+
+// AddBroadcastRecipientsRequest represents a request to add recipients to a
+// broadcast list
+type AddBroadcastRecipientsRequest struct {
+	Recipients []string `json:"recipients"`
+}
+
+// SendBroadcastRequest represents a message sent to every recipient of a
+// broadcast list as a direct message
+type SendBroadcastRequest struct {
+	EncryptedContent string   `json:"encrypted_content"`
+	TTL              *int64   `json:"ttl,omitempty"` // Time to live in seconds
+	AttachmentIDs    []string `json:"attachment_ids,omitempty"`
+}
+
+// Reasons a broadcast recipient didn't get a copy
+const (
+	broadcastErrorBlocked = "blocked"
+	broadcastErrorFailed  = "failed"
+)
+
+// validBroadcastListName trims a broadcast list name and checks its length
+func validBroadcastListName(name string) (string, bool) {
+	name = strings.TrimSpace(name)
+	return name, name != "" && utf8.RuneCountInString(name) <= 100
+}
+
+// rejectInvalidBroadcastRecipients drops duplicate recipients and writes an
+// error response if one is the owner or doesn't exist
+func rejectInvalidBroadcastRecipients(c *fiber.Ctx, ownerAddress string, recipients []string) ([]string, bool, error) {
+	unique := make([]string, 0, len(recipients))
+	seen := make(map[string]bool, len(recipients))
+	for _, recipient := range recipients {
+		if seen[recipient] {
+			continue
+		}
+		seen[recipient] = true
+		if recipient == "" || recipient == ownerAddress {
+			return nil, true, utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid recipient")
+		}
+		if _, err := stores.Users.GetByAddress(c.UserContext(), recipient); err != nil {
+			if errors.Is(err, models.ErrUserNotFound) {
+				return nil, true, utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeRecipientNotFound, "Recipient not found")
+			}
+			return nil, true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to verify recipient")
+		}
+		unique = append(unique, recipient)
+	}
+	return unique, false, nil
+}
+
+// getOwnBroadcastList loads one of the user's broadcast lists, writing a 404
+// response if they have no such list
+func getOwnBroadcastList(c *fiber.Ctx, userAddress string) (*models.BroadcastList, bool, error) {
+	list, err := models.GetBroadcastList(c.UserContext(), c.Params("id"), userAddress)
+	if err != nil {
+		if errors.Is(err, models.ErrBroadcastListNotFound) {
+			return nil, true, utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeBroadcastListNotFound, "Broadcast list not found")
+		}
+		return nil, true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get broadcast list")
+	}
+	return list, false, nil
+}
+
+// CreateBroadcastList handles creating a broadcast list
+func CreateBroadcastList() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		req := new(CreateBroadcastListRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+		name, ok := validBroadcastListName(req.Name)
+		if !ok {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Name is required and must be at most 100 characters")
+		}
+		recipients, rejected, err := rejectInvalidBroadcastRecipients(c, userAddress, req.Recipients)
+		if rejected {
+			return err
+		}
+		if full, err := rejectFullBroadcastList(c, len(recipients)); full {
+			return err
+		}
+
+		id, err := utils.NewID()
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to generate broadcast list ID")
+		}
+		list := &models.BroadcastList{
+			ID:           id,
+			OwnerAddress: userAddress,
+			Name:         name,
+		}
+		if err := models.CreateBroadcastList(c.UserContext(), list, recipients); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to create broadcast list")
+		}
+
+		reportBroadcastRecipients(c, list.RecipientCount)
+		return utils.CreatedResponse(c, list)
+	}
+}
+
+// GetBroadcastLists handles listing the user's broadcast lists
+func GetBroadcastLists() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		lists, err := models.GetBroadcastLists(c.UserContext(), userAddress)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get broadcast lists")
+		}
+
+		return utils.OKResponse(c, lists)
+	}
+}
+
+// GetBroadcastList handles retrieving one of the user's broadcast lists with
+// its recipients
+func GetBroadcastList() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		list, rejected, err := getOwnBroadcastList(c, userAddress)
+		if rejected {
+			return err
+		}
+
+		reportBroadcastRecipients(c, list.RecipientCount)
+		return utils.OKResponse(c, list)
+	}
+}
+
+// RenameBroadcastList handles renaming one of the user's broadcast lists
+func RenameBroadcastList() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		req := new(RenameBroadcastListRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+		name, ok := validBroadcastListName(req.Name)
+		if !ok {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Name is required and must be at most 100 characters")
+		}
+
+		if err := models.RenameBroadcastList(c.UserContext(), c.Params("id"), userAddress, name); err != nil {
+			if errors.Is(err, models.ErrBroadcastListNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeBroadcastListNotFound, "Broadcast list not found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to update broadcast list")
+		}
+
+		list, rejected, err := getOwnBroadcastList(c, userAddress)
+		if rejected {
+			return err
+		}
+		return utils.OKResponse(c, list)
+	}
+}
+
+// DeleteBroadcastList handles deleting one of the user's broadcast lists
+func DeleteBroadcastList() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		if err := models.DeleteBroadcastList(c.UserContext(), c.Params("id"), userAddress); err != nil {
+			if errors.Is(err, models.ErrBroadcastListNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeBroadcastListNotFound, "Broadcast list not found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to delete broadcast list")
+		}
+
+		return utils.OKResponse(c, fiber.Map{
+			"message": localized(c, "Broadcast list deleted"),
+		})
+	}
+}
+
+// AddBroadcastRecipients handles adding recipients to one of the user's
+// broadcast lists
+func AddBroadcastRecipients() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		list, rejected, err := getOwnBroadcastList(c, userAddress)
+		if rejected {
+			return err
+		}
+
+		req := new(AddBroadcastRecipientsRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+		if len(req.Recipients) == 0 {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Recipients are required")
+		}
+		recipients, rejected, err := rejectInvalidBroadcastRecipients(c, userAddress, req.Recipients)
+		if rejected {
+			return err
+		}
+
+		count, err := models.AddBroadcastRecipients(c.UserContext(), list.ID, recipients, quotaConfig.MaxBroadcastRecipients)
+		if err != nil {
+			if errors.Is(err, models.ErrBroadcastListFull) {
+				return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeBroadcastListFull, "Broadcast list is full")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to add recipients")
+		}
+
+		reportBroadcastRecipients(c, count)
+		return utils.OKResponse(c, fiber.Map{
+			"message":         localized(c, "Recipients added"),
+			"recipient_count": count,
+		})
+	}
+}
+
+// RemoveBroadcastRecipient handles taking a recipient off one of the user's
+// broadcast lists
+func RemoveBroadcastRecipient() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		list, rejected, err := getOwnBroadcastList(c, userAddress)
+		if rejected {
+			return err
+		}
+
+		if err := models.RemoveBroadcastRecipient(c.UserContext(), list.ID, c.Params("address")); err != nil {
+			if errors.Is(err, models.ErrBroadcastRecipientNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeRecipientNotFound, "Recipient not found in broadcast list")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to remove recipient")
+		}
+
+		return utils.OKResponse(c, fiber.Map{
+			"message": localized(c, "Recipient removed"),
+		})
+	}
+}
+
+// SendBroadcast handles sending a message to every recipient of one of the
+// user's broadcast lists. Each recipient gets their own direct message, with
+// the same checks as sending to them alone; recipients who can't be sent to
+// are reported in the response instead of failing the whole broadcast.
+func SendBroadcast() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		senderAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		list, rejected, err := getOwnBroadcastList(c, senderAddress)
+		if rejected {
+			return err
+		}
+		if len(list.Recipients) == 0 {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeBroadcastListEmpty, "Broadcast list has no recipients")
+		}
+
+		req := new(SendBroadcastRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+		if req.EncryptedContent == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Encrypted content is required")
+		}
+		encryptedContent, err := payloadEncoding(c).Decode(req.EncryptedContent)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid encrypted content")
+		}
+		if tooLarge, err := rejectOversizedContent(c, encryptedContent); tooLarge {
+			return err
+		}
+		if tooLarge, err := rejectTooManyAttachments(c, req.AttachmentIDs); tooLarge {
+			return err
+		}
+		if err := models.ValidateAttachments(c.UserContext(), senderAddress, req.AttachmentIDs); err != nil {
+			if errors.Is(err, models.ErrInvalidAttachment) {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid attachment")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to verify attachments")
+		}
+
+		broadcastID, err := utils.NewID()
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to generate broadcast ID")
+		}
+
+		var expirationTime *types.Time
+		if req.TTL != nil && *req.TTL > 0 {
+			expTime := types.NewTime(clock.Now().Add(time.Duration(*req.TTL) * time.Second))
+			expirationTime = &expTime
+		}
+
+		broadcast := &models.Broadcast{
+			ID:         broadcastID,
+			ListID:     list.ID,
+			Deliveries: make([]*models.BroadcastDelivery, 0, len(list.Recipients)),
+		}
+		sent := []*models.Message{}
+		for _, recipient := range list.Recipients {
+			message, reason := sendBroadcastCopy(c, senderAddress, recipient, encryptedContent, expirationTime, req.AttachmentIDs)
+			delivery := &models.BroadcastDelivery{RecipientAddress: recipient}
+			if message == nil {
+				delivery.Status = models.BroadcastStatusFailed
+				delivery.Error = &reason
+			} else {
+				delivery.MessageID = &message.ID
+				delivery.Status = string(message.Status)
+				sent = append(sent, message)
+			}
+			broadcast.Deliveries = append(broadcast.Deliveries, delivery)
+		}
+
+		if err := models.CreateBroadcast(c.UserContext(), broadcast); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to record broadcast")
+		}
+
+		for _, message := range sent {
+			go websocket.NotifyNewMessage(WebSocketPool, message)
+			go pushUnlessMuted(message.RecipientAddress, models.ConversationDirect, message.SenderAddress, &notifications.Notification{
+				Title: "New message",
+				Body:  "You have a new message",
+				Data: map[string]string{
+					"type":           "new_message",
+					"id":             message.ID,
+					"sender_address": message.SenderAddress,
+				},
+			})
+		}
+
+		return utils.CreatedResponse(c, broadcast)
+	}
+}
+
+// sendBroadcastCopy stores one recipient's copy of a broadcast. It returns
+// nil and the reason when the recipient can't be sent to.
+func sendBroadcastCopy(c *fiber.Ctx, senderAddress, recipient string, content []byte, expirationTime *types.Time, attachmentIDs []string) (*models.Message, string) {
+	blocked, err := models.HasBlocked(c.UserContext(), recipient, senderAddress)
+	if err != nil {
+		return nil, broadcastErrorFailed
+	}
+	if blocked {
+		return nil, broadcastErrorBlocked
+	}
+
+	messageID, err := utils.NewID()
+	if err != nil {
+		return nil, broadcastErrorFailed
+	}
+
+	// Plugins see each copy as a direct message
+	hooked := &plugins.Message{
+		Kind:           plugins.ConversationDirect,
+		ID:             messageID,
+		SenderAddress:  senderAddress,
+		ConversationID: recipient,
+		Content:        content,
+	}
+	if err := plugins.BeforeMessagePersist(c.UserContext(), hooked); err != nil {
+		var rejection *plugins.Rejection
+		if errors.As(err, &rejection) {
+			return nil, rejection.Reason
+		}
+		return nil, broadcastErrorFailed
+	}
+
+	message := &models.Message{
+		ID:               messageID,
+		SenderAddress:    senderAddress,
+		RecipientAddress: recipient,
+		EncryptedContent: hooked.Content,
+		Status:           models.MessageStatusPending,
+		ExpirationTime:   expirationTime,
+		SenderSessionID:  currentSession(c),
+	}
+	if err := models.CreateMessage(c.UserContext(), message); err != nil {
+		return nil, broadcastErrorFailed
+	}
+	if err := models.AttachMedia(c.UserContext(), models.AttachmentKindDirect, messageID, senderAddress, attachmentIDs); err != nil {
+		stores.Messages.Delete(c.UserContext(), messageID)
+		return nil, broadcastErrorFailed
+	}
+	return message, ""
+}
+
+// GetBroadcast handles retrieving a message sent to one of the user's
+// broadcast lists with the delivery status of each recipient's copy
+func GetBroadcast() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		list, rejected, err := getOwnBroadcastList(c, userAddress)
+		if rejected {
+			return err
+		}
+
+		broadcast, err := models.GetBroadcast(c.UserContext(), list.ID, c.Params("broadcast_id"))
+		if err != nil {
+			if errors.Is(err, models.ErrBroadcastNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeBroadcastNotFound, "Broadcast not found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get broadcast")
+		}
+
+		return utils.OKResponse(c, broadcast)
+	}
+}
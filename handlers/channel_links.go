@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"errors"
+	"net/url"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/plugins"
+	"github.com/piko/piko/utils"
+	"github.com/piko/piko/websocket"
+)
+
+// MessageLinkResponse represents a shareable link to a channel message
+type MessageLinkResponse struct {
+	// Link opens the message in the app
+	Link string `json:"link"`
+	// Path resolves the link over HTTPS, for sharing outside the app
+	Path string `json:"path"`
+}
+
+// ResolvedMessageLinkResponse represents the message a link points to.
+// Message is only included for members of the channel.
+type ResolvedMessageLinkResponse struct {
+	Link      string                  `json:"link"`
+	Channel   ChannelResponse         `json:"channel"`
+	MessageID string                  `json:"message_id"`
+	Message   *ChannelMessageResponse `json:"message,omitempty"`
+}
+
+// CrosspostRequest represents a request to share a channel message into
+// another channel
+type CrosspostRequest struct {
+	MessageID       string `json:"message_id"`
+	TargetChannelID string `json:"target_channel_id"`
+}
+
+// CrosspostResponse represents a cross-posted copy of a channel message
+type CrosspostResponse struct {
+	ID                   string `json:"id"`
+	ChannelID            string `json:"channel_id"`
+	ForwardedFrom        string `json:"forwarded_from"`
+	ForwardedFromChannel string `json:"forwarded_from_channel"`
+	Link                 string `json:"link"`
+}
+
+// messageLink returns the links to a channel message
+func messageLink(channelID, messageID string) MessageLinkResponse {
+	return MessageLinkResponse{
+		Link: "piko://channel/" + url.PathEscape(channelID) + "/" + url.PathEscape(messageID),
+		Path: "/api/links/channel/" + url.PathEscape(channelID) + "/" + url.PathEscape(messageID),
+	}
+}
+
+// getChannelMessageIn retrieves a message and the channel it was posted in,
+// writing a 404 response if either doesn't exist or they don't match
+func getChannelMessageIn(c *fiber.Ctx, channelID, messageID string) (*models.Channel, *models.ChannelMessage, bool, error) {
+	channel, err := models.GetChannelByID(c.UserContext(), channelID)
+	if err != nil {
+		if errors.Is(err, models.ErrChannelNotFound) {
+			return nil, nil, true, utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeChannelNotFound, "Channel not found")
+		}
+		return nil, nil, true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get channel")
+	}
+
+	message, err := models.GetChannelMessageByID(c.UserContext(), messageID)
+	if err != nil && !errors.Is(err, models.ErrMessageNotFound) {
+		return nil, nil, true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get message")
+	}
+	if message == nil || message.ChannelID != channelID {
+		return nil, nil, true, utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeMessageNotFound, "Message not found")
+	}
+	return channel, message, false, nil
+}
+
+// GetChannelMessageLink handles getting a shareable link to a channel message
+func GetChannelMessageLink() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		channelID := c.Params("id")
+		isMember, err := models.IsUserInChannel(c.UserContext(), channelID, userAddress)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check channel membership")
+		}
+		if !isMember {
+			return utils.ForbiddenResponse(c)
+		}
+
+		_, message, rejected, err := getChannelMessageIn(c, channelID, c.Params("message_id"))
+		if rejected {
+			return err
+		}
+
+		return utils.OKResponse(c, messageLink(channelID, message.ID))
+	}
+}
+
+// ResolveMessageLink handles opening a shared message link. Browsers are
+// redirected to the app; API clients get the channel, and the message if
+// they are a member. Links into private channels only resolve for members.
+func ResolveMessageLink() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Browsers open the app, which checks access itself. Nothing is
+		// looked up so the redirect doesn't reveal whether the message exists.
+		link := messageLink(c.Params("id"), c.Params("message_id"))
+		if c.Accepts(fiber.MIMEApplicationJSON, fiber.MIMETextHTML) == fiber.MIMETextHTML {
+			return c.Redirect(link.Link, fiber.StatusFound)
+		}
+
+		channel, message, rejected, err := getChannelMessageIn(c, c.Params("id"), c.Params("message_id"))
+		if rejected {
+			return err
+		}
+
+		isMember := false
+		userAddress, signedIn := middleware.GetUserAddress(c)
+		if signedIn {
+			isMember, err = models.IsUserInChannel(c.UserContext(), channel.ID, userAddress)
+			if err != nil {
+				return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check channel membership")
+			}
+		}
+		if !channel.IsPublic && !isMember {
+			return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeMessageNotFound, "Message not found")
+		}
+
+		response := ResolvedMessageLinkResponse{
+			Link:      link.Link,
+			Channel:   channelResponse(channel),
+			MessageID: message.ID,
+		}
+		if isMember {
+			attachments := loadAttachments(c.UserContext(), models.AttachmentKindChannel, []string{message.ID})
+			deviceNames := loadDeviceNames(c.UserContext(), []*string{message.SenderSessionID})
+			view := channelMessageResponse(c, userAddress, message, attachments[message.ID], deviceNames)
+			response.Message = &view
+		}
+		return utils.OKResponse(c, response)
+	}
+}
+
+// CrosspostChannelMessage handles sharing a message from one channel into
+// another. The copy keeps the content and attachments and is attributed to
+// the original message and channel. The user must be a member of both.
+func CrosspostChannelMessage() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		// Parse request body
+		req := new(CrosspostRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+		if req.MessageID == "" || req.TargetChannelID == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "message_id and target_channel_id are required")
+		}
+
+		sourceChannelID := c.Params("id")
+		if req.TargetChannelID == sourceChannelID {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Target channel must be a different channel")
+		}
+
+		for _, channelID := range []string{sourceChannelID, req.TargetChannelID} {
+			isMember, err := models.IsUserInChannel(c.UserContext(), channelID, userAddress)
+			if err != nil {
+				return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check channel membership")
+			}
+			if !isMember {
+				return utils.ForbiddenResponse(c)
+			}
+		}
+
+		_, source, rejected, err := getChannelMessageIn(c, sourceChannelID, req.MessageID)
+		if rejected {
+			return err
+		}
+		if rejected, err := rejectThrottledChannel(c, req.TargetChannelID); rejected {
+			return err
+		}
+
+		// Cross-posts of cross-posts are attributed to the original
+		forwardedFrom, forwardedFromChannel := source.ID, source.ChannelID
+		if source.ForwardedFrom != nil && source.ForwardedFromChannel != nil {
+			forwardedFrom, forwardedFromChannel = *source.ForwardedFrom, *source.ForwardedFromChannel
+		}
+
+		messageID, err := utils.NewID()
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to generate message ID")
+		}
+
+		// Let plugins refuse or rewrite the message
+		hooked := &plugins.Message{
+			Kind:           plugins.ConversationChannel,
+			ID:             messageID,
+			SenderAddress:  userAddress,
+			ConversationID: req.TargetChannelID,
+			Content:        source.EncryptedContent,
+		}
+		if rejected, err := rejectByPlugins(c, hooked); rejected {
+			return err
+		}
+
+		message := &models.ChannelMessage{
+			ID:                   messageID,
+			ChannelID:            req.TargetChannelID,
+			SenderAddress:        userAddress,
+			EncryptedContent:     hooked.Content,
+			ForwardedFrom:        &forwardedFrom,
+			ForwardedFromChannel: &forwardedFromChannel,
+			SenderSessionID:      currentSession(c),
+			StickerID:            source.StickerID,
+		}
+		if err := models.CreateChannelMessage(c.UserContext(), message); err != nil {
+			if errors.Is(err, models.ErrUserNotInChannel) {
+				return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotChannelMember, "User is not a member of the channel")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to create channel message")
+		}
+		if _, err := models.CopyAttachments(c.UserContext(), models.AttachmentKindChannel, source.ID, models.AttachmentKindChannel, messageID); err != nil {
+			models.DeleteChannelMessage(c.UserContext(), messageID, userAddress)
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to attach media")
+		}
+
+		// Notify channel members via WebSocket, and push to those offline
+		go websocket.NotifyNewChannelMessage(WebSocketPool, message)
+		go pushChannelMessage(message)
+
+		return utils.CreatedResponse(c, CrosspostResponse{
+			ID:                   messageID,
+			ChannelID:            req.TargetChannelID,
+			ForwardedFrom:        forwardedFrom,
+			ForwardedFromChannel: forwardedFromChannel,
+			Link:                 messageLink(req.TargetChannelID, messageID).Link,
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/utils"
+)
+
+// maxChannelAckBatch is the most messages one ack request may cover, one
+// page of GET /api/channels/:id/messages
+const maxChannelAckBatch = 100
+
+// AckChannelMessagesRequest represents a member acknowledging fetched channel messages
+type AckChannelMessagesRequest struct {
+	MessageIDs []string `json:"message_ids"`
+}
+
+// AckChannelMessagesResponse reports how many acks counted toward reach
+type AckChannelMessagesResponse struct {
+	Counted int `json:"counted"`
+}
+
+// AckChannelMessages handles a member acknowledging the channel messages it fetched
+func AckChannelMessages() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		// Get channel ID from URL parameter
+		channelID := c.Params("id")
+		if channelID == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Channel ID is required")
+		}
+
+		// Parse request body
+		req := new(AckChannelMessagesRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+		if len(req.MessageIDs) == 0 || len(req.MessageIDs) > maxChannelAckBatch {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, fmt.Sprintf("Between 1 and %d message IDs are required", maxChannelAckBatch))
+		}
+
+		// Check if user is a member of the channel
+		isMember, err := models.IsUserInChannel(c.UserContext(), channelID, userAddress)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check channel membership")
+		}
+		if !isMember {
+			return utils.ForbiddenResponse(c)
+		}
+
+		counted, err := models.AckChannelMessages(c.UserContext(), channelID, userAddress, req.MessageIDs, messagingConfig.ChannelReachWindow)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to acknowledge messages")
+		}
+
+		return utils.OKResponse(c, AckChannelMessagesResponse{
+			Counted: counted,
+		})
+	}
+}
+
+// GetChannelStats handles getting the member count, message count and
+// per-message reach of a channel, for its owners and admins
+func GetChannelStats() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		// Get channel ID from URL parameter
+		channelID := c.Params("id")
+		if channelID == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Channel ID is required")
+		}
+
+		// Check if user is an owner or admin
+		role, err := models.GetChannelRole(c.UserContext(), channelID, userAddress)
+		if err != nil && !errors.Is(err, models.ErrUserNotInChannel) {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check channel role")
+		}
+		if !role.CanManage() {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotChannelAdmin, "Only channel owners and admins can view channel stats")
+		}
+
+		pagination := utils.GetPaginationParams(c)
+		stats, err := models.GetChannelStats(c.UserContext(), channelID, pagination.Limit)
+		if err != nil {
+			if errors.Is(err, models.ErrChannelNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeChannelNotFound, "Channel not found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get channel stats")
+		}
+
+		return utils.OKResponse(c, stats)
+	}
+}
+
+// PruneChannelReach is a background task that deletes the per-member ack
+// rows once their messages stop accepting acks
+func PruneChannelReach(window time.Duration) {
+	if window <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		pruned, err := models.PruneChannelReachAcks(context.Background(), window)
+		if err != nil {
+			log.Printf("Failed to prune channel reach acks: %v", err)
+			continue
+		}
+
+		if pruned > 0 {
+			log.Printf("Pruned %d channel reach acks", pruned)
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"html/template"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cache"
+	"github.com/piko/piko/config"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/types"
+	"github.com/piko/piko/utils"
+)
+
+// webViewConfig holds how public channel pages are paged and cached
+var webViewConfig = config.DefaultConfig().WebView
+
+// InitWebView sets how public channel pages are paged and cached
+func InitWebView(cfg config.WebViewConfig) {
+	webViewConfig = cfg
+}
+
+// WebViewContentSecurityPolicy lets channel pages use their inline styles
+// and nothing else
+const WebViewContentSecurityPolicy = "default-src 'none'; style-src 'unsafe-inline'; frame-ancestors 'none'; base-uri 'none'; form-action 'none'"
+
+// webViewCacheBytes caps the memory used by cached channel pages
+const webViewCacheBytes = 16 << 20
+
+//go:embed channel_web.html
+var channelWebPage string
+
+var channelWebTemplate = template.Must(template.New("channel").Parse(channelWebPage))
+
+// ChannelWebViewRequest represents a request to publish a channel's web view
+type ChannelWebViewRequest struct {
+	Slug string `json:"slug"`
+}
+
+// ChannelWebViewResponse represents a channel's web view settings
+type ChannelWebViewResponse struct {
+	// Slug is empty when the channel has no web view
+	Slug string `json:"slug"`
+	Path string `json:"path,omitempty"`
+}
+
+// WebChannelPost represents a post shown on a channel's web view
+type WebChannelPost struct {
+	ID        string      `json:"id"`
+	Text      string      `json:"text"`
+	Timestamp types.Time  `json:"timestamp"`
+	EditedAt  *types.Time `json:"edited_at,omitempty"`
+}
+
+// WebChannelResponse represents a page of a channel's web view, newest
+// posts first
+type WebChannelResponse struct {
+	Name        string           `json:"name"`
+	Slug        string           `json:"slug"`
+	MemberCount int              `json:"member_count"`
+	Posts       []WebChannelPost `json:"posts"`
+	Page        int              `json:"page"`
+	HasNext     bool             `json:"has_next"`
+	HasPrev     bool             `json:"has_prev"`
+}
+
+// webChannelPage is what the channel page template renders
+type webChannelPage struct {
+	*WebChannelResponse
+	Description string
+	URL         string
+	PrevPage    int
+	NextPage    int
+}
+
+// webViewResponse builds the web view settings of a channel
+func webViewResponse(slug *string) ChannelWebViewResponse {
+	if slug == nil {
+		return ChannelWebViewResponse{}
+	}
+	return ChannelWebViewResponse{Slug: *slug, Path: "/c/" + *slug}
+}
+
+// channelWebViewError writes the response for an error from managing a
+// channel's web view
+func channelWebViewError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, models.ErrChannelNotFound):
+		return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeChannelNotFound, "Channel not found")
+	case errors.Is(err, models.ErrNotChannelAdmin):
+		return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotChannelAdmin, "Only channel owners and admins can manage the web view")
+	case errors.Is(err, models.ErrChannelNotPublic):
+		return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeChannelNotPublic, "Only public channels can have a web view")
+	case errors.Is(err, models.ErrInvalidWebSlug):
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Slug must be 5-32 lowercase letters, digits and underscores, starting with a letter")
+	case errors.Is(err, models.ErrWebSlugTaken):
+		return utils.ErrorResponse(c, fiber.StatusConflict, utils.CodeSlugTaken, "Slug already taken")
+	}
+	return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to update web view")
+}
+
+// GetChannelWebView handles an owner or admin checking a channel's web view
+func GetChannelWebView() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		slug, err := models.GetChannelWebSlug(c.UserContext(), c.Params("id"), userAddress)
+		if err != nil {
+			return channelWebViewError(c, err)
+		}
+
+		return utils.OKResponse(c, webViewResponse(slug))
+	}
+}
+
+// SetChannelWebView handles an owner or admin publishing a public channel's
+// posts at /c/:slug
+func SetChannelWebView() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		req := new(ChannelWebViewRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+		slug := strings.ToLower(strings.TrimSpace(req.Slug))
+
+		if err := models.SetChannelWebSlug(c.UserContext(), c.Params("id"), slug, userAddress); err != nil {
+			return channelWebViewError(c, err)
+		}
+
+		return utils.OKResponse(c, webViewResponse(&slug))
+	}
+}
+
+// DeleteChannelWebView handles an owner or admin taking a channel's web
+// view down
+func DeleteChannelWebView() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		if err := models.ClearChannelWebSlug(c.UserContext(), c.Params("id"), userAddress); err != nil {
+			return channelWebViewError(c, err)
+		}
+
+		return utils.OKResponse(c, fiber.Map{
+			"message": localized(c, "Web view removed"),
+		})
+	}
+}
+
+// WebViewCache caches successful channel pages for webView.cacheTtl, per
+// slug and page
+func WebViewCache() fiber.Handler {
+	return cache.New(cache.Config{
+		Next: func(c *fiber.Ctx) bool {
+			return webViewConfig.CacheTTL <= 0 || c.Response().StatusCode() != fiber.StatusOK
+		},
+		Expiration:   webViewConfig.CacheTTL,
+		CacheControl: true,
+		KeyGenerator: func(c *fiber.Ctx) string {
+			return c.Path() + "?page=" + strconv.Itoa(webViewPageNumber(c))
+		},
+		MaxBytes: webViewCacheBytes,
+	})
+}
+
+// webViewPageNumber returns the requested page, starting at 1
+func webViewPageNumber(c *fiber.Ctx) int {
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		return 1
+	}
+	return page
+}
+
+// setWebViewCacheControl lets browsers and proxies cache a channel page as
+// long as the server does
+func setWebViewCacheControl(c *fiber.Ctx) {
+	if webViewConfig.CacheTTL > 0 {
+		c.Set(fiber.HeaderCacheControl, "public, max-age="+strconv.Itoa(int(webViewConfig.CacheTTL.Seconds())))
+	}
+}
+
+// loadWebChannel loads a page of the channel published under the slug in
+// the URL. Channels without a web view, made private since or throttled
+// by moderation are not found.
+func loadWebChannel(c *fiber.Ctx) (*WebChannelResponse, error) {
+	slug := strings.ToLower(c.Params("slug"))
+	channel, err := models.GetWebChannel(c.UserContext(), slug)
+	if err != nil {
+		return nil, err
+	}
+	throttled, err := models.IsChannelThrottled(c.UserContext(), channel.ID)
+	if err != nil {
+		return nil, err
+	}
+	if throttled {
+		return nil, models.ErrChannelNotFound
+	}
+
+	page := webViewPageNumber(c)
+	perPage := webViewConfig.PostsPerPage
+	if perPage <= 0 {
+		perPage = 20
+	}
+	offset := (page - 1) * perPage
+	messages, err := models.GetChannelMessages(c.UserContext(), channel.ID, perPage, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	// Posts are published as plaintext; anything else, such as media-only
+	// posts, is left out
+	posts := []WebChannelPost{}
+	for _, message := range messages {
+		if !utf8.Valid(message.EncryptedContent) || strings.TrimSpace(string(message.EncryptedContent)) == "" {
+			continue
+		}
+		posts = append(posts, WebChannelPost{
+			ID:        message.ID,
+			Text:      string(message.EncryptedContent),
+			Timestamp: message.Timestamp,
+			EditedAt:  message.EditedAt,
+		})
+	}
+
+	return &WebChannelResponse{
+		Name:        channel.Name,
+		Slug:        slug,
+		MemberCount: channel.MemberCount,
+		Posts:       posts,
+		Page:        page,
+		HasNext:     offset+len(messages) < channel.MessageCount,
+		HasPrev:     page > 1,
+	}, nil
+}
+
+// GetWebChannel handles anyone reading a page of a channel's web view as
+// JSON, for frontends that render it themselves
+func GetWebChannel() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		channel, err := loadWebChannel(c)
+		if err != nil {
+			if errors.Is(err, models.ErrChannelNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeChannelNotFound, "Channel not found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get channel")
+		}
+
+		setWebViewCacheControl(c)
+		return utils.OKResponse(c, channel)
+	}
+}
+
+// ServeChannelWebPage handles anyone opening a channel's web view in a
+// browser. The page carries OpenGraph tags so links to it get a preview.
+func ServeChannelWebPage() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		channel, err := loadWebChannel(c)
+		if err != nil {
+			if errors.Is(err, models.ErrChannelNotFound) {
+				return c.Status(fiber.StatusNotFound).SendString(localized(c, "Channel not found"))
+			}
+			return c.Status(fiber.StatusInternalServerError).SendString(localized(c, "Failed to get channel"))
+		}
+
+		page := webChannelPage{
+			WebChannelResponse: channel,
+			Description:        strconv.Itoa(channel.MemberCount) + " members",
+			PrevPage:           channel.Page - 1,
+			NextPage:           channel.Page + 1,
+		}
+		if len(channel.Posts) > 0 {
+			page.Description = excerpt(channel.Posts[0].Text, 200)
+		}
+		if webViewConfig.BaseURL != "" {
+			page.URL = strings.TrimSuffix(webViewConfig.BaseURL, "/") + "/c/" + channel.Slug
+		}
+
+		var body bytes.Buffer
+		if err := channelWebTemplate.Execute(&body, page); err != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString(localized(c, "Failed to get channel"))
+		}
+
+		setWebViewCacheControl(c)
+		c.Type("html", "utf-8")
+		return c.Status(fiber.StatusOK).Send(body.Bytes())
+	}
+}
+
+// excerpt shortens text to at most max characters on one line
+func excerpt(text string, max int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= max {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:max-1]) + "…"
+}
@@ -1,21 +1,30 @@
 package handlers
 
 import (
-	"crypto/rand"
-	"encoding/hex"
+	"context"
 	"errors"
 	"strconv"
-	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/piko/piko/crypto"
 	"github.com/piko/piko/middleware"
 	"github.com/piko/piko/models"
+	"github.com/piko/piko/notifications"
+	"github.com/piko/piko/plugins"
+	"github.com/piko/piko/types"
+	"github.com/piko/piko/utils"
 )
 
 // CreateChannelRequest represents a request to create a channel
 type CreateChannelRequest struct {
-	Name string `json:"name"`
+	Name     string `json:"name"`
+	IsPublic bool   `json:"is_public"`
+}
+
+// UpdateChannelRequest represents a request to update a channel. Visibility
+// is left unchanged when is_public is omitted.
+type UpdateChannelRequest struct {
+	Name     string `json:"name"`
+	IsPublic *bool  `json:"is_public,omitempty"`
 }
 
 // ChannelResponse represents a channel response
@@ -23,12 +32,70 @@ type ChannelResponse struct {
 	ID          string `json:"id"`
 	Name        string `json:"name"`
 	AdminAddress string `json:"admin_address"`
-	CreatedAt   string `json:"created_at"`
+	IsPublic    bool   `json:"is_public"`
+	CreatedAt   types.Time `json:"created_at"`
+	MemberCount int    `json:"member_count"`
+	MessageCount int   `json:"message_count"`
+	UnreadCount int    `json:"unread_count"`
+}
+
+// DiscoverChannelResponse represents a public channel in discovery results
+type DiscoverChannelResponse struct {
+	ChannelResponse
+	InviteToken string `json:"invite_token"`
+}
+
+// ChannelInviteLinkResponse represents a channel's invite link
+type ChannelInviteLinkResponse struct {
+	Token string `json:"token"`
+	Path  string `json:"path"`
+}
+
+// inviteTokenLength is the length of channel and group invite tokens, in
+// URL-safe characters
+const inviteTokenLength = 22
+
+// newInviteToken generates a random invite token
+func newInviteToken() (string, error) {
+	return utils.GenerateRandomString(inviteTokenLength)
+}
+
+// channelResponse converts a channel to its response format
+func channelResponse(channel *models.Channel) ChannelResponse {
+	return ChannelResponse{
+		ID:           channel.ID,
+		Name:         channel.Name,
+		AdminAddress: channel.AdminAddress,
+		IsPublic:     channel.IsPublic,
+		CreatedAt:    channel.CreatedAt,
+		MemberCount:  channel.MemberCount,
+		MessageCount: channel.MessageCount,
+	}
+}
+
+// AddChannelMemberRequest represents a request to add a member to a channel
+type AddChannelMemberRequest struct {
+	UserAddress string `json:"user_address"`
+}
+
+// ChannelMemberResponse represents a channel member in API responses
+type ChannelMemberResponse struct {
+	UserAddress string     `json:"user_address"`
+	Role        string     `json:"role"`
+	JoinedAt    types.Time `json:"joined_at"`
+}
+
+// UpdateChannelMemberRoleRequest represents a request to promote or demote a channel member
+type UpdateChannelMemberRoleRequest struct {
+	Role string `json:"role"`
 }
 
 // ChannelMessageRequest represents a request to send a message to a channel
 type ChannelMessageRequest struct {
 	EncryptedContent string `json:"encrypted_content"`
+	ReplyToMessageID string `json:"reply_to_message_id,omitempty"`
+	AttachmentIDs   []string `json:"attachment_ids,omitempty"`
+	StickerID string `json:"sticker_id,omitempty"`
 }
 
 // ChannelMessageResponse represents a channel message response
@@ -37,8 +104,16 @@ type ChannelMessageResponse struct {
 	ChannelID       string `json:"channel_id"`
 	SenderAddress   string `json:"sender_address"`
 	EncryptedContent string `json:"encrypted_content"`
-	Timestamp       string `json:"timestamp"`
+	Timestamp       types.Time `json:"timestamp"`
 	BlockID         string `json:"block_id,omitempty"`
+	ReplyToMessageID string `json:"reply_to_message_id,omitempty"`
+	StickerID string `json:"sticker_id,omitempty"`
+	ForwardedFrom   string `json:"forwarded_from,omitempty"`
+	ForwardedFromChannel string `json:"forwarded_from_channel,omitempty"`
+	Attachments     []MediaResponse `json:"attachments,omitempty"`
+	SenderDevice    *SenderDeviceResponse `json:"sender_device,omitempty"`
+	Edited          bool `json:"edited"`
+	EditedAt        *types.Time `json:"edited_at,omitempty"`
 }
 
 // CreateChannel handles creating a new channel
@@ -47,54 +122,50 @@ func CreateChannel() fiber.Handler {
 		// Get user address from context
 		adminAddress, ok := middleware.GetUserAddress(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
 		}
 
 		// Parse request body
 		req := new(CreateChannelRequest)
 		if err := c.BodyParser(req); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid request body",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
 		}
 
 		// Validate request
 		if req.Name == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Channel name is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Channel name is required")
 		}
 
 		// Generate channel ID
-		idBytes := make([]byte, 32)
-		if _, err := rand.Read(idBytes); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to generate channel ID",
-			})
+		channelID, err := utils.NewID()
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to generate channel ID")
 		}
-		channelID := hex.EncodeToString(idBytes)
 
-		// Create channel
+		// Create channel; public channels get an invite link straight away so
+		// they can be joined from discovery
 		channel := &models.Channel{
 			ID:          channelID,
 			Name:        req.Name,
 			AdminAddress: adminAddress,
+			IsPublic:    req.IsPublic,
+		}
+		if req.IsPublic {
+			token, err := newInviteToken()
+			if err != nil {
+				return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to generate invite token")
+			}
+			channel.InviteToken = &token
 		}
-		if err := models.CreateChannel(channel); err != nil {
+		if err := models.CreateChannel(c.UserContext(), channel); err != nil {
 			if errors.Is(err, models.ErrChannelAlreadyExists) {
-				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
-					"error": "Channel already exists",
-				})
+				return utils.ErrorResponse(c, fiber.StatusConflict, utils.CodeChannelExists, "Channel already exists")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to create channel",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to create channel")
 		}
 
 		// Return channel ID
-		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		return utils.CreatedResponse(c, fiber.Map{
 			"id": channelID,
 		})
 	}
@@ -106,31 +177,28 @@ func GetChannels() fiber.Handler {
 		// Get user address from context
 		userAddress, ok := middleware.GetUserAddress(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
 		}
 
 		// Get channels from database
-		channels, err := models.GetChannelsByUser(userAddress)
+		channels, err := models.GetChannelsByUser(c.UserContext(), userAddress)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get channels")
+		}
+
+		unread, err := models.GetChannelUnreadCounts(c.UserContext(), userAddress)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to get channels",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get unread counts")
 		}
 
 		// Convert channels to response format
 		response := make([]ChannelResponse, len(channels))
 		for i, channel := range channels {
-			response[i] = ChannelResponse{
-				ID:          channel.ID,
-				Name:        channel.Name,
-				AdminAddress: channel.AdminAddress,
-				CreatedAt:   channel.CreatedAt.Format(time.RFC3339),
-			}
+			response[i] = channelResponse(channel)
+			response[i].UnreadCount = unread[channel.ID]
 		}
 
-		return c.Status(fiber.StatusOK).JSON(response)
+		return utils.OKResponse(c, response)
 	}
 }
 
@@ -140,52 +208,38 @@ func GetChannel() fiber.Handler {
 		// Get user address from context
 		userAddress, ok := middleware.GetUserAddress(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
 		}
 
 		// Get channel ID from URL parameter
 		channelID := c.Params("id")
 		if channelID == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Channel ID is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Channel ID is required")
 		}
 
 		// Get channel from database
-		channel, err := models.GetChannelByID(channelID)
+		channel, err := models.GetChannelByID(c.UserContext(), channelID)
 		if err != nil {
 			if errors.Is(err, models.ErrChannelNotFound) {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"error": "Channel not found",
-				})
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeChannelNotFound, "Channel not found")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to get channel",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get channel")
 		}
 
-		// Check if user is a member of the channel
-		isMember, err := models.IsUserInChannel(channelID, userAddress)
-		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to check channel membership",
-			})
-		}
-		if !isMember {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"error": "Access denied",
-			})
+		// Public channels can be viewed by anyone, private ones only by members
+		if !channel.IsPublic {
+			isMember, err := models.IsUserInChannel(c.UserContext(), channelID, userAddress)
+			if err != nil {
+				return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check channel membership")
+			}
+			if !isMember {
+				return utils.ForbiddenResponse(c)
+			}
 		}
 
 		// Return channel
-		return c.Status(fiber.StatusOK).JSON(ChannelResponse{
-			ID:          channel.ID,
-			Name:        channel.Name,
-			AdminAddress: channel.AdminAddress,
-			CreatedAt:   channel.CreatedAt.Format(time.RFC3339),
-		})
+		middleware.ReportQuota(c, "channel_members", int64(quotaConfig.MaxChannelMembers), int64(channel.MemberCount))
+		return utils.OKResponse(c, channelResponse(channel))
 	}
 }
 
@@ -195,74 +249,58 @@ func UpdateChannel() fiber.Handler {
 		// Get user address from context
 		userAddress, ok := middleware.GetUserAddress(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
 		}
 
 		// Get channel ID from URL parameter
 		channelID := c.Params("id")
 		if channelID == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Channel ID is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Channel ID is required")
 		}
 
 		// Parse request body
-		req := new(CreateChannelRequest)
+		req := new(UpdateChannelRequest)
 		if err := c.BodyParser(req); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid request body",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
 		}
 
 		// Validate request
 		if req.Name == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Channel name is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Channel name is required")
 		}
 
 		// Get channel from database
-		channel, err := models.GetChannelByID(channelID)
+		channel, err := models.GetChannelByID(c.UserContext(), channelID)
 		if err != nil {
 			if errors.Is(err, models.ErrChannelNotFound) {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"error": "Channel not found",
-				})
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeChannelNotFound, "Channel not found")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to get channel",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get channel")
 		}
 
-		// Check if user is the admin
-		if channel.AdminAddress != userAddress {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"error": "Only the channel admin can update the channel",
-			})
-		}
-
-		// Update channel
+		// Update channel; a channel made public needs an invite link to be
+		// joinable from discovery
 		channel.Name = req.Name
-		if err := models.UpdateChannel(channel); err != nil {
+		if req.IsPublic != nil {
+			channel.IsPublic = *req.IsPublic
+		}
+		if channel.IsPublic {
+			token, err := newInviteToken()
+			if err != nil {
+				return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to generate invite token")
+			}
+			channel.InviteToken = &token
+		}
+		if err := models.UpdateChannel(c.UserContext(), channel, userAddress); err != nil {
 			if errors.Is(err, models.ErrNotChannelAdmin) {
-				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-					"error": "Only the channel admin can update the channel",
-				})
+				return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotChannelAdmin, "Only channel owners and admins can update the channel")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to update channel",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to update channel")
 		}
+		recordConversationAudit(c, models.ConversationChannel, channelID, models.AuditActionChannelUpdate, channelID, "")
 
 		// Return updated channel
-		return c.Status(fiber.StatusOK).JSON(ChannelResponse{
-			ID:          channel.ID,
-			Name:        channel.Name,
-			AdminAddress: channel.AdminAddress,
-			CreatedAt:   channel.CreatedAt.Format(time.RFC3339),
-		})
+		return utils.OKResponse(c, channelResponse(channel))
 	}
 }
 
@@ -272,38 +310,29 @@ func DeleteChannel() fiber.Handler {
 		// Get user address from context
 		userAddress, ok := middleware.GetUserAddress(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
 		}
 
 		// Get channel ID from URL parameter
 		channelID := c.Params("id")
 		if channelID == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Channel ID is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Channel ID is required")
 		}
 
 		// Delete channel
-		if err := models.DeleteChannel(channelID, userAddress); err != nil {
+		if err := models.DeleteChannel(c.UserContext(), channelID, userAddress); err != nil {
 			if errors.Is(err, models.ErrChannelNotFound) {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"error": "Channel not found",
-				})
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeChannelNotFound, "Channel not found")
 			}
 			if errors.Is(err, models.ErrNotChannelAdmin) {
-				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-					"error": "Only the channel admin can delete the channel",
-				})
+				return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotChannelOwner, "Only the channel owner can delete the channel")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to delete channel",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to delete channel")
 		}
+		recordConversationAudit(c, models.ConversationChannel, channelID, models.AuditActionChannelDelete, channelID, "")
 
-		return c.Status(fiber.StatusOK).JSON(fiber.Map{
-			"message": "Channel deleted",
+		return utils.OKResponse(c, fiber.Map{
+			"message": localized(c, "Channel deleted"),
 		})
 	}
 }
@@ -314,75 +343,67 @@ func AddChannelMember() fiber.Handler {
 		// Get user address from context
 		adminAddress, ok := middleware.GetUserAddress(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
 		}
 
 		// Get channel ID from URL parameter
 		channelID := c.Params("id")
 		if channelID == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Channel ID is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Channel ID is required")
 		}
 
 		// Parse request body
-		type AddMemberRequest struct {
-			UserAddress string `json:"user_address"`
-		}
-		req := new(AddMemberRequest)
+		req := new(AddChannelMemberRequest)
 		if err := c.BodyParser(req); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid request body",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
 		}
 
 		// Validate request
 		if req.UserAddress == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "User address is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "User address is required")
 		}
 
 		// Verify user exists
-		_, err := models.GetUserByAddress(req.UserAddress)
+		_, err := stores.Users.GetByAddress(c.UserContext(), req.UserAddress)
 		if err != nil {
 			if errors.Is(err, models.ErrUserNotFound) {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"error": "User not found",
-				})
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeUserNotFound, "User not found")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to verify user",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to verify user")
+		}
+
+		// Let plugins refuse the join
+		if rejected, err := rejectJoinByPlugins(c, &plugins.MemberJoin{
+			Kind:           plugins.ConversationChannel,
+			ConversationID: channelID,
+			UserAddress:    req.UserAddress,
+			AddedBy:        adminAddress,
+		}); rejected {
+			return err
 		}
 
 		// Add member to channel
-		err = models.AddChannelMember(channelID, req.UserAddress, adminAddress)
+		err = models.AddChannelMember(c.UserContext(), channelID, req.UserAddress, adminAddress, quotaConfig.MaxChannelMembers)
 		if err != nil {
 			if errors.Is(err, models.ErrChannelNotFound) {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"error": "Channel not found",
-				})
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeChannelNotFound, "Channel not found")
 			}
 			if errors.Is(err, models.ErrNotChannelAdmin) {
-				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-					"error": "Only the channel admin can add members",
-				})
+				return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotChannelAdmin, "Only channel owners and admins can add members")
 			}
 			if errors.Is(err, models.ErrUserAlreadyInChannel) {
-				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
-					"error": "User is already a member of the channel",
-				})
+				return utils.ErrorResponse(c, fiber.StatusConflict, utils.CodeAlreadyChannelMember, "User is already a member of the channel")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to add member to channel",
-			})
+			if errors.Is(err, models.ErrChannelFull) {
+				return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeChannelFull, "Channel is full")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to add member to channel")
 		}
+		reportChannelMembers(c, channelID)
+		recordConversationAudit(c, models.ConversationChannel, channelID, models.AuditActionChannelMemberAdd, req.UserAddress, "")
 
-		return c.Status(fiber.StatusOK).JSON(fiber.Map{
-			"message": "Member added to channel",
+		return utils.OKResponse(c, fiber.Map{
+			"message": localized(c, "Member added to channel"),
 		})
 	}
 }
@@ -393,52 +414,210 @@ func RemoveChannelMember() fiber.Handler {
 		// Get user address from context
 		adminAddress, ok := middleware.GetUserAddress(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
 		}
 
 		// Get channel ID from URL parameter
 		channelID := c.Params("id")
 		if channelID == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Channel ID is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Channel ID is required")
 		}
 
 		// Get user address from URL parameter
 		userAddress := c.Params("address")
 		if userAddress == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "User address is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "User address is required")
 		}
 
 		// Remove member from channel
-		err := models.RemoveChannelMember(channelID, userAddress, adminAddress)
+		err := models.RemoveChannelMember(c.UserContext(), channelID, userAddress, adminAddress)
 		if err != nil {
 			if errors.Is(err, models.ErrChannelNotFound) {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"error": "Channel not found",
-				})
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeChannelNotFound, "Channel not found")
 			}
 			if errors.Is(err, models.ErrNotChannelAdmin) {
-				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-					"error": "Only the channel admin can remove members",
-				})
+				return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotChannelAdmin, "Only channel owners and admins can remove members")
+			}
+			if errors.Is(err, models.ErrNotChannelOwner) {
+				return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotChannelOwner, "Only the channel owner can remove admins")
+			}
+			if errors.Is(err, models.ErrChannelOwnerImmutable) {
+				return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeOwnerProtected, "The channel owner cannot be removed")
 			}
 			if errors.Is(err, models.ErrUserNotInChannel) {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"error": "User is not a member of the channel",
-				})
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeMemberNotFound, "User is not a member of the channel")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to remove member from channel")
+		}
+		recordConversationAudit(c, models.ConversationChannel, channelID, models.AuditActionChannelMemberRemove, userAddress, "")
+
+		return utils.OKResponse(c, fiber.Map{
+			"message": localized(c, "Member removed from channel"),
+		})
+	}
+}
+
+// CreateChannelInviteLink handles generating a new invite link for a
+// channel. The previous link stops working.
+func CreateChannelInviteLink() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		// Get channel ID from URL parameter
+		channelID := c.Params("id")
+		if channelID == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Channel ID is required")
+		}
+
+		token, err := newInviteToken()
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to generate invite token")
+		}
+
+		if err := models.SetChannelInviteToken(c.UserContext(), channelID, token, userAddress); err != nil {
+			if errors.Is(err, models.ErrChannelNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeChannelNotFound, "Channel not found")
+			}
+			if errors.Is(err, models.ErrNotChannelAdmin) {
+				return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotChannelAdmin, "Only channel owners and admins can create invite links")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to remove member from channel",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to create invite link")
 		}
 
-		return c.Status(fiber.StatusOK).JSON(fiber.Map{
-			"message": "Member removed from channel",
+		return utils.CreatedResponse(c, ChannelInviteLinkResponse{
+			Token: token,
+			Path:  "/api/channels/join/" + token,
+		})
+	}
+}
+
+// JoinChannelByLink handles joining a channel with an invite token
+func JoinChannelByLink() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		// Get invite token from URL parameter
+		token := c.Params("token")
+		if token == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invite token is required")
+		}
+
+		channel, err := models.GetChannelByInviteToken(c.UserContext(), token)
+		if err != nil {
+			if errors.Is(err, models.ErrInvalidInviteToken) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeInviteInvalid, "Invite link is invalid or has been replaced")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get channel")
+		}
+
+		// Let plugins refuse the join
+		if rejected, err := rejectJoinByPlugins(c, &plugins.MemberJoin{
+			Kind:           plugins.ConversationChannel,
+			ConversationID: channel.ID,
+			UserAddress:    userAddress,
+		}); rejected {
+			return err
+		}
+
+		if err := models.JoinChannel(c.UserContext(), channel.ID, userAddress, quotaConfig.MaxChannelMembers); err != nil {
+			if errors.Is(err, models.ErrUserAlreadyInChannel) {
+				return utils.ErrorResponse(c, fiber.StatusConflict, utils.CodeAlreadyChannelMember, "User is already a member of the channel")
+			}
+			if errors.Is(err, models.ErrChannelFull) {
+				return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeChannelFull, "Channel is full")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to join channel")
+		}
+		channel.MemberCount++
+		reportChannelMembers(c, channel.ID)
+		recordConversationAudit(c, models.ConversationChannel, channel.ID, models.AuditActionChannelMemberAdd, userAddress, "invite")
+
+		return utils.OKResponse(c, channelResponse(channel))
+	}
+}
+
+// DiscoverChannels handles searching public channels by name
+func DiscoverChannels() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		query := c.Query("query")
+		pagination := utils.GetPaginationParams(c)
+
+		channels, err := models.DiscoverChannels(c.UserContext(), query, pagination.Limit, pagination.CalculateOffset())
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to discover channels")
+		}
+
+		response := make([]DiscoverChannelResponse, len(channels))
+		for i, channel := range channels {
+			response[i] = DiscoverChannelResponse{
+				ChannelResponse: channelResponse(channel),
+				InviteToken:     *channel.InviteToken,
+			}
+		}
+
+		return utils.OKResponse(c, response)
+	}
+}
+
+// UpdateChannelMemberRole handles promoting or demoting a channel member
+func UpdateChannelMemberRole() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		ownerAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		// Get channel ID from URL parameter
+		channelID := c.Params("id")
+		if channelID == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Channel ID is required")
+		}
+
+		// Get user address from URL parameter
+		userAddress := c.Params("address")
+		if userAddress == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "User address is required")
+		}
+
+		// Parse request body
+		req := new(UpdateChannelMemberRoleRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+
+		// Update member role
+		err := models.SetChannelMemberRole(c.UserContext(), channelID, userAddress, models.ChannelRole(req.Role), ownerAddress)
+		if err != nil {
+			if errors.Is(err, models.ErrInvalidChannelRole) {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Role must be admin or member")
+			}
+			if errors.Is(err, models.ErrChannelNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeChannelNotFound, "Channel not found")
+			}
+			if errors.Is(err, models.ErrNotChannelOwner) {
+				return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotChannelOwner, "Only the channel owner can change member roles")
+			}
+			if errors.Is(err, models.ErrUserNotInChannel) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeMemberNotFound, "User is not a member of the channel")
+			}
+			if errors.Is(err, models.ErrChannelOwnerImmutable) {
+				return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeOwnerProtected, "The channel owner's role cannot be changed")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to update member role")
+		}
+		recordConversationAudit(c, models.ConversationChannel, channelID, models.AuditActionChannelMemberRole, userAddress, req.Role)
+
+		return utils.OKResponse(c, fiber.Map{
+			"message": localized(c, "Member role updated"),
 		})
 	}
 }
@@ -449,54 +628,41 @@ func GetChannelMembers() fiber.Handler {
 		// Get user address from context
 		userAddress, ok := middleware.GetUserAddress(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
 		}
 
 		// Get channel ID from URL parameter
 		channelID := c.Params("id")
 		if channelID == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Channel ID is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Channel ID is required")
 		}
 
 		// Check if user is a member of the channel
-		isMember, err := models.IsUserInChannel(channelID, userAddress)
+		isMember, err := models.IsUserInChannel(c.UserContext(), channelID, userAddress)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to check channel membership",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check channel membership")
 		}
 		if !isMember {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"error": "Access denied",
-			})
+			return utils.ForbiddenResponse(c)
 		}
 
 		// Get channel members
-		members, err := models.GetChannelMembers(channelID)
+		members, err := models.GetChannelMembers(c.UserContext(), channelID)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to get channel members",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get channel members")
 		}
 
 		// Convert members to response format
-		type MemberResponse struct {
-			UserAddress string `json:"user_address"`
-			JoinedAt    string `json:"joined_at"`
-		}
-		response := make([]MemberResponse, len(members))
+		response := make([]ChannelMemberResponse, len(members))
 		for i, member := range members {
-			response[i] = MemberResponse{
+			response[i] = ChannelMemberResponse{
 				UserAddress: member.UserAddress,
-				JoinedAt:    member.JoinedAt.Format(time.RFC3339),
+				Role:        string(member.Role),
+				JoinedAt:    member.JoinedAt,
 			}
 		}
 
-		return c.Status(fiber.StatusOK).JSON(response)
+		return utils.OKResponse(c, response)
 	}
 }
 
@@ -506,84 +672,126 @@ func SendChannelMessage() fiber.Handler {
 		// Get user address from context
 		senderAddress, ok := middleware.GetUserAddress(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
 		}
 
 		// Get channel ID from URL parameter
 		channelID := c.Params("id")
 		if channelID == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Channel ID is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Channel ID is required")
 		}
 
 		// Parse request body
 		req := new(ChannelMessageRequest)
 		if err := c.BodyParser(req); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid request body",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
 		}
 
 		// Validate request
 		if req.EncryptedContent == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Encrypted content is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Encrypted content is required")
 		}
 
 		// Check if user is a member of the channel
-		isMember, err := models.IsUserInChannel(channelID, senderAddress)
+		isMember, err := models.IsUserInChannel(c.UserContext(), channelID, senderAddress)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to check channel membership",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check channel membership")
 		}
 		if !isMember {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"error": "Access denied",
-			})
+			return utils.ForbiddenResponse(c)
+		}
+
+		// Channels throttled by moderation only get a trickle of messages
+		if rejected, err := rejectThrottledChannel(c, channelID); rejected {
+			return err
 		}
 
 		// Decode encrypted content
-		encryptedContent, err := crypto.DecodeBase64(req.EncryptedContent)
+		encryptedContent, err := payloadEncoding(c).Decode(req.EncryptedContent)
 		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid encrypted content",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid encrypted content")
+		}
+		if tooLarge, err := rejectOversizedContent(c, encryptedContent); tooLarge {
+			return err
 		}
 
 		// Generate message ID
-		idBytes := make([]byte, 32)
-		if _, err := rand.Read(idBytes); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to generate message ID",
-			})
+		messageID, err := utils.NewID()
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to generate message ID")
+		}
+
+		// Replies must point at a message in the same channel
+		if req.ReplyToMessageID != "" {
+			original, err := models.GetChannelMessageByID(c.UserContext(), req.ReplyToMessageID)
+			if err != nil && !errors.Is(err, models.ErrMessageNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to verify replied message")
+			}
+			if original == nil || original.ChannelID != channelID {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid reply_to_message_id")
+			}
+		}
+
+		// Sticker messages must name an existing sticker
+		if rejected, err := rejectUnknownSticker(c, req.StickerID); rejected {
+			return err
+		}
+
+		// Cap the number of attachments per message
+		if tooLarge, err := rejectTooManyAttachments(c, req.AttachmentIDs); tooLarge {
+			return err
+		}
+
+		// Attachments must be media uploaded by the sender
+		if err := models.ValidateAttachments(c.UserContext(), senderAddress, req.AttachmentIDs); err != nil {
+			if errors.Is(err, models.ErrInvalidAttachment) {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid attachment")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to verify attachments")
+		}
+
+		// Let plugins refuse or rewrite the message
+		hooked := &plugins.Message{
+			Kind:           plugins.ConversationChannel,
+			ID:             messageID,
+			SenderAddress:  senderAddress,
+			ConversationID: channelID,
+			Content:        encryptedContent,
+		}
+		if rejected, err := rejectByPlugins(c, hooked); rejected {
+			return err
 		}
-		messageID := hex.EncodeToString(idBytes)
 
 		// Create channel message
 		message := &models.ChannelMessage{
 			ID:              messageID,
 			ChannelID:       channelID,
 			SenderAddress:   senderAddress,
-			EncryptedContent: encryptedContent,
+			EncryptedContent: hooked.Content,
+			SenderSessionID: currentSession(c),
+		}
+		if req.ReplyToMessageID != "" {
+			message.ReplyToMessageID = &req.ReplyToMessageID
 		}
-		if err := models.CreateChannelMessage(message); err != nil {
+		if req.StickerID != "" {
+			message.StickerID = &req.StickerID
+		}
+		if err := models.CreateChannelMessage(c.UserContext(), message); err != nil {
 			if errors.Is(err, models.ErrUserNotInChannel) {
-				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-					"error": "User is not a member of the channel",
-				})
+				return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotChannelMember, "User is not a member of the channel")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to create channel message",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to create channel message")
+		}
+		if err := models.AttachMedia(c.UserContext(), models.AttachmentKindChannel, messageID, senderAddress, req.AttachmentIDs); err != nil {
+			models.DeleteChannelMessage(c.UserContext(), messageID, senderAddress)
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to attach media")
 		}
 
+		// Push to members who are offline
+		go pushChannelMessage(message)
+
 		// Return message ID
-		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		return utils.CreatedResponse(c, fiber.Map{
 			"id": messageID,
 		})
 	}
@@ -595,30 +803,22 @@ func GetChannelMessages() fiber.Handler {
 		// Get user address from context
 		userAddress, ok := middleware.GetUserAddress(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
 		}
 
 		// Get channel ID from URL parameter
 		channelID := c.Params("id")
 		if channelID == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Channel ID is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Channel ID is required")
 		}
 
 		// Check if user is a member of the channel
-		isMember, err := models.IsUserInChannel(channelID, userAddress)
+		isMember, err := models.IsUserInChannel(c.UserContext(), channelID, userAddress)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to check channel membership",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check channel membership")
 		}
 		if !isMember {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"error": "Access denied",
-			})
+			return utils.ForbiddenResponse(c)
 		}
 
 		// Get pagination parameters
@@ -627,93 +827,157 @@ func GetChannelMessages() fiber.Handler {
 		if c.Query("limit") != "" {
 			limit, err = strconv.Atoi(c.Query("limit"))
 			if err != nil || limit <= 0 {
-				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"error": "Invalid limit parameter",
-				})
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid limit parameter")
 			}
 		}
 		if c.Query("offset") != "" {
 			offset, err = strconv.Atoi(c.Query("offset"))
 			if err != nil || offset < 0 {
-				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"error": "Invalid offset parameter",
-				})
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid offset parameter")
 			}
 		}
 
 		// Get channel messages
-		messages, err := models.GetChannelMessages(channelID, limit, offset)
+		messages, err := models.GetChannelMessages(c.UserContext(), channelID, limit, offset)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to get channel messages",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get channel messages")
+		}
+
+		messageIDs := make([]string, len(messages))
+		for i, message := range messages {
+			messageIDs[i] = message.ID
 		}
+		attachments := loadAttachments(c.UserContext(), models.AttachmentKindChannel, messageIDs)
+
+		sessionIDs := make([]*string, len(messages))
+		for i, message := range messages {
+			sessionIDs[i] = message.SenderSessionID
+		}
+		deviceNames := loadDeviceNames(c.UserContext(), sessionIDs)
 
 		// Convert messages to response format
 		response := make([]ChannelMessageResponse, len(messages))
 		for i, message := range messages {
-			response[i] = ChannelMessageResponse{
-				ID:              message.ID,
-				ChannelID:       message.ChannelID,
-				SenderAddress:   message.SenderAddress,
-				EncryptedContent: crypto.EncodeBase64(message.EncryptedContent),
-				Timestamp:       message.Timestamp.Format(time.RFC3339),
-			}
-			if message.BlockID != nil {
-				response[i].BlockID = *message.BlockID
-			}
+			response[i] = channelMessageResponse(c, userAddress, message, attachments[message.ID], deviceNames)
 		}
 
-		return c.Status(fiber.StatusOK).JSON(response)
+		return utils.OKResponse(c, response)
 	}
 }
 
+// channelMessageResponse converts a channel message to its response format
+// as seen by viewerAddress
+func channelMessageResponse(c *fiber.Ctx, viewerAddress string, message *models.ChannelMessage, attachments []*models.Media, deviceNames map[string]string) ChannelMessageResponse {
+	response := ChannelMessageResponse{
+		ID:              message.ID,
+		ChannelID:       message.ChannelID,
+		SenderAddress:   message.SenderAddress,
+		EncryptedContent: payloadEncoding(c).Encode(message.EncryptedContent),
+		Timestamp:       message.Timestamp,
+		Attachments:     attachmentResponses(attachments),
+		SenderDevice:    senderDeviceFor(viewerAddress, message.SenderAddress, message.SenderSessionID, deviceNames),
+		Edited:          message.EditedAt != nil,
+		EditedAt:        message.EditedAt,
+	}
+	if message.BlockID != nil {
+		response.BlockID = *message.BlockID
+	}
+	if message.ReplyToMessageID != nil {
+		response.ReplyToMessageID = *message.ReplyToMessageID
+	}
+	if message.StickerID != nil {
+		response.StickerID = *message.StickerID
+	}
+	if message.ForwardedFrom != nil {
+		response.ForwardedFrom = *message.ForwardedFrom
+	}
+	if message.ForwardedFromChannel != nil {
+		response.ForwardedFromChannel = *message.ForwardedFromChannel
+	}
+	return response
+}
+
 // DeleteChannelMessage handles deleting a channel message
 func DeleteChannelMessage() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// Get user address from context
 		userAddress, ok := middleware.GetUserAddress(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
 		}
 
 		// Get channel ID from URL parameter
 		channelID := c.Params("channel_id")
 		if channelID == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Channel ID is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Channel ID is required")
 		}
 
 		// Get message ID from URL parameter
 		messageID := c.Params("message_id")
 		if messageID == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Message ID is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Message ID is required")
+		}
+
+		// Messages of accounts under legal hold are preserved
+		message, err := models.GetChannelMessageByID(c.UserContext(), messageID)
+		if err != nil {
+			if errors.Is(err, models.ErrMessageNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeMessageNotFound, "Message not found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get message")
+		}
+		if rejected, err := rejectLegalHold(c, message.SenderAddress); rejected {
+			return err
 		}
 
 		// Delete channel message
-		if err := models.DeleteChannelMessage(messageID, userAddress); err != nil {
+		if err := models.DeleteChannelMessage(c.UserContext(), messageID, userAddress); err != nil {
 			if errors.Is(err, models.ErrMessageNotFound) {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"error": "Message not found",
-				})
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeMessageNotFound, "Message not found")
 			}
 			if errors.Is(err, models.ErrNotChannelAdmin) {
-				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-					"error": "Only the channel admin or message sender can delete the message",
-				})
+				return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotChannelAdmin, "Only channel owners, admins or the message sender can delete the message")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to delete message",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to delete message")
 		}
 
-		return c.Status(fiber.StatusOK).JSON(fiber.Map{
-			"message": "Message deleted",
+		return utils.OKResponse(c, fiber.Map{
+			"message": localized(c, "Message deleted"),
 		})
 	}
-} 
\ No newline at end of file
+}
+
+// pushChannelMessage pushes a new channel message to offline members,
+// unless the channel is throttled by moderation
+func pushChannelMessage(message *models.ChannelMessage) {
+	if throttled, err := models.IsChannelThrottled(context.Background(), message.ChannelID); err != nil || throttled {
+		return
+	}
+
+	members, err := models.GetChannelMembers(context.Background(), message.ChannelID)
+	if err != nil {
+		return
+	}
+
+	addresses := make([]string, 0, len(members))
+	for _, member := range members {
+		addresses = append(addresses, member.UserAddress)
+	}
+	muted := mutedRecipients(models.ConversationChannel, message.ChannelID, addresses)
+
+	for _, member := range members {
+		if member.UserAddress == message.SenderAddress || muted[member.UserAddress] {
+			continue
+		}
+		pushIfOffline(member.UserAddress, &notifications.Notification{
+			Title: "New channel message",
+			Body:  "You have a new message in a channel",
+			Data: map[string]string{
+				"type":           "new_channel_message",
+				"id":             message.ID,
+				"channel_id":     message.ChannelID,
+				"sender_address": message.SenderAddress,
+			},
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/blockchain"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/utils"
+)
+
+// ConsensusParamsResponse represents the rules for the next block and the
+// full schedule of rule changes
+type ConsensusParamsResponse struct {
+	Height   int                       `json:"height"`
+	Next     *models.ConsensusParams   `json:"next"`
+	Schedule []*models.ConsensusParams `json:"schedule"`
+}
+
+// GetConsensusParams handles retrieving the consensus rules by activation height
+func GetConsensusParams() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// An empty chain is building its genesis block next
+		height := -1
+		latest, err := models.GetLatestBlock(c.UserContext())
+		if err != nil && !errors.Is(err, models.ErrBlockNotFound) {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get latest block")
+		}
+		if latest != nil {
+			height = latest.Height
+		}
+
+		schedule, err := models.GetConsensusSchedule(c.UserContext())
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get consensus params")
+		}
+
+		next, err := models.GetConsensusParams(c.UserContext(), height+1)
+		if err != nil && !errors.Is(err, models.ErrConsensusParamsNotFound) {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get consensus params")
+		}
+
+		return utils.OKResponse(c, ConsensusParamsResponse{
+			Height:   height,
+			Next:     next,
+			Schedule: schedule,
+		})
+	}
+}
+
+// VerifyChain handles checking the stored chain for tampering. It reports
+// the first invalid block, if any.
+func VerifyChain() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		report, err := blockchain.ValidateChain(c.UserContext())
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to verify blockchain")
+		}
+		return utils.OKResponse(c, report)
+	}
+}
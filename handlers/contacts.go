@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"errors"
+	"log"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/utils"
+)
+
+// maxDiscoveryHashes is how many phone hashes one discovery request may carry
+const maxDiscoveryHashes = 500
+
+// Contact label limits
+const (
+	maxContactLabels      = 10
+	maxContactLabelLength = 32
+)
+
+// DiscoverContactsRequest represents a lookup of an address book's phone numbers
+type DiscoverContactsRequest struct {
+	// Hashes are hex SHA-256 hashes of "+<country code><number>" phone numbers
+	Hashes []string `json:"hashes"`
+}
+
+// DiscoveredContact represents a registered user found by their phone hash
+type DiscoveredContact struct {
+	Hash     string `json:"hash"`
+	Address  string `json:"address"`
+	Username string `json:"username,omitempty"`
+}
+
+// SaveContactRequest represents a request to add or update a contact
+type SaveContactRequest struct {
+	Address string `json:"address"`
+	Alias   string `json:"alias,omitempty"`
+	// Labels replace the contact's labels; leave them out to keep the
+	// current ones
+	Labels  *[]string `json:"labels,omitempty"`
+	Blocked bool      `json:"blocked"`
+}
+
+// SaveContact handles adding a contact or updating its alias and blocked flag
+func SaveContact() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		// Parse request body
+		req := new(SaveContactRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+
+		// Validate request
+		if req.Address == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Contact address is required")
+		}
+		if req.Address == userAddress {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "You can't add yourself as a contact")
+		}
+		if utf8.RuneCountInString(req.Alias) > 100 {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Alias must be at most 100 characters")
+		}
+
+		var labels []string
+		if req.Labels != nil {
+			var valid bool
+			if labels, valid = normalizeContactLabels(*req.Labels); !valid {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Contacts can have at most 10 labels of up to 32 characters")
+			}
+		}
+
+		// Verify the contact exists
+		if _, err := stores.Users.GetByAddress(c.UserContext(), req.Address); err != nil {
+			if errors.Is(err, models.ErrUserNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeUserNotFound, "User not found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to verify contact")
+		}
+
+		contact := &models.Contact{
+			OwnerAddress:   userAddress,
+			ContactAddress: req.Address,
+			Alias:          req.Alias,
+			Labels:         labels,
+			Blocked:        req.Blocked,
+		}
+		if err := models.SaveContact(c.UserContext(), contact); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to save contact")
+		}
+
+		// Adding, removing or blocking a contact changes who sees the
+		// user's presence
+		refreshPresenceAudience(c.UserContext(), userAddress)
+
+		saved, err := models.GetContact(c.UserContext(), userAddress, req.Address)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get contact")
+		}
+
+		return utils.OKResponse(c, saved)
+	}
+}
+
+// GetContacts handles listing the user's contacts
+func GetContacts() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		contacts, err := models.GetContacts(c.UserContext(), userAddress)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get contacts")
+		}
+
+		// Optionally keep only the contacts with a label
+		if label := strings.TrimSpace(c.Query("label")); label != "" {
+			labeled := []*models.Contact{}
+			for _, contact := range contacts {
+				if slices.ContainsFunc(contact.Labels, func(l string) bool { return strings.EqualFold(l, label) }) {
+					labeled = append(labeled, contact)
+				}
+			}
+			contacts = labeled
+		}
+
+		return utils.OKResponse(c, contacts)
+	}
+}
+
+// normalizeContactLabels trims contact labels and drops duplicates, which
+// differ only in case. It reports false if there are too many or one is
+// empty or too long.
+func normalizeContactLabels(labels []string) ([]string, bool) {
+	normalized := []string{}
+	for _, label := range labels {
+		label = strings.TrimSpace(label)
+		if label == "" || utf8.RuneCountInString(label) > maxContactLabelLength {
+			return nil, false
+		}
+		if !slices.ContainsFunc(normalized, func(l string) bool { return strings.EqualFold(l, label) }) {
+			normalized = append(normalized, label)
+		}
+	}
+	return normalized, len(normalized) <= maxContactLabels
+}
+
+// contactNames loads what the user calls the given addresses, logging
+// failures since names are only a convenience
+func contactNames(c *fiber.Ctx, userAddress string, addresses []string) map[string]*models.ContactName {
+	names, err := stores.Contacts.Names(c.UserContext(), userAddress, addresses)
+	if err != nil {
+		log.Printf("Error loading contact names: %v", err)
+		return map[string]*models.ContactName{}
+	}
+	return names
+}
+
+// DeleteContact handles removing a contact
+func DeleteContact() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		address := c.Params("address")
+		if address == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Contact address is required")
+		}
+
+		if err := models.DeleteContact(c.UserContext(), userAddress, address); err != nil {
+			if errors.Is(err, models.ErrContactNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeContactNotFound, "Contact not found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to delete contact")
+		}
+		refreshPresenceAudience(c.UserContext(), userAddress)
+
+		return utils.OKResponse(c, fiber.Map{
+			"message": localized(c, "Contact deleted successfully"),
+		})
+	}
+}
+
+// DiscoverContacts handles finding which numbers of a client's address book
+// belong to registered users. Clients send SHA-256 hashes of the numbers
+// rather than the numbers themselves.
+func DiscoverContacts() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		// Restricted users can't look other users up
+		if restricted, err := rejectRestricted(c); restricted {
+			return err
+		}
+
+		req := new(DiscoverContactsRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+		if len(req.Hashes) == 0 || len(req.Hashes) > maxDiscoveryHashes {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Between 1 and 500 hashes are required")
+		}
+
+		hashes := make([]string, 0, len(req.Hashes))
+		seen := make(map[string]bool, len(req.Hashes))
+		for _, hash := range req.Hashes {
+			hash = strings.ToLower(hash)
+			if !isPhoneHash(hash) {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Hashes must be hex SHA-256 hashes")
+			}
+			if !seen[hash] {
+				seen[hash] = true
+				hashes = append(hashes, hash)
+			}
+		}
+
+		users, err := models.GetUsersByPhoneHashes(c.UserContext(), hashes)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to discover contacts")
+		}
+
+		// Restricted users don't show up, as in searches
+		found := make([]DiscoveredContact, 0, len(users))
+		for _, user := range users {
+			if user.Address == userAddress || isRestricted(user) {
+				continue
+			}
+			found = append(found, DiscoveredContact{
+				Hash:     utils.HashPhone(user.Phone),
+				Address:  user.Address,
+				Username: user.Username,
+			})
+		}
+
+		return utils.OKResponse(c, found)
+	}
+}
+
+// isPhoneHash checks if s is a lower case hex SHA-256 hash
+func isPhoneHash(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
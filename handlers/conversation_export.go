@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/utils"
+)
+
+// conversationExportTimeout bounds how long one export may keep its query
+// open. The export is streamed after the handler returns, so it can't use
+// the request's query timeout.
+const conversationExportTimeout = time.Minute * 5
+
+// ExportConversation handles downloading every message of a direct
+// conversation as newline-delimited JSON, one MessageResponse per line. The
+// content stays encrypted; the export is a personal backup, not a decryption.
+func ExportConversation() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		user, peer, err := loadConversationUsers(c.UserContext(), userAddress, c.Params("address"))
+		if err != nil {
+			return conversationUsersError(c, err)
+		}
+
+		if err := models.RecordAudit(c.UserContext(), &models.AuditEntry{
+			ActorAddress: user.Address,
+			Action:       models.AuditActionConversationExport,
+			Target:       peer.Address,
+			IPAddress:    c.IP(),
+		}); err != nil {
+			log.Printf("Error recording audit entry: %v", err)
+		}
+
+		// The stream writer runs after the handler returns, so everything it
+		// needs from the request is captured here
+		encoding := payloadEncoding(c)
+		address, peerAddress := user.Address, peer.Address
+
+		c.Attachment(fmt.Sprintf("conversation-%s.ndjson", peerAddress))
+		c.Set(fiber.HeaderContentType, "application/x-ndjson")
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			ctx, cancel := context.WithTimeout(context.Background(), conversationExportTimeout)
+			defer cancel()
+
+			encoder := json.NewEncoder(w)
+			err := models.StreamConversation(ctx, address, peerAddress, func(message *models.Message) error {
+				return encoder.Encode(MessageResponse{
+					ID:               message.ID,
+					SenderAddress:    message.SenderAddress,
+					RecipientAddress: message.RecipientAddress,
+					EncryptedContent: encoding.Encode(message.EncryptedContent),
+					Timestamp:        message.Timestamp,
+					Status:           string(message.Status),
+					ExpirationTime:   message.ExpirationTime,
+					BlockID:          message.BlockID,
+					EditedAt:         message.EditedAt,
+					ReplyToMessageID: message.ReplyToMessageID,
+					StickerID:        message.StickerID,
+					ForwardedFrom:    message.ForwardedFrom,
+				})
+			})
+			if err == nil {
+				err = w.Flush()
+			}
+			if err != nil {
+				log.Printf("Error exporting conversation %s/%s: %v", address, peerAddress, err)
+			}
+		})
+		return nil
+	}
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/piko/piko/models"
+)
+
+// ReconcileCounters is a background task that periodically repairs drift in
+// the denormalized group and channel member/message counters
+func ReconcileCounters(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		fixed, err := models.ReconcileCounters(context.Background())
+		if err != nil {
+			log.Printf("Failed to reconcile counters: %v", err)
+			continue
+		}
+
+		if fixed > 0 {
+			log.Printf("Reconciled %d drifted group/channel counters", fixed)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/alerts"
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/config"
+	"github.com/piko/piko/metrics"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/types"
+	"github.com/piko/piko/utils"
+)
+
+// Delivery SLA report periods, in hours
+const (
+	defaultDeliverySLAHours = 24
+	maxDeliverySLAHours     = 24 * 30
+)
+
+var deliveryHourlySeconds = metrics.NewGaugeVec(
+	"piko_message_delivery_hourly_seconds",
+	"Delivery time percentiles of the latest summarized hour, by message kind.",
+	"kind", "quantile",
+)
+
+// RunDeliverySLA is a background task that summarizes each finished hour of
+// delivery times into percentiles, and alerts when the 95th percentile goes
+// over the threshold
+func RunDeliverySLA(cfg config.DeliverySLAConfig, alerter *alerts.Service) {
+	if !cfg.Enabled || cfg.RollupInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(cfg.RollupInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ctx := context.Background()
+		now := clock.Now().UTC()
+
+		hours, err := models.GetUnsummarizedDeliveryHours(ctx, now.Truncate(time.Hour))
+		if err != nil {
+			log.Printf("Error getting delivery times to summarize: %v", err)
+			continue
+		}
+		for _, hour := range hours {
+			sla, stored, err := models.SummarizeDeliveryHour(ctx, hour.Hour, hour.Kind)
+			if err != nil {
+				log.Printf("Error summarizing delivery times of %s: %v", hour.Hour, err)
+				continue
+			}
+			if stored && breachesDeliverySLA(cfg, sla) {
+				alertDeliverySLA(ctx, cfg, alerter, sla)
+			}
+		}
+
+		if cfg.Retention > 0 {
+			if err := models.DeleteDeliverySLABefore(ctx, now.Add(-cfg.Retention)); err != nil {
+				log.Printf("Error deleting old delivery times: %v", err)
+			}
+		}
+
+		// Every instance reports the latest hour, whichever summarized it
+		latest, err := models.GetLatestDeliverySLA(ctx)
+		if err != nil {
+			log.Printf("Error getting delivery times: %v", err)
+			continue
+		}
+		for _, sla := range latest {
+			kind := string(sla.Kind)
+			deliveryHourlySeconds.Set(float64(sla.P50)/1000, kind, "0.5")
+			deliveryHourlySeconds.Set(float64(sla.P95)/1000, kind, "0.95")
+			deliveryHourlySeconds.Set(float64(sla.P99)/1000, kind, "0.99")
+			deliveryHourlySeconds.Set(float64(sla.Max)/1000, kind, "1")
+		}
+	}
+}
+
+// breachesDeliverySLA reports whether an hour had enough deliveries and a
+// 95th percentile over the threshold
+func breachesDeliverySLA(cfg config.DeliverySLAConfig, sla *models.DeliverySLA) bool {
+	return cfg.P95Threshold > 0 &&
+		sla.Samples >= cfg.MinSamples &&
+		time.Duration(sla.P95)*time.Millisecond > cfg.P95Threshold
+}
+
+// alertDeliverySLA tells operators that an hour's deliveries were slow
+func alertDeliverySLA(ctx context.Context, cfg config.DeliverySLAConfig, alerter *alerts.Service, sla *models.DeliverySLA) {
+	p95 := time.Duration(sla.P95) * time.Millisecond
+	alerter.Send(ctx, &alerts.Alert{
+		Title: "Slow message delivery",
+		Message: fmt.Sprintf("95th percentile %s message delivery time was %s in the hour from %s, over the %s threshold",
+			sla.Kind, p95, types.FormatTime(sla.Hour.Time), cfg.P95Threshold),
+		Time: clock.Now(),
+		Fields: map[string]string{
+			"kind":    string(sla.Kind),
+			"hour":    types.FormatTime(sla.Hour.Time),
+			"samples": strconv.Itoa(sla.Samples),
+			"p50_ms":  strconv.FormatInt(sla.P50, 10),
+			"p95_ms":  strconv.FormatInt(sla.P95, 10),
+			"p99_ms":  strconv.FormatInt(sla.P99, 10),
+			"max_ms":  strconv.FormatInt(sla.Max, 10),
+		},
+	})
+	if err := models.MarkDeliverySLAAlerted(ctx, sla.Hour.Time, sla.Kind); err != nil {
+		log.Printf("Error recording delivery alert: %v", err)
+	}
+}
+
+// GetDeliverySLA handles listing the hourly delivery time percentiles
+func GetDeliverySLA() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		hours := defaultDeliverySLAHours
+		if c.Query("hours") != "" {
+			var err error
+			hours, err = strconv.Atoi(c.Query("hours"))
+			if err != nil || hours <= 0 || hours > maxDeliverySLAHours {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Hours must be between 1 and 720")
+			}
+		}
+
+		since := clock.Now().UTC().Truncate(time.Hour).Add(-time.Duration(hours) * time.Hour)
+		sla, err := models.GetDeliverySLA(c.UserContext(), since)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get delivery times")
+		}
+
+		return utils.OKResponse(c, sla)
+	}
+}
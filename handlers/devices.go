@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/config"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/notifications"
+	"github.com/piko/piko/utils"
+	"github.com/piko/piko/websocket"
+)
+
+// PushNotifier sends push notifications to offline users
+var PushNotifier = notifications.NewService(config.NotificationsConfig{})
+
+// InitNotifications configures the push providers
+func InitNotifications(cfg config.NotificationsConfig) {
+	PushNotifier = notifications.NewService(cfg)
+}
+
+// RegisterDeviceRequest represents a request to register a device for push notifications
+type RegisterDeviceRequest struct {
+	Token    string `json:"token"`
+	Platform string `json:"platform"`
+}
+
+// RegisterDevice handles registering a device token for push notifications
+func RegisterDevice() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		// Parse request body
+		req := new(RegisterDeviceRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+
+		// Validate request
+		if req.Token == "" || len(req.Token) > 255 {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "A valid device token is required")
+		}
+		platform := models.DevicePlatform(req.Platform)
+		if !models.IsValidDevicePlatform(platform) {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Platform must be 'fcm' or 'apns'")
+		}
+
+		device := &models.Device{
+			UserAddress: userAddress,
+			Platform:    platform,
+			Token:       req.Token,
+		}
+		if sessionID, ok := middleware.GetSessionID(c); ok {
+			device.SessionID = &sessionID
+		}
+		if err := models.RegisterDevice(c.UserContext(), device); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to register device")
+		}
+
+		return utils.CreatedResponse(c, fiber.Map{
+			"message": localized(c, "Device registered successfully"),
+		})
+	}
+}
+
+// UnregisterDevice handles removing a device token
+func UnregisterDevice() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		token := c.Params("token")
+		if token == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Device token is required")
+		}
+
+		if err := models.DeleteDevice(c.UserContext(), userAddress, token); err != nil {
+			if errors.Is(err, models.ErrDeviceNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeDeviceNotFound, "Device not found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to unregister device")
+		}
+
+		return utils.OKResponse(c, fiber.Map{
+			"message": localized(c, "Device unregistered successfully"),
+		})
+	}
+}
+
+// pushIfOffline sends a push notification to a user who has no open WebSocket
+func pushIfOffline(address string, notification *notifications.Notification) {
+	if websocket.IsUserOnline(WebSocketPool, address) {
+		return
+	}
+	PushNotifier.NotifyUser(address, localizedNotification(address, notification))
+}
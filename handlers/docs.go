@@ -0,0 +1,373 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/types"
+	"github.com/piko/piko/utils"
+	"github.com/piko/piko/websocket"
+)
+
+// Page sizes of GetDocUpdates
+const (
+	defaultDocUpdatesLimit = 100
+	maxDocUpdatesLimit     = 500
+)
+
+// CreateDocRequest represents a request to attach a document to a conversation
+type CreateDocRequest struct {
+	ConversationType models.ConversationType `json:"conversation_type"`
+	// ConversationID is the peer's address for direct conversations and the
+	// group or channel ID otherwise
+	ConversationID string `json:"conversation_id"`
+}
+
+// AppendDocUpdateRequest represents an encrypted update to a document
+type AppendDocUpdateRequest struct {
+	Update string `json:"update"`
+}
+
+// DocUpdateResponse represents an update to a document
+type DocUpdateResponse struct {
+	DocID         string     `json:"doc_id"`
+	Version       int64      `json:"version"`
+	SenderAddress string     `json:"sender_address"`
+	Update        string     `json:"update"`
+	CreatedAt     types.Time `json:"created_at"`
+}
+
+// DocUpdatesResponse represents a page of a document's updates
+type DocUpdatesResponse struct {
+	DocID string `json:"doc_id"`
+	// Version is the document's latest version
+	Version int64               `json:"version"`
+	Updates []DocUpdateResponse `json:"updates"`
+	HasMore bool                `json:"has_more"`
+}
+
+// rejectNonConversationMember writes an error response unless the user can
+// attach documents to the conversation: a member of the group or channel,
+// or a user the peer of a direct conversation hasn't blocked
+func rejectNonConversationMember(c *fiber.Ctx, conversationType models.ConversationType, conversationID, userAddress string) (bool, error) {
+	switch conversationType {
+	case models.ConversationGroup:
+		_, rejected, err := rejectNonGroupMember(c, conversationID, userAddress)
+		return rejected, err
+	case models.ConversationChannel:
+		isMember, err := models.IsUserInChannel(c.UserContext(), conversationID, userAddress)
+		if err != nil {
+			return true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check channel membership")
+		}
+		if !isMember {
+			return true, utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotChannelMember, "You are not a member of this channel")
+		}
+		return false, nil
+	}
+
+	if conversationID == userAddress {
+		return true, utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid conversation_id")
+	}
+	if _, err := stores.Users.GetByAddress(c.UserContext(), conversationID); err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return true, utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeRecipientNotFound, "Recipient not found")
+		}
+		return true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to verify recipient")
+	}
+	blocked, err := models.HasBlocked(c.UserContext(), conversationID, userAddress)
+	if err != nil {
+		return true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to verify recipient")
+	}
+	if blocked {
+		return true, utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeMessagingNotAllowed, "You can't send messages to this user")
+	}
+	return false, nil
+}
+
+// getCollaboratingDoc loads the document in the id parameter, writing an
+// error response unless the user is one of its collaborators
+func getCollaboratingDoc(c *fiber.Ctx, userAddress string) (*models.CollabDoc, bool, error) {
+	doc, err := models.GetCollabDoc(c.UserContext(), c.Params("id"))
+	if err != nil {
+		if errors.Is(err, models.ErrCollabDocNotFound) {
+			return nil, true, utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeDocumentNotFound, "Document not found")
+		}
+		return nil, true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get document")
+	}
+
+	if doc.ConversationType != models.ConversationDirect {
+		rejected, err := rejectNonConversationMember(c, doc.ConversationType, doc.ConversationID, userAddress)
+		return doc, rejected, err
+	}
+	if userAddress != doc.CreatorAddress && userAddress != doc.ConversationID {
+		return nil, true, utils.ForbiddenResponse(c)
+	}
+	return doc, false, nil
+}
+
+// docCollaborators returns the addresses of the members of a document's
+// conversation
+func docCollaborators(ctx context.Context, doc *models.CollabDoc) ([]string, error) {
+	if doc.ConversationType == models.ConversationDirect {
+		return []string{doc.CreatorAddress, doc.ConversationID}, nil
+	}
+	return conversationMembers(ctx, doc.ConversationType, doc.ConversationID)
+}
+
+// conversationMembers returns the addresses of the members of a group or
+// channel
+func conversationMembers(ctx context.Context, conversationType models.ConversationType, conversationID string) ([]string, error) {
+	if conversationType == models.ConversationChannel {
+		members, err := models.GetChannelMembers(ctx, conversationID)
+		if err != nil {
+			return nil, err
+		}
+		addresses := make([]string, len(members))
+		for i, member := range members {
+			addresses[i] = member.UserAddress
+		}
+		return addresses, nil
+	}
+
+	members, err := models.GetGroupMembers(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	addresses := make([]string, len(members))
+	for i, member := range members {
+		addresses[i] = member.UserAddress
+	}
+	return addresses, nil
+}
+
+// CreateDoc handles attaching a new, empty document to a conversation
+func CreateDoc() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		req := new(CreateDocRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+		if !models.IsValidConversationType(req.ConversationType) || req.ConversationID == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "conversation_type must be direct, group or channel, and conversation_id is required")
+		}
+		if rejected, err := rejectNonConversationMember(c, req.ConversationType, req.ConversationID, userAddress); rejected {
+			return err
+		}
+
+		docID, err := utils.NewID()
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to generate document ID")
+		}
+
+		doc := &models.CollabDoc{
+			ID:               docID,
+			ConversationType: req.ConversationType,
+			ConversationID:   req.ConversationID,
+			CreatorAddress:   userAddress,
+		}
+		if err := models.CreateCollabDoc(c.UserContext(), doc); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to create document")
+		}
+
+		return utils.CreatedResponse(c, doc)
+	}
+}
+
+// GetDirectDocs handles listing the documents of the user's direct
+// conversation with the address parameter
+func GetDirectDocs() fiber.Handler {
+	return getConversationDocs(models.ConversationDirect)
+}
+
+// GetGroupDocs handles listing the documents of a group
+func GetGroupDocs() fiber.Handler {
+	return getConversationDocs(models.ConversationGroup)
+}
+
+// GetChannelDocs handles listing the documents of a channel
+func GetChannelDocs() fiber.Handler {
+	return getConversationDocs(models.ConversationChannel)
+}
+
+// getConversationDocs handles listing the documents of a conversation. The
+// conversation is in the address parameter for direct conversations and in
+// the id parameter otherwise.
+func getConversationDocs(conversationType models.ConversationType) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		conversationID := c.Params("id")
+		if conversationType == models.ConversationDirect {
+			conversationID = c.Params("address")
+		}
+		// Anyone can list their own direct documents with a peer
+		if conversationType != models.ConversationDirect {
+			if rejected, err := rejectNonConversationMember(c, conversationType, conversationID, userAddress); rejected {
+				return err
+			}
+		}
+
+		docs, err := models.GetConversationDocs(c.UserContext(), conversationType, conversationID, userAddress)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get documents")
+		}
+
+		return utils.OKResponse(c, docs)
+	}
+}
+
+// GetDoc handles retrieving a document's record
+func GetDoc() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		doc, rejected, err := getCollaboratingDoc(c, userAddress)
+		if rejected {
+			return err
+		}
+
+		return utils.OKResponse(c, doc)
+	}
+}
+
+// AppendDocUpdate handles a collaborator appending an encrypted update to a
+// document, which is replayed to the other collaborators
+func AppendDocUpdate() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		doc, rejected, err := getCollaboratingDoc(c, userAddress)
+		if rejected {
+			return err
+		}
+		// Peers who blocked the user stop receiving their updates
+		if doc.ConversationType == models.ConversationDirect {
+			peer := doc.ConversationID
+			if peer == userAddress {
+				peer = doc.CreatorAddress
+			}
+			if rejected, err := rejectNonConversationMember(c, models.ConversationDirect, peer, userAddress); rejected {
+				return err
+			}
+		}
+
+		req := new(AppendDocUpdateRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+		if req.Update == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Update is required")
+		}
+		content, err := payloadEncoding(c).Decode(req.Update)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid update encoding")
+		}
+		if tooLarge, err := rejectOversizedContent(c, content); tooLarge {
+			return err
+		}
+
+		update, err := models.AppendCollabDocUpdate(c.UserContext(), doc.ID, userAddress, content)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to save update")
+		}
+
+		go func() {
+			collaborators, err := docCollaborators(context.Background(), doc)
+			if err != nil {
+				log.Printf("Error getting collaborators of document %s: %v", doc.ID, err)
+				return
+			}
+			websocket.NotifyDocUpdate(WebSocketPool, doc, update, collaborators)
+		}()
+
+		return utils.CreatedResponse(c, docUpdateResponse(c, update))
+	}
+}
+
+// GetDocUpdates handles replaying a document's updates after a version, so
+// collaborators can catch up
+func GetDocUpdates() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		doc, rejected, err := getCollaboratingDoc(c, userAddress)
+		if rejected {
+			return err
+		}
+
+		var after int64
+		if c.Query("after") != "" {
+			after, err = strconv.ParseInt(c.Query("after"), 10, 64)
+			if err != nil || after < 0 {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid after parameter")
+			}
+		}
+
+		limit := defaultDocUpdatesLimit
+		if c.Query("limit") != "" {
+			parsed, err := strconv.Atoi(c.Query("limit"))
+			if err != nil || parsed <= 0 {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid limit parameter")
+			}
+			limit = min(parsed, maxDocUpdatesLimit)
+		}
+
+		// One extra tells whether there are more
+		updates, err := models.GetCollabDocUpdates(c.UserContext(), doc.ID, after, limit+1)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get updates")
+		}
+
+		response := DocUpdatesResponse{DocID: doc.ID, Version: doc.Version}
+		if len(updates) > limit {
+			updates = updates[:limit]
+			response.HasMore = true
+		}
+		response.Updates = make([]DocUpdateResponse, len(updates))
+		for i, update := range updates {
+			response.Updates[i] = docUpdateResponse(c, update)
+			// Updates appended since the document was loaded
+			response.Version = max(response.Version, update.Version)
+		}
+
+		return utils.OKResponse(c, response)
+	}
+}
+
+// docUpdateResponse converts an update to its response format
+func docUpdateResponse(c *fiber.Ctx, update *models.CollabDocUpdate) DocUpdateResponse {
+	return DocUpdateResponse{
+		DocID:         update.DocID,
+		Version:       update.Version,
+		SenderAddress: update.SenderAddress,
+		Update:        payloadEncoding(c).Encode(update.Content),
+		CreatedAt:     update.CreatedAt,
+	}
+}
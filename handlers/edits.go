@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/config"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/types"
+	"github.com/piko/piko/utils"
+	"github.com/piko/piko/websocket"
+)
+
+// EditGroupMessageRequest represents a request to edit a group message
+type EditGroupMessageRequest struct {
+	Content string `json:"content"`
+}
+
+// rejectUneditable checks that the user sent a message recently enough to
+// edit it, and that its sender isn't under legal hold
+func rejectUneditable(c *fiber.Ctx, cfg *config.Config, userAddress, senderAddress string, sentAt types.Time) (bool, error) {
+	if senderAddress != userAddress {
+		return true, utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotMessageSender, "Only the sender can edit this message")
+	}
+	if clock.Now().Sub(sentAt.Time) > cfg.Messaging.EditWindow {
+		return true, utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeEditWindowExpired, models.ErrEditWindowExpired.Error())
+	}
+	// Group and channel edits keep no history, so held messages can't change
+	return rejectLegalHold(c, senderAddress)
+}
+
+// EditChannelMessage handles a sender editing their channel message within
+// the edit window
+func EditChannelMessage(cfg *config.Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		// Parse request body
+		req := new(EditMessageRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+		if req.EncryptedContent == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Encrypted content is required")
+		}
+		encryptedContent, err := payloadEncoding(c).Decode(req.EncryptedContent)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid encrypted content")
+		}
+		if tooLarge, err := rejectOversizedContent(c, encryptedContent); tooLarge {
+			return err
+		}
+
+		channelID := c.Params("id")
+		isMember, err := models.IsUserInChannel(c.UserContext(), channelID, userAddress)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check channel membership")
+		}
+		if !isMember {
+			return utils.ForbiddenResponse(c)
+		}
+
+		_, message, rejected, err := getChannelMessageIn(c, channelID, c.Params("message_id"))
+		if rejected {
+			return err
+		}
+		if rejected, err := rejectUneditable(c, cfg, userAddress, message.SenderAddress, message.Timestamp); rejected {
+			return err
+		}
+
+		editedAt, err := models.EditChannelMessage(c.UserContext(), message.ID, encryptedContent)
+		if err != nil {
+			if errors.Is(err, models.ErrMessageNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeMessageNotFound, "Message not found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to edit message")
+		}
+		message.EncryptedContent = encryptedContent
+		message.EditedAt = types.NewTimePtr(editedAt)
+
+		// Tell the channel's connected members
+		go websocket.NotifyChannelMessageEdited(WebSocketPool, message)
+
+		attachments := loadAttachments(c.UserContext(), models.AttachmentKindChannel, []string{message.ID})
+		deviceNames := loadDeviceNames(c.UserContext(), []*string{message.SenderSessionID})
+		return utils.OKResponse(c, channelMessageResponse(c, userAddress, message, attachments[message.ID], deviceNames))
+	}
+}
+
+// EditGroupMessage handles a sender editing their group message within the
+// edit window
+func EditGroupMessage(cfg *config.Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		// Parse request body
+		req := new(EditGroupMessageRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+		if req.Content == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Content is required")
+		}
+		content, err := payloadEncoding(c).Decode(req.Content)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid content encoding")
+		}
+		if tooLarge, err := rejectOversizedContent(c, content); tooLarge {
+			return err
+		}
+
+		// Only members can edit, so senders who left can't
+		groupID := c.Params("id")
+		members, err := models.GetGroupMembers(c.UserContext(), groupID)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get group members")
+		}
+		isMember := false
+		for _, member := range members {
+			if member.UserAddress == userAddress {
+				isMember = true
+				break
+			}
+		}
+		if !isMember {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotGroupMember, "You are not a member of this group")
+		}
+
+		message, err := models.GetGroupMessageByID(c.UserContext(), c.Params("message_id"))
+		if err != nil && !errors.Is(err, models.ErrMessageNotFound) {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get message")
+		}
+		if message == nil || message.GroupID != groupID {
+			return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeMessageNotFound, "Message not found")
+		}
+		if rejected, err := rejectUneditable(c, cfg, userAddress, message.SenderAddress, message.Timestamp); rejected {
+			return err
+		}
+
+		editedAt, err := models.EditGroupMessage(c.UserContext(), message.ID, content)
+		if err != nil {
+			if errors.Is(err, models.ErrMessageNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeMessageNotFound, "Message not found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to edit message")
+		}
+		message.Content = content
+		message.EditedAt = types.NewTimePtr(editedAt)
+
+		// Tell the members subscribed to the group's room
+		go websocket.NotifyGroupMessageEdited(WebSocketPool, message)
+
+		attachments := loadAttachments(c.UserContext(), models.AttachmentKindGroup, []string{message.ID})
+		deviceNames := loadDeviceNames(c.UserContext(), []*string{message.SenderSessionID})
+		return utils.OKResponse(c, GroupMessageResponse{
+			ID:               message.ID,
+			GroupID:          message.GroupID,
+			SenderAddress:    message.SenderAddress,
+			Content:          payloadEncoding(c).Encode(message.Content),
+			Timestamp:        message.Timestamp,
+			ReplyToMessageID: message.ReplyToMessageID,
+			StickerID:        message.StickerID,
+			ForwardedFrom:    message.ForwardedFrom,
+			Attachments:      attachmentResponses(attachments[message.ID]),
+			SenderDevice:     senderDeviceFor(userAddress, message.SenderAddress, message.SenderSessionID, deviceNames),
+			Edited:           true,
+			EditedAt:         message.EditedAt,
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/crypto"
+)
+
+const (
+	// headerAcceptPayloadEncoding lists the encodings a client accepts for
+	// encrypted content, in the style of Accept-Encoding
+	headerAcceptPayloadEncoding = "Accept-Payload-Encoding"
+
+	// headerPayloadEncoding tells the client which encoding was used
+	headerPayloadEncoding = "Payload-Encoding"
+)
+
+// payloadEncoding returns the encoding of encrypted content in this request
+// and its response, negotiated from the Accept-Payload-Encoding header
+func payloadEncoding(c *fiber.Ctx) crypto.Encoding {
+	if encoding, ok := c.Locals("payload_encoding").(crypto.Encoding); ok {
+		return encoding
+	}
+
+	encoding := crypto.NegotiateEncoding(c.Get(headerAcceptPayloadEncoding))
+	c.Locals("payload_encoding", encoding)
+	c.Set(headerPayloadEncoding, string(encoding))
+	return encoding
+}
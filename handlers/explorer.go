@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/utils"
+)
+
+const (
+	// defaultExplorerLimit is the page size when no limit is given
+	defaultExplorerLimit = 20
+	// maxExplorerLimit caps the page size of explorer listings
+	maxExplorerLimit = 100
+)
+
+// BlocksResponse is a page of blocks, newest first
+type BlocksResponse struct {
+	Blocks []*models.Block `json:"blocks"`
+	Total  int             `json:"total"`
+	Limit  int             `json:"limit"`
+	Offset int             `json:"offset"`
+}
+
+// TransactionsResponse is a page of transactions
+type TransactionsResponse struct {
+	Transactions []*models.Transaction `json:"transactions"`
+	Total        int                   `json:"total"`
+	Limit        int                   `json:"limit"`
+	Offset       int                   `json:"offset"`
+}
+
+// explorerPage reads the limit and offset query parameters. Limits above
+// maxExplorerLimit are capped.
+func explorerPage(c *fiber.Ctx) (limit, offset int, err error) {
+	limit = defaultExplorerLimit
+	if c.Query("limit") != "" {
+		limit, err = strconv.Atoi(c.Query("limit"))
+		if err != nil || limit <= 0 {
+			return 0, 0, errors.New("Invalid limit parameter")
+		}
+		limit = min(limit, maxExplorerLimit)
+	}
+	if c.Query("offset") != "" {
+		offset, err = strconv.Atoi(c.Query("offset"))
+		if err != nil || offset < 0 {
+			return 0, 0, errors.New("Invalid offset parameter")
+		}
+	}
+	return limit, offset, nil
+}
+
+// ListBlocks handles listing blocks, newest first
+func ListBlocks() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		limit, offset, err := explorerPage(c)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, err.Error())
+		}
+
+		blocks, total, err := models.ListBlocks(c.UserContext(), limit, offset)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get blocks")
+		}
+
+		return utils.OKResponse(c, BlocksResponse{
+			Blocks: blocks,
+			Total:  total,
+			Limit:  limit,
+			Offset: offset,
+		})
+	}
+}
+
+// ListBlockTransactions handles listing the transactions in a block
+func ListBlockTransactions() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		blockID := c.Params("id")
+		limit, offset, err := explorerPage(c)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, err.Error())
+		}
+
+		exists, err := models.BlockExists(c.UserContext(), blockID)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get block")
+		}
+		if !exists {
+			return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeBlockNotFound, "Block not found")
+		}
+
+		transactions, total, err := models.ListBlockTransactions(c.UserContext(), blockID, limit, offset)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get transactions")
+		}
+
+		return utils.OKResponse(c, TransactionsResponse{
+			Transactions: transactions,
+			Total:        total,
+			Limit:        limit,
+			Offset:       offset,
+		})
+	}
+}
+
+// ListTransactions handles listing transactions, newest first, optionally
+// filtered by type and by an RFC 3339 time range. from is inclusive and to
+// is exclusive.
+func ListTransactions() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		limit, offset, err := explorerPage(c)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, err.Error())
+		}
+
+		var filter models.TransactionFilter
+		if txType := c.Query("type"); txType != "" {
+			filter.Type = models.TransactionType(txType)
+			if !models.IsValidTransactionType(filter.Type) {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid transaction type")
+			}
+		}
+		if from := c.Query("from"); from != "" {
+			if filter.From, err = time.Parse(time.RFC3339, from); err != nil {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid from parameter, expected an RFC 3339 time")
+			}
+		}
+		if to := c.Query("to"); to != "" {
+			if filter.To, err = time.Parse(time.RFC3339, to); err != nil {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid to parameter, expected an RFC 3339 time")
+			}
+		}
+		if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "from must be before to")
+		}
+
+		transactions, total, err := models.ListTransactions(c.UserContext(), filter, limit, offset)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get transactions")
+		}
+
+		return utils.OKResponse(c, TransactionsResponse{
+			Transactions: transactions,
+			Total:        total,
+			Limit:        limit,
+			Offset:       offset,
+		})
+	}
+}
@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/notifications"
+	"github.com/piko/piko/plugins"
+	"github.com/piko/piko/utils"
+	"github.com/piko/piko/websocket"
+)
+
+// ForwardMessageRequest represents a request to forward a direct message.
+// Exactly one target must be set.
+type ForwardMessageRequest struct {
+	RecipientAddress string `json:"recipient_address,omitempty"`
+	GroupID          string `json:"group_id,omitempty"`
+	ChannelID        string `json:"channel_id,omitempty"`
+}
+
+// ForwardMessageResponse represents a forwarded copy of a message
+type ForwardMessageResponse struct {
+	ID            string `json:"id"`
+	ForwardedFrom string `json:"forwarded_from"`
+}
+
+// ForwardMessage handles copying a direct message into another
+// conversation. The copy keeps the encrypted content and attachments and
+// references the original message.
+func ForwardMessage() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		// Parse request body
+		req := new(ForwardMessageRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+
+		targets := 0
+		for _, target := range []string{req.RecipientAddress, req.GroupID, req.ChannelID} {
+			if target != "" {
+				targets++
+			}
+		}
+		if targets != 1 {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Exactly one of recipient_address, group_id or channel_id is required")
+		}
+
+		// Only the participants of a conversation can forward its messages
+		source, err := stores.Messages.Get(c.UserContext(), c.Params("id"))
+		if err != nil {
+			if errors.Is(err, models.ErrMessageNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeMessageNotFound, "Message not found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get message")
+		}
+		if source.SenderAddress != userAddress && source.RecipientAddress != userAddress {
+			return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeMessageNotFound, "Message not found")
+		}
+
+		// Copies would outlive a disappearing message
+		if source.ExpirationTime != nil {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeForwardNotAllowed, "Disappearing messages can't be forwarded")
+		}
+
+		// Forwards of forwards point at the original message
+		forwardedFrom := source.ID
+		if source.ForwardedFrom != nil {
+			forwardedFrom = *source.ForwardedFrom
+		}
+
+		messageID, err := utils.NewID()
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to generate message ID")
+		}
+
+		switch {
+		case req.RecipientAddress != "":
+			return forwardToUser(c, userAddress, source, messageID, forwardedFrom, req.RecipientAddress)
+		case req.GroupID != "":
+			return forwardToGroup(c, userAddress, source, messageID, forwardedFrom, req.GroupID)
+		default:
+			return forwardToChannel(c, userAddress, source, messageID, forwardedFrom, req.ChannelID)
+		}
+	}
+}
+
+// forwardToUser copies a message into a direct conversation
+func forwardToUser(c *fiber.Ctx, userAddress string, source *models.Message, messageID, forwardedFrom, recipientAddress string) error {
+	if _, err := stores.Users.GetByAddress(c.UserContext(), recipientAddress); err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeRecipientNotFound, "Recipient not found")
+		}
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to verify recipient")
+	}
+
+	// Users who blocked the sender don't receive their messages
+	blocked, err := models.HasBlocked(c.UserContext(), recipientAddress, userAddress)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to verify recipient")
+	}
+	if blocked {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeMessagingNotAllowed, "You can't send messages to this user")
+	}
+
+	// Let plugins refuse or rewrite the message
+	hooked := &plugins.Message{
+		Kind:           plugins.ConversationDirect,
+		ID:             messageID,
+		SenderAddress:  userAddress,
+		ConversationID: recipientAddress,
+		Content:        source.EncryptedContent,
+	}
+	if rejected, err := rejectByPlugins(c, hooked); rejected {
+		return err
+	}
+
+	message := &models.Message{
+		ID:               messageID,
+		SenderAddress:    userAddress,
+		RecipientAddress: recipientAddress,
+		EncryptedContent: hooked.Content,
+		Status:           models.MessageStatusPending,
+		ForwardedFrom:    &forwardedFrom,
+		SenderSessionID:  currentSession(c),
+		StickerID:        source.StickerID,
+	}
+	if err := models.CreateMessage(c.UserContext(), message); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to create message")
+	}
+	if _, err := models.CopyAttachments(c.UserContext(), models.AttachmentKindDirect, source.ID, models.AttachmentKindDirect, messageID); err != nil {
+		stores.Messages.Delete(c.UserContext(), messageID)
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to attach media")
+	}
+
+	// Notify recipient via WebSocket if they're online, and push if not
+	go websocket.NotifyNewMessage(WebSocketPool, message)
+	go pushUnlessMuted(message.RecipientAddress, models.ConversationDirect, message.SenderAddress, &notifications.Notification{
+		Title: "New message",
+		Body:  "You have a new message",
+		Data: map[string]string{
+			"type":           "new_message",
+			"id":             message.ID,
+			"sender_address": message.SenderAddress,
+		},
+	})
+
+	return utils.CreatedResponse(c, ForwardMessageResponse{
+		ID:            messageID,
+		ForwardedFrom: forwardedFrom,
+	})
+}
+
+// forwardToGroup copies a message into a group
+func forwardToGroup(c *fiber.Ctx, userAddress string, source *models.Message, messageID, forwardedFrom, groupID string) error {
+	members, err := models.GetGroupMembers(c.UserContext(), groupID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get group members")
+	}
+	isMember := false
+	for _, member := range members {
+		if member.UserAddress == userAddress {
+			isMember = true
+			break
+		}
+	}
+	if !isMember {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotGroupMember, "You are not a member of this group")
+	}
+
+	// Let plugins refuse or rewrite the message
+	hooked := &plugins.Message{
+		Kind:           plugins.ConversationGroup,
+		ID:             messageID,
+		SenderAddress:  userAddress,
+		ConversationID: groupID,
+		Content:        source.EncryptedContent,
+	}
+	if rejected, err := rejectByPlugins(c, hooked); rejected {
+		return err
+	}
+
+	message := &models.GroupMessage{
+		ID:              messageID,
+		GroupID:         groupID,
+		SenderAddress:   userAddress,
+		Content:         hooked.Content,
+		ForwardedFrom:   &forwardedFrom,
+		SenderSessionID: currentSession(c),
+		StickerID:       source.StickerID,
+	}
+	if err := models.CreateGroupMessage(c.UserContext(), message); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to create message")
+	}
+	attachmentIDs, err := models.CopyAttachments(c.UserContext(), models.AttachmentKindDirect, source.ID, models.AttachmentKindGroup, messageID)
+	if err != nil {
+		models.DeleteGroupMessage(c.UserContext(), messageID)
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to attach media")
+	}
+
+	// Notify group members via WebSocket
+	go notifyGroupMessage(message, attachmentIDs)
+
+	return utils.CreatedResponse(c, ForwardMessageResponse{
+		ID:            messageID,
+		ForwardedFrom: forwardedFrom,
+	})
+}
+
+// forwardToChannel copies a message into a channel
+func forwardToChannel(c *fiber.Ctx, userAddress string, source *models.Message, messageID, forwardedFrom, channelID string) error {
+	isMember, err := models.IsUserInChannel(c.UserContext(), channelID, userAddress)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check channel membership")
+	}
+	if !isMember {
+		return utils.ForbiddenResponse(c)
+	}
+	if rejected, err := rejectThrottledChannel(c, channelID); rejected {
+		return err
+	}
+
+	// Let plugins refuse or rewrite the message
+	hooked := &plugins.Message{
+		Kind:           plugins.ConversationChannel,
+		ID:             messageID,
+		SenderAddress:  userAddress,
+		ConversationID: channelID,
+		Content:        source.EncryptedContent,
+	}
+	if rejected, err := rejectByPlugins(c, hooked); rejected {
+		return err
+	}
+
+	message := &models.ChannelMessage{
+		ID:               messageID,
+		ChannelID:        channelID,
+		SenderAddress:    userAddress,
+		EncryptedContent: hooked.Content,
+		ForwardedFrom:    &forwardedFrom,
+		SenderSessionID:  currentSession(c),
+		StickerID:        source.StickerID,
+	}
+	if err := models.CreateChannelMessage(c.UserContext(), message); err != nil {
+		if errors.Is(err, models.ErrUserNotInChannel) {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotChannelMember, "User is not a member of the channel")
+		}
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to create channel message")
+	}
+	if _, err := models.CopyAttachments(c.UserContext(), models.AttachmentKindDirect, source.ID, models.AttachmentKindChannel, messageID); err != nil {
+		models.DeleteChannelMessage(c.UserContext(), messageID, userAddress)
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to attach media")
+	}
+
+	// Notify channel members via WebSocket, and push to those offline
+	go websocket.NotifyNewChannelMessage(WebSocketPool, message)
+	go pushChannelMessage(message)
+
+	return utils.CreatedResponse(c, ForwardMessageResponse{
+		ID:            messageID,
+		ForwardedFrom: forwardedFrom,
+	})
+}
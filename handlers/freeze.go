@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/config"
+	"github.com/piko/piko/crypto"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/notifications"
+	"github.com/piko/piko/types"
+	"github.com/piko/piko/utils"
+	"github.com/piko/piko/websocket"
+)
+
+// errInvalidRecoveryCode is returned for a wrong phone or recovery code,
+// without telling which
+var errInvalidRecoveryCode = errors.New("Invalid recovery code")
+
+// FreezeAccountRequest identifies the account to freeze when not signed in
+type FreezeAccountRequest struct {
+	Phone        string `json:"phone,omitempty"`
+	RecoveryCode string `json:"recovery_code,omitempty"`
+}
+
+// FreezeAccountResponse tells how long an account is frozen
+type FreezeAccountResponse struct {
+	Message     string     `json:"message"`
+	FrozenUntil types.Time `json:"frozen_until"`
+	Revoked     int        `json:"revoked"`
+}
+
+// RecoveryCodeResponse holds a new recovery code. It is only shown once.
+type RecoveryCodeResponse struct {
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// hashRecoveryCode hashes a recovery code, ignoring case, spaces and dashes
+func hashRecoveryCode(code string) string {
+	code = strings.NewReplacer("-", "", " ", "").Replace(strings.ToUpper(code))
+	return crypto.HashToHex([]byte(code))
+}
+
+// rejectBlockedAccount writes a 403 response if an operator suspended the
+// account, or a 423 response if it is frozen
+func rejectBlockedAccount(c *fiber.Ctx, userAddress string) (bool, error) {
+	suspension, err := models.GetAccountSuspension(c.UserContext(), userAddress)
+	if err != nil {
+		return true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check account")
+	}
+	if suspension != nil {
+		return true, utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeAccountSuspended, "Account is suspended")
+	}
+
+	frozenUntil, err := models.GetAccountFrozenUntil(c.UserContext(), userAddress)
+	if err != nil {
+		return true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check account")
+	}
+	if frozenUntil == nil {
+		return false, nil
+	}
+	return true, utils.ErrorResponseWith(c, fiber.StatusLocked, utils.CodeAccountFrozen, "Account is frozen", fiber.Map{
+		"frozen_until": types.NewTime(*frozenUntil),
+	})
+}
+
+// accountBlocked reports whether an account is suspended or frozen, for
+// connections that can't be answered like rejectBlockedAccount does
+func accountBlocked(ctx context.Context, userAddress string) (bool, error) {
+	suspension, err := models.GetAccountSuspension(ctx, userAddress)
+	if err != nil {
+		return false, err
+	}
+	if suspension != nil {
+		return true, nil
+	}
+
+	frozenUntil, err := models.GetAccountFrozenUntil(ctx, userAddress)
+	if err != nil {
+		return false, err
+	}
+	return frozenUntil != nil, nil
+}
+
+// rejectBlockedPhone is rejectBlockedAccount for the account of a phone, if
+// there is one
+func rejectBlockedPhone(c *fiber.Ctx, phone string) (bool, error) {
+	user, err := stores.Users.GetByPhone(c.UserContext(), phone)
+	if errors.Is(err, models.ErrUserNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check account")
+	}
+	return rejectBlockedAccount(c, user.Address)
+}
+
+// CreateRecoveryCode handles generating a recovery code that can freeze the
+// account without signing in. Any earlier code stops working.
+func CreateRecoveryCode() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		codeBytes, err := crypto.GenerateRandomBytes(15)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to generate recovery code")
+		}
+
+		// 24 characters, in groups of four for writing down
+		encoded := base32.StdEncoding.EncodeToString(codeBytes)
+		groups := make([]string, 0, len(encoded)/4)
+		for i := 0; i < len(encoded); i += 4 {
+			groups = append(groups, encoded[i:i+4])
+		}
+		code := strings.Join(groups, "-")
+
+		if err := models.SetRecoveryCode(c.UserContext(), userAddress, hashRecoveryCode(code)); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to save recovery code")
+		}
+
+		recordSessionAudit(c, userAddress, models.AuditActionRecoveryCodeCreate, userAddress)
+
+		return utils.CreatedResponse(c, RecoveryCodeResponse{RecoveryCode: code})
+	}
+}
+
+// FreezeAccount handles an emergency freeze by a user who believes their key
+// or phone was stolen. Every session is signed out, new logins are refused
+// for the cooldown, and the user's devices and phone are told. Signed-in
+// users freeze their own account; others give their phone and recovery code.
+func FreezeAccount(cfg *config.Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		req := new(FreezeAccountRequest)
+		if err := c.BodyParser(req); err != nil && len(c.Body()) > 0 {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+
+		var user *models.User
+		var err error
+		if userAddress, ok := middleware.GetUserAddress(c); ok {
+			user, err = stores.Users.GetByAddress(c.UserContext(), userAddress)
+		} else {
+			if req.Phone == "" || req.RecoveryCode == "" {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Phone number and recovery code are required")
+			}
+			user, err = freezingUser(c, req)
+		}
+		if err != nil {
+			if errors.Is(err, models.ErrUserNotFound) || errors.Is(err, errInvalidRecoveryCode) {
+				return utils.ErrorResponse(c, fiber.StatusUnauthorized, utils.CodeInvalidRecoveryCode, errInvalidRecoveryCode.Error())
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to find user")
+		}
+
+		frozenUntil := clock.Now().Add(cfg.Auth.FreezeCooldown)
+		if err := models.FreezeAccount(c.UserContext(), user.Address, frozenUntil); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to freeze account")
+		}
+		if stored, err := models.GetAccountFrozenUntil(c.UserContext(), user.Address); err == nil && stored != nil {
+			frozenUntil = *stored
+		}
+
+		revoked, err := models.RevokeOtherSessions(c.UserContext(), user.Address, "", models.SessionRevokedFreeze)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to revoke sessions")
+		}
+
+		recordSessionAudit(c, user.Address, models.AuditActionAccountFreeze, user.Address)
+
+		// Tell the user everywhere they can be reached and disconnect their
+		// WebSocket, then forget the push tokens of the signed out devices
+		websocket.NotifyAccountFrozen(WebSocketPool, user.Address, frozenUntil)
+		title := localized(c, "Account frozen")
+		body := localized(c, "Your account was frozen and all devices were signed out")
+		message := fmt.Sprintf("%s. %s: %s", body, localized(c, "New logins are blocked until"), frozenUntil.UTC().Format(time.RFC1123))
+		go func() {
+			PushNotifier.NotifyUser(user.Address, &notifications.Notification{
+				Title: title,
+				Body:  body,
+				Data: map[string]string{
+					"type":         websocket.MessageTypeAccountFrozen,
+					"frozen_until": types.FormatTime(frozenUntil),
+				},
+			})
+			forgetSessionDevices(revoked)
+
+			if err := utils.SendSMS(utils.FromConfigSMS(&cfg.SMS), user.Phone, message); err != nil {
+				log.Printf("Error sending account freeze SMS: %v", err)
+			}
+		}()
+
+		return utils.OKResponse(c, FreezeAccountResponse{
+			Message:     localized(c, "Account frozen"),
+			FrozenUntil: types.NewTime(frozenUntil),
+			Revoked:     len(revoked),
+		})
+	}
+}
+
+// freezingUser finds the user a phone and recovery code belong to
+func freezingUser(c *fiber.Ctx, req *FreezeAccountRequest) (*models.User, error) {
+	user, err := stores.Users.GetByPhone(c.UserContext(), req.Phone)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := models.HasRecoveryCode(c.UserContext(), user.Address, hashRecoveryCode(req.RecoveryCode))
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errInvalidRecoveryCode
+	}
+	return user, nil
+}
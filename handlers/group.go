@@ -1,15 +1,17 @@
 package handlers
 
 import (
-	"crypto/rand"
-	"encoding/hex"
+	"context"
 	"errors"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/piko/piko/crypto"
 	"github.com/piko/piko/middleware"
 	"github.com/piko/piko/models"
+	"github.com/piko/piko/notifications"
+	"github.com/piko/piko/plugins"
+	"github.com/piko/piko/types"
+	"github.com/piko/piko/utils"
 	"github.com/piko/piko/websocket"
 )
 
@@ -22,33 +24,63 @@ type CreateGroupRequest struct {
 
 // GroupResponse represents a group response
 type GroupResponse struct {
-	ID          string `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	PhotoURL    string `json:"photo_url,omitempty"`
-	CreatedBy   string `json:"created_by"`
-	MemberCount int    `json:"member_count"`
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	PhotoURL     string `json:"photo_url,omitempty"`
+	CreatedBy    string `json:"created_by"`
+	MemberCount  int    `json:"member_count"`
+	MessageCount int    `json:"message_count"`
+	UnreadCount  int    `json:"unread_count"`
+	// TopicsEnabled means the group is in topic mode
+	TopicsEnabled bool `json:"topics_enabled"`
+	// JoinRequestsEnabled means users can ask to join with POST /api/groups/:id/join
+	JoinRequestsEnabled bool `json:"join_requests_enabled"`
 }
 
 // GroupMemberResponse represents a group member response
 type GroupMemberResponse struct {
+	UserAddress string     `json:"user_address"`
+	Role        string     `json:"role"`
+	JoinedAt    types.Time `json:"joined_at"`
+}
+
+// AddGroupMemberRequest represents a request to add a member to a group
+type AddGroupMemberRequest struct {
 	UserAddress string `json:"user_address"`
-	Role        string `json:"role"`
-	JoinedAt    string `json:"joined_at"`
+	IsAdmin     bool   `json:"is_admin"`
 }
 
 // SendGroupMessageRequest represents a request to send a message to a group
 type SendGroupMessageRequest struct {
-	Content string `json:"content"`
+	Content          string   `json:"content"`
+	ReplyToMessageID string   `json:"reply_to_message_id,omitempty"`
+	AttachmentIDs    []string `json:"attachment_ids,omitempty"`
+	// TopicID posts the message to a topic of a group in topic mode
+	TopicID string `json:"topic_id,omitempty"`
+	// StickerID sends a sticker; the content carries a fallback for clients
+	// that can't show it
+	StickerID string `json:"sticker_id,omitempty"`
 }
 
 // GroupMessageResponse represents a group message response
 type GroupMessageResponse struct {
-	ID            string `json:"id"`
-	GroupID       string `json:"group_id"`
-	SenderAddress string `json:"sender_address"`
-	Content       string `json:"content"`
-	Timestamp     string `json:"timestamp"`
+	ID               string                `json:"id"`
+	GroupID          string                `json:"group_id"`
+	SenderAddress    string                `json:"sender_address"`
+	Content          string                `json:"content"`
+	Timestamp        types.Time            `json:"timestamp"`
+	ReplyToMessageID *string               `json:"reply_to_message_id,omitempty"`
+	StickerID        *string               `json:"sticker_id,omitempty"`
+	ForwardedFrom    *string               `json:"forwarded_from,omitempty"`
+	Attachments      []MediaResponse       `json:"attachments,omitempty"`
+	SenderDevice     *SenderDeviceResponse `json:"sender_device,omitempty"`
+	System           bool                  `json:"system,omitempty"`
+	Edited           bool                  `json:"edited"`
+	EditedAt         *types.Time           `json:"edited_at,omitempty"`
+	TopicID          *string               `json:"topic_id,omitempty"`
+	// Contact is what the viewer calls the sender
+	Contact *models.ContactName `json:"contact,omitempty"`
 }
 
 // CreateGroup handles creating a new group
@@ -57,34 +89,31 @@ func CreateGroup() fiber.Handler {
 		// Get user address from context
 		userAddress, ok := middleware.GetUserAddress(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
 		}
 
 		// Parse request body
 		req := new(CreateGroupRequest)
 		if err := c.BodyParser(req); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid request body",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
 		}
 
 		// Validate request
 		if req.Name == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Group name is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Group name is required")
+		}
+
+		// Photos must be uploaded media or on an allowed domain
+		photoURL, err := resolvePhotoURL(c.UserContext(), userAddress, req.PhotoURL, "")
+		if err != nil {
+			return photoURLError(c, err)
 		}
 
 		// Generate group ID
-		idBytes := make([]byte, 32)
-		if _, err := rand.Read(idBytes); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to generate group ID",
-			})
+		groupID, err := utils.NewID()
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to generate group ID")
 		}
-		groupID := hex.EncodeToString(idBytes)
 
 		// Create group
 		group := &models.Group{
@@ -92,16 +121,17 @@ func CreateGroup() fiber.Handler {
 			Name:           req.Name,
 			Description:    req.Description,
 			CreatorAddress: userAddress,
-			PhotoURL:       req.PhotoURL,
+			PhotoURL:       photoURL,
 		}
-		if err := models.CreateGroup(group, userAddress); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to create group",
-			})
+		if err := models.CreateGroup(c.UserContext(), group, userAddress); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to create group")
 		}
 
+		// Subscribe the creator's connection to the group's messages
+		WebSocketPool.JoinRoom(websocket.GroupRoom(groupID), userAddress)
+
 		// Return group ID
-		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		return utils.CreatedResponse(c, fiber.Map{
 			"id": groupID,
 		})
 	}
@@ -113,33 +143,38 @@ func GetGroups() fiber.Handler {
 		// Get user address from context
 		userAddress, ok := middleware.GetUserAddress(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
 		}
 
 		// Get groups from database
-		groups, err := models.GetUserGroups(userAddress)
+		groups, err := models.GetUserGroups(c.UserContext(), userAddress)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get groups")
+		}
+
+		unread, err := models.GetGroupUnreadCounts(c.UserContext(), userAddress)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to get groups",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get unread counts")
 		}
 
 		// Convert groups to response format
 		response := make([]GroupResponse, len(groups))
 		for i, group := range groups {
 			response[i] = GroupResponse{
-				ID:          group.ID,
-				Name:        group.Name,
-				Description: group.Description,
-				PhotoURL:    group.PhotoURL,
-				CreatedBy:   group.CreatorAddress,
-				MemberCount: group.MemberCount,
+				ID:                  group.ID,
+				Name:                group.Name,
+				Description:         group.Description,
+				PhotoURL:            group.PhotoURL,
+				CreatedBy:           group.CreatorAddress,
+				MemberCount:         group.MemberCount,
+				MessageCount:        group.MessageCount,
+				UnreadCount:         unread[group.ID],
+				TopicsEnabled:       group.TopicsEnabled,
+				JoinRequestsEnabled: group.JoinRequestsEnabled,
 			}
 		}
 
-		return c.Status(fiber.StatusOK).JSON(response)
+		return utils.OKResponse(c, response)
 	}
 }
 
@@ -149,25 +184,19 @@ func GetGroup() fiber.Handler {
 		// Get user address from context
 		userAddress, ok := middleware.GetUserAddress(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
 		}
 
 		// Get group ID from URL parameter
 		groupID := c.Params("id")
 		if groupID == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Group ID is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Group ID is required")
 		}
 
 		// Check if user is a member of the group
-		members, err := models.GetGroupMembers(groupID)
+		members, err := models.GetGroupMembers(c.UserContext(), groupID)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to get group members",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get group members")
 		}
 
 		isMember := false
@@ -179,32 +208,30 @@ func GetGroup() fiber.Handler {
 		}
 
 		if !isMember {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"error": "You are not a member of this group",
-			})
+			return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotGroupMember, "You are not a member of this group")
 		}
 
 		// Get group from database
-		group, err := models.GetGroupByID(groupID)
+		group, err := models.GetGroupByID(c.UserContext(), groupID)
 		if err != nil {
 			if errors.Is(err, models.ErrGroupNotFound) {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"error": "Group not found",
-				})
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeGroupNotFound, "Group not found")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to get group",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get group")
 		}
 
 		// Return group
-		return c.Status(fiber.StatusOK).JSON(GroupResponse{
-			ID:          group.ID,
-			Name:        group.Name,
-			Description: group.Description,
-			PhotoURL:    group.PhotoURL,
-			CreatedBy:   group.CreatorAddress,
-			MemberCount: group.MemberCount,
+		middleware.ReportQuota(c, "group_members", int64(quotaConfig.MaxGroupMembers), int64(group.MemberCount))
+		return utils.OKResponse(c, GroupResponse{
+			ID:                  group.ID,
+			Name:                group.Name,
+			Description:         group.Description,
+			PhotoURL:            group.PhotoURL,
+			CreatedBy:           group.CreatorAddress,
+			MemberCount:         group.MemberCount,
+			MessageCount:        group.MessageCount,
+			TopicsEnabled:       group.TopicsEnabled,
+			JoinRequestsEnabled: group.JoinRequestsEnabled,
 		})
 	}
 }
@@ -215,57 +242,41 @@ func UpdateGroup() fiber.Handler {
 		// Get user address from context
 		userAddress, ok := middleware.GetUserAddress(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
 		}
 
 		// Get group ID from URL parameter
 		groupID := c.Params("id")
 		if groupID == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Group ID is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Group ID is required")
 		}
 
 		// Check if user is an admin of the group
-		isAdmin, err := models.IsGroupAdmin(groupID, userAddress)
+		isAdmin, err := models.IsGroupAdmin(c.UserContext(), groupID, userAddress)
 		if err != nil {
 			if errors.Is(err, models.ErrGroupMemberNotFound) {
-				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-					"error": "You are not a member of this group",
-				})
+				return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotGroupMember, "You are not a member of this group")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to check admin status",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check admin status")
 		}
 
 		if !isAdmin {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"error": "You are not an admin of this group",
-			})
+			return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotGroupAdmin, "You are not an admin of this group")
 		}
 
 		// Get group from database
-		group, err := models.GetGroupByID(groupID)
+		group, err := models.GetGroupByID(c.UserContext(), groupID)
 		if err != nil {
 			if errors.Is(err, models.ErrGroupNotFound) {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"error": "Group not found",
-				})
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeGroupNotFound, "Group not found")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to get group",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get group")
 		}
 
 		// Parse request body
 		req := new(CreateGroupRequest)
 		if err := c.BodyParser(req); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid request body",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
 		}
 
 		// Update group fields
@@ -273,23 +284,29 @@ func UpdateGroup() fiber.Handler {
 			group.Name = req.Name
 		}
 		group.Description = req.Description
-		group.PhotoURL = req.PhotoURL
+		photoURL, err := resolvePhotoURL(c.UserContext(), userAddress, req.PhotoURL, group.PhotoURL)
+		if err != nil {
+			return photoURLError(c, err)
+		}
+		group.PhotoURL = photoURL
 
 		// Save changes
-		if err := models.UpdateGroup(group); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to update group",
-			})
+		if err := models.UpdateGroup(c.UserContext(), group); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to update group")
 		}
+		recordConversationAudit(c, models.ConversationGroup, groupID, models.AuditActionGroupUpdate, groupID, "")
 
 		// Return updated group
-		return c.Status(fiber.StatusOK).JSON(GroupResponse{
-			ID:          group.ID,
-			Name:        group.Name,
-			Description: group.Description,
-			PhotoURL:    group.PhotoURL,
-			CreatedBy:   group.CreatorAddress,
-			MemberCount: group.MemberCount,
+		return utils.OKResponse(c, GroupResponse{
+			ID:                  group.ID,
+			Name:                group.Name,
+			Description:         group.Description,
+			PhotoURL:            group.PhotoURL,
+			CreatedBy:           group.CreatorAddress,
+			MemberCount:         group.MemberCount,
+			MessageCount:        group.MessageCount,
+			TopicsEnabled:       group.TopicsEnabled,
+			JoinRequestsEnabled: group.JoinRequestsEnabled,
 		})
 	}
 }
@@ -300,48 +317,41 @@ func DeleteGroup() fiber.Handler {
 		// Get user address from context
 		userAddress, ok := middleware.GetUserAddress(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
 		}
 
 		// Get group ID from URL parameter
 		groupID := c.Params("id")
 		if groupID == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Group ID is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Group ID is required")
 		}
 
 		// Get group from database
-		group, err := models.GetGroupByID(groupID)
+		group, err := models.GetGroupByID(c.UserContext(), groupID)
 		if err != nil {
 			if errors.Is(err, models.ErrGroupNotFound) {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"error": "Group not found",
-				})
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeGroupNotFound, "Group not found")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to get group",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get group")
 		}
 
-		// Check if user is the creator of the group
+		// Only the owner can delete the group, or any admin once an owner
+		// has left it without transferring ownership
 		if group.CreatorAddress != userAddress {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"error": "Only the creator can delete the group",
-			})
+			if rejected, err := rejectUnlessOrphanedGroupAdmin(c, group, userAddress); rejected {
+				return err
+			}
 		}
 
 		// Delete group
-		if err := models.DeleteGroup(groupID); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to delete group",
-			})
+		if err := models.DeleteGroup(c.UserContext(), groupID); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to delete group")
 		}
+		WebSocketPool.CloseRoom(websocket.GroupRoom(groupID))
+		recordConversationAudit(c, models.ConversationGroup, groupID, models.AuditActionGroupDelete, groupID, "")
 
-		return c.Status(fiber.StatusOK).JSON(fiber.Map{
-			"message": "Group deleted successfully",
+		return utils.OKResponse(c, fiber.Map{
+			"message": localized(c, "Group deleted successfully"),
 		})
 	}
 }
@@ -352,67 +362,46 @@ func AddGroupMember() fiber.Handler {
 		// Get user address from context
 		userAddress, ok := middleware.GetUserAddress(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
 		}
 
 		// Get group ID from URL parameter
 		groupID := c.Params("id")
 		if groupID == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Group ID is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Group ID is required")
 		}
 
 		// Check if user is an admin of the group
-		isAdmin, err := models.IsGroupAdmin(groupID, userAddress)
+		isAdmin, err := models.IsGroupAdmin(c.UserContext(), groupID, userAddress)
 		if err != nil {
 			if errors.Is(err, models.ErrGroupMemberNotFound) {
-				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-					"error": "You are not a member of this group",
-				})
+				return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotGroupMember, "You are not a member of this group")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to check admin status",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check admin status")
 		}
 
 		if !isAdmin {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"error": "You are not an admin of this group",
-			})
+			return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotGroupAdmin, "You are not an admin of this group")
 		}
 
 		// Parse request body
-		var req struct {
-			UserAddress string `json:"user_address"`
-			IsAdmin     bool   `json:"is_admin"`
-		}
-		if err := c.BodyParser(&req); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid request body",
-			})
+		req := new(AddGroupMemberRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
 		}
 
 		// Validate request
 		if req.UserAddress == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "User address is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "User address is required")
 		}
 
 		// Check if user exists
-		_, err = models.GetUserByAddress(req.UserAddress)
+		_, err = stores.Users.GetByAddress(c.UserContext(), req.UserAddress)
 		if err != nil {
 			if errors.Is(err, models.ErrUserNotFound) {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"error": "User not found",
-				})
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeUserNotFound, "User not found")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to check user",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check user")
 		}
 
 		// Determine role
@@ -421,21 +410,33 @@ func AddGroupMember() fiber.Handler {
 			role = models.GroupRoleAdmin
 		}
 
+		// Let plugins refuse the join
+		if rejected, err := rejectJoinByPlugins(c, &plugins.MemberJoin{
+			Kind:           plugins.ConversationGroup,
+			ConversationID: groupID,
+			UserAddress:    req.UserAddress,
+			AddedBy:        userAddress,
+		}); rejected {
+			return err
+		}
+
 		// Add member to group
-		err = models.AddGroupMember(groupID, req.UserAddress, role)
+		err = models.AddGroupMember(c.UserContext(), groupID, req.UserAddress, role, quotaConfig.MaxGroupMembers)
 		if err != nil {
 			if errors.Is(err, models.ErrAlreadyGroupMember) {
-				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
-					"error": "User is already a member of this group",
-				})
+				return utils.ErrorResponse(c, fiber.StatusConflict, utils.CodeAlreadyGroupMember, "User is already a member of this group")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to add member",
-			})
+			if errors.Is(err, models.ErrGroupFull) {
+				return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeGroupFull, "Group is full")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to add member")
 		}
+		WebSocketPool.JoinRoom(websocket.GroupRoom(groupID), req.UserAddress)
+		reportGroupMembers(c, groupID)
+		recordConversationAudit(c, models.ConversationGroup, groupID, models.AuditActionGroupMemberAdd, req.UserAddress, string(role))
 
-		return c.Status(fiber.StatusOK).JSON(fiber.Map{
-			"message": "Member added successfully",
+		return utils.OKResponse(c, fiber.Map{
+			"message": localized(c, "Member added successfully"),
 		})
 	}
 }
@@ -446,56 +447,47 @@ func RemoveGroupMember() fiber.Handler {
 		// Get user address from context
 		userAddress, ok := middleware.GetUserAddress(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
 		}
 
 		// Get group ID and member address from URL parameters
 		groupID := c.Params("id")
 		memberAddress := c.Params("address")
 		if groupID == "" || memberAddress == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Group ID and member address are required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Group ID and member address are required")
 		}
 
 		// Check if user is an admin of the group or is removing themselves
 		if userAddress != memberAddress {
-			isAdmin, err := models.IsGroupAdmin(groupID, userAddress)
+			isAdmin, err := models.IsGroupAdmin(c.UserContext(), groupID, userAddress)
 			if err != nil {
 				if errors.Is(err, models.ErrGroupMemberNotFound) {
-					return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-						"error": "You are not a member of this group",
-					})
+					return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotGroupMember, "You are not a member of this group")
 				}
-				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-					"error": "Failed to check admin status",
-				})
+				return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check admin status")
 			}
 
 			if !isAdmin {
-				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-					"error": "You are not an admin of this group",
-				})
+				return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotGroupAdmin, "You are not an admin of this group")
 			}
 		}
 
 		// Remove member from group
-		err := models.RemoveGroupMember(groupID, memberAddress)
+		err := models.RemoveGroupMember(c.UserContext(), groupID, memberAddress)
 		if err != nil {
 			if errors.Is(err, models.ErrGroupMemberNotFound) {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"error": "Member not found in group",
-				})
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeMemberNotFound, "Member not found in group")
+			}
+			if rejected, err := rejectAdminlessGroup(c, err); rejected {
+				return err
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to remove member",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to remove member")
 		}
+		WebSocketPool.LeaveRoom(websocket.GroupRoom(groupID), memberAddress)
+		recordConversationAudit(c, models.ConversationGroup, groupID, models.AuditActionGroupMemberRemove, memberAddress, "")
 
-		return c.Status(fiber.StatusOK).JSON(fiber.Map{
-			"message": "Member removed successfully",
+		return utils.OKResponse(c, fiber.Map{
+			"message": localized(c, "Member removed successfully"),
 		})
 	}
 }
@@ -506,25 +498,19 @@ func GetGroupMembers() fiber.Handler {
 		// Get user address from context
 		userAddress, ok := middleware.GetUserAddress(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
 		}
 
 		// Get group ID from URL parameter
 		groupID := c.Params("id")
 		if groupID == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Group ID is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Group ID is required")
 		}
 
 		// Check if user is a member of the group
-		members, err := models.GetGroupMembers(groupID)
+		members, err := models.GetGroupMembers(c.UserContext(), groupID)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to get group members",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get group members")
 		}
 
 		isMember := false
@@ -536,9 +522,7 @@ func GetGroupMembers() fiber.Handler {
 		}
 
 		if !isMember {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"error": "You are not a member of this group",
-			})
+			return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotGroupMember, "You are not a member of this group")
 		}
 
 		// Convert members to response format
@@ -547,11 +531,11 @@ func GetGroupMembers() fiber.Handler {
 			response[i] = GroupMemberResponse{
 				UserAddress: member.UserAddress,
 				Role:        string(member.Role),
-				JoinedAt:    member.JoinedAt.Format("2006-01-02T15:04:05Z07:00"),
+				JoinedAt:    member.JoinedAt,
 			}
 		}
 
-		return c.Status(fiber.StatusOK).JSON(response)
+		return utils.OKResponse(c, response)
 	}
 }
 
@@ -561,91 +545,140 @@ func SendGroupMessage() fiber.Handler {
 		// Get user address from context
 		userAddress, ok := middleware.GetUserAddress(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
 		}
 
 		// Get group ID from URL parameter
 		groupID := c.Params("id")
 		if groupID == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Group ID is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Group ID is required")
 		}
 
 		// Check if user is a member of the group
-		members, err := models.GetGroupMembers(groupID)
+		members, err := models.GetGroupMembers(c.UserContext(), groupID)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to get group members",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get group members")
 		}
 
-		isMember := false
+		isMember, isAdmin := false, false
 		for _, member := range members {
 			if member.UserAddress == userAddress {
 				isMember = true
+				isAdmin = member.Role == models.GroupRoleAdmin
 				break
 			}
 		}
 
+		// Guests need a pass that lets them write
 		if !isMember {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"error": "You are not a member of this group",
-			})
+			if rejected, err := rejectWithoutGuestPass(c, groupID, userAddress, true); rejected {
+				return err
+			}
 		}
 
 		// Parse request body
 		req := new(SendGroupMessageRequest)
 		if err := c.BodyParser(req); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid request body",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
 		}
 
 		// Validate request
 		if req.Content == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Content is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Content is required")
 		}
 
 		// Generate message ID
-		idBytes := make([]byte, 32)
-		if _, err := rand.Read(idBytes); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to generate message ID",
-			})
+		messageID, err := utils.NewID()
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to generate message ID")
 		}
-		messageID := hex.EncodeToString(idBytes)
 
 		// Create message
-		content, err := crypto.DecodeBase64(req.Content)
+		content, err := payloadEncoding(c).Decode(req.Content)
 		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid content encoding",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid content encoding")
+		}
+		if tooLarge, err := rejectOversizedContent(c, content); tooLarge {
+			return err
+		}
+
+		// Replies must point at a message in the same group
+		if req.ReplyToMessageID != "" {
+			original, err := models.GetGroupMessageByID(c.UserContext(), req.ReplyToMessageID)
+			if err != nil && !errors.Is(err, models.ErrMessageNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to verify replied message")
+			}
+			if original == nil || original.GroupID != groupID {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid reply_to_message_id")
+			}
+		}
+
+		if req.TopicID != "" {
+			if rejected, err := rejectUnpostableTopic(c, groupID, req.TopicID, isAdmin); rejected {
+				return err
+			}
+		}
+
+		// Sticker messages must name an existing sticker
+		if rejected, err := rejectUnknownSticker(c, req.StickerID); rejected {
+			return err
+		}
+
+		// Cap the number of attachments per message
+		if tooLarge, err := rejectTooManyAttachments(c, req.AttachmentIDs); tooLarge {
+			return err
+		}
+
+		// Attachments must be media uploaded by the sender
+		if err := models.ValidateAttachments(c.UserContext(), userAddress, req.AttachmentIDs); err != nil {
+			if errors.Is(err, models.ErrInvalidAttachment) {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid attachment")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to verify attachments")
+		}
+
+		// Let plugins refuse or rewrite the message
+		hooked := &plugins.Message{
+			Kind:           plugins.ConversationGroup,
+			ID:             messageID,
+			SenderAddress:  userAddress,
+			ConversationID: groupID,
+			Content:        content,
+		}
+		if rejected, err := rejectByPlugins(c, hooked); rejected {
+			return err
 		}
 
 		message := &models.GroupMessage{
-			ID:            messageID,
-			GroupID:       groupID,
-			SenderAddress: userAddress,
-			Content:       content,
+			ID:              messageID,
+			GroupID:         groupID,
+			SenderAddress:   userAddress,
+			Content:         hooked.Content,
+			SenderSessionID: currentSession(c),
+		}
+		if req.ReplyToMessageID != "" {
+			message.ReplyToMessageID = &req.ReplyToMessageID
+		}
+		if req.TopicID != "" {
+			message.TopicID = &req.TopicID
+		}
+		if req.StickerID != "" {
+			message.StickerID = &req.StickerID
 		}
 
 		// Save message to database
-		if err := models.CreateGroupMessage(message); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to create message",
-			})
+		if err := models.CreateGroupMessage(c.UserContext(), message); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to create message")
+		}
+		if err := models.AttachMedia(c.UserContext(), models.AttachmentKindGroup, messageID, userAddress, req.AttachmentIDs); err != nil {
+			models.DeleteGroupMessage(c.UserContext(), messageID)
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to attach media")
 		}
 
 		// Notify group members via WebSocket
-		go notifyGroupMessage(groupID, message)
+		go notifyGroupMessage(message, req.AttachmentIDs)
 
-		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		return utils.CreatedResponse(c, fiber.Map{
 			"id": messageID,
 		})
 	}
@@ -657,25 +690,19 @@ func GetGroupMessages() fiber.Handler {
 		// Get user address from context
 		userAddress, ok := middleware.GetUserAddress(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
 		}
 
 		// Get group ID from URL parameter
 		groupID := c.Params("id")
 		if groupID == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Group ID is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Group ID is required")
 		}
 
 		// Check if user is a member of the group
-		members, err := models.GetGroupMembers(groupID)
+		members, err := models.GetGroupMembers(c.UserContext(), groupID)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to get group members",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get group members")
 		}
 
 		isMember := false
@@ -687,9 +714,9 @@ func GetGroupMessages() fiber.Handler {
 		}
 
 		if !isMember {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"error": "You are not a member of this group",
-			})
+			if rejected, err := rejectWithoutGuestPass(c, groupID, userAddress, false); rejected {
+				return err
+			}
 		}
 
 		// Get pagination parameters
@@ -709,51 +736,94 @@ func GetGroupMessages() fiber.Handler {
 		}
 
 		// Get messages from database
-		messages, err := models.GetGroupMessages(groupID, limit, offset)
+		messages, err := models.GetGroupMessages(c.UserContext(), groupID, c.Query("topic_id"), limit, offset)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to get messages",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get messages")
 		}
 
+		messageIDs := make([]string, len(messages))
+		for i, message := range messages {
+			messageIDs[i] = message.ID
+		}
+		attachments := loadAttachments(c.UserContext(), models.AttachmentKindGroup, messageIDs)
+
+		sessionIDs := make([]*string, len(messages))
+		senders := make([]string, len(messages))
+		for i, message := range messages {
+			sessionIDs[i] = message.SenderSessionID
+			senders[i] = message.SenderAddress
+		}
+		deviceNames := loadDeviceNames(c.UserContext(), sessionIDs)
+		names := contactNames(c, userAddress, senders)
+
 		// Convert messages to response format
 		response := make([]GroupMessageResponse, len(messages))
 		for i, message := range messages {
 			response[i] = GroupMessageResponse{
-				ID:            message.ID,
-				GroupID:       message.GroupID,
-				SenderAddress: message.SenderAddress,
-				Content:       crypto.EncodeBase64(message.Content),
-				Timestamp:     message.Timestamp.Format("2006-01-02T15:04:05Z07:00"),
+				ID:               message.ID,
+				GroupID:          message.GroupID,
+				SenderAddress:    message.SenderAddress,
+				Content:          payloadEncoding(c).Encode(message.Content),
+				Timestamp:        message.Timestamp,
+				ReplyToMessageID: message.ReplyToMessageID,
+				StickerID:        message.StickerID,
+				ForwardedFrom:    message.ForwardedFrom,
+				Attachments:      attachmentResponses(attachments[message.ID]),
+				SenderDevice:     senderDeviceFor(userAddress, message.SenderAddress, message.SenderSessionID, deviceNames),
+				System:           message.System,
+				Edited:           message.EditedAt != nil,
+				EditedAt:         message.EditedAt,
+				TopicID:          message.TopicID,
+				Contact:          names[message.SenderAddress],
 			}
 		}
 
-		return c.Status(fiber.StatusOK).JSON(response)
+		return utils.OKResponse(c, response)
 	}
 }
 
-// notifyGroupMessage notifies all group members about a new message
-func notifyGroupMessage(groupID string, message *models.GroupMessage) {
+// notifyGroupMessage fans a new message out to the group's connected
+// members and pushes a notification to the others
+func notifyGroupMessage(message *models.GroupMessage, attachmentIDs []string) {
+	delivered := make(map[string]bool)
+	for _, address := range websocket.NotifyNewGroupMessage(WebSocketPool, message, attachmentIDs) {
+		delivered[address] = true
+	}
+
 	// Get group members
-	members, err := models.GetGroupMembers(groupID)
+	members, err := models.GetGroupMembers(context.Background(), message.GroupID)
 	if err != nil {
 		return
 	}
 
-	// Notify all online members except the sender
+	data := map[string]string{
+		"type":           "new_group_message",
+		"id":             message.ID,
+		"group_id":       message.GroupID,
+		"sender_address": message.SenderAddress,
+	}
+	if message.TopicID != nil {
+		data["topic_id"] = *message.TopicID
+	}
+
+	recipients := make([]string, 0, len(members))
 	for _, member := range members {
-		if member.UserAddress == message.SenderAddress {
-			continue
+		if member.UserAddress != message.SenderAddress && !delivered[member.UserAddress] {
+			recipients = append(recipients, member.UserAddress)
 		}
+	}
 
-		WebSocketPool.Broadcast <- websocket.Message{
-			Type: "new_group_message",
-			Payload: map[string]interface{}{
-				"id":             message.ID,
-				"group_id":       message.GroupID,
-				"sender_address": message.SenderAddress,
-			},
-			To: member.UserAddress,
+	// Members who muted the group get the message without a notification
+	muted := mutedRecipients(models.ConversationGroup, message.GroupID, recipients)
+	for _, address := range recipients {
+		if muted[address] {
+			continue
 		}
+
+		pushIfOffline(address, &notifications.Notification{
+			Title: "New group message",
+			Body:  "You have a new message in a group",
+			Data:  data,
+		})
 	}
 }
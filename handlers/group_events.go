@@ -0,0 +1,380 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/config"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/notifications"
+	"github.com/piko/piko/types"
+	"github.com/piko/piko/utils"
+	"github.com/piko/piko/websocket"
+)
+
+// CreateGroupEventRequest represents a request to schedule a group event
+type CreateGroupEventRequest struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	StartsAt    types.Time `json:"starts_at"`
+}
+
+// RSVPRequest represents a member's answer to a group event
+type RSVPRequest struct {
+	Status models.RSVPStatus `json:"status"`
+}
+
+// GroupEventResponse represents a group event with its answers
+type GroupEventResponse struct {
+	*models.GroupEvent
+	RSVPs []*models.GroupEventRSVP `json:"rsvps"`
+}
+
+// rejectNonGroupMember writes a 403 response if the user isn't a member of
+// the group, and otherwise reports whether they are one of its admins
+func rejectNonGroupMember(c *fiber.Ctx, groupID, userAddress string) (isAdmin, rejected bool, err error) {
+	isAdmin, err = models.IsGroupAdmin(c.UserContext(), groupID, userAddress)
+	if err != nil {
+		if errors.Is(err, models.ErrGroupMemberNotFound) {
+			return false, true, utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotGroupMember, "You are not a member of this group")
+		}
+		return false, true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check group membership")
+	}
+	return isAdmin, false, nil
+}
+
+// CreateGroupEvent handles a member scheduling an event in a group
+func CreateGroupEvent() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		groupID := c.Params("id")
+		if _, rejected, err := rejectNonGroupMember(c, groupID, userAddress); rejected {
+			return err
+		}
+
+		req := new(CreateGroupEventRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+		req.Title = strings.TrimSpace(req.Title)
+		if req.Title == "" || utf8.RuneCountInString(req.Title) > 100 {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Title is required and must be at most 100 characters")
+		}
+		if utf8.RuneCountInString(req.Description) > 2000 {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Description must be at most 2000 characters")
+		}
+		if !req.StartsAt.After(clock.Now()) {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Event must start in the future")
+		}
+
+		eventID, err := utils.NewID()
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to generate event ID")
+		}
+
+		event := &models.GroupEvent{
+			ID:             eventID,
+			GroupID:        groupID,
+			CreatorAddress: userAddress,
+			Title:          req.Title,
+			Description:    req.Description,
+			StartsAt:       req.StartsAt,
+		}
+		if err := models.CreateGroupEvent(c.UserContext(), event); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to create event")
+		}
+
+		// The creator is going unless they say otherwise
+		if err := models.SetGroupEventRSVP(c.UserContext(), eventID, userAddress, models.RSVPGoing); err != nil {
+			log.Printf("Error recording RSVP of the creator of event %s: %v", eventID, err)
+		}
+
+		return groupEventResponse(c, fiber.StatusCreated, groupID, eventID)
+	}
+}
+
+// GetGroupEvents handles listing a group's upcoming events
+func GetGroupEvents() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		groupID := c.Params("id")
+		if _, rejected, err := rejectNonGroupMember(c, groupID, userAddress); rejected {
+			return err
+		}
+
+		// Events stay listed for a day after they start
+		pagination := utils.GetPaginationParams(c)
+		events, err := models.GetGroupEvents(c.UserContext(), groupID, clock.Now().Add(-24*time.Hour), pagination.Limit)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get events")
+		}
+
+		return utils.OKResponse(c, events)
+	}
+}
+
+// GetGroupEvent handles retrieving a group event with its answers
+func GetGroupEvent() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		groupID := c.Params("id")
+		if _, rejected, err := rejectNonGroupMember(c, groupID, userAddress); rejected {
+			return err
+		}
+
+		return groupEventResponse(c, fiber.StatusOK, groupID, c.Params("event_id"))
+	}
+}
+
+// DeleteGroupEvent handles cancelling a group event. Only its creator and
+// the group's admins may cancel it.
+func DeleteGroupEvent() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		groupID := c.Params("id")
+		isAdmin, rejected, err := rejectNonGroupMember(c, groupID, userAddress)
+		if rejected {
+			return err
+		}
+
+		eventID := c.Params("event_id")
+		event, err := models.GetGroupEvent(c.UserContext(), groupID, eventID)
+		if err != nil {
+			if errors.Is(err, models.ErrGroupEventNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeEventNotFound, "Event not found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get event")
+		}
+		if event.CreatorAddress != userAddress && !isAdmin {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotGroupAdmin, "Only the event's creator and group admins can cancel it")
+		}
+
+		if err := models.DeleteGroupEvent(c.UserContext(), groupID, eventID); err != nil && !errors.Is(err, models.ErrGroupEventNotFound) {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to cancel event")
+		}
+
+		return utils.OKResponse(c, fiber.Map{
+			"message": localized(c, "Event cancelled"),
+		})
+	}
+}
+
+// RSVPGroupEvent handles a member answering whether they will attend a
+// group event
+func RSVPGroupEvent() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		groupID := c.Params("id")
+		if _, rejected, err := rejectNonGroupMember(c, groupID, userAddress); rejected {
+			return err
+		}
+
+		req := new(RSVPRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+		if !models.IsValidRSVPStatus(req.Status) {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Status must be going, maybe or not_going")
+		}
+
+		eventID := c.Params("event_id")
+		if rejected, err := rejectMissingGroupEvent(c, groupID, eventID); rejected {
+			return err
+		}
+
+		if err := models.SetGroupEventRSVP(c.UserContext(), eventID, userAddress, req.Status); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to save RSVP")
+		}
+
+		return groupEventResponse(c, fiber.StatusOK, groupID, eventID)
+	}
+}
+
+// DeleteGroupEventRSVP handles a member withdrawing their answer to a
+// group event
+func DeleteGroupEventRSVP() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		groupID := c.Params("id")
+		if _, rejected, err := rejectNonGroupMember(c, groupID, userAddress); rejected {
+			return err
+		}
+
+		eventID := c.Params("event_id")
+		if rejected, err := rejectMissingGroupEvent(c, groupID, eventID); rejected {
+			return err
+		}
+
+		if err := models.DeleteGroupEventRSVP(c.UserContext(), eventID, userAddress); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to withdraw RSVP")
+		}
+
+		return groupEventResponse(c, fiber.StatusOK, groupID, eventID)
+	}
+}
+
+// rejectMissingGroupEvent writes a 404 response if the group has no such event
+func rejectMissingGroupEvent(c *fiber.Ctx, groupID, eventID string) (bool, error) {
+	if _, err := models.GetGroupEvent(c.UserContext(), groupID, eventID); err != nil {
+		if errors.Is(err, models.ErrGroupEventNotFound) {
+			return true, utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeEventNotFound, "Event not found")
+		}
+		return true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get event")
+	}
+	return false, nil
+}
+
+// groupEventResponse writes a group event and its answers
+func groupEventResponse(c *fiber.Ctx, status int, groupID, eventID string) error {
+	event, err := models.GetGroupEvent(c.UserContext(), groupID, eventID)
+	if err != nil {
+		if errors.Is(err, models.ErrGroupEventNotFound) {
+			return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeEventNotFound, "Event not found")
+		}
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get event")
+	}
+
+	rsvps, err := models.GetGroupEventRSVPs(c.UserContext(), eventID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get RSVPs")
+	}
+
+	return utils.SuccessResponse(c, status, GroupEventResponse{GroupEvent: event, RSVPs: rsvps})
+}
+
+// RunGroupEvents is a background task that announces group events as they
+// start and reminds members of the ones starting soon
+func RunGroupEvents(cfg config.GroupEventConfig) {
+	if cfg.CheckInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(cfg.CheckInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ctx := context.Background()
+		now := clock.Now()
+
+		// Starts go first, so events that started while the server was
+		// down are announced rather than reminded of
+		started, err := models.GetStartedGroupEvents(ctx, now)
+		if err != nil {
+			log.Printf("Failed to get started group events: %v", err)
+		}
+		for _, event := range started {
+			announceGroupEvent(ctx, event, now)
+		}
+
+		due, err := models.GetGroupEventsToRemind(ctx, now.Add(cfg.ReminderLead))
+		if err != nil {
+			log.Printf("Failed to get group events to remind: %v", err)
+		}
+		for _, event := range due {
+			remindGroupEvent(ctx, event, now)
+		}
+	}
+}
+
+// announceGroupEvent posts a system message to an event's group as it starts
+func announceGroupEvent(ctx context.Context, event *models.GroupEvent, now time.Time) {
+	claimed, err := models.ClaimGroupEventStart(ctx, event.ID, now)
+	if err != nil || !claimed {
+		if err != nil {
+			log.Printf("Failed to mark group event %s started: %v", event.ID, err)
+		}
+		return
+	}
+
+	messageID, err := utils.NewID()
+	if err != nil {
+		log.Printf("Failed to generate ID of start message of group event %s: %v", event.ID, err)
+		return
+	}
+
+	// The server holds no group keys, so system messages are plain text
+	message := &models.GroupMessage{
+		ID:      messageID,
+		GroupID: event.GroupID,
+		Content: []byte("Event starting now: " + event.Title),
+		System:  true,
+	}
+	if err := models.CreateGroupMessage(ctx, message); err != nil {
+		log.Printf("Failed to post start message of group event %s: %v", event.ID, err)
+		return
+	}
+
+	go notifyGroupMessage(message, nil)
+}
+
+// remindGroupEvent reminds the members of an event's group who haven't
+// declined it that it starts soon, over WebSocket or push
+func remindGroupEvent(ctx context.Context, event *models.GroupEvent, now time.Time) {
+	claimed, err := models.ClaimGroupEventReminder(ctx, event.ID, now)
+	if err != nil || !claimed {
+		if err != nil {
+			log.Printf("Failed to mark group event %s reminded: %v", event.ID, err)
+		}
+		return
+	}
+
+	recipients, err := models.GetGroupEventReminderRecipients(ctx, event)
+	if err != nil {
+		log.Printf("Failed to get recipients of group event %s reminder: %v", event.ID, err)
+		return
+	}
+
+	muted := mutedRecipients(models.ConversationGroup, event.GroupID, recipients)
+	for _, address := range recipients {
+		if websocket.NotifyGroupEventReminder(WebSocketPool, address, event) || muted[address] {
+			continue
+		}
+		pushIfOffline(address, &notifications.Notification{
+			Title: "Event starting soon",
+			Body:  event.Title,
+			Data: map[string]string{
+				"type":      websocket.MessageTypeGroupEventReminder,
+				"event_id":  event.ID,
+				"group_id":  event.GroupID,
+				"starts_at": types.FormatTime(event.StartsAt.Time),
+			},
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/crypto"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/types"
+	"github.com/piko/piko/utils"
+)
+
+// Group export formats
+const (
+	exportFormatNDJSON = "ndjson"
+	exportFormatCSV    = "csv"
+)
+
+// GroupExportMessage is a line of a group export: a message with the
+// sender's membership. The content is exported as stored.
+type GroupExportMessage struct {
+	ID               string      `json:"id"`
+	SenderAddress    string      `json:"sender_address"`
+	SenderUsername   string      `json:"sender_username,omitempty"`
+	SenderRole       string      `json:"sender_role,omitempty"`
+	SenderJoinedAt   *types.Time `json:"sender_joined_at,omitempty"`
+	Content          string      `json:"content"`
+	Timestamp        types.Time  `json:"timestamp"`
+	ReplyToMessageID *string     `json:"reply_to_message_id,omitempty"`
+	ForwardedFrom    *string     `json:"forwarded_from,omitempty"`
+	TopicID          *string     `json:"topic_id,omitempty"`
+	System           bool        `json:"system,omitempty"`
+	EditedAt         *types.Time `json:"edited_at,omitempty"`
+}
+
+// groupExportColumns are the columns of a CSV group export, in the order
+// of GroupExportMessage.csvRecord
+var groupExportColumns = []string{
+	"id", "sender_address", "sender_username", "sender_role", "sender_joined_at", "content",
+	"timestamp", "reply_to_message_id", "forwarded_from", "topic_id", "system", "edited_at",
+}
+
+// newGroupExportMessage converts a history entry to its export line
+func newGroupExportMessage(entry *models.GroupHistoryEntry, encoding crypto.Encoding) GroupExportMessage {
+	message := GroupExportMessage{
+		ID:               entry.ID,
+		SenderAddress:    entry.SenderAddress,
+		SenderUsername:   entry.SenderUsername,
+		SenderJoinedAt:   entry.SenderJoinedAt,
+		Content:          encoding.Encode(entry.Content),
+		Timestamp:        entry.Timestamp,
+		ReplyToMessageID: entry.ReplyToMessageID,
+		ForwardedFrom:    entry.ForwardedFrom,
+		TopicID:          entry.TopicID,
+		System:           entry.System,
+		EditedAt:         entry.EditedAt,
+	}
+	if entry.SenderRole != nil {
+		message.SenderRole = string(*entry.SenderRole)
+	}
+	return message
+}
+
+// csvRecord returns the message as a row of a CSV export
+func (m GroupExportMessage) csvRecord() []string {
+	optional := func(value *string) string {
+		if value == nil {
+			return ""
+		}
+		return *value
+	}
+	optionalTime := func(value *types.Time) string {
+		if value == nil {
+			return ""
+		}
+		return types.FormatTime(value.Time)
+	}
+	return []string{
+		m.ID, m.SenderAddress, m.SenderUsername, m.SenderRole, optionalTime(m.SenderJoinedAt), m.Content,
+		types.FormatTime(m.Timestamp.Time), optional(m.ReplyToMessageID), optional(m.ForwardedFrom), optional(m.TopicID),
+		strconv.FormatBool(m.System), optionalTime(m.EditedAt),
+	}
+}
+
+// ExportGroup handles a group admin downloading the group's whole message
+// history, as newline-delimited JSON or CSV. Messages are streamed in
+// pages, so the export works for groups of any size.
+func ExportGroup() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		groupID := c.Params("id")
+		format := c.Query("format", exportFormatNDJSON)
+		if format != exportFormatNDJSON && format != exportFormatCSV {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Format must be ndjson or csv")
+		}
+
+		if _, err := models.GetGroupByID(c.UserContext(), groupID); err != nil {
+			if errors.Is(err, models.ErrGroupNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeGroupNotFound, "Group not found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get group")
+		}
+		isAdmin, rejected, err := rejectNonGroupMember(c, groupID, userAddress)
+		if rejected {
+			return err
+		}
+		if !isAdmin {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotGroupAdmin, "You are not an admin of this group")
+		}
+
+		recordConversationAudit(c, models.ConversationGroup, groupID, models.AuditActionGroupExport, groupID, format)
+
+		// The stream writer runs after the handler returns, so everything it
+		// needs from the request is captured here
+		encoding := payloadEncoding(c)
+
+		c.Attachment(fmt.Sprintf("group-%s.%s", groupID, format))
+		if format == exportFormatCSV {
+			c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+		} else {
+			c.Set(fiber.HeaderContentType, "application/x-ndjson")
+		}
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			ctx, cancel := context.WithTimeout(context.Background(), conversationExportTimeout)
+			defer cancel()
+
+			var err error
+			if format == exportFormatCSV {
+				err = writeGroupExportCSV(ctx, w, groupID, encoding)
+			} else {
+				encoder := json.NewEncoder(w)
+				err = models.StreamGroupHistory(ctx, groupID, func(entry *models.GroupHistoryEntry) error {
+					return encoder.Encode(newGroupExportMessage(entry, encoding))
+				})
+			}
+			if err == nil {
+				err = w.Flush()
+			}
+			if err != nil {
+				log.Printf("Error exporting group %s: %v", groupID, err)
+			}
+		})
+		return nil
+	}
+}
+
+// writeGroupExportCSV writes a group's history as CSV with a header row
+func writeGroupExportCSV(ctx context.Context, w *bufio.Writer, groupID string, encoding crypto.Encoding) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(groupExportColumns); err != nil {
+		return err
+	}
+	err := models.StreamGroupHistory(ctx, groupID, func(entry *models.GroupHistoryEntry) error {
+		return writer.Write(newGroupExportMessage(entry, encoding).csvRecord())
+	})
+	if err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/types"
+	"github.com/piko/piko/utils"
+)
+
+// GrantGuestPassRequest represents an admin letting a non-member into a
+// group until a given time
+type GrantGuestPassRequest struct {
+	UserAddress string             `json:"user_address"`
+	Access      models.GuestAccess `json:"access,omitempty"`
+	ExpiresAt   types.Time         `json:"expires_at"`
+}
+
+// rejectWithoutGuestPass writes a 403 response unless the user, who isn't a
+// member of the group, holds an unexpired guest pass for it that allows
+// sending when write is set
+func rejectWithoutGuestPass(c *fiber.Ctx, groupID, userAddress string, write bool) (bool, error) {
+	pass, err := models.GetGuestPass(c.UserContext(), groupID, userAddress)
+	if err != nil {
+		if errors.Is(err, models.ErrGuestPassNotFound) {
+			return true, utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotGroupMember, "You are not a member of this group")
+		}
+		return true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check group membership")
+	}
+	if write && !pass.CanWrite() {
+		return true, utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeGuestPassReadOnly, "Your guest pass is read-only")
+	}
+	return false, nil
+}
+
+// GrantGuestPass handles an admin giving a user who isn't a member
+// time-limited access to a group's messages
+func GrantGuestPass() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		groupID := c.Params("id")
+		if rejected, err := rejectNonGroupAdmin(c, groupID, userAddress); rejected {
+			return err
+		}
+
+		req := new(GrantGuestPassRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+		if req.UserAddress == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "User address is required")
+		}
+		if req.Access == "" {
+			req.Access = models.GuestAccessRead
+		}
+		if req.Access != models.GuestAccessRead && req.Access != models.GuestAccessWrite {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Access must be read or write")
+		}
+		if !req.ExpiresAt.After(clock.Now()) {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Expiry must be in the future")
+		}
+
+		if _, err := stores.Users.GetByAddress(c.UserContext(), req.UserAddress); err != nil {
+			if errors.Is(err, models.ErrUserNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeUserNotFound, "User not found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check user")
+		}
+
+		// Members already have full access
+		_, err := models.IsGroupAdmin(c.UserContext(), groupID, req.UserAddress)
+		if err == nil {
+			return utils.ErrorResponse(c, fiber.StatusConflict, utils.CodeAlreadyGroupMember, "User is already a member of this group")
+		}
+		if !errors.Is(err, models.ErrGroupMemberNotFound) {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check group membership")
+		}
+
+		pass := &models.GuestPass{
+			GroupID:     groupID,
+			UserAddress: req.UserAddress,
+			Access:      req.Access,
+			GrantedBy:   userAddress,
+			ExpiresAt:   req.ExpiresAt,
+		}
+		if err := models.GrantGuestPass(c.UserContext(), pass); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to grant guest pass")
+		}
+
+		return utils.CreatedResponse(c, pass)
+	}
+}
+
+// GetGuestPasses handles an admin listing a group's unexpired guest passes
+func GetGuestPasses() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		groupID := c.Params("id")
+		if rejected, err := rejectNonGroupAdmin(c, groupID, userAddress); rejected {
+			return err
+		}
+
+		passes, err := models.GetGuestPasses(c.UserContext(), groupID)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get guest passes")
+		}
+
+		return utils.OKResponse(c, passes)
+	}
+}
+
+// RevokeGuestPass handles an admin revoking a guest pass, or a guest
+// giving theirs up
+func RevokeGuestPass() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		groupID := c.Params("id")
+		guestAddress := c.Params("address")
+		if guestAddress != userAddress {
+			if rejected, err := rejectNonGroupAdmin(c, groupID, userAddress); rejected {
+				return err
+			}
+		}
+
+		if err := models.RevokeGuestPass(c.UserContext(), groupID, guestAddress); err != nil {
+			if errors.Is(err, models.ErrGuestPassNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeGuestPassNotFound, "Guest pass not found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to revoke guest pass")
+		}
+
+		return utils.OKResponse(c, fiber.Map{
+			"message": localized(c, "Guest pass revoked"),
+		})
+	}
+}
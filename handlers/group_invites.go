@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/plugins"
+	"github.com/piko/piko/types"
+	"github.com/piko/piko/utils"
+	"github.com/piko/piko/websocket"
+)
+
+// CreateGroupInviteRequest represents a request to create a group invite
+// link. Both limits are optional.
+type CreateGroupInviteRequest struct {
+	MaxUses   *int        `json:"max_uses,omitempty"`
+	ExpiresAt *types.Time `json:"expires_at,omitempty"`
+}
+
+// GroupInviteResponse represents a group invite link
+type GroupInviteResponse struct {
+	models.GroupInvite
+	Path string `json:"path"`
+}
+
+// groupInviteResponse converts a group invite to its response format
+func groupInviteResponse(invite *models.GroupInvite) GroupInviteResponse {
+	return GroupInviteResponse{
+		GroupInvite: *invite,
+		Path:        "/api/groups/join/" + invite.Token,
+	}
+}
+
+// rejectNonGroupAdmin writes a 403 response unless the user is an admin of
+// the group
+func rejectNonGroupAdmin(c *fiber.Ctx, groupID, userAddress string) (bool, error) {
+	isAdmin, err := models.IsGroupAdmin(c.UserContext(), groupID, userAddress)
+	if err != nil {
+		if errors.Is(err, models.ErrGroupMemberNotFound) {
+			return true, utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotGroupMember, "You are not a member of this group")
+		}
+		return true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check admin status")
+	}
+	if !isAdmin {
+		return true, utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotGroupAdmin, "You are not an admin of this group")
+	}
+	return false, nil
+}
+
+// CreateGroupInvite handles creating an invite link for a group
+func CreateGroupInvite() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		// Get group ID from URL parameter
+		groupID := c.Params("id")
+		if groupID == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Group ID is required")
+		}
+
+		if rejected, err := rejectNonGroupAdmin(c, groupID, userAddress); rejected {
+			return err
+		}
+
+		// Parse request body
+		req := new(CreateGroupInviteRequest)
+		if len(c.Body()) > 0 {
+			if err := c.BodyParser(req); err != nil {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+			}
+		}
+
+		// Validate request
+		if req.MaxUses != nil && *req.MaxUses < 1 {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Max uses must be at least 1")
+		}
+		if req.ExpiresAt != nil && !req.ExpiresAt.After(clock.Now()) {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Expiry must be in the future")
+		}
+
+		token, err := newInviteToken()
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to generate invite token")
+		}
+
+		invite := &models.GroupInvite{
+			Token:     token,
+			GroupID:   groupID,
+			CreatedBy: userAddress,
+			MaxUses:   req.MaxUses,
+			ExpiresAt: req.ExpiresAt,
+			CreatedAt: types.NewTime(clock.Now()),
+		}
+		if err := models.CreateGroupInvite(c.UserContext(), invite); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to create invite")
+		}
+
+		return utils.CreatedResponse(c, groupInviteResponse(invite))
+	}
+}
+
+// GetGroupInvites handles listing the invite links of a group
+func GetGroupInvites() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		// Get group ID from URL parameter
+		groupID := c.Params("id")
+		if groupID == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Group ID is required")
+		}
+
+		if rejected, err := rejectNonGroupAdmin(c, groupID, userAddress); rejected {
+			return err
+		}
+
+		invites, err := models.GetGroupInvites(c.UserContext(), groupID)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get invites")
+		}
+
+		response := make([]GroupInviteResponse, len(invites))
+		for i, invite := range invites {
+			response[i] = groupInviteResponse(invite)
+		}
+
+		return utils.OKResponse(c, response)
+	}
+}
+
+// RevokeGroupInvite handles revoking an invite link of a group
+func RevokeGroupInvite() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		// Get group ID and token from URL parameters
+		groupID := c.Params("id")
+		token := c.Params("token")
+		if groupID == "" || token == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Group ID and invite token are required")
+		}
+
+		if rejected, err := rejectNonGroupAdmin(c, groupID, userAddress); rejected {
+			return err
+		}
+
+		if err := models.RevokeGroupInvite(c.UserContext(), groupID, token); err != nil {
+			if errors.Is(err, models.ErrGroupInviteNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeInviteNotFound, "Invite not found or already revoked")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to revoke invite")
+		}
+
+		return utils.OKResponse(c, fiber.Map{
+			"message": localized(c, "Invite revoked"),
+		})
+	}
+}
+
+// JoinGroupByInvite handles joining a group with an invite link
+func JoinGroupByInvite() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		// Get invite token from URL parameter
+		token := c.Params("token")
+		if token == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invite token is required")
+		}
+
+		// Plugins may refuse the join once the invite's group is known
+		groupID, err := models.RedeemGroupInvite(c.UserContext(), token, userAddress, quotaConfig.MaxGroupMembers, func(groupID string) error {
+			return plugins.BeforeMemberJoin(c.UserContext(), &plugins.MemberJoin{
+				Kind:           plugins.ConversationGroup,
+				ConversationID: groupID,
+				UserAddress:    userAddress,
+			})
+		})
+		if err != nil {
+			var rejection *plugins.Rejection
+			if errors.As(err, &rejection) {
+				return pluginErrorResponse(c, err)
+			}
+			if errors.Is(err, models.ErrGroupInviteInvalid) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeInviteInvalid, "Invite is invalid, revoked, expired or used up")
+			}
+			if errors.Is(err, models.ErrAlreadyGroupMember) {
+				return utils.ErrorResponse(c, fiber.StatusConflict, utils.CodeAlreadyGroupMember, "User is already a member of this group")
+			}
+			if errors.Is(err, models.ErrGroupFull) {
+				return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeGroupFull, "Group is full")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to join group")
+		}
+		WebSocketPool.JoinRoom(websocket.GroupRoom(groupID), userAddress)
+		reportGroupMembers(c, groupID)
+		recordConversationAudit(c, models.ConversationGroup, groupID, models.AuditActionGroupMemberAdd, userAddress, "invite")
+
+		group, err := models.GetGroupByID(c.UserContext(), groupID)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get group")
+		}
+
+		return utils.OKResponse(c, GroupResponse{
+			ID:                  group.ID,
+			Name:                group.Name,
+			Description:         group.Description,
+			PhotoURL:            group.PhotoURL,
+			CreatedBy:           group.CreatorAddress,
+			MemberCount:         group.MemberCount,
+			MessageCount:        group.MessageCount,
+			TopicsEnabled:       group.TopicsEnabled,
+			JoinRequestsEnabled: group.JoinRequestsEnabled,
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/plugins"
+	"github.com/piko/piko/utils"
+	"github.com/piko/piko/websocket"
+)
+
+// SetGroupJoinModeRequest represents an admin turning join requests on or off
+type SetGroupJoinModeRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+// JoinGroupRequest represents a user asking to join a group
+type JoinGroupRequest struct {
+	Message string `json:"message,omitempty"`
+}
+
+// SetGroupJoinMode handles an admin turning the group's join request mode on
+// or off. Pending requests are kept when it is turned off.
+func SetGroupJoinMode() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		groupID := c.Params("id")
+		if rejected, err := rejectNonGroupAdmin(c, groupID, userAddress); rejected {
+			return err
+		}
+
+		req := new(SetGroupJoinModeRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+
+		if err := models.SetGroupJoinRequestsEnabled(c.UserContext(), groupID, req.Enabled); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to update join mode")
+		}
+		recordConversationAudit(c, models.ConversationGroup, groupID, models.AuditActionGroupJoinMode, groupID, strconv.FormatBool(req.Enabled))
+
+		return utils.OKResponse(c, fiber.Map{
+			"join_requests_enabled": req.Enabled,
+		})
+	}
+}
+
+// RequestToJoinGroup handles a user asking to join a group that accepts
+// join requests
+func RequestToJoinGroup() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		req := new(JoinGroupRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+		req.Message = strings.TrimSpace(req.Message)
+		if utf8.RuneCountInString(req.Message) > 255 {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Message must be at most 255 characters")
+		}
+
+		groupID := c.Params("id")
+		enabled, err := models.GroupJoinRequestsEnabled(c.UserContext(), groupID)
+		if err != nil {
+			if errors.Is(err, models.ErrGroupNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeGroupNotFound, "Group not found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get group")
+		}
+		if !enabled {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeJoinRequestsDisabled, "This group does not accept join requests")
+		}
+
+		request := &models.GroupJoinRequest{
+			GroupID:     groupID,
+			UserAddress: userAddress,
+			Message:     req.Message,
+		}
+		if err := models.CreateGroupJoinRequest(c.UserContext(), request); err != nil {
+			switch {
+			case errors.Is(err, models.ErrAlreadyGroupMember):
+				return utils.ErrorResponse(c, fiber.StatusConflict, utils.CodeAlreadyGroupMember, "User is already a member of this group")
+			case errors.Is(err, models.ErrJoinRequestPending):
+				return utils.ErrorResponse(c, fiber.StatusConflict, utils.CodeJoinRequestPending, "Your join request is already pending")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to create join request")
+		}
+
+		return utils.CreatedResponse(c, request)
+	}
+}
+
+// GetGroupJoinRequests handles an admin listing a group's pending join
+// requests
+func GetGroupJoinRequests() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		groupID := c.Params("id")
+		if rejected, err := rejectNonGroupAdmin(c, groupID, userAddress); rejected {
+			return err
+		}
+
+		requests, err := models.GetPendingGroupJoinRequests(c.UserContext(), groupID)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get join requests")
+		}
+
+		return utils.OKResponse(c, requests)
+	}
+}
+
+// ApproveGroupJoinRequest handles an admin letting a requester into the group
+func ApproveGroupJoinRequest() fiber.Handler {
+	return decideGroupJoinRequest(true)
+}
+
+// RejectGroupJoinRequest handles an admin turning a join request down
+func RejectGroupJoinRequest() fiber.Handler {
+	return decideGroupJoinRequest(false)
+}
+
+// decideGroupJoinRequest approves or rejects a pending join request and
+// tells the requester
+func decideGroupJoinRequest(approve bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		groupID := c.Params("id")
+		if rejected, err := rejectNonGroupAdmin(c, groupID, userAddress); rejected {
+			return err
+		}
+
+		// Plugins get the same say as when joining with an invite
+		requester := c.Params("address")
+		err := models.DecideGroupJoinRequest(c.UserContext(), groupID, requester, userAddress, approve, quotaConfig.MaxGroupMembers, func() error {
+			return plugins.BeforeMemberJoin(c.UserContext(), &plugins.MemberJoin{
+				Kind:           plugins.ConversationGroup,
+				ConversationID: groupID,
+				UserAddress:    requester,
+			})
+		})
+		if err != nil {
+			var rejection *plugins.Rejection
+			if errors.As(err, &rejection) {
+				return pluginErrorResponse(c, err)
+			}
+			switch {
+			case errors.Is(err, models.ErrJoinRequestNotFound):
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeJoinRequestNotFound, "Join request not found")
+			case errors.Is(err, models.ErrAlreadyGroupMember):
+				return utils.ErrorResponse(c, fiber.StatusConflict, utils.CodeAlreadyGroupMember, "User is already a member of this group")
+			case errors.Is(err, models.ErrGroupFull):
+				return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeGroupFull, "Group is full")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to decide join request")
+		}
+
+		status, message := models.JoinRequestRejected, "Join request rejected"
+		if approve {
+			status, message = models.JoinRequestApproved, "Join request approved"
+			WebSocketPool.JoinRoom(websocket.GroupRoom(groupID), requester)
+			reportGroupMembers(c, groupID)
+			recordConversationAudit(c, models.ConversationGroup, groupID, models.AuditActionGroupMemberAdd, requester, "join_request")
+		}
+		go websocket.NotifyGroupJoinRequestDecided(WebSocketPool, groupID, requester, status)
+
+		return utils.OKResponse(c, fiber.Map{
+			"message": localized(c, message),
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/utils"
+	"github.com/piko/piko/websocket"
+)
+
+// TransferGroupOwnershipRequest represents the owner handing a group over to
+// another member
+type TransferGroupOwnershipRequest struct {
+	NewOwnerAddress string `json:"new_owner_address"`
+}
+
+// UpdateGroupMemberRoleRequest represents an admin promoting or demoting a member
+type UpdateGroupMemberRoleRequest struct {
+	Role models.GroupRole `json:"role"`
+}
+
+// rejectAdminlessGroup writes a 409 response if a membership change failed
+// because it would leave the group without its owner or without an admin
+func rejectAdminlessGroup(c *fiber.Ctx, err error) (bool, error) {
+	switch {
+	case errors.Is(err, models.ErrGroupOwnerMustTransfer):
+		return true, utils.ErrorResponse(c, fiber.StatusConflict, utils.CodeOwnershipTransferRequired, "The group owner must transfer ownership first")
+	case errors.Is(err, models.ErrLastGroupAdmin):
+		return true, utils.ErrorResponse(c, fiber.StatusConflict, utils.CodeLastAdmin, "Promote another admin first")
+	}
+	return false, nil
+}
+
+// rejectUnlessOrphanedGroupAdmin writes a 403 response unless the group's
+// owner has left it and the user is one of its admins. Groups whose creator
+// left before ownership could be transferred have no owner.
+func rejectUnlessOrphanedGroupAdmin(c *fiber.Ctx, group *models.Group, userAddress string) (bool, error) {
+	_, err := models.IsGroupAdmin(c.UserContext(), group.ID, group.CreatorAddress)
+	if err == nil {
+		return true, utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotGroupOwner, "Only the owner can delete the group")
+	}
+	if !errors.Is(err, models.ErrGroupMemberNotFound) {
+		return true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check admin status")
+	}
+	return rejectNonGroupAdmin(c, group.ID, userAddress)
+}
+
+// TransferGroupOwnership handles the owner making another member the
+// group's owner. The new owner becomes an admin if they weren't one.
+func TransferGroupOwnership() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		req := new(TransferGroupOwnershipRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+		if req.NewOwnerAddress == "" || req.NewOwnerAddress == userAddress {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "new_owner_address must be another member")
+		}
+
+		groupID := c.Params("id")
+		err := models.TransferGroupOwnership(c.UserContext(), groupID, userAddress, req.NewOwnerAddress)
+		if err != nil {
+			switch {
+			case errors.Is(err, models.ErrGroupNotFound):
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeGroupNotFound, "Group not found")
+			case errors.Is(err, models.ErrNotGroupOwner):
+				return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotGroupOwner, "Only the owner can transfer ownership")
+			case errors.Is(err, models.ErrGroupMemberNotFound):
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeMemberNotFound, "Member not found in group")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to transfer ownership")
+		}
+
+		go websocket.NotifyGroupOwnerChanged(WebSocketPool, groupID, userAddress, req.NewOwnerAddress)
+		recordConversationAudit(c, models.ConversationGroup, groupID, models.AuditActionGroupOwnershipTransfer, req.NewOwnerAddress, "")
+
+		return utils.OKResponse(c, fiber.Map{
+			"message":       localized(c, "Ownership transferred"),
+			"owner_address": req.NewOwnerAddress,
+		})
+	}
+}
+
+// UpdateGroupMemberRole handles an admin promoting a member to admin or
+// demoting an admin, including themselves. The owner can't be demoted, and
+// neither can the last admin.
+func UpdateGroupMemberRole() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		groupID := c.Params("id")
+		if rejected, err := rejectNonGroupAdmin(c, groupID, userAddress); rejected {
+			return err
+		}
+
+		req := new(UpdateGroupMemberRoleRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+		if req.Role != models.GroupRoleAdmin && req.Role != models.GroupRoleMember {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Role must be admin or member")
+		}
+
+		err := models.UpdateMemberRole(c.UserContext(), groupID, c.Params("address"), req.Role)
+		if err != nil {
+			if errors.Is(err, models.ErrGroupMemberNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeMemberNotFound, "Member not found in group")
+			}
+			if rejected, err := rejectAdminlessGroup(c, err); rejected {
+				return err
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to update member role")
+		}
+		recordConversationAudit(c, models.ConversationGroup, groupID, models.AuditActionGroupMemberRole, c.Params("address"), string(req.Role))
+
+		return utils.OKResponse(c, fiber.Map{
+			"message": localized(c, "Member role updated"),
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/utils"
+	"github.com/piko/piko/websocket"
+)
+
+// SetGroupTopicModeRequest represents an admin turning topic mode on or off
+type SetGroupTopicModeRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+// CreateGroupTopicRequest represents a request to create a group topic
+type CreateGroupTopicRequest struct {
+	Name string `json:"name"`
+}
+
+// getGroupTopicIn loads a topic of a group, writing a 404 response if the
+// group has no such topic
+func getGroupTopicIn(c *fiber.Ctx, groupID, topicID string) (*models.GroupTopic, bool, error) {
+	topic, err := models.GetGroupTopic(c.UserContext(), groupID, topicID)
+	if err != nil {
+		if errors.Is(err, models.ErrGroupTopicNotFound) {
+			return nil, true, utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeTopicNotFound, "Topic not found")
+		}
+		return nil, true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get topic")
+	}
+	return topic, false, nil
+}
+
+// rejectUnpostableTopic checks that a message can be posted to a topic:
+// the group is in topic mode, the topic is in the group, and it is open
+// unless the sender is an admin
+func rejectUnpostableTopic(c *fiber.Ctx, groupID, topicID string, isAdmin bool) (bool, error) {
+	enabled, err := models.GroupTopicsEnabled(c.UserContext(), groupID)
+	if err != nil {
+		return true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get group")
+	}
+	if !enabled {
+		return true, utils.ErrorResponse(c, fiber.StatusConflict, utils.CodeTopicsDisabled, "Topics are not enabled in this group")
+	}
+
+	topic, rejected, err := getGroupTopicIn(c, groupID, topicID)
+	if rejected {
+		return true, err
+	}
+	if topic.ClosedAt != nil && !isAdmin {
+		return true, utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeTopicClosed, "This topic is closed")
+	}
+	return false, nil
+}
+
+// SetGroupTopicMode handles an admin turning the group's topic mode on or
+// off. Existing topics and their messages are kept when it is turned off.
+func SetGroupTopicMode() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		groupID := c.Params("id")
+		if rejected, err := rejectNonGroupAdmin(c, groupID, userAddress); rejected {
+			return err
+		}
+
+		req := new(SetGroupTopicModeRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+
+		if err := models.SetGroupTopicsEnabled(c.UserContext(), groupID, req.Enabled); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to update topic mode")
+		}
+		recordConversationAudit(c, models.ConversationGroup, groupID, models.AuditActionGroupTopicMode, groupID, strconv.FormatBool(req.Enabled))
+
+		return utils.OKResponse(c, fiber.Map{
+			"topics_enabled": req.Enabled,
+		})
+	}
+}
+
+// CreateGroupTopic handles an admin creating a topic in a group in topic mode
+func CreateGroupTopic() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		groupID := c.Params("id")
+		if rejected, err := rejectNonGroupAdmin(c, groupID, userAddress); rejected {
+			return err
+		}
+
+		req := new(CreateGroupTopicRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+		req.Name = strings.TrimSpace(req.Name)
+		if req.Name == "" || utf8.RuneCountInString(req.Name) > 128 {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Name is required and must be at most 128 characters")
+		}
+
+		enabled, err := models.GroupTopicsEnabled(c.UserContext(), groupID)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get group")
+		}
+		if !enabled {
+			return utils.ErrorResponse(c, fiber.StatusConflict, utils.CodeTopicsDisabled, "Topics are not enabled in this group")
+		}
+
+		topicID, err := utils.NewID()
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to generate topic ID")
+		}
+
+		topic := &models.GroupTopic{
+			ID:             topicID,
+			GroupID:        groupID,
+			Name:           req.Name,
+			CreatorAddress: userAddress,
+		}
+		if err := models.CreateGroupTopic(c.UserContext(), topic); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to create topic")
+		}
+
+		go websocket.NotifyGroupTopicChanged(WebSocketPool, websocket.MessageTypeGroupTopicCreated, topic)
+
+		return utils.CreatedResponse(c, topic)
+	}
+}
+
+// GetGroupTopics handles listing a group's topics with the user's unread
+// count in each
+func GetGroupTopics() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		groupID := c.Params("id")
+		if _, rejected, err := rejectNonGroupMember(c, groupID, userAddress); rejected {
+			return err
+		}
+
+		topics, err := models.GetGroupTopics(c.UserContext(), groupID, userAddress)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get topics")
+		}
+
+		return utils.OKResponse(c, topics)
+	}
+}
+
+// CloseGroupTopic handles an admin closing a topic to members' messages
+func CloseGroupTopic() fiber.Handler {
+	return setGroupTopicClosed(true)
+}
+
+// ReopenGroupTopic handles an admin reopening a closed topic
+func ReopenGroupTopic() fiber.Handler {
+	return setGroupTopicClosed(false)
+}
+
+// setGroupTopicClosed handles closing or reopening a topic
+func setGroupTopicClosed(closed bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		groupID := c.Params("id")
+		if rejected, err := rejectNonGroupAdmin(c, groupID, userAddress); rejected {
+			return err
+		}
+
+		topicID := c.Params("topic_id")
+		if _, rejected, err := getGroupTopicIn(c, groupID, topicID); rejected {
+			return err
+		}
+
+		changed, err := models.SetGroupTopicClosed(c.UserContext(), groupID, topicID, closed)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to update topic")
+		}
+
+		topic, rejected, err := getGroupTopicIn(c, groupID, topicID)
+		if rejected {
+			return err
+		}
+		if changed {
+			messageType := websocket.MessageTypeGroupTopicReopened
+			if closed {
+				messageType = websocket.MessageTypeGroupTopicClosed
+			}
+			go websocket.NotifyGroupTopicChanged(WebSocketPool, messageType, topic)
+		}
+
+		return utils.OKResponse(c, topic)
+	}
+}
+
+// MarkGroupTopicRead handles moving the user's read cursor in a topic forward
+func MarkGroupTopicRead() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		groupID := c.Params("id")
+		topicID := c.Params("topic_id")
+
+		// Parse request body
+		req := new(MarkReadRequest)
+		if err := c.BodyParser(req); err != nil || req.MessageID == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Message ID is required")
+		}
+
+		if _, rejected, err := rejectNonGroupMember(c, groupID, userAddress); rejected {
+			return err
+		}
+
+		// The message must be in this topic
+		message, err := models.GetGroupMessageByID(c.UserContext(), req.MessageID)
+		if err != nil && !errors.Is(err, models.ErrMessageNotFound) {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get message")
+		}
+		if message == nil || message.GroupID != groupID || message.TopicID == nil || *message.TopicID != topicID {
+			return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeMessageNotFound, "Message not found in this topic")
+		}
+
+		advanced, err := models.MarkGroupTopicRead(c.UserContext(), topicID, userAddress, message)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to mark topic as read")
+		}
+		if advanced {
+			go websocket.NotifyGroupTopicRead(WebSocketPool, message, userAddress)
+		}
+
+		return utils.OKResponse(c, fiber.Map{
+			"message": localized(c, "Topic marked as read"),
+		})
+	}
+}
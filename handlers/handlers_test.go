@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/i18n"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/store"
+)
+
+// testResponse is a decoded JSON response envelope
+type testResponse struct {
+	Status string          `json:"status"`
+	Code   string          `json:"code"`
+	Error  string          `json:"error"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// useStores swaps the handlers' stores for the test's, restoring the
+// previous ones when it ends
+func useStores(t *testing.T, s store.Stores) {
+	t.Helper()
+
+	previous := stores
+	InitStores(s)
+	t.Cleanup(func() { InitStores(previous) })
+}
+
+// serve runs one request against handler as the given user, the way it
+// would run after the auth middleware
+func serve(t *testing.T, user *models.User, method, route, target, body string, handler fiber.Handler) (int, testResponse) {
+	t.Helper()
+
+	app := fiber.New()
+	app.Add(method, route, func(c *fiber.Ctx) error {
+		if user != nil {
+			c.Locals("user_id", user.ID)
+			c.Locals("address", user.Address)
+		}
+		// Set the locale so responses aren't translated from the user's
+		// saved language
+		c.Locals("locale", i18n.Default)
+		return c.Next()
+	}, handler)
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, target, err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading response: %v", err)
+	}
+	var decoded testResponse
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatalf("decoding response %q: %v", raw, err)
+	}
+	return resp.StatusCode, decoded
+}
+
+// decodeData decodes the data of a successful response
+func decodeData(t *testing.T, resp testResponse, v interface{}) {
+	t.Helper()
+
+	if err := json.Unmarshal(resp.Data, v); err != nil {
+		t.Fatalf("decoding data %q: %v", resp.Data, err)
+	}
+}
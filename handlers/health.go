@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/blockchain"
+	"github.com/piko/piko/database"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/websocket"
+)
+
+// Health statuses
+const (
+	healthOK          = "ok"
+	healthUnavailable = "unavailable"
+)
+
+// readinessTimeout bounds how long the readiness checks wait on the database
+const readinessTimeout = 2 * time.Second
+
+// ComponentHealth represents the state of one thing the server depends on
+type ComponentHealth struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// Height is the latest block height, for the blockchain
+	Height *int `json:"height,omitempty"`
+	// Clients is the number of connected clients, for the WebSocket pool
+	Clients *int `json:"clients,omitempty"`
+}
+
+// HealthResponse represents the server's health
+type HealthResponse struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentHealth `json:"components,omitempty"`
+}
+
+// Healthz handles liveness probes. It only reports that the process is
+// serving requests, so a restart can't fix anything it would fail on.
+func Healthz(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(HealthResponse{Status: healthOK})
+}
+
+// Readyz handles readiness probes. It checks the database, the blockchain
+// and the WebSocket pool, and answers 503 while any of them can't serve
+// traffic, including while the server drains for shutdown.
+func Readyz(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), readinessTimeout)
+	defer cancel()
+
+	components := map[string]ComponentHealth{
+		"database":   databaseHealth(ctx),
+		"blockchain": blockchainHealth(ctx),
+		"websocket":  webSocketHealth(),
+	}
+
+	resp := HealthResponse{Status: healthOK, Components: components}
+	status := fiber.StatusOK
+	for _, component := range components {
+		if component.Status != healthOK {
+			resp.Status = healthUnavailable
+			status = fiber.StatusServiceUnavailable
+		}
+	}
+	return c.Status(status).JSON(resp)
+}
+
+// databaseHealth checks the database answers
+func databaseHealth(ctx context.Context) ComponentHealth {
+	if database.DB == nil {
+		return ComponentHealth{Status: healthUnavailable, Error: "Not connected"}
+	}
+	if !database.Ready() {
+		return ComponentHealth{Status: healthUnavailable, Error: "Schema not ready"}
+	}
+	if err := database.DB.PingContext(ctx); err != nil {
+		log.Printf("Readiness: database ping failed: %v", err)
+		return ComponentHealth{Status: healthUnavailable, Error: "Unreachable"}
+	}
+	return ComponentHealth{Status: healthOK}
+}
+
+// blockchainHealth checks the consensus schedule is stored and the chain
+// can be read
+func blockchainHealth(ctx context.Context) ComponentHealth {
+	if !blockchain.Initialized() {
+		return ComponentHealth{Status: healthUnavailable, Error: "Not initialized"}
+	}
+
+	height := 0
+	latest, err := models.GetLatestBlock(ctx)
+	switch {
+	case err == nil:
+		height = latest.Height
+	case !errors.Is(err, models.ErrBlockNotFound):
+		log.Printf("Readiness: reading latest block failed: %v", err)
+		return ComponentHealth{Status: healthUnavailable, Error: "Unreadable"}
+	}
+	return ComponentHealth{Status: healthOK, Height: &height}
+}
+
+// webSocketHealth checks the WebSocket pool accepts new clients
+func webSocketHealth() ComponentHealth {
+	clients := websocket.ClientCount(WebSocketPool)
+	if WebSocketPool.IsDraining() {
+		return ComponentHealth{Status: healthUnavailable, Error: "Draining", Clients: &clients}
+	}
+	return ComponentHealth{Status: healthOK, Clients: &clients}
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/crypto"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/utils"
+	"github.com/piko/piko/websocket"
+)
+
+// startKeyRotation marks the user's direct conversations as needing a new
+// session after they replaced their identity key, and tells their peers.
+// Peers who verified the old key lose their verification. The new keys are
+// already saved, so failures are only logged.
+func startKeyRotation(ctx context.Context, userAddress string, identityKey []byte) {
+	if err := resetKeyVerifications(ctx, userAddress, identityKey); err != nil {
+		log.Printf("Failed to reset key verifications for %s: %v", userAddress, err)
+	}
+
+	id, err := utils.NewID()
+	if err != nil {
+		log.Printf("Failed to generate key rotation ID: %v", err)
+		return
+	}
+	rotation, err := models.StartKeyRotation(ctx, id, userAddress, crypto.KeyFingerprint(identityKey))
+	if err != nil {
+		log.Printf("Failed to start key rotation for %s: %v", userAddress, err)
+		return
+	}
+	go websocket.NotifyKeyRotated(WebSocketPool, rotation)
+}
+
+// GetKeyRotation handles the user checking which peers have acknowledged
+// their latest identity key
+func GetKeyRotation() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		rotation, err := models.GetKeyRotation(c.UserContext(), userAddress)
+		if err != nil {
+			if errors.Is(err, models.ErrKeyRotationNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeKeyRotationNotFound, "Key rotation not found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get key rotation")
+		}
+
+		return utils.OKResponse(c, rotation)
+	}
+}
+
+// GetPendingKeyRotations handles the user listing conversation partners
+// whose new identity keys they still need to start sessions with
+func GetPendingKeyRotations() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		rotations, err := models.GetPendingKeyRotations(c.UserContext(), userAddress)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get key rotations")
+		}
+
+		return utils.OKResponse(c, rotations)
+	}
+}
+
+// AcknowledgeKeyRotation handles a peer confirming that they started a new
+// session with a rotating user's new identity key
+func AcknowledgeKeyRotation() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		rotation, changed, err := models.AcknowledgeKeyRotation(c.UserContext(), c.Params("id"), userAddress)
+		if err != nil {
+			if errors.Is(err, models.ErrKeyRotationNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeKeyRotationNotFound, "Key rotation not found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to acknowledge key rotation")
+		}
+
+		if changed {
+			go websocket.NotifyKeyRotationAcknowledged(WebSocketPool, rotation, userAddress)
+		}
+
+		return utils.OKResponse(c, rotation)
+	}
+}
@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/config"
+	"github.com/piko/piko/crypto"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/types"
+	"github.com/piko/piko/utils"
+	"github.com/piko/piko/websocket"
+)
+
+const (
+	// prekeySize is the size of an X25519 public key
+	prekeySize = 32
+	// prekeySignatureSize is the size of an Ed25519 signature
+	prekeySignatureSize = ed25519.SignatureSize
+)
+
+// keysConfig holds the key directory settings
+var keysConfig = config.DefaultConfig().Keys
+
+// InitKeys configures how many prekeys users keep and when they're asked
+// to replenish and rotate them
+func InitKeys(cfg config.KeysConfig) {
+	keysConfig = cfg
+}
+
+// SignedPrekeyRequest represents a signed prekey. The signature is the
+// identity key's Ed25519 signature of the prekey's public key.
+type SignedPrekeyRequest struct {
+	KeyID     uint32 `json:"key_id"`
+	PublicKey string `json:"public_key"`
+	Signature string `json:"signature"`
+}
+
+// OneTimePrekeyRequest represents a one-time prekey
+type OneTimePrekeyRequest struct {
+	KeyID     uint32 `json:"key_id"`
+	PublicKey string `json:"public_key"`
+}
+
+// UploadKeysRequest represents a request to publish keys. The identity key
+// and signed prekey are required on the first upload; later uploads may
+// only rotate the signed prekey or add one-time prekeys.
+type UploadKeysRequest struct {
+	IdentityKey    string                 `json:"identity_key,omitempty"`
+	SignedPrekey   *SignedPrekeyRequest   `json:"signed_prekey,omitempty"`
+	OneTimePrekeys []OneTimePrekeyRequest `json:"one_time_prekeys,omitempty"`
+}
+
+// KeyStatusResponse represents the state of the user's own published keys
+type KeyStatusResponse struct {
+	IdentityKey           string     `json:"identity_key"`
+	SignedPrekeyID        uint32     `json:"signed_prekey_id"`
+	SignedPrekeyUpdatedAt types.Time `json:"signed_prekey_updated_at"`
+	OneTimePrekeys        int        `json:"one_time_prekeys"`
+	// RotateSignedPrekey is set once the signed prekey should be replaced
+	RotateSignedPrekey bool `json:"rotate_signed_prekey"`
+	// UploadPrekeys is how many one-time prekeys can be added
+	UploadPrekeys int `json:"upload_prekeys"`
+}
+
+// SignedPrekeyResponse represents a signed prekey in a key bundle
+type SignedPrekeyResponse struct {
+	KeyID     uint32 `json:"key_id"`
+	PublicKey string `json:"public_key"`
+	Signature string `json:"signature"`
+}
+
+// OneTimePrekeyResponse represents a one-time prekey in a key bundle
+type OneTimePrekeyResponse struct {
+	KeyID     uint32 `json:"key_id"`
+	PublicKey string `json:"public_key"`
+}
+
+// KeyBundleResponse represents the keys needed to start an encrypted
+// session with a user. OneTimePrekey is omitted when the user has run out.
+type KeyBundleResponse struct {
+	Address       string                 `json:"address"`
+	IdentityKey   string                 `json:"identity_key"`
+	SignedPrekey  SignedPrekeyResponse   `json:"signed_prekey"`
+	OneTimePrekey *OneTimePrekeyResponse `json:"one_time_prekey,omitempty"`
+}
+
+// UploadKeys handles publishing the user's identity key, signed prekey and
+// one-time prekeys
+func UploadKeys() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		// Parse request body
+		req := new(UploadKeysRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+		if req.IdentityKey == "" && req.SignedPrekey == nil && len(req.OneTimePrekeys) == 0 {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "No keys to upload")
+		}
+		if len(req.OneTimePrekeys) > keysConfig.MaxOneTimePrekeys {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeTooManyPrekeys, "Too many one-time prekeys")
+		}
+
+		encoding := payloadEncoding(c)
+
+		// Keep the stored identity key unless a new one is uploaded
+		var identityKey []byte
+		if req.IdentityKey != "" {
+			key, err := encoding.Decode(req.IdentityKey)
+			if err != nil || len(key) != ed25519.PublicKeySize {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid identity key")
+			}
+			identityKey = key
+		} else {
+			bundle, err := models.GetKeyBundle(c.UserContext(), userAddress)
+			if err != nil {
+				if errors.Is(err, models.ErrKeyBundleNotFound) {
+					return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Identity key and signed prekey are required on the first upload")
+				}
+				return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get keys")
+			}
+			identityKey = bundle.IdentityKey
+		}
+
+		var signed *models.SignedPrekey
+		if req.SignedPrekey != nil {
+			publicKey, err := encoding.Decode(req.SignedPrekey.PublicKey)
+			if err != nil || len(publicKey) != prekeySize {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid signed prekey")
+			}
+			signature, err := encoding.Decode(req.SignedPrekey.Signature)
+			if err != nil || len(signature) != prekeySignatureSize {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidSignature, "Invalid signed prekey signature")
+			}
+			valid, err := crypto.Verify(identityKey, publicKey, signature)
+			if err != nil || !valid {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidSignature, "Signed prekey signature does not match the identity key")
+			}
+			signed = &models.SignedPrekey{
+				KeyID:     req.SignedPrekey.KeyID,
+				PublicKey: publicKey,
+				Signature: signature,
+			}
+		}
+
+		prekeys := make([]models.OneTimePrekey, 0, len(req.OneTimePrekeys))
+		seen := make(map[uint32]bool, len(req.OneTimePrekeys))
+		for _, prekey := range req.OneTimePrekeys {
+			publicKey, err := encoding.Decode(prekey.PublicKey)
+			if err != nil || len(publicKey) != prekeySize {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid one-time prekey")
+			}
+			if seen[prekey.KeyID] {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Duplicate one-time prekey ID")
+			}
+			seen[prekey.KeyID] = true
+			prekeys = append(prekeys, models.OneTimePrekey{KeyID: prekey.KeyID, PublicKey: publicKey})
+		}
+
+		rotated, err := models.SaveKeys(c.UserContext(), userAddress, identityKey, signed, prekeys, keysConfig.MaxOneTimePrekeys)
+		if err != nil {
+			switch {
+			case errors.Is(err, models.ErrSignedPrekeyRequired):
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "A signed prekey is required with a new identity key")
+			case errors.Is(err, models.ErrPrekeyIDExists):
+				return utils.ErrorResponse(c, fiber.StatusConflict, utils.CodePrekeyExists, "One-time prekey ID already uploaded")
+			case errors.Is(err, models.ErrTooManyPrekeys):
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeTooManyPrekeys, "Too many one-time prekeys")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to save keys")
+		}
+
+		// Peers who tracked another key lose their verification, whether the
+		// identity key was rotated or published for the first time
+		if rotated {
+			startKeyRotation(c.UserContext(), userAddress, identityKey)
+		} else if req.IdentityKey != "" {
+			if err := resetKeyVerifications(c.UserContext(), userAddress, identityKey); err != nil {
+				log.Printf("Failed to reset key verifications for %s: %v", userAddress, err)
+			}
+		}
+
+		return keyStatus(c, userAddress)
+	}
+}
+
+// GetKeyStatus handles retrieving the state of the user's own keys, so
+// clients know when to rotate the signed prekey or upload more prekeys
+func GetKeyStatus() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		return keyStatus(c, userAddress)
+	}
+}
+
+// GetKeyBundle handles retrieving a user's key bundle to start an encrypted
+// session with them. Each request consumes one of their one-time prekeys.
+func GetKeyBundle() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		address := c.Params("address")
+
+		// Don't hand out prekeys to users the owner blocked
+		blocked, err := models.HasBlocked(c.UserContext(), address, userAddress)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check block status")
+		}
+		if blocked {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeMessagingNotAllowed, "You can't message this user")
+		}
+
+		bundle, prekey, err := models.ConsumeKeyBundle(c.UserContext(), address)
+		if err != nil {
+			if errors.Is(err, models.ErrKeyBundleNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeKeysNotFound, "User has not published keys")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get keys")
+		}
+
+		if prekey != nil && bundle.OneTimePrekeys < keysConfig.LowPrekeyThreshold {
+			go websocket.NotifyPrekeysLow(WebSocketPool, address, bundle.OneTimePrekeys)
+		}
+
+		encoding := payloadEncoding(c)
+		response := KeyBundleResponse{
+			Address:     address,
+			IdentityKey: encoding.Encode(bundle.IdentityKey),
+			SignedPrekey: SignedPrekeyResponse{
+				KeyID:     bundle.SignedPrekey.KeyID,
+				PublicKey: encoding.Encode(bundle.SignedPrekey.PublicKey),
+				Signature: encoding.Encode(bundle.SignedPrekey.Signature),
+			},
+		}
+		if prekey != nil {
+			response.OneTimePrekey = &OneTimePrekeyResponse{
+				KeyID:     prekey.KeyID,
+				PublicKey: encoding.Encode(prekey.PublicKey),
+			}
+		}
+
+		return utils.OKResponse(c, response)
+	}
+}
+
+// keyStatus writes the state of a user's published keys
+func keyStatus(c *fiber.Ctx, userAddress string) error {
+	bundle, err := models.GetKeyBundle(c.UserContext(), userAddress)
+	if err != nil {
+		if errors.Is(err, models.ErrKeyBundleNotFound) {
+			return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeKeysNotFound, "No keys published")
+		}
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get keys")
+	}
+
+	return utils.OKResponse(c, KeyStatusResponse{
+		IdentityKey:           payloadEncoding(c).Encode(bundle.IdentityKey),
+		SignedPrekeyID:        bundle.SignedPrekey.KeyID,
+		SignedPrekeyUpdatedAt: types.NewTime(bundle.SignedPrekeyUpdatedAt),
+		OneTimePrekeys:        bundle.OneTimePrekeys,
+		RotateSignedPrekey:    clock.Now().Sub(bundle.SignedPrekeyUpdatedAt) >= keysConfig.SignedPrekeyMaxAge,
+		UploadPrekeys:         max(keysConfig.MaxOneTimePrekeys-bundle.OneTimePrekeys, 0),
+	})
+}
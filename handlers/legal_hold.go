@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/utils"
+)
+
+// PlaceLegalHoldRequest represents a request to put an account under legal hold
+type PlaceLegalHoldRequest struct {
+	Reason string `json:"reason"`
+}
+
+// maxLegalHoldReasonLength matches the reason column
+const maxLegalHoldReasonLength = 500
+
+// recordLegalHoldAudit records an admin's access to legal hold data
+func recordLegalHoldAudit(c *fiber.Ctx, action, target, details string) {
+	adminAddress, _ := middleware.GetUserAddress(c)
+	if err := models.RecordAudit(c.UserContext(), &models.AuditEntry{
+		ActorAddress: adminAddress,
+		Action:       action,
+		Target:       target,
+		IPAddress:    c.IP(),
+		Details:      details,
+	}); err != nil {
+		log.Printf("Error recording audit entry: %v", err)
+	}
+}
+
+// rejectLegalHold responds with 423 Locked if any of the accounts is under
+// legal hold
+func rejectLegalHold(c *fiber.Ctx, addresses ...string) (bool, error) {
+	err := stores.LegalHolds.Check(c.UserContext(), addresses...)
+	if err == nil {
+		return false, nil
+	}
+	if errors.Is(err, models.ErrUnderLegalHold) {
+		return true, utils.ErrorResponse(c, fiber.StatusLocked, utils.CodeLegalHold, "This data is under legal hold and cannot be deleted")
+	}
+	return true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check legal hold")
+}
+
+// GetLegalHolds handles listing all accounts under legal hold
+func GetLegalHolds() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		holds, err := models.GetLegalHolds(c.UserContext())
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get legal holds")
+		}
+
+		recordLegalHoldAudit(c, models.AuditActionLegalHoldList, "", "")
+		return utils.OKResponse(c, holds)
+	}
+}
+
+// PlaceLegalHold handles putting an account under legal hold
+func PlaceLegalHold() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		adminAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		address := c.Params("address")
+		if address == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "User address is required")
+		}
+
+		req := new(PlaceLegalHoldRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+		if req.Reason == "" || len(req.Reason) > maxLegalHoldReasonLength {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, fmt.Sprintf("Reason is required and must be at most %d characters", maxLegalHoldReasonLength))
+		}
+
+		if _, err := stores.Users.GetByAddress(c.UserContext(), address); err != nil {
+			if errors.Is(err, models.ErrUserNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeUserNotFound, "User not found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get user")
+		}
+
+		hold := &models.LegalHold{
+			UserAddress: address,
+			Reason:      req.Reason,
+			PlacedBy:    adminAddress,
+		}
+		if err := models.PlaceLegalHold(c.UserContext(), hold); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to place legal hold")
+		}
+
+		recordLegalHoldAudit(c, models.AuditActionLegalHoldPlace, address, req.Reason)
+
+		hold, err := models.GetLegalHold(c.UserContext(), address)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get legal hold")
+		}
+		return utils.OKResponse(c, hold)
+	}
+}
+
+// ReleaseLegalHold handles lifting the legal hold on an account
+func ReleaseLegalHold() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		address := c.Params("address")
+		if address == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "User address is required")
+		}
+
+		if err := models.ReleaseLegalHold(c.UserContext(), address); err != nil {
+			if errors.Is(err, models.ErrLegalHoldNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeLegalHoldNotFound, "Account is not under legal hold")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to release legal hold")
+		}
+
+		recordLegalHoldAudit(c, models.AuditActionLegalHoldRelease, address, "")
+		return utils.OKResponse(c, fiber.Map{
+			"message": localized(c, "Legal hold released"),
+		})
+	}
+}
+
+// ExportLegalHold handles downloading the compliance export of an account
+// under legal hold
+func ExportLegalHold() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		address := c.Params("address")
+		if address == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "User address is required")
+		}
+
+		export, err := models.BuildComplianceExport(c.UserContext(), address)
+		if err != nil {
+			if errors.Is(err, models.ErrLegalHoldNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeLegalHoldNotFound, "Account is not under legal hold")
+			}
+			if errors.Is(err, models.ErrUserNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeUserNotFound, "User not found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to build compliance export")
+		}
+
+		body, err := json.MarshalIndent(export, "", "  ")
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to encode compliance export")
+		}
+
+		recordLegalHoldAudit(c, models.AuditActionLegalHoldExport, address, "")
+
+		c.Attachment(fmt.Sprintf("compliance-%s.json", address))
+		return c.Send(body)
+	}
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/config"
+	"github.com/piko/piko/utils"
+)
+
+// messagingConfig holds the message content and attachment limits
+var messagingConfig = config.DefaultConfig().Messaging
+
+// InitMessaging configures message content and attachment limits
+func InitMessaging(cfg config.MessagingConfig) {
+	messagingConfig = cfg
+}
+
+// rejectOversizedContent writes a 413 response when decoded content is too
+// large to store inline, pointing the client at the media endpoints instead
+func rejectOversizedContent(c *fiber.Ctx, content []byte) (bool, error) {
+	if messagingConfig.MaxContentSize <= 0 || len(content) <= messagingConfig.MaxContentSize {
+		return false, nil
+	}
+
+	return true, utils.ErrorResponseWith(c, fiber.StatusRequestEntityTooLarge, utils.CodeContentTooLarge, fmt.Sprintf("Content too large (max %d bytes), upload it as media and send it as an attachment", messagingConfig.MaxContentSize), fiber.Map{
+		"max_size": messagingConfig.MaxContentSize,
+		"upload":   "/api/media",
+	})
+}
+
+// rejectTooManyAttachments writes a 413 response when a message references
+// more media than allowed
+func rejectTooManyAttachments(c *fiber.Ctx, attachmentIDs []string) (bool, error) {
+	if messagingConfig.MaxAttachments <= 0 || len(attachmentIDs) <= messagingConfig.MaxAttachments {
+		return false, nil
+	}
+
+	return true, utils.ErrorResponseWith(c, fiber.StatusRequestEntityTooLarge, utils.CodeTooManyAttachments, fmt.Sprintf("Too many attachments (max %d)", messagingConfig.MaxAttachments), fiber.Map{
+		"max_attachments": messagingConfig.MaxAttachments,
+	})
+}
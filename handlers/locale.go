@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/i18n"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/notifications"
+)
+
+// localized translates a system message to the request's locale
+func localized(c *fiber.Ctx, message string) string {
+	return i18n.T(middleware.GetLocale(c), message)
+}
+
+// localizedNotification translates a push notification to the language the
+// recipient saved in their settings. The sender's request says nothing
+// about the recipient's language.
+func localizedNotification(address string, notification *notifications.Notification) *notifications.Notification {
+	language, err := models.GetUserLanguage(context.Background(), address)
+	if err != nil {
+		return notification
+	}
+	locale, ok := i18n.Supported(language)
+	if !ok || locale == i18n.Default {
+		return notification
+	}
+
+	translated := *notification
+	translated.Title = i18n.T(locale, notification.Title)
+	translated.Body = i18n.T(locale, notification.Body)
+	return &translated
+}
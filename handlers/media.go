@@ -0,0 +1,423 @@
+package handlers
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/config"
+	"github.com/piko/piko/crypto"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/storage"
+	"github.com/piko/piko/types"
+	"github.com/piko/piko/utils"
+)
+
+var (
+	// MediaStore holds uploaded media
+	MediaStore storage.Backend
+
+	// mediaConfig holds the media upload limits
+	mediaConfig = config.DefaultConfig().Media
+)
+
+// InitMedia configures media storage and upload limits
+func InitMedia(storageCfg config.StorageConfig, mediaCfg config.MediaConfig) error {
+	backend, err := storage.New(storageCfg)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(mediaCfg.TempDir, 0755); err != nil {
+		return err
+	}
+
+	MediaStore = backend
+	mediaConfig = mediaCfg
+	return nil
+}
+
+// MediaResponse represents an uploaded file with a time-limited download URL
+type MediaResponse struct {
+	ID           string     `json:"id"`
+	FileName     string     `json:"file_name"`
+	MimeType     string     `json:"mime_type"`
+	Size         int64      `json:"size"`
+	URL          string     `json:"url"`
+	URLExpiresAt types.Time `json:"url_expires_at"`
+}
+
+// CreateMediaUploadRequest represents a request to start a chunked upload
+type CreateMediaUploadRequest struct {
+	FileName string `json:"file_name"`
+	MimeType string `json:"mime_type"`
+	Size     int64  `json:"size"`
+}
+
+// MediaUploadResponse represents the state of a chunked upload
+type MediaUploadResponse struct {
+	UploadID  string `json:"upload_id"`
+	Size      int64  `json:"size"`
+	Received  int64  `json:"received"`
+	ChunkSize int64  `json:"chunk_size"`
+}
+
+// UploadMedia handles uploading a file in a single multipart request
+func UploadMedia() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		// Get file from request
+		file, err := c.FormFile("file")
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "No file provided")
+		}
+
+		// Larger files must use chunked uploads
+		if file.Size > mediaConfig.ChunkSize {
+			return utils.ErrorResponse(c, fiber.StatusRequestEntityTooLarge, utils.CodeFileTooLarge, fmt.Sprintf("File too large for a single upload (max %d bytes), use a chunked upload", mediaConfig.ChunkSize))
+		}
+
+		contentType := file.Header.Get("Content-Type")
+		if !isAllowedMediaType(contentType) {
+			return utils.ErrorResponse(c, fiber.StatusUnsupportedMediaType, utils.CodeFileTypeNotAllowed, "File type not allowed")
+		}
+
+		if rejected, err := rejectOverStorageQuota(c, userAddress, file.Size); rejected {
+			return err
+		}
+
+		src, err := file.Open()
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to read file")
+		}
+		defer src.Close()
+
+		media, err := storeMedia(c.UserContext(), userAddress, file.Filename, contentType, src, file.Size)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to store file")
+		}
+
+		return utils.CreatedResponse(c, mediaResponse(media))
+	}
+}
+
+// CreateMediaUpload handles starting a chunked upload
+func CreateMediaUpload() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		// Parse request body
+		req := new(CreateMediaUploadRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+
+		// Validate request
+		if req.FileName == "" || req.Size <= 0 {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "File name and size are required")
+		}
+		if req.Size > mediaConfig.MaxSize {
+			return utils.ErrorResponse(c, fiber.StatusRequestEntityTooLarge, utils.CodeFileTooLarge, fmt.Sprintf("File too large (max %d bytes)", mediaConfig.MaxSize))
+		}
+		if !isAllowedMediaType(req.MimeType) {
+			return utils.ErrorResponse(c, fiber.StatusUnsupportedMediaType, utils.CodeFileTypeNotAllowed, "File type not allowed")
+		}
+		if rejected, err := rejectOverStorageQuota(c, userAddress, req.Size); rejected {
+			return err
+		}
+
+		uploadID, err := randomHexID()
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to generate upload ID")
+		}
+
+		upload := &models.MediaUpload{
+			ID:           uploadID,
+			OwnerAddress: userAddress,
+			FileName:     filepath.Base(req.FileName),
+			MimeType:     req.MimeType,
+			Size:         req.Size,
+		}
+		if err := models.CreateMediaUpload(c.UserContext(), upload); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to create upload")
+		}
+
+		return utils.CreatedResponse(c, MediaUploadResponse{
+			UploadID:  upload.ID,
+			Size:      upload.Size,
+			Received:  0,
+			ChunkSize: mediaConfig.ChunkSize,
+		})
+	}
+}
+
+// UploadMediaChunk handles appending a chunk to a chunked upload. Chunks are
+// sent as the raw request body with their byte offset in the "offset" query
+// parameter and must arrive in order.
+func UploadMediaChunk() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		upload, err := models.GetMediaUpload(c.UserContext(), c.Params("id"))
+		if err != nil && !errors.Is(err, models.ErrUploadNotFound) {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get upload")
+		}
+		if upload == nil || upload.OwnerAddress != userAddress {
+			return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeUploadNotFound, "Upload not found")
+		}
+
+		offset, err := strconv.ParseInt(c.Query("offset"), 10, 64)
+		if err != nil || offset != upload.Received {
+			return utils.ErrorResponseWith(c, fiber.StatusConflict, utils.CodeChunkOffsetMismatch, "Chunk offset does not match bytes received", fiber.Map{
+				"received": upload.Received,
+			})
+		}
+
+		chunk := c.Body()
+		if len(chunk) == 0 || int64(len(chunk)) > mediaConfig.ChunkSize {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, fmt.Sprintf("Chunk must be between 1 and %d bytes", mediaConfig.ChunkSize))
+		}
+		if upload.Received+int64(len(chunk)) > upload.Size {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Chunk exceeds declared file size")
+		}
+
+		// Append the chunk to the staging file
+		tempPath := filepath.Join(mediaConfig.TempDir, upload.ID)
+		tempFile, err := os.OpenFile(tempPath, os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to write chunk")
+		}
+		_, err = tempFile.WriteAt(chunk, offset)
+		if closeErr := tempFile.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to write chunk")
+		}
+
+		received := offset + int64(len(chunk))
+		if err := models.UpdateMediaUploadReceived(c.UserContext(), upload.ID, received); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to record chunk")
+		}
+
+		if received < upload.Size {
+			return utils.SuccessResponse(c, fiber.StatusAccepted, MediaUploadResponse{
+				UploadID:  upload.ID,
+				Size:      upload.Size,
+				Received:  received,
+				ChunkSize: mediaConfig.ChunkSize,
+			})
+		}
+
+		// Last chunk: move the file to storage
+		src, err := os.Open(tempPath)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to read upload")
+		}
+		media, err := storeMedia(c.UserContext(), userAddress, upload.FileName, upload.MimeType, src, upload.Size)
+		src.Close()
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to store file")
+		}
+
+		os.Remove(tempPath)
+		if err := models.DeleteMediaUpload(c.UserContext(), upload.ID); err != nil {
+			log.Printf("Error deleting finished upload %s: %v", upload.ID, err)
+		}
+
+		return utils.CreatedResponse(c, mediaResponse(media))
+	}
+}
+
+// GetMedia handles retrieving a media object with a fresh download URL
+func GetMedia() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		mediaID := c.Params("id")
+		allowed, err := models.CanAccessMedia(c.UserContext(), mediaID, userAddress)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check media access")
+		}
+		if !allowed {
+			return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeMediaNotFound, "Media not found")
+		}
+
+		media, err := models.GetMediaByID(c.UserContext(), mediaID)
+		if err != nil {
+			if errors.Is(err, models.ErrMediaNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeMediaNotFound, "Media not found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get media")
+		}
+
+		return utils.OKResponse(c, mediaResponse(media))
+	}
+}
+
+// DownloadMedia handles downloading a media object through a signed URL
+func DownloadMedia() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		mediaID := c.Params("id")
+		expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+		if err != nil || !utils.VerifyURLSignature(mediaConfig.SigningSecret, mediaDownloadPath(mediaID), expires, c.Query("signature")) {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeDownloadLinkInvalid, "Invalid or expired download link")
+		}
+
+		media, err := models.GetMediaByID(c.UserContext(), mediaID)
+		if err != nil {
+			if errors.Is(err, models.ErrMediaNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeMediaNotFound, "Media not found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get media")
+		}
+
+		reader, err := MediaStore.Get(media.StorageKey)
+		if err != nil {
+			if errors.Is(err, storage.ErrObjectNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeMediaNotFound, "Media file not found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to open media file")
+		}
+
+		c.Set("Content-Type", media.MimeType)
+		c.Set("Content-Disposition", attachmentDisposition(media.FileName))
+		return c.SendStream(reader, int(media.Size))
+	}
+}
+
+// attachmentDisposition builds a Content-Disposition header that makes
+// browsers download a user's file instead of rendering it. Names that can't
+// be sent as is are encoded per RFC 2231, and are left out if even that
+// fails.
+func attachmentDisposition(fileName string) string {
+	if disposition := mime.FormatMediaType("attachment", map[string]string{"filename": fileName}); disposition != "" {
+		return disposition
+	}
+	return "attachment"
+}
+
+// storeMedia writes a file to the storage backend and records it
+func storeMedia(ctx context.Context, ownerAddress, fileName, mimeType string, src io.Reader, size int64) (*models.Media, error) {
+	mediaID, err := randomHexID()
+	if err != nil {
+		return nil, err
+	}
+
+	// Spread objects over prefixes so no single directory grows too large
+	storageKey := fmt.Sprintf("%s/%s", mediaID[:2], mediaID)
+	if err := MediaStore.Put(storageKey, src, size, mimeType); err != nil {
+		return nil, err
+	}
+
+	media := &models.Media{
+		ID:           mediaID,
+		OwnerAddress: ownerAddress,
+		StorageKey:   storageKey,
+		FileName:     filepath.Base(fileName),
+		MimeType:     mimeType,
+		Size:         size,
+	}
+	if err := models.CreateMedia(ctx, media); err != nil {
+		MediaStore.Delete(storageKey)
+		return nil, err
+	}
+	return media, nil
+}
+
+// mediaResponse converts a media object to its response with a signed download URL
+func mediaResponse(media *models.Media) MediaResponse {
+	expiresAt := clock.Now().Add(mediaConfig.URLExpiry)
+	path := mediaDownloadPath(media.ID)
+	signature := utils.SignURL(mediaConfig.SigningSecret, path, expiresAt.Unix())
+
+	return MediaResponse{
+		ID:           media.ID,
+		FileName:     media.FileName,
+		MimeType:     media.MimeType,
+		Size:         media.Size,
+		URL:          fmt.Sprintf("%s?expires=%d&signature=%s", path, expiresAt.Unix(), signature),
+		URLExpiresAt: types.NewTime(expiresAt),
+	}
+}
+
+// loadAttachments loads the attachments of messages, logging failures so a
+// storage hiccup doesn't hide the messages themselves
+func loadAttachments(ctx context.Context, kind models.AttachmentKind, messageIDs []string) map[string][]*models.Media {
+	attachments, err := models.GetAttachments(ctx, kind, messageIDs)
+	if err != nil {
+		log.Printf("Error loading %s attachments: %v", kind, err)
+		return map[string][]*models.Media{}
+	}
+	return attachments
+}
+
+// attachmentResponses converts the attachments of a message to responses
+func attachmentResponses(attachments []*models.Media) []MediaResponse {
+	if len(attachments) == 0 {
+		return nil
+	}
+	response := make([]MediaResponse, len(attachments))
+	for i, media := range attachments {
+		response[i] = mediaResponse(media)
+	}
+	return response
+}
+
+// mediaDownloadPath is the path signed download URLs point at
+func mediaDownloadPath(mediaID string) string {
+	return "/api/media/" + mediaID + "/download"
+}
+
+// isAllowedMediaType checks a MIME type against the configured allow list.
+// Entries ending in "/" match a whole family, e.g. "image/".
+func isAllowedMediaType(mimeType string) bool {
+	if mimeType == "" {
+		return false
+	}
+	for _, allowed := range mediaConfig.AllowedTypes {
+		if strings.HasSuffix(allowed, "/") && strings.HasPrefix(mimeType, allowed) {
+			return true
+		}
+		if mimeType == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// randomHexID generates a random 32-byte hex identifier
+func randomHexID() (string, error) {
+	idBytes := make([]byte, 32)
+	if err := crypto.ReadRandom(idBytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(idBytes), nil
+}
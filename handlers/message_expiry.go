@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/piko/piko/metrics"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/websocket"
+)
+
+var (
+	expiredMessagesPurged = metrics.NewCounterVec(
+		"piko_messages_expired_total",
+		"Direct messages deleted after passing their expiration time.",
+	)
+	expiryRuns = metrics.NewCounterVec(
+		"piko_message_expiry_runs_total",
+		"Expired message purge runs, by result.",
+		"result",
+	)
+)
+
+// PurgeExpiredMessages is a background task that periodically deletes
+// expired direct messages and tells online participants which ones are
+// gone. It also clears expired group guest passes.
+func PurgeExpiredMessages(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := models.DeleteExpiredGuestPasses(context.Background()); err != nil {
+			log.Printf("Failed to delete expired guest passes: %v", err)
+		}
+
+		expired, err := models.DeleteExpiredMessages(context.Background())
+		// A failed batch may follow successful ones, which are still reported
+		if err != nil {
+			log.Printf("Failed to purge expired messages: %v", err)
+			expiryRuns.Inc("error")
+		} else {
+			expiryRuns.Inc("ok")
+		}
+		if len(expired) == 0 {
+			continue
+		}
+
+		expiredMessagesPurged.Add(float64(len(expired)))
+		notifyMessagesExpired(expired)
+	}
+}
+
+// notifyMessagesExpired sends each participant one event listing their
+// purged messages
+func notifyMessagesExpired(expired []*models.ExpiredMessage) {
+	byUser := map[string][]string{}
+	for _, message := range expired {
+		byUser[message.SenderAddress] = append(byUser[message.SenderAddress], message.ID)
+		if message.RecipientAddress != message.SenderAddress {
+			byUser[message.RecipientAddress] = append(byUser[message.RecipientAddress], message.ID)
+		}
+	}
+
+	for address, ids := range byUser {
+		websocket.NotifyMessagesExpired(WebSocketPool, address, ids)
+	}
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/utils"
+	"github.com/piko/piko/websocket"
+)
+
+// maxStatusBatch is the most messages one status update may cover, one
+// page of GET /api/messages/inbox
+const maxStatusBatch = 100
+
+// UpdateMessageStatusesRequest represents a recipient marking several
+// messages delivered or read
+type UpdateMessageStatusesRequest struct {
+	MessageIDs []string `json:"message_ids"`
+	Status     string   `json:"status"`
+}
+
+// UpdateMessageStatusesResponse reports which messages moved to the status
+type UpdateMessageStatusesResponse struct {
+	Updated []string `json:"updated"`
+	// Skipped are the IDs that aren't messages the user received
+	Skipped []string `json:"skipped"`
+}
+
+// UpdateMessageStatuses handles a recipient marking a batch of the messages
+// they received as delivered or read. Senders get one event for all of
+// their messages that changed.
+func UpdateMessageStatuses() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		// Parse request body
+		req := new(UpdateMessageStatusesRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+		if len(req.MessageIDs) == 0 || len(req.MessageIDs) > maxStatusBatch {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, fmt.Sprintf("Between 1 and %d message IDs are required", maxStatusBatch))
+		}
+		status := models.MessageStatus(req.Status)
+		if status != models.MessageStatusDelivered && status != models.MessageStatusRead {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Status must be delivered or read")
+		}
+
+		messageIDs := make([]string, 0, len(req.MessageIDs))
+		seen := make(map[string]bool, len(req.MessageIDs))
+		for _, id := range req.MessageIDs {
+			if !seen[id] {
+				seen[id] = true
+				messageIDs = append(messageIDs, id)
+			}
+		}
+
+		changes, skipped, err := models.UpdateMessageStatuses(c.UserContext(), userAddress, messageIDs, status)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to update message status")
+		}
+
+		if len(changes) > 0 {
+			go websocket.NotifyStatusChanges(WebSocketPool, userAddress, status, changes)
+		}
+
+		updated := make([]string, len(changes))
+		for i, change := range changes {
+			updated[i] = change.MessageID
+		}
+		return utils.OKResponse(c, UpdateMessageStatusesResponse{
+			Updated: updated,
+			Skipped: skipped,
+		})
+	}
+}
@@ -1,35 +1,94 @@
 package handlers
 
 import (
-	"crypto/rand"
-	"encoding/hex"
+	"context"
 	"errors"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/piko/piko/crypto"
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/config"
 	"github.com/piko/piko/middleware"
 	"github.com/piko/piko/models"
+	"github.com/piko/piko/notifications"
+	"github.com/piko/piko/plugins"
+	"github.com/piko/piko/types"
+	"github.com/piko/piko/utils"
 	"github.com/piko/piko/websocket"
 )
 
 // SendMessageRequest represents a request to send a message
 type SendMessageRequest struct {
-	RecipientAddress string `json:"recipient_address"`
-	EncryptedContent string `json:"encrypted_content"`
-	TTL              *int64 `json:"ttl,omitempty"` // Time to live in seconds
+	RecipientAddress string   `json:"recipient_address"`
+	EncryptedContent string   `json:"encrypted_content"`
+	TTL              *int64   `json:"ttl,omitempty"` // Time to live in seconds
+	ReplyToMessageID string   `json:"reply_to_message_id,omitempty"`
+	AttachmentIDs    []string `json:"attachment_ids,omitempty"`
+	// StickerID sends a sticker; the content carries a fallback for clients
+	// that can't show it
+	StickerID string `json:"sticker_id,omitempty"`
 }
 
 // MessageResponse represents a message response
 type MessageResponse struct {
-	ID               string     `json:"id"`
-	SenderAddress    string     `json:"sender_address"`
-	RecipientAddress string     `json:"recipient_address"`
+	ID               string                `json:"id"`
+	SenderAddress    string                `json:"sender_address"`
+	RecipientAddress string                `json:"recipient_address"`
+	EncryptedContent string                `json:"encrypted_content"`
+	Timestamp        types.Time            `json:"timestamp"`
+	Status           string                `json:"status"`
+	ExpirationTime   *types.Time           `json:"expiration_time,omitempty"`
+	BlockID          *string               `json:"block_id,omitempty"`
+	EditedAt         *types.Time           `json:"edited_at,omitempty"`
+	ReplyToMessageID *string               `json:"reply_to_message_id,omitempty"`
+	StickerID        *string               `json:"sticker_id,omitempty"`
+	ForwardedFrom    *string               `json:"forwarded_from,omitempty"`
+	Attachments      []MediaResponse       `json:"attachments,omitempty"`
+	SenderDevice     *SenderDeviceResponse `json:"sender_device,omitempty"`
+	// Contact is what the viewer calls the other participant
+	Contact *models.ContactName `json:"contact,omitempty"`
+}
+
+// MessagesResponse represents a page of a user's inbox or sent messages
+type MessagesResponse struct {
+	Messages []MessageResponse `json:"messages"`
+	// HasMore reports whether there are more messages in the direction
+	// being paged
+	HasMore bool `json:"has_more"`
+}
+
+// mailboxPage reads the before, after and limit query parameters, writing
+// a 400 response if both before and after are given
+func mailboxPage(c *fiber.Ctx) (models.MessagePage, bool, error) {
+	pagination := utils.GetPaginationParams(c)
+	if pagination.Before != "" && pagination.After != "" {
+		return models.MessagePage{}, true, utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Use either before or after")
+	}
+	return models.MessagePage{
+		Before: pagination.Before,
+		After:  pagination.After,
+		Limit:  pagination.Limit,
+	}, false, nil
+}
+
+// mailboxError writes the response for an error from loading a page of
+// messages
+func mailboxError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, models.ErrInvalidCursor) {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid cursor")
+	}
+	return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get messages")
+}
+
+// EditMessageRequest represents a request to edit a message
+type EditMessageRequest struct {
+	EncryptedContent string `json:"encrypted_content"`
+}
+
+// MessageEditResponse represents a previous version of an edited message
+type MessageEditResponse struct {
 	EncryptedContent string     `json:"encrypted_content"`
-	Timestamp        time.Time  `json:"timestamp"`
-	Status           string     `json:"status"`
-	ExpirationTime   *time.Time `json:"expiration_time,omitempty"`
-	BlockID          *string    `json:"block_id,omitempty"`
+	EditedAt         types.Time `json:"edited_at"`
 }
 
 // SendMessage handles sending a message
@@ -38,88 +97,143 @@ func SendMessage() fiber.Handler {
 		// Get user address from context
 		senderAddress, ok := middleware.GetUserAddress(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
 		}
 
 		// Parse request body
 		req := new(SendMessageRequest)
 		if err := c.BodyParser(req); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid request body",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
 		}
 
 		// Validate request
 		if req.RecipientAddress == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Recipient address is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Recipient address is required")
 		}
 		if req.EncryptedContent == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Encrypted content is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Encrypted content is required")
 		}
 
 		// Verify recipient address exists
-		_, err := models.GetUserByAddress(req.RecipientAddress)
+		_, err := stores.Users.GetByAddress(c.UserContext(), req.RecipientAddress)
 		if err != nil {
 			if errors.Is(err, models.ErrUserNotFound) {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"error": "Recipient not found",
-				})
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeRecipientNotFound, "Recipient not found")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to verify recipient",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to verify recipient")
+		}
+
+		// Users who blocked the sender don't receive their messages
+		blocked, err := models.HasBlocked(c.UserContext(), req.RecipientAddress, senderAddress)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to verify recipient")
+		}
+		if blocked {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeMessagingNotAllowed, "You can't send messages to this user")
 		}
 
 		// Decode encrypted content
-		encryptedContent, err := crypto.DecodeBase64(req.EncryptedContent)
+		encryptedContent, err := payloadEncoding(c).Decode(req.EncryptedContent)
 		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid encrypted content",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid encrypted content")
+		}
+		if tooLarge, err := rejectOversizedContent(c, encryptedContent); tooLarge {
+			return err
+		}
+
+		// Verify the replied-to message belongs to this conversation
+		var replyToMessageID *string
+		if req.ReplyToMessageID != "" {
+			if err := validateDirectReply(c.UserContext(), req.ReplyToMessageID, senderAddress, req.RecipientAddress); err != nil {
+				if errors.Is(err, models.ErrMessageNotFound) || errors.Is(err, models.ErrInvalidReplyTarget) {
+					return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid reply_to_message_id")
+				}
+				return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to verify replied message")
+			}
+			replyToMessageID = &req.ReplyToMessageID
+		}
+
+		// Sticker messages must name an existing sticker
+		if rejected, err := rejectUnknownSticker(c, req.StickerID); rejected {
+			return err
+		}
+
+		// Cap the number of attachments per message
+		if tooLarge, err := rejectTooManyAttachments(c, req.AttachmentIDs); tooLarge {
+			return err
+		}
+
+		// Attachments must be media uploaded by the sender
+		if err := models.ValidateAttachments(c.UserContext(), senderAddress, req.AttachmentIDs); err != nil {
+			if errors.Is(err, models.ErrInvalidAttachment) {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid attachment")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to verify attachments")
 		}
 
 		// Generate message ID
-		idBytes := make([]byte, 32)
-		if _, err := rand.Read(idBytes); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to generate message ID",
-			})
+		messageID, err := utils.NewID()
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to generate message ID")
 		}
-		messageID := hex.EncodeToString(idBytes)
 
 		// Calculate expiration time if TTL is provided
-		var expirationTime *time.Time
+		var expirationTime *types.Time
 		if req.TTL != nil && *req.TTL > 0 {
-			expTime := time.Now().Add(time.Duration(*req.TTL) * time.Second)
+			expTime := types.NewTime(clock.Now().Add(time.Duration(*req.TTL) * time.Second))
 			expirationTime = &expTime
 		}
 
+		// Let plugins refuse or rewrite the message
+		hooked := &plugins.Message{
+			Kind:           plugins.ConversationDirect,
+			ID:             messageID,
+			SenderAddress:  senderAddress,
+			ConversationID: req.RecipientAddress,
+			Content:        encryptedContent,
+		}
+		if rejected, err := rejectByPlugins(c, hooked); rejected {
+			return err
+		}
+
 		// Create message
 		message := &models.Message{
 			ID:               messageID,
 			SenderAddress:    senderAddress,
 			RecipientAddress: req.RecipientAddress,
-			EncryptedContent: encryptedContent,
+			EncryptedContent: hooked.Content,
 			Status:           models.MessageStatusPending,
 			ExpirationTime:   expirationTime,
+			ReplyToMessageID: replyToMessageID,
+			SenderSessionID:  currentSession(c),
+		}
+		if req.StickerID != "" {
+			message.StickerID = &req.StickerID
+		}
+		if err := models.CreateMessage(c.UserContext(), message); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to create message")
 		}
-		if err := models.CreateMessage(message); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to create message",
-			})
+		if err := models.AttachMedia(c.UserContext(), models.AttachmentKindDirect, messageID, senderAddress, req.AttachmentIDs); err != nil {
+			stores.Messages.Delete(c.UserContext(), messageID)
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to attach media")
 		}
 
 		// Notify recipient via WebSocket if they're online
 		go websocket.NotifyNewMessage(WebSocketPool, message)
 
+		// Push to the recipient's devices if they're offline
+		go pushUnlessMuted(message.RecipientAddress, models.ConversationDirect, message.SenderAddress, &notifications.Notification{
+			Title: "New message",
+			Body:  "You have a new message",
+			Data: map[string]string{
+				"type":           "new_message",
+				"id":             message.ID,
+				"sender_address": message.SenderAddress,
+			},
+		})
+
 		// Return message ID and status
-		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		return utils.CreatedResponse(c, fiber.Map{
 			"id":     messageID,
 			"status": string(models.MessageStatusPending),
 		})
@@ -132,19 +246,27 @@ func GetInbox() fiber.Handler {
 		// Get user address from context
 		userAddress, ok := middleware.GetUserAddress(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
+		}
+
+		page, rejected, err := mailboxPage(c)
+		if rejected {
+			return err
 		}
 
 		// Get messages from database
-		messages, err := models.GetMessagesByRecipient(userAddress)
+		messages, hasMore, err := models.GetMessagesByRecipientPage(c.UserContext(), userAddress, page)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to get messages",
-			})
+			return mailboxError(c, err)
 		}
 
+		attachments := loadAttachments(c.UserContext(), models.AttachmentKindDirect, directMessageIDs(messages))
+		senders := make([]string, len(messages))
+		for i, message := range messages {
+			senders[i] = message.SenderAddress
+		}
+		names := contactNames(c, userAddress, senders)
+
 		// Convert messages to response format and update status
 		response := make([]MessageResponse, len(messages))
 		for i, message := range messages {
@@ -152,16 +274,22 @@ func GetInbox() fiber.Handler {
 				ID:               message.ID,
 				SenderAddress:    message.SenderAddress,
 				RecipientAddress: message.RecipientAddress,
-				EncryptedContent: crypto.EncodeBase64(message.EncryptedContent),
+				EncryptedContent: payloadEncoding(c).Encode(message.EncryptedContent),
 				Timestamp:        message.Timestamp,
 				Status:           string(message.Status),
 				ExpirationTime:   message.ExpirationTime,
 				BlockID:          message.BlockID,
+				EditedAt:         message.EditedAt,
+				ReplyToMessageID: message.ReplyToMessageID,
+				StickerID:        message.StickerID,
+				ForwardedFrom:    message.ForwardedFrom,
+				Attachments:      attachmentResponses(attachments[message.ID]),
+				Contact:          names[message.SenderAddress],
 			}
 
 			// Update message status to delivered if it's pending
 			if message.Status == models.MessageStatusPending {
-				if err := models.UpdateMessageStatus(message.ID, models.MessageStatusDelivered); err != nil {
+				if err := models.UpdateMessageStatus(c.UserContext(), message.ID, models.MessageStatusDelivered); err != nil {
 					// Log error but continue
 					// TODO: Add proper logging
 				} else {
@@ -177,7 +305,7 @@ func GetInbox() fiber.Handler {
 								"message_id": msgID,
 								"status":     "delivered",
 								"recipient":  userAddress,
-								"timestamp":  time.Now().Format(time.RFC3339),
+								"timestamp":  types.FormatTime(time.Now()),
 							},
 							To: sender,
 						}
@@ -186,7 +314,10 @@ func GetInbox() fiber.Handler {
 			}
 		}
 
-		return c.Status(fiber.StatusOK).JSON(response)
+		return utils.OKResponse(c, MessagesResponse{
+			Messages: response,
+			HasMore:  hasMore,
+		})
 	}
 }
 
@@ -196,19 +327,32 @@ func GetSentMessages() fiber.Handler {
 		// Get user address from context
 		userAddress, ok := middleware.GetUserAddress(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
+		}
+
+		page, rejected, err := mailboxPage(c)
+		if rejected {
+			return err
 		}
 
 		// Get messages from database
-		messages, err := models.GetMessagesBySender(userAddress)
+		messages, hasMore, err := models.GetMessagesBySenderPage(c.UserContext(), userAddress, page)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to get messages",
-			})
+			return mailboxError(c, err)
 		}
 
+		attachments := loadAttachments(c.UserContext(), models.AttachmentKindDirect, directMessageIDs(messages))
+		sessionIDs := make([]*string, len(messages))
+		for i, message := range messages {
+			sessionIDs[i] = message.SenderSessionID
+		}
+		deviceNames := loadDeviceNames(c.UserContext(), sessionIDs)
+		recipients := make([]string, len(messages))
+		for i, message := range messages {
+			recipients[i] = message.RecipientAddress
+		}
+		names := contactNames(c, userAddress, recipients)
+
 		// Convert messages to response format
 		response := make([]MessageResponse, len(messages))
 		for i, message := range messages {
@@ -216,15 +360,25 @@ func GetSentMessages() fiber.Handler {
 				ID:               message.ID,
 				SenderAddress:    message.SenderAddress,
 				RecipientAddress: message.RecipientAddress,
-				EncryptedContent: crypto.EncodeBase64(message.EncryptedContent),
+				EncryptedContent: payloadEncoding(c).Encode(message.EncryptedContent),
 				Timestamp:        message.Timestamp,
 				Status:           string(message.Status),
 				ExpirationTime:   message.ExpirationTime,
 				BlockID:          message.BlockID,
+				EditedAt:         message.EditedAt,
+				ReplyToMessageID: message.ReplyToMessageID,
+				StickerID:        message.StickerID,
+				ForwardedFrom:    message.ForwardedFrom,
+				Attachments:      attachmentResponses(attachments[message.ID]),
+				SenderDevice:     senderDevice(message.SenderSessionID, deviceNames),
+				Contact:          names[message.RecipientAddress],
 			}
 		}
 
-		return c.Status(fiber.StatusOK).JSON(response)
+		return utils.OKResponse(c, MessagesResponse{
+			Messages: response,
+			HasMore:  hasMore,
+		})
 	}
 }
 
@@ -234,42 +388,32 @@ func GetMessage() fiber.Handler {
 		// Get user address from context
 		userAddress, ok := middleware.GetUserAddress(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
 		}
 
 		// Get message ID from URL parameter
 		messageID := c.Params("id")
 		if messageID == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Message ID is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Message ID is required")
 		}
 
 		// Get message from database
-		message, err := models.GetMessageByID(messageID)
+		message, err := stores.Messages.Get(c.UserContext(), messageID)
 		if err != nil {
 			if errors.Is(err, models.ErrMessageNotFound) {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"error": "Message not found",
-				})
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeMessageNotFound, "Message not found")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to get message",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get message")
 		}
 
 		// Check if user is sender or recipient
 		if message.SenderAddress != userAddress && message.RecipientAddress != userAddress {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"error": "Access denied",
-			})
+			return utils.ForbiddenResponse(c)
 		}
 
 		// Update message status to read if user is recipient and message is not read
 		if message.RecipientAddress == userAddress && message.Status != models.MessageStatusRead {
-			if err := models.UpdateMessageStatus(message.ID, models.MessageStatusRead); err != nil {
+			if err := models.UpdateMessageStatus(c.UserContext(), message.ID, models.MessageStatusRead); err != nil {
 				// Log error but continue
 				// TODO: Add proper logging
 			} else {
@@ -281,7 +425,7 @@ func GetMessage() fiber.Handler {
 							"message_id": message.ID,
 							"status":     "read",
 							"recipient":  userAddress,
-							"timestamp":  time.Now().Format(time.RFC3339),
+							"timestamp":  types.FormatTime(time.Now()),
 						},
 						To: message.SenderAddress,
 					}
@@ -289,19 +433,34 @@ func GetMessage() fiber.Handler {
 			}
 		}
 
+		attachments := loadAttachments(c.UserContext(), models.AttachmentKindDirect, []string{message.ID})
+		deviceNames := loadDeviceNames(c.UserContext(), []*string{message.SenderSessionID})
+		peerAddress := message.SenderAddress
+		if peerAddress == userAddress {
+			peerAddress = message.RecipientAddress
+		}
+		names := contactNames(c, userAddress, []string{peerAddress})
+
 		// Convert message to response format
 		response := MessageResponse{
 			ID:               message.ID,
 			SenderAddress:    message.SenderAddress,
 			RecipientAddress: message.RecipientAddress,
-			EncryptedContent: crypto.EncodeBase64(message.EncryptedContent),
+			EncryptedContent: payloadEncoding(c).Encode(message.EncryptedContent),
 			Timestamp:        message.Timestamp,
 			Status:           string(message.Status),
 			ExpirationTime:   message.ExpirationTime,
 			BlockID:          message.BlockID,
+			EditedAt:         message.EditedAt,
+			ReplyToMessageID: message.ReplyToMessageID,
+			StickerID:        message.StickerID,
+			ForwardedFrom:    message.ForwardedFrom,
+			Attachments:      attachmentResponses(attachments[message.ID]),
+			SenderDevice:     senderDeviceFor(userAddress, message.SenderAddress, message.SenderSessionID, deviceNames),
+			Contact:          names[peerAddress],
 		}
 
-		return c.Status(fiber.StatusOK).JSON(response)
+		return utils.OKResponse(c, response)
 	}
 }
 
@@ -311,48 +470,191 @@ func DeleteMessage() fiber.Handler {
 		// Get user address from context
 		userAddress, ok := middleware.GetUserAddress(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
 		}
 
 		// Get message ID from URL parameter
 		messageID := c.Params("id")
 		if messageID == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Message ID is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Message ID is required")
 		}
 
 		// Get message from database
-		message, err := models.GetMessageByID(messageID)
+		message, err := stores.Messages.Get(c.UserContext(), messageID)
 		if err != nil {
 			if errors.Is(err, models.ErrMessageNotFound) {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"error": "Message not found",
-				})
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeMessageNotFound, "Message not found")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to get message",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get message")
 		}
 
 		// Check if user is sender or recipient
 		if message.SenderAddress != userAddress && message.RecipientAddress != userAddress {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"error": "Access denied",
-			})
+			return utils.ForbiddenResponse(c)
+		}
+
+		if rejected, err := rejectLegalHold(c, message.SenderAddress, message.RecipientAddress); rejected {
+			return err
 		}
 
 		// Delete message
-		if err := models.DeleteMessage(messageID); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to delete message",
-			})
+		if err := stores.Messages.Delete(c.UserContext(), messageID); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to delete message")
 		}
 
-		return c.Status(fiber.StatusOK).JSON(fiber.Map{
-			"message": "Message deleted",
+		return utils.OKResponse(c, fiber.Map{
+			"message": localized(c, "Message deleted"),
 		})
 	}
 }
+
+// EditMessage handles replacing the content of a sent message
+func EditMessage(cfg *config.Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		// Get message ID from URL parameter
+		messageID := c.Params("id")
+		if messageID == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Message ID is required")
+		}
+
+		// Parse request body
+		req := new(EditMessageRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+		if req.EncryptedContent == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Encrypted content is required")
+		}
+
+		// Decode encrypted content
+		encryptedContent, err := payloadEncoding(c).Decode(req.EncryptedContent)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid encrypted content")
+		}
+		if tooLarge, err := rejectOversizedContent(c, encryptedContent); tooLarge {
+			return err
+		}
+
+		// Get message from database
+		message, err := stores.Messages.Get(c.UserContext(), messageID)
+		if err != nil {
+			if errors.Is(err, models.ErrMessageNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeMessageNotFound, "Message not found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get message")
+		}
+
+		// Only the sender may edit, and only within the edit window
+		if message.SenderAddress != userAddress {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotMessageSender, "Only the sender can edit this message")
+		}
+		if clock.Now().Sub(message.Timestamp.Time) > cfg.Messaging.EditWindow {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeEditWindowExpired, models.ErrEditWindowExpired.Error())
+		}
+
+		// Save the new content
+		editedAt, err := stores.Messages.Edit(c.UserContext(), messageID, encryptedContent)
+		if err != nil {
+			if errors.Is(err, models.ErrMessageNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeMessageNotFound, "Message not found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to edit message")
+		}
+		message.EncryptedContent = encryptedContent
+		message.EditedAt = types.NewTimePtr(editedAt)
+
+		// Push the edit to the recipient if they're online
+		go websocket.NotifyMessageEdited(WebSocketPool, message)
+
+		return utils.OKResponse(c, MessageResponse{
+			ID:               message.ID,
+			SenderAddress:    message.SenderAddress,
+			RecipientAddress: message.RecipientAddress,
+			EncryptedContent: payloadEncoding(c).Encode(message.EncryptedContent),
+			Timestamp:        message.Timestamp,
+			Status:           string(message.Status),
+			ExpirationTime:   message.ExpirationTime,
+			BlockID:          message.BlockID,
+			EditedAt:         message.EditedAt,
+			ReplyToMessageID: message.ReplyToMessageID,
+			StickerID:        message.StickerID,
+			ForwardedFrom:    message.ForwardedFrom,
+		})
+	}
+}
+
+// GetMessageEdits handles retrieving the edit history of a message
+func GetMessageEdits() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		// Get message ID from URL parameter
+		messageID := c.Params("id")
+		if messageID == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Message ID is required")
+		}
+
+		// Get message from database
+		message, err := stores.Messages.Get(c.UserContext(), messageID)
+		if err != nil {
+			if errors.Is(err, models.ErrMessageNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeMessageNotFound, "Message not found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get message")
+		}
+
+		// Check if user is sender or recipient
+		if message.SenderAddress != userAddress && message.RecipientAddress != userAddress {
+			return utils.ForbiddenResponse(c)
+		}
+
+		// Get edit history
+		edits, err := stores.Messages.Edits(c.UserContext(), messageID)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get edit history")
+		}
+
+		response := make([]MessageEditResponse, len(edits))
+		for i, edit := range edits {
+			response[i] = MessageEditResponse{
+				EncryptedContent: payloadEncoding(c).Encode(edit.EncryptedContent),
+				EditedAt:         edit.EditedAt,
+			}
+		}
+
+		return utils.OKResponse(c, response)
+	}
+}
+
+// validateDirectReply checks that a replied-to message was exchanged between
+// the same two users
+func validateDirectReply(ctx context.Context, messageID, senderAddress, recipientAddress string) error {
+	original, err := stores.Messages.Get(ctx, messageID)
+	if err != nil {
+		return err
+	}
+	if (original.SenderAddress == senderAddress && original.RecipientAddress == recipientAddress) ||
+		(original.SenderAddress == recipientAddress && original.RecipientAddress == senderAddress) {
+		return nil
+	}
+	return models.ErrInvalidReplyTarget
+}
+
+// directMessageIDs collects the IDs of direct messages
+func directMessageIDs(messages []*models.Message) []string {
+	ids := make([]string, len(messages))
+	for i, message := range messages {
+		ids[i] = message.ID
+	}
+	return ids
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/config"
+	"github.com/piko/piko/crypto"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/store"
+	"github.com/piko/piko/types"
+	"github.com/piko/piko/utils"
+)
+
+var carol = &models.User{ID: 4, Phone: "+15550004444", Username: "carol", Address: "PikoCarol"}
+
+// directMessage returns a message from alice to bob sent age ago
+func directMessage(id string, age time.Duration) *models.Message {
+	return &models.Message{
+		ID:               id,
+		SenderAddress:    alice.Address,
+		RecipientAddress: bob.Address,
+		EncryptedContent: []byte("original"),
+		Timestamp:        types.NewTime(clock.Now().Add(-age)),
+		Status:           models.MessageStatusDelivered,
+	}
+}
+
+// editBody is an EditMessageRequest for content
+func editBody(content string) string {
+	return `{"encrypted_content":"` + crypto.EncodingBase64.Encode([]byte(content)) + `"}`
+}
+
+func TestEditMessage(t *testing.T) {
+	messages := store.NewMemoryMessages(directMessage("m1", time.Minute))
+	useStores(t, store.Stores{Messages: messages})
+	cfg := config.DefaultConfig()
+
+	status, resp := serve(t, alice, http.MethodPut, "/messages/:id", "/messages/m1", editBody("edited"), EditMessage(cfg))
+	if status != http.StatusOK {
+		t.Fatalf("got status %d (%s), want 200", status, resp.Code)
+	}
+	var edited MessageResponse
+	decodeData(t, resp, &edited)
+	if edited.EncryptedContent != crypto.EncodingBase64.Encode([]byte("edited")) || edited.EditedAt == nil {
+		t.Errorf("got content %q and edited_at %v, want the new content and an edit time", edited.EncryptedContent, edited.EditedAt)
+	}
+
+	message, err := messages.Get(context.Background(), "m1")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if string(message.EncryptedContent) != "edited" {
+		t.Errorf("stored content is %q, want edited", message.EncryptedContent)
+	}
+
+	// Both participants see the previous version
+	for _, user := range []*models.User{alice, bob} {
+		status, resp := serve(t, user, http.MethodGet, "/messages/:id/edits", "/messages/m1/edits", "", GetMessageEdits())
+		if status != http.StatusOK {
+			t.Fatalf("%s got status %d (%s) getting edits, want 200", user.Username, status, resp.Code)
+		}
+		var edits []MessageEditResponse
+		decodeData(t, resp, &edits)
+		if len(edits) != 1 || edits[0].EncryptedContent != crypto.EncodingBase64.Encode([]byte("original")) {
+			t.Errorf("%s got edits %+v, want the original content", user.Username, edits)
+		}
+	}
+}
+
+func TestEditMessageRejects(t *testing.T) {
+	cfg := config.DefaultConfig()
+	useStores(t, store.Stores{Messages: store.NewMemoryMessages(
+		directMessage("recent", time.Minute),
+		directMessage("old", cfg.Messaging.EditWindow+time.Minute),
+	)})
+
+	tests := []struct {
+		name   string
+		user   *models.User
+		target string
+		body   string
+		status int
+		code   string
+	}{
+		{"anonymous", nil, "/messages/recent", editBody("edited"), http.StatusUnauthorized, utils.CodeUnauthorized},
+		{"missing content", alice, "/messages/recent", `{}`, http.StatusBadRequest, utils.CodeValidationFailed},
+		{"undecodable content", alice, "/messages/recent", `{"encrypted_content":"%%%"}`, http.StatusBadRequest, utils.CodeValidationFailed},
+		{"missing message", alice, "/messages/missing", editBody("edited"), http.StatusNotFound, utils.CodeMessageNotFound},
+		{"recipient", bob, "/messages/recent", editBody("edited"), http.StatusForbidden, utils.CodeNotMessageSender},
+		{"edit window over", alice, "/messages/old", editBody("edited"), http.StatusForbidden, utils.CodeEditWindowExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, resp := serve(t, tt.user, http.MethodPut, "/messages/:id", tt.target, tt.body, EditMessage(cfg))
+			if status != tt.status || resp.Code != tt.code {
+				t.Errorf("got %d %s, want %d %s", status, resp.Code, tt.status, tt.code)
+			}
+		})
+	}
+}
+
+func TestGetMessageEditsRejectsOutsiders(t *testing.T) {
+	useStores(t, store.Stores{Messages: store.NewMemoryMessages(directMessage("m1", time.Minute))})
+
+	status, resp := serve(t, carol, http.MethodGet, "/messages/:id/edits", "/messages/m1/edits", "", GetMessageEdits())
+	if status != http.StatusForbidden || resp.Code != utils.CodeForbidden {
+		t.Errorf("got %d %s, want 403 %s", status, resp.Code, utils.CodeForbidden)
+	}
+}
+
+func TestDeleteMessage(t *testing.T) {
+	// Either participant may delete
+	for _, user := range []*models.User{alice, bob} {
+		messages := store.NewMemoryMessages(directMessage("m1", time.Minute))
+		useStores(t, store.Stores{Messages: messages, LegalHolds: store.NewMemoryLegalHolds()})
+
+		status, resp := serve(t, user, http.MethodDelete, "/messages/:id", "/messages/m1", "", DeleteMessage())
+		if status != http.StatusOK {
+			t.Fatalf("%s got status %d (%s), want 200", user.Username, status, resp.Code)
+		}
+		if _, err := messages.Get(context.Background(), "m1"); !errors.Is(err, models.ErrMessageNotFound) {
+			t.Errorf("%s: Get after delete error = %v, want ErrMessageNotFound", user.Username, err)
+		}
+	}
+}
+
+func TestDeleteMessageRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		user   *models.User
+		target string
+		held   []string
+		status int
+		code   string
+	}{
+		{"anonymous", nil, "/messages/m1", nil, http.StatusUnauthorized, utils.CodeUnauthorized},
+		{"missing message", alice, "/messages/missing", nil, http.StatusNotFound, utils.CodeMessageNotFound},
+		{"outsider", carol, "/messages/m1", nil, http.StatusForbidden, utils.CodeForbidden},
+		{"legal hold", alice, "/messages/m1", []string{bob.Address}, http.StatusLocked, utils.CodeLegalHold},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages := store.NewMemoryMessages(directMessage("m1", time.Minute))
+			useStores(t, store.Stores{Messages: messages, LegalHolds: store.NewMemoryLegalHolds(tt.held...)})
+
+			status, resp := serve(t, tt.user, http.MethodDelete, "/messages/:id", tt.target, "", DeleteMessage())
+			if status != tt.status || resp.Code != tt.code {
+				t.Errorf("got %d %s, want %d %s", status, resp.Code, tt.status, tt.code)
+			}
+			if _, err := messages.Get(context.Background(), "m1"); err != nil {
+				t.Errorf("message is gone after a rejected delete: %v", err)
+			}
+		})
+	}
+}
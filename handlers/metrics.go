@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"log"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/config"
+	"github.com/piko/piko/metrics"
+	"github.com/piko/piko/utils"
+	"github.com/piko/piko/websocket"
+)
+
+func init() {
+	metrics.NewGaugeFunc("piko_websocket_connections", "Clients connected to the messaging WebSocket.", func() float64 {
+		return float64(websocket.ClientCount(WebSocketPool))
+	})
+	metrics.NewGaugeFunc("piko_secret_chat_websocket_connections", "Clients connected to the secret chat WebSocket.", func() float64 {
+		return float64(websocket.ClientCount(SecretChatPool))
+	})
+}
+
+// Metrics handles scraping the server's metrics in the Prometheus text
+// format. When a token is configured, it must be sent as a bearer token.
+func Metrics(cfg config.MetricsConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if cfg.Token != "" {
+			token := strings.TrimPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(token), []byte(cfg.Token)) != 1 {
+				return utils.UnauthorizedResponse(c)
+			}
+		}
+
+		c.Set(fiber.HeaderContentType, "text/plain; version=0.0.4; charset=utf-8")
+		if err := metrics.Write(c); err != nil {
+			log.Printf("Error writing metrics: %v", err)
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to write metrics")
+		}
+		return nil
+	}
+}
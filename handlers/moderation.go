@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/config"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/utils"
+)
+
+// moderationConfig holds when reported public channels are throttled
+var moderationConfig = config.DefaultConfig().Moderation
+
+// InitModeration sets when reported public channels are throttled
+func InitModeration(cfg config.ModerationConfig) {
+	moderationConfig = cfg
+}
+
+// AppealChannelFlagRequest represents a channel owner's appeal against a throttle
+type AppealChannelFlagRequest struct {
+	Reason string `json:"reason"`
+}
+
+// ReviewChannelFlagRequest represents an admin's decision on a throttled channel
+type ReviewChannelFlagRequest struct {
+	// Action is "release" to lift the throttle or "uphold" to keep it
+	Action string `json:"action"`
+}
+
+// reportThresholds returns the thresholds of the moderation config as of now
+func reportThresholds() models.ReportThresholds {
+	now := clock.Now()
+	return models.ReportThresholds{
+		ThrottleAt:      moderationConfig.ThrottleAt,
+		ReleaseAt:       moderationConfig.ReleaseAt,
+		Since:           now.Add(-moderationConfig.ReportWindow),
+		ReportersBefore: now.Add(-moderationConfig.MinReporterAge),
+	}
+}
+
+// evaluateChannelReports throttles or releases a channel according to the
+// reports against it. It returns whether the channel is throttled.
+func evaluateChannelReports(ctx context.Context, channelID string) (bool, error) {
+	if moderationConfig.ThrottleAt <= 0 {
+		return false, nil
+	}
+	flag, throttled, err := models.EvaluateChannelReports(ctx, channelID, reportThresholds())
+	if err != nil {
+		return false, err
+	}
+	if throttled {
+		log.Printf("Channel %s throttled after reports from %d users", channelID, flag.ReporterCount)
+	}
+	return flag != nil && flag.Throttled, nil
+}
+
+// rejectThrottledChannel writes a 429 response if a channel throttled by
+// moderation has used up its message allowance. Throttles whose reports
+// have since dropped off are released here, so they lift without waiting
+// for a new report.
+func rejectThrottledChannel(c *fiber.Ctx, channelID string) (bool, error) {
+	throttled, err := models.IsChannelThrottled(c.UserContext(), channelID)
+	if err == nil && throttled {
+		throttled, err = evaluateChannelReports(c.UserContext(), channelID)
+	}
+	if err != nil {
+		return true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check channel moderation")
+	}
+	if !throttled {
+		return false, nil
+	}
+	return middleware.LimitThrottledChannel(c, channelID)
+}
+
+// GetChannelFlag handles the owner of a channel checking whether it is
+// throttled
+func GetChannelFlag() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		channelID := c.Params("id")
+		if rejected, err := rejectNonOwner(c, channelID, userAddress); rejected {
+			return err
+		}
+
+		flag, err := models.GetChannelFlag(c.UserContext(), channelID)
+		if err != nil {
+			if errors.Is(err, models.ErrChannelFlagNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeChannelNotFlagged, "Channel has not been flagged")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get channel flag")
+		}
+
+		return utils.OKResponse(c, flag)
+	}
+}
+
+// AppealChannelFlag handles the owner of a throttled channel asking the
+// server's admins to review the throttle
+func AppealChannelFlag() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		req := new(AppealChannelFlagRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+		req.Reason = strings.TrimSpace(req.Reason)
+		if req.Reason == "" || utf8.RuneCountInString(req.Reason) > 1000 {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Reason is required and must be at most 1000 characters")
+		}
+
+		channelID := c.Params("id")
+		if rejected, err := rejectNonOwner(c, channelID, userAddress); rejected {
+			return err
+		}
+
+		if err := models.AppealChannelFlag(c.UserContext(), channelID, req.Reason); err != nil {
+			if errors.Is(err, models.ErrChannelNotThrottled) {
+				return utils.ErrorResponse(c, fiber.StatusConflict, utils.CodeChannelNotThrottled, "Channel is not throttled")
+			}
+			if errors.Is(err, models.ErrAppealExists) {
+				return utils.ErrorResponse(c, fiber.StatusConflict, utils.CodeAlreadyAppealed, "This throttle has already been appealed")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to appeal")
+		}
+
+		flag, err := models.GetChannelFlag(c.UserContext(), channelID)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get channel flag")
+		}
+		return utils.OKResponse(c, flag)
+	}
+}
+
+// rejectNonOwner writes a 404 response if the channel doesn't exist or a
+// 403 response if the user doesn't own it
+func rejectNonOwner(c *fiber.Ctx, channelID, userAddress string) (bool, error) {
+	channel, err := models.GetChannelByID(c.UserContext(), channelID)
+	if err != nil {
+		if errors.Is(err, models.ErrChannelNotFound) {
+			return true, utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeChannelNotFound, "Channel not found")
+		}
+		return true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get channel")
+	}
+	if channel.AdminAddress != userAddress {
+		return true, utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotChannelOwner, "Only the channel owner can do this")
+	}
+	return false, nil
+}
+
+// GetChannelFlags handles listing the throttled channels waiting for review
+func GetChannelFlags() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		pagination := utils.GetPaginationParams(c)
+
+		flags, err := models.GetChannelFlagsForReview(c.UserContext(), pagination.Limit)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get channel flags")
+		}
+
+		return utils.OKResponse(c, flags)
+	}
+}
+
+// ReviewChannelFlag handles an admin releasing or upholding a channel's throttle
+func ReviewChannelFlag() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		adminAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		req := new(ReviewChannelFlagRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+
+		channelID := c.Params("id")
+		var err error
+		switch req.Action {
+		case "release":
+			err = models.ReleaseChannelFlag(c.UserContext(), channelID, adminAddress)
+		case "uphold":
+			err = models.UpholdChannelFlag(c.UserContext(), channelID, adminAddress)
+		default:
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Action must be release or uphold")
+		}
+		if err != nil {
+			if errors.Is(err, models.ErrChannelFlagNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeChannelNotThrottled, "Throttled channel not found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to review channel")
+		}
+
+		flag, err := models.GetChannelFlag(c.UserContext(), channelID)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get channel flag")
+		}
+		return utils.OKResponse(c, flag)
+	}
+}
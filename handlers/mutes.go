@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/notifications"
+	"github.com/piko/piko/types"
+	"github.com/piko/piko/utils"
+)
+
+// MuteRequest represents a request to mute a conversation
+type MuteRequest struct {
+	ConversationType models.ConversationType `json:"conversation_type"`
+	// ConversationID is the peer's address for direct conversations
+	ConversationID string `json:"conversation_id"`
+	// MutedUntil is when the mute ends; without it the conversation stays
+	// muted until it is unmuted
+	MutedUntil *types.Time `json:"muted_until,omitempty"`
+}
+
+// DoNotDisturbRequest represents a request to turn on do not disturb
+type DoNotDisturbRequest struct {
+	// Until is when do not disturb ends; without it, it stays on until it
+	// is turned off
+	Until *types.Time `json:"until,omitempty"`
+}
+
+// MutesResponse represents what a user has silenced
+type MutesResponse struct {
+	Mutes []*models.Mute `json:"mutes"`
+	// DoNotDisturb is set while do not disturb is on
+	DoNotDisturb *models.DoNotDisturb `json:"do_not_disturb,omitempty"`
+}
+
+// MuteConversation handles muting a direct conversation, group or channel
+func MuteConversation() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		// Parse request body
+		req := new(MuteRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+
+		// Validate request
+		if !models.IsValidConversationType(req.ConversationType) || req.ConversationID == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "conversation_type must be direct, group or channel, and conversation_id is required")
+		}
+		if req.MutedUntil != nil && !req.MutedUntil.After(clock.Now()) {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "muted_until must be in the future")
+		}
+		if rejected, err := rejectUnknownMuteConversation(c, req.ConversationType, req.ConversationID, userAddress); rejected {
+			return err
+		}
+
+		mute := &models.Mute{
+			UserAddress:      userAddress,
+			ConversationType: req.ConversationType,
+			ConversationID:   req.ConversationID,
+			MutedUntil:       req.MutedUntil,
+			CreatedAt:        types.NewTime(clock.Now()),
+		}
+		if err := models.SetMute(c.UserContext(), mute); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to mute conversation")
+		}
+
+		return utils.OKResponse(c, mute)
+	}
+}
+
+// rejectUnknownMuteConversation writes an error response unless the user
+// can mute the conversation: another user, or a group or channel they are
+// a member of
+func rejectUnknownMuteConversation(c *fiber.Ctx, conversationType models.ConversationType, conversationID, userAddress string) (bool, error) {
+	if conversationType != models.ConversationDirect {
+		return rejectNonConversationMember(c, conversationType, conversationID, userAddress)
+	}
+
+	if conversationID == userAddress {
+		return true, utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid conversation_id")
+	}
+	if _, err := stores.Users.GetByAddress(c.UserContext(), conversationID); err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return true, utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeUserNotFound, "User not found")
+		}
+		return true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get user")
+	}
+	return false, nil
+}
+
+// UnmuteConversation handles unmuting a conversation
+func UnmuteConversation() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		conversationType := models.ConversationType(c.Params("conversation_type"))
+		if !models.IsValidConversationType(conversationType) {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Conversation type must be direct, group or channel")
+		}
+
+		if err := models.DeleteMute(c.UserContext(), userAddress, conversationType, c.Params("conversation_id")); err != nil {
+			if errors.Is(err, models.ErrMuteNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeMuteNotFound, "Conversation is not muted")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to unmute conversation")
+		}
+
+		return utils.OKResponse(c, fiber.Map{
+			"message": localized(c, "Conversation unmuted"),
+		})
+	}
+}
+
+// GetMutes handles listing the conversations a user has muted, and their
+// do not disturb
+func GetMutes() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		mutes, err := models.GetMutes(c.UserContext(), userAddress)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get mutes")
+		}
+		dnd, err := models.GetDoNotDisturb(c.UserContext(), userAddress)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get mutes")
+		}
+
+		return utils.OKResponse(c, MutesResponse{Mutes: mutes, DoNotDisturb: dnd})
+	}
+}
+
+// SetDoNotDisturb handles turning on do not disturb, which silences all of
+// a user's notifications
+func SetDoNotDisturb() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		// Parse request body
+		req := new(DoNotDisturbRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+
+		var until *time.Time
+		if req.Until != nil {
+			if !req.Until.After(clock.Now()) {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "until must be in the future")
+			}
+			until = &req.Until.Time
+		}
+		if err := models.SetDoNotDisturb(c.UserContext(), userAddress, until); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to turn on do not disturb")
+		}
+
+		dnd, err := models.GetDoNotDisturb(c.UserContext(), userAddress)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get do not disturb")
+		}
+
+		return utils.OKResponse(c, dnd)
+	}
+}
+
+// ClearDoNotDisturb handles turning do not disturb off
+func ClearDoNotDisturb() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		if err := models.ClearDoNotDisturb(c.UserContext(), userAddress); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to turn off do not disturb")
+		}
+
+		return utils.OKResponse(c, fiber.Map{
+			"message": localized(c, "Do not disturb turned off"),
+		})
+	}
+}
+
+// mutedRecipients loads which recipients silenced a conversation. Failures
+// are logged and nobody counts as muted, so notifications aren't lost.
+func mutedRecipients(conversationType models.ConversationType, conversationID string, addresses []string) map[string]bool {
+	muted, err := models.GetMutedUsers(context.Background(), conversationType, conversationID, addresses)
+	if err != nil {
+		log.Printf("Error loading mutes of %s %s: %v", conversationType, conversationID, err)
+		return map[string]bool{}
+	}
+	return muted
+}
+
+// pushUnlessMuted sends a push notification about a conversation to an
+// offline user, unless they muted it or turned on do not disturb
+func pushUnlessMuted(address string, conversationType models.ConversationType, conversationID string, notification *notifications.Notification) {
+	if mutedRecipients(conversationType, conversationID, []string{address})[address] {
+		return
+	}
+	pushIfOffline(address, notification)
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/config"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/utils"
+)
+
+// OTP delivery channels a client may ask for
+const (
+	otpChannelSMS   = "sms"
+	otpChannelEmail = "email"
+)
+
+// rejectInvalidOTPChannel writes a 400 response if an OTP is requested on a
+// channel other than SMS or email. An empty channel means SMS.
+func rejectInvalidOTPChannel(c *fiber.Ctx, channel string) (bool, error) {
+	if channel == "" || channel == otpChannelSMS || channel == otpChannelEmail {
+		return false, nil
+	}
+	return true, utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Channel must be sms or email")
+}
+
+// normalizeEmail returns the bare address of an email, or false if it isn't
+// a valid one
+func normalizeEmail(email string) (string, bool) {
+	email = strings.TrimSpace(email)
+	if !utils.IsValidEmail(email) {
+		return "", false
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || len(addr.Address) > 254 {
+		return "", false
+	}
+	return addr.Address, true
+}
+
+// sendOTP delivers an OTP by email when it has an address, otherwise by
+// SMS. Nothing is sent to test phones.
+func sendOTP(cfg *config.Config, otp *models.OTP) error {
+	if isTestPhone(otp.Phone) {
+		return nil
+	}
+	if otp.Email != "" {
+		return utils.SendOTPEmail(utils.FromConfigEmail(&cfg.Mailer), otp.Email, otp.Code)
+	}
+	return utils.SendOTP(utils.FromConfigSMS(&cfg.SMS), otp.Phone, otp.Code)
+}
+
+// otpSentMessage tells the user where to look for an OTP
+func otpSentMessage(c *fiber.Ctx, otp *models.OTP) string {
+	if otp.Email != "" {
+		return localized(c, "OTP sent to your email")
+	}
+	return localized(c, "OTP sent to your phone")
+}
+
+// otpErrorCode returns the error code of an OTP that can't be verified
+func otpErrorCode(err error) string {
+	switch {
+	case errors.Is(err, models.ErrOTPExpired):
+		return utils.CodeOTPExpired
+	case errors.Is(err, models.ErrOTPInvalid):
+		return utils.CodeInvalidOTP
+	}
+	return utils.CodeOTPNotFound
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/utils"
+)
+
+// mediaReferencePrefix starts photo URLs that point at media uploaded to
+// this server. Members get a download link from GET /api/media/:id.
+const mediaReferencePrefix = "/api/media/"
+
+// maxPhotoURLLength is the size of the photo_url column
+const maxPhotoURLLength = 255
+
+var (
+	errPhotoURLInvalid = errors.New("photo_url must be an https URL or a /api/media/:id reference of at most 255 characters")
+	errPhotoURLDomain  = errors.New("photo_url domain is not allowed")
+	errPhotoURLMedia   = errors.New("photo_url must reference an image you uploaded")
+	errPhotoURLFetch   = errors.New("photo_url image could not be fetched")
+)
+
+// resolvePhotoURL validates a group photo URL and returns the URL to store.
+// Media references must be images uploaded by the user, and external URLs
+// must be on an allowed domain. External images are copied into media
+// storage when rehosting is on, and a reference to the copy is returned.
+// The group's current photo is always accepted unchanged.
+func resolvePhotoURL(ctx context.Context, ownerAddress, raw, current string) (string, error) {
+	if raw == "" || raw == current {
+		return raw, nil
+	}
+	if len(raw) > maxPhotoURLLength {
+		return "", errPhotoURLInvalid
+	}
+
+	if mediaID, ok := strings.CutPrefix(raw, mediaReferencePrefix); ok {
+		if mediaID == "" || strings.Contains(mediaID, "/") {
+			return "", errPhotoURLInvalid
+		}
+		media, err := models.GetMediaByID(ctx, mediaID)
+		if err != nil {
+			if errors.Is(err, models.ErrMediaNotFound) {
+				return "", errPhotoURLMedia
+			}
+			return "", err
+		}
+		if media.OwnerAddress != ownerAddress || !isPhotoType(media.MimeType) {
+			return "", errPhotoURLMedia
+		}
+		return raw, nil
+	}
+
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Scheme != "https" || parsed.Host == "" || parsed.User != nil {
+		return "", errPhotoURLInvalid
+	}
+	if !isAllowedExternalHost(parsed.Hostname()) {
+		return "", errPhotoURLDomain
+	}
+	if !mediaConfig.RehostExternal {
+		return raw, nil
+	}
+
+	media, err := rehostImage(ctx, ownerAddress, parsed)
+	if err != nil {
+		return "", err
+	}
+	return mediaReferencePrefix + media.ID, nil
+}
+
+// photoURLError writes the response for an error from resolvePhotoURL
+func photoURLError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, errPhotoURLInvalid), errors.Is(err, errPhotoURLDomain), errors.Is(err, errPhotoURLMedia):
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidPhotoURL, err.Error())
+	case errors.Is(err, errPhotoURLFetch):
+		return utils.ErrorResponse(c, fiber.StatusUnprocessableEntity, utils.CodePhotoURLUnreachable, err.Error())
+	}
+	return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check photo_url")
+}
+
+// isAllowedExternalHost checks a host against the configured external
+// domains, which also allow their subdomains
+func isAllowedExternalHost(host string) bool {
+	host = strings.ToLower(host)
+	for _, domain := range mediaConfig.ExternalDomains {
+		domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+		if domain != "" && (host == domain || strings.HasSuffix(host, "."+domain)) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPhotoType checks if a MIME type can be shown as a group photo. SVG is
+// excluded because it can carry scripts.
+func isPhotoType(mimeType string) bool {
+	return strings.HasPrefix(mimeType, "image/") && mimeType != "image/svg+xml" && isAllowedMediaType(mimeType)
+}
+
+// rehostImage downloads an external image into media storage. Redirects
+// must stay on allowed domains, and the body must look like an image no
+// larger than a single upload.
+func rehostImage(ctx context.Context, ownerAddress string, source *url.URL) (*models.Media, error) {
+	client := &http.Client{
+		Timeout: mediaConfig.RehostTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errPhotoURLFetch
+			}
+			if req.URL.Scheme != "https" || !isAllowedExternalHost(req.URL.Hostname()) {
+				return errPhotoURLDomain
+			}
+			return nil
+		},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source.String(), nil)
+	if err != nil {
+		return nil, errPhotoURLInvalid
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		if errors.Is(err, errPhotoURLDomain) {
+			return nil, errPhotoURLDomain
+		}
+		return nil, errPhotoURLFetch
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w (status %d)", errPhotoURLFetch, resp.StatusCode)
+	}
+	mimeType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || !isPhotoType(mimeType) {
+		return nil, fmt.Errorf("%w (not an image)", errPhotoURLFetch)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, mediaConfig.ChunkSize+1))
+	if err != nil {
+		return nil, errPhotoURLFetch
+	}
+	if int64(len(body)) > mediaConfig.ChunkSize {
+		return nil, fmt.Errorf("%w (larger than %d bytes)", errPhotoURLFetch, mediaConfig.ChunkSize)
+	}
+	if !isPhotoType(http.DetectContentType(body)) {
+		return nil, fmt.Errorf("%w (not an image)", errPhotoURLFetch)
+	}
+
+	fileName := path.Base(source.Path)
+	if fileName == "." || fileName == "/" {
+		fileName = "photo"
+	}
+	return storeMedia(ctx, ownerAddress, fileName, mimeType, bytes.NewReader(body), int64(len(body)))
+}
@@ -0,0 +1,288 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/config"
+	"github.com/piko/piko/crypto"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/types"
+	"github.com/piko/piko/utils"
+)
+
+// Account PIN length limits, in characters
+const (
+	minPINLength = 4
+	maxPINLength = 64
+)
+
+// SetPINRequest represents a request to set or change the account PIN
+type SetPINRequest struct {
+	PIN string `json:"pin"`
+	// CurrentPIN is required when changing an existing PIN
+	CurrentPIN string `json:"current_pin,omitempty"`
+}
+
+// RemovePINRequest represents a request to remove the account PIN
+type RemovePINRequest struct {
+	CurrentPIN string `json:"current_pin"`
+}
+
+// PINStatusResponse tells whether the account has a PIN
+type PINStatusResponse struct {
+	Enabled     bool        `json:"enabled"`
+	LockedUntil *types.Time `json:"locked_until,omitempty"`
+	UpdatedAt   *types.Time `json:"updated_at,omitempty"`
+}
+
+// hashPIN hashes a PIN with the configured Argon2 parameters
+func hashPIN(cfg *config.Config, pin string) (string, error) {
+	hash, err := crypto.HashPassword(pin, nil, cfg.Auth.Argon2Time, cfg.Auth.Argon2Memory, cfg.Auth.Argon2Threads, cfg.Auth.Argon2KeyLength)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(hash), nil
+}
+
+// rejectWrongPIN checks candidate against the user's PIN. A wrong or locked
+// PIN is rejected and wrong ones count towards the lockout.
+func rejectWrongPIN(c *fiber.Ctx, cfg *config.Config, pin *models.AccountPIN, candidate string) (bool, error) {
+	if pin.Locked(clock.Now()) {
+		return true, utils.ErrorResponseWith(c, fiber.StatusTooManyRequests, utils.CodePINLocked, "Too many wrong PINs. Try again later.", fiber.Map{
+			"locked_until": pin.LockedUntil,
+		})
+	}
+
+	ok, err := crypto.VerifyPassword(candidate, pin.Hash, cfg.Auth.Argon2Time, cfg.Auth.Argon2Memory, cfg.Auth.Argon2Threads, cfg.Auth.Argon2KeyLength)
+	if err != nil {
+		return true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to verify PIN")
+	}
+	if ok {
+		if err := models.ResetPINFailures(c.UserContext(), pin.UserAddress); err != nil {
+			return true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to verify PIN")
+		}
+		return false, nil
+	}
+
+	lockedUntil, err := models.RecordPINFailure(c.UserContext(), pin.UserAddress, cfg.Auth.PINMaxAttempts, cfg.Auth.PINLockout)
+	if err != nil {
+		return true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to verify PIN")
+	}
+	if lockedUntil != nil {
+		return true, utils.ErrorResponseWith(c, fiber.StatusTooManyRequests, utils.CodePINLocked, "Too many wrong PINs. Try again later.", fiber.Map{
+			"locked_until": types.NewTime(*lockedUntil),
+		})
+	}
+	return true, utils.ErrorResponse(c, fiber.StatusUnauthorized, utils.CodeInvalidPIN, "Invalid PIN")
+}
+
+// loginPIN returns the PIN a login to an account must present, or nil when
+// the account has none or the device token shows the device signed in
+// before
+func loginPIN(c *fiber.Ctx, userAddress, deviceToken string) (*models.AccountPIN, error) {
+	pin, err := models.GetAccountPIN(c.UserContext(), userAddress)
+	if errors.Is(err, models.ErrPINNotSet) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if deviceToken != "" {
+		trusted, err := models.IsTrustedDevice(c.UserContext(), crypto.HashToHex([]byte(deviceToken)), userAddress)
+		if err != nil {
+			return nil, err
+		}
+		if trusted {
+			return nil, nil
+		}
+	}
+	return pin, nil
+}
+
+// requirePIN rejects a login that needs a PIN but didn't send one, or
+// whose PIN is locked
+func requirePIN(c *fiber.Ctx, pin *models.AccountPIN, candidate string) (bool, error) {
+	if pin.Locked(clock.Now()) {
+		return true, utils.ErrorResponseWith(c, fiber.StatusTooManyRequests, utils.CodePINLocked, "Too many wrong PINs. Try again later.", fiber.Map{
+			"locked_until": pin.LockedUntil,
+		})
+	}
+	if candidate == "" {
+		return true, utils.ErrorResponseWith(c, fiber.StatusUnauthorized, utils.CodePINRequired, "PIN required", fiber.Map{
+			"pin_required": true,
+		})
+	}
+	return false, nil
+}
+
+// rejectMissingPIN rejects a login that needs a PIN but didn't send one,
+// before the OTP is used up. It returns the PIN to check once the OTP is
+// verified.
+func rejectMissingPIN(c *fiber.Ctx, req *VerifyOTPRequest) (*models.AccountPIN, bool, error) {
+	user, err := stores.Users.GetByPhone(c.UserContext(), req.Phone)
+	if errors.Is(err, models.ErrUserNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get PIN")
+	}
+
+	pin, err := loginPIN(c, user.Address, req.DeviceToken)
+	if err != nil {
+		return nil, true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get PIN")
+	}
+	if pin == nil {
+		return nil, false, nil
+	}
+	if rejected, err := requirePIN(c, pin, req.PIN); rejected {
+		return nil, true, err
+	}
+	return pin, false, nil
+}
+
+// rejectUntrustedDevice runs the whole PIN check for logins that prove who
+// they are in one step, such as key login: a device that hasn't signed in
+// before needs the account PIN, if there is one
+func rejectUntrustedDevice(c *fiber.Ctx, cfg *config.Config, userAddress, candidate, deviceToken string) (bool, error) {
+	pin, err := loginPIN(c, userAddress, deviceToken)
+	if err != nil {
+		return true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get PIN")
+	}
+	if pin == nil {
+		return false, nil
+	}
+	if rejected, err := requirePIN(c, pin, candidate); rejected {
+		return true, err
+	}
+	return rejectWrongPIN(c, cfg, pin, candidate)
+}
+
+// issueDeviceToken returns a token the device sends when it signs in again,
+// so it isn't asked for the PIN. Only its hash is stored.
+func issueDeviceToken(c *fiber.Ctx, userAddress, sessionID string) (string, error) {
+	tokenBytes, err := crypto.GenerateRandomBytes(32)
+	if err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(tokenBytes)
+	if err := models.TrustDevice(c.UserContext(), crypto.HashToHex([]byte(token)), userAddress, sessionID); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// GetPINStatus handles checking whether the account has a PIN
+func GetPINStatus() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		pin, err := models.GetAccountPIN(c.UserContext(), userAddress)
+		if errors.Is(err, models.ErrPINNotSet) {
+			return utils.OKResponse(c, PINStatusResponse{})
+		}
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get PIN")
+		}
+
+		response := PINStatusResponse{Enabled: true, UpdatedAt: &pin.UpdatedAt}
+		if pin.Locked(clock.Now()) {
+			response.LockedUntil = pin.LockedUntil
+		}
+		return utils.OKResponse(c, response)
+	}
+}
+
+// SetPIN handles setting or changing the account PIN
+func SetPIN(cfg *config.Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		req := new(SetPINRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+		if n := utf8.RuneCountInString(req.PIN); n < minPINLength || n > maxPINLength {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "PIN must be 4 to 64 characters")
+		}
+
+		// Changing a PIN needs the current one
+		current, err := models.GetAccountPIN(c.UserContext(), userAddress)
+		if err != nil && !errors.Is(err, models.ErrPINNotSet) {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get PIN")
+		}
+		if current != nil {
+			if req.CurrentPIN == "" {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Current PIN is required")
+			}
+			if rejected, err := rejectWrongPIN(c, cfg, current, req.CurrentPIN); rejected {
+				return err
+			}
+		}
+
+		hash, err := hashPIN(cfg, req.PIN)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to hash PIN")
+		}
+		if err := models.SetAccountPIN(c.UserContext(), userAddress, hash); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to set PIN")
+		}
+
+		recordSessionAudit(c, userAddress, models.AuditActionPINSet, userAddress)
+
+		return utils.OKResponse(c, fiber.Map{
+			"message": localized(c, "PIN set successfully"),
+		})
+	}
+}
+
+// RemovePIN handles removing the account PIN
+func RemovePIN(cfg *config.Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		req := new(RemovePINRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+
+		current, err := models.GetAccountPIN(c.UserContext(), userAddress)
+		if errors.Is(err, models.ErrPINNotSet) {
+			return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodePINNotSet, err.Error())
+		}
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get PIN")
+		}
+		if rejected, err := rejectWrongPIN(c, cfg, current, req.CurrentPIN); rejected {
+			return err
+		}
+
+		if err := models.DeleteAccountPIN(c.UserContext(), userAddress); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to remove PIN")
+		}
+
+		recordSessionAudit(c, userAddress, models.AuditActionPINRemove, userAddress)
+
+		return utils.OKResponse(c, fiber.Map{
+			"message": localized(c, "PIN removed successfully"),
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/plugins"
+	"github.com/piko/piko/utils"
+)
+
+// rejectByPlugins runs the plugins' message hooks, which may rewrite the
+// message's content. It writes a 403 response when a plugin refuses the
+// message.
+func rejectByPlugins(c *fiber.Ctx, message *plugins.Message) (bool, error) {
+	if err := plugins.BeforeMessagePersist(c.UserContext(), message); err != nil {
+		return true, pluginErrorResponse(c, err)
+	}
+	return false, nil
+}
+
+// rejectJoinByPlugins runs the plugins' member join hooks, writing a 403
+// response when a plugin refuses the join
+func rejectJoinByPlugins(c *fiber.Ctx, join *plugins.MemberJoin) (bool, error) {
+	if err := plugins.BeforeMemberJoin(c.UserContext(), join); err != nil {
+		return true, pluginErrorResponse(c, err)
+	}
+	return false, nil
+}
+
+// pluginErrorResponse writes the response for an error from a plugin hook.
+// Rejections are shown to the user; anything else is an internal error.
+func pluginErrorResponse(c *fiber.Ctx, err error) error {
+	var rejection *plugins.Rejection
+	if errors.As(err, &rejection) {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodePluginRejected, rejection.Reason)
+	}
+	return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to apply server policy")
+}
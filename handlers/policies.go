@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"errors"
+	"net/url"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/utils"
+)
+
+// AcceptPoliciesRequest represents a user accepting policy versions
+type AcceptPoliciesRequest struct {
+	PolicyIDs []int `json:"policy_ids"`
+}
+
+// PublishPolicyRequest represents an admin publishing a new policy version
+type PublishPolicyRequest struct {
+	Kind    models.PolicyKind `json:"kind"`
+	Version string            `json:"version"`
+	URL     string            `json:"url"`
+}
+
+// PendingPoliciesResponse lists the current policies a user hasn't accepted
+type PendingPoliciesResponse struct {
+	Pending []*models.Policy `json:"pending"`
+}
+
+// missingPolicies returns the current policies not in accepted
+func missingPolicies(c *fiber.Ctx, accepted []int) ([]*models.Policy, error) {
+	current, err := models.GetCurrentPolicies(c.UserContext())
+	if err != nil {
+		return nil, err
+	}
+
+	isAccepted := make(map[int]bool, len(accepted))
+	for _, id := range accepted {
+		isAccepted[id] = true
+	}
+	missing := []*models.Policy{}
+	for _, policy := range current {
+		if !isAccepted[policy.ID] {
+			missing = append(missing, policy)
+		}
+	}
+	return missing, nil
+}
+
+// acceptCurrentPolicies records a user's acceptance of every current policy
+func acceptCurrentPolicies(c *fiber.Ctx, userID int) error {
+	current, err := models.GetCurrentPolicies(c.UserContext())
+	if err != nil || len(current) == 0 {
+		return err
+	}
+
+	ids := make([]int, len(current))
+	for i, policy := range current {
+		ids[i] = policy.ID
+	}
+	return models.AcceptPolicies(c.UserContext(), userID, ids)
+}
+
+// GetPolicies handles getting the current terms of service and privacy policy
+func GetPolicies() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		policies, err := models.GetCurrentPolicies(c.UserContext())
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get policies")
+		}
+
+		return utils.OKResponse(c, policies)
+	}
+}
+
+// AcceptPolicies handles a user accepting the current policies
+func AcceptPolicies() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user ID from context
+		userID, ok := middleware.GetUserID(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		// Parse request body
+		req := new(AcceptPoliciesRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+		if len(req.PolicyIDs) == 0 {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Policy IDs are required")
+		}
+
+		if err := models.AcceptPolicies(c.UserContext(), userID, req.PolicyIDs); err != nil {
+			if errors.Is(err, models.ErrPolicyNotCurrent) {
+				return utils.ErrorResponse(c, fiber.StatusConflict, utils.CodePolicyOutdated, "A newer version of the policy has been published")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to accept policies")
+		}
+
+		// Report what is still left to accept
+		pending, err := models.GetPendingPolicies(c.UserContext(), userID)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get pending policies")
+		}
+
+		return utils.OKResponse(c, PendingPoliciesResponse{Pending: pending})
+	}
+}
+
+// GetPolicyAcceptances handles getting the policy versions a user accepted and when
+func GetPolicyAcceptances() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user ID from context
+		userID, ok := middleware.GetUserID(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		acceptances, err := models.GetPolicyAcceptances(c.UserContext(), userID)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get policy acceptances")
+		}
+
+		return utils.OKResponse(c, acceptances)
+	}
+}
+
+// PublishPolicy handles an admin publishing a new policy version. Every user
+// has to accept it before they can use the API again.
+func PublishPolicy() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Parse request body
+		req := new(PublishPolicyRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+
+		// Validate request
+		if !models.IsValidPolicyKind(req.Kind) {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Kind must be terms or privacy")
+		}
+		if req.Version == "" || len(req.Version) > 32 {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Version is required and must be at most 32 characters")
+		}
+		if parsed, err := url.Parse(req.URL); err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || len(req.URL) > 255 {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "URL must be an http or https URL of at most 255 characters")
+		}
+
+		policy := &models.Policy{
+			Kind:    req.Kind,
+			Version: req.Version,
+			URL:     req.URL,
+		}
+		if err := models.CreatePolicy(c.UserContext(), policy); err != nil {
+			if errors.Is(err, models.ErrPolicyVersionExists) {
+				return utils.ErrorResponse(c, fiber.StatusConflict, utils.CodePolicyVersionExists, "Policy version already exists")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to publish policy")
+		}
+
+		return utils.CreatedResponse(c, policy)
+	}
+}
@@ -0,0 +1,392 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/utils"
+	"github.com/piko/piko/websocket"
+)
+
+// Limits of CreatePoll
+const (
+	minPollOptions        = 2
+	maxPollOptions        = 10
+	maxPollQuestionLength = 300
+	maxPollOptionLength   = 100
+)
+
+// CreatePollRequest represents a request to attach a poll to a group or
+// channel message the user sent
+type CreatePollRequest struct {
+	ConversationType models.ConversationType `json:"conversation_type"`
+	ConversationID   string                  `json:"conversation_id"`
+	MessageID        string                  `json:"message_id"`
+	Question         string                  `json:"question"`
+	Options          []string                `json:"options"`
+	// Anonymous polls show how many members chose each option but not who
+	Anonymous bool `json:"anonymous,omitempty"`
+}
+
+// VotePollRequest represents a member's vote on a poll
+type VotePollRequest struct {
+	// Option is the position of the chosen option, counting from 0
+	Option int `json:"option"`
+}
+
+// PollResponse represents a poll with its results and the user's vote
+type PollResponse struct {
+	*models.Poll
+	// MyVote is the position of the option the user voted for, if they voted
+	MyVote *int `json:"my_vote,omitempty"`
+}
+
+// rejectNonPollOwner writes a 403 response unless the user may close the
+// poll: its creator, or an admin of its group or channel
+func rejectNonPollOwner(c *fiber.Ctx, poll *models.Poll, userAddress string) (bool, error) {
+	if poll.CreatorAddress == userAddress {
+		return false, nil
+	}
+
+	switch poll.ConversationType {
+	case models.ConversationGroup:
+		isAdmin, rejected, err := rejectNonGroupMember(c, poll.ConversationID, userAddress)
+		if rejected {
+			return true, err
+		}
+		if !isAdmin {
+			return true, utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotGroupAdmin, "Only the poll's creator and admins can close it")
+		}
+	default:
+		role, err := models.GetChannelRole(c.UserContext(), poll.ConversationID, userAddress)
+		if err != nil && !errors.Is(err, models.ErrUserNotInChannel) {
+			return true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check channel membership")
+		}
+		if !role.CanManage() {
+			return true, utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotChannelAdmin, "Only the poll's creator and admins can close it")
+		}
+	}
+	return false, nil
+}
+
+// rejectForeignPollMessage writes an error response unless the message is
+// in the conversation and was sent by the user
+func rejectForeignPollMessage(c *fiber.Ctx, conversationType models.ConversationType, conversationID, messageID, userAddress string) (bool, error) {
+	var messageConversation, senderAddress string
+	var err error
+	if conversationType == models.ConversationGroup {
+		var message *models.GroupMessage
+		if message, err = models.GetGroupMessageByID(c.UserContext(), messageID); err == nil {
+			messageConversation, senderAddress = message.GroupID, message.SenderAddress
+		}
+	} else {
+		var message *models.ChannelMessage
+		if message, err = models.GetChannelMessageByID(c.UserContext(), messageID); err == nil {
+			messageConversation, senderAddress = message.ChannelID, message.SenderAddress
+		}
+	}
+	if err != nil && !errors.Is(err, models.ErrMessageNotFound) {
+		return true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get message")
+	}
+	if err != nil || messageConversation != conversationID {
+		return true, utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeMessageNotFound, "Message not found")
+	}
+	if senderAddress != userAddress {
+		return true, utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotMessageSender, "Only the message's sender can attach a poll to it")
+	}
+	return false, nil
+}
+
+// getMemberPoll loads the poll in the id parameter, writing an error
+// response unless the user is a member of its group or channel
+func getMemberPoll(c *fiber.Ctx, userAddress string) (*models.Poll, bool, error) {
+	poll, err := models.GetPoll(c.UserContext(), c.Params("id"))
+	if err != nil {
+		if errors.Is(err, models.ErrPollNotFound) {
+			return nil, true, utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodePollNotFound, "Poll not found")
+		}
+		return nil, true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get poll")
+	}
+
+	rejected, err := rejectNonConversationMember(c, poll.ConversationType, poll.ConversationID, userAddress)
+	return poll, rejected, err
+}
+
+// pollResponse adds the user's vote to a poll
+func pollResponse(ctx context.Context, poll *models.Poll, userAddress string) (*PollResponse, error) {
+	response := &PollResponse{Poll: poll}
+	position, err := models.GetPollVote(ctx, poll.ID, userAddress)
+	if err != nil {
+		if errors.Is(err, models.ErrPollVoteNotFound) {
+			return response, nil
+		}
+		return nil, err
+	}
+	response.MyVote = &position
+	return response, nil
+}
+
+// updatedPollResponse reloads a poll after a change, tells the members of
+// its conversation and writes it with the user's vote
+func updatedPollResponse(c *fiber.Ctx, pollID, userAddress string) error {
+	poll, err := models.GetPoll(c.UserContext(), pollID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get poll")
+	}
+
+	go notifyPollUpdated(poll)
+
+	response, err := pollResponse(c.UserContext(), poll, userAddress)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get vote")
+	}
+	return utils.OKResponse(c, response)
+}
+
+// notifyPollUpdated sends a poll's results to the online members of its
+// conversation
+func notifyPollUpdated(poll *models.Poll) {
+	var members []string
+	if poll.ConversationType == models.ConversationChannel {
+		var err error
+		members, err = conversationMembers(context.Background(), poll.ConversationType, poll.ConversationID)
+		if err != nil {
+			log.Printf("Error getting members of channel %s for poll %s: %v", poll.ConversationID, poll.ID, err)
+			return
+		}
+	}
+	websocket.NotifyPollUpdated(WebSocketPool, poll, members)
+}
+
+// CreatePoll handles attaching a poll to a group or channel message
+func CreatePoll() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		req := new(CreatePollRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+		if (req.ConversationType != models.ConversationGroup && req.ConversationType != models.ConversationChannel) || req.ConversationID == "" || req.MessageID == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "conversation_type must be group or channel, and conversation_id and message_id are required")
+		}
+		req.Question = strings.TrimSpace(req.Question)
+		if req.Question == "" || utf8.RuneCountInString(req.Question) > maxPollQuestionLength {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Question is required and must be at most 300 characters")
+		}
+		if len(req.Options) < minPollOptions || len(req.Options) > maxPollOptions {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "A poll must have between 2 and 10 options")
+		}
+		for i, option := range req.Options {
+			req.Options[i] = strings.TrimSpace(option)
+			if req.Options[i] == "" || utf8.RuneCountInString(req.Options[i]) > maxPollOptionLength {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Options are required and must be at most 100 characters")
+			}
+		}
+
+		if rejected, err := rejectNonConversationMember(c, req.ConversationType, req.ConversationID, userAddress); rejected {
+			return err
+		}
+		if rejected, err := rejectForeignPollMessage(c, req.ConversationType, req.ConversationID, req.MessageID, userAddress); rejected {
+			return err
+		}
+
+		pollID, err := utils.NewID()
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to generate poll ID")
+		}
+
+		poll := &models.Poll{
+			ID:               pollID,
+			ConversationType: req.ConversationType,
+			ConversationID:   req.ConversationID,
+			MessageID:        req.MessageID,
+			CreatorAddress:   userAddress,
+			Question:         req.Question,
+			Anonymous:        req.Anonymous,
+		}
+		if err := models.CreatePoll(c.UserContext(), poll, req.Options); err != nil {
+			if errors.Is(err, models.ErrPollExists) {
+				return utils.ErrorResponse(c, fiber.StatusConflict, utils.CodePollExists, "This message already has a poll")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to create poll")
+		}
+
+		go notifyPollUpdated(poll)
+
+		return utils.CreatedResponse(c, PollResponse{Poll: poll})
+	}
+}
+
+// GetPoll handles retrieving a poll's results and the user's vote
+func GetPoll() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		poll, rejected, err := getMemberPoll(c, userAddress)
+		if rejected {
+			return err
+		}
+
+		response, err := pollResponse(c.UserContext(), poll, userAddress)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get vote")
+		}
+		return utils.OKResponse(c, response)
+	}
+}
+
+// GetGroupPolls handles listing the polls of a group
+func GetGroupPolls() fiber.Handler {
+	return getConversationPolls(models.ConversationGroup)
+}
+
+// GetChannelPolls handles listing the polls of a channel
+func GetChannelPolls() fiber.Handler {
+	return getConversationPolls(models.ConversationChannel)
+}
+
+// getConversationPolls handles listing the polls of the group or channel in
+// the id parameter, newest first
+func getConversationPolls(conversationType models.ConversationType) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		conversationID := c.Params("id")
+		if rejected, err := rejectNonConversationMember(c, conversationType, conversationID, userAddress); rejected {
+			return err
+		}
+
+		pagination := utils.GetPaginationParams(c)
+		polls, err := models.GetConversationPolls(c.UserContext(), conversationType, conversationID, pagination.Limit, pagination.CalculateOffset())
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get polls")
+		}
+
+		responses := make([]*PollResponse, len(polls))
+		for i, poll := range polls {
+			if responses[i], err = pollResponse(c.UserContext(), poll, userAddress); err != nil {
+				return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get vote")
+			}
+		}
+		return utils.OKResponse(c, responses)
+	}
+}
+
+// VotePoll handles a member voting on a poll. Members vote once; to change
+// their answer they retract their vote and vote again.
+func VotePoll() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		req := new(VotePollRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+
+		poll, rejected, err := getMemberPoll(c, userAddress)
+		if rejected {
+			return err
+		}
+
+		if err := models.CastPollVote(c.UserContext(), poll.ID, userAddress, req.Option); err != nil {
+			switch {
+			case errors.Is(err, models.ErrPollNotFound):
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodePollNotFound, "Poll not found")
+			case errors.Is(err, models.ErrPollClosed):
+				return utils.ErrorResponse(c, fiber.StatusConflict, utils.CodePollClosed, "This poll is closed")
+			case errors.Is(err, models.ErrInvalidPollOption):
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid option")
+			case errors.Is(err, models.ErrAlreadyVoted):
+				return utils.ErrorResponse(c, fiber.StatusConflict, utils.CodeAlreadyVoted, "You have already voted on this poll")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to save vote")
+		}
+
+		return updatedPollResponse(c, poll.ID, userAddress)
+	}
+}
+
+// RetractPollVote handles a member taking back their vote on an open poll
+func RetractPollVote() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		poll, rejected, err := getMemberPoll(c, userAddress)
+		if rejected {
+			return err
+		}
+
+		if err := models.RetractPollVote(c.UserContext(), poll.ID, userAddress); err != nil {
+			switch {
+			case errors.Is(err, models.ErrPollNotFound):
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodePollNotFound, "Poll not found")
+			case errors.Is(err, models.ErrPollClosed):
+				return utils.ErrorResponse(c, fiber.StatusConflict, utils.CodePollClosed, "This poll is closed")
+			case errors.Is(err, models.ErrPollVoteNotFound):
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodePollVoteNotFound, "You haven't voted on this poll")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to retract vote")
+		}
+
+		return updatedPollResponse(c, poll.ID, userAddress)
+	}
+}
+
+// ClosePoll handles closing a poll to further votes. Only its creator and
+// the admins of its group or channel may close it.
+func ClosePoll() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		poll, rejected, err := getMemberPoll(c, userAddress)
+		if rejected {
+			return err
+		}
+		if rejected, err := rejectNonPollOwner(c, poll, userAddress); rejected {
+			return err
+		}
+
+		if err := models.ClosePoll(c.UserContext(), poll.ID); err != nil {
+			switch {
+			case errors.Is(err, models.ErrPollNotFound):
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodePollNotFound, "Poll not found")
+			case errors.Is(err, models.ErrPollClosed):
+				return utils.ErrorResponse(c, fiber.StatusConflict, utils.CodePollClosed, "This poll is closed")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to close poll")
+		}
+
+		return updatedPollResponse(c, poll.ID, userAddress)
+	}
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/crypto"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/types"
+	"github.com/piko/piko/utils"
+)
+
+// newPowChallenge stores a new proof-of-work challenge for a purpose
+func newPowChallenge(c *fiber.Ctx, purpose models.PowPurpose, difficulty int, expiry time.Duration) (*models.PowChallenge, error) {
+	nonceBytes, err := crypto.GenerateRandomBytes(32)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge := &models.PowChallenge{
+		Nonce:      hex.EncodeToString(nonceBytes),
+		Purpose:    purpose,
+		Difficulty: difficulty,
+		ExpiresAt:  types.NewTime(clock.Now().Add(expiry)),
+	}
+	if err := models.CreatePowChallenge(c.UserContext(), challenge); err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+// rejectUnsolvedPow checks the solution to a proof-of-work challenge issued
+// for a purpose and consumes the challenge, writing a 400 or 403 response
+// when it fails
+func rejectUnsolvedPow(c *fiber.Ctx, purpose models.PowPurpose, nonce, solution string) (bool, error) {
+	if nonce == "" || solution == "" {
+		return true, utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Challenge and solution are required")
+	}
+
+	challenge, err := models.GetPowChallenge(c.UserContext(), nonce, purpose)
+	if err != nil {
+		if errors.Is(err, models.ErrChallengeInvalid) {
+			return true, utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeChallengeInvalid, "Challenge invalid or expired")
+		}
+		return true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to verify challenge")
+	}
+
+	if !crypto.VerifyProofOfWork(challenge.Nonce, solution, challenge.Difficulty) {
+		return true, utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeInvalidProofOfWork, "Invalid proof of work")
+	}
+
+	if err := models.ConsumePowChallenge(c.UserContext(), challenge.Nonce); err != nil {
+		if errors.Is(err, models.ErrChallengeInvalid) {
+			return true, utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeChallengeInvalid, "Challenge invalid or expired")
+		}
+		return true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to verify challenge")
+	}
+	return false, nil
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"context"
+	"log"
+
+	"github.com/piko/piko/privacy"
+)
+
+// presenceAudience loads who may see a user come online and go offline. If
+// it can't be loaded the failure is logged and nobody else is told, rather
+// than everyone.
+func presenceAudience(ctx context.Context, address string) privacy.Audience {
+	policy, err := privacy.ForAddress(ctx, address)
+	if err == nil {
+		var audience privacy.Audience
+		if audience, err = policy.Audience(ctx, privacy.Status); err == nil {
+			return audience
+		}
+	}
+	log.Printf("Error loading presence audience of %s: %v", address, err)
+	return privacy.Audience{Owner: address}
+}
+
+// refreshPresenceAudience updates who sees a connected user's presence
+// after their privacy settings or contacts change
+func refreshPresenceAudience(ctx context.Context, address string) {
+	WebSocketPool.UpdatePresenceAudience(address, presenceAudience(ctx, address))
+}
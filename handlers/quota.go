@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/config"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/utils"
+)
+
+// quotaConfig holds the group, channel, broadcast list and storage limits
+var quotaConfig = config.DefaultConfig().Quotas
+
+// InitQuotas sets the group, channel, broadcast list and storage limits
+func InitQuotas(cfg config.QuotaConfig) {
+	quotaConfig = cfg
+}
+
+// reportGroupMembers reports how full a group is. Counting failures only
+// leave the quota out of the response.
+func reportGroupMembers(c *fiber.Ctx, groupID string) {
+	if quotaConfig.MaxGroupMembers <= 0 {
+		return
+	}
+	count, err := models.CountGroupMembers(c.UserContext(), groupID)
+	if err != nil {
+		log.Printf("Error counting members of group %s: %v", groupID, err)
+		return
+	}
+	middleware.ReportQuota(c, "group_members", int64(quotaConfig.MaxGroupMembers), int64(count))
+}
+
+// reportChannelMembers reports how full a channel is. Counting failures
+// only leave the quota out of the response.
+func reportChannelMembers(c *fiber.Ctx, channelID string) {
+	if quotaConfig.MaxChannelMembers <= 0 {
+		return
+	}
+	count, err := models.CountChannelMembers(c.UserContext(), channelID)
+	if err != nil {
+		log.Printf("Error counting members of channel %s: %v", channelID, err)
+		return
+	}
+	middleware.ReportQuota(c, "channel_members", int64(quotaConfig.MaxChannelMembers), int64(count))
+}
+
+// rejectFullBroadcastList writes a 403 response if a list would hold more
+// than the allowed number of recipients
+func rejectFullBroadcastList(c *fiber.Ctx, count int) (bool, error) {
+	if quotaConfig.MaxBroadcastRecipients <= 0 || count <= quotaConfig.MaxBroadcastRecipients {
+		return false, nil
+	}
+	return true, utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeBroadcastListFull, "Broadcast list is full")
+}
+
+// reportBroadcastRecipients reports how full a broadcast list is
+func reportBroadcastRecipients(c *fiber.Ctx, count int) {
+	if quotaConfig.MaxBroadcastRecipients <= 0 {
+		return
+	}
+	middleware.ReportQuota(c, "broadcast_recipients", int64(quotaConfig.MaxBroadcastRecipients), int64(count))
+}
+
+// rejectOverStorageQuota writes a 413 response if storing size more bytes
+// would take a user past their storage quota, and otherwise reports the
+// quota as it will be once they're stored
+func rejectOverStorageQuota(c *fiber.Ctx, ownerAddress string, size int64) (bool, error) {
+	limit := quotaConfig.StoragePerUser
+	if limit <= 0 {
+		return false, nil
+	}
+
+	used, err := models.GetStorageUsed(c.UserContext(), ownerAddress)
+	if err != nil {
+		return true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check storage quota")
+	}
+	if used+size > limit {
+		middleware.ReportQuota(c, "storage", limit, used)
+		return true, utils.ErrorResponseWith(c, fiber.StatusRequestEntityTooLarge, utils.CodeQuotaExceeded, "Storage quota exceeded", fiber.Map{
+			"limit": limit,
+			"used":  used,
+		})
+	}
+
+	middleware.ReportQuota(c, "storage", limit, used+size)
+	return false, nil
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/utils"
+	"github.com/piko/piko/websocket"
+)
+
+// MarkReadRequest represents a member marking a group or channel read up to a message
+type MarkReadRequest struct {
+	MessageID string `json:"message_id"`
+}
+
+// MarkGroupRead handles moving the user's read cursor in a group forward
+func MarkGroupRead() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		// Get group ID from URL parameter
+		groupID := c.Params("id")
+
+		// Parse request body
+		req := new(MarkReadRequest)
+		if err := c.BodyParser(req); err != nil || req.MessageID == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Message ID is required")
+		}
+
+		// Check if user is a member of the group
+		if _, err := models.IsGroupAdmin(c.UserContext(), groupID, userAddress); err != nil {
+			if errors.Is(err, models.ErrGroupMemberNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotGroupMember, "You are not a member of this group")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check membership")
+		}
+
+		// The message must be in this group
+		message, err := models.GetGroupMessageByID(c.UserContext(), req.MessageID)
+		if err != nil && !errors.Is(err, models.ErrMessageNotFound) {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get message")
+		}
+		if message == nil || message.GroupID != groupID {
+			return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeMessageNotFound, "Message not found in this group")
+		}
+
+		advanced, err := models.MarkGroupRead(c.UserContext(), groupID, userAddress, message)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to mark group as read")
+		}
+		if advanced {
+			go websocket.NotifyGroupRead(WebSocketPool, groupID, userAddress, message.ID)
+		}
+
+		return utils.OKResponse(c, fiber.Map{
+			"message": localized(c, "Group marked as read"),
+		})
+	}
+}
+
+// MarkChannelRead handles moving the user's read cursor in a channel forward
+func MarkChannelRead() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		// Get channel ID from URL parameter
+		channelID := c.Params("id")
+
+		// Parse request body
+		req := new(MarkReadRequest)
+		if err := c.BodyParser(req); err != nil || req.MessageID == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Message ID is required")
+		}
+
+		// Check if user is a member of the channel
+		isMember, err := models.IsUserInChannel(c.UserContext(), channelID, userAddress)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check membership")
+		}
+		if !isMember {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeNotChannelMember, "You are not a member of this channel")
+		}
+
+		// The message must be in this channel
+		message, err := models.GetChannelMessageByID(c.UserContext(), req.MessageID)
+		if err != nil && !errors.Is(err, models.ErrMessageNotFound) {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get message")
+		}
+		if message == nil || message.ChannelID != channelID {
+			return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeMessageNotFound, "Message not found in this channel")
+		}
+
+		advanced, err := models.MarkChannelRead(c.UserContext(), channelID, userAddress, message)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to mark channel as read")
+		}
+		if advanced {
+			go websocket.NotifyChannelRead(WebSocketPool, message, userAddress)
+		}
+
+		return utils.OKResponse(c, fiber.Map{
+			"message": localized(c, "Channel marked as read"),
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/types"
+	"github.com/piko/piko/utils"
+)
+
+// Page sizes of GetReceipts
+const (
+	defaultReceiptsLimit = 100
+	maxReceiptsLimit     = 500
+)
+
+// MessageReceiptsResponse represents the status history of a direct message
+type MessageReceiptsResponse struct {
+	MessageID string                   `json:"message_id"`
+	Status    string                   `json:"status"`
+	SentAt    types.Time               `json:"sent_at"`
+	Receipts  []*models.MessageReceipt `json:"receipts"`
+}
+
+// ReceiptsResponse represents the status changes of a user's sent messages
+type ReceiptsResponse struct {
+	Receipts []*models.MessageReceipt `json:"receipts"`
+	// NextCursor is the cursor to ask for the following receipts with
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// GetMessageReceipts handles getting when a direct message was delivered
+// and read. Only the sender and recipient can see it.
+func GetMessageReceipts() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		message, err := stores.Messages.Get(c.UserContext(), c.Params("id"))
+		if err != nil {
+			if errors.Is(err, models.ErrMessageNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeMessageNotFound, "Message not found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get message")
+		}
+		if message.SenderAddress != userAddress && message.RecipientAddress != userAddress {
+			return utils.ForbiddenResponse(c)
+		}
+
+		receipts, err := models.GetMessageReceipts(c.UserContext(), message.ID)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get receipts")
+		}
+
+		return utils.OKResponse(c, MessageReceiptsResponse{
+			MessageID: message.ID,
+			Status:    string(message.Status),
+			SentAt:    message.Timestamp,
+			Receipts:  receipts,
+		})
+	}
+}
+
+// GetReceipts handles getting the status changes of the user's sent
+// messages after a cursor or time, so a sender who was offline can catch up
+func GetReceipts() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		// Continue from a cursor, or start after a time
+		var cursor *models.ReceiptCursor
+		switch {
+		case c.Query("cursor") != "":
+			parsed, err := models.ParseReceiptCursor(c.Query("cursor"))
+			if err != nil {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid cursor parameter")
+			}
+			cursor = &parsed
+		case c.Query("since") != "":
+			parsed, err := time.Parse(types.TimeLayout, c.Query("since"))
+			if err != nil {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid since parameter")
+			}
+			at := models.ReceiptCursorAt(parsed)
+			cursor = &at
+		}
+
+		limit := defaultReceiptsLimit
+		if c.Query("limit") != "" {
+			parsed, err := strconv.Atoi(c.Query("limit"))
+			if err != nil || parsed <= 0 {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid limit parameter")
+			}
+			limit = min(parsed, maxReceiptsLimit)
+		}
+
+		after := models.ReceiptCursorAt(time.Time{})
+		if cursor != nil {
+			after = *cursor
+		}
+
+		// One extra tells whether there are more
+		receipts, err := models.GetReceiptsAfter(c.UserContext(), userAddress, after, limit+1)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get receipts")
+		}
+
+		response := ReceiptsResponse{Receipts: receipts}
+		if len(receipts) > limit {
+			response.Receipts = receipts[:limit]
+			response.HasMore = true
+		}
+		if len(response.Receipts) > 0 {
+			response.NextCursor = response.Receipts[len(response.Receipts)-1].Cursor().String()
+		} else if cursor != nil {
+			response.NextCursor = cursor.String()
+		}
+		return utils.OKResponse(c, response)
+	}
+}
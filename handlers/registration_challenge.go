@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/config"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/types"
+	"github.com/piko/piko/utils"
+)
+
+// registrationChallengeConfig holds what has to be passed before an OTP is
+// sent to register a phone number
+var registrationChallengeConfig = config.DefaultConfig().Auth.RegistrationChallenge
+
+// InitRegistrationChallenge configures the proof-of-work or CAPTCHA
+// required to register
+func InitRegistrationChallenge(cfg config.RegistrationChallengeConfig) {
+	registrationChallengeConfig = cfg
+}
+
+// RegistrationChallengeResponse tells a client what it has to pass to
+// register. Nonce and difficulty are set for a proof-of-work, and the site
+// key for a CAPTCHA.
+type RegistrationChallengeResponse struct {
+	// Type is "none", "pow" or "captcha"
+	Type       string      `json:"type"`
+	Nonce      string      `json:"nonce,omitempty"`
+	Difficulty int         `json:"difficulty,omitempty"`
+	ExpiresAt  *types.Time `json:"expires_at,omitempty"`
+	SiteKey    string      `json:"site_key,omitempty"`
+}
+
+// errCaptchaUnavailable is returned when the CAPTCHA provider can't be reached
+var errCaptchaUnavailable = errors.New("CAPTCHA provider unavailable")
+
+// registrationChallengeType returns the configured challenge type, treating
+// an empty type as none
+func registrationChallengeType() string {
+	if registrationChallengeConfig.Type == "" {
+		return config.RegistrationChallengeNone
+	}
+	return registrationChallengeConfig.Type
+}
+
+// GetRegistrationChallenge handles issuing the challenge a client has to
+// pass before registering
+func GetRegistrationChallenge() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		response := RegistrationChallengeResponse{Type: registrationChallengeType()}
+
+		switch response.Type {
+		case config.RegistrationChallengePoW:
+			challenge, err := newPowChallenge(c, models.PowPurposeRegistration,
+				registrationChallengeConfig.ProofOfWorkDifficulty, registrationChallengeConfig.ChallengeExpiry)
+			if err != nil {
+				return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to create challenge")
+			}
+			response.Nonce = challenge.Nonce
+			response.Difficulty = challenge.Difficulty
+			response.ExpiresAt = &challenge.ExpiresAt
+		case config.RegistrationChallengeCaptcha:
+			response.SiteKey = registrationChallengeConfig.Captcha.SiteKey
+		}
+
+		return utils.OKResponse(c, response)
+	}
+}
+
+// rejectUnverifiedRegistration checks the proof-of-work or CAPTCHA token of
+// a registration request, writing an error response when it fails. Test
+// phones are exempt so load tests can register.
+func rejectUnverifiedRegistration(c *fiber.Ctx, req *RegisterRequest) (bool, error) {
+	if isTestPhone(req.Phone) {
+		return false, nil
+	}
+
+	switch registrationChallengeType() {
+	case config.RegistrationChallengePoW:
+		return rejectUnsolvedPow(c, models.PowPurposeRegistration, req.Challenge, req.Solution)
+	case config.RegistrationChallengeCaptcha:
+		if req.CaptchaToken == "" {
+			return true, utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "CAPTCHA token is required")
+		}
+		ok, err := verifyCaptcha(c.UserContext(), registrationChallengeConfig.Captcha, req.CaptchaToken, c.IP())
+		if err != nil {
+			log.Printf("Error verifying CAPTCHA: %v", err)
+			return true, utils.ErrorResponse(c, fiber.StatusServiceUnavailable, utils.CodeServiceUnavailable, "Failed to verify CAPTCHA")
+		}
+		if !ok {
+			return true, utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeCaptchaFailed, "CAPTCHA verification failed")
+		}
+	}
+	return false, nil
+}
+
+// verifyCaptcha checks a CAPTCHA token with the provider's siteverify
+// endpoint
+func verifyCaptcha(ctx context.Context, cfg config.CaptchaConfig, token, remoteIP string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	form := url.Values{
+		"secret":   {cfg.Secret},
+		"response": {token},
+		"remoteip": {remoteIP},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.VerifyURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", errCaptchaUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("%w (status %d)", errCaptchaUnavailable, resp.StatusCode)
+	}
+
+	var result struct {
+		Success bool `json:"success"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&result); err != nil {
+		return false, fmt.Errorf("%w: %v", errCaptchaUnavailable, err)
+	}
+	return result.Success, nil
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/utils"
+)
+
+// CreateReportRequest represents a request to report abuse
+type CreateReportRequest struct {
+	TargetType models.ReportTarget `json:"target_type"`
+	TargetID   string              `json:"target_id"`
+	Reason     string              `json:"reason"`
+}
+
+// CreateReport handles reporting a user, message, group or channel to the
+// server's admins
+func CreateReport() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		// Parse request body
+		req := new(CreateReportRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+
+		// Validate request
+		if !models.IsValidReportTarget(req.TargetType) {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Target type must be user, message, group or channel")
+		}
+		if req.TargetID == "" || len(req.TargetID) > 64 {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid target ID")
+		}
+		req.Reason = strings.TrimSpace(req.Reason)
+		if req.Reason == "" || utf8.RuneCountInString(req.Reason) > 500 {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Reason is required and must be at most 500 characters")
+		}
+
+		report := &models.Report{
+			ReporterAddress: userAddress,
+			TargetType:      req.TargetType,
+			TargetID:        req.TargetID,
+			Reason:          req.Reason,
+		}
+		if err := models.CreateReport(c.UserContext(), report); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to create report")
+		}
+
+		// Throttle the channel if this report takes it over the threshold
+		if report.TargetType == models.ReportTargetChannel {
+			if _, err := evaluateChannelReports(c.UserContext(), report.TargetID); err != nil && !errors.Is(err, models.ErrChannelNotFound) {
+				log.Printf("Error evaluating reports against channel %s: %v", report.TargetID, err)
+			}
+		}
+
+		return utils.CreatedResponse(c, report)
+	}
+}
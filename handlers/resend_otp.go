@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/config"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/utils"
+)
+
+// ResendOTPRequest represents a request to send a new code in place of the
+// last one
+type ResendOTPRequest struct {
+	Phone string `json:"phone"`
+}
+
+// ResendOTPResponse represents a resent OTP
+type ResendOTPResponse struct {
+	Message string `json:"message"`
+	// ExpiresIn is how many minutes the new code is valid
+	ExpiresIn int `json:"expires_in"`
+	// ResendsLeft is how many more times the code can be resent
+	ResendsLeft int `json:"resends_left"`
+	// RetryAfter is how many seconds until it can be resent again
+	RetryAfter int `json:"retry_after"`
+}
+
+// retryAfterSeconds rounds a wait up to whole seconds, at least one
+func retryAfterSeconds(wait time.Duration) int {
+	seconds := int(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	return seconds
+}
+
+// ResendOTP handles sending a new code for a registration or login that is
+// waiting for one, the same way the last code was sent
+func ResendOTP(cfg *config.Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Parse request body
+		req := new(ResendOTPRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+
+		// Validate request
+		if req.Phone == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Phone number is required")
+		}
+
+		code, err := otpCode(cfg, req.Phone)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to generate OTP")
+		}
+
+		otp, wait, err := models.ResendOTP(c.UserContext(), req.Phone, code, cfg.Auth.OTPExpiryMinutes, cfg.Auth.OTPResendCooldown, cfg.Auth.OTPMaxResends)
+		if err != nil {
+			switch {
+			case errors.Is(err, models.ErrOTPNotFound):
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeOTPNotFound, "No code to resend. Please register or log in again.")
+			case errors.Is(err, models.ErrOTPResendTooSoon):
+				retryAfter := retryAfterSeconds(wait)
+				c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+				return utils.ErrorResponseWith(c, fiber.StatusTooManyRequests, utils.CodeOTPResendTooSoon, "Please wait before requesting another code", fiber.Map{
+					"retry_after": retryAfter,
+				})
+			case errors.Is(err, models.ErrOTPResendLimit):
+				return utils.ErrorResponseWith(c, fiber.StatusTooManyRequests, utils.CodeOTPResendLimit, "This code can't be resent again. Please register or log in again.", fiber.Map{
+					"resends_left": 0,
+				})
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to generate OTP")
+		}
+
+		// Send OTP via SMS or email, except to test phones
+		if err := sendOTP(cfg, otp); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to send OTP")
+		}
+
+		return utils.OKResponse(c, ResendOTPResponse{
+			Message:     otpSentMessage(c, otp),
+			ExpiresIn:   cfg.Auth.OTPExpiryMinutes,
+			ResendsLeft: max(cfg.Auth.OTPMaxResends-otp.ResendCount, 0),
+			RetryAfter:  retryAfterSeconds(cfg.Auth.OTPResendCooldown),
+		})
+	}
+}
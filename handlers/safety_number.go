@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/crypto"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/types"
+	"github.com/piko/piko/utils"
+	"github.com/piko/piko/websocket"
+)
+
+// SafetyNumberResponse represents the key verification state of a conversation
+type SafetyNumberResponse struct {
+	PeerAddress        string      `json:"peer_address"`
+	SafetyNumber       string      `json:"safety_number"`
+	PeerKeyFingerprint string      `json:"peer_key_fingerprint"`
+	Verified           bool        `json:"verified"`
+	VerifiedAt         *types.Time `json:"verified_at,omitempty"`
+}
+
+// VerifySafetyNumberRequest represents a request to mark a conversation as verified
+type VerifySafetyNumberRequest struct {
+	Verified     bool   `json:"verified"`
+	SafetyNumber string `json:"safety_number"`
+}
+
+// GetSafetyNumber handles retrieving the safety number for a conversation
+func GetSafetyNumber() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		user, peer, err := loadConversationUsers(c.UserContext(), userAddress, c.Params("address"))
+		if err != nil {
+			return conversationUsersError(c, err)
+		}
+
+		userKey, peerKey, err := conversationIdentityKeys(c.UserContext(), user, peer)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to load conversation keys")
+		}
+
+		key, err := refreshConversationKey(c.UserContext(), user, peer, peerKey)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to load conversation keys")
+		}
+
+		return utils.OKResponse(c, SafetyNumberResponse{
+			PeerAddress:        peer.Address,
+			SafetyNumber:       crypto.SafetyNumber(user.Address, userKey, peer.Address, peerKey),
+			PeerKeyFingerprint: key.PeerKeyFingerprint,
+			Verified:           key.Verified,
+			VerifiedAt:         key.VerifiedAt,
+		})
+	}
+}
+
+// VerifySafetyNumber handles marking a conversation's safety number as verified
+func VerifySafetyNumber() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		// Parse request body
+		req := new(VerifySafetyNumberRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+
+		user, peer, err := loadConversationUsers(c.UserContext(), userAddress, c.Params("address"))
+		if err != nil {
+			return conversationUsersError(c, err)
+		}
+
+		userKey, peerKey, err := conversationIdentityKeys(c.UserContext(), user, peer)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to load conversation keys")
+		}
+
+		if _, err := refreshConversationKey(c.UserContext(), user, peer, peerKey); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to load conversation keys")
+		}
+
+		// The client must confirm the number it compared, so a key change
+		// between fetching and verifying can't be verified by accident
+		safetyNumber := crypto.SafetyNumber(user.Address, userKey, peer.Address, peerKey)
+		if req.Verified && req.SafetyNumber != safetyNumber {
+			return utils.ErrorResponse(c, fiber.StatusConflict, utils.CodeSafetyNumberMismatch, "Safety number does not match")
+		}
+
+		if err := models.SetConversationVerified(c.UserContext(), user.Address, peer.Address, req.Verified); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to update verification")
+		}
+
+		key, err := models.GetConversationKey(c.UserContext(), user.Address, peer.Address)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to load conversation keys")
+		}
+
+		return utils.OKResponse(c, SafetyNumberResponse{
+			PeerAddress:        peer.Address,
+			SafetyNumber:       safetyNumber,
+			PeerKeyFingerprint: key.PeerKeyFingerprint,
+			Verified:           key.Verified,
+			VerifiedAt:         key.VerifiedAt,
+		})
+	}
+}
+
+// loadConversationUsers loads both participants of a one-to-one conversation
+func loadConversationUsers(ctx context.Context, userAddress, peerAddress string) (*models.User, *models.User, error) {
+	if peerAddress == "" || peerAddress == userAddress {
+		return nil, nil, models.ErrUserNotFound
+	}
+
+	user, err := stores.Users.GetByAddress(ctx, userAddress)
+	if err != nil {
+		return nil, nil, err
+	}
+	peer, err := stores.Users.GetByAddress(ctx, peerAddress)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, peer, nil
+}
+
+// identityKey returns the key peers encrypt to: the identity key of the
+// user's key bundle, or the account key if they haven't published one
+func identityKey(ctx context.Context, user *models.User) ([]byte, error) {
+	bundle, err := models.GetKeyBundle(ctx, user.Address)
+	if err != nil {
+		if errors.Is(err, models.ErrKeyBundleNotFound) {
+			return user.PublicKey, nil
+		}
+		return nil, err
+	}
+	return bundle.IdentityKey, nil
+}
+
+// conversationIdentityKeys returns the identity keys of both participants
+// of a one-to-one conversation
+func conversationIdentityKeys(ctx context.Context, user, peer *models.User) ([]byte, []byte, error) {
+	userKey, err := identityKey(ctx, user)
+	if err != nil {
+		return nil, nil, err
+	}
+	peerKey, err := identityKey(ctx, peer)
+	if err != nil {
+		return nil, nil, err
+	}
+	return userKey, peerKey, nil
+}
+
+// conversationUsersError converts a loadConversationUsers error to a response
+func conversationUsersError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, models.ErrUserNotFound) {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeUserNotFound, "User not found")
+	}
+	return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get user")
+}
+
+// refreshConversationKey records the fingerprint of the peer's identity key
+// the first time the user looks at the conversation. Later key changes are
+// recorded when the peer uploads them, see resetKeyVerifications.
+func refreshConversationKey(ctx context.Context, user, peer *models.User, peerKey []byte) (*models.ConversationKey, error) {
+	if err := models.SaveConversationKey(ctx, user.Address, peer.Address, crypto.KeyFingerprint(peerKey)); err != nil {
+		return nil, err
+	}
+	return models.GetConversationKey(ctx, user.Address, peer.Address)
+}
+
+// resetKeyVerifications records a user's current identity key for everyone
+// who tracked an older one, clearing their verification and sending them
+// safety_number_changed
+func resetKeyVerifications(ctx context.Context, address string, identityKey []byte) error {
+	owners, err := models.ResetConversationKeys(ctx, address, crypto.KeyFingerprint(identityKey))
+	if err != nil {
+		return err
+	}
+	for _, owner := range owners {
+		go websocket.NotifySafetyNumberChanged(WebSocketPool, owner, address)
+	}
+	return nil
+}
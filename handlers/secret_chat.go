@@ -1,15 +1,19 @@
 package handlers
 
 import (
-	"crypto/rand"
+	"context"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/websocket/v2"
+	"github.com/piko/piko/clock"
 	"github.com/piko/piko/crypto"
 	"github.com/piko/piko/models"
+	"github.com/piko/piko/types"
+	"github.com/piko/piko/utils"
 	ws "github.com/piko/piko/websocket"
 )
 
@@ -23,13 +27,25 @@ func init() {
 
 // CreateSecretChatRequest represents a request to create a secret chat
 type CreateSecretChatRequest struct {
-	// No parameters needed for creation
+	// Challenge and Solution are a solved proof-of-work challenge from
+	// GET /api/secret-chat/challenge, required unless it is disabled
+	Challenge string `json:"challenge,omitempty"`
+	Solution  string `json:"solution,omitempty"`
+	// TTLSeconds is how long the chat lasts, within the server's bounds;
+	// the server default applies when it is 0
+	TTLSeconds int64 `json:"ttl_seconds,omitempty"`
+	// MaxParticipants limits how many participants may join; the server
+	// cap applies when it is 0
+	MaxParticipants int `json:"max_participants,omitempty"`
 }
 
 // CreateSecretChatResponse represents a response to create a secret chat
 type CreateSecretChatResponse struct {
-	ChannelID string    `json:"channel_id"`
-	ExpiresAt time.Time `json:"expires_at"`
+	ChannelID string     `json:"channel_id"`
+	ExpiresAt types.Time `json:"expires_at"`
+	// ExpiresIn is the number of seconds until the chat expires
+	ExpiresIn       int64 `json:"expires_in"`
+	MaxParticipants int   `json:"max_participants"`
 }
 
 // JoinSecretChatRequest represents a request to join a secret chat
@@ -40,10 +56,13 @@ type JoinSecretChatRequest struct {
 
 // JoinSecretChatResponse represents a response to join a secret chat
 type JoinSecretChatResponse struct {
-	SessionID    string    `json:"session_id"`
-	ChannelID    string    `json:"channel_id"`
-	ExpiresAt    time.Time `json:"expires_at"`
-	WebSocketURL string    `json:"websocket_url"`
+	SessionID    string     `json:"session_id"`
+	ChannelID    string     `json:"channel_id"`
+	ExpiresAt    types.Time `json:"expires_at"`
+	WebSocketURL string     `json:"websocket_url"`
+	// ExpiresIn is the number of seconds until the chat expires
+	ExpiresIn       int64 `json:"expires_in"`
+	MaxParticipants int   `json:"max_participants"`
 }
 
 // SecretChatMessageRequest represents a request to send a message in a secret chat
@@ -54,28 +73,64 @@ type SecretChatMessageRequest struct {
 
 // SecretChatMessageResponse represents a message in a secret chat
 type SecretChatMessageResponse struct {
-	ID               string    `json:"id"`
-	ChannelID        string    `json:"channel_id"`
-	DisplayName      string    `json:"display_name"`
-	EncryptedContent string    `json:"encrypted_content"`
-	Timestamp        time.Time `json:"timestamp"`
+	ID               string     `json:"id"`
+	ChannelID        string     `json:"channel_id"`
+	DisplayName      string     `json:"display_name"`
+	EncryptedContent string     `json:"encrypted_content"`
+	Timestamp        types.Time `json:"timestamp"`
 }
 
 // CreateSecretChat handles creating a new secret chat
 func CreateSecretChat() fiber.Handler {
 	return func(c *fiber.Ctx) error {
+		// Secret chats aren't available to signed-in restricted users
+		if restricted, err := rejectRestricted(c); restricted {
+			return err
+		}
+
+		// Parse request body
+		req := new(CreateSecretChatRequest)
+		if len(c.Body()) > 0 {
+			if err := c.BodyParser(req); err != nil {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+			}
+		}
+
+		// Check the requested lifetime and participant limit against the
+		// server's bounds
+		ttl := secretChatConfig.DefaultTTL
+		if req.TTLSeconds != 0 {
+			ttl = time.Duration(req.TTLSeconds) * time.Second
+			if req.TTLSeconds < 0 || ttl < secretChatConfig.MinTTL || ttl > secretChatConfig.MaxTTL {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, fmt.Sprintf("TTL must be between %d and %d seconds",
+					int64(secretChatConfig.MinTTL.Seconds()), int64(secretChatConfig.MaxTTL.Seconds())))
+			}
+		}
+		maxParticipants := secretChatConfig.MaxParticipants
+		if req.MaxParticipants != 0 {
+			if req.MaxParticipants < 2 || (maxParticipants > 0 && req.MaxParticipants > maxParticipants) {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, secretChatParticipantsError())
+			}
+			maxParticipants = req.MaxParticipants
+		}
+
+		// Anonymous creation costs the client a proof-of-work
+		if rejected, err := rejectUnsolvedChallenge(c, req); rejected {
+			return err
+		}
+
 		// Create a new secret chat
-		chat, err := models.CreateSecretChat()
+		chat, err := models.CreateSecretChat(c.UserContext(), ttl, maxParticipants)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to create secret chat",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to create secret chat")
 		}
 
 		// Return the channel ID
-		return c.Status(fiber.StatusCreated).JSON(CreateSecretChatResponse{
-			ChannelID: chat.ChannelID,
-			ExpiresAt: chat.ExpiresAt,
+		return utils.CreatedResponse(c, CreateSecretChatResponse{
+			ChannelID:       chat.ChannelID,
+			ExpiresAt:       chat.ExpiresAt,
+			ExpiresIn:       secondsUntil(chat.ExpiresAt.Time),
+			MaxParticipants: chat.MaxParticipants,
 		})
 	}
 }
@@ -83,50 +138,47 @@ func CreateSecretChat() fiber.Handler {
 // JoinSecretChat handles joining a secret chat
 func JoinSecretChat() fiber.Handler {
 	return func(c *fiber.Ctx) error {
+		// Secret chats aren't available to signed-in restricted users
+		if restricted, err := rejectRestricted(c); restricted {
+			return err
+		}
+
 		// Parse request body
 		req := new(JoinSecretChatRequest)
 		if err := c.BodyParser(req); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid request body",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
 		}
 
 		// Validate request
 		if req.ChannelID == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Channel ID is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Channel ID is required")
 		}
 		if req.DisplayName == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Display name is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Display name is required")
 		}
 
 		// Get chat info
-		chat, err := models.GetSecretChat(req.ChannelID)
+		chat, err := models.GetSecretChat(c.UserContext(), req.ChannelID)
 		if err != nil {
 			if errors.Is(err, models.ErrSecretChatNotFound) {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"error": "Secret chat not found",
-				})
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeSecretChatNotFound, "Secret chat not found")
 			}
 			if errors.Is(err, models.ErrSecretChatExpired) {
-				return c.Status(fiber.StatusGone).JSON(fiber.Map{
-					"error": "Secret chat has expired",
-				})
+				return utils.ErrorResponse(c, fiber.StatusGone, utils.CodeSecretChatExpired, "Secret chat has expired")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to get secret chat",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get secret chat")
 		}
 
 		// Join the chat
-		participant, err := models.JoinSecretChat(req.ChannelID, req.DisplayName)
+		participant, err := models.JoinSecretChat(c.UserContext(), req.ChannelID, req.DisplayName)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to join secret chat",
-			})
+			if errors.Is(err, models.ErrSecretChatFull) {
+				return utils.ErrorResponse(c, fiber.StatusConflict, utils.CodeSecretChatFull, "Secret chat is full")
+			}
+			if errors.Is(err, models.ErrSecretChatExpired) {
+				return utils.ErrorResponse(c, fiber.StatusGone, utils.CodeSecretChatExpired, "Secret chat has expired")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to join secret chat")
 		}
 
 		// Generate WebSocket URL
@@ -137,11 +189,13 @@ func JoinSecretChat() fiber.Handler {
 		wsURL := scheme + "://" + c.Hostname() + "/ws/secret/" + participant.SessionID
 
 		// Return session info
-		return c.Status(fiber.StatusOK).JSON(JoinSecretChatResponse{
-			SessionID:    participant.SessionID,
-			ChannelID:    participant.ChannelID,
-			ExpiresAt:    chat.ExpiresAt,
-			WebSocketURL: wsURL,
+		return utils.OKResponse(c, JoinSecretChatResponse{
+			SessionID:       participant.SessionID,
+			ChannelID:       participant.ChannelID,
+			ExpiresAt:       chat.ExpiresAt,
+			WebSocketURL:    wsURL,
+			ExpiresIn:       secondsUntil(chat.ExpiresAt.Time),
+			MaxParticipants: chat.MaxParticipants,
 		})
 	}
 }
@@ -152,63 +206,48 @@ func SendSecretChatMessage() fiber.Handler {
 		// Parse request body
 		req := new(SecretChatMessageRequest)
 		if err := c.BodyParser(req); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid request body",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
 		}
 
 		// Validate request
 		if req.SessionID == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Session ID is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Session ID is required")
 		}
 		if req.EncryptedContent == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Encrypted content is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Encrypted content is required")
 		}
 
 		// Get participant info
-		participant, err := models.GetParticipant(req.SessionID)
+		participant, err := models.GetParticipant(c.UserContext(), req.SessionID)
 		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Invalid session",
-			})
+			return utils.ErrorResponse(c, fiber.StatusUnauthorized, utils.CodeInvalidSession, "Invalid session")
 		}
 
 		// Check if chat exists and is not expired
-		_, err = models.GetSecretChat(participant.ChannelID)
+		_, err = models.GetSecretChat(c.UserContext(), participant.ChannelID)
 		if err != nil {
 			if errors.Is(err, models.ErrSecretChatNotFound) {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"error": "Secret chat not found",
-				})
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeSecretChatNotFound, "Secret chat not found")
 			}
 			if errors.Is(err, models.ErrSecretChatExpired) {
-				return c.Status(fiber.StatusGone).JSON(fiber.Map{
-					"error": "Secret chat has expired",
-				})
+				return utils.ErrorResponse(c, fiber.StatusGone, utils.CodeSecretChatExpired, "Secret chat has expired")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to get secret chat",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get secret chat")
 		}
 
 		// Decode encrypted content
-		encryptedContent, err := crypto.DecodeBase64(req.EncryptedContent)
+		encryptedContent, err := payloadEncoding(c).Decode(req.EncryptedContent)
 		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid encrypted content",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid encrypted content")
+		}
+		if tooLarge, err := rejectOversizedContent(c, encryptedContent); tooLarge {
+			return err
 		}
 
 		// Generate message ID
 		idBytes := make([]byte, 32)
-		if _, err := rand.Read(idBytes); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to generate message ID",
-			})
+		if err := crypto.ReadRandom(idBytes); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to generate message ID")
 		}
 		messageID := hex.EncodeToString(idBytes)
 
@@ -219,12 +258,10 @@ func SendSecretChatMessage() fiber.Handler {
 			SessionID:        participant.SessionID,
 			DisplayName:      participant.DisplayName,
 			EncryptedContent: encryptedContent,
-			Timestamp:        time.Now(),
+			Timestamp:        types.NewTime(clock.Now()),
 		}
-		if err := models.CreateSecretChatMessage(message); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to create message",
-			})
+		if err := models.CreateSecretChatMessage(c.UserContext(), message); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to create message")
 		}
 
 		// Broadcast message to all participants in the channel
@@ -241,7 +278,7 @@ func SendSecretChatMessage() fiber.Handler {
 		}
 
 		// Return message ID
-		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		return utils.CreatedResponse(c, fiber.Map{
 			"id": messageID,
 		})
 	}
@@ -253,36 +290,28 @@ func GetSecretChatMessages() fiber.Handler {
 		// Get channel ID from URL parameter
 		channelID := c.Params("channel_id")
 		if channelID == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Channel ID is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Channel ID is required")
 		}
 
 		// Get session ID from query parameter
 		sessionID := c.Query("session_id")
 		if sessionID == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Session ID is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Session ID is required")
 		}
 
 		// Get participant info
-		participant, err := models.GetParticipant(sessionID)
+		participant, err := models.GetParticipant(c.UserContext(), sessionID)
 		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Invalid session",
-			})
+			return utils.ErrorResponse(c, fiber.StatusUnauthorized, utils.CodeInvalidSession, "Invalid session")
 		}
 
 		// Check if participant is in the requested channel
 		if participant.ChannelID != channelID {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"error": "Access denied",
-			})
+			return utils.ForbiddenResponse(c)
 		}
 
 		// Update participant's last active timestamp
-		if err := models.UpdateParticipantActivity(sessionID); err != nil {
+		if err := models.UpdateParticipantActivity(c.UserContext(), sessionID); err != nil {
 			// Log error but continue
 			// TODO: Add proper logging
 		}
@@ -298,21 +327,15 @@ func GetSecretChatMessages() fiber.Handler {
 		}
 
 		// Get messages
-		messages, err := models.GetSecretChatMessages(channelID, limit, offset)
+		messages, err := models.GetSecretChatMessages(c.UserContext(), channelID, limit, offset)
 		if err != nil {
 			if errors.Is(err, models.ErrSecretChatNotFound) {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"error": "Secret chat not found",
-				})
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeSecretChatNotFound, "Secret chat not found")
 			}
 			if errors.Is(err, models.ErrSecretChatExpired) {
-				return c.Status(fiber.StatusGone).JSON(fiber.Map{
-					"error": "Secret chat has expired",
-				})
+				return utils.ErrorResponse(c, fiber.StatusGone, utils.CodeSecretChatExpired, "Secret chat has expired")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to get messages",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get messages")
 		}
 
 		// Convert messages to response format
@@ -322,12 +345,12 @@ func GetSecretChatMessages() fiber.Handler {
 				ID:               message.ID,
 				ChannelID:        message.ChannelID,
 				DisplayName:      message.DisplayName,
-				EncryptedContent: crypto.EncodeBase64(message.EncryptedContent),
+				EncryptedContent: payloadEncoding(c).Encode(message.EncryptedContent),
 				Timestamp:        message.Timestamp,
 			}
 		}
 
-		return c.Status(fiber.StatusOK).JSON(response)
+		return utils.OKResponse(c, response)
 	}
 }
 
@@ -337,39 +360,29 @@ func DeleteSecretChat() fiber.Handler {
 		// Get channel ID from URL parameter
 		channelID := c.Params("channel_id")
 		if channelID == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Channel ID is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Channel ID is required")
 		}
 
 		// Get session ID from query parameter
 		sessionID := c.Query("session_id")
 		if sessionID == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Session ID is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Session ID is required")
 		}
 
 		// Get participant info
-		participant, err := models.GetParticipant(sessionID)
+		participant, err := models.GetParticipant(c.UserContext(), sessionID)
 		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Invalid session",
-			})
+			return utils.ErrorResponse(c, fiber.StatusUnauthorized, utils.CodeInvalidSession, "Invalid session")
 		}
 
 		// Check if participant is in the requested channel
 		if participant.ChannelID != channelID {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"error": "Access denied",
-			})
+			return utils.ForbiddenResponse(c)
 		}
 
 		// Delete the chat
-		if err := models.DeleteSecretChat(channelID); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to delete secret chat",
-			})
+		if err := models.DeleteSecretChat(c.UserContext(), channelID); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to delete secret chat")
 		}
 
 		// Notify all participants that the chat has been deleted
@@ -381,7 +394,7 @@ func DeleteSecretChat() fiber.Handler {
 			To: channelID,
 		}
 
-		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		return utils.OKResponse(c, fiber.Map{
 			"success": true,
 		})
 	}
@@ -389,7 +402,7 @@ func DeleteSecretChat() fiber.Handler {
 
 // SecretChatWebSocketHandler handles WebSocket connections for secret chats
 func SecretChatWebSocketHandler() fiber.Handler {
-	return websocket.New(func(c *websocket.Conn) {
+	return rejectWhenDraining(SecretChatPool, websocket.New(func(c *websocket.Conn) {
 		// Get session ID from URL parameter
 		sessionID := c.Params("session_id")
 		if sessionID == "" {
@@ -398,7 +411,7 @@ func SecretChatWebSocketHandler() fiber.Handler {
 		}
 
 		// Get participant info
-		participant, err := models.GetParticipant(sessionID)
+		participant, err := models.GetParticipant(context.Background(), sessionID)
 		if err != nil {
 			c.Close()
 			return
@@ -406,21 +419,39 @@ func SecretChatWebSocketHandler() fiber.Handler {
 
 		// Create a new client
 		client := &ws.Client{
-			ID:      sessionID,
-			Address: participant.ChannelID, // Use channel ID as the address for filtering
-			Conn:    c,
-			Pool:    SecretChatPool,
+			ID:          sessionID,
+			Address:     participant.ChannelID, // Use channel ID as the address for filtering
+			Conn:        c,
+			Pool:        SecretChatPool,
+			ConnectedAt: time.Now(),
 		}
 
 		// Register client
 		SecretChatPool.Register <- client
 
 		// Update participant's last active timestamp
-		models.UpdateParticipantActivity(sessionID)
+		models.UpdateParticipantActivity(context.Background(), sessionID)
 
 		// Start reading messages
 		client.Read()
-	})
+	}))
+}
+
+// secretChatParticipantsError describes the participant limits a creator may ask for
+func secretChatParticipantsError() string {
+	if secretChatConfig.MaxParticipants > 0 {
+		return fmt.Sprintf("Max participants must be between 2 and %d", secretChatConfig.MaxParticipants)
+	}
+	return "Max participants must be at least 2"
+}
+
+// secondsUntil returns the whole seconds left until t, or 0 if it has passed
+func secondsUntil(t time.Time) int64 {
+	remaining := t.Sub(clock.Now())
+	if remaining <= 0 {
+		return 0
+	}
+	return int64(remaining / time.Second)
 }
 
 // CleanupExpiredSecretChats is a background task to clean up expired secret chats
@@ -429,7 +460,7 @@ func CleanupExpiredSecretChats() {
 	defer ticker.Stop()
 
 	for range ticker.C {
-		count, err := models.CleanupExpiredSecretChats()
+		count, err := models.CleanupExpiredSecretChats(context.Background())
 		if err != nil {
 			// TODO: Add proper logging
 			continue
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/config"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/types"
+	"github.com/piko/piko/utils"
+)
+
+// secretChatConfig holds the anonymous secret chat settings
+var secretChatConfig = config.DefaultConfig().SecretChat
+
+// InitSecretChat configures the proof-of-work required to create secret chats
+func InitSecretChat(cfg config.SecretChatConfig) {
+	secretChatConfig = cfg
+}
+
+// SecretChatChallengeResponse represents a proof-of-work challenge for
+// creating a secret chat
+type SecretChatChallengeResponse struct {
+	Nonce      string     `json:"nonce"`
+	Difficulty int        `json:"difficulty"`
+	ExpiresAt  types.Time `json:"expires_at"`
+}
+
+// GetSecretChatChallenge handles issuing a proof-of-work challenge that has
+// to be solved before creating a secret chat
+func GetSecretChatChallenge() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if secretChatConfig.ProofOfWorkDifficulty <= 0 {
+			return utils.OKResponse(c, SecretChatChallengeResponse{})
+		}
+
+		challenge, err := newPowChallenge(c, models.PowPurposeSecretChat, secretChatConfig.ProofOfWorkDifficulty, secretChatConfig.ChallengeExpiry)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to create challenge")
+		}
+
+		return utils.OKResponse(c, SecretChatChallengeResponse{
+			Nonce:      challenge.Nonce,
+			Difficulty: challenge.Difficulty,
+			ExpiresAt:  challenge.ExpiresAt,
+		})
+	}
+}
+
+// rejectUnsolvedChallenge checks the proof-of-work of a secret chat creation
+// request and consumes its challenge, writing a 400 or 403 response when it
+// fails
+func rejectUnsolvedChallenge(c *fiber.Ctx, req *CreateSecretChatRequest) (bool, error) {
+	if secretChatConfig.ProofOfWorkDifficulty <= 0 {
+		return false, nil
+	}
+
+	return rejectUnsolvedPow(c, models.PowPurposeSecretChat, req.Challenge, req.Solution)
+}
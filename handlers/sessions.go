@@ -0,0 +1,359 @@
+package handlers
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/config"
+	"github.com/piko/piko/crypto"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/notifications"
+	"github.com/piko/piko/types"
+	"github.com/piko/piko/utils"
+	"github.com/piko/piko/websocket"
+)
+
+// SessionResponse represents a signed-in device
+type SessionResponse struct {
+	ID         string     `json:"id"`
+	DeviceName string     `json:"device_name"`
+	UserAgent  string     `json:"user_agent"`
+	IPAddress  string     `json:"ip_address"`
+	CreatedAt  types.Time `json:"created_at"`
+	LastSeenAt types.Time `json:"last_seen_at"`
+	Current    bool       `json:"current"`
+}
+
+// SenderDeviceResponse identifies the device a message was sent from. It is
+// only shown to the sender.
+type SenderDeviceResponse struct {
+	SessionID  string `json:"session_id"`
+	DeviceName string `json:"device_name"`
+}
+
+// SecurityLogResponse represents a user's security log
+type SecurityLogResponse struct {
+	Events         []*models.AuditEntry      `json:"events"`
+	DeviceActivity []*models.SessionActivity `json:"device_activity"`
+}
+
+// issueSessionToken starts a new session for the user and returns a token bound to it
+func issueSessionToken(c *fiber.Ctx, cfg *config.Config, user *models.User) (string, string, error) {
+	idBytes := make([]byte, 32)
+	if err := crypto.ReadRandom(idBytes); err != nil {
+		return "", "", err
+	}
+
+	userAgent := c.Get(fiber.HeaderUserAgent)
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+
+	// Clients name themselves, e.g. "Sara's iPhone"; fall back to the user agent
+	deviceName := c.Get("X-Device-Name")
+	if deviceName == "" {
+		deviceName = userAgent
+	}
+	if len(deviceName) > 100 {
+		deviceName = deviceName[:100]
+	}
+
+	session := &models.Session{
+		ID:          hex.EncodeToString(idBytes),
+		UserAddress: user.Address,
+		DeviceName:  deviceName,
+		UserAgent:   userAgent,
+		IPAddress:   c.IP(),
+	}
+	if err := models.CreateSession(c.UserContext(), session); err != nil {
+		return "", "", err
+	}
+
+	token, err := middleware.GenerateJWT(user, session.ID, cfg.Auth.JWTSecret, cfg.Auth.JWTExpirationTime)
+	if err != nil {
+		return "", "", err
+	}
+	return token, session.ID, nil
+}
+
+// GetSessions handles listing the user's active sessions
+func GetSessions() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+		currentSessionID, _ := middleware.GetSessionID(c)
+
+		sessions, err := models.GetUserSessions(c.UserContext(), userAddress)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get sessions")
+		}
+
+		response := make([]SessionResponse, len(sessions))
+		for i, session := range sessions {
+			response[i] = SessionResponse{
+				ID:         session.ID,
+				DeviceName: session.DeviceName,
+				UserAgent:  session.UserAgent,
+				IPAddress:  session.IPAddress,
+				CreatedAt:  session.CreatedAt,
+				LastSeenAt: session.LastSeenAt,
+				Current:    session.ID == currentSessionID,
+			}
+		}
+
+		return utils.OKResponse(c, response)
+	}
+}
+
+// WipeSession handles remotely wiping another of the user's sessions
+func WipeSession() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		// Only session-bound tokens can wipe other sessions
+		currentSessionID, ok := middleware.GetSessionID(c)
+		if !ok {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeReauthenticationRequired, "Sign in again to manage sessions")
+		}
+
+		sessionID := c.Params("id")
+		if sessionID == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Session ID is required")
+		}
+		if sessionID == currentSessionID {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeCurrentSession, "Cannot wipe the current session")
+		}
+
+		session, err := models.GetSessionByID(c.UserContext(), sessionID)
+		if err != nil && !errors.Is(err, models.ErrSessionNotFound) {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get session")
+		}
+		if session == nil || session.UserAddress != userAddress || session.RevokedAt != nil {
+			return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeSessionNotFound, "Session not found")
+		}
+
+		// Revoke first so the device can't keep using its token
+		if err := models.RevokeSession(c.UserContext(), sessionID, models.SessionRevokedRemoteWipe); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to revoke session")
+		}
+
+		if err := models.RecordAudit(c.UserContext(), &models.AuditEntry{
+			ActorAddress: userAddress,
+			Action:       models.AuditActionRemoteWipe,
+			Target:       sessionID,
+			IPAddress:    c.IP(),
+			Details:      fmt.Sprintf("initiated from session %s", currentSessionID),
+		}); err != nil {
+			log.Printf("Error recording audit entry: %v", err)
+		}
+
+		// Tell the device to wipe itself and disconnect it, then forget its
+		// push tokens
+		websocket.NotifyRemoteWipe(WebSocketPool, sessionID)
+		title, body := localized(c, "Signed out"), localized(c, "This device was signed out remotely")
+		go func() {
+			PushNotifier.NotifySession(sessionID, &notifications.Notification{
+				Title: title,
+				Body:  body,
+				Data: map[string]string{
+					"type":       websocket.MessageTypeRemoteWipe,
+					"session_id": sessionID,
+				},
+			})
+			if err := models.DeleteSessionDevices(context.Background(), sessionID); err != nil {
+				log.Printf("Error removing devices of wiped session: %v", err)
+			}
+		}()
+
+		return utils.OKResponse(c, fiber.Map{
+			"message": localized(c, "Session wiped successfully"),
+		})
+	}
+}
+
+// RevokeSession handles signing out one of the user's sessions, which may
+// be the current one. Unlike a remote wipe, the device is not told to
+// delete its data.
+func RevokeSession() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		sessionID := c.Params("id")
+		if sessionID == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Session ID is required")
+		}
+
+		session, err := models.GetSessionByID(c.UserContext(), sessionID)
+		if err != nil && !errors.Is(err, models.ErrSessionNotFound) {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get session")
+		}
+		if session == nil || session.UserAddress != userAddress || session.RevokedAt != nil {
+			return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeSessionNotFound, "Session not found")
+		}
+
+		if err := models.RevokeSession(c.UserContext(), sessionID, models.SessionRevokedSignOut); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to revoke session")
+		}
+
+		recordSessionAudit(c, userAddress, models.AuditActionSessionRevoke, sessionID)
+		websocket.NotifySessionRevoked(WebSocketPool, sessionID)
+		go forgetSessionDevices([]string{sessionID})
+
+		return utils.OKResponse(c, fiber.Map{
+			"message": localized(c, "Session signed out"),
+		})
+	}
+}
+
+// RevokeOtherSessions handles signing out every session of the user but
+// the current one
+func RevokeOtherSessions() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		// Only session-bound tokens know which session to keep
+		currentSessionID, ok := middleware.GetSessionID(c)
+		if !ok {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeReauthenticationRequired, "Sign in again to manage sessions")
+		}
+
+		revoked, err := models.RevokeOtherSessions(c.UserContext(), userAddress, currentSessionID, models.SessionRevokedSignOut)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to revoke sessions")
+		}
+
+		if len(revoked) > 0 {
+			recordSessionAudit(c, userAddress, models.AuditActionSessionRevokeOthers, fmt.Sprintf("%d sessions", len(revoked)))
+			for _, sessionID := range revoked {
+				websocket.NotifySessionRevoked(WebSocketPool, sessionID)
+			}
+			go forgetSessionDevices(revoked)
+		}
+
+		return utils.OKResponse(c, fiber.Map{
+			"message": localized(c, "Other sessions signed out"),
+			"revoked": len(revoked),
+		})
+	}
+}
+
+// recordSessionAudit records a session action in the audit log, noting the
+// session it was taken from
+func recordSessionAudit(c *fiber.Ctx, userAddress, action, target string) {
+	details := ""
+	if sessionID, ok := middleware.GetSessionID(c); ok {
+		details = fmt.Sprintf("initiated from session %s", sessionID)
+	}
+	if err := models.RecordAudit(c.UserContext(), &models.AuditEntry{
+		ActorAddress: userAddress,
+		Action:       action,
+		Target:       target,
+		IPAddress:    c.IP(),
+		Details:      details,
+	}); err != nil {
+		log.Printf("Error recording audit entry: %v", err)
+	}
+}
+
+// forgetSessionDevices removes the push tokens of signed-out sessions, so
+// their devices stop getting notifications
+func forgetSessionDevices(sessionIDs []string) {
+	for _, sessionID := range sessionIDs {
+		if err := models.DeleteSessionDevices(context.Background(), sessionID); err != nil {
+			log.Printf("Error removing devices of revoked session: %v", err)
+		}
+	}
+}
+
+// GetSecurityLog handles retrieving the user's security events and how many
+// messages each of their devices has sent
+func GetSecurityLog() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		events, err := models.GetAuditLogForActor(c.UserContext(), userAddress, 100)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get security log")
+		}
+
+		activity, err := models.GetSessionActivity(c.UserContext(), userAddress)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get device activity")
+		}
+
+		return utils.OKResponse(c, SecurityLogResponse{
+			Events:         events,
+			DeviceActivity: activity,
+		})
+	}
+}
+
+// currentSession returns the session ID of the request, if any
+func currentSession(c *fiber.Ctx) *string {
+	sessionID, ok := middleware.GetSessionID(c)
+	if !ok {
+		return nil
+	}
+	return &sessionID
+}
+
+// loadDeviceNames looks up the device names of the given sessions
+func loadDeviceNames(ctx context.Context, sessionIDs []*string) map[string]string {
+	ids := []string{}
+	for _, id := range sessionIDs {
+		if id != nil {
+			ids = append(ids, *id)
+		}
+	}
+
+	names, err := models.GetSessionDeviceNames(ctx, ids)
+	if err != nil {
+		log.Printf("Error loading device names: %v", err)
+		return map[string]string{}
+	}
+	return names
+}
+
+// senderDeviceFor returns the sender device of a message, only when the
+// viewer is the sender
+func senderDeviceFor(viewerAddress, senderAddress string, sessionID *string, names map[string]string) *SenderDeviceResponse {
+	if senderAddress != viewerAddress {
+		return nil
+	}
+	return senderDevice(sessionID, names)
+}
+
+// senderDevice builds the sender device of a message
+func senderDevice(sessionID *string, names map[string]string) *SenderDeviceResponse {
+	if sessionID == nil {
+		return nil
+	}
+	return &SenderDeviceResponse{
+		SessionID:  *sessionID,
+		DeviceName: names[*sessionID],
+	}
+}
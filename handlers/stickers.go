@@ -0,0 +1,391 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/utils"
+)
+
+// maxStickersPerPack caps the stickers or emoji of a pack
+const maxStickersPerPack = 120
+
+// CreateStickerPackRequest represents a request to create a sticker pack
+type CreateStickerPackRequest struct {
+	Name string `json:"name"`
+	// Kind is stickers, the default, or emoji
+	Kind models.StickerPackKind `json:"kind,omitempty"`
+}
+
+// AddStickerRequest represents a request to add an image to a sticker pack
+type AddStickerRequest struct {
+	// MediaID is an image the creator uploaded through the media endpoints
+	MediaID string `json:"media_id"`
+	// Emoji is the emoji a sticker stands for, or a custom emoji's shortcode
+	Emoji string `json:"emoji,omitempty"`
+}
+
+// StickerResponse represents a sticker with a signed URL of its image
+type StickerResponse struct {
+	*models.Sticker
+	Image MediaResponse `json:"image"`
+}
+
+// StickerPackResponse represents a sticker pack with its stickers
+type StickerPackResponse struct {
+	*models.StickerPack
+	Stickers []StickerResponse `json:"stickers"`
+	// Installed tells whether the user installed the pack
+	Installed bool `json:"installed"`
+}
+
+// stickerResponse converts a sticker to its response
+func stickerResponse(sticker *models.Sticker) StickerResponse {
+	return StickerResponse{Sticker: sticker, Image: mediaResponse(sticker.Media)}
+}
+
+// stickerPackResponse loads the stickers of a pack into its response
+func stickerPackResponse(ctx context.Context, pack *models.StickerPack, installed bool) (*StickerPackResponse, error) {
+	stickers, err := models.GetStickers(ctx, pack.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &StickerPackResponse{
+		StickerPack: pack,
+		Stickers:    make([]StickerResponse, len(stickers)),
+		Installed:   installed,
+	}
+	for i, sticker := range stickers {
+		response.Stickers[i] = stickerResponse(sticker)
+	}
+	return response, nil
+}
+
+// getStickerPack loads the sticker pack in the id parameter, writing a 404
+// response if there is none
+func getStickerPack(c *fiber.Ctx) (*models.StickerPack, bool, error) {
+	pack, err := models.GetStickerPack(c.UserContext(), c.Params("id"))
+	if err != nil {
+		if errors.Is(err, models.ErrStickerPackNotFound) {
+			return nil, true, utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeStickerPackNotFound, "Sticker pack not found")
+		}
+		return nil, true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get sticker pack")
+	}
+	return pack, false, nil
+}
+
+// getOwnStickerPack loads the sticker pack in the id parameter, writing an
+// error response unless the user created it
+func getOwnStickerPack(c *fiber.Ctx, userAddress string) (*models.StickerPack, bool, error) {
+	pack, rejected, err := getStickerPack(c)
+	if rejected {
+		return nil, true, err
+	}
+	if pack.CreatorAddress != userAddress {
+		return nil, true, utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeForbidden, "Only the pack's creator can change it")
+	}
+	return pack, false, nil
+}
+
+// writeStickerPack writes a sticker pack with its stickers
+func writeStickerPack(c *fiber.Ctx, status int, pack *models.StickerPack, userAddress string) error {
+	installed, err := models.IsStickerPackInstalled(c.UserContext(), userAddress, pack.ID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get sticker pack")
+	}
+	response, err := stickerPackResponse(c.UserContext(), pack, installed)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get stickers")
+	}
+	return utils.SuccessResponse(c, status, response)
+}
+
+// rejectUnknownSticker writes a 400 response if a message names a sticker
+// that doesn't exist
+func rejectUnknownSticker(c *fiber.Ctx, stickerID string) (bool, error) {
+	if stickerID == "" {
+		return false, nil
+	}
+	if _, err := models.GetSticker(c.UserContext(), stickerID); err != nil {
+		if errors.Is(err, models.ErrStickerNotFound) {
+			return true, utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid sticker_id")
+		}
+		return true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to verify sticker")
+	}
+	return false, nil
+}
+
+// CreateStickerPack handles creating an empty sticker or custom emoji pack,
+// installed for its creator
+func CreateStickerPack() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		req := new(CreateStickerPackRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+		req.Name = strings.TrimSpace(req.Name)
+		if req.Name == "" || utf8.RuneCountInString(req.Name) > 64 {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Name is required and must be at most 64 characters")
+		}
+		if req.Kind == "" {
+			req.Kind = models.StickerPackStickers
+		}
+		if !models.IsValidStickerPackKind(req.Kind) {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Kind must be stickers or emoji")
+		}
+
+		packID, err := utils.NewID()
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to generate sticker pack ID")
+		}
+
+		pack := &models.StickerPack{
+			ID:             packID,
+			Name:           req.Name,
+			Kind:           req.Kind,
+			CreatorAddress: userAddress,
+		}
+		if err := models.CreateStickerPack(c.UserContext(), pack); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to create sticker pack")
+		}
+
+		return utils.CreatedResponse(c, StickerPackResponse{
+			StickerPack: pack,
+			Stickers:    []StickerResponse{},
+			Installed:   true,
+		})
+	}
+}
+
+// GetStickerPacks handles listing the sticker packs the user installed
+func GetStickerPacks() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		packs, err := models.GetInstalledStickerPacks(c.UserContext(), userAddress)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get sticker packs")
+		}
+
+		responses := make([]*StickerPackResponse, len(packs))
+		for i, pack := range packs {
+			if responses[i], err = stickerPackResponse(c.UserContext(), pack, true); err != nil {
+				return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get stickers")
+			}
+		}
+		return utils.OKResponse(c, responses)
+	}
+}
+
+// GetStickerPack handles retrieving a sticker pack with its stickers. Any
+// user who knows its ID can see it.
+func GetStickerPack() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		pack, rejected, err := getStickerPack(c)
+		if rejected {
+			return err
+		}
+
+		return writeStickerPack(c, fiber.StatusOK, pack, userAddress)
+	}
+}
+
+// DeleteStickerPack handles the creator deleting a sticker pack, which
+// uninstalls it for everyone
+func DeleteStickerPack() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		pack, rejected, err := getOwnStickerPack(c, userAddress)
+		if rejected {
+			return err
+		}
+
+		if err := models.DeleteStickerPack(c.UserContext(), pack.ID); err != nil && !errors.Is(err, models.ErrStickerPackNotFound) {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to delete sticker pack")
+		}
+
+		return utils.OKResponse(c, fiber.Map{
+			"message": localized(c, "Sticker pack deleted"),
+		})
+	}
+}
+
+// AddSticker handles the creator adding an image they uploaded to a
+// sticker pack
+func AddSticker() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		pack, rejected, err := getOwnStickerPack(c, userAddress)
+		if rejected {
+			return err
+		}
+
+		req := new(AddStickerRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+		req.Emoji = strings.TrimSpace(req.Emoji)
+		if pack.Kind == models.StickerPackEmoji {
+			if !models.IsValidShortcode(req.Emoji) {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Custom emoji need a shortcode of 2 to 32 lowercase letters, digits and underscores")
+			}
+		} else if utf8.RuneCountInString(req.Emoji) > 32 {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Emoji must be at most 32 characters")
+		}
+
+		// Stickers are images the creator uploaded through the media pipeline
+		media, err := models.GetMediaByID(c.UserContext(), req.MediaID)
+		if err != nil && !errors.Is(err, models.ErrMediaNotFound) {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get media")
+		}
+		if media == nil || media.OwnerAddress != userAddress {
+			return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeMediaNotFound, "Media not found")
+		}
+		if !strings.HasPrefix(media.MimeType, "image/") {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeFileTypeNotAllowed, "Stickers must be images")
+		}
+
+		stickerID, err := utils.NewID()
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to generate sticker ID")
+		}
+
+		sticker := &models.Sticker{
+			ID:      stickerID,
+			PackID:  pack.ID,
+			MediaID: media.ID,
+			Emoji:   req.Emoji,
+			Media:   media,
+		}
+		if err := models.AddSticker(c.UserContext(), sticker, maxStickersPerPack); err != nil {
+			switch {
+			case errors.Is(err, models.ErrStickerPackNotFound):
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeStickerPackNotFound, "Sticker pack not found")
+			case errors.Is(err, models.ErrStickerPackFull):
+				return utils.ErrorResponse(c, fiber.StatusConflict, utils.CodeStickerPackFull, "This sticker pack is full")
+			case errors.Is(err, models.ErrShortcodeTaken):
+				return utils.ErrorResponse(c, fiber.StatusConflict, utils.CodeShortcodeTaken, "This pack already has an emoji with that shortcode")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to add sticker")
+		}
+
+		return utils.CreatedResponse(c, stickerResponse(sticker))
+	}
+}
+
+// DeleteSticker handles the creator removing a sticker from a pack
+func DeleteSticker() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		pack, rejected, err := getOwnStickerPack(c, userAddress)
+		if rejected {
+			return err
+		}
+
+		if err := models.DeleteSticker(c.UserContext(), pack.ID, c.Params("sticker_id")); err != nil {
+			if errors.Is(err, models.ErrStickerNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeStickerNotFound, "Sticker not found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to delete sticker")
+		}
+
+		return writeStickerPack(c, fiber.StatusOK, pack, userAddress)
+	}
+}
+
+// InstallStickerPack handles adding a sticker pack to the user's packs
+func InstallStickerPack() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		pack, rejected, err := getStickerPack(c)
+		if rejected {
+			return err
+		}
+
+		if err := models.InstallStickerPack(c.UserContext(), userAddress, pack.ID); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to install sticker pack")
+		}
+
+		return writeStickerPack(c, fiber.StatusOK, pack, userAddress)
+	}
+}
+
+// UninstallStickerPack handles removing a sticker pack from the user's packs
+func UninstallStickerPack() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		pack, rejected, err := getStickerPack(c)
+		if rejected {
+			return err
+		}
+
+		if err := models.UninstallStickerPack(c.UserContext(), userAddress, pack.ID); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to uninstall sticker pack")
+		}
+
+		return writeStickerPack(c, fiber.StatusOK, pack, userAddress)
+	}
+}
+
+// GetSticker handles retrieving a sticker, so clients can show the
+// stickers of messages they received
+func GetSticker() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		sticker, err := models.GetSticker(c.UserContext(), c.Params("id"))
+		if err != nil {
+			if errors.Is(err, models.ErrStickerNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeStickerNotFound, "Sticker not found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get sticker")
+		}
+
+		return utils.OKResponse(c, stickerResponse(sticker))
+	}
+}
@@ -0,0 +1,12 @@
+package handlers
+
+import "github.com/piko/piko/store"
+
+// stores are the user, message, legal hold and contact stores handlers read
+// and write through
+var stores = store.SQL()
+
+// InitStores sets the stores handlers use. Unit tests pass in-memory stores.
+func InitStores(s store.Stores) {
+	stores = s
+}
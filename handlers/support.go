@@ -0,0 +1,376 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/config"
+	"github.com/piko/piko/crypto"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/notifications"
+	"github.com/piko/piko/utils"
+	"github.com/piko/piko/websocket"
+)
+
+const (
+	// supportBotUsername is the username of the support bot account
+	supportBotUsername = "support"
+
+	// maxSupportMessageLength caps ticket messages and admin replies
+	maxSupportMessageLength = 4000
+)
+
+// supportBotAddress is the address of the account support replies come from
+var supportBotAddress string
+
+// InitSupport creates the support bot account if it doesn't exist yet. The
+// bot's private key is thrown away, so nobody can sign in as it.
+func InitSupport(cryptoCfg config.CryptoConfig) error {
+	ctx := context.Background()
+
+	bot, err := stores.Users.GetByPhone(ctx, models.SupportBotPhone)
+	if err == nil {
+		supportBotAddress = bot.Address
+		return nil
+	}
+	if !errors.Is(err, models.ErrUserNotFound) {
+		return err
+	}
+
+	keyPair, err := crypto.GenerateKeyPair()
+	if err != nil {
+		return err
+	}
+	address, err := crypto.GenerateAddress(keyPair.PublicKey, cryptoCfg.AddressLength)
+	if err != nil {
+		return err
+	}
+	randomBytes, err := crypto.GenerateRandomBytes(16)
+	if err != nil {
+		return err
+	}
+
+	bot = &models.User{
+		Phone:        models.SupportBotPhone,
+		PasswordHash: base64.StdEncoding.EncodeToString(randomBytes),
+		PublicKey:    keyPair.PublicKey,
+		Address:      address,
+		Role:         models.UserRoleBot,
+	}
+	if err := models.CreateUser(ctx, bot); err != nil {
+		return err
+	}
+	if err := stores.Users.SetUsername(ctx, bot.ID, supportBotUsername); err != nil && !errors.Is(err, models.ErrUsernameAlreadyExists) {
+		return err
+	}
+
+	supportBotAddress = bot.Address
+	return nil
+}
+
+// CreateSupportTicketRequest represents a request to contact support
+type CreateSupportTicketRequest struct {
+	Category    models.TicketCategory `json:"category"`
+	Message     string                `json:"message"`
+	LogsMediaID string                `json:"logs_media_id,omitempty"`
+}
+
+// CreateSupportTicketResponse represents a new ticket and the account its
+// replies will come from
+type CreateSupportTicketResponse struct {
+	Ticket            *models.SupportTicket `json:"ticket"`
+	SupportBotAddress string                `json:"support_bot_address"`
+}
+
+// SupportTicketReplyRequest represents an admin's reply to a ticket
+type SupportTicketReplyRequest struct {
+	Message string `json:"message"`
+}
+
+// SupportTicketResponse represents a ticket with its replies and logs
+type SupportTicketResponse struct {
+	*models.SupportTicket
+	Logs    *MediaResponse               `json:"logs,omitempty"`
+	Replies []*models.SupportTicketReply `json:"replies"`
+}
+
+// validSupportMessage trims a ticket message or reply and reports whether it
+// is non-empty and within the length limit
+func validSupportMessage(message *string) bool {
+	*message = strings.TrimSpace(*message)
+	return *message != "" && utf8.RuneCountInString(*message) <= maxSupportMessageLength
+}
+
+// sendSupportMessage posts a message from the support bot to a user's
+// direct conversation with it. The bot holds no keys the user trusts, so
+// these messages carry plain UTF-8 text rather than ciphertext.
+func sendSupportMessage(ctx context.Context, userAddress, text string) error {
+	messageID, err := utils.NewID()
+	if err != nil {
+		return err
+	}
+
+	message := &models.Message{
+		ID:               messageID,
+		SenderAddress:    supportBotAddress,
+		RecipientAddress: userAddress,
+		EncryptedContent: []byte(text),
+		Status:           models.MessageStatusPending,
+	}
+	if err := models.CreateMessage(ctx, message); err != nil {
+		return err
+	}
+
+	go websocket.NotifyNewMessage(WebSocketPool, message)
+	go pushUnlessMuted(userAddress, models.ConversationDirect, message.SenderAddress, &notifications.Notification{
+		Title: "Support",
+		Body:  "You have a new message from support",
+		Data: map[string]string{
+			"type":           "new_message",
+			"id":             message.ID,
+			"sender_address": message.SenderAddress,
+		},
+	})
+	return nil
+}
+
+// supportTicketResponse loads the replies and logs of a ticket
+func supportTicketResponse(ctx context.Context, ticket *models.SupportTicket) (*SupportTicketResponse, error) {
+	replies, err := models.GetSupportTicketReplies(ctx, ticket.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &SupportTicketResponse{SupportTicket: ticket, Replies: replies}
+	if ticket.LogsMediaID != nil {
+		media, err := models.GetMediaByID(ctx, *ticket.LogsMediaID)
+		if err != nil && !errors.Is(err, models.ErrMediaNotFound) {
+			return nil, err
+		}
+		if media != nil {
+			logs := mediaResponse(media)
+			response.Logs = &logs
+		}
+	}
+	return response, nil
+}
+
+// supportTicketID parses the ticket ID route parameter
+func supportTicketID(c *fiber.Ctx) (int, bool) {
+	id, err := strconv.Atoi(c.Params("id"))
+	return id, err == nil && id > 0
+}
+
+// CreateSupportTicket handles opening a support ticket. The support bot
+// acknowledges it in the user's direct conversation with the bot, where
+// admin replies are posted too.
+func CreateSupportTicket() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		// Parse request body
+		req := new(CreateSupportTicketRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+
+		// Validate request
+		if !models.IsValidTicketCategory(req.Category) {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Category must be bug, feedback, account or other")
+		}
+		if !validSupportMessage(&req.Message) {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, fmt.Sprintf("Message is required and must be at most %d characters", maxSupportMessageLength))
+		}
+
+		// Logs must be media uploaded by the user
+		var logsMediaID *string
+		if req.LogsMediaID != "" {
+			if err := models.ValidateAttachments(c.UserContext(), userAddress, []string{req.LogsMediaID}); err != nil {
+				if errors.Is(err, models.ErrInvalidAttachment) {
+					return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid logs_media_id")
+				}
+				return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to verify logs")
+			}
+			logsMediaID = &req.LogsMediaID
+		}
+
+		ticket := &models.SupportTicket{
+			UserAddress: userAddress,
+			Category:    req.Category,
+			Message:     req.Message,
+			LogsMediaID: logsMediaID,
+		}
+		if err := models.CreateSupportTicket(c.UserContext(), ticket); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to create support ticket")
+		}
+
+		acknowledgement := fmt.Sprintf("Thanks for contacting support. We received ticket #%d and will reply here.", ticket.ID)
+		if err := sendSupportMessage(c.UserContext(), userAddress, acknowledgement); err != nil {
+			log.Printf("Error sending support acknowledgement for ticket %d: %v", ticket.ID, err)
+		}
+
+		return utils.CreatedResponse(c, CreateSupportTicketResponse{
+			Ticket:            ticket,
+			SupportBotAddress: supportBotAddress,
+		})
+	}
+}
+
+// GetSupportTickets handles listing the tickets the user opened
+func GetSupportTickets() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		tickets, err := models.GetUserSupportTickets(c.UserContext(), userAddress)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get support tickets")
+		}
+
+		return utils.OKResponse(c, tickets)
+	}
+}
+
+// GetAdminSupportTickets handles listing the support queue
+func GetAdminSupportTickets() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		status := models.TicketStatus(c.Query("status", string(models.TicketStatusOpen)))
+		if status != models.TicketStatusOpen && status != models.TicketStatusClosed {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid ticket status")
+		}
+		pagination := utils.GetPaginationParams(c)
+
+		tickets, err := models.GetSupportTickets(c.UserContext(), status, pagination.Limit, pagination.CalculateOffset())
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get support tickets")
+		}
+
+		return utils.OKResponse(c, tickets)
+	}
+}
+
+// GetAdminSupportTicket handles retrieving a ticket with its replies and a
+// download URL for its logs
+func GetAdminSupportTicket() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, ok := supportTicketID(c)
+		if !ok {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid ticket ID")
+		}
+
+		ticket, err := models.GetSupportTicket(c.UserContext(), id)
+		if err != nil {
+			if errors.Is(err, models.ErrTicketNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeTicketNotFound, "Support ticket not found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get support ticket")
+		}
+
+		response, err := supportTicketResponse(c.UserContext(), ticket)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get support ticket")
+		}
+
+		return utils.OKResponse(c, response)
+	}
+}
+
+// ReplySupportTicket handles an admin replying to an open ticket. The reply
+// is posted by the support bot in the user's conversation with it.
+func ReplySupportTicket() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		adminAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		id, ok := supportTicketID(c)
+		if !ok {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid ticket ID")
+		}
+
+		req := new(SupportTicketReplyRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
+		}
+		if !validSupportMessage(&req.Message) {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, fmt.Sprintf("Message is required and must be at most %d characters", maxSupportMessageLength))
+		}
+
+		reply := &models.SupportTicketReply{
+			TicketID:     id,
+			AdminAddress: adminAddress,
+			Message:      req.Message,
+		}
+		if err := models.AddSupportTicketReply(c.UserContext(), reply); err != nil {
+			if errors.Is(err, models.ErrTicketNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeTicketNotFound, "Support ticket not found")
+			}
+			if errors.Is(err, models.ErrTicketClosed) {
+				return utils.ErrorResponse(c, fiber.StatusConflict, utils.CodeTicketClosed, "Support ticket is closed")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to reply to support ticket")
+		}
+
+		ticket, err := models.GetSupportTicket(c.UserContext(), id)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get support ticket")
+		}
+		if err := sendSupportMessage(c.UserContext(), ticket.UserAddress, req.Message); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to send support reply")
+		}
+
+		return utils.CreatedResponse(c, reply)
+	}
+}
+
+// CloseSupportTicket handles an admin closing a ticket
+func CloseSupportTicket() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		adminAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		id, ok := supportTicketID(c)
+		if !ok {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid ticket ID")
+		}
+
+		if err := models.CloseSupportTicket(c.UserContext(), id, adminAddress); err != nil {
+			if errors.Is(err, models.ErrTicketNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeTicketNotFound, "Support ticket not found")
+			}
+			if errors.Is(err, models.ErrTicketClosed) {
+				return utils.ErrorResponse(c, fiber.StatusConflict, utils.CodeTicketClosed, "Support ticket is already closed")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to close support ticket")
+		}
+
+		ticket, err := models.GetSupportTicket(c.UserContext(), id)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get support ticket")
+		}
+		closing := fmt.Sprintf("Ticket #%d has been closed. Open a new ticket if you need more help.", ticket.ID)
+		if err := sendSupportMessage(c.UserContext(), ticket.UserAddress, closing); err != nil {
+			log.Printf("Error sending support closing message for ticket %d: %v", ticket.ID, err)
+		}
+
+		return utils.OKResponse(c, ticket)
+	}
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/config"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/utils"
+)
+
+// testPhones holds the load testing phone prefix and its fixed OTP
+var testPhones config.AuthConfig
+
+// InitTestPhones reserves the configured phone prefix for load testing
+func InitTestPhones(cfg config.AuthConfig) {
+	testPhones = cfg
+}
+
+// isTestPhone checks if a phone number is reserved for load testing
+func isTestPhone(phone string) bool {
+	return testPhones.TestPhonePrefix != "" && testPhones.TestPhoneCode != "" &&
+		strings.HasPrefix(phone, testPhones.TestPhonePrefix)
+}
+
+// newOTP creates the OTP for a phone number: a random code, or the fixed
+// test code for test phones. It is sent to email if given, otherwise by SMS.
+func newOTP(c *fiber.Ctx, cfg *config.Config, phone, email string) (*models.OTP, error) {
+	code, err := otpCode(cfg, phone)
+	if err != nil {
+		return nil, err
+	}
+	return models.CreateOTP(c.UserContext(), phone, email, code, cfg.Auth.OTPExpiryMinutes)
+}
+
+// otpCode returns a random OTP code, or the fixed test code for test phones
+func otpCode(cfg *config.Config, phone string) (string, error) {
+	if isTestPhone(phone) {
+		return testPhones.TestPhoneCode, nil
+	}
+	return utils.GenerateOTP(cfg.Auth.OTPLength, cfg.Auth.OTPCharset)
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/types"
+	"github.com/piko/piko/utils"
+)
+
+// Usage report periods, in days
+const (
+	defaultUsageDays = 7
+	maxUsageDays     = 90
+)
+
+// UsageResponse represents a user's recent activity
+type UsageResponse struct {
+	Since          types.Time                  `json:"since"`
+	Days           int                         `json:"days"`
+	MessagesPerDay []*models.DailyMessageCount `json:"messages_per_day"`
+	MessagesSent   int                         `json:"messages_sent"`
+	// Devices lists every session with the messages it has ever sent
+	Devices     []*models.SessionActivity `json:"devices"`
+	PushDevices int                       `json:"push_devices"`
+	Storage     StorageUsageResponse      `json:"storage"`
+	// Actions counts the user's audit log entries in the period, by action
+	Actions map[string]int `json:"actions"`
+}
+
+// StorageUsageResponse represents the media a user stores
+type StorageUsageResponse struct {
+	Used int64 `json:"used"`
+	// Limit is 0 when storage isn't limited
+	Limit int64 `json:"limit"`
+}
+
+// GetUsage handles showing users their own recent activity, so they can
+// see what their clients have been doing
+func GetUsage() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Get user address from context
+		userAddress, ok := middleware.GetUserAddress(c)
+		if !ok {
+			return utils.UnauthorizedResponse(c)
+		}
+
+		days := defaultUsageDays
+		if c.Query("days") != "" {
+			var err error
+			days, err = strconv.Atoi(c.Query("days"))
+			if err != nil || days <= 0 || days > maxUsageDays {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Days must be between 1 and 90")
+			}
+		}
+
+		// The period starts at midnight UTC, days-1 days before today
+		now := clock.Now().UTC()
+		since := now.Truncate(24*time.Hour).AddDate(0, 0, 1-days)
+
+		perDay, err := models.GetDailyMessageCounts(c.UserContext(), userAddress, since, now)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get usage")
+		}
+		sent := 0
+		for _, day := range perDay {
+			sent += day.Total
+		}
+
+		devices, err := models.GetSessionActivity(c.UserContext(), userAddress)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get device activity")
+		}
+
+		pushDevices, err := models.GetUserDevices(c.UserContext(), userAddress)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get usage")
+		}
+
+		used, err := models.GetStorageUsed(c.UserContext(), userAddress)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get usage")
+		}
+		limit := max(quotaConfig.StoragePerUser, 0)
+		middleware.ReportQuota(c, "storage", limit, used)
+
+		actions, err := models.GetAuditActionCounts(c.UserContext(), userAddress, since)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get usage")
+		}
+
+		return utils.OKResponse(c, UsageResponse{
+			Since:          types.NewTime(since),
+			Days:           days,
+			MessagesPerDay: perDay,
+			MessagesSent:   sent,
+			Devices:        devices,
+			PushDevices:    len(pushDevices),
+			Storage:        StorageUsageResponse{Used: used, Limit: limit},
+			Actions:        actions,
+		})
+	}
+}
@@ -1,11 +1,15 @@
 package handlers
 
 import (
+	"context"
 	"errors"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/piko/piko/middleware"
 	"github.com/piko/piko/models"
+	"github.com/piko/piko/privacy"
+	"github.com/piko/piko/types"
+	"github.com/piko/piko/utils"
 )
 
 // SearchUsersRequest represents a request to search for users
@@ -18,6 +22,22 @@ type UserResponse struct {
 	Address  string `json:"address"`
 	Username string `json:"username,omitempty"`
 	Phone    string `json:"phone,omitempty"`
+	// Alias and Labels are what the requester calls the user in their contacts
+	Alias  string   `json:"alias,omitempty"`
+	Labels []string `json:"labels,omitempty"`
+	// LastSeenAt and Avatar are only set when getting a single user whose
+	// privacy settings let the requester see them
+	LastSeenAt *types.Time     `json:"last_seen_at,omitempty"`
+	Avatar     *AvatarResponse `json:"avatar,omitempty"`
+}
+
+// withContactName adds what the requester calls the user to a response
+func (r UserResponse) withContactName(names map[string]*models.ContactName) UserResponse {
+	if name, ok := names[r.Address]; ok {
+		r.Alias = name.Alias
+		r.Labels = name.Labels
+	}
+	return r
 }
 
 // SetUsernameRequest represents a request to set or update a username
@@ -28,83 +48,125 @@ type SetUsernameRequest struct {
 // SearchUsers handles searching for users by address or other identifiers
 func SearchUsers() fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		// Get user ID from context to ensure the requester is authenticated
-		_, ok := middleware.GetUserID(c)
+		// Get user address from context to ensure the requester is authenticated
+		userAddress, ok := middleware.GetUserAddress(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
+		}
+
+		// Restricted users can't look other users up
+		if restricted, err := rejectRestricted(c); restricted {
+			return err
 		}
 
 		// Get query from request
 		query := c.Query("query")
 		if query == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Search query is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Search query is required")
 		}
 
 		// Search for users by address, phone, or username
-		users, err := models.SearchUsers(query)
+		users, err := stores.Users.Search(c.UserContext(), query)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to search users",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to search users")
 		}
 
-		// Convert users to response format
-		response := make([]UserResponse, len(users))
+		addresses := make([]string, len(users))
 		for i, user := range users {
-			response[i] = UserResponse{
+			addresses[i] = user.Address
+		}
+		names := contactNames(c, userAddress, addresses)
+
+		// Convert users to response format, leaving out restricted users
+		response := make([]UserResponse, 0, len(users))
+		for _, user := range users {
+			if isRestricted(user) {
+				continue
+			}
+			response = append(response, UserResponse{
 				Address:  user.Address,
 				Username: user.Username,
 				Phone:    maskPhone(user.Phone),
-			}
+			}.withContactName(names))
 		}
 
-		return c.Status(fiber.StatusOK).JSON(response)
+		return utils.OKResponse(c, response)
 	}
 }
 
 // GetUser handles retrieving a user by their address
 func GetUser() fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		// Get user ID from context to ensure the requester is authenticated
-		_, ok := middleware.GetUserID(c)
+		// Get user address from context to ensure the requester is authenticated
+		userAddress, ok := middleware.GetUserAddress(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
 		}
 
 		// Get address from URL parameter
 		address := c.Params("address")
 		if address == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Address is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Address is required")
 		}
 
 		// Get user by address
-		user, err := models.GetUserByAddress(address)
+		user, err := stores.Users.GetByAddress(c.UserContext(), address)
 		if err != nil {
 			if errors.Is(err, models.ErrUserNotFound) {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"error": "User not found",
-				})
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeUserNotFound, "User not found")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to get user",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get user")
 		}
 
 		// Return user with masked sensitive information
-		return c.Status(fiber.StatusOK).JSON(UserResponse{
+		response := UserResponse{
 			Address:  user.Address,
 			Username: user.Username,
 			Phone:    maskPhone(user.Phone),
-		})
+		}.withContactName(contactNames(c, userAddress, []string{user.Address}))
+
+		// Add what the user's privacy settings let the requester see
+		policy, err := privacy.For(c.UserContext(), user)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get user")
+		}
+		if response.LastSeenAt, err = visibleLastSeen(c.UserContext(), policy, userAddress); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get user")
+		}
+		if response.Avatar, err = visibleAvatar(c.UserContext(), policy, user, userAddress); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get user")
+		}
+
+		return utils.OKResponse(c, response)
+	}
+}
+
+// visibleLastSeen returns when a user was last seen, or nil if the viewer
+// isn't allowed to know
+func visibleLastSeen(ctx context.Context, policy *privacy.Policy, viewerAddress string) (*types.Time, error) {
+	allowed, err := policy.Allows(ctx, privacy.LastSeen, viewerAddress)
+	if err != nil || !allowed {
+		return nil, err
+	}
+	return models.GetLastSeen(ctx, policy.OwnerAddress)
+}
+
+// visibleAvatar returns a user's active avatar, or nil if they have none or
+// the viewer isn't allowed to see it
+func visibleAvatar(ctx context.Context, policy *privacy.Policy, user *models.User, viewerAddress string) (*AvatarResponse, error) {
+	allowed, err := policy.Allows(ctx, privacy.ProfilePhoto, viewerAddress)
+	if err != nil || !allowed {
+		return nil, err
+	}
+	avatar, err := models.GetActiveAvatarForUser(ctx, user.ID)
+	if errors.Is(err, models.ErrAvatarNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
 	}
+	response := avatarResponse(avatar, viewerAddress)
+	return &response, nil
 }
 
 // SetUsername handles setting or updating a user's username
@@ -113,46 +175,34 @@ func SetUsername() fiber.Handler {
 		// Get user ID from context to ensure the requester is authenticated
 		userID, ok := middleware.GetUserID(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
 		}
 
 		// Parse request body
 		req := new(SetUsernameRequest)
 		if err := c.BodyParser(req); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid request body",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
 		}
 
 		// Validate username
 		if req.Username == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Username is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Username is required")
 		}
 
 		// Set username
-		err := models.SetUsername(userID, req.Username)
+		err := stores.Users.SetUsername(c.UserContext(), userID, req.Username)
 		if err != nil {
 			if errors.Is(err, models.ErrInvalidUsername) {
-				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"error": "Invalid username format. Username must be 3-30 characters long and contain only alphanumeric characters and underscores.",
-				})
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid username format. Username must be 3-30 characters long and contain only alphanumeric characters and underscores.")
 			}
 			if errors.Is(err, models.ErrUsernameAlreadyExists) {
-				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
-					"error": "Username already exists",
-				})
+				return utils.ErrorResponse(c, fiber.StatusConflict, utils.CodeUsernameTaken, "Username already exists")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to set username",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to set username")
 		}
 
-		return c.Status(fiber.StatusOK).JSON(fiber.Map{
-			"message":  "Username set successfully",
+		return utils.OKResponse(c, fiber.Map{
+			"message":  localized(c, "Username set successfully"),
 			"username": req.Username,
 		})
 	}
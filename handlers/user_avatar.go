@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -12,6 +13,8 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/piko/piko/middleware"
 	"github.com/piko/piko/models"
+	"github.com/piko/piko/storage"
+	"github.com/piko/piko/utils"
 )
 
 // UploadAvatar handles uploading a user avatar
@@ -20,51 +23,44 @@ func UploadAvatar() fiber.Handler {
 		// Get user ID from context
 		userID, ok := middleware.GetUserID(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
 		}
+		userAddress, _ := middleware.GetUserAddress(c)
 
 		// Get file from request
 		file, err := c.FormFile("avatar")
 		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "No avatar file provided",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "No avatar file provided")
 		}
 
 		// Validate file size (max 5MB)
 		if file.Size > 5*1024*1024 {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Avatar file too large (max 5MB)",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeFileTooLarge, "Avatar file too large (max 5MB)")
 		}
 
-		// Validate file type
+		// Validate file type. SVG is refused because it can carry scripts.
 		contentType := file.Header.Get("Content-Type")
-		if !strings.HasPrefix(contentType, "image/") {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid file type. Only images are allowed",
-			})
+		if !isPhotoType(contentType) {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeFileTypeNotAllowed, "Invalid file type. Only images are allowed")
 		}
 
-		// Create uploads directory if it doesn't exist
-		uploadsDir := "./uploads/avatars"
-		if err := os.MkdirAll(uploadsDir, 0755); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to create uploads directory",
-			})
+		// Store the file with uploaded media, under a random key so names
+		// can't collide or point outside the user's prefix
+		objectID, err := randomHexID()
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to save avatar")
 		}
-
-		// Generate a unique filename
 		filename := fmt.Sprintf("%d_%s", userID, filepath.Base(file.Filename))
-		filepath := filepath.Join(uploadsDir, filename)
+		storageKey := fmt.Sprintf("avatars/%d/%s", userID, objectID)
 
-		// Save the file
-		if err := c.SaveFile(file, filepath); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to save avatar",
-			})
+		src, err := file.Open()
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to read file")
+		}
+		defer src.Close()
+		if err := MediaStore.Put(storageKey, src, file.Size, contentType); err != nil {
+			log.Printf("Error storing avatar: %v", err)
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to save avatar")
 		}
 
 		// TODO: Process image (resize, compress, etc.)
@@ -75,7 +71,7 @@ func UploadAvatar() fiber.Handler {
 		// Create avatar record in database
 		avatar := &models.UserAvatar{
 			UserID:   userID,
-			FilePath: filepath,
+			FilePath: storageKey,
 			FileName: filename,
 			FileSize: int(file.Size),
 			MimeType: contentType,
@@ -84,15 +80,13 @@ func UploadAvatar() fiber.Handler {
 			IsActive: true, // Set as active by default
 		}
 
-		if err := models.CreateAvatar(avatar); err != nil {
+		if err := models.CreateAvatar(c.UserContext(), avatar); err != nil {
 			// Delete the file if database insertion fails
-			os.Remove(filepath)
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to save avatar information",
-			})
+			MediaStore.Delete(storageKey)
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to save avatar information")
 		}
 
-		return c.Status(fiber.StatusCreated).JSON(avatar)
+		return utils.CreatedResponse(c, avatarResponse(avatar, userAddress))
 	}
 }
 
@@ -102,20 +96,17 @@ func GetUserAvatars() fiber.Handler {
 		// Get user ID from context
 		userID, ok := middleware.GetUserID(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
 		}
+		userAddress, _ := middleware.GetUserAddress(c)
 
 		// Get avatars
-		avatars, err := models.GetAllAvatarsForUser(userID)
+		avatars, err := models.GetAllAvatarsForUser(c.UserContext(), userID)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to get avatars",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get avatars")
 		}
 
-		return c.Status(fiber.StatusOK).JSON(avatars)
+		return utils.OKResponse(c, avatarResponses(avatars, userAddress))
 	}
 }
 
@@ -125,25 +116,20 @@ func GetActiveAvatar() fiber.Handler {
 		// Get user ID from context
 		userID, ok := middleware.GetUserID(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
 		}
+		userAddress, _ := middleware.GetUserAddress(c)
 
 		// Get active avatar
-		avatar, err := models.GetActiveAvatarForUser(userID)
+		avatar, err := models.GetActiveAvatarForUser(c.UserContext(), userID)
 		if err != nil {
 			if errors.Is(err, models.ErrAvatarNotFound) {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"error": "No active avatar found",
-				})
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeAvatarNotFound, "No active avatar found")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to get active avatar",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get active avatar")
 		}
 
-		return c.Status(fiber.StatusOK).JSON(avatar)
+		return utils.OKResponse(c, avatarResponse(avatar, userAddress))
 	}
 }
 
@@ -153,33 +139,25 @@ func SetActiveAvatar() fiber.Handler {
 		// Get user ID from context
 		userID, ok := middleware.GetUserID(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
 		}
 
 		// Get avatar ID from URL parameter
 		avatarID, err := strconv.Atoi(c.Params("id"))
 		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid avatar ID",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid avatar ID")
 		}
 
 		// Set avatar as active
-		if err := models.SetActiveAvatar(avatarID, userID); err != nil {
+		if err := models.SetActiveAvatar(c.UserContext(), avatarID, userID); err != nil {
 			if errors.Is(err, models.ErrAvatarNotFound) {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"error": "Avatar not found",
-				})
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeAvatarNotFound, "Avatar not found")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to set active avatar",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to set active avatar")
 		}
 
-		return c.Status(fiber.StatusOK).JSON(fiber.Map{
-			"message": "Avatar set as active",
+		return utils.OKResponse(c, fiber.Map{
+			"message": localized(c, "Avatar set as active"),
 		})
 	}
 }
@@ -190,114 +168,156 @@ func DeleteAvatar() fiber.Handler {
 		// Get user ID from context
 		userID, ok := middleware.GetUserID(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
 		}
 
 		// Get avatar ID from URL parameter
 		avatarID, err := strconv.Atoi(c.Params("id"))
 		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid avatar ID",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid avatar ID")
 		}
 
 		// Get the avatar to get the file path
-		avatar, err := models.GetAvatarByID(avatarID)
+		avatar, err := models.GetAvatarByID(c.UserContext(), avatarID)
 		if err != nil {
 			if errors.Is(err, models.ErrAvatarNotFound) {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"error": "Avatar not found",
-				})
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeAvatarNotFound, "Avatar not found")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to get avatar",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get avatar")
 		}
 
 		// Verify that the avatar belongs to the user
 		if avatar.UserID != userID {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"error": "You don't have permission to delete this avatar",
-			})
+			return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeForbidden, "You don't have permission to delete this avatar")
 		}
 
 		// Delete avatar from database
-		if err := models.DeleteAvatar(avatarID, userID); err != nil {
+		if err := models.DeleteAvatar(c.UserContext(), avatarID, userID); err != nil {
 			if errors.Is(err, models.ErrAvatarNotFound) {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"error": "Avatar not found",
-				})
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeAvatarNotFound, "Avatar not found")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to delete avatar",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to delete avatar")
 		}
 
 		// Delete the file
-		if err := os.Remove(avatar.FilePath); err != nil && !os.IsNotExist(err) {
+		if err := deleteAvatarFile(avatar); err != nil {
 			// Log error but don't return it to the client
 			// The database record is already deleted
-			fmt.Printf("Error deleting avatar file: %v\n", err)
+			log.Printf("Error deleting avatar file: %v", err)
 		}
 
-		return c.Status(fiber.StatusOK).JSON(fiber.Map{
-			"message": "Avatar deleted successfully",
+		return utils.OKResponse(c, fiber.Map{
+			"message": localized(c, "Avatar deleted successfully"),
 		})
 	}
 }
 
-// ServeAvatar handles serving an avatar file
+// ServeAvatar handles serving an avatar file through a signed URL. The
+// viewer the URL was issued to must still be allowed to see the photo, and
+// only its owner can see avatars that aren't active.
 func ServeAvatar() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// Get avatar ID from URL parameter
 		avatarID, err := strconv.Atoi(c.Params("id"))
 		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid avatar ID",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Invalid avatar ID")
+		}
+
+		viewerAddress := c.Query("viewer")
+		expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+		if err != nil || !utils.VerifyURLSignature(mediaConfig.SigningSecret, avatarSignedPath(avatarID, viewerAddress), expires, c.Query("signature")) {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeDownloadLinkInvalid, "Invalid or expired download link")
 		}
 
 		// Get avatar from database
-		avatar, err := models.GetAvatarByID(avatarID)
+		avatar, err := models.GetAvatarByID(c.UserContext(), avatarID)
 		if err != nil {
 			if errors.Is(err, models.ErrAvatarNotFound) {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"error": "Avatar not found",
-				})
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeAvatarNotFound, "Avatar not found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get avatar")
+		}
+
+		owner, err := stores.Users.GetByID(c.UserContext(), avatar.UserID)
+		if err != nil {
+			if errors.Is(err, models.ErrUserNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeAvatarNotFound, "Avatar not found")
+			}
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get avatar")
+		}
+		if owner.Address != viewerAddress {
+			visible, err := profilePhotoVisible(c.UserContext(), owner, viewerAddress)
+			if err != nil {
+				return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get avatar")
+			}
+			if !visible || !avatar.IsActive {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeAvatarNotFound, "Avatar not found")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to get avatar",
-			})
 		}
 
 		// Open the file
-		file, err := os.Open(avatar.FilePath)
+		file, err := openAvatarFile(avatar)
 		if err != nil {
-			if os.IsNotExist(err) {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"error": "Avatar file not found",
-				})
+			if errors.Is(err, storage.ErrObjectNotFound) {
+				return utils.ErrorResponse(c, fiber.StatusNotFound, utils.CodeAvatarNotFound, "Avatar file not found")
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to open avatar file",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to open avatar file")
 		}
 		defer file.Close()
 
-		// Set content type header
-		c.Set("Content-Type", avatar.MimeType)
+		// Only images that can't run scripts are shown inline; anything else
+		// stored before SVG was refused is downloaded instead
+		if isPhotoType(avatar.MimeType) {
+			c.Set("Content-Type", avatar.MimeType)
+			c.Set("Content-Disposition", "inline")
+		} else {
+			c.Set("Content-Type", fiber.MIMEOctetStream)
+			c.Set("Content-Disposition", attachmentDisposition(filepath.Base(avatar.FilePath)))
+		}
 		c.Set("Content-Length", strconv.Itoa(avatar.FileSize))
+		// The URL is signed for one viewer, so shared caches mustn't keep it
+		c.Set("Cache-Control", "private")
 
 		// Stream the file to the response
 		_, err = io.Copy(c, file)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to send avatar file",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to send avatar file")
 		}
 
 		return nil
 	}
 }
+
+// legacyAvatarDir is where avatars were saved on disk before they were kept
+// in media storage. The file path of those avatars is still a path under it.
+const legacyAvatarDir = "uploads/avatars"
+
+// isLegacyAvatar checks if an avatar's file was saved on disk by an older
+// version
+func isLegacyAvatar(avatar *models.UserAvatar) bool {
+	return strings.HasPrefix(filepath.ToSlash(filepath.Clean(avatar.FilePath)), legacyAvatarDir+"/")
+}
+
+// openAvatarFile opens an avatar's file, returning storage.ErrObjectNotFound
+// if it is gone
+func openAvatarFile(avatar *models.UserAvatar) (io.ReadCloser, error) {
+	if !isLegacyAvatar(avatar) {
+		return MediaStore.Get(avatar.FilePath)
+	}
+	file, err := os.Open(avatar.FilePath)
+	if os.IsNotExist(err) {
+		return nil, storage.ErrObjectNotFound
+	}
+	return file, err
+}
+
+// deleteAvatarFile deletes an avatar's file. Files already gone are fine.
+func deleteAvatarFile(avatar *models.UserAvatar) error {
+	if !isLegacyAvatar(avatar) {
+		return MediaStore.Delete(avatar.FilePath)
+	}
+	if err := os.Remove(avatar.FilePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
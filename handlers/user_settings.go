@@ -6,38 +6,52 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/piko/piko/middleware"
 	"github.com/piko/piko/models"
+	"github.com/piko/piko/utils"
 )
 
+// UpdateUserSettingsRequest represents a request to update user settings.
+// Omitted fields are left unchanged.
+type UpdateUserSettingsRequest struct {
+	Nickname            string             `json:"nickname"`
+	Theme               models.ThemeType   `json:"theme"`
+	NotificationEnabled *bool              `json:"notification_enabled"`
+	SoundEnabled        *bool              `json:"sound_enabled"`
+	Language            string             `json:"language"`
+	AutoDownloadMedia   *bool              `json:"auto_download_media"`
+	PrivacyLastSeen     models.PrivacyType `json:"privacy_last_seen"`
+	PrivacyProfilePhoto models.PrivacyType `json:"privacy_profile_photo"`
+	PrivacyStatus       models.PrivacyType `json:"privacy_status"`
+}
+
+// UpdateNicknameRequest represents a request to change the user's nickname
+type UpdateNicknameRequest struct {
+	Nickname string `json:"nickname"`
+}
+
 // GetUserSettings handles retrieving user settings
 func GetUserSettings() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// Get user ID from context
 		userID, ok := middleware.GetUserID(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
 		}
 
 		// Get user settings
-		settings, err := models.GetUserSettings(userID)
+		settings, err := models.GetUserSettings(c.UserContext(), userID)
 		if err != nil {
 			if errors.Is(err, models.ErrSettingsNotFound) {
 				// Create default settings if not found
-				settings, err = models.CreateDefaultSettings(userID)
+				settings, err = models.CreateDefaultSettings(c.UserContext(), userID)
 				if err != nil {
-					return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-						"error": "Failed to create default settings",
-					})
+					return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to create default settings")
 				}
 			} else {
-				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-					"error": "Failed to get settings",
-				})
+				return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get settings")
 			}
 		}
 
-		return c.Status(fiber.StatusOK).JSON(settings)
+		return utils.OKResponse(c, settings)
 	}
 }
 
@@ -47,45 +61,27 @@ func UpdateUserSettings() fiber.Handler {
 		// Get user ID from context
 		userID, ok := middleware.GetUserID(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
 		}
 
 		// Get current settings
-		settings, err := models.GetUserSettings(userID)
+		settings, err := models.GetUserSettings(c.UserContext(), userID)
 		if err != nil {
 			if errors.Is(err, models.ErrSettingsNotFound) {
 				// Create default settings if not found
-				settings, err = models.CreateDefaultSettings(userID)
+				settings, err = models.CreateDefaultSettings(c.UserContext(), userID)
 				if err != nil {
-					return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-						"error": "Failed to create default settings",
-					})
+					return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to create default settings")
 				}
 			} else {
-				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-					"error": "Failed to get settings",
-				})
+				return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get settings")
 			}
 		}
 
 		// Parse request body
-		var updateReq struct {
-			Nickname            string             `json:"nickname"`
-			Theme               models.ThemeType   `json:"theme"`
-			NotificationEnabled *bool              `json:"notification_enabled"`
-			SoundEnabled        *bool              `json:"sound_enabled"`
-			Language            string             `json:"language"`
-			AutoDownloadMedia   *bool              `json:"auto_download_media"`
-			PrivacyLastSeen     models.PrivacyType `json:"privacy_last_seen"`
-			PrivacyProfilePhoto models.PrivacyType `json:"privacy_profile_photo"`
-			PrivacyStatus       models.PrivacyType `json:"privacy_status"`
-		}
-		if err := c.BodyParser(&updateReq); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid request body",
-			})
+		updateReq := new(UpdateUserSettingsRequest)
+		if err := c.BodyParser(updateReq); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
 		}
 
 		// Update only provided fields
@@ -118,13 +114,14 @@ func UpdateUserSettings() fiber.Handler {
 		}
 
 		// Save changes
-		if err := models.UpdateUserSettings(settings); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to update settings",
-			})
+		if err := models.UpdateUserSettings(c.UserContext(), settings); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to update settings")
+		}
+		if userAddress, ok := middleware.GetUserAddress(c); ok {
+			refreshPresenceAudience(c.UserContext(), userAddress)
 		}
 
-		return c.Status(fiber.StatusOK).JSON(settings)
+		return utils.OKResponse(c, settings)
 	}
 }
 
@@ -134,38 +131,28 @@ func UpdateNickname() fiber.Handler {
 		// Get user ID from context
 		userID, ok := middleware.GetUserID(c)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized",
-			})
+			return utils.UnauthorizedResponse(c)
 		}
 
 		// Parse request body
-		var req struct {
-			Nickname string `json:"nickname"`
-		}
-		if err := c.BodyParser(&req); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid request body",
-			})
+		req := new(UpdateNicknameRequest)
+		if err := c.BodyParser(req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeInvalidRequestBody, "Invalid request body")
 		}
 
 		// Validate nickname
 		if req.Nickname == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Nickname is required",
-			})
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, utils.CodeValidationFailed, "Nickname is required")
 		}
 
 		// Update nickname
-		err := models.UpdateNickname(userID, req.Nickname)
+		err := models.UpdateNickname(c.UserContext(), userID, req.Nickname)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to update nickname",
-			})
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to update nickname")
 		}
 
-		return c.Status(fiber.StatusOK).JSON(fiber.Map{
-			"message":  "Nickname updated successfully",
+		return utils.OKResponse(c, fiber.Map{
+			"message":  localized(c, "Nickname updated successfully"),
 			"nickname": req.Nickname,
 		})
 	}
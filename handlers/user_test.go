@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/store"
+	"github.com/piko/piko/types"
+	"github.com/piko/piko/utils"
+)
+
+var (
+	alice = &models.User{ID: 1, Phone: "+15550001111", Username: "alice", Address: "PikoAlice"}
+	bob   = &models.User{ID: 2, Phone: "+15550002222", Username: "bob", Address: "PikoBob"}
+)
+
+// minor returns a user young enough to be in restricted mode
+func minor() *models.User {
+	birthdate := types.NewTime(clock.Now().AddDate(-12, 0, 0))
+	return &models.User{ID: 3, Phone: "+15550003333", Username: "bobby", Address: "PikoBobby", Birthdate: &birthdate}
+}
+
+func TestSearchUsers(t *testing.T) {
+	s := store.Memory([]*models.User{alice, bob, minor()}, nil)
+	contacts := store.NewMemoryContacts()
+	contacts.SetName(alice.Address, bob.Address, "Bobcat", "work")
+	s.Contacts = contacts
+	useStores(t, s)
+
+	status, resp := serve(t, alice, http.MethodGet, "/users/search", "/users/search?query=bob", "", SearchUsers())
+	if status != http.StatusOK {
+		t.Fatalf("got status %d (%s), want 200", status, resp.Code)
+	}
+	var users []UserResponse
+	decodeData(t, resp, &users)
+
+	// bobby is in restricted mode, so only bob is found
+	if len(users) != 1 {
+		t.Fatalf("got %d users, want 1: %+v", len(users), users)
+	}
+	got := users[0]
+	if got.Address != bob.Address || got.Username != bob.Username {
+		t.Errorf("got user %s (%s), want %s (%s)", got.Address, got.Username, bob.Address, bob.Username)
+	}
+	if got.Phone != maskPhone(bob.Phone) {
+		t.Errorf("got phone %q, want it masked as %q", got.Phone, maskPhone(bob.Phone))
+	}
+	if got.Alias != "Bobcat" || len(got.Labels) != 1 || got.Labels[0] != "work" {
+		t.Errorf("got alias %q and labels %v, want Bobcat and [work]", got.Alias, got.Labels)
+	}
+}
+
+func TestSearchUsersRejects(t *testing.T) {
+	useStores(t, store.Memory([]*models.User{alice, bob, minor()}, nil))
+
+	tests := []struct {
+		name   string
+		user   *models.User
+		target string
+		status int
+		code   string
+	}{
+		{"anonymous", nil, "/users/search?query=bob", http.StatusUnauthorized, utils.CodeUnauthorized},
+		{"restricted user", minor(), "/users/search?query=bob", http.StatusForbidden, utils.CodeRestrictedMode},
+		{"missing query", alice, "/users/search", http.StatusBadRequest, utils.CodeValidationFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, resp := serve(t, tt.user, http.MethodGet, "/users/search", tt.target, "", SearchUsers())
+			if status != tt.status || resp.Code != tt.code {
+				t.Errorf("got %d %s, want %d %s", status, resp.Code, tt.status, tt.code)
+			}
+		})
+	}
+}
+
+func TestSetUsername(t *testing.T) {
+	users := store.NewMemoryUsers(alice, bob)
+	useStores(t, store.Stores{Users: users})
+
+	status, resp := serve(t, alice, http.MethodPut, "/users/username", "/users/username", `{"username":"alice_2"}`, SetUsername())
+	if status != http.StatusOK {
+		t.Fatalf("got status %d (%s), want 200", status, resp.Code)
+	}
+	user, err := users.GetByID(context.Background(), alice.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if user.Username != "alice_2" {
+		t.Errorf("got username %q after setting it, want alice_2", user.Username)
+	}
+}
+
+func TestSetUsernameRejects(t *testing.T) {
+	useStores(t, store.Stores{Users: store.NewMemoryUsers(alice, bob)})
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		code   string
+	}{
+		{"invalid body", `{`, http.StatusBadRequest, utils.CodeInvalidRequestBody},
+		{"empty username", `{"username":""}`, http.StatusBadRequest, utils.CodeValidationFailed},
+		{"too short", `{"username":"al"}`, http.StatusBadRequest, utils.CodeValidationFailed},
+		{"invalid characters", `{"username":"alice!"}`, http.StatusBadRequest, utils.CodeValidationFailed},
+		{"taken", `{"username":"bob"}`, http.StatusConflict, utils.CodeUsernameTaken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, resp := serve(t, alice, http.MethodPut, "/users/username", "/users/username", tt.body, SetUsername())
+			if status != tt.status || resp.Code != tt.code {
+				t.Errorf("got %d %s, want %d %s", status, resp.Code, tt.status, tt.code)
+			}
+		})
+	}
+}
@@ -1,24 +1,44 @@
 package handlers
 
 import (
+	"context"
+	"log"
+	"strconv"
+	"sync/atomic"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
 	wsfiber "github.com/gofiber/websocket/v2"
+	"github.com/piko/piko/config"
+	"github.com/piko/piko/crypto"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/utils"
 	"github.com/piko/piko/websocket"
 )
 
 var (
 	// WebSocketPool is the global WebSocket pool
 	WebSocketPool = websocket.NewPool()
+
+	// drainRetryAfter is the Retry-After hint, in nanoseconds, given to
+	// upgrades refused while draining. It is set before the pools start
+	// draining and read by request goroutines.
+	drainRetryAfter atomic.Int64
 )
 
+// defaultDrainRetryAfter is the Retry-After hint when draining without
+// more jitter than it
+const defaultDrainRetryAfter = 5 * time.Second
+
 func init() {
 	// Start the WebSocket pool
 	go WebSocketPool.Start()
 }
 
-// WebSocketHandler handles WebSocket connections
-func WebSocketHandler() fiber.Handler {
-	return wsfiber.New(func(c *wsfiber.Conn) {
+// WebSocketHandler handles WebSocket connections authenticated by the token
+// and address query parameters
+func WebSocketHandler(cfg *config.Config) fiber.Handler {
+	return rejectWhenDraining(WebSocketPool, wsfiber.New(func(c *wsfiber.Conn) {
 		// Get user address from query parameter
 		address := c.Query("address")
 		if address == "" {
@@ -33,20 +53,101 @@ func WebSocketHandler() fiber.Handler {
 			return
 		}
 
-		// TODO: Validate token and address
-		// For now, we'll trust the client
+		// Only accept the account the token was issued to, before anything
+		// of the account's is sent
+		claims, err := middleware.AuthenticateToken(context.Background(), token, cfg.Auth.JWTSecret)
+		if err != nil || claims.Address != address {
+			c.Close()
+			return
+		}
+
+		// Suspended and frozen accounts stay disconnected until the
+		// suspension is lifted or the freeze ends
+		if blocked, err := accountBlocked(context.Background(), address); err != nil || blocked {
+			c.Close()
+			return
+		}
 
 		// Create a new client
 		client := &websocket.Client{
-			Address: address,
-			Conn:    c,
-			Pool:    WebSocketPool,
+			Address:     address,
+			Conn:        c,
+			Pool:        WebSocketPool,
+			SessionID:   claims.SessionID,
+			Encoding:    crypto.NegotiateEncoding(c.Query("encoding")),
+			ConnectedAt: time.Now(),
 		}
 
+		// Optionally stream recent conversations after connecting
+		prefetch, _ := strconv.Atoi(c.Query("prefetch"))
+		pageSize, _ := strconv.Atoi(c.Query("prefetch_page_size"))
+		client.EnablePrefetch(prefetch, pageSize)
+
+		// Only tell the users the privacy settings allow when the client
+		// comes online
+		client.SetPresenceAudience(presenceAudience(context.Background(), address))
+
 		// Register client
 		WebSocketPool.Register <- client
 
 		// Start reading messages
 		client.Read()
-	})
+	}))
+}
+
+// InitWebSockets applies the send queue and keepalive settings to the
+// WebSocket pools, and connects them to the other instances if a broker is
+// configured
+func InitWebSockets(cfg config.ServerConfig, redisCfg config.RedisConfig) error {
+	opts := websocket.Options{
+		SendBuffer:   cfg.WebSocketSendBuffer,
+		WriteTimeout: cfg.WebSocketWriteTimeout,
+		PingInterval: cfg.WebSocketPingInterval,
+		PongTimeout:  cfg.WebSocketPongTimeout,
+	}
+	WebSocketPool.Configure(opts)
+	SecretChatPool.Configure(opts)
+
+	pools := map[string]*websocket.Pool{
+		cfg.WebSocketBrokerChannel:             WebSocketPool,
+		cfg.WebSocketBrokerChannel + ":secret": SecretChatPool,
+	}
+	for channel, pool := range pools {
+		broker, err := websocket.NewBroker(cfg.WebSocketBroker, redisCfg, channel)
+		if err != nil {
+			return err
+		}
+		if broker == nil {
+			continue
+		}
+		if err := pool.UseBroker(broker); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DrainWebSockets asks all connected clients to reconnect elsewhere and
+// stops accepting new WebSocket upgrades
+func DrainWebSockets(jitter time.Duration) {
+	drainRetryAfter.Store(int64(max(jitter, defaultDrainRetryAfter)))
+
+	count := WebSocketPool.Drain(jitter)
+	count += SecretChatPool.Drain(jitter)
+	log.Printf("Sent reconnect_soon to %d WebSocket clients", count)
+}
+
+// rejectWhenDraining refuses new upgrades with 503 once the pool is draining
+func rejectWhenDraining(pool *websocket.Pool, next fiber.Handler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if pool.IsDraining() {
+			retryAfter := time.Duration(drainRetryAfter.Load())
+			if retryAfter <= 0 {
+				retryAfter = defaultDrainRetryAfter
+			}
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(retryAfter.Seconds())))
+			return utils.ErrorResponse(c, fiber.StatusServiceUnavailable, utils.CodeShuttingDown, "Server is shutting down, please reconnect shortly")
+		}
+		return next(c)
+	}
 }
@@ -0,0 +1,323 @@
+package i18n
+
+// fa holds the Persian translations
+var fa = map[string]string{
+	// Authentication and sessions
+	"Unauthorized":                                        "دسترسی غیرمجاز",
+	"no authorization header provided":                    "هدر احراز هویت ارسال نشده است",
+	"invalid authorization header format":                 "قالب هدر احراز هویت نامعتبر است",
+	"invalid token":                                       "توکن نامعتبر است",
+	"token expired":                                       "توکن منقضی شده است",
+	"session revoked":                                     "این نشست لغو شده است",
+	"admin role required":                                 "نقش مدیر لازم است",
+	"Failed to check session":                             "بررسی نشست ناموفق بود",
+	"Invalid session":                                     "نشست نامعتبر است",
+	"Session ID is required":                              "شناسه نشست الزامی است",
+	"Phone number is required":                            "شماره تلفن الزامی است",
+	"Phone number and verification code are required":     "شماره تلفن و کد تأیید الزامی است",
+	"No code to resend. Please register or log in again.": "کدی برای ارسال دوباره نیست. لطفاً دوباره ثبت‌نام یا وارد شوید.",
+	"Please wait before requesting another code":          "لطفاً پیش از درخواست کد دیگر کمی صبر کنید",
+	"This code can't be resent again. Please register or log in again.": "این کد دیگر قابل ارسال دوباره نیست. لطفاً دوباره ثبت‌نام یا وارد شوید.",
+	"Channel must be sms or email":                                      "روش ارسال باید sms یا email باشد",
+	"A valid email address is required":                                 "یک آدرس ایمیل معتبر لازم است",
+	"No email address on this account":                                  "این حساب آدرس ایمیلی ندارد",
+	"Invalid verification code":                                         "کد تأیید نامعتبر است",
+	"Maximum verification attempts reached. Please request a new OTP.":  "تعداد دفعات مجاز تأیید به پایان رسید. لطفاً کد جدیدی درخواست کنید.",
+	"Failed to send OTP":                                                "ارسال کد تأیید ناموفق بود",
+	"Failed to generate OTP":                                            "ساخت کد تأیید ناموفق بود",
+	"Failed to verify OTP":                                              "تأیید کد ناموفق بود",
+	"Failed to generate token":                                          "ساخت توکن ناموفق بود",
+	"Invalid public key":                                                "کلید عمومی نامعتبر است",
+	"Unknown public key":                                                "کلید عمومی ناشناخته است",
+	"Invalid signature":                                                 "امضا نامعتبر است",
+	"Challenge invalid or expired":                                      "چالش نامعتبر است یا منقضی شده است",
+	"Failed to verify challenge":                                        "بررسی چالش ناموفق بود",
+	"Failed to create challenge":                                        "ساخت چالش ناموفق بود",
+	"CAPTCHA token is required":                                         "توکن CAPTCHA الزامی است",
+	"CAPTCHA verification failed":                                       "تأیید CAPTCHA ناموفق بود",
+	"Failed to verify CAPTCHA":                                          "بررسی CAPTCHA ناموفق بود",
+	"Too many requests":                                                 "تعداد درخواست‌ها بیش از حد مجاز است",
+	"Requests from your network are blocked":                            "درخواست‌های شبکه شما مسدود شده است",
+	"Too many failed requests. Please try again later.":                 "درخواست‌های ناموفق بیش از حد است. لطفاً بعداً دوباره تلاش کنید.",
+	"policy acceptance required":                                        "پذیرش شرایط و سیاست حریم خصوصی لازم است",
+	"Failed to check policy acceptance":                                 "بررسی پذیرش سیاست‌ها ناموفق بود",
+	"PIN required":                                                      "رمز حساب لازم است",
+	"Invalid PIN":                                                       "رمز حساب نادرست است",
+	"Too many wrong PINs. Try again later.":                             "رمز حساب چند بار نادرست وارد شد. بعداً دوباره تلاش کنید.",
+	"PIN not set":                                                       "رمز حساب تنظیم نشده است",
+	"PIN must be 4 to 64 characters":                                    "رمز حساب باید بین ۴ تا ۶۴ نویسه باشد",
+	"Current PIN is required":                                           "رمز فعلی حساب الزامی است",
+	"Account is frozen":                                                 "حساب مسدود شده است",
+	"Invalid recovery code":                                             "کد بازیابی نامعتبر است",
+	"Phone number and recovery code are required":                       "شماره تلفن و کد بازیابی الزامی است",
+
+	// Requests
+	"Invalid request body":  "بدنه درخواست نامعتبر است",
+	"Access denied":         "دسترسی مجاز نیست",
+	"Internal Server Error": "خطای داخلی سرور",
+
+	// Users
+	"User not found":                        "کاربر یافت نشد",
+	"User address is required":              "آدرس کاربر الزامی است",
+	"Failed to get user":                    "دریافت اطلاعات کاربر ناموفق بود",
+	"Failed to find user":                   "یافتن کاربر ناموفق بود",
+	"Recipient not found":                   "گیرنده یافت نشد",
+	"Failed to verify recipient":            "بررسی گیرنده ناموفق بود",
+	"Avatar not found":                      "تصویر پروفایل یافت نشد",
+	"Invalid avatar ID":                     "شناسه تصویر پروفایل نامعتبر است",
+	"Failed to get usage":                   "دریافت آمار استفاده ناموفق بود",
+	"Days must be between 1 and 90":         "تعداد روزها باید بین ۱ تا ۹۰ باشد",
+	"Between 1 and 500 hashes are required": "بین ۱ تا ۵۰۰ هش لازم است",
+	"Hashes must be hex SHA-256 hashes":     "هش‌ها باید SHA-256 هگزادسیمال باشند",
+	"Failed to discover contacts":           "یافتن مخاطبان ناموفق بود",
+
+	// Messages
+	"Message not found":                          "پیام یافت نشد",
+	"Message ID is required":                     "شناسه پیام الزامی است",
+	"Encrypted content is required":              "محتوای رمزنگاری‌شده الزامی است",
+	"Invalid encrypted content":                  "محتوای رمزنگاری‌شده نامعتبر است",
+	"Failed to get message":                      "دریافت پیام ناموفق بود",
+	"Failed to get messages":                     "دریافت پیام‌ها ناموفق بود",
+	"Invalid cursor":                             "نشانگر صفحه نامعتبر است",
+	"Use either before or after":                 "فقط یکی از before یا after را بفرستید",
+	"Between 1 and 100 message IDs are required": "بین ۱ تا ۱۰۰ شناسه پیام لازم است",
+	"Status must be delivered or read":           "وضعیت باید delivered یا read باشد",
+	"Failed to update message status":            "به‌روزرسانی وضعیت پیام ناموفق بود",
+	"Only the sender can edit this message":      "فقط فرستنده می‌تواند این پیام را ویرایش کند",
+	"message edit window has expired":            "مهلت ویرایش پیام تمام شده است",
+	"Failed to edit message":                     "ویرایش پیام ناموفق بود",
+	"Failed to get receipts":                     "دریافت رسیدهای پیام ناموفق بود",
+	"Invalid since parameter":                    "پارامتر since نامعتبر است",
+	"Failed to create message":                   "ایجاد پیام ناموفق بود",
+	"Failed to generate message ID":              "ساخت شناسه پیام ناموفق بود",
+	"You can't send messages to this user":       "نمی‌توانید به این کاربر پیام بدهید",
+	"Invalid reply_to_message_id":                "پیام پاسخ‌داده‌شده نامعتبر است",
+	"Failed to verify replied message":           "بررسی پیام پاسخ‌داده‌شده ناموفق بود",
+	"Disappearing messages can't be forwarded":   "پیام‌های ناپدیدشونده قابل هدایت نیستند",
+
+	// Broadcast lists
+	"Broadcast list not found":                            "فهرست پخش یافت نشد",
+	"Broadcast list is full":                              "ظرفیت فهرست پخش تکمیل است",
+	"Broadcast list has no recipients":                    "فهرست پخش گیرنده‌ای ندارد",
+	"Name is required and must be at most 100 characters": "نام الزامی است و حداکثر می‌تواند ۱۰۰ نویسه باشد",
+	"Invalid recipient":                                   "گیرنده نامعتبر است",
+	"Recipients are required":                             "گیرندگان الزامی هستند",
+	"Recipient not found in broadcast list":               "گیرنده در فهرست پخش یافت نشد",
+	"Broadcast not found":                                 "پیام پخش‌شده یافت نشد",
+	"Failed to get broadcast lists":                       "دریافت فهرست‌های پخش ناموفق بود",
+	"Failed to create broadcast list":                     "ایجاد فهرست پخش ناموفق بود",
+	"Failed to record broadcast":                          "ثبت پیام پخش‌شده ناموفق بود",
+
+	// Media
+	"Media not found":              "فایل یافت نشد",
+	"Invalid attachment":           "پیوست نامعتبر است",
+	"Failed to attach media":       "پیوست فایل ناموفق بود",
+	"Failed to verify attachments": "بررسی پیوست‌ها ناموفق بود",
+	"File type not allowed":        "نوع فایل مجاز نیست",
+	"Storage quota exceeded":       "فضای ذخیره‌سازی شما پر شده است",
+
+	// Groups
+	"Group not found":                               "گروه یافت نشد",
+	"Group ID is required":                          "شناسه گروه الزامی است",
+	"You are not a member of this group":            "شما عضو این گروه نیستید",
+	"You are not an admin of this group":            "شما مدیر این گروه نیستید",
+	"User is already a member of this group":        "کاربر از قبل عضو این گروه است",
+	"Failed to get group":                           "دریافت گروه ناموفق بود",
+	"Failed to get group members":                   "دریافت اعضای گروه ناموفق بود",
+	"Invite token is required":                      "توکن دعوت الزامی است",
+	"Group is full":                                 "ظرفیت گروه تکمیل است",
+	"The group owner must transfer ownership first": "مالک گروه ابتدا باید مالکیت را منتقل کند",
+	"Promote another admin first":                   "ابتدا عضو دیگری را مدیر کنید",
+	"Only the owner can delete the group":           "فقط مالک می‌تواند گروه را حذف کند",
+	"Only the owner can transfer ownership":         "فقط مالک می‌تواند مالکیت را منتقل کند",
+	"new_owner_address must be another member":      "مالک جدید باید عضو دیگری از گروه باشد",
+	"Failed to transfer ownership":                  "انتقال مالکیت ناموفق بود",
+	"Role must be admin or member":                  "نقش باید admin یا member باشد",
+	"Failed to update join mode":                    "تغییر حالت عضویت ناموفق بود",
+	"Message must be at most 255 characters":        "پیام حداکثر می‌تواند ۲۵۵ نویسه باشد",
+	"This group does not accept join requests":      "این گروه درخواست عضویت نمی‌پذیرد",
+	"Your join request is already pending":          "درخواست عضویت شما در انتظار بررسی است",
+	"Failed to create join request":                 "ثبت درخواست عضویت ناموفق بود",
+	"Failed to get join requests":                   "دریافت درخواست‌های عضویت ناموفق بود",
+	"Join request not found":                        "درخواست عضویت یافت نشد",
+	"Failed to decide join request":                 "بررسی درخواست عضویت ناموفق بود",
+	"Your guest pass is read-only":                  "مجوز مهمان شما فقط خواندنی است",
+	"Access must be read or write":                  "دسترسی باید read یا write باشد",
+	"Failed to grant guest pass":                    "صدور مجوز مهمان ناموفق بود",
+	"Failed to get guest passes":                    "دریافت مجوزهای مهمان ناموفق بود",
+	"Key rotation not found":                        "چرخش کلید یافت نشد",
+	"Failed to get key rotation":                    "دریافت چرخش کلید ناموفق بود",
+	"Failed to get key rotations":                   "دریافت چرخش‌های کلید ناموفق بود",
+	"Failed to acknowledge key rotation":            "تأیید چرخش کلید ناموفق بود",
+	"Guest pass not found":                          "مجوز مهمان یافت نشد",
+	"Failed to revoke guest pass":                   "لغو مجوز مهمان ناموفق بود",
+	"Failed to update member role":                  "تغییر نقش عضو ناموفق بود",
+
+	// Channels
+	"Channel not found":                                      "کانال یافت نشد",
+	"Channel ID is required":                                 "شناسه کانال الزامی است",
+	"User is not a member of the channel":                    "کاربر عضو کانال نیست",
+	"User is already a member of the channel":                "کاربر از قبل عضو کانال است",
+	"Failed to get channel":                                  "دریافت کانال ناموفق بود",
+	"Failed to check channel membership":                     "بررسی عضویت کانال ناموفق بود",
+	"Channel is full":                                        "ظرفیت کانال تکمیل است",
+	"Failed to check channel moderation":                     "بررسی وضعیت نظارت کانال ناموفق بود",
+	"Channel has not been flagged":                           "کانال علامت‌گذاری نشده است",
+	"Channel is not throttled":                               "کانال محدود نشده است",
+	"This throttle has already been appealed":                "برای این محدودیت قبلاً درخواست تجدیدنظر داده شده است",
+	"Only the channel owner can do this":                     "فقط مالک کانال می‌تواند این کار را انجام دهد",
+	"message_id and target_channel_id are required":          "شناسه پیام و کانال مقصد الزامی است",
+	"Target channel must be a different channel":             "کانال مقصد باید کانال دیگری باشد",
+	"Only channel owners and admins can manage the web view": "فقط مالک و مدیران کانال می‌توانند نمای وب را مدیریت کنند",
+	"Only public channels can have a web view":               "فقط کانال‌های عمومی می‌توانند نمای وب داشته باشند",
+	"Slug must be 5-32 lowercase letters, digits and underscores, starting with a letter": "نشانی باید ۵ تا ۳۲ حرف کوچک انگلیسی، رقم یا زیرخط باشد و با حرف شروع شود",
+	"Slug already taken":        "این نشانی قبلاً استفاده شده است",
+	"Failed to update web view": "به‌روزرسانی نمای وب ناموفق بود",
+
+	// Secret chats
+	"Secret chat not found":   "چت مخفی یافت نشد",
+	"Secret chat has expired": "چت مخفی منقضی شده است",
+
+	// Support
+	"Support ticket not found": "تیکت پشتیبانی یافت نشد",
+	"Invalid ticket ID":        "شناسه تیکت نامعتبر است",
+
+	// Group events
+	"Failed to check group membership":                        "بررسی عضویت گروه ناموفق بود",
+	"Title is required and must be at most 100 characters":    "عنوان الزامی است و حداکثر ۱۰۰ نویسه است",
+	"Description must be at most 2000 characters":             "توضیحات حداکثر ۲۰۰۰ نویسه است",
+	"Event must start in the future":                          "رویداد باید در آینده شروع شود",
+	"Failed to generate event ID":                             "ایجاد شناسه رویداد ناموفق بود",
+	"Failed to create event":                                  "ایجاد رویداد ناموفق بود",
+	"Failed to get events":                                    "دریافت رویدادها ناموفق بود",
+	"Event not found":                                         "رویداد یافت نشد",
+	"Failed to get event":                                     "دریافت رویداد ناموفق بود",
+	"Only the event's creator and group admins can cancel it": "فقط سازنده رویداد و مدیران گروه می‌توانند آن را لغو کنند",
+	"Failed to cancel event":                                  "لغو رویداد ناموفق بود",
+	"Status must be going, maybe or not_going":                "وضعیت باید going، maybe یا not_going باشد",
+	"Failed to save RSVP":                                     "ثبت پاسخ ناموفق بود",
+	"Failed to withdraw RSVP":                                 "پس گرفتن پاسخ ناموفق بود",
+	"Failed to get RSVPs":                                     "دریافت پاسخ‌ها ناموفق بود",
+
+	// Group topics
+	"Topics are not enabled in this group":                  "موضوع‌ها در این گروه فعال نیستند",
+	"Topic not found":                                       "موضوع یافت نشد",
+	"This topic is closed":                                  "این موضوع بسته شده است",
+	"Name is required and must be at most 128 characters":   "نام الزامی است و حداکثر ۱۲۸ نویسه است",
+	"Failed to create topic":                                "ایجاد موضوع ناموفق بود",
+	"Failed to get topics":                                  "دریافت موضوع‌ها ناموفق بود",
+	"Failed to get topic":                                   "دریافت موضوع ناموفق بود",
+	"Failed to update topic":                                "به‌روزرسانی موضوع ناموفق بود",
+	"Failed to update topic mode":                           "تغییر حالت موضوع‌ها ناموفق بود",
+	"Message not found in this topic":                       "پیام در این موضوع یافت نشد",
+	"Failed to mark topic as read":                          "علامت‌گذاری موضوع به‌عنوان خوانده‌شده ناموفق بود",
+	"Conversation type must be direct, group or channel":    "نوع گفتگو باید direct، group یا channel باشد",
+	"muted_until must be in the future":                     "muted_until باید در آینده باشد",
+	"until must be in the future":                           "until باید در آینده باشد",
+	"Conversation is not muted":                             "این گفتگو بی‌صدا نیست",
+	"Failed to mute conversation":                           "بی‌صدا کردن گفتگو ناموفق بود",
+	"Failed to unmute conversation":                         "باصدا کردن گفتگو ناموفق بود",
+	"Failed to get mutes":                                   "دریافت گفتگوهای بی‌صدا ناموفق بود",
+	"Failed to turn on do not disturb":                      "روشن کردن حالت مزاحم نشوید ناموفق بود",
+	"Failed to turn off do not disturb":                     "خاموش کردن حالت مزاحم نشوید ناموفق بود",
+	"Failed to get do not disturb":                          "دریافت حالت مزاحم نشوید ناموفق بود",
+	"Format must be ndjson or csv":                          "قالب باید ndjson یا csv باشد",
+	"Only channel owners and admins can view the audit log": "فقط مالک و مدیران کانال می‌توانند گزارش رویدادها را ببینند",
+	"Account is suspended":                                  "حساب کاربری تعلیق شده است",
+	"Account is not suspended":                              "حساب کاربری تعلیق نشده است",
+	"You cannot suspend your own account":                   "نمی‌توانید حساب خودتان را تعلیق کنید",
+	"Exactly one of address, phone or username is required": "دقیقاً یکی از address، phone یا username لازم است",
+	"Failed to suspend account":                             "تعلیق حساب ناموفق بود",
+	"Failed to lift suspension":                             "لغو تعلیق ناموفق بود",
+	"Failed to get suspension":                              "دریافت وضعیت تعلیق ناموفق بود",
+	"Failed to purge messages":                              "پاک‌سازی پیام‌ها ناموفق بود",
+	"invalid admin API key":                                 "کلید API مدیریت نامعتبر است",
+	"Failed to get audit log":                               "دریافت گزارش رویدادها ناموفق بود",
+
+	// Polls
+	"conversation_type must be group or channel, and conversation_id and message_id are required": "conversation_type باید group یا channel باشد و conversation_id و message_id الزامی است",
+	"Question is required and must be at most 300 characters":                                     "پرسش الزامی است و حداکثر ۳۰۰ نویسه است",
+	"A poll must have between 2 and 10 options":                                                   "نظرسنجی باید بین ۲ تا ۱۰ گزینه داشته باشد",
+	"Options are required and must be at most 100 characters":                                     "گزینه‌ها الزامی است و هر کدام حداکثر ۱۰۰ نویسه است",
+	"Only the message's sender can attach a poll to it":                                           "فقط فرستنده پیام می‌تواند به آن نظرسنجی پیوست کند",
+	"This message already has a poll":                                                             "این پیام از قبل نظرسنجی دارد",
+	"Poll not found":                                                                              "نظرسنجی یافت نشد",
+	"This poll is closed":                                                                         "این نظرسنجی بسته شده است",
+	"Invalid option":                                                                              "گزینه نامعتبر است",
+	"You have already voted on this poll":                                                         "شما قبلاً در این نظرسنجی رأی داده‌اید",
+	"You haven't voted on this poll":                                                              "شما در این نظرسنجی رأی نداده‌اید",
+	"Only the poll's creator and admins can close it":                                             "فقط سازنده نظرسنجی و مدیران می‌توانند آن را ببندند",
+
+	// Sticker packs
+	"Name is required and must be at most 64 characters":                                 "نام الزامی است و حداکثر ۶۴ نویسه است",
+	"Kind must be stickers or emoji":                                                     "نوع باید stickers یا emoji باشد",
+	"Sticker pack not found":                                                             "بسته استیکر یافت نشد",
+	"Only the pack's creator can change it":                                              "فقط سازنده بسته می‌تواند آن را تغییر دهد",
+	"Custom emoji need a shortcode of 2 to 32 lowercase letters, digits and underscores": "ایموجی سفارشی به کد کوتاهی از ۲ تا ۳۲ حرف کوچک، رقم و زیرخط نیاز دارد",
+	"Emoji must be at most 32 characters":                                                "ایموجی حداکثر ۳۲ نویسه است",
+	"Stickers must be images":                                                            "استیکرها باید تصویر باشند",
+	"This sticker pack is full":                                                          "این بسته استیکر پر است",
+	"This pack already has an emoji with that shortcode":                                 "این بسته از قبل ایموجی با این کد کوتاه دارد",
+	"Sticker not found":                                                                  "استیکر یافت نشد",
+	"Invalid sticker_id":                                                                 "استیکر نامعتبر است",
+
+	// Confirmations
+	"Avatar set as active":               "تصویر پروفایل فعال شد",
+	"Avatar deleted successfully":        "تصویر پروفایل حذف شد",
+	"Channel deleted":                    "کانال حذف شد",
+	"Member added to channel":            "عضو به کانال اضافه شد",
+	"Member removed from channel":        "عضو از کانال حذف شد",
+	"Member role updated":                "نقش عضو به‌روزرسانی شد",
+	"Message deleted":                    "پیام حذف شد",
+	"Sticker pack deleted":               "بسته استیکر حذف شد",
+	"Contact deleted successfully":       "مخاطب حذف شد",
+	"Conversation unmuted":               "گفتگو باصدا شد",
+	"Suspension lifted":                  "تعلیق لغو شد",
+	"Do not disturb turned off":          "حالت مزاحم نشوید خاموش شد",
+	"Session signed out":                 "نشست خارج شد",
+	"Other sessions signed out":          "نشست‌های دیگر خارج شدند",
+	"Session wiped successfully":         "نشست پاک شد",
+	"PIN set successfully":               "رمز حساب تنظیم شد",
+	"PIN removed successfully":           "رمز حساب حذف شد",
+	"Account frozen":                     "حساب مسدود شد",
+	"Username set successfully":          "نام کاربری ثبت شد",
+	"Invite revoked":                     "دعوت لغو شد",
+	"Report updated successfully":        "گزارش به‌روزرسانی شد",
+	"Nickname updated successfully":      "نام مستعار به‌روزرسانی شد",
+	"Device registered successfully":     "دستگاه ثبت شد",
+	"Device unregistered successfully":   "ثبت دستگاه لغو شد",
+	"Group deleted successfully":         "گروه حذف شد",
+	"Member added successfully":          "عضو اضافه شد",
+	"Member removed successfully":        "عضو حذف شد",
+	"Group marked as read":               "گروه خوانده‌شده علامت خورد",
+	"Join request approved":              "درخواست عضویت پذیرفته شد",
+	"Join request rejected":              "درخواست عضویت رد شد",
+	"Broadcast list deleted":             "فهرست پخش حذف شد",
+	"Recipients added":                   "گیرندگان اضافه شدند",
+	"Recipient removed":                  "گیرنده حذف شد",
+	"Web view removed":                   "نمای وب حذف شد",
+	"Guest pass revoked":                 "مجوز مهمان لغو شد",
+	"Ownership transferred":              "مالکیت منتقل شد",
+	"Channel marked as read":             "کانال خوانده‌شده علامت خورد",
+	"Topic marked as read":               "موضوع خوانده‌شده علامت خورد",
+	"Legal hold released":                "نگهداری قانونی برداشته شد",
+	"OTP sent to your phone":             "کد تأیید به تلفن شما ارسال شد",
+	"OTP sent to your email":             "کد تأیید به ایمیل شما ارسال شد",
+	"OTP sent successfully":              "کد تأیید ارسال شد",
+	"Phone number verified successfully": "شماره تلفن تأیید شد",
+	"Event cancelled":                    "رویداد لغو شد",
+	// Push notifications
+	"New message":                         "پیام جدید",
+	"You have a new message":              "یک پیام جدید دارید",
+	"New group message":                   "پیام جدید گروه",
+	"You have a new message in a group":   "یک پیام جدید در گروه دارید",
+	"New channel message":                 "پیام جدید کانال",
+	"You have a new message in a channel": "یک پیام جدید در کانال دارید",
+	"Support":                             "پشتیبانی",
+	"You have a new message from support": "یک پیام جدید از پشتیبانی دارید",
+	"Signed out":                          "خارج شدید",
+	"This device was signed out remotely": "این دستگاه از راه دور از حساب خارج شد",
+	"Event starting soon":                 "رویداد به‌زودی شروع می‌شود",
+	"Your account was frozen and all devices were signed out": "حساب شما مسدود شد و همه دستگاه‌ها از حساب خارج شدند",
+	"New logins are blocked until":                            "ورود جدید تا این زمان ممکن نیست",
+}
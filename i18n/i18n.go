@@ -0,0 +1,97 @@
+// Package i18n translates the errors and system messages the server sends.
+//
+// Messages are looked up by their English text, so handlers keep writing
+// English and anything without a translation is sent in English.
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Default is the locale of the messages in the code, used when no
+// supported locale was asked for
+const Default = "en"
+
+// catalogs maps each supported locale other than Default to its
+// translations, keyed by the English message
+var catalogs = map[string]map[string]string{
+	"fa": fa,
+}
+
+// Locales lists the supported locales
+func Locales() []string {
+	locales := []string{Default}
+	for locale := range catalogs {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales[1:])
+	return locales
+}
+
+// Supported returns the supported locale matching a language tag such as
+// "fa-IR", comparing only the primary language
+func Supported(tag string) (string, bool) {
+	language, _, _ := strings.Cut(strings.TrimSpace(tag), "-")
+	language = strings.ToLower(language)
+	if language == Default {
+		return Default, true
+	}
+	if _, ok := catalogs[language]; ok {
+		return language, true
+	}
+	return "", false
+}
+
+// Negotiate picks the supported locale an Accept-Language header prefers
+// most, or Default if it names none
+func Negotiate(acceptLanguage string) string {
+	type preference struct {
+		tag     string
+		quality float64
+	}
+
+	var preferences []preference
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if quality <= 0 {
+			continue
+		}
+		preferences = append(preferences, preference{tag, quality})
+	}
+	sort.SliceStable(preferences, func(i, j int) bool {
+		return preferences[i].quality > preferences[j].quality
+	})
+
+	for _, p := range preferences {
+		if p.tag == "*" {
+			return Default
+		}
+		if locale, ok := Supported(p.tag); ok {
+			return locale
+		}
+	}
+	return Default
+}
+
+// T translates an English message to a locale, returning it unchanged when
+// there is no translation
+func T(locale, message string) string {
+	if translated, ok := catalogs[locale][message]; ok {
+		return translated
+	}
+	return message
+}
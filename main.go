@@ -1,49 +1,165 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/piko/piko/alerts"
 	"github.com/piko/piko/api"
+	"github.com/piko/piko/blockchain"
+	"github.com/piko/piko/clock"
 	"github.com/piko/piko/config"
+	"github.com/piko/piko/crypto"
 	"github.com/piko/piko/database"
 	"github.com/piko/piko/handlers"
+	"github.com/piko/piko/messagestore"
+	"github.com/piko/piko/middleware"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/plugins"
+	"github.com/piko/piko/store"
+	"github.com/piko/piko/utils"
 )
 
 func main() {
 	// Parse command line flags
 	configPath := flag.String("config", "./config/config.json", "Path to configuration file")
+	envPath := flag.String("env", ".env", "Path to an optional .env file of PIKO_* overrides")
 	flag.Parse()
 
-	// Load configuration
-	cfg, err := config.LoadConfig(*configPath)
+	// Load configuration, with PIKO_* environment variables overriding it
+	cfg, err := config.Load(*configPath, *envPath)
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
-	// Initialize database connection
+	// Connect to the database, waiting for it to come up, and bring the
+	// schema up to date before anything else, the HTTP server included
 	if err := database.Initialize(cfg.Database); err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 	defer database.Close()
 
+	// Store the consensus schedule the chain verifier checks blocks against
+	if err := blockchain.InitConsensus(context.Background(), cfg.Blockchain.Consensus); err != nil {
+		log.Fatalf("Failed to initialize consensus params: %v", err)
+	}
+
+	// Enable the plugins listed in the configuration
+	if err := plugins.Init(cfg.Plugins); err != nil {
+		log.Fatalf("Failed to initialize plugins: %v", err)
+	}
+	if names := plugins.Enabled(); len(names) > 0 {
+		log.Printf("Plugins enabled: %v", names)
+	}
+
+	// Mirror message writes to the storage backend being migrated to
+	if cfg.Blockchain.Shadow.Enabled {
+		store, err := messagestore.New(cfg.Blockchain)
+		if err != nil {
+			log.Fatalf("Failed to initialize shadow message storage: %v", err)
+		}
+		models.EnableMessageShadow(store, cfg.Blockchain.Shadow.ReadSampleRate)
+	}
+
+	// Read time and randomness from the system. Tests swap in their own
+	// with clock.Set and crypto.SetRandSource.
+	clock.Set(clock.System)
+	crypto.SetRandSource(crypto.SystemRand)
+
+	// Select the ID generation strategy
+	if err := utils.InitIDGenerator(cfg.IDs); err != nil {
+		log.Fatalf("Failed to initialize ID generator: %v", err)
+	}
+
+	// Set up media storage
+	if err := handlers.InitMedia(cfg.Storage, cfg.Media); err != nil {
+		log.Fatalf("Failed to initialize media storage: %v", err)
+	}
+
+	// Apply message content and attachment limits
+	handlers.InitMessaging(cfg.Messaging)
+
+	// Check OTP codes can be generated before anyone asks for one
+	if err := utils.ValidateOTPFormat(cfg.Auth.OTPLength, cfg.Auth.OTPCharset); err != nil {
+		log.Fatalf("Invalid OTP configuration: %v", err)
+	}
+
+	// Reserve test phones for load testing
+	handlers.InitTestPhones(cfg.Auth)
+	if cfg.Auth.TestPhonePrefix != "" && cfg.Auth.TestPhoneCode != "" {
+		log.Printf("Warning: phones starting with %s sign in with a fixed OTP", cfg.Auth.TestPhonePrefix)
+	}
+
+	// Apply the registration challenge
+	handlers.InitRegistrationChallenge(cfg.Auth.RegistrationChallenge)
+
+	// Apply registration age checks
+	handlers.InitAgeGate(cfg.AgeGate)
+
+	// Apply the secret chat proof-of-work difficulty
+	handlers.InitSecretChat(cfg.SecretChat)
+
+	// Configure the key directory
+	handlers.InitKeys(cfg.Keys)
+
+	// Configure group, channel and storage quotas
+	handlers.InitQuotas(cfg.Quotas)
+	middleware.InitQuotas(cfg.Quotas)
+
+	// Configure when reported public channels are throttled
+	handlers.InitModeration(cfg.Moderation)
+
+	// Configure public channel web views
+	handlers.InitWebView(cfg.WebView)
+
+	// Give the configured operators the admin role
+	handlers.InitAdmin(cfg.Admin)
+	middleware.InitAdmin(cfg.Admin)
+	if err := models.PromoteAdmins(context.Background(), cfg.Admin.Phones); err != nil {
+		log.Fatalf("Failed to promote admins: %v", err)
+	}
+
+	// Create the support bot account that replies to support tickets
+	if err := handlers.InitSupport(cfg.Crypto); err != nil {
+		log.Fatalf("Failed to initialize support: %v", err)
+	}
+
+	// Request bodies must fit at least one media chunk
+	bodyLimit := fiber.DefaultBodyLimit
+	if chunkLimit := int(cfg.Media.ChunkSize) + 1024*1024; chunkLimit > bodyLimit {
+		bodyLimit = chunkLimit
+	}
+
 	// Create a new Fiber instance
 	app := fiber.New(fiber.Config{
 		AppName:      "Piko Decentralized Messaging",
 		ErrorHandler: api.ErrorHandler,
+		BodyLimit:    bodyLimit,
 	})
 
 	// Register middleware
 	app.Use(recover.New())
+
+	// Answer liveness and readiness probes ahead of the access log, which
+	// they would otherwise flood
+	app.Get("/healthz", handlers.Healthz)
+	app.Get("/readyz", handlers.Readyz)
+
 	app.Use(logger.New())
+	app.Use(middleware.SecurityHeaders())
+	app.Use(middleware.QueryTimeout(cfg.Database.QueryTimeout))
+	app.Use(middleware.Locale())
+	app.Use(middleware.QuotaWarnings())
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     cfg.CORS.AllowOrigins,
 		AllowMethods:     cfg.CORS.AllowMethods,
@@ -52,12 +168,58 @@ func main() {
 		MaxAge:           cfg.CORS.MaxAge,
 	}))
 
-	// Register API routes
-	api.RegisterRoutes(app)
+	// Expose Prometheus metrics and time every request
+	if cfg.Metrics.Enabled {
+		app.Use(middleware.Metrics())
+		app.Get("/metrics", handlers.Metrics(cfg.Metrics))
+	}
+
+	// Set up request rate limiting
+	if err := middleware.InitRateLimit(cfg.RateLimit, cfg.Redis); err != nil {
+		log.Fatalf("Failed to initialize rate limiting: %v", err)
+	}
+
+	// Refuse denied and banned client IPs
+	if err := middleware.InitIPFilter(cfg.IPFilter); err != nil {
+		log.Fatalf("Failed to initialize IP filtering: %v", err)
+	}
+	app.Use(middleware.FilterIPs())
+
+	// Apply WebSocket send queue and keepalive settings, and relay
+	// WebSocket messages between instances
+	if err := handlers.InitWebSockets(cfg.Server, cfg.Redis); err != nil {
+		log.Fatalf("Failed to initialize WebSockets: %v", err)
+	}
+
+	// Set up push notification providers
+	handlers.InitNotifications(cfg.Notifications)
+
+	// Register API routes, reading and writing users and messages through
+	// MySQL
+	api.NewServer(cfg, store.SQL()).RegisterRoutes(app)
 
 	// Start the cleanup routine for expired secret chats
 	go handlers.CleanupExpiredSecretChats()
 
+	// Start the purge routine for expired direct messages
+	go handlers.PurgeExpiredMessages(cfg.Messaging.ExpiryInterval)
+
+	// Start the pruning routine for channel reach acks
+	go handlers.PruneChannelReach(cfg.Messaging.ChannelReachWindow)
+
+	// Start the reconciliation routine for group and channel counters
+	go handlers.ReconcileCounters(cfg.Database.CounterReconcileInterval)
+
+	// Start the routine announcing group events and sending their reminders
+	go handlers.RunGroupEvents(cfg.GroupEvents)
+
+	// Measure message delivery times and alert operators when they degrade
+	handlers.WebSocketPool.TrackDeliveries(cfg.DeliverySLA.Enabled)
+	go handlers.RunDeliverySLA(cfg.DeliverySLA, alerts.NewService(cfg.Alerts))
+
+	// Start the maintenance routine for message table partitions
+	go database.RunPartitionMaintenance(cfg.Database.Partitioning)
+
 	// Start the server in a goroutine
 	go func() {
 		addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
@@ -72,6 +234,11 @@ func main() {
 	<-quit
 
 	log.Println("Shutting down server...")
+
+	// Ask WebSocket clients to reconnect elsewhere before we go away
+	handlers.DrainWebSockets(cfg.Server.ReconnectJitter)
+	time.Sleep(cfg.Server.DrainTimeout)
+
 	if err := app.Shutdown(); err != nil {
 		log.Fatalf("Server shutdown failed: %v", err)
 	}
@@ -0,0 +1,137 @@
+// Package messagestore implements the backend messages are being migrated
+// to. While shadow mode is on, models mirrors every direct message write to
+// it and compares a sample of reads.
+package messagestore
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/piko/piko/config"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/storage"
+	"github.com/piko/piko/types"
+)
+
+// New creates the shadow message store selected by StorageType
+func New(cfg config.BlockchainConfig) (models.MessageShadowStore, error) {
+	switch cfg.StorageType {
+	case "local":
+		backend, err := storage.NewLocalBackend(filepath.Join(cfg.DataDir, "messages"))
+		if err != nil {
+			return nil, err
+		}
+		return NewObjectStore(backend), nil
+	case "badger":
+		return nil, fmt.Errorf("badger message storage is not built into this server, use storageType \"local\"")
+	default:
+		return nil, fmt.Errorf("unsupported message storage type: %s", cfg.StorageType)
+	}
+}
+
+// ObjectStore keeps each message as a JSON object in a storage backend
+type ObjectStore struct {
+	backend storage.Backend
+}
+
+// NewObjectStore creates a message store on top of a storage backend
+func NewObjectStore(backend storage.Backend) *ObjectStore {
+	return &ObjectStore{backend: backend}
+}
+
+// record is the stored form of a message. Unlike models.Message it keeps
+// every column, including the sender session.
+type record struct {
+	ID               string      `json:"id"`
+	SenderAddress    string      `json:"sender_address"`
+	RecipientAddress string      `json:"recipient_address"`
+	EncryptedContent []byte      `json:"encrypted_content"`
+	Timestamp        types.Time  `json:"timestamp"`
+	Status           string      `json:"status"`
+	ExpirationTime   *types.Time `json:"expiration_time,omitempty"`
+	BlockID          *string     `json:"block_id,omitempty"`
+	EditedAt         *types.Time `json:"edited_at,omitempty"`
+	ReplyToMessageID *string     `json:"reply_to_message_id,omitempty"`
+	ForwardedFrom    *string     `json:"forwarded_from,omitempty"`
+	SenderSessionID  *string     `json:"sender_session_id,omitempty"`
+	StickerID        *string     `json:"sticker_id,omitempty"`
+}
+
+// key maps a message ID to its object key
+func key(id string) string {
+	return "messages/" + id + ".json"
+}
+
+// PutMessage stores or replaces a message
+func (s *ObjectStore) PutMessage(message *models.Message) error {
+	data, err := json.Marshal(record{
+		ID:               message.ID,
+		SenderAddress:    message.SenderAddress,
+		RecipientAddress: message.RecipientAddress,
+		EncryptedContent: message.EncryptedContent,
+		Timestamp:        message.Timestamp,
+		Status:           string(message.Status),
+		ExpirationTime:   message.ExpirationTime,
+		BlockID:          message.BlockID,
+		EditedAt:         message.EditedAt,
+		ReplyToMessageID: message.ReplyToMessageID,
+		ForwardedFrom:    message.ForwardedFrom,
+		SenderSessionID:  message.SenderSessionID,
+		StickerID:        message.StickerID,
+	})
+	if err != nil {
+		return err
+	}
+	return s.backend.Put(key(message.ID), bytes.NewReader(data), int64(len(data)), "application/json")
+}
+
+// GetMessage retrieves a message, returning models.ErrMessageNotFound if
+// the store doesn't have it
+func (s *ObjectStore) GetMessage(id string) (*models.Message, error) {
+	object, err := s.backend.Get(key(id))
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			return nil, models.ErrMessageNotFound
+		}
+		return nil, err
+	}
+	defer object.Close()
+
+	data, err := io.ReadAll(object)
+	if err != nil {
+		return nil, err
+	}
+	var r record
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, err
+	}
+
+	return &models.Message{
+		ID:               r.ID,
+		SenderAddress:    r.SenderAddress,
+		RecipientAddress: r.RecipientAddress,
+		EncryptedContent: r.EncryptedContent,
+		Timestamp:        r.Timestamp,
+		Status:           models.MessageStatus(r.Status),
+		ExpirationTime:   r.ExpirationTime,
+		BlockID:          r.BlockID,
+		EditedAt:         r.EditedAt,
+		ReplyToMessageID: r.ReplyToMessageID,
+		ForwardedFrom:    r.ForwardedFrom,
+		SenderSessionID:  r.SenderSessionID,
+		StickerID:        r.StickerID,
+	}, nil
+}
+
+// DeleteMessage removes a message
+func (s *ObjectStore) DeleteMessage(id string) error {
+	err := s.backend.Delete(key(id))
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return nil
+	}
+	return err
+}
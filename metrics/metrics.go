@@ -0,0 +1,351 @@
+// Package metrics keeps counters, gauges and histograms and writes them in
+// the Prometheus text exposition format. Metrics are declared as package
+// variables next to the code they measure and registered on creation.
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are histogram upper bounds in seconds suited to request and
+// database latencies
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// collector is a metric family that can write itself
+type collector interface {
+	metricName() string
+	write(buf *bytes.Buffer)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]collector{}
+)
+
+// register adds a metric family. Registering a name again replaces the
+// earlier family.
+func register(c collector) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[c.metricName()] = c
+}
+
+// Write writes every registered metric in the Prometheus text format,
+// sorted by name
+func Write(w io.Writer) error {
+	registryMu.RLock()
+	collectors := make([]collector, 0, len(registry))
+	for _, c := range registry {
+		collectors = append(collectors, c)
+	}
+	registryMu.RUnlock()
+
+	sort.Slice(collectors, func(i, j int) bool {
+		return collectors[i].metricName() < collectors[j].metricName()
+	})
+
+	var buf bytes.Buffer
+	for _, c := range collectors {
+		c.write(&buf)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// writeHeader writes the HELP and TYPE lines of a family
+func writeHeader(buf *bytes.Buffer, name, help, typ string) {
+	help = strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+	fmt.Fprintf(buf, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// writeSample writes one sample line
+func writeSample(buf *bytes.Buffer, name string, labels, values []string, value float64) {
+	buf.WriteString(name)
+	if len(labels) > 0 {
+		buf.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(label)
+			buf.WriteString(`="`)
+			buf.WriteString(escapeLabelValue(values[i]))
+			buf.WriteByte('"')
+		}
+		buf.WriteByte('}')
+	}
+	buf.WriteByte(' ')
+	buf.WriteString(formatValue(value))
+	buf.WriteByte('\n')
+}
+
+// escapeLabelValue escapes a label value for the text format
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// formatValue formats a sample value for the text format
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// seriesKey identifies the series of a family by its label values
+func seriesKey(labels, values []string) string {
+	if len(values) != len(labels) {
+		panic(fmt.Sprintf("metrics: got %d label values for %d labels", len(values), len(labels)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// sortedKeys returns the keys of a series map in a stable order
+func sortedKeys[T any](series map[string]T) []string {
+	keys := make([]string, 0, len(series))
+	for key := range series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// CounterVec is a family of counters partitioned by labels
+type CounterVec struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	series map[string]*counterSeries
+}
+
+type counterSeries struct {
+	values []string
+	value  float64
+}
+
+// NewCounterVec creates and registers a counter family. A family without
+// labels is a single counter.
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{name: name, help: help, labels: labels, series: map[string]*counterSeries{}}
+	register(c)
+	return c
+}
+
+// Inc adds one to the counter with the given label values
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add adds a non-negative amount to the counter with the given label values
+func (c *CounterVec) Add(amount float64, values ...string) {
+	if amount < 0 {
+		panic("metrics: counters can't decrease")
+	}
+	key := seriesKey(c.labels, values)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.series[key]
+	if !ok {
+		s = &counterSeries{values: append([]string(nil), values...)}
+		c.series[key] = s
+	}
+	s.value += amount
+}
+
+func (c *CounterVec) metricName() string { return c.name }
+
+func (c *CounterVec) write(buf *bytes.Buffer) {
+	writeHeader(buf, c.name, c.help, "counter")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range sortedKeys(c.series) {
+		s := c.series[key]
+		writeSample(buf, c.name, c.labels, s.values, s.value)
+	}
+}
+
+// Gauge is a single value that can go up and down
+type Gauge struct {
+	name string
+	help string
+
+	mu    sync.Mutex
+	value float64
+}
+
+// NewGauge creates and registers a gauge
+func NewGauge(name, help string) *Gauge {
+	g := &Gauge{name: name, help: help}
+	register(g)
+	return g
+}
+
+// Set sets the gauge to a value
+func (g *Gauge) Set(value float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.value = value
+}
+
+func (g *Gauge) metricName() string { return g.name }
+
+func (g *Gauge) write(buf *bytes.Buffer) {
+	writeHeader(buf, g.name, g.help, "gauge")
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	writeSample(buf, g.name, nil, nil, g.value)
+}
+
+// GaugeVec is a family of gauges partitioned by labels
+type GaugeVec struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	series map[string]*counterSeries
+}
+
+// NewGaugeVec creates and registers a gauge family
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{name: name, help: help, labels: labels, series: map[string]*counterSeries{}}
+	register(g)
+	return g
+}
+
+// Set sets the gauge with the given label values
+func (g *GaugeVec) Set(value float64, values ...string) {
+	key := seriesKey(g.labels, values)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	s, ok := g.series[key]
+	if !ok {
+		s = &counterSeries{values: append([]string(nil), values...)}
+		g.series[key] = s
+	}
+	s.value = value
+}
+
+func (g *GaugeVec) metricName() string { return g.name }
+
+func (g *GaugeVec) write(buf *bytes.Buffer) {
+	writeHeader(buf, g.name, g.help, "gauge")
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, key := range sortedKeys(g.series) {
+		s := g.series[key]
+		writeSample(buf, g.name, g.labels, s.values, s.value)
+	}
+}
+
+// funcMetric is a gauge or counter whose value is read when metrics are
+// written, for values kept elsewhere like connection counts
+type funcMetric struct {
+	name  string
+	help  string
+	typ   string
+	value func() float64
+}
+
+// NewGaugeFunc creates and registers a gauge that reports the result of fn
+func NewGaugeFunc(name, help string, fn func() float64) {
+	register(&funcMetric{name: name, help: help, typ: "gauge", value: fn})
+}
+
+// NewCounterFunc creates and registers a counter that reports the result of
+// fn, which must never decrease
+func NewCounterFunc(name, help string, fn func() float64) {
+	register(&funcMetric{name: name, help: help, typ: "counter", value: fn})
+}
+
+func (f *funcMetric) metricName() string { return f.name }
+
+func (f *funcMetric) write(buf *bytes.Buffer) {
+	writeHeader(buf, f.name, f.help, f.typ)
+	writeSample(buf, f.name, nil, nil, f.value())
+}
+
+// HistogramVec is a family of histograms partitioned by labels
+type HistogramVec struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	values []string
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec creates and registers a histogram family with the given
+// bucket upper bounds, which must be sorted
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{name: name, help: help, labels: labels, buckets: buckets, series: map[string]*histogramSeries{}}
+	register(h)
+	return h
+}
+
+// Observe records a value in the histogram with the given label values
+func (h *HistogramVec) Observe(value float64, values ...string) {
+	key := seriesKey(h.labels, values)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{values: append([]string(nil), values...), counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	if i := sort.SearchFloat64s(h.buckets, value); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += value
+}
+
+func (h *HistogramVec) metricName() string { return h.name }
+
+func (h *HistogramVec) write(buf *bytes.Buffer) {
+	writeHeader(buf, h.name, h.help, "histogram")
+	bucketLabels := append(append([]string(nil), h.labels...), "le")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		bucketValues := append(append([]string(nil), s.values...), "")
+
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += s.counts[i]
+			bucketValues[len(bucketValues)-1] = formatValue(bound)
+			writeSample(buf, h.name+"_bucket", bucketLabels, bucketValues, float64(cumulative))
+		}
+		bucketValues[len(bucketValues)-1] = "+Inf"
+		writeSample(buf, h.name+"_bucket", bucketLabels, bucketValues, float64(s.count))
+		writeSample(buf, h.name+"_sum", h.labels, s.values, s.sum)
+		writeSample(buf, h.name+"_count", h.labels, s.values, float64(s.count))
+	}
+}
@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/config"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/utils"
+)
+
+// ErrAdminRequired is returned when a non-admin calls an admin route
+var ErrAdminRequired = errors.New("admin role required")
+
+// ErrInvalidAdminKey is returned for an admin API key that isn't configured
+var ErrInvalidAdminKey = errors.New("invalid admin API key")
+
+// AdminKeyHeader is the header operator tooling sends its admin API key in
+const AdminKeyHeader = "X-Admin-Key"
+
+// adminKeyHashes are the SHA-256 hashes of the configured admin API keys.
+// Comparing hashes keeps the comparison constant time whatever the length
+// of the key sent.
+var adminKeyHashes [][sha256.Size]byte
+
+// InitAdmin configures the admin API keys
+func InitAdmin(cfg config.AdminConfig) {
+	adminKeyHashes = nil
+	for _, key := range cfg.APIKeys {
+		if key != "" {
+			adminKeyHashes = append(adminKeyHashes, sha256.Sum256([]byte(key)))
+		}
+	}
+}
+
+// AdminRequired is a middleware that only lets users with the admin role
+// through. It must run after AuthRequired.
+func AdminRequired() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if rejected, err := rejectNonAdmin(c); rejected {
+			return err
+		}
+
+		return c.Next()
+	}
+}
+
+// OperatorRequired is a middleware for the operator endpoints of the admin
+// API. It lets through requests with a configured admin API key, and
+// otherwise requires the token of a user with the admin role.
+func OperatorRequired(cfg *config.Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if key := c.Get(AdminKeyHeader); key != "" {
+			keyID, ok := adminKeyID(key)
+			if !ok {
+				return utils.ErrorResponse(c, fiber.StatusUnauthorized, utils.CodeUnauthorized, ErrInvalidAdminKey.Error())
+			}
+			c.Locals("admin_key_id", keyID)
+			return c.Next()
+		}
+
+		if rejected, err := rejectUnauthenticated(c, cfg); rejected {
+			return err
+		}
+		if rejected, err := rejectNonAdmin(c); rejected {
+			return err
+		}
+
+		return c.Next()
+	}
+}
+
+// GetOperator returns who is calling an operator endpoint: the admin's
+// address, or the ID of the admin API key used
+func GetOperator(c *fiber.Ctx) string {
+	if keyID, ok := c.Locals("admin_key_id").(string); ok {
+		return keyID
+	}
+	address, _ := GetUserAddress(c)
+	return address
+}
+
+// adminKeyID checks a key against the configured admin API keys. A valid
+// key is identified by the start of its hash, so audit entries can tell
+// keys apart without storing them.
+func adminKeyID(key string) (string, bool) {
+	hash := sha256.Sum256([]byte(key))
+	valid := 0
+	for _, configured := range adminKeyHashes {
+		valid |= subtle.ConstantTimeCompare(hash[:], configured[:])
+	}
+	if valid == 0 {
+		return "", false
+	}
+	return "api_key:" + hex.EncodeToString(hash[:4]), true
+}
+
+// rejectNonAdmin writes an error response unless the signed in user has
+// the admin role
+func rejectNonAdmin(c *fiber.Ctx) (bool, error) {
+	userID, ok := GetUserID(c)
+	if !ok {
+		return true, utils.UnauthorizedResponse(c)
+	}
+
+	// Look the role up on every request so demotions apply immediately
+	user, err := models.GetUserByID(c.UserContext(), userID)
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			return true, utils.UnauthorizedResponse(c)
+		}
+		return true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to get user")
+	}
+	if user.Role != models.UserRoleAdmin {
+		return true, utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeAdminRequired, ErrAdminRequired.Error())
+	}
+	return false, nil
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -10,6 +11,7 @@ import (
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/piko/piko/config"
 	"github.com/piko/piko/models"
+	"github.com/piko/piko/utils"
 )
 
 var (
@@ -21,20 +23,24 @@ var (
 	ErrInvalidToken = errors.New("invalid token")
 	// ErrTokenExpired is returned when the JWT token is expired
 	ErrTokenExpired = errors.New("token expired")
+	// ErrSessionRevoked is returned when the token's session has been revoked
+	ErrSessionRevoked = errors.New("session revoked")
 )
 
 // JWTClaims represents the claims in a JWT token
 type JWTClaims struct {
-	UserID  int    `json:"user_id"`
-	Address string `json:"address"`
+	UserID    int    `json:"user_id"`
+	Address   string `json:"address"`
+	SessionID string `json:"session_id,omitempty"`
 	jwt.StandardClaims
 }
 
-// GenerateJWT generates a new JWT token for a user
-func GenerateJWT(user *models.User, secret string, expirationTime time.Duration) (string, error) {
+// GenerateJWT generates a new JWT token for a user session
+func GenerateJWT(user *models.User, sessionID string, secret string, expirationTime time.Duration) (string, error) {
 	claims := JWTClaims{
-		UserID:  user.ID,
-		Address: user.Address,
+		UserID:    user.ID,
+		Address:   user.Address,
+		SessionID: sessionID,
 		StandardClaims: jwt.StandardClaims{
 			ExpiresAt: time.Now().Add(expirationTime).Unix(),
 			IssuedAt:  time.Now().Unix(),
@@ -47,67 +53,133 @@ func GenerateJWT(user *models.User, secret string, expirationTime time.Duration)
 	return token.SignedString([]byte(secret))
 }
 
+// parseToken validates the bearer token in an Authorization header
+func parseToken(authHeader string, secret string) (*JWTClaims, error) {
+	if authHeader == "" {
+		return nil, ErrNoAuthHeader
+	}
+
+	// Check if the header is in the correct format
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return nil, ErrInvalidAuthHeader
+	}
+
+	// Parse the token
+	tokenString := parts[1]
+	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
+		// Validate the signing method
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(secret), nil
+	})
+
+	// Check for parsing errors
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrTokenExpired
+		}
+		return nil, ErrInvalidToken
+	}
+
+	// Check if the token is valid
+	if !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	// Get the claims
+	claims, ok := token.Claims.(*JWTClaims)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
 // AuthRequired is a middleware that checks if the user is authenticated
 func AuthRequired(cfg *config.Config) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		// Get the authorization header
-		authHeader := c.Get("Authorization")
-		if authHeader == "" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": ErrNoAuthHeader.Error(),
-			})
+		if rejected, err := rejectUnauthenticated(c, cfg); rejected {
+			return err
 		}
 
-		// Check if the header is in the correct format
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": ErrInvalidAuthHeader.Error(),
-			})
+		// Continue to the next middleware/handler
+		return c.Next()
+	}
+}
+
+// AuthenticateToken validates a token passed outside the Authorization
+// header, such as in a WebSocket URL, with the same signature, expiry and
+// session checks as AuthRequired
+func AuthenticateToken(ctx context.Context, token string, secret string) (*JWTClaims, error) {
+	claims, err := parseToken("Bearer "+token, secret)
+	if err != nil {
+		return nil, err
+	}
+	if claims.SessionID != "" {
+		active, err := models.IsSessionActive(ctx, claims.SessionID)
+		if err != nil {
+			return nil, err
 		}
+		if !active {
+			return nil, ErrSessionRevoked
+		}
+	}
+	return claims, nil
+}
 
-		// Parse the token
-		tokenString := parts[1]
-		token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-			// Validate the signing method
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte(cfg.Auth.JWTSecret), nil
-		})
+// rejectUnauthenticated writes an error response unless the request has a
+// valid token of an active session. Otherwise it stores the claims in the
+// context.
+func rejectUnauthenticated(c *fiber.Ctx, cfg *config.Config) (bool, error) {
+	// Parse the token from the authorization header
+	claims, err := parseToken(c.Get("Authorization"), cfg.Auth.JWTSecret)
+	if err != nil {
+		return true, utils.ErrorResponse(c, fiber.StatusUnauthorized, utils.CodeUnauthorized, err.Error())
+	}
 
-		// Check for parsing errors
+	// Reject tokens whose session was revoked
+	if claims.SessionID != "" {
+		active, err := models.IsSessionActive(c.UserContext(), claims.SessionID)
 		if err != nil {
-			if errors.Is(err, jwt.ErrTokenExpired) {
-				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-					"error": ErrTokenExpired.Error(),
-				})
-			}
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": ErrInvalidToken.Error(),
-			})
+			return true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check session")
 		}
-
-		// Check if the token is valid
-		if !token.Valid {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": ErrInvalidToken.Error(),
-			})
+		if !active {
+			return true, utils.ErrorResponse(c, fiber.StatusUnauthorized, utils.CodeSessionRevoked, ErrSessionRevoked.Error())
 		}
+	}
+
+	// Users must accept updated terms and privacy policy before continuing
+	if rejected, err := rejectPendingPolicies(c, claims.UserID); rejected {
+		return true, err
+	}
 
-		// Get the claims
-		claims, ok := token.Claims.(*JWTClaims)
-		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": ErrInvalidToken.Error(),
-			})
+	// Store the claims in the context
+	c.Locals("user_id", claims.UserID)
+	c.Locals("address", claims.Address)
+	c.Locals("session_id", claims.SessionID)
+	return false, nil
+}
+
+// OptionalAuth is a middleware for routes that don't require a token but
+// behave differently for signed-in users. A valid token sets the same
+// context values as AuthRequired; a missing or invalid one is ignored.
+func OptionalAuth(cfg *config.Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, err := parseToken(c.Get("Authorization"), cfg.Auth.JWTSecret)
+		if err != nil {
+			return c.Next()
+		}
+		if claims.SessionID != "" {
+			active, err := models.IsSessionActive(c.UserContext(), claims.SessionID)
+			if err != nil || !active {
+				return c.Next()
+			}
 		}
 
-		// Store the claims in the context
 		c.Locals("user_id", claims.UserID)
 		c.Locals("address", claims.Address)
-
-		// Continue to the next middleware/handler
+		c.Locals("session_id", claims.SessionID)
 		return c.Next()
 	}
 }
@@ -118,6 +190,12 @@ func GetUserID(c *fiber.Ctx) (int, bool) {
 	return userID, ok
 }
 
+// GetSessionID gets the session ID from the context
+func GetSessionID(c *fiber.Ctx) (string, bool) {
+	sessionID, ok := c.Locals("session_id").(string)
+	return sessionID, ok && sessionID != ""
+}
+
 // GetUserAddress gets the user address from the context
 func GetUserAddress(c *fiber.Ctx) (string, bool) {
 	address, ok := c.Locals("address").(string)
@@ -0,0 +1,234 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/config"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/utils"
+)
+
+// ipSweepEvery is how many requests pass between sweeps of ended bans and
+// failure windows
+const ipSweepEvery = 1024
+
+// ipFailures counts the 401 and 429 responses an IP got in its current
+// window
+type ipFailures struct {
+	count       int
+	windowStart time.Time
+}
+
+// ipFilter holds the parsed allow and deny lists, and the bans and failure
+// counts of this instance
+type ipFilter struct {
+	cfg   config.IPFilterConfig
+	allow []netip.Prefix
+	deny  []netip.Prefix
+
+	mu       sync.Mutex
+	failures map[netip.Addr]*ipFailures
+	bans     map[netip.Addr]time.Time
+	checks   int
+}
+
+// ipFilterState is nil when IP filtering is disabled
+var ipFilterState *ipFilter
+
+// InitIPFilter parses the IP allow and deny lists and, when bans are
+// persisted, loads the bans that haven't ended
+func InitIPFilter(cfg config.IPFilterConfig) error {
+	if !cfg.Enabled {
+		ipFilterState = nil
+		return nil
+	}
+
+	filter := &ipFilter{
+		cfg:      cfg,
+		failures: make(map[netip.Addr]*ipFailures),
+		bans:     make(map[netip.Addr]time.Time),
+	}
+	var err error
+	if filter.allow, err = parsePrefixes(cfg.Allow); err != nil {
+		return fmt.Errorf("ipFilter.allow: %w", err)
+	}
+	if filter.deny, err = parsePrefixes(cfg.Deny); err != nil {
+		return fmt.Errorf("ipFilter.deny: %w", err)
+	}
+
+	if cfg.Persist {
+		bans, err := models.GetActiveIPBans(context.Background())
+		if err != nil {
+			return fmt.Errorf("failed to load IP bans: %w", err)
+		}
+		for _, ban := range bans {
+			if ip, err := netip.ParseAddr(ban.IP); err == nil {
+				filter.bans[ip] = ban.BannedUntil
+			}
+		}
+	}
+
+	ipFilterState = filter
+	return nil
+}
+
+// parsePrefixes parses CIDRs, taking a single IP as a prefix of its full
+// length
+func parsePrefixes(entries []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip, err := netip.ParseAddr(entry)
+			if err != nil {
+				return nil, err
+			}
+			ip = ip.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(ip, ip.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// FilterIPs is a middleware that refuses client IPs on the deny list or
+// temporarily banned, and bans IPs that get too many 401 and 429 responses.
+// IPs on the allow list are never refused.
+func FilterIPs() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		filter := ipFilterState
+		if filter == nil {
+			return c.Next()
+		}
+		ip, err := netip.ParseAddr(c.IP())
+		if err != nil {
+			return c.Next()
+		}
+		ip = ip.Unmap()
+
+		if matchesPrefix(filter.allow, ip) {
+			return c.Next()
+		}
+		if matchesPrefix(filter.deny, ip) {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeIPBlocked, "Requests from your network are blocked")
+		}
+		if until, banned := filter.bannedUntil(ip); banned {
+			retryAfter := int(math.Ceil(until.Sub(clock.Now()).Seconds()))
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+			return utils.ErrorResponse(c, fiber.StatusForbidden, utils.CodeIPBlocked, "Too many failed requests. Please try again later.")
+		}
+
+		err = c.Next()
+
+		// Errors returned rather than written get their status from the
+		// error handler, after this middleware
+		status := c.Response().StatusCode()
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) {
+			status = fiberErr.Code
+		}
+		if status == fiber.StatusUnauthorized || status == fiber.StatusTooManyRequests {
+			filter.recordFailure(ip)
+		}
+		return err
+	}
+}
+
+// matchesPrefix checks if an IP is in any of the prefixes
+func matchesPrefix(prefixes []netip.Prefix, ip netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// bannedUntil returns when an IP's ban ends, if it is banned
+func (f *ipFilter) bannedUntil(ip netip.Addr) (time.Time, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := clock.Now()
+	f.checks++
+	if f.checks%ipSweepEvery == 0 {
+		f.sweep(now)
+	}
+
+	until, ok := f.bans[ip]
+	if !ok || !until.After(now) {
+		return time.Time{}, false
+	}
+	return until, true
+}
+
+// recordFailure counts a 401 or 429 response, banning the IP once it
+// reaches the threshold within the window
+func (f *ipFilter) recordFailure(ip netip.Addr) {
+	if f.cfg.BanThreshold <= 0 || f.cfg.BanDuration <= 0 {
+		return
+	}
+
+	f.mu.Lock()
+	now := clock.Now()
+	failures, ok := f.failures[ip]
+	if !ok || now.Sub(failures.windowStart) >= f.cfg.BanWindow {
+		failures = &ipFailures{windowStart: now}
+		f.failures[ip] = failures
+	}
+	failures.count++
+	if failures.count < f.cfg.BanThreshold {
+		f.mu.Unlock()
+		return
+	}
+
+	until := now.Add(f.cfg.BanDuration)
+	f.bans[ip] = until
+	delete(f.failures, ip)
+	f.mu.Unlock()
+
+	log.Printf("Banned %s until %s after %d failed requests", ip, until.Format(time.RFC3339), f.cfg.BanThreshold)
+	if f.cfg.Persist {
+		go func() {
+			if err := models.SaveIPBan(context.Background(), models.IPBan{IP: ip.String(), BannedUntil: until}); err != nil {
+				log.Printf("Error saving IP ban: %v", err)
+			}
+		}()
+	}
+}
+
+// sweep forgets ended bans and failure windows. Callers hold f.mu.
+func (f *ipFilter) sweep(now time.Time) {
+	for ip, until := range f.bans {
+		if !until.After(now) {
+			delete(f.bans, ip)
+		}
+	}
+	for ip, failures := range f.failures {
+		if now.Sub(failures.windowStart) >= f.cfg.BanWindow {
+			delete(f.failures, ip)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/i18n"
+	"github.com/piko/piko/models"
+)
+
+// localeKey is the c.Locals key holding the request's locale
+const localeKey = "locale"
+
+// Locale translates the error message of JSON error responses to the
+// request's locale. Errors returned by handlers are written with the app's
+// error handler first so they're translated too.
+func Locale() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Vary(fiber.HeaderAcceptLanguage)
+
+		if err := c.Next(); err != nil {
+			if err := c.App().ErrorHandler(c, err); err != nil {
+				return err
+			}
+		}
+
+		translateError(c)
+		return nil
+	}
+}
+
+// GetLocale gets the locale of errors and system messages for the request.
+// Signed-in users who saved a supported language in their settings get it;
+// everyone else gets the best match for their Accept-Language header. The
+// result is kept in c.Locals, so only the first call reads the settings.
+func GetLocale(c *fiber.Ctx) string {
+	if locale, ok := c.Locals(localeKey).(string); ok {
+		return locale
+	}
+
+	locale := ""
+	if address, ok := GetUserAddress(c); ok {
+		if language, err := models.GetUserLanguage(c.UserContext(), address); err == nil {
+			locale, _ = i18n.Supported(language)
+		}
+	}
+	if locale == "" {
+		locale = i18n.Negotiate(c.Get(fiber.HeaderAcceptLanguage))
+	}
+
+	c.Locals(localeKey, locale)
+	return locale
+}
+
+// translateError replaces the error message of a JSON error response with
+// its translation
+func translateError(c *fiber.Ctx) {
+	if c.Response().StatusCode() < fiber.StatusBadRequest {
+		return
+	}
+	if !strings.HasPrefix(string(c.Response().Header.ContentType()), fiber.MIMEApplicationJSON) {
+		return
+	}
+
+	locale := GetLocale(c)
+	c.Set(fiber.HeaderContentLanguage, locale)
+	if locale == i18n.Default {
+		return
+	}
+
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(c.Response().Body(), &body); err != nil {
+		return
+	}
+	var message string
+	if err := json.Unmarshal(body["error"], &message); err != nil {
+		return
+	}
+	translated := i18n.T(locale, message)
+	if translated == message {
+		return
+	}
+
+	encoded, err := json.Marshal(translated)
+	if err != nil {
+		return
+	}
+	body["error"] = encoded
+	data, err := json.Marshal(body)
+	if err != nil {
+		return
+	}
+	c.Response().SetBody(data)
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/metrics"
+)
+
+var requestDuration = metrics.NewHistogramVec(
+	"piko_http_request_duration_seconds",
+	"Time spent serving HTTP requests, by route pattern and status code.",
+	metrics.DefaultBuckets,
+	"method", "route", "status",
+)
+
+// Metrics records the latency of every request. Routes are labelled by
+// their pattern, e.g. /api/messages/:id, so IDs don't create new series.
+// WebSocket upgrades are left out since their duration is the connection's.
+func Metrics() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if strings.EqualFold(c.Get(fiber.HeaderUpgrade), "websocket") {
+			return c.Next()
+		}
+
+		start := time.Now()
+		err := c.Next()
+
+		// The error handler hasn't written the response yet
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = fiber.StatusInternalServerError
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				status = fiberErr.Code
+			}
+		}
+
+		// Requests no route matched end on a catch-all middleware
+		route := c.Route().Path
+		if route == "/" {
+			route = "unmatched"
+		}
+
+		requestDuration.Observe(time.Since(start).Seconds(), c.Method(), route, strconv.Itoa(status))
+		return err
+	}
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/utils"
+)
+
+// ErrPolicyAcceptanceRequired is returned when a user must accept updated
+// terms of service or privacy policy before continuing
+var ErrPolicyAcceptanceRequired = errors.New("policy acceptance required")
+
+// policyRoutesPrefix is where users read and accept policies, which must
+// stay reachable while acceptance is pending
+const policyRoutesPrefix = "/api/policies"
+
+// rejectPendingPolicies writes a 451 response listing the current policies
+// the user still has to accept
+func rejectPendingPolicies(c *fiber.Ctx, userID int) (bool, error) {
+	if strings.HasPrefix(c.Path(), policyRoutesPrefix) {
+		return false, nil
+	}
+
+	pending, err := models.HasPendingPolicies(c.UserContext(), userID)
+	if err != nil {
+		return true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check policy acceptance")
+	}
+	if !pending {
+		return false, nil
+	}
+
+	policies, err := models.GetPendingPolicies(c.UserContext(), userID)
+	if err != nil {
+		return true, utils.ErrorResponse(c, fiber.StatusInternalServerError, utils.CodeInternal, "Failed to check policy acceptance")
+	}
+	return true, utils.ErrorResponseWith(c, fiber.StatusUnavailableForLegalReasons, utils.CodePolicyAcceptanceRequired, ErrPolicyAcceptanceRequired.Error(), fiber.Map{
+		"policies": policies,
+		"accept":   policyRoutesPrefix + "/accept",
+	})
+}
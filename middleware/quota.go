@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/config"
+)
+
+// quotaWarningsKey is the c.Locals key holding the request's quota warnings
+const quotaWarningsKey = "quotaWarnings"
+
+// quotaWarnAt is the fraction of a quota past which responses carry a warning
+var quotaWarnAt = config.DefaultConfig().Quotas.WarnAt
+
+// QuotaWarning tells a client it is close to a limit
+type QuotaWarning struct {
+	Quota     string `json:"quota"`
+	Limit     int64  `json:"limit"`
+	Used      int64  `json:"used"`
+	Remaining int64  `json:"remaining"`
+}
+
+// InitQuotas sets when quota warnings are given
+func InitQuotas(cfg config.QuotaConfig) {
+	quotaWarnAt = cfg.WarnAt
+}
+
+// ReportQuota reports how much of a quota the caller has used. The limit
+// and what remains are sent in X-Quota-<Name>-Limit and
+// X-Quota-<Name>-Remaining headers, and once used passes the warning
+// threshold a warning is added to the response. Limits of 0 or less mean
+// there is no quota and report nothing.
+func ReportQuota(c *fiber.Ctx, name string, limit, used int64) {
+	if limit <= 0 {
+		return
+	}
+	remaining := limit - used
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	header := "X-Quota-" + quotaHeaderName(name)
+	c.Set(header+"-Limit", strconv.FormatInt(limit, 10))
+	c.Set(header+"-Remaining", strconv.FormatInt(remaining, 10))
+
+	if float64(used) < quotaWarnAt*float64(limit) {
+		return
+	}
+	warnings, _ := c.Locals(quotaWarningsKey).([]QuotaWarning)
+	c.Locals(quotaWarningsKey, append(warnings, QuotaWarning{
+		Quota:     name,
+		Limit:     limit,
+		Used:      used,
+		Remaining: remaining,
+	}))
+}
+
+// quotaHeaderName turns a quota name like "group_members" into the
+// "Group-Members" part of its headers
+func quotaHeaderName(name string) string {
+	words := strings.Split(name, "_")
+	for i, word := range words {
+		if word != "" {
+			words[i] = strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return strings.Join(words, "-")
+}
+
+// QuotaWarnings adds the warnings given by ReportQuota to successful JSON
+// responses as a "warnings" array next to their data
+func QuotaWarnings() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		warnings, _ := c.Locals(quotaWarningsKey).([]QuotaWarning)
+		if len(warnings) == 0 || c.Response().StatusCode() >= fiber.StatusBadRequest {
+			return nil
+		}
+		if !strings.HasPrefix(string(c.Response().Header.ContentType()), fiber.MIMEApplicationJSON) {
+			return nil
+		}
+
+		var body map[string]json.RawMessage
+		if err := json.Unmarshal(c.Response().Body(), &body); err != nil {
+			return nil
+		}
+		encoded, err := json.Marshal(warnings)
+		if err != nil {
+			return nil
+		}
+		body["warnings"] = encoded
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil
+		}
+		c.Response().SetBody(data)
+		return nil
+	}
+}
@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/piko/piko/config"
+	"github.com/piko/piko/ratelimit"
+	"github.com/piko/piko/utils"
+)
+
+var (
+	// rateLimiter is nil when rate limiting is disabled
+	rateLimiter ratelimit.Limiter
+	rateLimits  config.RateLimitConfig
+)
+
+// InitRateLimit sets up the rate limiter used by LimitAuth, LimitMessages,
+// LimitExports, LimitReports, LimitContactDiscovery and LimitThrottledChannel
+func InitRateLimit(cfg config.RateLimitConfig, redisCfg config.RedisConfig) error {
+	rateLimits = cfg
+	if !cfg.Enabled {
+		rateLimiter = nil
+		return nil
+	}
+
+	limiter, err := ratelimit.New(cfg, redisCfg)
+	if err != nil {
+		return err
+	}
+	rateLimiter = limiter
+	return nil
+}
+
+// LimitAuth limits the auth endpoints per client IP and per phone number
+// so OTP SMS can't be abused
+func LimitAuth() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if rateLimiter == nil {
+			return c.Next()
+		}
+
+		if limited, err := checkRateLimit(c, "auth_ip", "auth:ip:"+c.IP(), rateLimits.AuthPerIP); limited {
+			return err
+		}
+
+		var body struct {
+			Phone string `json:"phone"`
+		}
+		if err := json.Unmarshal(c.Body(), &body); err == nil && body.Phone != "" {
+			phone := strings.TrimSpace(body.Phone)
+			if limited, err := checkRateLimit(c, "auth_phone", "auth:phone:"+phone, rateLimits.AuthPerPhone); limited {
+				return err
+			}
+		}
+
+		return c.Next()
+	}
+}
+
+// LimitMessages limits message sends per authenticated address. It must run
+// after AuthRequired.
+func LimitMessages() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if rateLimiter == nil {
+			return c.Next()
+		}
+
+		if address, ok := GetUserAddress(c); ok {
+			if limited, err := checkRateLimit(c, "messages", "messages:"+address, rateLimits.MessagesPerAddress); limited {
+				return err
+			}
+		}
+
+		return c.Next()
+	}
+}
+
+// LimitExports limits conversation and group exports per authenticated
+// address. It must run after AuthRequired.
+func LimitExports() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if rateLimiter == nil {
+			return c.Next()
+		}
+
+		if address, ok := GetUserAddress(c); ok {
+			if limited, err := checkRateLimit(c, "exports", "exports:"+address, rateLimits.ExportsPerAddress); limited {
+				return err
+			}
+		}
+
+		return c.Next()
+	}
+}
+
+// LimitReports limits abuse reports per authenticated address. It must run
+// after AuthRequired.
+func LimitReports() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if rateLimiter == nil {
+			return c.Next()
+		}
+
+		if address, ok := GetUserAddress(c); ok {
+			if limited, err := checkRateLimit(c, "reports", "reports:"+address, rateLimits.ReportsPerAddress); limited {
+				return err
+			}
+		}
+
+		return c.Next()
+	}
+}
+
+// LimitContactDiscovery limits address book lookups per authenticated
+// address. It must run after AuthRequired.
+func LimitContactDiscovery() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if rateLimiter == nil {
+			return c.Next()
+		}
+
+		if address, ok := GetUserAddress(c); ok {
+			if limited, err := checkRateLimit(c, "contact_discovery", "discovery:"+address, rateLimits.ContactDiscoveryPerAddress); limited {
+				return err
+			}
+		}
+
+		return c.Next()
+	}
+}
+
+// LimitThrottledChannel limits the messages sent to a channel throttled by
+// moderation, writing a 429 response when its allowance is used up
+func LimitThrottledChannel(c *fiber.Ctx, channelID string) (bool, error) {
+	if rateLimiter == nil {
+		return false, nil
+	}
+	return checkRateLimit(c, "throttled_channel", "throttled:"+channelID, rateLimits.ThrottledChannelMessages)
+}
+
+// checkRateLimit takes a token for key, reports what is left as the quota
+// name and, when none is left, writes a 429 response. Limiter failures let
+// the request through rather than locking everyone out.
+func checkRateLimit(c *fiber.Ctx, name, key string, rule config.RateLimitRule) (bool, error) {
+	result, err := rateLimiter.Allow(key, rule)
+	if err != nil {
+		log.Printf("Rate limiter failed for %s: %v", key, err)
+		return false, nil
+	}
+	if rule.Requests > 0 && rule.Interval > 0 {
+		ReportQuota(c, name, int64(rule.Requests), int64(rule.Requests-result.Remaining))
+	}
+	if result.Allowed {
+		return false, nil
+	}
+
+	retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+	return true, utils.ErrorResponse(c, fiber.StatusTooManyRequests, utils.CodeRateLimited, "Too many requests")
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/database"
+)
+
+// FreezeAccount blocks new logins to an account until a time. Freezing a
+// frozen account never shortens the freeze.
+func FreezeAccount(ctx context.Context, userAddress string, until time.Time) error {
+	_, err := database.DB.ExecContext(ctx,
+		`INSERT INTO account_freezes (user_address, frozen_until) VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE frozen_until = GREATEST(frozen_until, VALUES(frozen_until)), frozen_at = CURRENT_TIMESTAMP`,
+		userAddress, until,
+	)
+	return err
+}
+
+// GetAccountFrozenUntil returns when an account's freeze ends, or nil if it
+// isn't frozen
+func GetAccountFrozenUntil(ctx context.Context, userAddress string) (*time.Time, error) {
+	var until time.Time
+	err := database.DB.QueryRowContext(ctx,
+		"SELECT frozen_until FROM account_freezes WHERE user_address = ? AND frozen_until > ?",
+		userAddress, clock.Now(),
+	).Scan(&until)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &until, nil
+}
+
+// SetRecoveryCode stores the hash of a user's recovery code, replacing any
+// earlier code
+func SetRecoveryCode(ctx context.Context, userAddress, codeHash string) error {
+	_, err := database.DB.ExecContext(ctx,
+		`INSERT INTO recovery_codes (user_address, code_hash) VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE code_hash = VALUES(code_hash), created_at = CURRENT_TIMESTAMP`,
+		userAddress, codeHash,
+	)
+	return err
+}
+
+// HasRecoveryCode reports whether a user's recovery code has the given hash
+func HasRecoveryCode(ctx context.Context, userAddress, codeHash string) (bool, error) {
+	var exists bool
+	err := database.DB.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM recovery_codes WHERE user_address = ? AND code_hash = ?)",
+		userAddress, codeHash,
+	).Scan(&exists)
+	return exists, err
+}
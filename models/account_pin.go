@@ -0,0 +1,125 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
+)
+
+var (
+	// ErrPINNotSet is returned when a user has no account PIN
+	ErrPINNotSet = errors.New("PIN not set")
+)
+
+// AccountPIN is the passcode a user must enter, besides the OTP, when
+// signing in on a new device
+type AccountPIN struct {
+	UserAddress    string      `json:"-"`
+	Hash           string      `json:"-"`
+	FailedAttempts int         `json:"-"`
+	LockedUntil    *types.Time `json:"locked_until,omitempty"`
+	UpdatedAt      types.Time  `json:"updated_at"`
+}
+
+// Locked reports whether too many wrong PINs were entered recently
+func (p *AccountPIN) Locked(now time.Time) bool {
+	return p.LockedUntil != nil && p.LockedUntil.After(now)
+}
+
+// SetAccountPIN sets or replaces a user's PIN and clears any lockout
+func SetAccountPIN(ctx context.Context, userAddress, hash string) error {
+	_, err := database.DB.ExecContext(ctx,
+		`INSERT INTO account_pins (user_address, pin_hash) VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE pin_hash = VALUES(pin_hash), failed_attempts = 0, locked_until = NULL`,
+		userAddress, hash,
+	)
+	return err
+}
+
+// GetAccountPIN retrieves a user's PIN
+func GetAccountPIN(ctx context.Context, userAddress string) (*AccountPIN, error) {
+	pin := &AccountPIN{}
+	err := database.DB.QueryRowContext(ctx,
+		"SELECT user_address, pin_hash, failed_attempts, locked_until, updated_at FROM account_pins WHERE user_address = ?",
+		userAddress,
+	).Scan(&pin.UserAddress, &pin.Hash, &pin.FailedAttempts, &pin.LockedUntil, &pin.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPINNotSet
+		}
+		return nil, err
+	}
+	return pin, nil
+}
+
+// DeleteAccountPIN removes a user's PIN
+func DeleteAccountPIN(ctx context.Context, userAddress string) error {
+	_, err := database.DB.ExecContext(ctx, "DELETE FROM account_pins WHERE user_address = ?", userAddress)
+	return err
+}
+
+// RecordPINFailure counts a wrong PIN. Reaching maxAttempts locks the PIN
+// for lockout and starts the count again. It returns when the PIN is locked
+// until, or nil if it isn't.
+func RecordPINFailure(ctx context.Context, userAddress string, maxAttempts int, lockout time.Duration) (*time.Time, error) {
+	// locked_until is assigned first so it sees the old count
+	_, err := database.DB.ExecContext(ctx,
+		`UPDATE account_pins SET
+			locked_until = IF(failed_attempts + 1 >= ?, ?, locked_until),
+			failed_attempts = IF(failed_attempts + 1 >= ?, 0, failed_attempts + 1)
+		WHERE user_address = ?`,
+		maxAttempts, clock.Now().Add(lockout), maxAttempts, userAddress,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	pin, err := GetAccountPIN(ctx, userAddress)
+	if err != nil {
+		return nil, err
+	}
+	if !pin.Locked(clock.Now()) {
+		return nil, nil
+	}
+	lockedUntil := pin.LockedUntil.Time
+	return &lockedUntil, nil
+}
+
+// ResetPINFailures clears the count of wrong PINs after a correct one
+func ResetPINFailures(ctx context.Context, userAddress string) error {
+	_, err := database.DB.ExecContext(ctx,
+		"UPDATE account_pins SET failed_attempts = 0 WHERE user_address = ? AND failed_attempts > 0",
+		userAddress,
+	)
+	return err
+}
+
+// TrustDevice remembers a device token issued to a session, so signing in
+// again from that device doesn't ask for the PIN. The token stops working
+// when the session is signed out.
+func TrustDevice(ctx context.Context, tokenHash, userAddress, sessionID string) error {
+	_, err := database.DB.ExecContext(ctx,
+		"INSERT INTO trusted_devices (token_hash, user_address, session_id) VALUES (?, ?, ?)",
+		tokenHash, userAddress, sessionID,
+	)
+	return err
+}
+
+// IsTrustedDevice reports whether a device token belongs to an active
+// session of the user
+func IsTrustedDevice(ctx context.Context, tokenHash, userAddress string) (bool, error) {
+	var exists bool
+	err := database.DB.QueryRowContext(ctx,
+		`SELECT EXISTS(
+			SELECT 1 FROM trusted_devices t JOIN sessions s ON s.id = t.session_id
+			WHERE t.token_hash = ? AND t.user_address = ? AND s.revoked_at IS NULL
+		)`,
+		tokenHash, userAddress,
+	).Scan(&exists)
+	return exists, err
+}
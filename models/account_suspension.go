@@ -0,0 +1,67 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
+)
+
+// ErrAccountSuspensionNotFound is returned when an account is not suspended
+var ErrAccountSuspensionNotFound = errors.New("account suspension not found")
+
+// AccountSuspension blocks an account from signing in until an operator
+// lifts it
+type AccountSuspension struct {
+	UserAddress string `json:"user_address"`
+	Reason      string `json:"reason"`
+	// SuspendedBy is the admin's address, or the ID of the admin API key used
+	SuspendedBy string     `json:"suspended_by"`
+	CreatedAt   types.Time `json:"created_at"`
+}
+
+// SuspendAccount suspends an account, replacing the reason of an existing
+// suspension
+func SuspendAccount(ctx context.Context, suspension *AccountSuspension) error {
+	_, err := database.DB.ExecContext(ctx,
+		`INSERT INTO account_suspensions (user_address, reason, suspended_by) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE reason = VALUES(reason), suspended_by = VALUES(suspended_by)`,
+		suspension.UserAddress, suspension.Reason, suspension.SuspendedBy,
+	)
+	return err
+}
+
+// LiftAccountSuspension lets a suspended account sign in again
+func LiftAccountSuspension(ctx context.Context, userAddress string) error {
+	result, err := database.DB.ExecContext(ctx, "DELETE FROM account_suspensions WHERE user_address = ?", userAddress)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrAccountSuspensionNotFound
+	}
+	return nil
+}
+
+// GetAccountSuspension retrieves an account's suspension, or nil if it
+// isn't suspended
+func GetAccountSuspension(ctx context.Context, userAddress string) (*AccountSuspension, error) {
+	suspension := &AccountSuspension{}
+	err := database.DB.QueryRowContext(ctx,
+		"SELECT user_address, reason, suspended_by, created_at FROM account_suspensions WHERE user_address = ?",
+		userAddress,
+	).Scan(&suspension.UserAddress, &suspension.Reason, &suspension.SuspendedBy, &suspension.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return suspension, nil
+}
@@ -0,0 +1,183 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
+)
+
+// Audit actions
+const (
+	// AuditActionRemoteWipe is recorded when a user wipes one of their sessions
+	AuditActionRemoteWipe = "session.remote_wipe"
+	// AuditActionSessionRevoke is recorded when a user signs out one of their sessions
+	AuditActionSessionRevoke = "session.revoke"
+	// AuditActionSessionRevokeOthers is recorded when a user signs out all their other sessions
+	AuditActionSessionRevokeOthers = "session.revoke_others"
+	// AuditActionPINSet is recorded when a user sets or changes their account PIN
+	AuditActionPINSet = "pin.set"
+	// AuditActionPINRemove is recorded when a user removes their account PIN
+	AuditActionPINRemove = "pin.remove"
+	// AuditActionAccountFreeze is recorded when a user freezes their account
+	AuditActionAccountFreeze = "account.freeze"
+	// AuditActionRecoveryCodeCreate is recorded when a user creates a new recovery code
+	AuditActionRecoveryCodeCreate = "recovery_code.create"
+	// AuditActionLegalHoldPlace is recorded when an admin places a legal hold
+	AuditActionLegalHoldPlace = "legal_hold.place"
+	// AuditActionLegalHoldRelease is recorded when an admin releases a legal hold
+	AuditActionLegalHoldRelease = "legal_hold.release"
+	// AuditActionLegalHoldList is recorded when an admin lists legal holds
+	AuditActionLegalHoldList = "legal_hold.list"
+	// AuditActionLegalHoldExport is recorded when an admin downloads a compliance export
+	AuditActionLegalHoldExport = "legal_hold.export"
+	// AuditActionConversationExport is recorded when a user downloads a backup of a conversation
+	AuditActionConversationExport = "conversation.export"
+	// AuditActionGroupExport is recorded when a group admin downloads the group's history
+	AuditActionGroupExport = "group.export"
+	// AuditActionUserLookup is recorded when an operator looks up an account
+	AuditActionUserLookup = "user.lookup"
+	// AuditActionAccountSuspend is recorded when an operator suspends an account
+	AuditActionAccountSuspend = "account.suspend"
+	// AuditActionAccountUnsuspend is recorded when an operator lifts an account's suspension
+	AuditActionAccountUnsuspend = "account.unsuspend"
+	// AuditActionMessagePurge is recorded when an operator deletes every message an account sent
+	AuditActionMessagePurge = "message.purge"
+)
+
+// Audit actions of group and channel administration, recorded with the
+// conversation so its admins can review them
+const (
+	// AuditActionGroupUpdate is recorded when an admin changes a group's name, description or photo
+	AuditActionGroupUpdate = "group.update"
+	// AuditActionGroupDelete is recorded when the owner deletes a group
+	AuditActionGroupDelete = "group.delete"
+	// AuditActionGroupMemberAdd is recorded when a user joins a group or an admin adds them
+	AuditActionGroupMemberAdd = "group.member_add"
+	// AuditActionGroupMemberRemove is recorded when a member leaves a group or an admin removes them
+	AuditActionGroupMemberRemove = "group.member_remove"
+	// AuditActionGroupMemberRole is recorded when a member's role in a group changes
+	AuditActionGroupMemberRole = "group.member_role"
+	// AuditActionGroupOwnershipTransfer is recorded when the owner hands a group to another member
+	AuditActionGroupOwnershipTransfer = "group.ownership_transfer"
+	// AuditActionGroupJoinMode is recorded when an admin changes how users join a group
+	AuditActionGroupJoinMode = "group.join_mode"
+	// AuditActionGroupTopicMode is recorded when an admin turns a group's topics on or off
+	AuditActionGroupTopicMode = "group.topic_mode"
+	// AuditActionChannelUpdate is recorded when an admin changes a channel's name or visibility
+	AuditActionChannelUpdate = "channel.update"
+	// AuditActionChannelDelete is recorded when the owner deletes a channel
+	AuditActionChannelDelete = "channel.delete"
+	// AuditActionChannelMemberAdd is recorded when a user joins a channel or an admin adds them
+	AuditActionChannelMemberAdd = "channel.member_add"
+	// AuditActionChannelMemberRemove is recorded when an admin removes a member from a channel
+	AuditActionChannelMemberRemove = "channel.member_remove"
+	// AuditActionChannelMemberRole is recorded when a member's role in a channel changes
+	AuditActionChannelMemberRole = "channel.member_role"
+)
+
+// AuditEntry is a record of a security-relevant or administrative action
+type AuditEntry struct {
+	ID           int    `json:"id"`
+	ActorAddress string `json:"actor_address"`
+	Action       string `json:"action"`
+	Target       string `json:"target"`
+	IPAddress    string `json:"ip_address"`
+	Details      string `json:"details,omitempty"`
+	// ConversationType and ConversationID are the group or channel an
+	// administrative action was taken in
+	ConversationType ConversationType `json:"conversation_type,omitempty"`
+	ConversationID   string           `json:"conversation_id,omitempty"`
+	CreatedAt        types.Time       `json:"created_at"`
+}
+
+// RecordAudit appends an entry to the audit log
+func RecordAudit(ctx context.Context, entry *AuditEntry) error {
+	_, err := database.DB.ExecContext(ctx,
+		`INSERT INTO audit_log (actor_address, action, target, ip_address, details, conversation_type, conversation_id)
+		VALUES (?, ?, ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''))`,
+		entry.ActorAddress, entry.Action, entry.Target, entry.IPAddress, entry.Details, entry.ConversationType, entry.ConversationID,
+	)
+	return err
+}
+
+// auditColumns are the columns scanAuditEntry reads
+const auditColumns = `id, actor_address, action, target, ip_address, COALESCE(details, ''),
+	COALESCE(conversation_type, ''), COALESCE(conversation_id, ''), created_at`
+
+// scanAuditEntries reads the audit entries of a query selecting auditColumns
+func scanAuditEntries(rows *sql.Rows) ([]*AuditEntry, error) {
+	defer rows.Close()
+
+	entries := []*AuditEntry{}
+	for rows.Next() {
+		entry := &AuditEntry{}
+		err := rows.Scan(
+			&entry.ID, &entry.ActorAddress, &entry.Action, &entry.Target, &entry.IPAddress, &entry.Details,
+			&entry.ConversationType, &entry.ConversationID, &entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// GetConversationAuditLog retrieves the most recent administrative actions
+// taken in a group or channel, newest first
+func GetConversationAuditLog(ctx context.Context, conversationType ConversationType, conversationID string, limit, offset int) ([]*AuditEntry, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		`SELECT `+auditColumns+`
+		FROM audit_log WHERE conversation_type = ? AND conversation_id = ? ORDER BY id DESC LIMIT ? OFFSET ?`,
+		conversationType, conversationID, limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanAuditEntries(rows)
+}
+
+// GetAuditLogForActor retrieves the most recent audit entries of a user
+func GetAuditLogForActor(ctx context.Context, actorAddress string, limit int) ([]*AuditEntry, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		`SELECT `+auditColumns+`
+		FROM audit_log WHERE actor_address = ? ORDER BY id DESC LIMIT ?`,
+		actorAddress, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanAuditEntries(rows)
+}
+
+// GetAuditActionCounts counts a user's audit log entries since a time, by action
+func GetAuditActionCounts(ctx context.Context, actorAddress string, since time.Time) (map[string]int, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT action, COUNT(*) FROM audit_log WHERE actor_address = ? AND created_at >= ? GROUP BY action",
+		actorAddress, since,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var action string
+		var n int
+		if err := rows.Scan(&action, &n); err != nil {
+			return nil, err
+		}
+		counts[action] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
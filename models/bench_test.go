@@ -0,0 +1,118 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/piko/piko/database"
+	"github.com/piko/piko/database/dbtest"
+)
+
+// benchMemberCounts are the group and channel sizes membership checks are measured at
+var benchMemberCounts = []int{10, 100, 1000}
+
+// benchAddress returns the address of the i-th member of a benchmark group or channel
+func benchAddress(i int) string {
+	return fmt.Sprintf("PikoBenchMember%06d", i)
+}
+
+// seedChannel creates a channel with n members, the first being its owner
+func seedChannel(b *testing.B, n int) string {
+	b.Helper()
+	ctx := context.Background()
+
+	channel := &Channel{
+		ID:           fmt.Sprintf("bench-channel-%d", time.Now().UnixNano()),
+		Name:         "Benchmark",
+		AdminAddress: benchAddress(0),
+	}
+	if err := CreateChannel(ctx, channel); err != nil {
+		b.Fatal(err)
+	}
+	for i := 1; i < n; i++ {
+		if err := JoinChannel(ctx, channel.ID, benchAddress(i), 0); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return channel.ID
+}
+
+// seedGroup creates a group with n members, the first being its admin
+func seedGroup(b *testing.B, n int) string {
+	b.Helper()
+	ctx := context.Background()
+
+	groupID := fmt.Sprintf("bench-group-%d", time.Now().UnixNano())
+	_, err := database.DB.ExecContext(ctx,
+		"INSERT INTO chat_groups (id, name, creator_address, member_count) VALUES (?, ?, ?, ?)",
+		groupID, "Benchmark", benchAddress(0), n,
+	)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		role := GroupRoleMember
+		if i == 0 {
+			role = GroupRoleAdmin
+		}
+		_, err := database.DB.ExecContext(ctx,
+			"INSERT INTO group_members (group_id, user_address, role) VALUES (?, ?, ?)",
+			groupID, benchAddress(i), role,
+		)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	return groupID
+}
+
+func BenchmarkChannelMembershipCheck(b *testing.B) {
+	dbtest.Open(b)
+	ctx := context.Background()
+
+	for _, n := range benchMemberCounts {
+		channelID := seedChannel(b, n)
+		member := benchAddress(n - 1)
+		b.Run(fmt.Sprintf("members=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := GetChannelRole(ctx, channelID, member); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkGroupMembershipCheck measures the group handlers' membership
+// check, which loads every member and scans for the caller
+func BenchmarkGroupMembershipCheck(b *testing.B) {
+	dbtest.Open(b)
+	ctx := context.Background()
+
+	for _, n := range benchMemberCounts {
+		groupID := seedGroup(b, n)
+		member := benchAddress(n - 1)
+		b.Run(fmt.Sprintf("members=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				members, err := GetGroupMembers(ctx, groupID)
+				if err != nil {
+					b.Fatal(err)
+				}
+				isMember := false
+				for _, m := range members {
+					if m.UserAddress == member {
+						isMember = true
+						break
+					}
+				}
+				if !isMember {
+					b.Fatal("member not found")
+				}
+			}
+		})
+	}
+}
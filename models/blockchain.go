@@ -1,11 +1,14 @@
 package models
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
 )
 
 var (
@@ -27,13 +30,29 @@ const (
 	TransactionTypeChannelCreate TransactionType = "channel_create"
 	// TransactionTypeChannelJoin indicates a channel join transaction
 	TransactionTypeChannelJoin TransactionType = "channel_join"
+	// TransactionTypeGroupMessage indicates a group message transaction
+	TransactionTypeGroupMessage TransactionType = "group_message"
+	// TransactionTypeGroupCreate indicates a group creation transaction
+	TransactionTypeGroupCreate TransactionType = "group_create"
+	// TransactionTypeGroupJoin indicates a group join transaction
+	TransactionTypeGroupJoin TransactionType = "group_join"
 )
 
+// IsValidTransactionType checks if a transaction type is known
+func IsValidTransactionType(txType TransactionType) bool {
+	switch txType {
+	case TransactionTypeMessage, TransactionTypeChannelMessage, TransactionTypeChannelCreate, TransactionTypeChannelJoin,
+		TransactionTypeGroupMessage, TransactionTypeGroupCreate, TransactionTypeGroupJoin:
+		return true
+	}
+	return false
+}
+
 // Block represents a block in the blockchain
 type Block struct {
 	ID           string    `json:"id"`
 	PreviousHash *string   `json:"previous_hash,omitempty"`
-	Timestamp    time.Time `json:"timestamp"`
+	Timestamp    types.Time `json:"timestamp"`
 	MerkleRoot   string    `json:"merkle_root"`
 	Nonce        int64     `json:"nonce"`
 	Height       int       `json:"height"`
@@ -46,22 +65,22 @@ type Transaction struct {
 	BlockID   string         `json:"block_id"`
 	Type      TransactionType `json:"type"`
 	DataID    string         `json:"data_id"`
-	Timestamp time.Time      `json:"timestamp"`
+	Timestamp types.Time      `json:"timestamp"`
 }
 
 // CreateBlock creates a new block in the database
-func CreateBlock(block *Block) error {
-	_, err := database.DB.Exec(
-		"INSERT INTO blocks (id, previous_hash, merkle_root, nonce, height) VALUES (?, ?, ?, ?, ?)",
-		block.ID, block.PreviousHash, block.MerkleRoot, block.Nonce, block.Height,
+func CreateBlock(ctx context.Context, block *Block) error {
+	_, err := database.DB.ExecContext(ctx,
+		"INSERT INTO blocks (id, previous_hash, timestamp, merkle_root, nonce, height) VALUES (?, ?, ?, ?, ?, ?)",
+		block.ID, block.PreviousHash, block.Timestamp, block.MerkleRoot, block.Nonce, block.Height,
 	)
 	return err
 }
 
 // GetBlockByID retrieves a block by its ID
-func GetBlockByID(id string) (*Block, error) {
+func GetBlockByID(ctx context.Context, id string) (*Block, error) {
 	block := &Block{}
-	err := database.DB.QueryRow(
+	err := database.DB.QueryRowContext(ctx,
 		"SELECT id, previous_hash, timestamp, merkle_root, nonce, height FROM blocks WHERE id = ?",
 		id,
 	).Scan(
@@ -75,7 +94,7 @@ func GetBlockByID(id string) (*Block, error) {
 	}
 
 	// Get transactions for this block
-	transactions, err := GetTransactionsByBlockID(id)
+	transactions, err := GetTransactionsByBlockID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -85,9 +104,9 @@ func GetBlockByID(id string) (*Block, error) {
 }
 
 // GetBlockByHeight retrieves a block by its height
-func GetBlockByHeight(height int) (*Block, error) {
+func GetBlockByHeight(ctx context.Context, height int) (*Block, error) {
 	block := &Block{}
-	err := database.DB.QueryRow(
+	err := database.DB.QueryRowContext(ctx,
 		"SELECT id, previous_hash, timestamp, merkle_root, nonce, height FROM blocks WHERE height = ?",
 		height,
 	).Scan(
@@ -101,7 +120,7 @@ func GetBlockByHeight(height int) (*Block, error) {
 	}
 
 	// Get transactions for this block
-	transactions, err := GetTransactionsByBlockID(block.ID)
+	transactions, err := GetTransactionsByBlockID(ctx, block.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -110,10 +129,91 @@ func GetBlockByHeight(height int) (*Block, error) {
 	return block, nil
 }
 
+// GetRecentBlocks retrieves the most recent blocks, newest first, without
+// their transactions
+func GetRecentBlocks(ctx context.Context, limit int) ([]*Block, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT id, previous_hash, timestamp, merkle_root, nonce, height FROM blocks ORDER BY height DESC LIMIT ?",
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	blocks := []*Block{}
+	for rows.Next() {
+		block := &Block{}
+		if err := rows.Scan(&block.ID, &block.PreviousHash, &block.Timestamp, &block.MerkleRoot, &block.Nonce, &block.Height); err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, rows.Err()
+}
+
+// GetBlocksFrom retrieves up to limit blocks from a height upwards, oldest
+// first, with their transactions
+func GetBlocksFrom(ctx context.Context, height int, limit int) ([]*Block, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT id, previous_hash, timestamp, merkle_root, nonce, height FROM blocks WHERE height >= ? ORDER BY height, id LIMIT ?",
+		height, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	blocks := []*Block{}
+	byID := map[string]*Block{}
+	for rows.Next() {
+		block := &Block{Transactions: []*Transaction{}}
+		if err := rows.Scan(&block.ID, &block.PreviousHash, &block.Timestamp, &block.MerkleRoot, &block.Nonce, &block.Height); err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, block)
+		byID[block.ID] = block
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(blocks) == 0 {
+		return blocks, nil
+	}
+
+	// Load the transactions of every block in one query
+	placeholders := make([]string, len(blocks))
+	args := make([]interface{}, len(blocks))
+	for i, block := range blocks {
+		placeholders[i] = "?"
+		args[i] = block.ID
+	}
+	txRows, err := database.DB.QueryContext(ctx,
+		"SELECT hash, block_id, type, data_id, timestamp FROM transactions WHERE block_id IN ("+strings.Join(placeholders, ", ")+") ORDER BY timestamp",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer txRows.Close()
+
+	for txRows.Next() {
+		transaction := &Transaction{}
+		var txType string
+		if err := txRows.Scan(&transaction.Hash, &transaction.BlockID, &txType, &transaction.DataID, &transaction.Timestamp); err != nil {
+			return nil, err
+		}
+		transaction.Type = TransactionType(txType)
+		block := byID[transaction.BlockID]
+		block.Transactions = append(block.Transactions, transaction)
+	}
+	return blocks, txRows.Err()
+}
+
 // GetLatestBlock retrieves the latest block in the blockchain
-func GetLatestBlock() (*Block, error) {
+func GetLatestBlock(ctx context.Context) (*Block, error) {
 	block := &Block{}
-	err := database.DB.QueryRow(
+	err := database.DB.QueryRowContext(ctx,
 		"SELECT id, previous_hash, timestamp, merkle_root, nonce, height FROM blocks ORDER BY height DESC LIMIT 1",
 	).Scan(
 		&block.ID, &block.PreviousHash, &block.Timestamp, &block.MerkleRoot, &block.Nonce, &block.Height,
@@ -126,7 +226,7 @@ func GetLatestBlock() (*Block, error) {
 	}
 
 	// Get transactions for this block
-	transactions, err := GetTransactionsByBlockID(block.ID)
+	transactions, err := GetTransactionsByBlockID(ctx, block.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -136,19 +236,19 @@ func GetLatestBlock() (*Block, error) {
 }
 
 // CreateTransaction creates a new transaction in the database
-func CreateTransaction(transaction *Transaction) error {
-	_, err := database.DB.Exec(
-		"INSERT INTO transactions (hash, block_id, type, data_id) VALUES (?, ?, ?, ?)",
-		transaction.Hash, transaction.BlockID, transaction.Type, transaction.DataID,
+func CreateTransaction(ctx context.Context, transaction *Transaction) error {
+	_, err := database.DB.ExecContext(ctx,
+		"INSERT INTO transactions (hash, block_id, type, data_id, timestamp) VALUES (?, ?, ?, ?, ?)",
+		transaction.Hash, transaction.BlockID, transaction.Type, transaction.DataID, transaction.Timestamp,
 	)
 	return err
 }
 
 // GetTransactionByHash retrieves a transaction by its hash
-func GetTransactionByHash(hash string) (*Transaction, error) {
+func GetTransactionByHash(ctx context.Context, hash string) (*Transaction, error) {
 	transaction := &Transaction{}
 	var txType string
-	err := database.DB.QueryRow(
+	err := database.DB.QueryRowContext(ctx,
 		"SELECT hash, block_id, type, data_id, timestamp FROM transactions WHERE hash = ?",
 		hash,
 	).Scan(
@@ -165,8 +265,8 @@ func GetTransactionByHash(hash string) (*Transaction, error) {
 }
 
 // GetTransactionsByBlockID retrieves all transactions for a block
-func GetTransactionsByBlockID(blockID string) ([]*Transaction, error) {
-	rows, err := database.DB.Query(
+func GetTransactionsByBlockID(ctx context.Context, blockID string) ([]*Transaction, error) {
+	rows, err := database.DB.QueryContext(ctx,
 		"SELECT hash, block_id, type, data_id, timestamp FROM transactions WHERE block_id = ? ORDER BY timestamp",
 		blockID,
 	)
@@ -195,10 +295,10 @@ func GetTransactionsByBlockID(blockID string) ([]*Transaction, error) {
 }
 
 // GetTransactionsByAddress retrieves all transactions related to an address
-func GetTransactionsByAddress(address string) ([]*Transaction, error) {
+func GetTransactionsByAddress(ctx context.Context, address string) ([]*Transaction, error) {
 	// This query joins the transactions table with messages and channel_messages
 	// to find all transactions related to the given address
-	rows, err := database.DB.Query(`
+	rows, err := database.DB.QueryContext(ctx, `
 		SELECT t.hash, t.block_id, t.type, t.data_id, t.timestamp 
 		FROM transactions t
 		LEFT JOIN messages m ON t.data_id = m.id AND t.type = 'message'
@@ -233,12 +333,12 @@ func GetTransactionsByAddress(address string) ([]*Transaction, error) {
 }
 
 // GetBlockchainStats retrieves statistics about the blockchain
-func GetBlockchainStats() (map[string]interface{}, error) {
+func GetBlockchainStats(ctx context.Context) (map[string]interface{}, error) {
 	stats := make(map[string]interface{})
 
 	// Get total number of blocks
 	var blockCount int
-	err := database.DB.QueryRow("SELECT COUNT(*) FROM blocks").Scan(&blockCount)
+	err := database.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM blocks").Scan(&blockCount)
 	if err != nil {
 		return nil, err
 	}
@@ -246,14 +346,14 @@ func GetBlockchainStats() (map[string]interface{}, error) {
 
 	// Get total number of transactions
 	var txCount int
-	err = database.DB.QueryRow("SELECT COUNT(*) FROM transactions").Scan(&txCount)
+	err = database.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM transactions").Scan(&txCount)
 	if err != nil {
 		return nil, err
 	}
 	stats["transaction_count"] = txCount
 
 	// Get transaction counts by type
-	rows, err := database.DB.Query("SELECT type, COUNT(*) FROM transactions GROUP BY type")
+	rows, err := database.DB.QueryContext(ctx, "SELECT type, COUNT(*) FROM transactions GROUP BY type")
 	if err != nil {
 		return nil, err
 	}
@@ -276,7 +376,7 @@ func GetBlockchainStats() (map[string]interface{}, error) {
 
 	// Get latest block timestamp
 	var latestTimestamp time.Time
-	err = database.DB.QueryRow("SELECT timestamp FROM blocks ORDER BY height DESC LIMIT 1").Scan(&latestTimestamp)
+	err = database.DB.QueryRowContext(ctx, "SELECT timestamp FROM blocks ORDER BY height DESC LIMIT 1").Scan(&latestTimestamp)
 	if err != nil && err != sql.ErrNoRows {
 		return nil, err
 	}
@@ -285,4 +385,113 @@ func GetBlockchainStats() (map[string]interface{}, error) {
 	}
 
 	return stats, nil
-} 
\ No newline at end of file
+} 
+
+// TransactionFilter narrows a transaction listing. Zero fields match every
+// transaction.
+type TransactionFilter struct {
+	Type TransactionType
+	// From is inclusive, To is exclusive
+	From time.Time
+	To   time.Time
+}
+
+// ListBlocks retrieves a page of blocks, newest first, without their
+// transactions, along with the total number of blocks
+func ListBlocks(ctx context.Context, limit, offset int) ([]*Block, int, error) {
+	var total int
+	if err := database.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM blocks").Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT id, previous_hash, timestamp, merkle_root, nonce, height FROM blocks ORDER BY height DESC, id LIMIT ? OFFSET ?",
+		limit, offset,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	blocks := []*Block{}
+	for rows.Next() {
+		block := &Block{}
+		if err := rows.Scan(&block.ID, &block.PreviousHash, &block.Timestamp, &block.MerkleRoot, &block.Nonce, &block.Height); err != nil {
+			return nil, 0, err
+		}
+		blocks = append(blocks, block)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return blocks, total, nil
+}
+
+// BlockExists checks if a block is stored
+func BlockExists(ctx context.Context, id string) (bool, error) {
+	var exists bool
+	err := database.DB.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM blocks WHERE id = ?)",
+		id,
+	).Scan(&exists)
+	return exists, err
+}
+
+// ListBlockTransactions retrieves a page of a block's transactions, oldest
+// first, along with the block's total number of transactions
+func ListBlockTransactions(ctx context.Context, blockID string, limit, offset int) ([]*Transaction, int, error) {
+	return listTransactions(ctx, "block_id = ?", []interface{}{blockID}, "timestamp, hash", limit, offset)
+}
+
+// ListTransactions retrieves a page of transactions matching a filter,
+// newest first, along with the total number that match
+func ListTransactions(ctx context.Context, filter TransactionFilter, limit, offset int) ([]*Transaction, int, error) {
+	conditions := []string{"1 = 1"}
+	args := []interface{}{}
+	if filter.Type != "" {
+		conditions = append(conditions, "type = ?")
+		args = append(args, filter.Type)
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "timestamp >= ?")
+		args = append(args, filter.From)
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "timestamp < ?")
+		args = append(args, filter.To)
+	}
+	return listTransactions(ctx, strings.Join(conditions, " AND "), args, "timestamp DESC, hash", limit, offset)
+}
+
+// listTransactions counts the transactions matching a WHERE clause and
+// retrieves a page of them in the given order
+func listTransactions(ctx context.Context, where string, args []interface{}, orderBy string, limit, offset int) ([]*Transaction, int, error) {
+	var total int
+	if err := database.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM transactions WHERE "+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT hash, block_id, type, data_id, timestamp FROM transactions WHERE "+where+" ORDER BY "+orderBy+" LIMIT ? OFFSET ?",
+		append(args, limit, offset)...,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	transactions := []*Transaction{}
+	for rows.Next() {
+		transaction := &Transaction{}
+		var txType string
+		if err := rows.Scan(&transaction.Hash, &transaction.BlockID, &txType, &transaction.DataID, &transaction.Timestamp); err != nil {
+			return nil, 0, err
+		}
+		transaction.Type = TransactionType(txType)
+		transactions = append(transactions, transaction)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return transactions, total, nil
+}
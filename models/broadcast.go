@@ -0,0 +1,357 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
+)
+
+var (
+	// ErrBroadcastListNotFound is returned when a broadcast list doesn't
+	// exist or belongs to someone else
+	ErrBroadcastListNotFound = errors.New("broadcast list not found")
+	// ErrBroadcastListFull is returned when a broadcast list would exceed its
+	// recipient limit
+	ErrBroadcastListFull = errors.New("broadcast list is full")
+	// ErrBroadcastRecipientNotFound is returned when a user isn't on a
+	// broadcast list
+	ErrBroadcastRecipientNotFound = errors.New("broadcast recipient not found")
+	// ErrBroadcastNotFound is returned when a broadcast doesn't exist
+	ErrBroadcastNotFound = errors.New("broadcast not found")
+)
+
+// BroadcastList is a named set of recipients a user sends the same direct
+// message to. Recipients don't know about the list.
+type BroadcastList struct {
+	ID             string     `json:"id"`
+	OwnerAddress   string     `json:"owner_address"`
+	Name           string     `json:"name"`
+	RecipientCount int        `json:"recipient_count"`
+	CreatedAt      types.Time `json:"created_at"`
+	// Recipients is filled in by GetBroadcastList
+	Recipients []string `json:"recipients,omitempty"`
+}
+
+// BroadcastStatusFailed marks a delivery for which no message was created,
+// and BroadcastStatusDeleted one whose message no longer exists
+const (
+	BroadcastStatusFailed  = "failed"
+	BroadcastStatusDeleted = "deleted"
+)
+
+// BroadcastDelivery is the direct message a broadcast became for one
+// recipient
+type BroadcastDelivery struct {
+	RecipientAddress string  `json:"recipient_address"`
+	MessageID        *string `json:"message_id,omitempty"`
+	// Status is the message's status, or BroadcastStatusFailed or
+	// BroadcastStatusDeleted
+	Status string `json:"status"`
+	// Error says why no message was created
+	Error *string `json:"error,omitempty"`
+}
+
+// Broadcast is a message sent to a broadcast list
+type Broadcast struct {
+	ID         string               `json:"id"`
+	ListID     string               `json:"list_id"`
+	CreatedAt  types.Time           `json:"created_at"`
+	Deliveries []*BroadcastDelivery `json:"deliveries"`
+}
+
+// CreateBroadcastList stores a broadcast list with its first recipients
+func CreateBroadcastList(ctx context.Context, list *BroadcastList, recipients []string) error {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := clock.Now()
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO broadcast_lists (id, owner_address, name, created_at) VALUES (?, ?, ?, ?)",
+		list.ID, list.OwnerAddress, list.Name, now,
+	)
+	if err != nil {
+		return err
+	}
+	for _, recipient := range recipients {
+		_, err := tx.ExecContext(ctx,
+			"INSERT IGNORE INTO broadcast_recipients (list_id, recipient_address) VALUES (?, ?)",
+			list.ID, recipient,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	list.CreatedAt = types.NewTime(now)
+	list.Recipients = recipients
+	list.RecipientCount = len(recipients)
+	return nil
+}
+
+// broadcastListColumns are the columns scanned by scanBroadcastList
+const broadcastListColumns = `l.id, l.owner_address, l.name, l.created_at,
+	(SELECT COUNT(*) FROM broadcast_recipients r WHERE r.list_id = l.id)`
+
+// scanBroadcastList scans a row of broadcastListColumns
+func scanBroadcastList(row interface{ Scan(...any) error }) (*BroadcastList, error) {
+	list := &BroadcastList{}
+	if err := row.Scan(&list.ID, &list.OwnerAddress, &list.Name, &list.CreatedAt, &list.RecipientCount); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// GetBroadcastList retrieves one of a user's broadcast lists with its
+// recipients
+func GetBroadcastList(ctx context.Context, id, ownerAddress string) (*BroadcastList, error) {
+	list, err := scanBroadcastList(database.DB.QueryRowContext(ctx,
+		"SELECT "+broadcastListColumns+" FROM broadcast_lists l WHERE l.id = ? AND l.owner_address = ?",
+		id, ownerAddress,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrBroadcastListNotFound
+		}
+		return nil, err
+	}
+
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT recipient_address FROM broadcast_recipients WHERE list_id = ? ORDER BY added_at, recipient_address",
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list.Recipients = []string{}
+	for rows.Next() {
+		var recipient string
+		if err := rows.Scan(&recipient); err != nil {
+			return nil, err
+		}
+		list.Recipients = append(list.Recipients, recipient)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// GetBroadcastLists retrieves a user's broadcast lists, newest first
+func GetBroadcastLists(ctx context.Context, ownerAddress string) ([]*BroadcastList, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT "+broadcastListColumns+" FROM broadcast_lists l WHERE l.owner_address = ? ORDER BY l.created_at DESC",
+		ownerAddress,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lists := []*BroadcastList{}
+	for rows.Next() {
+		list, err := scanBroadcastList(rows)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, list)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return lists, nil
+}
+
+// RenameBroadcastList changes the name of one of a user's broadcast lists
+func RenameBroadcastList(ctx context.Context, id, ownerAddress, name string) error {
+	result, err := database.DB.ExecContext(ctx,
+		"UPDATE broadcast_lists SET name = ? WHERE id = ? AND owner_address = ?",
+		name, id, ownerAddress,
+	)
+	if err != nil {
+		return err
+	}
+	return broadcastListAffected(result)
+}
+
+// DeleteBroadcastList deletes one of a user's broadcast lists. The direct
+// messages sent through it are kept.
+func DeleteBroadcastList(ctx context.Context, id, ownerAddress string) error {
+	result, err := database.DB.ExecContext(ctx,
+		"DELETE FROM broadcast_lists WHERE id = ? AND owner_address = ?",
+		id, ownerAddress,
+	)
+	if err != nil {
+		return err
+	}
+	return broadcastListAffected(result)
+}
+
+// broadcastListAffected returns ErrBroadcastListNotFound if a statement
+// matched no list
+func broadcastListAffected(result sql.Result) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrBroadcastListNotFound
+	}
+	return nil
+}
+
+// AddBroadcastRecipients adds recipients to a broadcast list, skipping those
+// already on it, so that it holds at most maxRecipients, or any number if
+// maxRecipients is 0. It returns how many recipients the list has.
+func AddBroadcastRecipients(ctx context.Context, id string, recipients []string, maxRecipients int) (int, error) {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Lock the list so concurrent additions can't pass the limit
+	var locked string
+	if err := tx.QueryRowContext(ctx, "SELECT id FROM broadcast_lists WHERE id = ? FOR UPDATE", id).Scan(&locked); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, ErrBroadcastListNotFound
+		}
+		return 0, err
+	}
+
+	for _, recipient := range recipients {
+		_, err := tx.ExecContext(ctx,
+			"INSERT IGNORE INTO broadcast_recipients (list_id, recipient_address) VALUES (?, ?)",
+			id, recipient,
+		)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	var count int
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM broadcast_recipients WHERE list_id = ?", id).Scan(&count); err != nil {
+		return 0, err
+	}
+	if maxRecipients > 0 && count > maxRecipients {
+		return 0, ErrBroadcastListFull
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// RemoveBroadcastRecipient takes a recipient off a broadcast list
+func RemoveBroadcastRecipient(ctx context.Context, id, recipientAddress string) error {
+	result, err := database.DB.ExecContext(ctx,
+		"DELETE FROM broadcast_recipients WHERE list_id = ? AND recipient_address = ?",
+		id, recipientAddress,
+	)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrBroadcastRecipientNotFound
+	}
+	return nil
+}
+
+// CreateBroadcast records a message sent to a broadcast list and what it
+// became for each recipient
+func CreateBroadcast(ctx context.Context, broadcast *Broadcast) error {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := clock.Now()
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO broadcast_messages (id, list_id, created_at) VALUES (?, ?, ?)",
+		broadcast.ID, broadcast.ListID, now,
+	)
+	if err != nil {
+		return err
+	}
+	for _, delivery := range broadcast.Deliveries {
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO broadcast_deliveries (broadcast_id, recipient_address, message_id, error) VALUES (?, ?, ?, ?)",
+			broadcast.ID, delivery.RecipientAddress, delivery.MessageID, delivery.Error,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	broadcast.CreatedAt = types.NewTime(now)
+	return nil
+}
+
+// GetBroadcast retrieves a message sent to a broadcast list with the
+// current status of each recipient's copy
+func GetBroadcast(ctx context.Context, listID, id string) (*Broadcast, error) {
+	broadcast := &Broadcast{}
+	err := database.DB.QueryRowContext(ctx,
+		"SELECT id, list_id, created_at FROM broadcast_messages WHERE id = ? AND list_id = ?",
+		id, listID,
+	).Scan(&broadcast.ID, &broadcast.ListID, &broadcast.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrBroadcastNotFound
+		}
+		return nil, err
+	}
+
+	rows, err := database.DB.QueryContext(ctx,
+		`SELECT d.recipient_address, d.message_id, d.error, m.status
+		FROM broadcast_deliveries d LEFT JOIN messages m ON m.id = d.message_id
+		WHERE d.broadcast_id = ? ORDER BY d.recipient_address`,
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	broadcast.Deliveries = []*BroadcastDelivery{}
+	for rows.Next() {
+		delivery := &BroadcastDelivery{}
+		var status sql.NullString
+		if err := rows.Scan(&delivery.RecipientAddress, &delivery.MessageID, &delivery.Error, &status); err != nil {
+			return nil, err
+		}
+		switch {
+		case delivery.MessageID == nil:
+			delivery.Status = BroadcastStatusFailed
+		case !status.Valid:
+			delivery.Status = BroadcastStatusDeleted
+		default:
+			delivery.Status = status.String
+		}
+		broadcast.Deliveries = append(broadcast.Deliveries, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return broadcast, nil
+}
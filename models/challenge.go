@@ -0,0 +1,58 @@
+package models
+
+import (
+	"context"
+	"errors"
+
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
+)
+
+var (
+	// ErrChallengeInvalid is returned when a login challenge doesn't exist,
+	// has expired or was already used
+	ErrChallengeInvalid = errors.New("challenge invalid or expired")
+)
+
+// AuthChallenge is a single-use nonce a client signs to log in with its key
+type AuthChallenge struct {
+	Nonce     string     `json:"nonce"`
+	CreatedAt types.Time `json:"created_at"`
+	ExpiresAt types.Time `json:"expires_at"`
+}
+
+// CreateAuthChallenge stores a new login challenge
+func CreateAuthChallenge(ctx context.Context, challenge *AuthChallenge) error {
+	// Clear out expired challenges so the table doesn't grow unbounded
+	if _, err := database.DB.ExecContext(ctx, "DELETE FROM auth_challenges WHERE expires_at < ?", clock.Now()); err != nil {
+		return err
+	}
+
+	_, err := database.DB.ExecContext(ctx,
+		"INSERT INTO auth_challenges (nonce, expires_at) VALUES (?, ?)",
+		challenge.Nonce, challenge.ExpiresAt,
+	)
+	return err
+}
+
+// ConsumeAuthChallenge marks a challenge as used. Each challenge can only be
+// consumed once, and only before it expires.
+func ConsumeAuthChallenge(ctx context.Context, nonce string) error {
+	result, err := database.DB.ExecContext(ctx,
+		"UPDATE auth_challenges SET used = TRUE WHERE nonce = ? AND used = FALSE AND expires_at > ?",
+		nonce, clock.Now(),
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrChallengeInvalid
+	}
+	return nil
+}
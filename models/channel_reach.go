@@ -0,0 +1,135 @@
+package models
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"time"
+
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
+)
+
+// ChannelMessageReach is how many members have fetched a channel message
+type ChannelMessageReach struct {
+	MessageID string     `json:"message_id"`
+	Timestamp types.Time `json:"timestamp"`
+	Reach     int        `json:"reach"`
+}
+
+// ChannelStats are the aggregate stats of a channel shown to its owners and admins
+type ChannelStats struct {
+	MemberCount  int                    `json:"member_count"`
+	MessageCount int                    `json:"message_count"`
+	Messages     []*ChannelMessageReach `json:"messages"`
+}
+
+// readerHash identifies a member's ack of a message without storing their address
+func readerHash(messageID, userAddress string) string {
+	hash := sha256.Sum256([]byte(messageID + ":" + userAddress))
+	return hex.EncodeToString(hash[:])
+}
+
+// AckChannelMessages records that a member fetched messages of a channel and
+// returns how many were counted toward reach for the first time. Messages
+// that don't belong to the channel, were sent by the member or are older
+// than window are skipped.
+func AckChannelMessages(ctx context.Context, channelID, userAddress string, messageIDs []string, window time.Duration) (int, error) {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	since := clock.Now().Add(-window)
+	counted := 0
+	for _, messageID := range messageIDs {
+		var senderAddress string
+		err := tx.QueryRowContext(ctx,
+			"SELECT sender_address FROM channel_messages"+database.PartitionSelection("channel_messages", since, clock.Now())+" WHERE id = ? AND channel_id = ? AND timestamp > ?",
+			messageID, channelID, since,
+		).Scan(&senderAddress)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				continue
+			}
+			return 0, err
+		}
+		if senderAddress == userAddress {
+			continue
+		}
+
+		result, err := tx.ExecContext(ctx,
+			"INSERT IGNORE INTO channel_reach_acks (message_id, reader_hash) VALUES (?, ?)",
+			messageID, readerHash(messageID, userAddress),
+		)
+		if err != nil {
+			return 0, err
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		if rowsAffected == 0 {
+			continue
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE channel_messages SET reach_count = reach_count + 1 WHERE id = ?", messageID)
+		if err != nil {
+			return 0, err
+		}
+		counted++
+	}
+
+	return counted, tx.Commit()
+}
+
+// PruneChannelReachAcks deletes ack rows older than window. Their messages
+// no longer accept acks, so the aggregate counts stay correct.
+func PruneChannelReachAcks(ctx context.Context, window time.Duration) (int64, error) {
+	result, err := database.DB.ExecContext(ctx,
+		"DELETE FROM channel_reach_acks WHERE acked_at < ?",
+		clock.Now().Add(-window),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// GetChannelStats retrieves the counters of a channel and the reach of its
+// most recent messages
+func GetChannelStats(ctx context.Context, channelID string, limit int) (*ChannelStats, error) {
+	channel, err := GetChannelByID(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT id, timestamp, reach_count FROM channel_messages WHERE channel_id = ? ORDER BY timestamp DESC LIMIT ?",
+		channelID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := &ChannelStats{
+		MemberCount:  channel.MemberCount,
+		MessageCount: channel.MessageCount,
+		Messages:     []*ChannelMessageReach{},
+	}
+	for rows.Next() {
+		reach := &ChannelMessageReach{}
+		if err := rows.Scan(&reach.MessageID, &reach.Timestamp, &reach.Reach); err != nil {
+			return nil, err
+		}
+		stats.Messages = append(stats.Messages, reach)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+
+	"github.com/piko/piko/database"
+)
+
+var (
+	// ErrInvalidWebSlug is returned when a web view slug has the wrong format
+	ErrInvalidWebSlug = errors.New("invalid web view slug")
+	// ErrWebSlugTaken is returned when another channel uses the slug
+	ErrWebSlugTaken = errors.New("web view slug already taken")
+	// ErrChannelNotPublic is returned when a private channel is given a web
+	// view
+	ErrChannelNotPublic = errors.New("channel is not public")
+)
+
+// webSlugPattern matches 5-32 lowercase letters, digits and underscores
+// starting with a letter
+var webSlugPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{4,31}$`)
+
+// IsValidWebSlug checks if a web view slug is valid
+func IsValidWebSlug(slug string) bool {
+	return webSlugPattern.MatchString(slug)
+}
+
+// getManagedChannelWeb returns whether a channel is public and its web view
+// slug, after checking that userAddress may manage it
+func getManagedChannelWeb(ctx context.Context, channelID, userAddress string) (bool, *string, error) {
+	var isPublic bool
+	var slug *string
+	err := database.DB.QueryRowContext(ctx, "SELECT is_public, web_slug FROM channels WHERE id = ?", channelID).Scan(&isPublic, &slug)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil, ErrChannelNotFound
+		}
+		return false, nil, err
+	}
+
+	// Check if user is an owner or admin
+	if err := requireChannelManager(ctx, channelID, userAddress); err != nil {
+		return false, nil, err
+	}
+	return isPublic, slug, nil
+}
+
+// SetChannelWebSlug publishes a public channel's web view under slug on
+// behalf of userAddress, replacing its previous slug
+func SetChannelWebSlug(ctx context.Context, channelID, slug, userAddress string) error {
+	isPublic, _, err := getManagedChannelWeb(ctx, channelID, userAddress)
+	if err != nil {
+		return err
+	}
+	if !isPublic {
+		return ErrChannelNotPublic
+	}
+	if !IsValidWebSlug(slug) {
+		return ErrInvalidWebSlug
+	}
+
+	var count int
+	err = database.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM channels WHERE web_slug = ? AND id != ?", slug, channelID).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrWebSlugTaken
+	}
+
+	_, err = database.DB.ExecContext(ctx, "UPDATE channels SET web_slug = ? WHERE id = ?", slug, channelID)
+	return err
+}
+
+// ClearChannelWebSlug takes a channel's web view down on behalf of
+// userAddress
+func ClearChannelWebSlug(ctx context.Context, channelID, userAddress string) error {
+	if _, _, err := getManagedChannelWeb(ctx, channelID, userAddress); err != nil {
+		return err
+	}
+	_, err := database.DB.ExecContext(ctx, "UPDATE channels SET web_slug = NULL WHERE id = ?", channelID)
+	return err
+}
+
+// GetChannelWebSlug returns the slug of a channel's web view to one of its
+// owners or admins, or nil if it has none
+func GetChannelWebSlug(ctx context.Context, channelID, userAddress string) (*string, error) {
+	_, slug, err := getManagedChannelWeb(ctx, channelID, userAddress)
+	return slug, err
+}
+
+// GetWebChannel retrieves the channel whose web view is published under
+// slug. Channels that were made private since are not found.
+func GetWebChannel(ctx context.Context, slug string) (*Channel, error) {
+	channel := &Channel{}
+	err := database.DB.QueryRowContext(ctx,
+		"SELECT id, name, admin_address, is_public, created_at, member_count, message_count FROM channels WHERE web_slug = ? AND is_public = TRUE",
+		slug,
+	).Scan(
+		&channel.ID, &channel.Name, &channel.AdminAddress, &channel.IsPublic, &channel.CreatedAt, &channel.MemberCount, &channel.MessageCount,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrChannelNotFound
+		}
+		return nil, err
+	}
+	return channel, nil
+}
@@ -1,11 +1,14 @@
 package models
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"time"
 
+	"github.com/piko/piko/clock"
 	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
 )
 
 var (
@@ -17,23 +20,55 @@ var (
 	ErrUserNotInChannel = errors.New("user not in channel")
 	// ErrUserAlreadyInChannel is returned when a user is already in a channel
 	ErrUserAlreadyInChannel = errors.New("user already in channel")
+	// ErrChannelFull is returned when a channel has reached its member limit
+	ErrChannelFull = errors.New("channel is full")
 	// ErrNotChannelAdmin is returned when a user is not an admin of a channel
 	ErrNotChannelAdmin = errors.New("not channel admin")
+	// ErrNotChannelOwner is returned when an action requires the channel owner
+	ErrNotChannelOwner = errors.New("not channel owner")
+	// ErrChannelOwnerImmutable is returned when trying to demote or remove the channel owner
+	ErrChannelOwnerImmutable = errors.New("channel owner cannot be changed")
+	// ErrInvalidChannelRole is returned when a role cannot be assigned to a member
+	ErrInvalidChannelRole = errors.New("invalid channel role")
+	// ErrInvalidInviteToken is returned when no channel has the given invite token
+	ErrInvalidInviteToken = errors.New("invalid invite token")
 )
 
+// ChannelRole is a member's role within a channel
+type ChannelRole string
+
+const (
+	// ChannelRoleOwner is the channel creator; there is exactly one per channel
+	ChannelRoleOwner ChannelRole = "owner"
+	// ChannelRoleAdmin can manage the channel, its members and its messages
+	ChannelRoleAdmin ChannelRole = "admin"
+	// ChannelRoleMember is a regular member
+	ChannelRoleMember ChannelRole = "member"
+)
+
+// CanManage reports whether the role may manage the channel
+func (r ChannelRole) CanManage() bool {
+	return r == ChannelRoleOwner || r == ChannelRoleAdmin
+}
+
 // Channel represents a channel in the system
 type Channel struct {
 	ID          string    `json:"id"`
 	Name        string    `json:"name"`
 	AdminAddress string    `json:"admin_address"`
-	CreatedAt   time.Time `json:"created_at"`
+	IsPublic    bool      `json:"is_public"`
+	InviteToken *string   `json:"-"`
+	CreatedAt   types.Time `json:"created_at"`
+	MemberCount int       `json:"member_count"`
+	MessageCount int      `json:"message_count"`
 }
 
 // ChannelMember represents a member of a channel
 type ChannelMember struct {
 	ChannelID   string    `json:"channel_id"`
 	UserAddress string    `json:"user_address"`
-	JoinedAt    time.Time `json:"joined_at"`
+	Role        ChannelRole `json:"role"`
+	JoinedAt    types.Time `json:"joined_at"`
 }
 
 // ChannelMessage represents a message in a channel
@@ -42,15 +77,23 @@ type ChannelMessage struct {
 	ChannelID       string    `json:"channel_id"`
 	SenderAddress   string    `json:"sender_address"`
 	EncryptedContent []byte    `json:"encrypted_content"`
-	Timestamp       time.Time `json:"timestamp"`
+	Timestamp       types.Time `json:"timestamp"`
 	BlockID         *string   `json:"block_id,omitempty"`
+	ReplyToMessageID *string  `json:"reply_to_message_id,omitempty"`
+	ForwardedFrom   *string   `json:"forwarded_from,omitempty"`
+	// ForwardedFromChannel is the channel a cross-posted message came from
+	ForwardedFromChannel *string `json:"forwarded_from_channel,omitempty"`
+	SenderSessionID *string   `json:"-"`
+	EditedAt        *types.Time `json:"edited_at,omitempty"`
+	// StickerID is the sticker a sticker message sends
+	StickerID *string `json:"sticker_id,omitempty"`
 }
 
 // CreateChannel creates a new channel in the database
-func CreateChannel(channel *Channel) error {
+func CreateChannel(ctx context.Context, channel *Channel) error {
 	// Check if channel with same ID exists
 	var count int
-	err := database.DB.QueryRow("SELECT COUNT(*) FROM channels WHERE id = ?", channel.ID).Scan(&count)
+	err := database.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM channels WHERE id = ?", channel.ID).Scan(&count)
 	if err != nil {
 		return err
 	}
@@ -58,31 +101,41 @@ func CreateChannel(channel *Channel) error {
 		return ErrChannelAlreadyExists
 	}
 
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	// Insert channel into database
-	_, err = database.DB.Exec(
-		"INSERT INTO channels (id, name, admin_address) VALUES (?, ?, ?)",
-		channel.ID, channel.Name, channel.AdminAddress,
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO channels (id, name, admin_address, is_public, invite_token, member_count) VALUES (?, ?, ?, ?, ?, 1)",
+		channel.ID, channel.Name, channel.AdminAddress, channel.IsPublic, channel.InviteToken,
 	)
 	if err != nil {
 		return err
 	}
 
-	// Add admin as a member
-	_, err = database.DB.Exec(
-		"INSERT INTO channel_members (channel_id, user_address) VALUES (?, ?)",
-		channel.ID, channel.AdminAddress,
+	// Add the creator as the owner
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO channel_members (channel_id, user_address, role) VALUES (?, ?, ?)",
+		channel.ID, channel.AdminAddress, ChannelRoleOwner,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // GetChannelByID retrieves a channel by its ID
-func GetChannelByID(id string) (*Channel, error) {
+func GetChannelByID(ctx context.Context, id string) (*Channel, error) {
 	channel := &Channel{}
-	err := database.DB.QueryRow(
-		"SELECT id, name, admin_address, created_at FROM channels WHERE id = ?",
+	err := database.DB.QueryRowContext(ctx,
+		"SELECT id, name, admin_address, is_public, created_at, member_count, message_count FROM channels WHERE id = ?",
 		id,
 	).Scan(
-		&channel.ID, &channel.Name, &channel.AdminAddress, &channel.CreatedAt,
+		&channel.ID, &channel.Name, &channel.AdminAddress, &channel.IsPublic, &channel.CreatedAt, &channel.MemberCount, &channel.MessageCount,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -94,9 +147,9 @@ func GetChannelByID(id string) (*Channel, error) {
 }
 
 // GetChannelsByUser retrieves all channels for a user
-func GetChannelsByUser(userAddress string) ([]*Channel, error) {
-	rows, err := database.DB.Query(`
-		SELECT c.id, c.name, c.admin_address, c.created_at 
+func GetChannelsByUser(ctx context.Context, userAddress string) ([]*Channel, error) {
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT c.id, c.name, c.admin_address, c.is_public, c.created_at, c.member_count, c.message_count 
 		FROM channels c 
 		JOIN channel_members cm ON c.id = cm.channel_id 
 		WHERE cm.user_address = ? 
@@ -112,7 +165,7 @@ func GetChannelsByUser(userAddress string) ([]*Channel, error) {
 	for rows.Next() {
 		channel := &Channel{}
 		err := rows.Scan(
-			&channel.ID, &channel.Name, &channel.AdminAddress, &channel.CreatedAt,
+			&channel.ID, &channel.Name, &channel.AdminAddress, &channel.IsPublic, &channel.CreatedAt, &channel.MemberCount, &channel.MessageCount,
 		)
 		if err != nil {
 			return nil, err
@@ -125,34 +178,76 @@ func GetChannelsByUser(userAddress string) ([]*Channel, error) {
 	return channels, nil
 }
 
-// UpdateChannel updates a channel's information
-func UpdateChannel(channel *Channel) error {
-	// Check if user is admin
-	var adminAddress string
-	err := database.DB.QueryRow("SELECT admin_address FROM channels WHERE id = ?", channel.ID).Scan(&adminAddress)
+// GetChannelRole returns the role of a user in a channel
+func GetChannelRole(ctx context.Context, channelID string, userAddress string) (ChannelRole, error) {
+	var role ChannelRole
+	err := database.DB.QueryRowContext(ctx,
+		"SELECT role FROM channel_members WHERE channel_id = ? AND user_address = ?",
+		channelID, userAddress,
+	).Scan(&role)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return ErrChannelNotFound
+			return "", ErrUserNotInChannel
+		}
+		return "", err
+	}
+	return role, nil
+}
+
+// requireChannelManager checks that a user is an owner or admin of a channel
+func requireChannelManager(ctx context.Context, channelID string, userAddress string) error {
+	role, err := GetChannelRole(ctx, channelID, userAddress)
+	if err != nil {
+		if errors.Is(err, ErrUserNotInChannel) {
+			return ErrNotChannelAdmin
 		}
 		return err
 	}
-	if adminAddress != channel.AdminAddress {
+	if !role.CanManage() {
 		return ErrNotChannelAdmin
 	}
+	return nil
+}
+
+// UpdateChannel updates a channel's name and visibility on behalf of
+// userAddress. A channel made public keeps its invite token, or gets
+// channel.InviteToken if it has none.
+func UpdateChannel(ctx context.Context, channel *Channel, userAddress string) error {
+	// Check if channel exists
+	var count int
+	err := database.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM channels WHERE id = ?", channel.ID).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrChannelNotFound
+	}
+
+	// Check if user is an owner or admin
+	if err := requireChannelManager(ctx, channel.ID, userAddress); err != nil {
+		return err
+	}
 
 	// Update channel
-	_, err = database.DB.Exec(
-		"UPDATE channels SET name = ? WHERE id = ?",
-		channel.Name, channel.ID,
+	_, err = database.DB.ExecContext(ctx,
+		"UPDATE channels SET name = ?, is_public = ?, invite_token = COALESCE(invite_token, ?) WHERE id = ?",
+		channel.Name, channel.IsPublic, channel.InviteToken, channel.ID,
 	)
 	return err
 }
 
 // DeleteChannel deletes a channel by its ID
-func DeleteChannel(id string, userAddress string) error {
-	// Check if user is admin
+func DeleteChannel(ctx context.Context, id string, userAddress string) error {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Only the owner may delete the channel. Locking the row keeps members
+	// and messages from being added while it is deleted.
 	var adminAddress string
-	err := database.DB.QueryRow("SELECT admin_address FROM channels WHERE id = ?", id).Scan(&adminAddress)
+	err = tx.QueryRowContext(ctx, "SELECT admin_address FROM channels WHERE id = ? FOR UPDATE", id).Scan(&adminAddress)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return ErrChannelNotFound
@@ -163,28 +258,27 @@ func DeleteChannel(id string, userAddress string) error {
 		return ErrNotChannelAdmin
 	}
 
-	// Delete channel
-	_, err = database.DB.Exec("DELETE FROM channels WHERE id = ?", id)
-	if err != nil {
-		return err
-	}
-
-	// Delete channel members
-	_, err = database.DB.Exec("DELETE FROM channel_members WHERE channel_id = ?", id)
-	if err != nil {
-		return err
+	// Delete channel messages, read positions and members, which have no
+	// foreign keys to cascade from the channel
+	for _, query := range []string{
+		"DELETE FROM channel_messages WHERE channel_id = ?",
+		"DELETE FROM channel_message_reads WHERE channel_id = ?",
+		"DELETE FROM channel_members WHERE channel_id = ?",
+		"DELETE FROM channels WHERE id = ?",
+	} {
+		if _, err := tx.ExecContext(ctx, query, id); err != nil {
+			return err
+		}
 	}
 
-	// Delete channel messages
-	_, err = database.DB.Exec("DELETE FROM channel_messages WHERE channel_id = ?", id)
-	return err
+	return tx.Commit()
 }
 
 // AddChannelMember adds a member to a channel
-func AddChannelMember(channelID string, userAddress string, adminAddress string) error {
+func AddChannelMember(ctx context.Context, channelID string, userAddress string, adminAddress string, maxMembers int) error {
 	// Check if channel exists
 	var count int
-	err := database.DB.QueryRow("SELECT COUNT(*) FROM channels WHERE id = ?", channelID).Scan(&count)
+	err := database.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM channels WHERE id = ?", channelID).Scan(&count)
 	if err != nil {
 		return err
 	}
@@ -192,18 +286,25 @@ func AddChannelMember(channelID string, userAddress string, adminAddress string)
 		return ErrChannelNotFound
 	}
 
-	// Check if user is admin
-	var channelAdminAddress string
-	err = database.DB.QueryRow("SELECT admin_address FROM channels WHERE id = ?", channelID).Scan(&channelAdminAddress)
-	if err != nil {
+	// Check if user is an owner or admin
+	if err := requireChannelManager(ctx, channelID, adminAddress); err != nil {
 		return err
 	}
-	if channelAdminAddress != adminAddress {
-		return ErrNotChannelAdmin
-	}
 
+	return insertChannelMember(ctx, channelID, userAddress, maxMembers)
+}
+
+// JoinChannel adds a user to a channel they were invited to by link
+func JoinChannel(ctx context.Context, channelID string, userAddress string, maxMembers int) error {
+	return insertChannelMember(ctx, channelID, userAddress, maxMembers)
+}
+
+// insertChannelMember adds a regular member to a channel of fewer than
+// maxMembers members, or of any size if maxMembers is 0
+func insertChannelMember(ctx context.Context, channelID string, userAddress string, maxMembers int) error {
 	// Check if user is already in channel
-	err = database.DB.QueryRow("SELECT COUNT(*) FROM channel_members WHERE channel_id = ? AND user_address = ?", channelID, userAddress).Scan(&count)
+	var count int
+	err := database.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM channel_members WHERE channel_id = ? AND user_address = ?", channelID, userAddress).Scan(&count)
 	if err != nil {
 		return err
 	}
@@ -211,19 +312,53 @@ func AddChannelMember(channelID string, userAddress string, adminAddress string)
 		return ErrUserAlreadyInChannel
 	}
 
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the member list so concurrent joins can't pass the limit
+	if maxMembers > 0 {
+		err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM channel_members WHERE channel_id = ? FOR UPDATE", channelID).Scan(&count)
+		if err != nil {
+			return err
+		}
+		if count >= maxMembers {
+			return ErrChannelFull
+		}
+	}
+
 	// Add member
-	_, err = database.DB.Exec(
+	_, err = tx.ExecContext(ctx,
 		"INSERT INTO channel_members (channel_id, user_address) VALUES (?, ?)",
 		channelID, userAddress,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+
+	// Keep the denormalized member count in step
+	_, err = tx.ExecContext(ctx, "UPDATE channels SET member_count = member_count + 1 WHERE id = ?", channelID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CountChannelMembers returns the number of members of a channel
+func CountChannelMembers(ctx context.Context, channelID string) (int, error) {
+	var count int
+	err := database.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM channel_members WHERE channel_id = ?", channelID).Scan(&count)
+	return count, err
 }
 
 // RemoveChannelMember removes a member from a channel
-func RemoveChannelMember(channelID string, userAddress string, adminAddress string) error {
+func RemoveChannelMember(ctx context.Context, channelID string, userAddress string, adminAddress string) error {
 	// Check if channel exists
 	var count int
-	err := database.DB.QueryRow("SELECT COUNT(*) FROM channels WHERE id = ?", channelID).Scan(&count)
+	err := database.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM channels WHERE id = ?", channelID).Scan(&count)
 	if err != nil {
 		return err
 	}
@@ -231,37 +366,172 @@ func RemoveChannelMember(channelID string, userAddress string, adminAddress stri
 		return ErrChannelNotFound
 	}
 
-	// Check if user is admin
-	var channelAdminAddress string
-	err = database.DB.QueryRow("SELECT admin_address FROM channels WHERE id = ?", channelID).Scan(&channelAdminAddress)
-	if err != nil {
+	// Check if user is an owner or admin
+	actorRole, err := GetChannelRole(ctx, channelID, adminAddress)
+	if err != nil && !errors.Is(err, ErrUserNotInChannel) {
 		return err
 	}
-	if channelAdminAddress != adminAddress {
+	if !actorRole.CanManage() {
 		return ErrNotChannelAdmin
 	}
 
 	// Check if user is in channel
-	err = database.DB.QueryRow("SELECT COUNT(*) FROM channel_members WHERE channel_id = ? AND user_address = ?", channelID, userAddress).Scan(&count)
+	targetRole, err := GetChannelRole(ctx, channelID, userAddress)
 	if err != nil {
 		return err
 	}
-	if count == 0 {
-		return ErrUserNotInChannel
+
+	// The owner stays, and only the owner may remove other admins
+	if targetRole == ChannelRoleOwner {
+		return ErrChannelOwnerImmutable
+	}
+	if targetRole == ChannelRoleAdmin && actorRole != ChannelRoleOwner && userAddress != adminAddress {
+		return ErrNotChannelOwner
 	}
 
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	// Remove member
-	_, err = database.DB.Exec(
+	_, err = tx.ExecContext(ctx,
 		"DELETE FROM channel_members WHERE channel_id = ? AND user_address = ?",
 		channelID, userAddress,
 	)
+	if err != nil {
+		return err
+	}
+
+	// Keep the denormalized member count in step
+	_, err = tx.ExecContext(ctx, "UPDATE channels SET member_count = GREATEST(member_count - 1, 0) WHERE id = ?", channelID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SetChannelInviteToken replaces the invite token of a channel, which
+// invalidates any previous link
+func SetChannelInviteToken(ctx context.Context, channelID string, token string, userAddress string) error {
+	// Check if channel exists
+	var count int
+	err := database.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM channels WHERE id = ?", channelID).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrChannelNotFound
+	}
+
+	// Check if user is an owner or admin
+	if err := requireChannelManager(ctx, channelID, userAddress); err != nil {
+		return err
+	}
+
+	_, err = database.DB.ExecContext(ctx, "UPDATE channels SET invite_token = ? WHERE id = ?", token, channelID)
+	return err
+}
+
+// GetChannelByInviteToken retrieves the channel an invite token belongs to
+func GetChannelByInviteToken(ctx context.Context, token string) (*Channel, error) {
+	channel := &Channel{}
+	err := database.DB.QueryRowContext(ctx,
+		"SELECT id, name, admin_address, is_public, created_at, member_count, message_count FROM channels WHERE invite_token = ?",
+		token,
+	).Scan(
+		&channel.ID, &channel.Name, &channel.AdminAddress, &channel.IsPublic, &channel.CreatedAt, &channel.MemberCount, &channel.MessageCount,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrInvalidInviteToken
+		}
+		return nil, err
+	}
+	return channel, nil
+}
+
+// DiscoverChannels searches public channels by name, largest first. The
+// invite token is included so the results can be joined.
+func DiscoverChannels(ctx context.Context, query string, limit int, offset int) ([]*Channel, error) {
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT id, name, admin_address, is_public, invite_token, created_at, member_count, message_count
+		FROM channels
+		WHERE is_public = TRUE AND invite_token IS NOT NULL AND name LIKE ?
+		ORDER BY member_count DESC, created_at DESC
+		LIMIT ? OFFSET ?`,
+		"%"+query+"%", limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	channels := []*Channel{}
+	for rows.Next() {
+		channel := &Channel{}
+		err := rows.Scan(
+			&channel.ID, &channel.Name, &channel.AdminAddress, &channel.IsPublic, &channel.InviteToken, &channel.CreatedAt, &channel.MemberCount, &channel.MessageCount,
+		)
+		if err != nil {
+			return nil, err
+		}
+		channels = append(channels, channel)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return channels, nil
+}
+
+// SetChannelMemberRole promotes or demotes a channel member. Only the owner
+// may change roles, and the owner's own role is fixed.
+func SetChannelMemberRole(ctx context.Context, channelID string, userAddress string, role ChannelRole, ownerAddress string) error {
+	if role != ChannelRoleAdmin && role != ChannelRoleMember {
+		return ErrInvalidChannelRole
+	}
+
+	// Check if channel exists
+	var count int
+	err := database.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM channels WHERE id = ?", channelID).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrChannelNotFound
+	}
+
+	// Check if user is the owner
+	actorRole, err := GetChannelRole(ctx, channelID, ownerAddress)
+	if err != nil && !errors.Is(err, ErrUserNotInChannel) {
+		return err
+	}
+	if actorRole != ChannelRoleOwner {
+		return ErrNotChannelOwner
+	}
+
+	// Check the target member
+	targetRole, err := GetChannelRole(ctx, channelID, userAddress)
+	if err != nil {
+		return err
+	}
+	if targetRole == ChannelRoleOwner {
+		return ErrChannelOwnerImmutable
+	}
+
+	_, err = database.DB.ExecContext(ctx,
+		"UPDATE channel_members SET role = ? WHERE channel_id = ? AND user_address = ?",
+		role, channelID, userAddress,
+	)
 	return err
 }
 
 // IsUserInChannel checks if a user is in a channel
-func IsUserInChannel(channelID string, userAddress string) (bool, error) {
+func IsUserInChannel(ctx context.Context, channelID string, userAddress string) (bool, error) {
 	var count int
-	err := database.DB.QueryRow("SELECT COUNT(*) FROM channel_members WHERE channel_id = ? AND user_address = ?", channelID, userAddress).Scan(&count)
+	err := database.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM channel_members WHERE channel_id = ? AND user_address = ?", channelID, userAddress).Scan(&count)
 	if err != nil {
 		return false, err
 	}
@@ -269,9 +539,9 @@ func IsUserInChannel(channelID string, userAddress string) (bool, error) {
 }
 
 // GetChannelMembers retrieves all members of a channel
-func GetChannelMembers(channelID string) ([]*ChannelMember, error) {
-	rows, err := database.DB.Query(
-		"SELECT channel_id, user_address, joined_at FROM channel_members WHERE channel_id = ? ORDER BY joined_at",
+func GetChannelMembers(ctx context.Context, channelID string) ([]*ChannelMember, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT channel_id, user_address, role, joined_at FROM channel_members WHERE channel_id = ? ORDER BY joined_at",
 		channelID,
 	)
 	if err != nil {
@@ -283,7 +553,7 @@ func GetChannelMembers(channelID string) ([]*ChannelMember, error) {
 	for rows.Next() {
 		member := &ChannelMember{}
 		err := rows.Scan(
-			&member.ChannelID, &member.UserAddress, &member.JoinedAt,
+			&member.ChannelID, &member.UserAddress, &member.Role, &member.JoinedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -297,10 +567,10 @@ func GetChannelMembers(channelID string) ([]*ChannelMember, error) {
 }
 
 // CreateChannelMessage creates a new channel message in the database
-func CreateChannelMessage(message *ChannelMessage) error {
+func CreateChannelMessage(ctx context.Context, message *ChannelMessage) error {
 	// Check if user is in channel
 	var count int
-	err := database.DB.QueryRow("SELECT COUNT(*) FROM channel_members WHERE channel_id = ? AND user_address = ?", message.ChannelID, message.SenderAddress).Scan(&count)
+	err := database.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM channel_members WHERE channel_id = ? AND user_address = ?", message.ChannelID, message.SenderAddress).Scan(&count)
 	if err != nil {
 		return err
 	}
@@ -308,22 +578,38 @@ func CreateChannelMessage(message *ChannelMessage) error {
 		return ErrUserNotInChannel
 	}
 
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	// Insert message
-	_, err = database.DB.Exec(
-		"INSERT INTO channel_messages (id, channel_id, sender_address, encrypted_content) VALUES (?, ?, ?, ?)",
-		message.ID, message.ChannelID, message.SenderAddress, message.EncryptedContent,
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO channel_messages (id, channel_id, sender_address, encrypted_content, reply_to_message_id, forwarded_from, forwarded_from_channel, sender_session_id, sticker_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		message.ID, message.ChannelID, message.SenderAddress, message.EncryptedContent, message.ReplyToMessageID, message.ForwardedFrom, message.ForwardedFromChannel, message.SenderSessionID, message.StickerID,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+
+	// Keep the denormalized message count in step
+	_, err = tx.ExecContext(ctx, "UPDATE channels SET message_count = message_count + 1 WHERE id = ?", message.ChannelID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // GetChannelMessageByID retrieves a channel message by its ID
-func GetChannelMessageByID(id string) (*ChannelMessage, error) {
+func GetChannelMessageByID(ctx context.Context, id string) (*ChannelMessage, error) {
 	message := &ChannelMessage{}
-	err := database.DB.QueryRow(
-		"SELECT id, channel_id, sender_address, encrypted_content, timestamp, block_id FROM channel_messages WHERE id = ?",
+	err := database.DB.QueryRowContext(ctx,
+		"SELECT id, channel_id, sender_address, encrypted_content, timestamp, block_id, reply_to_message_id, forwarded_from, forwarded_from_channel, sender_session_id, edited_at, sticker_id FROM channel_messages WHERE id = ?",
 		id,
 	).Scan(
-		&message.ID, &message.ChannelID, &message.SenderAddress, &message.EncryptedContent, &message.Timestamp, &message.BlockID,
+		&message.ID, &message.ChannelID, &message.SenderAddress, &message.EncryptedContent, &message.Timestamp, &message.BlockID, &message.ReplyToMessageID, &message.ForwardedFrom, &message.ForwardedFromChannel, &message.SenderSessionID, &message.EditedAt, &message.StickerID,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -335,9 +621,9 @@ func GetChannelMessageByID(id string) (*ChannelMessage, error) {
 }
 
 // GetChannelMessages retrieves all messages in a channel
-func GetChannelMessages(channelID string, limit int, offset int) ([]*ChannelMessage, error) {
-	rows, err := database.DB.Query(
-		"SELECT id, channel_id, sender_address, encrypted_content, timestamp, block_id FROM channel_messages WHERE channel_id = ? ORDER BY timestamp DESC LIMIT ? OFFSET ?",
+func GetChannelMessages(ctx context.Context, channelID string, limit int, offset int) ([]*ChannelMessage, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT id, channel_id, sender_address, encrypted_content, timestamp, block_id, reply_to_message_id, forwarded_from, forwarded_from_channel, sender_session_id, edited_at, sticker_id FROM channel_messages WHERE channel_id = ? ORDER BY timestamp DESC LIMIT ? OFFSET ?",
 		channelID, limit, offset,
 	)
 	if err != nil {
@@ -349,7 +635,7 @@ func GetChannelMessages(channelID string, limit int, offset int) ([]*ChannelMess
 	for rows.Next() {
 		message := &ChannelMessage{}
 		err := rows.Scan(
-			&message.ID, &message.ChannelID, &message.SenderAddress, &message.EncryptedContent, &message.Timestamp, &message.BlockID,
+			&message.ID, &message.ChannelID, &message.SenderAddress, &message.EncryptedContent, &message.Timestamp, &message.BlockID, &message.ReplyToMessageID, &message.ForwardedFrom, &message.ForwardedFromChannel, &message.SenderSessionID, &message.EditedAt, &message.StickerID,
 		)
 		if err != nil {
 			return nil, err
@@ -362,9 +648,30 @@ func GetChannelMessages(channelID string, limit int, offset int) ([]*ChannelMess
 	return messages, nil
 }
 
+// EditChannelMessage replaces the content of a channel message, returning
+// when it was edited
+func EditChannelMessage(ctx context.Context, id string, encryptedContent []byte) (*time.Time, error) {
+	editedAt := clock.Now()
+	result, err := database.DB.ExecContext(ctx,
+		"UPDATE channel_messages SET encrypted_content = ?, edited_at = ? WHERE id = ?",
+		encryptedContent, editedAt, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		return nil, ErrMessageNotFound
+	}
+	return &editedAt, nil
+}
+
 // UpdateChannelMessageBlockID updates the block ID of a channel message
-func UpdateChannelMessageBlockID(id string, blockID string) error {
-	_, err := database.DB.Exec(
+func UpdateChannelMessageBlockID(ctx context.Context, id string, blockID string) error {
+	_, err := database.DB.ExecContext(ctx,
 		"UPDATE channel_messages SET block_id = ? WHERE id = ?",
 		blockID, id,
 	)
@@ -372,10 +679,10 @@ func UpdateChannelMessageBlockID(id string, blockID string) error {
 }
 
 // DeleteChannelMessage deletes a channel message by its ID
-func DeleteChannelMessage(id string, userAddress string) error {
-	// Check if user is the sender or channel admin
+func DeleteChannelMessage(ctx context.Context, id string, userAddress string) error {
+	// Check if user is the sender or a channel owner or admin
 	var senderAddress, channelID string
-	err := database.DB.QueryRow("SELECT sender_address, channel_id FROM channel_messages WHERE id = ?", id).Scan(&senderAddress, &channelID)
+	err := database.DB.QueryRowContext(ctx, "SELECT sender_address, channel_id FROM channel_messages WHERE id = ?", id).Scan(&senderAddress, &channelID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return ErrMessageNotFound
@@ -384,18 +691,39 @@ func DeleteChannelMessage(id string, userAddress string) error {
 	}
 
 	if senderAddress != userAddress {
-		// Check if user is channel admin
-		var adminAddress string
-		err := database.DB.QueryRow("SELECT admin_address FROM channels WHERE id = ?", channelID).Scan(&adminAddress)
-		if err != nil {
+		if err := requireChannelManager(ctx, channelID, userAddress); err != nil {
 			return err
 		}
-		if adminAddress != userAddress {
-			return ErrNotChannelAdmin
-		}
 	}
 
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	// Delete message
-	_, err = database.DB.Exec("DELETE FROM channel_messages WHERE id = ?", id)
-	return err
+	result, err := tx.ExecContext(ctx, "DELETE FROM channel_messages WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	// Keep the denormalized message count in step
+	if rowsAffected > 0 {
+		_, err = tx.ExecContext(ctx, "UPDATE channels SET message_count = GREATEST(message_count - 1, 0) WHERE id = ?", channelID)
+		if err != nil {
+			return err
+		}
+	}
+
+	// A poll goes with the message it is attached to
+	if _, err := tx.ExecContext(ctx, "DELETE FROM polls WHERE message_id = ?", id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 } 
\ No newline at end of file
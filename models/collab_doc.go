@@ -0,0 +1,205 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
+)
+
+var (
+	// ErrCollabDocNotFound is returned when a collaborative document is not found
+	ErrCollabDocNotFound = errors.New("document not found")
+)
+
+// ConversationType is the kind of conversation a document is attached to
+type ConversationType string
+
+const (
+	// ConversationDirect is a conversation between two users. Its ID is the
+	// address of the creator's peer.
+	ConversationDirect ConversationType = "direct"
+	// ConversationGroup is a group
+	ConversationGroup ConversationType = "group"
+	// ConversationChannel is a channel
+	ConversationChannel ConversationType = "channel"
+)
+
+// IsValidConversationType checks if a conversation type is supported
+func IsValidConversationType(conversationType ConversationType) bool {
+	return conversationType == ConversationDirect || conversationType == ConversationGroup || conversationType == ConversationChannel
+}
+
+// CollabDoc is a shared document attached to a conversation. The server
+// only stores its encrypted updates in order; clients merge them, so they
+// should use a CRDT whose updates can be applied in any order.
+type CollabDoc struct {
+	ID               string           `json:"id"`
+	ConversationType ConversationType `json:"conversation_type"`
+	ConversationID   string           `json:"conversation_id"`
+	CreatorAddress   string           `json:"creator_address"`
+	// Version is the number of updates appended so far
+	Version   int64      `json:"version"`
+	CreatedAt types.Time `json:"created_at"`
+	UpdatedAt types.Time `json:"updated_at"`
+}
+
+// CollabDocUpdate is an encrypted update to a document
+type CollabDocUpdate struct {
+	DocID         string
+	Version       int64
+	SenderAddress string
+	Content       []byte
+	CreatedAt     types.Time
+}
+
+// CreateCollabDoc creates an empty document
+func CreateCollabDoc(ctx context.Context, doc *CollabDoc) error {
+	now := clock.Now()
+	_, err := database.DB.ExecContext(ctx,
+		"INSERT INTO collab_docs (id, conversation_type, conversation_id, creator_address, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)",
+		doc.ID, doc.ConversationType, doc.ConversationID, doc.CreatorAddress, now, now,
+	)
+	if err != nil {
+		return err
+	}
+	doc.CreatedAt = types.NewTime(now)
+	doc.UpdatedAt = doc.CreatedAt
+	return nil
+}
+
+// collabDocColumns are the columns scanned by scanCollabDoc
+const collabDocColumns = "id, conversation_type, conversation_id, creator_address, version, created_at, updated_at"
+
+// scanCollabDoc scans a row of collabDocColumns
+func scanCollabDoc(row interface{ Scan(...any) error }) (*CollabDoc, error) {
+	doc := &CollabDoc{}
+	err := row.Scan(&doc.ID, &doc.ConversationType, &doc.ConversationID, &doc.CreatorAddress, &doc.Version, &doc.CreatedAt, &doc.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// GetCollabDoc retrieves a document by its ID
+func GetCollabDoc(ctx context.Context, id string) (*CollabDoc, error) {
+	doc, err := scanCollabDoc(database.DB.QueryRowContext(ctx,
+		"SELECT "+collabDocColumns+" FROM collab_docs WHERE id = ?",
+		id,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrCollabDocNotFound
+		}
+		return nil, err
+	}
+	return doc, nil
+}
+
+// GetConversationDocs lists the documents of a conversation, most recently
+// updated first. Direct conversations are seen from the viewer's side, so
+// conversationID is the viewer's peer.
+func GetConversationDocs(ctx context.Context, conversationType ConversationType, conversationID, viewerAddress string) ([]*CollabDoc, error) {
+	query := "SELECT " + collabDocColumns + " FROM collab_docs WHERE conversation_type = ? AND conversation_id = ?"
+	args := []interface{}{conversationType, conversationID}
+	if conversationType == ConversationDirect {
+		query = "SELECT " + collabDocColumns + ` FROM collab_docs WHERE conversation_type = ?
+			AND ((creator_address = ? AND conversation_id = ?) OR (creator_address = ? AND conversation_id = ?))`
+		args = []interface{}{conversationType, viewerAddress, conversationID, conversationID, viewerAddress}
+	}
+
+	rows, err := database.DB.QueryContext(ctx, query+" ORDER BY updated_at DESC", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	docs := []*CollabDoc{}
+	for rows.Next() {
+		doc, err := scanCollabDoc(rows)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// AppendCollabDocUpdate stores an update as the document's next version
+func AppendCollabDocUpdate(ctx context.Context, docID, senderAddress string, content []byte) (*CollabDocUpdate, error) {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Lock the document so concurrent updates get consecutive versions
+	var version int64
+	err = tx.QueryRowContext(ctx, "SELECT version FROM collab_docs WHERE id = ? FOR UPDATE", docID).Scan(&version)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrCollabDocNotFound
+		}
+		return nil, err
+	}
+
+	update := &CollabDocUpdate{
+		DocID:         docID,
+		Version:       version + 1,
+		SenderAddress: senderAddress,
+		Content:       content,
+		CreatedAt:     types.NewTime(clock.Now()),
+	}
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO collab_doc_updates (doc_id, version, sender_address, content, created_at) VALUES (?, ?, ?, ?, ?)",
+		update.DocID, update.Version, update.SenderAddress, update.Content, update.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.ExecContext(ctx,
+		"UPDATE collab_docs SET version = ?, updated_at = ? WHERE id = ?",
+		update.Version, update.CreatedAt, docID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return update, nil
+}
+
+// GetCollabDocUpdates returns up to limit updates of a document after a
+// version, oldest first
+func GetCollabDocUpdates(ctx context.Context, docID string, afterVersion int64, limit int) ([]*CollabDocUpdate, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		`SELECT doc_id, version, sender_address, content, created_at FROM collab_doc_updates
+		WHERE doc_id = ? AND version > ? ORDER BY version LIMIT ?`,
+		docID, afterVersion, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	updates := []*CollabDocUpdate{}
+	for rows.Next() {
+		update := &CollabDocUpdate{}
+		if err := rows.Scan(&update.DocID, &update.Version, &update.SenderAddress, &update.Content, &update.CreatedAt); err != nil {
+			return nil, err
+		}
+		updates = append(updates, update)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return updates, nil
+}
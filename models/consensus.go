@@ -0,0 +1,139 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
+)
+
+var (
+	// ErrConsensusParamsNotFound is returned when no rules apply at a height
+	ErrConsensusParamsNotFound = errors.New("no consensus params for height")
+)
+
+// ConsensusParams are the block rules in force from ActivationHeight until
+// the next scheduled change
+type ConsensusParams struct {
+	ActivationHeight int               `json:"activation_height"`
+	Difficulty       int               `json:"difficulty"`
+	TransactionTypes []TransactionType `json:"transaction_types"`
+	Description      string            `json:"description"`
+	CreatedAt        types.Time        `json:"created_at"`
+}
+
+// AllowsTransactionType checks if blocks under these rules may include a
+// transaction type
+func (p *ConsensusParams) AllowsTransactionType(txType TransactionType) bool {
+	for _, allowed := range p.TransactionTypes {
+		if allowed == txType {
+			return true
+		}
+	}
+	return false
+}
+
+// Equal checks if two sets of rules are the same, ignoring when they were stored
+func (p *ConsensusParams) Equal(other *ConsensusParams) bool {
+	return p.ActivationHeight == other.ActivationHeight &&
+		p.Difficulty == other.Difficulty &&
+		joinTransactionTypes(p.TransactionTypes) == joinTransactionTypes(other.TransactionTypes)
+}
+
+// joinTransactionTypes stores a list of transaction types in one column
+func joinTransactionTypes(txTypes []TransactionType) string {
+	names := make([]string, len(txTypes))
+	for i, txType := range txTypes {
+		names[i] = string(txType)
+	}
+	return strings.Join(names, ",")
+}
+
+// splitTransactionTypes reads a list of transaction types from its column
+func splitTransactionTypes(column string) []TransactionType {
+	txTypes := []TransactionType{}
+	for _, name := range strings.Split(column, ",") {
+		if name != "" {
+			txTypes = append(txTypes, TransactionType(name))
+		}
+	}
+	return txTypes
+}
+
+// scanConsensusParams scans a consensus_params row
+func scanConsensusParams(scan func(dest ...interface{}) error) (*ConsensusParams, error) {
+	params := &ConsensusParams{}
+	var txTypes string
+	if err := scan(&params.ActivationHeight, &params.Difficulty, &txTypes, &params.Description, &params.CreatedAt); err != nil {
+		return nil, err
+	}
+	params.TransactionTypes = splitTransactionTypes(txTypes)
+	return params, nil
+}
+
+// GetConsensusParams retrieves the rules in force at a block height
+func GetConsensusParams(ctx context.Context, height int) (*ConsensusParams, error) {
+	row := database.DB.QueryRowContext(ctx,
+		`SELECT activation_height, difficulty, transaction_types, description, created_at
+		FROM consensus_params WHERE activation_height <= ?
+		ORDER BY activation_height DESC LIMIT 1`,
+		height,
+	)
+	params, err := scanConsensusParams(row.Scan)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrConsensusParamsNotFound
+		}
+		return nil, err
+	}
+	return params, nil
+}
+
+// GetConsensusSchedule retrieves every scheduled set of rules by activation height
+func GetConsensusSchedule(ctx context.Context) ([]*ConsensusParams, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		`SELECT activation_height, difficulty, transaction_types, description, created_at
+		FROM consensus_params ORDER BY activation_height`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedule := []*ConsensusParams{}
+	for rows.Next() {
+		params, err := scanConsensusParams(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		schedule = append(schedule, params)
+	}
+	return schedule, rows.Err()
+}
+
+// ReplaceConsensusSchedule stores a new schedule in place of the old one
+func ReplaceConsensusSchedule(ctx context.Context, schedule []*ConsensusParams) error {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM consensus_params"); err != nil {
+		return err
+	}
+	for _, params := range schedule {
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO consensus_params (activation_height, difficulty, transaction_types, description) VALUES (?, ?, ?, ?)",
+			params.ActivationHeight, params.Difficulty, joinTransactionTypes(params.TransactionTypes), params.Description,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
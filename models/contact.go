@@ -0,0 +1,311 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
+)
+
+var (
+	// ErrContactNotFound is returned when a contact is not found
+	ErrContactNotFound = errors.New("contact not found")
+)
+
+// Contact is an entry in a user's contact list
+type Contact struct {
+	OwnerAddress   string     `json:"-"`
+	ContactAddress string     `json:"address"`
+	Alias          string     `json:"alias,omitempty"`
+	Labels         []string   `json:"labels"`
+	Blocked        bool       `json:"blocked"`
+	CreatedAt      types.Time `json:"created_at"`
+	UpdatedAt      types.Time `json:"updated_at"`
+}
+
+// ContactName is what a user calls one of their contacts
+type ContactName struct {
+	Alias  string   `json:"alias,omitempty"`
+	Labels []string `json:"labels,omitempty"`
+}
+
+// SaveContact adds a contact, or updates the alias and blocked flag of an
+// existing one. Its labels are replaced too, unless Labels is nil.
+func SaveContact(ctx context.Context, contact *Contact) error {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO contacts (owner_address, contact_address, alias, blocked) VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE alias = VALUES(alias), blocked = VALUES(blocked), updated_at = NOW()`,
+		contact.OwnerAddress, contact.ContactAddress, contact.Alias, contact.Blocked,
+	)
+	if err != nil {
+		return err
+	}
+
+	if contact.Labels != nil {
+		_, err = tx.ExecContext(ctx,
+			"DELETE FROM contact_labels WHERE owner_address = ? AND contact_address = ?",
+			contact.OwnerAddress, contact.ContactAddress,
+		)
+		if err != nil {
+			return err
+		}
+		for _, label := range contact.Labels {
+			_, err = tx.ExecContext(ctx,
+				"INSERT INTO contact_labels (owner_address, contact_address, label) VALUES (?, ?, ?)",
+				contact.OwnerAddress, contact.ContactAddress, label,
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetContact retrieves one of a user's contacts
+func GetContact(ctx context.Context, ownerAddress, contactAddress string) (*Contact, error) {
+	contact := &Contact{}
+	err := database.DB.QueryRowContext(ctx,
+		`SELECT owner_address, contact_address, alias, blocked, created_at, updated_at
+		FROM contacts WHERE owner_address = ? AND contact_address = ?`,
+		ownerAddress, contactAddress,
+	).Scan(&contact.OwnerAddress, &contact.ContactAddress, &contact.Alias, &contact.Blocked, &contact.CreatedAt, &contact.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrContactNotFound
+		}
+		return nil, err
+	}
+
+	labels, err := getContactLabels(ctx, ownerAddress, []string{contactAddress})
+	if err != nil {
+		return nil, err
+	}
+	contact.Labels = labelsOf(labels, contactAddress)
+	return contact, nil
+}
+
+// GetContacts retrieves a user's contact list, including blocked entries
+func GetContacts(ctx context.Context, ownerAddress string) ([]*Contact, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		`SELECT owner_address, contact_address, alias, blocked, created_at, updated_at
+		FROM contacts WHERE owner_address = ? ORDER BY alias, contact_address`,
+		ownerAddress,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	contacts := []*Contact{}
+	for rows.Next() {
+		contact := &Contact{}
+		if err := rows.Scan(&contact.OwnerAddress, &contact.ContactAddress, &contact.Alias, &contact.Blocked, &contact.CreatedAt, &contact.UpdatedAt); err != nil {
+			return nil, err
+		}
+		contacts = append(contacts, contact)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Every contact of the owner is wanted, so no address filter is needed
+	labels, err := getContactLabels(ctx, ownerAddress, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, contact := range contacts {
+		contact.Labels = labelsOf(labels, contact.ContactAddress)
+	}
+	return contacts, nil
+}
+
+// GetContactNames maps the given addresses to the alias and labels their
+// owner gave them. Addresses that aren't named contacts are left out.
+func GetContactNames(ctx context.Context, ownerAddress string, addresses []string) (map[string]*ContactName, error) {
+	names := map[string]*ContactName{}
+	if len(addresses) == 0 {
+		return names, nil
+	}
+
+	args := []interface{}{ownerAddress}
+	for _, address := range addresses {
+		args = append(args, address)
+	}
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT contact_address, alias FROM contacts WHERE owner_address = ? AND alias <> '' AND contact_address IN (?"+strings.Repeat(", ?", len(addresses)-1)+")",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var address, alias string
+		if err := rows.Scan(&address, &alias); err != nil {
+			return nil, err
+		}
+		names[address] = &ContactName{Alias: alias}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	labels, err := getContactLabels(ctx, ownerAddress, addresses)
+	if err != nil {
+		return nil, err
+	}
+	for address, addressLabels := range labels {
+		name, ok := names[address]
+		if !ok {
+			name = &ContactName{}
+			names[address] = name
+		}
+		name.Labels = addressLabels
+	}
+	return names, nil
+}
+
+// getContactLabels maps contact addresses to their labels, sorted. A nil
+// address list loads the labels of every contact of the owner.
+func getContactLabels(ctx context.Context, ownerAddress string, addresses []string) (map[string][]string, error) {
+	query := "SELECT contact_address, label FROM contact_labels WHERE owner_address = ?"
+	args := []interface{}{ownerAddress}
+	if addresses != nil {
+		if len(addresses) == 0 {
+			return map[string][]string{}, nil
+		}
+		query += " AND contact_address IN (?" + strings.Repeat(", ?", len(addresses)-1) + ")"
+		for _, address := range addresses {
+			args = append(args, address)
+		}
+	}
+
+	rows, err := database.DB.QueryContext(ctx, query+" ORDER BY label", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	labels := map[string][]string{}
+	for rows.Next() {
+		var address, label string
+		if err := rows.Scan(&address, &label); err != nil {
+			return nil, err
+		}
+		labels[address] = append(labels[address], label)
+	}
+	return labels, rows.Err()
+}
+
+// labelsOf returns a contact's labels, never nil so they marshal as a list
+func labelsOf(labels map[string][]string, address string) []string {
+	if contactLabels, ok := labels[address]; ok {
+		return contactLabels
+	}
+	return []string{}
+}
+
+// DeleteContact removes a contact from a user's list
+func DeleteContact(ctx context.Context, ownerAddress, contactAddress string) error {
+	result, err := database.DB.ExecContext(ctx,
+		"DELETE FROM contacts WHERE owner_address = ? AND contact_address = ?",
+		ownerAddress, contactAddress,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrContactNotFound
+	}
+	return nil
+}
+
+// IsContact reports whether other is in owner's contact list and not
+// blocked. This is the set PrivacyContacts settings are evaluated against.
+func IsContact(ctx context.Context, ownerAddress, otherAddress string) (bool, error) {
+	var exists bool
+	err := database.DB.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM contacts WHERE owner_address = ? AND contact_address = ? AND blocked = FALSE)",
+		ownerAddress, otherAddress,
+	).Scan(&exists)
+	return exists, err
+}
+
+// HasBlocked reports whether owner has blocked other
+func HasBlocked(ctx context.Context, ownerAddress, otherAddress string) (bool, error) {
+	var blocked bool
+	err := database.DB.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM contacts WHERE owner_address = ? AND contact_address = ? AND blocked = TRUE)",
+		ownerAddress, otherAddress,
+	).Scan(&blocked)
+	return blocked, err
+}
+
+// GetContactAddresses returns the addresses in a user's contact list that
+// aren't blocked
+func GetContactAddresses(ctx context.Context, ownerAddress string) ([]string, error) {
+	return contactAddresses(ctx, ownerAddress, false)
+}
+
+// GetBlockedAddresses returns the addresses a user has blocked
+func GetBlockedAddresses(ctx context.Context, ownerAddress string) ([]string, error) {
+	return contactAddresses(ctx, ownerAddress, true)
+}
+
+// contactAddresses returns the blocked or unblocked addresses in a user's
+// contact list
+func contactAddresses(ctx context.Context, ownerAddress string, blocked bool) ([]string, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT contact_address FROM contacts WHERE owner_address = ? AND blocked = ?",
+		ownerAddress, blocked,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	addresses := []string{}
+	for rows.Next() {
+		var address string
+		if err := rows.Scan(&address); err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, address)
+	}
+	return addresses, rows.Err()
+}
+
+// PrivacyAllows reports whether viewer may see information owner protects
+// with the given privacy setting
+func PrivacyAllows(ctx context.Context, privacy PrivacyType, ownerAddress, viewerAddress string) (bool, error) {
+	if ownerAddress == viewerAddress {
+		return true, nil
+	}
+
+	switch privacy {
+	case PrivacyEveryone:
+		blocked, err := HasBlocked(ctx, ownerAddress, viewerAddress)
+		return !blocked, err
+	case PrivacyContacts:
+		return IsContact(ctx, ownerAddress, viewerAddress)
+	default:
+		return false, nil
+	}
+}
@@ -0,0 +1,133 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
+)
+
+var (
+	// ErrConversationKeyNotFound is returned when no key record exists for a conversation
+	ErrConversationKeyNotFound = errors.New("conversation key not found")
+)
+
+// ConversationKey is what one user knows about a peer's key in a one-to-one
+// conversation, and whether they have verified it out of band
+type ConversationKey struct {
+	OwnerAddress       string      `json:"owner_address"`
+	PeerAddress        string      `json:"peer_address"`
+	PeerKeyFingerprint string      `json:"peer_key_fingerprint"`
+	Verified           bool        `json:"verified"`
+	VerifiedAt         *types.Time `json:"verified_at,omitempty"`
+	UpdatedAt          types.Time  `json:"updated_at"`
+}
+
+// GetConversationKey retrieves the key record an owner keeps for a peer
+func GetConversationKey(ctx context.Context, ownerAddress, peerAddress string) (*ConversationKey, error) {
+	key := &ConversationKey{}
+	err := database.DB.QueryRowContext(ctx,
+		`SELECT owner_address, peer_address, peer_key_fingerprint, verified, verified_at, updated_at
+		FROM conversation_keys WHERE owner_address = ? AND peer_address = ?`,
+		ownerAddress, peerAddress,
+	).Scan(
+		&key.OwnerAddress, &key.PeerAddress, &key.PeerKeyFingerprint, &key.Verified, &key.VerifiedAt, &key.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrConversationKeyNotFound
+		}
+		return nil, err
+	}
+	return key, nil
+}
+
+// SaveConversationKey records the peer key fingerprint an owner has seen.
+// An existing record is left untouched.
+func SaveConversationKey(ctx context.Context, ownerAddress, peerAddress, fingerprint string) error {
+	_, err := database.DB.ExecContext(ctx,
+		"INSERT IGNORE INTO conversation_keys (owner_address, peer_address, peer_key_fingerprint) VALUES (?, ?, ?)",
+		ownerAddress, peerAddress, fingerprint,
+	)
+	return err
+}
+
+// SetConversationVerified marks a peer's key as verified or unverified
+func SetConversationVerified(ctx context.Context, ownerAddress, peerAddress string, verified bool) error {
+	var verifiedAt *time.Time
+	if verified {
+		now := clock.Now()
+		verifiedAt = &now
+	}
+
+	result, err := database.DB.ExecContext(ctx,
+		"UPDATE conversation_keys SET verified = ?, verified_at = ? WHERE owner_address = ? AND peer_address = ?",
+		verified, verifiedAt, ownerAddress, peerAddress,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrConversationKeyNotFound
+	}
+	return nil
+}
+
+// ResetConversationKeys stores a peer's new key fingerprint wherever it
+// differs from the one on record, clearing any verification. It returns the
+// addresses of the users whose record changed.
+func ResetConversationKeys(ctx context.Context, peerAddress, fingerprint string) ([]string, error) {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx,
+		"SELECT owner_address FROM conversation_keys WHERE peer_address = ? AND peer_key_fingerprint != ? FOR UPDATE",
+		peerAddress, fingerprint,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	owners := []string{}
+	for rows.Next() {
+		var owner string
+		if err := rows.Scan(&owner); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		owners = append(owners, owner)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(owners) == 0 {
+		return owners, nil
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE conversation_keys SET peer_key_fingerprint = ?, verified = FALSE, verified_at = NULL
+		WHERE peer_address = ? AND peer_key_fingerprint != ?`,
+		fingerprint, peerAddress, fingerprint,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return owners, nil
+}
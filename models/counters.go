@@ -0,0 +1,43 @@
+package models
+
+import (
+	"context"
+
+	"github.com/piko/piko/database"
+)
+
+// counterReconciliations recompute the denormalized member and message
+// counters from their source tables, touching only rows that have drifted
+var counterReconciliations = []string{
+	`UPDATE chat_groups g
+	SET g.member_count = (SELECT COUNT(*) FROM group_members gm WHERE gm.group_id = g.id)
+	WHERE g.member_count <> (SELECT COUNT(*) FROM group_members gm WHERE gm.group_id = g.id)`,
+	`UPDATE chat_groups g
+	SET g.message_count = (SELECT COUNT(*) FROM group_messages m WHERE m.group_id = g.id)
+	WHERE g.message_count <> (SELECT COUNT(*) FROM group_messages m WHERE m.group_id = g.id)`,
+	`UPDATE channels c
+	SET c.member_count = (SELECT COUNT(*) FROM channel_members cm WHERE cm.channel_id = c.id)
+	WHERE c.member_count <> (SELECT COUNT(*) FROM channel_members cm WHERE cm.channel_id = c.id)`,
+	`UPDATE channels c
+	SET c.message_count = (SELECT COUNT(*) FROM channel_messages m WHERE m.channel_id = c.id)
+	WHERE c.message_count <> (SELECT COUNT(*) FROM channel_messages m WHERE m.channel_id = c.id)`,
+}
+
+// ReconcileCounters corrects any group or channel counters that no longer
+// match their membership and message tables and returns how many rows were fixed
+func ReconcileCounters(ctx context.Context) (int64, error) {
+	var fixed int64
+	for _, query := range counterReconciliations {
+		result, err := database.DB.ExecContext(ctx, query)
+		if err != nil {
+			return fixed, err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fixed, err
+		}
+		fixed += rowsAffected
+	}
+	return fixed, nil
+}
@@ -0,0 +1,192 @@
+package models
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
+)
+
+// DeliveryKind is the kind of message a delivery time was measured for
+type DeliveryKind string
+
+const (
+	// DeliveryDirect is a one-to-one message
+	DeliveryDirect DeliveryKind = "direct"
+	// DeliveryGroup is a group message, measured per member
+	DeliveryGroup DeliveryKind = "group"
+)
+
+// DeliverySLA holds the delivery time percentiles of one kind of message
+// for one hour, in milliseconds
+type DeliverySLA struct {
+	Hour    types.Time   `json:"hour"`
+	Kind    DeliveryKind `json:"kind"`
+	Samples int          `json:"samples"`
+	P50     int64        `json:"p50_ms"`
+	P95     int64        `json:"p95_ms"`
+	P99     int64        `json:"p99_ms"`
+	Max     int64        `json:"max_ms"`
+	Alerted bool         `json:"alerted"`
+}
+
+// DeliveryHour is an hour of delivery samples that hasn't been summarized
+type DeliveryHour struct {
+	Hour time.Time
+	Kind DeliveryKind
+}
+
+// RecordDeliverySample records how long a message took to be delivered
+func RecordDeliverySample(ctx context.Context, kind DeliveryKind, latency time.Duration, at time.Time) error {
+	_, err := database.DB.ExecContext(ctx,
+		"INSERT INTO delivery_samples (kind, hour, latency_ms) VALUES (?, ?, ?)",
+		kind, at.UTC().Truncate(time.Hour), latency.Milliseconds(),
+	)
+	return err
+}
+
+// GetUnsummarizedDeliveryHours lists the hours before a time that still
+// have samples, oldest first
+func GetUnsummarizedDeliveryHours(ctx context.Context, before time.Time) ([]DeliveryHour, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT DISTINCT hour, kind FROM delivery_samples WHERE hour < ? ORDER BY hour",
+		before,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hours := []DeliveryHour{}
+	for rows.Next() {
+		var hour DeliveryHour
+		if err := rows.Scan(&hour.Hour, &hour.Kind); err != nil {
+			return nil, err
+		}
+		hours = append(hours, hour)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return hours, nil
+}
+
+// SummarizeDeliveryHour stores the percentiles of an hour's samples and
+// deletes them. It returns false if another instance stored them first.
+func SummarizeDeliveryHour(ctx context.Context, hour time.Time, kind DeliveryKind) (*DeliverySLA, bool, error) {
+	sla := &DeliverySLA{Hour: types.NewTime(hour), Kind: kind}
+	err := database.DB.QueryRowContext(ctx,
+		"SELECT COUNT(*), COALESCE(MAX(latency_ms), 0) FROM delivery_samples WHERE hour = ? AND kind = ?",
+		hour, kind,
+	).Scan(&sla.Samples, &sla.Max)
+	if err != nil {
+		return nil, false, err
+	}
+	if sla.Samples == 0 {
+		return nil, false, nil
+	}
+
+	for _, p := range []struct {
+		quantile float64
+		value    *int64
+	}{{0.5, &sla.P50}, {0.95, &sla.P95}, {0.99, &sla.P99}} {
+		// Nearest rank: the smallest sample at or above the quantile
+		offset := int(math.Ceil(p.quantile*float64(sla.Samples))) - 1
+		err := database.DB.QueryRowContext(ctx,
+			"SELECT latency_ms FROM delivery_samples WHERE hour = ? AND kind = ? ORDER BY latency_ms LIMIT 1 OFFSET ?",
+			hour, kind, max(offset, 0),
+		).Scan(p.value)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	result, err := database.DB.ExecContext(ctx,
+		`INSERT IGNORE INTO delivery_sla (hour, kind, samples, p50_ms, p95_ms, p99_ms, max_ms)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		hour, kind, sla.Samples, sla.P50, sla.P95, sla.P99, sla.Max,
+	)
+	if err != nil {
+		return nil, false, err
+	}
+	stored, err := result.RowsAffected()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if _, err := database.DB.ExecContext(ctx,
+		"DELETE FROM delivery_samples WHERE hour = ? AND kind = ?", hour, kind,
+	); err != nil {
+		return nil, false, err
+	}
+	return sla, stored > 0, nil
+}
+
+// MarkDeliverySLAAlerted records that an hour raised an alert
+func MarkDeliverySLAAlerted(ctx context.Context, hour time.Time, kind DeliveryKind) error {
+	_, err := database.DB.ExecContext(ctx,
+		"UPDATE delivery_sla SET alerted = TRUE WHERE hour = ? AND kind = ?", hour, kind,
+	)
+	return err
+}
+
+// GetDeliverySLA lists the hourly percentiles since a time, latest first
+func GetDeliverySLA(ctx context.Context, since time.Time) ([]*DeliverySLA, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		`SELECT hour, kind, samples, p50_ms, p95_ms, p99_ms, max_ms, alerted
+		FROM delivery_sla WHERE hour >= ? ORDER BY hour DESC, kind`,
+		since,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hours := []*DeliverySLA{}
+	for rows.Next() {
+		sla := &DeliverySLA{}
+		if err := rows.Scan(&sla.Hour, &sla.Kind, &sla.Samples, &sla.P50, &sla.P95, &sla.P99, &sla.Max, &sla.Alerted); err != nil {
+			return nil, err
+		}
+		hours = append(hours, sla)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return hours, nil
+}
+
+// GetLatestDeliverySLA returns the latest summarized hour of each kind
+func GetLatestDeliverySLA(ctx context.Context) ([]*DeliverySLA, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		`SELECT s.hour, s.kind, s.samples, s.p50_ms, s.p95_ms, s.p99_ms, s.max_ms, s.alerted
+		FROM delivery_sla s
+		JOIN (SELECT kind, MAX(hour) AS hour FROM delivery_sla GROUP BY kind) latest
+			ON latest.kind = s.kind AND latest.hour = s.hour`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	latest := []*DeliverySLA{}
+	for rows.Next() {
+		sla := &DeliverySLA{}
+		if err := rows.Scan(&sla.Hour, &sla.Kind, &sla.Samples, &sla.P50, &sla.P95, &sla.P99, &sla.Max, &sla.Alerted); err != nil {
+			return nil, err
+		}
+		latest = append(latest, sla)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return latest, nil
+}
+
+// DeleteDeliverySLABefore deletes the percentiles of hours before a time
+func DeleteDeliverySLABefore(ctx context.Context, before time.Time) error {
+	_, err := database.DB.ExecContext(ctx, "DELETE FROM delivery_sla WHERE hour < ?", before)
+	return err
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"context"
+	"errors"
+
+	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
+)
+
+var (
+	// ErrDeviceNotFound is returned when a device is not found
+	ErrDeviceNotFound = errors.New("device not found")
+)
+
+// DevicePlatform identifies the push provider a device token belongs to
+type DevicePlatform string
+
+const (
+	// DevicePlatformFCM is for Firebase Cloud Messaging tokens
+	DevicePlatformFCM DevicePlatform = "fcm"
+	// DevicePlatformAPNs is for Apple Push Notification service tokens
+	DevicePlatformAPNs DevicePlatform = "apns"
+)
+
+// Device represents a device registered for push notifications
+type Device struct {
+	ID          int            `json:"id"`
+	UserAddress string         `json:"user_address"`
+	SessionID   *string        `json:"session_id,omitempty"`
+	Platform    DevicePlatform `json:"platform"`
+	Token       string         `json:"token"`
+	CreatedAt   types.Time     `json:"created_at"`
+	UpdatedAt   types.Time     `json:"updated_at"`
+}
+
+// IsValidDevicePlatform checks if a platform is supported
+func IsValidDevicePlatform(platform DevicePlatform) bool {
+	return platform == DevicePlatformFCM || platform == DevicePlatformAPNs
+}
+
+// RegisterDevice registers a push token for a user. A token that was
+// registered before moves to the new user.
+func RegisterDevice(ctx context.Context, device *Device) error {
+	_, err := database.DB.ExecContext(ctx,
+		`INSERT INTO devices (user_address, session_id, platform, token) VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE user_address = VALUES(user_address), session_id = VALUES(session_id),
+		platform = VALUES(platform), updated_at = NOW()`,
+		device.UserAddress, device.SessionID, device.Platform, device.Token,
+	)
+	return err
+}
+
+// GetUserDevices retrieves all devices registered by a user
+func GetUserDevices(ctx context.Context, userAddress string) ([]*Device, error) {
+	return queryDevices(ctx,
+		"SELECT id, user_address, session_id, platform, token, created_at, updated_at FROM devices WHERE user_address = ?",
+		userAddress,
+	)
+}
+
+// GetSessionDevices retrieves the devices registered from a session
+func GetSessionDevices(ctx context.Context, sessionID string) ([]*Device, error) {
+	return queryDevices(ctx,
+		"SELECT id, user_address, session_id, platform, token, created_at, updated_at FROM devices WHERE session_id = ?",
+		sessionID,
+	)
+}
+
+// queryDevices runs a device query and scans the results
+func queryDevices(ctx context.Context, query string, args ...interface{}) ([]*Device, error) {
+	rows, err := database.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	devices := []*Device{}
+	for rows.Next() {
+		device := &Device{}
+		var platform string
+		err := rows.Scan(&device.ID, &device.UserAddress, &device.SessionID, &platform, &device.Token, &device.CreatedAt, &device.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		device.Platform = DevicePlatform(platform)
+		devices = append(devices, device)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
+
+// DeleteDevice removes a user's device token
+func DeleteDevice(ctx context.Context, userAddress, token string) error {
+	result, err := database.DB.ExecContext(ctx, "DELETE FROM devices WHERE user_address = ? AND token = ?", userAddress, token)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrDeviceNotFound
+	}
+	return nil
+}
+
+// DeleteDeviceToken removes a token regardless of owner, used when the push
+// provider reports it as invalid
+func DeleteDeviceToken(ctx context.Context, token string) error {
+	_, err := database.DB.ExecContext(ctx, "DELETE FROM devices WHERE token = ?", token)
+	return err
+}
+
+// DeleteSessionDevices removes all devices registered from a session
+func DeleteSessionDevices(ctx context.Context, sessionID string) error {
+	_, err := database.DB.ExecContext(ctx, "DELETE FROM devices WHERE session_id = ?", sessionID)
+	return err
+}
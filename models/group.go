@@ -1,11 +1,14 @@
 package models
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"time"
 
+	"github.com/piko/piko/clock"
 	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
 )
 
 var (
@@ -17,6 +20,16 @@ var (
 	ErrNotGroupAdmin = errors.New("user is not a group admin")
 	// ErrAlreadyGroupMember is returned when a user is already a group member
 	ErrAlreadyGroupMember = errors.New("user is already a group member")
+	// ErrGroupFull is returned when a group has reached its member limit
+	ErrGroupFull = errors.New("group is full")
+	// ErrNotGroupOwner is returned when a user is not a group's owner
+	ErrNotGroupOwner = errors.New("user is not the group owner")
+	// ErrGroupOwnerMustTransfer is returned when the owner would leave or
+	// stop being an admin without transferring ownership first
+	ErrGroupOwnerMustTransfer = errors.New("group owner must transfer ownership first")
+	// ErrLastGroupAdmin is returned when a change would leave a group's
+	// members without an admin
+	ErrLastGroupAdmin = errors.New("group would have no admin left")
 )
 
 // GroupRole defines the role of a user in a group
@@ -31,45 +44,61 @@ const (
 
 // Group represents a group chat
 type Group struct {
-	ID             string    `json:"id"`
-	Name           string    `json:"name"`
-	Description    string    `json:"description"`
-	CreatorAddress string    `json:"creator_address"`
-	PhotoURL       string    `json:"photo_url,omitempty"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
-	MemberCount    int       `json:"member_count"`
+	ID             string     `json:"id"`
+	Name           string     `json:"name"`
+	Description    string     `json:"description"`
+	CreatorAddress string     `json:"creator_address"`
+	PhotoURL       string     `json:"photo_url,omitempty"`
+	CreatedAt      types.Time `json:"created_at"`
+	UpdatedAt      types.Time `json:"updated_at"`
+	MemberCount    int        `json:"member_count"`
+	MessageCount   int        `json:"message_count"`
+	// TopicsEnabled puts the group in topic mode, where messages can be
+	// posted to named topics
+	TopicsEnabled bool `json:"topics_enabled"`
+	// JoinRequestsEnabled lets users ask to join, pending an admin's approval
+	JoinRequestsEnabled bool `json:"join_requests_enabled"`
 }
 
 // GroupMember represents a member of a group
 type GroupMember struct {
-	GroupID     string    `json:"group_id"`
-	UserAddress string    `json:"user_address"`
-	Role        GroupRole `json:"role"`
-	JoinedAt    time.Time `json:"joined_at"`
+	GroupID     string     `json:"group_id"`
+	UserAddress string     `json:"user_address"`
+	Role        GroupRole  `json:"role"`
+	JoinedAt    types.Time `json:"joined_at"`
 }
 
 // GroupMessage represents a message in a group
 type GroupMessage struct {
-	ID            string    `json:"id"`
-	GroupID       string    `json:"group_id"`
-	SenderAddress string    `json:"sender_address"`
-	Content       []byte    `json:"content"`
-	Timestamp     time.Time `json:"timestamp"`
-	BlockID       *string   `json:"block_id,omitempty"`
+	ID               string     `json:"id"`
+	GroupID          string     `json:"group_id"`
+	SenderAddress    string     `json:"sender_address"`
+	Content          []byte     `json:"content"`
+	Timestamp        types.Time `json:"timestamp"`
+	BlockID          *string    `json:"block_id,omitempty"`
+	ReplyToMessageID *string    `json:"reply_to_message_id,omitempty"`
+	ForwardedFrom    *string    `json:"forwarded_from,omitempty"`
+	SenderSessionID  *string    `json:"-"`
+	// System messages are posted by the server, with no sender and plain
+	// UTF-8 text content
+	System   bool        `json:"system,omitempty"`
+	EditedAt *types.Time `json:"edited_at,omitempty"`
+	TopicID  *string     `json:"topic_id,omitempty"`
+	// StickerID is the sticker a sticker message sends
+	StickerID *string `json:"sticker_id,omitempty"`
 }
 
 // CreateGroup creates a new group
-func CreateGroup(group *Group, creatorAddress string) error {
-	tx, err := database.DB.Begin()
+func CreateGroup(ctx context.Context, group *Group, creatorAddress string) error {
+	tx, err := database.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
 	// Insert group
-	_, err = tx.Exec(
-		"INSERT INTO groups (id, name, description, creator_address, photo_url) VALUES (?, ?, ?, ?, ?)",
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO chat_groups (id, name, description, creator_address, photo_url, member_count) VALUES (?, ?, ?, ?, ?, 1)",
 		group.ID, group.Name, group.Description, creatorAddress, group.PhotoURL,
 	)
 	if err != nil {
@@ -77,7 +106,7 @@ func CreateGroup(group *Group, creatorAddress string) error {
 	}
 
 	// Add creator as admin
-	_, err = tx.Exec(
+	_, err = tx.ExecContext(ctx,
 		"INSERT INTO group_members (group_id, user_address, role) VALUES (?, ?, ?)",
 		group.ID, creatorAddress, GroupRoleAdmin,
 	)
@@ -89,16 +118,16 @@ func CreateGroup(group *Group, creatorAddress string) error {
 }
 
 // GetGroupByID retrieves a group by its ID
-func GetGroupByID(id string) (*Group, error) {
+func GetGroupByID(ctx context.Context, id string) (*Group, error) {
 	group := &Group{}
-	err := database.DB.QueryRow(
-		`SELECT g.id, g.name, g.description, g.creator_address, g.photo_url, g.created_at, g.updated_at, 
-		(SELECT COUNT(*) FROM group_members WHERE group_id = g.id) as member_count 
-		FROM groups g WHERE g.id = ?`,
+	err := database.DB.QueryRowContext(ctx,
+		`SELECT g.id, g.name, g.description, g.creator_address, g.photo_url, g.created_at, g.updated_at,
+		g.member_count, g.message_count, g.topics_enabled, g.join_requests_enabled
+		FROM chat_groups g WHERE g.id = ?`,
 		id,
 	).Scan(
 		&group.ID, &group.Name, &group.Description, &group.CreatorAddress, &group.PhotoURL,
-		&group.CreatedAt, &group.UpdatedAt, &group.MemberCount,
+		&group.CreatedAt, &group.UpdatedAt, &group.MemberCount, &group.MessageCount, &group.TopicsEnabled, &group.JoinRequestsEnabled,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -110,11 +139,11 @@ func GetGroupByID(id string) (*Group, error) {
 }
 
 // GetUserGroups retrieves all groups a user is a member of
-func GetUserGroups(userAddress string) ([]*Group, error) {
-	rows, err := database.DB.Query(
-		`SELECT g.id, g.name, g.description, g.creator_address, g.photo_url, g.created_at, g.updated_at, 
-		(SELECT COUNT(*) FROM group_members WHERE group_id = g.id) as member_count 
-		FROM groups g 
+func GetUserGroups(ctx context.Context, userAddress string) ([]*Group, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		`SELECT g.id, g.name, g.description, g.creator_address, g.photo_url, g.created_at, g.updated_at,
+		g.member_count, g.message_count, g.topics_enabled, g.join_requests_enabled
+		FROM chat_groups g 
 		JOIN group_members gm ON g.id = gm.group_id 
 		WHERE gm.user_address = ? 
 		ORDER BY g.updated_at DESC`,
@@ -130,7 +159,7 @@ func GetUserGroups(userAddress string) ([]*Group, error) {
 		group := &Group{}
 		err := rows.Scan(
 			&group.ID, &group.Name, &group.Description, &group.CreatorAddress, &group.PhotoURL,
-			&group.CreatedAt, &group.UpdatedAt, &group.MemberCount,
+			&group.CreatedAt, &group.UpdatedAt, &group.MemberCount, &group.MessageCount, &group.TopicsEnabled, &group.JoinRequestsEnabled,
 		)
 		if err != nil {
 			return nil, err
@@ -144,36 +173,36 @@ func GetUserGroups(userAddress string) ([]*Group, error) {
 }
 
 // UpdateGroup updates a group's information
-func UpdateGroup(group *Group) error {
-	_, err := database.DB.Exec(
-		"UPDATE groups SET name = ?, description = ?, photo_url = ?, updated_at = NOW() WHERE id = ?",
+func UpdateGroup(ctx context.Context, group *Group) error {
+	_, err := database.DB.ExecContext(ctx,
+		"UPDATE chat_groups SET name = ?, description = ?, photo_url = ?, updated_at = NOW() WHERE id = ?",
 		group.Name, group.Description, group.PhotoURL, group.ID,
 	)
 	return err
 }
 
 // DeleteGroup deletes a group
-func DeleteGroup(id string) error {
-	tx, err := database.DB.Begin()
+func DeleteGroup(ctx context.Context, id string) error {
+	tx, err := database.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
 	// Delete group members
-	_, err = tx.Exec("DELETE FROM group_members WHERE group_id = ?", id)
+	_, err = tx.ExecContext(ctx, "DELETE FROM group_members WHERE group_id = ?", id)
 	if err != nil {
 		return err
 	}
 
 	// Delete group messages
-	_, err = tx.Exec("DELETE FROM group_messages WHERE group_id = ?", id)
+	_, err = tx.ExecContext(ctx, "DELETE FROM group_messages WHERE group_id = ?", id)
 	if err != nil {
 		return err
 	}
 
 	// Delete group
-	_, err = tx.Exec("DELETE FROM groups WHERE id = ?", id)
+	_, err = tx.ExecContext(ctx, "DELETE FROM chat_groups WHERE id = ?", id)
 	if err != nil {
 		return err
 	}
@@ -181,11 +210,27 @@ func DeleteGroup(id string) error {
 	return tx.Commit()
 }
 
-// AddGroupMember adds a member to a group
-func AddGroupMember(groupID, userAddress string, role GroupRole) error {
+// AddGroupMember adds a member to a group of fewer than maxMembers members,
+// or of any size if maxMembers is 0
+func AddGroupMember(ctx context.Context, groupID, userAddress string, role GroupRole, maxMembers int) error {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := addGroupMemberTx(ctx, tx, groupID, userAddress, role, maxMembers); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// addGroupMemberTx adds a member to a group inside a transaction
+func addGroupMemberTx(ctx context.Context, tx *sql.Tx, groupID, userAddress string, role GroupRole, maxMembers int) error {
 	// Check if user is already a member
 	var count int
-	err := database.DB.QueryRow("SELECT COUNT(*) FROM group_members WHERE group_id = ? AND user_address = ?",
+	err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM group_members WHERE group_id = ? AND user_address = ?",
 		groupID, userAddress).Scan(&count)
 	if err != nil {
 		return err
@@ -194,17 +239,45 @@ func AddGroupMember(groupID, userAddress string, role GroupRole) error {
 		return ErrAlreadyGroupMember
 	}
 
+	// Lock the member list so concurrent joins can't pass the limit
+	if maxMembers > 0 {
+		err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM group_members WHERE group_id = ? FOR UPDATE", groupID).Scan(&count)
+		if err != nil {
+			return err
+		}
+		if count >= maxMembers {
+			return ErrGroupFull
+		}
+	}
+
 	// Add member
-	_, err = database.DB.Exec(
+	_, err = tx.ExecContext(ctx,
 		"INSERT INTO group_members (group_id, user_address, role) VALUES (?, ?, ?)",
 		groupID, userAddress, role,
 	)
+	if err != nil {
+		return err
+	}
+
+	// Keep the denormalized member count in step
+	_, err = tx.ExecContext(ctx, "UPDATE chat_groups SET member_count = member_count + 1 WHERE id = ?", groupID)
 	return err
 }
 
-// RemoveGroupMember removes a member from a group
-func RemoveGroupMember(groupID, userAddress string) error {
-	result, err := database.DB.Exec(
+// RemoveGroupMember removes a member from a group. The owner can't be
+// removed, and neither can the last admin while other members remain.
+func RemoveGroupMember(ctx context.Context, groupID, userAddress string) error {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkGroupKeepsAdminTx(ctx, tx, groupID, userAddress); err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx,
 		"DELETE FROM group_members WHERE group_id = ? AND user_address = ?",
 		groupID, userAddress,
 	)
@@ -220,12 +293,25 @@ func RemoveGroupMember(groupID, userAddress string) error {
 		return ErrGroupMemberNotFound
 	}
 
-	return nil
+	// Keep the denormalized member count in step
+	_, err = tx.ExecContext(ctx, "UPDATE chat_groups SET member_count = GREATEST(member_count - 1, 0) WHERE id = ?", groupID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CountGroupMembers returns the number of members of a group
+func CountGroupMembers(ctx context.Context, groupID string) (int, error) {
+	var count int
+	err := database.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM group_members WHERE group_id = ?", groupID).Scan(&count)
+	return count, err
 }
 
 // GetGroupMembers retrieves all members of a group
-func GetGroupMembers(groupID string) ([]*GroupMember, error) {
-	rows, err := database.DB.Query(
+func GetGroupMembers(ctx context.Context, groupID string) ([]*GroupMember, error) {
+	rows, err := database.DB.QueryContext(ctx,
 		"SELECT group_id, user_address, role, joined_at FROM group_members WHERE group_id = ?",
 		groupID,
 	)
@@ -252,9 +338,9 @@ func GetGroupMembers(groupID string) ([]*GroupMember, error) {
 }
 
 // IsGroupAdmin checks if a user is an admin of a group
-func IsGroupAdmin(groupID, userAddress string) (bool, error) {
+func IsGroupAdmin(ctx context.Context, groupID, userAddress string) (bool, error) {
 	var role string
-	err := database.DB.QueryRow(
+	err := database.DB.QueryRowContext(ctx,
 		"SELECT role FROM group_members WHERE group_id = ? AND user_address = ?",
 		groupID, userAddress,
 	).Scan(&role)
@@ -269,9 +355,22 @@ func IsGroupAdmin(groupID, userAddress string) (bool, error) {
 	return GroupRole(role) == GroupRoleAdmin, nil
 }
 
-// UpdateMemberRole updates a member's role in a group
-func UpdateMemberRole(groupID, userAddress string, role GroupRole) error {
-	result, err := database.DB.Exec(
+// UpdateMemberRole updates a member's role in a group. The owner can't be
+// demoted, and neither can the last admin while other members remain.
+func UpdateMemberRole(ctx context.Context, groupID, userAddress string, role GroupRole) error {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if role != GroupRoleAdmin {
+		if err := checkGroupKeepsAdminTx(ctx, tx, groupID, userAddress); err != nil {
+			return err
+		}
+	}
+
+	result, err := tx.ExecContext(ctx,
 		"UPDATE group_members SET role = ? WHERE group_id = ? AND user_address = ?",
 		role, groupID, userAddress,
 	)
@@ -284,30 +383,202 @@ func UpdateMemberRole(groupID, userAddress string, role GroupRole) error {
 		return err
 	}
 	if rowsAffected == 0 {
-		return ErrGroupMemberNotFound
+		// MySQL doesn't count rows whose role was already set
+		var count int
+		err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM group_members WHERE group_id = ? AND user_address = ?", groupID, userAddress).Scan(&count)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrGroupMemberNotFound
+		}
 	}
 
+	return tx.Commit()
+}
+
+// checkGroupKeepsAdminTx checks that a member can leave the group's admins:
+// they aren't its owner, and another admin remains if anyone else does. It
+// locks the member list so concurrent changes can't both pass.
+func checkGroupKeepsAdminTx(ctx context.Context, tx *sql.Tx, groupID, userAddress string) error {
+	var ownerAddress string
+	err := tx.QueryRowContext(ctx, "SELECT creator_address FROM chat_groups WHERE id = ?", groupID).Scan(&ownerAddress)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrGroupNotFound
+		}
+		return err
+	}
+	if ownerAddress == userAddress {
+		return ErrGroupOwnerMustTransfer
+	}
+
+	rows, err := tx.QueryContext(ctx, "SELECT user_address, role FROM group_members WHERE group_id = ? FOR UPDATE", groupID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	isAdmin, otherAdmins, otherMembers := false, 0, 0
+	for rows.Next() {
+		var address string
+		var role GroupRole
+		if err := rows.Scan(&address, &role); err != nil {
+			return err
+		}
+		switch {
+		case address == userAddress:
+			isAdmin = role == GroupRoleAdmin
+		case role == GroupRoleAdmin:
+			otherAdmins++
+			otherMembers++
+		default:
+			otherMembers++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if isAdmin && otherAdmins == 0 && otherMembers > 0 {
+		return ErrLastGroupAdmin
+	}
 	return nil
 }
 
+// TransferGroupOwnership makes another member the owner of a group and one
+// of its admins. The previous owner stays an admin.
+func TransferGroupOwnership(ctx context.Context, groupID, ownerAddress, newOwnerAddress string) error {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var currentOwner string
+	err = tx.QueryRowContext(ctx, "SELECT creator_address FROM chat_groups WHERE id = ? FOR UPDATE", groupID).Scan(&currentOwner)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrGroupNotFound
+		}
+		return err
+	}
+	if currentOwner != ownerAddress {
+		return ErrNotGroupOwner
+	}
+
+	var count int
+	err = tx.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM group_members WHERE group_id = ? AND user_address = ? FOR UPDATE",
+		groupID, newOwnerAddress,
+	).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrGroupMemberNotFound
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE group_members SET role = ? WHERE group_id = ? AND user_address = ?",
+		GroupRoleAdmin, groupID, newOwnerAddress,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "UPDATE chat_groups SET creator_address = ? WHERE id = ?", newOwnerAddress, groupID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
 // CreateGroupMessage creates a new message in a group
-func CreateGroupMessage(message *GroupMessage) error {
-	_, err := database.DB.Exec(
-		"INSERT INTO group_messages (id, group_id, sender_address, content) VALUES (?, ?, ?, ?)",
-		message.ID, message.GroupID, message.SenderAddress, message.Content,
+func CreateGroupMessage(ctx context.Context, message *GroupMessage) error {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO group_messages (id, group_id, sender_address, content, reply_to_message_id, forwarded_from, sender_session_id, is_system, topic_id, sticker_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		message.ID, message.GroupID, message.SenderAddress, message.Content, message.ReplyToMessageID, message.ForwardedFrom, message.SenderSessionID, message.System, message.TopicID, message.StickerID,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+
+	// Keep the denormalized message count in step
+	_, err = tx.ExecContext(ctx, "UPDATE chat_groups SET message_count = message_count + 1 WHERE id = ?", message.GroupID)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	message.Timestamp = types.NewTime(clock.Now())
+	return nil
+}
+
+// GetGroupMessageByID retrieves a group message by its ID
+func GetGroupMessageByID(ctx context.Context, id string) (*GroupMessage, error) {
+	message := &GroupMessage{}
+	err := database.DB.QueryRowContext(ctx,
+		"SELECT id, group_id, sender_address, content, timestamp, block_id, reply_to_message_id, forwarded_from, sender_session_id, is_system, edited_at, topic_id, sticker_id FROM group_messages WHERE id = ?",
+		id,
+	).Scan(
+		&message.ID, &message.GroupID, &message.SenderAddress, &message.Content,
+		&message.Timestamp, &message.BlockID, &message.ReplyToMessageID, &message.ForwardedFrom, &message.SenderSessionID, &message.System, &message.EditedAt, &message.TopicID, &message.StickerID,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrMessageNotFound
+		}
+		return nil, err
+	}
+	return message, nil
 }
 
-// GetGroupMessages retrieves messages from a group
-func GetGroupMessages(groupID string, limit, offset int) ([]*GroupMessage, error) {
-	rows, err := database.DB.Query(
-		"SELECT id, group_id, sender_address, content, timestamp, block_id FROM group_messages WHERE group_id = ? ORDER BY timestamp DESC LIMIT ? OFFSET ?",
-		groupID, limit, offset,
+// EditGroupMessage replaces the content of a group message, returning when
+// it was edited
+func EditGroupMessage(ctx context.Context, id string, content []byte) (*time.Time, error) {
+	editedAt := clock.Now()
+	result, err := database.DB.ExecContext(ctx,
+		"UPDATE group_messages SET content = ?, edited_at = ? WHERE id = ? AND is_system = FALSE",
+		content, editedAt, id,
 	)
 	if err != nil {
 		return nil, err
 	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		return nil, ErrMessageNotFound
+	}
+	return &editedAt, nil
+}
+
+// GetGroupMessages retrieves messages from a group, or from one of its
+// topics if topicID is set
+func GetGroupMessages(ctx context.Context, groupID, topicID string, limit, offset int) ([]*GroupMessage, error) {
+	query := "SELECT id, group_id, sender_address, content, timestamp, block_id, reply_to_message_id, forwarded_from, sender_session_id, is_system, edited_at, topic_id, sticker_id FROM group_messages WHERE group_id = ?"
+	args := []interface{}{groupID}
+	if topicID != "" {
+		query += " AND topic_id = ?"
+		args = append(args, topicID)
+	}
+	query += " ORDER BY timestamp DESC LIMIT ? OFFSET ?"
+	args = append(args, limit, offset)
+
+	rows, err := database.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
 	messages := []*GroupMessage{}
@@ -315,7 +586,7 @@ func GetGroupMessages(groupID string, limit, offset int) ([]*GroupMessage, error
 		message := &GroupMessage{}
 		err := rows.Scan(
 			&message.ID, &message.GroupID, &message.SenderAddress, &message.Content,
-			&message.Timestamp, &message.BlockID,
+			&message.Timestamp, &message.BlockID, &message.ReplyToMessageID, &message.ForwardedFrom, &message.SenderSessionID, &message.System, &message.EditedAt, &message.TopicID, &message.StickerID,
 		)
 		if err != nil {
 			return nil, err
@@ -329,7 +600,55 @@ func GetGroupMessages(groupID string, limit, offset int) ([]*GroupMessage, error
 }
 
 // DeleteGroupMessage deletes a message from a group
-func DeleteGroupMessage(id string) error {
-	_, err := database.DB.Exec("DELETE FROM group_messages WHERE id = ?", id)
-	return err
+func DeleteGroupMessage(ctx context.Context, id string) error {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var groupID string
+	err = tx.QueryRowContext(ctx, "SELECT group_id FROM group_messages WHERE id = ? FOR UPDATE", id).Scan(&groupID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM group_messages WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	// A poll goes with the message it is attached to
+	if _, err := tx.ExecContext(ctx, "DELETE FROM polls WHERE message_id = ?", id); err != nil {
+		return err
+	}
+
+	// Keep the denormalized message count in step
+	_, err = tx.ExecContext(ctx, "UPDATE chat_groups SET message_count = GREATEST(message_count - 1, 0) WHERE id = ?", groupID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// MarkGroupMessageDelivered records that a member received a group message.
+// It returns false if the delivery was already recorded.
+func MarkGroupMessageDelivered(ctx context.Context, messageID, userAddress string) (bool, error) {
+	result, err := database.DB.ExecContext(ctx,
+		"INSERT IGNORE INTO group_message_deliveries (message_id, user_address) VALUES (?, ?)",
+		messageID, userAddress,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
 }
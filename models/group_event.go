@@ -0,0 +1,265 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
+)
+
+var (
+	// ErrGroupEventNotFound is returned when a group event is not found
+	ErrGroupEventNotFound = errors.New("group event not found")
+)
+
+// RSVPStatus is a member's answer to a group event
+type RSVPStatus string
+
+const (
+	// RSVPGoing means the member will attend
+	RSVPGoing RSVPStatus = "going"
+	// RSVPMaybe means the member might attend
+	RSVPMaybe RSVPStatus = "maybe"
+	// RSVPNotGoing means the member won't attend and isn't reminded
+	RSVPNotGoing RSVPStatus = "not_going"
+)
+
+// IsValidRSVPStatus checks if an RSVP status is supported
+func IsValidRSVPStatus(status RSVPStatus) bool {
+	return status == RSVPGoing || status == RSVPMaybe || status == RSVPNotGoing
+}
+
+// GroupEvent is a scheduled event in a group. Members are reminded before
+// it starts, and a system message is posted to the group when it does.
+type GroupEvent struct {
+	ID             string      `json:"id"`
+	GroupID        string      `json:"group_id"`
+	CreatorAddress string      `json:"creator_address"`
+	Title          string      `json:"title"`
+	Description    string      `json:"description,omitempty"`
+	StartsAt       types.Time  `json:"starts_at"`
+	RemindedAt     *types.Time `json:"reminded_at,omitempty"`
+	StartedAt      *types.Time `json:"started_at,omitempty"`
+	CreatedAt      types.Time  `json:"created_at"`
+	Going          int         `json:"going"`
+	Maybe          int         `json:"maybe"`
+	NotGoing       int         `json:"not_going"`
+}
+
+// GroupEventRSVP is one member's answer to a group event
+type GroupEventRSVP struct {
+	UserAddress string     `json:"user_address"`
+	Status      RSVPStatus `json:"status"`
+	UpdatedAt   types.Time `json:"updated_at"`
+}
+
+// groupEventColumns are the columns scanned by scanGroupEvent, with the
+// RSVP counts of each event
+const groupEventColumns = `e.id, e.group_id, e.creator_address, e.title, COALESCE(e.description, ''),
+	e.starts_at, e.reminded_at, e.started_at, e.created_at,
+	(SELECT COUNT(*) FROM group_event_rsvps r WHERE r.event_id = e.id AND r.status = 'going'),
+	(SELECT COUNT(*) FROM group_event_rsvps r WHERE r.event_id = e.id AND r.status = 'maybe'),
+	(SELECT COUNT(*) FROM group_event_rsvps r WHERE r.event_id = e.id AND r.status = 'not_going')`
+
+// scanGroupEvent scans a row of groupEventColumns
+func scanGroupEvent(row interface{ Scan(...any) error }) (*GroupEvent, error) {
+	event := &GroupEvent{}
+	err := row.Scan(
+		&event.ID, &event.GroupID, &event.CreatorAddress, &event.Title, &event.Description,
+		&event.StartsAt, &event.RemindedAt, &event.StartedAt, &event.CreatedAt,
+		&event.Going, &event.Maybe, &event.NotGoing,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// queryGroupEvents runs a query selecting groupEventColumns
+func queryGroupEvents(ctx context.Context, query string, args ...interface{}) ([]*GroupEvent, error) {
+	rows, err := database.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*GroupEvent{}
+	for rows.Next() {
+		event, err := scanGroupEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// CreateGroupEvent schedules an event in a group
+func CreateGroupEvent(ctx context.Context, event *GroupEvent) error {
+	_, err := database.DB.ExecContext(ctx,
+		"INSERT INTO group_events (id, group_id, creator_address, title, description, starts_at) VALUES (?, ?, ?, ?, ?, ?)",
+		event.ID, event.GroupID, event.CreatorAddress, event.Title, event.Description, event.StartsAt,
+	)
+	return err
+}
+
+// GetGroupEvent retrieves an event of a group
+func GetGroupEvent(ctx context.Context, groupID, eventID string) (*GroupEvent, error) {
+	event, err := scanGroupEvent(database.DB.QueryRowContext(ctx,
+		"SELECT "+groupEventColumns+" FROM group_events e WHERE e.id = ? AND e.group_id = ?",
+		eventID, groupID,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrGroupEventNotFound
+		}
+		return nil, err
+	}
+	return event, nil
+}
+
+// GetGroupEvents lists the events of a group starting after a time, soonest first
+func GetGroupEvents(ctx context.Context, groupID string, after time.Time, limit int) ([]*GroupEvent, error) {
+	return queryGroupEvents(ctx,
+		"SELECT "+groupEventColumns+" FROM group_events e WHERE e.group_id = ? AND e.starts_at >= ? ORDER BY e.starts_at LIMIT ?",
+		groupID, after, limit,
+	)
+}
+
+// DeleteGroupEvent cancels an event of a group
+func DeleteGroupEvent(ctx context.Context, groupID, eventID string) error {
+	result, err := database.DB.ExecContext(ctx, "DELETE FROM group_events WHERE id = ? AND group_id = ?", eventID, groupID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrGroupEventNotFound
+	}
+	return nil
+}
+
+// SetGroupEventRSVP records or changes a member's answer to an event
+func SetGroupEventRSVP(ctx context.Context, eventID, userAddress string, status RSVPStatus) error {
+	_, err := database.DB.ExecContext(ctx,
+		`INSERT INTO group_event_rsvps (event_id, user_address, status) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE status = VALUES(status)`,
+		eventID, userAddress, status,
+	)
+	return err
+}
+
+// DeleteGroupEventRSVP withdraws a member's answer to an event
+func DeleteGroupEventRSVP(ctx context.Context, eventID, userAddress string) error {
+	_, err := database.DB.ExecContext(ctx,
+		"DELETE FROM group_event_rsvps WHERE event_id = ? AND user_address = ?",
+		eventID, userAddress,
+	)
+	return err
+}
+
+// GetGroupEventRSVPs lists the answers to an event, latest first
+func GetGroupEventRSVPs(ctx context.Context, eventID string) ([]*GroupEventRSVP, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT user_address, status, updated_at FROM group_event_rsvps WHERE event_id = ? ORDER BY updated_at DESC",
+		eventID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rsvps := []*GroupEventRSVP{}
+	for rows.Next() {
+		rsvp := &GroupEventRSVP{}
+		if err := rows.Scan(&rsvp.UserAddress, &rsvp.Status, &rsvp.UpdatedAt); err != nil {
+			return nil, err
+		}
+		rsvps = append(rsvps, rsvp)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return rsvps, nil
+}
+
+// GetGroupEventsToRemind lists the events starting before a time whose
+// reminders haven't been sent, leaving out events that already started
+func GetGroupEventsToRemind(ctx context.Context, startsBefore time.Time) ([]*GroupEvent, error) {
+	return queryGroupEvents(ctx,
+		"SELECT "+groupEventColumns+` FROM group_events e
+		WHERE e.reminded_at IS NULL AND e.started_at IS NULL AND e.starts_at <= ? ORDER BY e.starts_at`,
+		startsBefore,
+	)
+}
+
+// GetStartedGroupEvents lists the events that have started but haven't
+// been announced
+func GetStartedGroupEvents(ctx context.Context, now time.Time) ([]*GroupEvent, error) {
+	return queryGroupEvents(ctx,
+		"SELECT "+groupEventColumns+" FROM group_events e WHERE e.started_at IS NULL AND e.starts_at <= ? ORDER BY e.starts_at",
+		now,
+	)
+}
+
+// ClaimGroupEventReminder marks an event's reminder as sent. It returns
+// false if another instance got there first, so each reminder goes out once.
+func ClaimGroupEventReminder(ctx context.Context, eventID string, now time.Time) (bool, error) {
+	return claimGroupEvent(ctx, "UPDATE group_events SET reminded_at = ? WHERE id = ? AND reminded_at IS NULL", now, eventID)
+}
+
+// ClaimGroupEventStart marks an event as started. It returns false if
+// another instance got there first, so each start is announced once.
+func ClaimGroupEventStart(ctx context.Context, eventID string, now time.Time) (bool, error) {
+	return claimGroupEvent(ctx, "UPDATE group_events SET started_at = ? WHERE id = ? AND started_at IS NULL", now, eventID)
+}
+
+// claimGroupEvent runs a conditional update and reports whether it matched
+func claimGroupEvent(ctx context.Context, query string, now time.Time, eventID string) (bool, error) {
+	result, err := database.DB.ExecContext(ctx, query, now, eventID)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// GetGroupEventReminderRecipients lists the members of an event's group who
+// haven't said they aren't going
+func GetGroupEventReminderRecipients(ctx context.Context, event *GroupEvent) ([]string, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		`SELECT gm.user_address FROM group_members gm
+		LEFT JOIN group_event_rsvps r ON r.event_id = ? AND r.user_address = gm.user_address
+		WHERE gm.group_id = ? AND (r.status IS NULL OR r.status <> ?)`,
+		event.ID, event.GroupID, RSVPNotGoing,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	addresses := []string{}
+	for rows.Next() {
+		var address string
+		if err := rows.Scan(&address); err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, address)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return addresses, nil
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"context"
+
+	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
+)
+
+// groupHistoryPageSize is how many messages StreamGroupHistory reads per query
+const groupHistoryPageSize = 500
+
+// GroupHistoryEntry is a group message with what is known about its sender
+type GroupHistoryEntry struct {
+	*GroupMessage
+	SenderUsername string
+	// SenderRole and SenderJoinedAt are nil if the sender left the group
+	SenderRole     *GroupRole
+	SenderJoinedAt *types.Time
+}
+
+// StreamGroupHistory calls fn for every message of a group, oldest first.
+// Messages are read a page at a time, continuing after the last message of
+// the previous page, so neither the whole history nor a query is held open
+// while fn runs.
+func StreamGroupHistory(ctx context.Context, groupID string, fn func(*GroupHistoryEntry) error) error {
+	var cursor *ReadCursor
+	for {
+		page, err := groupHistoryPage(ctx, groupID, cursor)
+		if err != nil {
+			return err
+		}
+		for _, entry := range page {
+			if err := fn(entry); err != nil {
+				return err
+			}
+		}
+		if len(page) < groupHistoryPageSize {
+			return nil
+		}
+
+		last := page[len(page)-1]
+		cursor = &ReadCursor{MessageID: last.ID, Timestamp: last.Timestamp.Time}
+	}
+}
+
+// groupHistoryPage reads the page of a group's history after a cursor, or
+// the first page if the cursor is nil
+func groupHistoryPage(ctx context.Context, groupID string, cursor *ReadCursor) ([]*GroupHistoryEntry, error) {
+	query := `SELECT m.id, m.group_id, m.sender_address, m.content, m.timestamp, m.block_id, m.reply_to_message_id,
+		m.forwarded_from, m.is_system, m.edited_at, m.topic_id, COALESCE(u.username, ''), gm.role, gm.joined_at
+		FROM group_messages m
+		LEFT JOIN users u ON u.address = m.sender_address
+		LEFT JOIN group_members gm ON gm.group_id = m.group_id AND gm.user_address = m.sender_address
+		WHERE m.group_id = ?`
+	args := []interface{}{groupID}
+	if cursor != nil {
+		query += " AND (m.timestamp > ? OR (m.timestamp = ? AND m.id > ?))"
+		args = append(args, cursor.Timestamp, cursor.Timestamp, cursor.MessageID)
+	}
+	query += " ORDER BY m.timestamp ASC, m.id ASC LIMIT ?"
+	args = append(args, groupHistoryPageSize)
+
+	rows, err := database.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	page := make([]*GroupHistoryEntry, 0, groupHistoryPageSize)
+	for rows.Next() {
+		entry := &GroupHistoryEntry{GroupMessage: &GroupMessage{}}
+		err := rows.Scan(
+			&entry.ID, &entry.GroupID, &entry.SenderAddress, &entry.Content, &entry.Timestamp, &entry.BlockID, &entry.ReplyToMessageID,
+			&entry.ForwardedFrom, &entry.System, &entry.EditedAt, &entry.TopicID, &entry.SenderUsername, &entry.SenderRole, &entry.SenderJoinedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		page = append(page, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
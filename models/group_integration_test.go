@@ -0,0 +1,273 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/piko/piko/database/dbtest"
+)
+
+// testAddress returns a unique address for a test's member, so tests sharing
+// the scratch database don't see each other's rows
+func testAddress(t *testing.T, name string) string {
+	t.Helper()
+	return fmt.Sprintf("PikoTest%s%d", name, time.Now().UnixNano())
+}
+
+// createTestGroup creates a group owned by ownerAddress
+func createTestGroup(t *testing.T, ownerAddress string) *Group {
+	t.Helper()
+
+	group := &Group{
+		ID:          fmt.Sprintf("test-group-%d", time.Now().UnixNano()),
+		Name:        "Integration",
+		Description: "Group lifecycle test",
+	}
+	if err := CreateGroup(context.Background(), group, ownerAddress); err != nil {
+		t.Fatalf("CreateGroup: %v", err)
+	}
+	return group
+}
+
+// mustGetGroup retrieves a group that has to exist
+func mustGetGroup(t *testing.T, id string) *Group {
+	t.Helper()
+
+	group, err := GetGroupByID(context.Background(), id)
+	if err != nil {
+		t.Fatalf("GetGroupByID(%s): %v", id, err)
+	}
+	return group
+}
+
+func TestIntegrationGroupCreateAndUpdate(t *testing.T) {
+	dbtest.Open(t)
+	ctx := context.Background()
+	owner := testAddress(t, "Owner")
+
+	created := createTestGroup(t, owner)
+
+	group := mustGetGroup(t, created.ID)
+	if group.Name != created.Name || group.Description != created.Description || group.CreatorAddress != owner {
+		t.Errorf("got group %+v, want name %q, description %q and creator %s", group, created.Name, created.Description, owner)
+	}
+	if group.MemberCount != 1 || group.MessageCount != 0 {
+		t.Errorf("got %d members and %d messages, want 1 and 0", group.MemberCount, group.MessageCount)
+	}
+	if isAdmin, err := IsGroupAdmin(ctx, group.ID, owner); err != nil || !isAdmin {
+		t.Errorf("IsGroupAdmin(owner) = %v, %v, want true", isAdmin, err)
+	}
+
+	groups, err := GetUserGroups(ctx, owner)
+	if err != nil {
+		t.Fatalf("GetUserGroups: %v", err)
+	}
+	if len(groups) != 1 || groups[0].ID != group.ID {
+		t.Errorf("GetUserGroups returned %d groups, want only %s", len(groups), group.ID)
+	}
+
+	group.Name = "Renamed"
+	group.Description = "Updated description"
+	if err := UpdateGroup(ctx, group); err != nil {
+		t.Fatalf("UpdateGroup: %v", err)
+	}
+	if updated := mustGetGroup(t, group.ID); updated.Name != "Renamed" || updated.Description != "Updated description" {
+		t.Errorf("got name %q and description %q after update", updated.Name, updated.Description)
+	}
+
+	if _, err := GetGroupByID(ctx, "test-group-missing"); !errors.Is(err, ErrGroupNotFound) {
+		t.Errorf("GetGroupByID(missing) error = %v, want ErrGroupNotFound", err)
+	}
+}
+
+func TestIntegrationGroupMembers(t *testing.T) {
+	dbtest.Open(t)
+	ctx := context.Background()
+	owner, member, latecomer := testAddress(t, "Owner"), testAddress(t, "Member"), testAddress(t, "Late")
+
+	group := createTestGroup(t, owner)
+
+	if err := AddGroupMember(ctx, group.ID, member, GroupRoleMember, 2); err != nil {
+		t.Fatalf("AddGroupMember: %v", err)
+	}
+	if err := AddGroupMember(ctx, group.ID, member, GroupRoleMember, 0); !errors.Is(err, ErrAlreadyGroupMember) {
+		t.Errorf("adding a member twice: error = %v, want ErrAlreadyGroupMember", err)
+	}
+	if err := AddGroupMember(ctx, group.ID, latecomer, GroupRoleMember, 2); !errors.Is(err, ErrGroupFull) {
+		t.Errorf("adding past the limit: error = %v, want ErrGroupFull", err)
+	}
+
+	members, err := GetGroupMembers(ctx, group.ID)
+	if err != nil {
+		t.Fatalf("GetGroupMembers: %v", err)
+	}
+	if len(members) != 2 {
+		t.Errorf("got %d members, want 2", len(members))
+	}
+	if got := mustGetGroup(t, group.ID).MemberCount; got != 2 {
+		t.Errorf("member_count = %d, want 2", got)
+	}
+	if isAdmin, err := IsGroupAdmin(ctx, group.ID, member); err != nil || isAdmin {
+		t.Errorf("IsGroupAdmin(member) = %v, %v, want false", isAdmin, err)
+	}
+
+	if err := RemoveGroupMember(ctx, group.ID, member); err != nil {
+		t.Fatalf("RemoveGroupMember: %v", err)
+	}
+	if err := RemoveGroupMember(ctx, group.ID, member); !errors.Is(err, ErrGroupMemberNotFound) {
+		t.Errorf("removing a member twice: error = %v, want ErrGroupMemberNotFound", err)
+	}
+	if got := mustGetGroup(t, group.ID).MemberCount; got != 1 {
+		t.Errorf("member_count = %d after removal, want 1", got)
+	}
+	if _, err := IsGroupAdmin(ctx, group.ID, member); !errors.Is(err, ErrGroupMemberNotFound) {
+		t.Errorf("IsGroupAdmin(removed member) error = %v, want ErrGroupMemberNotFound", err)
+	}
+}
+
+func TestIntegrationGroupOwnershipAndAdmins(t *testing.T) {
+	dbtest.Open(t)
+	ctx := context.Background()
+	owner, admin, member := testAddress(t, "Owner"), testAddress(t, "Admin"), testAddress(t, "Member")
+
+	group := createTestGroup(t, owner)
+	for _, address := range []string{admin, member} {
+		if err := AddGroupMember(ctx, group.ID, address, GroupRoleMember, 0); err != nil {
+			t.Fatalf("AddGroupMember(%s): %v", address, err)
+		}
+	}
+
+	if err := RemoveGroupMember(ctx, group.ID, owner); !errors.Is(err, ErrGroupOwnerMustTransfer) {
+		t.Errorf("removing the owner: error = %v, want ErrGroupOwnerMustTransfer", err)
+	}
+	if err := UpdateMemberRole(ctx, group.ID, owner, GroupRoleMember); !errors.Is(err, ErrGroupOwnerMustTransfer) {
+		t.Errorf("demoting the owner: error = %v, want ErrGroupOwnerMustTransfer", err)
+	}
+
+	if err := UpdateMemberRole(ctx, group.ID, admin, GroupRoleAdmin); err != nil {
+		t.Fatalf("UpdateMemberRole: %v", err)
+	}
+	if isAdmin, err := IsGroupAdmin(ctx, group.ID, admin); err != nil || !isAdmin {
+		t.Errorf("IsGroupAdmin(promoted member) = %v, %v, want true", isAdmin, err)
+	}
+
+	if err := TransferGroupOwnership(ctx, group.ID, admin, member); !errors.Is(err, ErrNotGroupOwner) {
+		t.Errorf("transferring by a non-owner: error = %v, want ErrNotGroupOwner", err)
+	}
+	if err := TransferGroupOwnership(ctx, group.ID, owner, testAddress(t, "Stranger")); !errors.Is(err, ErrGroupMemberNotFound) {
+		t.Errorf("transferring to a non-member: error = %v, want ErrGroupMemberNotFound", err)
+	}
+	if err := TransferGroupOwnership(ctx, group.ID, owner, member); err != nil {
+		t.Fatalf("TransferGroupOwnership: %v", err)
+	}
+	if got := mustGetGroup(t, group.ID).CreatorAddress; got != member {
+		t.Errorf("owner = %s after transfer, want %s", got, member)
+	}
+	if isAdmin, err := IsGroupAdmin(ctx, group.ID, member); err != nil || !isAdmin {
+		t.Errorf("IsGroupAdmin(new owner) = %v, %v, want true", isAdmin, err)
+	}
+
+	// The previous owner stays an admin and can now leave
+	if err := RemoveGroupMember(ctx, group.ID, owner); err != nil {
+		t.Errorf("removing the previous owner: %v", err)
+	}
+}
+
+func TestIntegrationGroupMessages(t *testing.T) {
+	dbtest.Open(t)
+	ctx := context.Background()
+	owner := testAddress(t, "Owner")
+
+	group := createTestGroup(t, owner)
+
+	ids := []string{}
+	for i := 0; i < 3; i++ {
+		message := &GroupMessage{
+			ID:            fmt.Sprintf("test-group-message-%d-%d", time.Now().UnixNano(), i),
+			GroupID:       group.ID,
+			SenderAddress: owner,
+			Content:       []byte(fmt.Sprintf("message %d", i)),
+		}
+		if err := CreateGroupMessage(ctx, message); err != nil {
+			t.Fatalf("CreateGroupMessage: %v", err)
+		}
+		ids = append(ids, message.ID)
+	}
+	if got := mustGetGroup(t, group.ID).MessageCount; got != 3 {
+		t.Errorf("message_count = %d, want 3", got)
+	}
+
+	messages, err := GetGroupMessages(ctx, group.ID, "", 2, 0)
+	if err != nil {
+		t.Fatalf("GetGroupMessages: %v", err)
+	}
+	if len(messages) != 2 {
+		t.Errorf("got %d messages with limit 2, want 2", len(messages))
+	}
+
+	edited, err := EditGroupMessage(ctx, ids[0], []byte("edited"))
+	if err != nil {
+		t.Fatalf("EditGroupMessage: %v", err)
+	}
+	message, err := GetGroupMessageByID(ctx, ids[0])
+	if err != nil {
+		t.Fatalf("GetGroupMessageByID: %v", err)
+	}
+	if string(message.Content) != "edited" || message.EditedAt == nil || edited == nil {
+		t.Errorf("got content %q and edited_at %v after edit", message.Content, message.EditedAt)
+	}
+
+	if err := DeleteGroupMessage(ctx, ids[0]); err != nil {
+		t.Fatalf("DeleteGroupMessage: %v", err)
+	}
+	if _, err := GetGroupMessageByID(ctx, ids[0]); !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("GetGroupMessageByID(deleted) error = %v, want ErrMessageNotFound", err)
+	}
+	if got := mustGetGroup(t, group.ID).MessageCount; got != 2 {
+		t.Errorf("message_count = %d after delete, want 2", got)
+	}
+}
+
+func TestIntegrationGroupDelete(t *testing.T) {
+	dbtest.Open(t)
+	ctx := context.Background()
+	owner, member := testAddress(t, "Owner"), testAddress(t, "Member")
+
+	group := createTestGroup(t, owner)
+	if err := AddGroupMember(ctx, group.ID, member, GroupRoleMember, 0); err != nil {
+		t.Fatalf("AddGroupMember: %v", err)
+	}
+	message := &GroupMessage{
+		ID:            fmt.Sprintf("test-group-message-%d", time.Now().UnixNano()),
+		GroupID:       group.ID,
+		SenderAddress: member,
+		Content:       []byte("hello"),
+	}
+	if err := CreateGroupMessage(ctx, message); err != nil {
+		t.Fatalf("CreateGroupMessage: %v", err)
+	}
+
+	if err := DeleteGroup(ctx, group.ID); err != nil {
+		t.Fatalf("DeleteGroup: %v", err)
+	}
+
+	if _, err := GetGroupByID(ctx, group.ID); !errors.Is(err, ErrGroupNotFound) {
+		t.Errorf("GetGroupByID(deleted) error = %v, want ErrGroupNotFound", err)
+	}
+	if count, err := CountGroupMembers(ctx, group.ID); err != nil || count != 0 {
+		t.Errorf("CountGroupMembers(deleted) = %d, %v, want 0", count, err)
+	}
+	if _, err := GetGroupMessageByID(ctx, message.ID); !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("GetGroupMessageByID(deleted group) error = %v, want ErrMessageNotFound", err)
+	}
+	groups, err := GetUserGroups(ctx, member)
+	if err != nil {
+		t.Fatalf("GetUserGroups: %v", err)
+	}
+	if len(groups) != 0 {
+		t.Errorf("GetUserGroups returned %d groups after delete, want 0", len(groups))
+	}
+}
@@ -0,0 +1,134 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
+)
+
+var (
+	// ErrGroupInviteNotFound is returned when a group invite doesn't exist
+	ErrGroupInviteNotFound = errors.New("group invite not found")
+	// ErrGroupInviteInvalid is returned when an invite was revoked, has
+	// expired or has no uses left
+	ErrGroupInviteInvalid = errors.New("group invite invalid or expired")
+)
+
+// GroupInvite is a tokenized link to join a group
+type GroupInvite struct {
+	Token     string      `json:"token"`
+	GroupID   string      `json:"group_id"`
+	CreatedBy string      `json:"created_by"`
+	MaxUses   *int        `json:"max_uses,omitempty"`
+	Uses      int         `json:"uses"`
+	ExpiresAt *types.Time `json:"expires_at,omitempty"`
+	RevokedAt *types.Time `json:"revoked_at,omitempty"`
+	CreatedAt types.Time  `json:"created_at"`
+}
+
+// CreateGroupInvite stores a new group invite
+func CreateGroupInvite(ctx context.Context, invite *GroupInvite) error {
+	_, err := database.DB.ExecContext(ctx,
+		"INSERT INTO group_invites (token, group_id, created_by, max_uses, expires_at) VALUES (?, ?, ?, ?, ?)",
+		invite.Token, invite.GroupID, invite.CreatedBy, invite.MaxUses, invite.ExpiresAt,
+	)
+	return err
+}
+
+// GetGroupInvites retrieves the invites of a group, newest first
+func GetGroupInvites(ctx context.Context, groupID string) ([]*GroupInvite, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		`SELECT token, group_id, created_by, max_uses, uses, expires_at, revoked_at, created_at
+		FROM group_invites WHERE group_id = ? ORDER BY created_at DESC`,
+		groupID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invites := []*GroupInvite{}
+	for rows.Next() {
+		invite := &GroupInvite{}
+		err := rows.Scan(
+			&invite.Token, &invite.GroupID, &invite.CreatedBy, &invite.MaxUses, &invite.Uses,
+			&invite.ExpiresAt, &invite.RevokedAt, &invite.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		invites = append(invites, invite)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return invites, nil
+}
+
+// RevokeGroupInvite stops an invite of a group from being used
+func RevokeGroupInvite(ctx context.Context, groupID, token string) error {
+	result, err := database.DB.ExecContext(ctx,
+		"UPDATE group_invites SET revoked_at = NOW() WHERE token = ? AND group_id = ? AND revoked_at IS NULL",
+		token, groupID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrGroupInviteNotFound
+	}
+	return nil
+}
+
+// RedeemGroupInvite adds a user to the group of an invite and counts the
+// use. It returns the ID of the group joined. beforeJoin is called with the
+// group's ID while the invite is locked, and its error aborts the join.
+// Groups with maxMembers members are full; 0 means no limit.
+func RedeemGroupInvite(ctx context.Context, token, userAddress string, maxMembers int, beforeJoin func(groupID string) error) (string, error) {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	// Lock the invite so concurrent joins can't exceed max uses
+	var groupID string
+	err = tx.QueryRowContext(ctx,
+		`SELECT group_id FROM group_invites
+		WHERE token = ? AND revoked_at IS NULL
+		AND (expires_at IS NULL OR expires_at > ?)
+		AND (max_uses IS NULL OR uses < max_uses)
+		FOR UPDATE`,
+		token, clock.Now(),
+	).Scan(&groupID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", ErrGroupInviteInvalid
+		}
+		return "", err
+	}
+
+	if err := beforeJoin(groupID); err != nil {
+		return "", err
+	}
+
+	if err := addGroupMemberTx(ctx, tx, groupID, userAddress, GroupRoleMember, maxMembers); err != nil {
+		return "", err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE group_invites SET uses = uses + 1 WHERE token = ?", token)
+	if err != nil {
+		return "", err
+	}
+
+	return groupID, tx.Commit()
+}
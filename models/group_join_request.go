@@ -0,0 +1,193 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
+)
+
+var (
+	// ErrJoinRequestNotFound is returned when a user has no pending request
+	// to join a group
+	ErrJoinRequestNotFound = errors.New("join request not found")
+	// ErrJoinRequestPending is returned when a user asks to join a group
+	// while an earlier request is still pending
+	ErrJoinRequestPending = errors.New("join request already pending")
+)
+
+// JoinRequestStatus is the state of a request to join a group
+type JoinRequestStatus string
+
+const (
+	// JoinRequestPending is a request awaiting an admin's decision
+	JoinRequestPending JoinRequestStatus = "pending"
+	// JoinRequestApproved is a request that made the user a member
+	JoinRequestApproved JoinRequestStatus = "approved"
+	// JoinRequestRejected is a request an admin turned down
+	JoinRequestRejected JoinRequestStatus = "rejected"
+)
+
+// GroupJoinRequest is a user's request to join a group in join request mode
+type GroupJoinRequest struct {
+	GroupID     string            `json:"group_id"`
+	UserAddress string            `json:"user_address"`
+	Message     string            `json:"message,omitempty"`
+	Status      JoinRequestStatus `json:"status"`
+	DecidedBy   *string           `json:"decided_by,omitempty"`
+	DecidedAt   *types.Time       `json:"decided_at,omitempty"`
+	CreatedAt   types.Time        `json:"created_at"`
+}
+
+// SetGroupJoinRequestsEnabled turns a group's join request mode on or off
+func SetGroupJoinRequestsEnabled(ctx context.Context, groupID string, enabled bool) error {
+	_, err := database.DB.ExecContext(ctx,
+		"UPDATE chat_groups SET join_requests_enabled = ? WHERE id = ?",
+		enabled, groupID,
+	)
+	return err
+}
+
+// GroupJoinRequestsEnabled reports whether a group accepts join requests
+func GroupJoinRequestsEnabled(ctx context.Context, groupID string) (bool, error) {
+	var enabled bool
+	err := database.DB.QueryRowContext(ctx, "SELECT join_requests_enabled FROM chat_groups WHERE id = ?", groupID).Scan(&enabled)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, ErrGroupNotFound
+		}
+		return false, err
+	}
+	return enabled, nil
+}
+
+// CreateGroupJoinRequest files a pending request to join a group. A user
+// whose earlier request was decided can ask again.
+func CreateGroupJoinRequest(ctx context.Context, request *GroupJoinRequest) error {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var members int
+	err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM group_members WHERE group_id = ? AND user_address = ?",
+		request.GroupID, request.UserAddress).Scan(&members)
+	if err != nil {
+		return err
+	}
+	if members > 0 {
+		return ErrAlreadyGroupMember
+	}
+
+	var status JoinRequestStatus
+	err = tx.QueryRowContext(ctx,
+		"SELECT status FROM group_join_requests WHERE group_id = ? AND user_address = ? FOR UPDATE",
+		request.GroupID, request.UserAddress,
+	).Scan(&status)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if status == JoinRequestPending {
+		return ErrJoinRequestPending
+	}
+
+	now := clock.Now()
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO group_join_requests (group_id, user_address, message, status, created_at) VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE message = VALUES(message), status = VALUES(status), created_at = VALUES(created_at),
+			decided_by = NULL, decided_at = NULL`,
+		request.GroupID, request.UserAddress, request.Message, JoinRequestPending, now,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	request.Status = JoinRequestPending
+	request.CreatedAt = types.NewTime(now)
+	return nil
+}
+
+// GetPendingGroupJoinRequests lists a group's pending join requests,
+// oldest first
+func GetPendingGroupJoinRequests(ctx context.Context, groupID string) ([]*GroupJoinRequest, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		`SELECT group_id, user_address, COALESCE(message, ''), status, decided_by, decided_at, created_at
+		FROM group_join_requests WHERE group_id = ? AND status = ? ORDER BY created_at`,
+		groupID, JoinRequestPending,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	requests := []*GroupJoinRequest{}
+	for rows.Next() {
+		request := &GroupJoinRequest{}
+		err := rows.Scan(
+			&request.GroupID, &request.UserAddress, &request.Message, &request.Status,
+			&request.DecidedBy, &request.DecidedAt, &request.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, request)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// DecideGroupJoinRequest approves or rejects a pending join request.
+// Approving adds the user as a regular member of a group of fewer than
+// maxMembers members, or of any size if maxMembers is 0. beforeJoin is
+// called before an approved user is added and can refuse the join.
+func DecideGroupJoinRequest(ctx context.Context, groupID, userAddress, decidedBy string, approve bool, maxMembers int, beforeJoin func() error) error {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status JoinRequestStatus
+	err = tx.QueryRowContext(ctx,
+		"SELECT status FROM group_join_requests WHERE group_id = ? AND user_address = ? FOR UPDATE",
+		groupID, userAddress,
+	).Scan(&status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrJoinRequestNotFound
+		}
+		return err
+	}
+	if status != JoinRequestPending {
+		return ErrJoinRequestNotFound
+	}
+
+	decision := JoinRequestRejected
+	if approve {
+		decision = JoinRequestApproved
+		if err := beforeJoin(); err != nil {
+			return err
+		}
+		if err := addGroupMemberTx(ctx, tx, groupID, userAddress, GroupRoleMember, maxMembers); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE group_join_requests SET status = ?, decided_by = ?, decided_at = ? WHERE group_id = ? AND user_address = ?",
+		decision, decidedBy, clock.Now(), groupID, userAddress,
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,163 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
+)
+
+var (
+	// ErrGroupTopicNotFound is returned when a group topic is not found
+	ErrGroupTopicNotFound = errors.New("group topic not found")
+)
+
+// GroupTopic is a named thread of a group in topic mode. Closed topics
+// keep their messages, but only admins can post to them.
+type GroupTopic struct {
+	ID             string      `json:"id"`
+	GroupID        string      `json:"group_id"`
+	Name           string      `json:"name"`
+	CreatorAddress string      `json:"creator_address"`
+	ClosedAt       *types.Time `json:"closed_at,omitempty"`
+	CreatedAt      types.Time  `json:"created_at"`
+	MessageCount   int         `json:"message_count"`
+	LastMessageAt  *types.Time `json:"last_message_at,omitempty"`
+	// UnreadCount is the number of messages from others the viewer hasn't
+	// read, filled in by GetGroupTopics
+	UnreadCount int `json:"unread_count"`
+}
+
+// groupTopicReadScope keeps the read cursors of group topics
+var groupTopicReadScope = readScope{messages: "group_messages", reads: "group_topic_reads", column: "topic_id"}
+
+// SetGroupTopicsEnabled turns a group's topic mode on or off
+func SetGroupTopicsEnabled(ctx context.Context, groupID string, enabled bool) error {
+	_, err := database.DB.ExecContext(ctx,
+		"UPDATE chat_groups SET topics_enabled = ? WHERE id = ?",
+		enabled, groupID,
+	)
+	return err
+}
+
+// GroupTopicsEnabled reports whether a group is in topic mode
+func GroupTopicsEnabled(ctx context.Context, groupID string) (bool, error) {
+	var enabled bool
+	err := database.DB.QueryRowContext(ctx, "SELECT topics_enabled FROM chat_groups WHERE id = ?", groupID).Scan(&enabled)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, ErrGroupNotFound
+		}
+		return false, err
+	}
+	return enabled, nil
+}
+
+// CreateGroupTopic adds a topic to a group
+func CreateGroupTopic(ctx context.Context, topic *GroupTopic) error {
+	_, err := database.DB.ExecContext(ctx,
+		"INSERT INTO group_topics (id, group_id, name, creator_address) VALUES (?, ?, ?, ?)",
+		topic.ID, topic.GroupID, topic.Name, topic.CreatorAddress,
+	)
+	if err != nil {
+		return err
+	}
+	topic.CreatedAt = types.NewTime(clock.Now())
+	return nil
+}
+
+// groupTopicColumns are the columns scanned by scanGroupTopic, with the
+// message count and last activity of each topic
+const groupTopicColumns = `t.id, t.group_id, t.name, t.creator_address, t.closed_at, t.created_at,
+	(SELECT COUNT(*) FROM group_messages m WHERE m.topic_id = t.id),
+	(SELECT MAX(m.timestamp) FROM group_messages m WHERE m.topic_id = t.id)`
+
+// scanGroupTopic scans a row of groupTopicColumns
+func scanGroupTopic(row interface{ Scan(...any) error }, extra ...any) (*GroupTopic, error) {
+	topic := &GroupTopic{}
+	dest := []any{
+		&topic.ID, &topic.GroupID, &topic.Name, &topic.CreatorAddress, &topic.ClosedAt, &topic.CreatedAt,
+		&topic.MessageCount, &topic.LastMessageAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	return topic, nil
+}
+
+// GetGroupTopic retrieves a topic of a group
+func GetGroupTopic(ctx context.Context, groupID, topicID string) (*GroupTopic, error) {
+	topic, err := scanGroupTopic(database.DB.QueryRowContext(ctx,
+		"SELECT "+groupTopicColumns+" FROM group_topics t WHERE t.id = ? AND t.group_id = ?",
+		topicID, groupID,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrGroupTopicNotFound
+		}
+		return nil, err
+	}
+	return topic, nil
+}
+
+// GetGroupTopics lists a group's topics, open ones first, with the number
+// of messages from others a member hasn't read in each
+func GetGroupTopics(ctx context.Context, groupID, userAddress string) ([]*GroupTopic, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT "+groupTopicColumns+`,
+		(SELECT COUNT(*) FROM group_messages m
+			LEFT JOIN group_topic_reads r ON r.topic_id = t.id AND r.user_address = ?
+			WHERE m.topic_id = t.id AND m.sender_address != ?
+				AND (r.topic_id IS NULL OR m.timestamp > r.last_read_timestamp
+					OR (m.timestamp = r.last_read_timestamp AND m.id > r.last_read_message_id)))
+		FROM group_topics t WHERE t.group_id = ?
+		ORDER BY t.closed_at IS NOT NULL, t.created_at`,
+		userAddress, userAddress, groupID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	topics := []*GroupTopic{}
+	for rows.Next() {
+		var unread int
+		topic, err := scanGroupTopic(rows, &unread)
+		if err != nil {
+			return nil, err
+		}
+		topic.UnreadCount = unread
+		topics = append(topics, topic)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return topics, nil
+}
+
+// SetGroupTopicClosed closes or reopens a topic. It returns false if the
+// topic was already in that state.
+func SetGroupTopicClosed(ctx context.Context, groupID, topicID string, closed bool) (bool, error) {
+	query := "UPDATE group_topics SET closed_at = NOW() WHERE id = ? AND group_id = ? AND closed_at IS NULL"
+	if !closed {
+		query = "UPDATE group_topics SET closed_at = NULL WHERE id = ? AND group_id = ? AND closed_at IS NOT NULL"
+	}
+	result, err := database.DB.ExecContext(ctx, query, topicID, groupID)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// MarkGroupTopicRead moves a member's read cursor in a topic forward to a
+// message. It returns false if the cursor was already at or past it.
+func MarkGroupTopicRead(ctx context.Context, topicID, userAddress string, message *GroupMessage) (bool, error) {
+	return groupTopicReadScope.markRead(ctx, topicID, userAddress, ReadCursor{MessageID: message.ID, Timestamp: message.Timestamp.Time})
+}
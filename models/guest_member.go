@@ -0,0 +1,133 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
+)
+
+var (
+	// ErrGuestPassNotFound is returned when a user has no unexpired guest
+	// pass for a group
+	ErrGuestPassNotFound = errors.New("guest pass not found")
+)
+
+// GuestAccess is what a guest pass lets its holder do in a group
+type GuestAccess string
+
+const (
+	// GuestAccessRead lets the guest read the group's messages
+	GuestAccessRead GuestAccess = "read"
+	// GuestAccessWrite also lets the guest send messages
+	GuestAccessWrite GuestAccess = "write"
+)
+
+// GuestPass gives a user who isn't a member access to a group until it expires
+type GuestPass struct {
+	GroupID     string      `json:"group_id"`
+	UserAddress string      `json:"user_address"`
+	Access      GuestAccess `json:"access"`
+	GrantedBy   string      `json:"granted_by"`
+	ExpiresAt   types.Time  `json:"expires_at"`
+	CreatedAt   types.Time  `json:"created_at"`
+}
+
+// CanWrite reports whether the pass lets its holder send messages
+func (p *GuestPass) CanWrite() bool {
+	return p.Access == GuestAccessWrite
+}
+
+// GrantGuestPass stores a guest pass, replacing any pass the user already
+// had for the group
+func GrantGuestPass(ctx context.Context, pass *GuestPass) error {
+	now := clock.Now()
+	_, err := database.DB.ExecContext(ctx,
+		`INSERT INTO guest_members (group_id, user_address, access, granted_by, expires_at, created_at) VALUES (?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE access = VALUES(access), granted_by = VALUES(granted_by),
+			expires_at = VALUES(expires_at), created_at = VALUES(created_at)`,
+		pass.GroupID, pass.UserAddress, pass.Access, pass.GrantedBy, pass.ExpiresAt, now,
+	)
+	if err != nil {
+		return err
+	}
+	pass.CreatedAt = types.NewTime(now)
+	return nil
+}
+
+// GetGuestPass retrieves a user's unexpired guest pass for a group
+func GetGuestPass(ctx context.Context, groupID, userAddress string) (*GuestPass, error) {
+	pass := &GuestPass{}
+	err := database.DB.QueryRowContext(ctx,
+		`SELECT group_id, user_address, access, granted_by, expires_at, created_at FROM guest_members
+		WHERE group_id = ? AND user_address = ? AND expires_at > ?`,
+		groupID, userAddress, clock.Now(),
+	).Scan(&pass.GroupID, &pass.UserAddress, &pass.Access, &pass.GrantedBy, &pass.ExpiresAt, &pass.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrGuestPassNotFound
+		}
+		return nil, err
+	}
+	return pass, nil
+}
+
+// GetGuestPasses retrieves a group's unexpired guest passes, soonest to
+// expire first
+func GetGuestPasses(ctx context.Context, groupID string) ([]*GuestPass, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		`SELECT group_id, user_address, access, granted_by, expires_at, created_at FROM guest_members
+		WHERE group_id = ? AND expires_at > ? ORDER BY expires_at`,
+		groupID, clock.Now(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	passes := []*GuestPass{}
+	for rows.Next() {
+		pass := &GuestPass{}
+		if err := rows.Scan(&pass.GroupID, &pass.UserAddress, &pass.Access, &pass.GrantedBy, &pass.ExpiresAt, &pass.CreatedAt); err != nil {
+			return nil, err
+		}
+		passes = append(passes, pass)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return passes, nil
+}
+
+// RevokeGuestPass deletes a user's guest pass for a group
+func RevokeGuestPass(ctx context.Context, groupID, userAddress string) error {
+	result, err := database.DB.ExecContext(ctx,
+		"DELETE FROM guest_members WHERE group_id = ? AND user_address = ? AND expires_at > ?",
+		groupID, userAddress, clock.Now(),
+	)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrGuestPassNotFound
+	}
+	return nil
+}
+
+// DeleteExpiredGuestPasses deletes guest passes past their expiry and
+// returns how many it deleted. Expired passes already grant nothing, so
+// this only keeps the table small.
+func DeleteExpiredGuestPasses(ctx context.Context) (int64, error) {
+	result, err := database.DB.ExecContext(ctx, "DELETE FROM guest_members WHERE expires_at <= ?", clock.Now())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
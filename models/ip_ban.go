@@ -0,0 +1,51 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/database"
+)
+
+// IPBan is a client IP refused until a time
+type IPBan struct {
+	IP          string
+	BannedUntil time.Time
+}
+
+// SaveIPBan stores a ban on a client IP. Banning a banned IP never shortens
+// the ban.
+func SaveIPBan(ctx context.Context, ban IPBan) error {
+	_, err := database.DB.ExecContext(ctx,
+		`INSERT INTO ip_bans (ip, banned_until) VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE banned_until = GREATEST(banned_until, VALUES(banned_until))`,
+		ban.IP, ban.BannedUntil,
+	)
+	return err
+}
+
+// GetActiveIPBans retrieves the bans that haven't ended, clearing out the
+// ones that have
+func GetActiveIPBans(ctx context.Context) ([]IPBan, error) {
+	now := clock.Now()
+	if _, err := database.DB.ExecContext(ctx, "DELETE FROM ip_bans WHERE banned_until <= ?", now); err != nil {
+		return nil, err
+	}
+
+	rows, err := database.DB.QueryContext(ctx, "SELECT ip, banned_until FROM ip_bans WHERE banned_until > ?", now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bans := []IPBan{}
+	for rows.Next() {
+		var ban IPBan
+		if err := rows.Scan(&ban.IP, &ban.BannedUntil); err != nil {
+			return nil, err
+		}
+		bans = append(bans, ban)
+	}
+	return bans, rows.Err()
+}
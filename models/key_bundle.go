@@ -0,0 +1,226 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/database"
+)
+
+var (
+	// ErrKeyBundleNotFound is returned when a user hasn't uploaded keys
+	ErrKeyBundleNotFound = errors.New("key bundle not found")
+	// ErrSignedPrekeyRequired is returned when a new identity key is uploaded without a signed prekey
+	ErrSignedPrekeyRequired = errors.New("signed prekey required with a new identity key")
+	// ErrPrekeyIDExists is returned when a one-time prekey ID is already stored
+	ErrPrekeyIDExists = errors.New("one-time prekey ID already uploaded")
+	// ErrTooManyPrekeys is returned when an upload would store more one-time prekeys than allowed
+	ErrTooManyPrekeys = errors.New("too many one-time prekeys")
+)
+
+// SignedPrekey is a medium-term prekey signed with the owner's identity key
+type SignedPrekey struct {
+	KeyID     uint32
+	PublicKey []byte
+	Signature []byte
+}
+
+// OneTimePrekey is a prekey handed out to a single session initiator
+type OneTimePrekey struct {
+	KeyID     uint32
+	PublicKey []byte
+}
+
+// KeyBundle is the identity key and signed prekey a user publishes so
+// others can start encrypted sessions with them
+type KeyBundle struct {
+	UserAddress           string
+	IdentityKey           []byte
+	SignedPrekey          SignedPrekey
+	SignedPrekeyUpdatedAt time.Time
+	// OneTimePrekeys is how many one-time prekeys are left
+	OneTimePrekeys int
+}
+
+// GetKeyBundle retrieves a user's key bundle and how many one-time prekeys
+// they have left, without consuming any
+func GetKeyBundle(ctx context.Context, userAddress string) (*KeyBundle, error) {
+	bundle := &KeyBundle{UserAddress: userAddress}
+	err := database.DB.QueryRowContext(ctx,
+		`SELECT identity_key, signed_prekey_id, signed_prekey, signed_prekey_signature, signed_prekey_updated_at,
+			(SELECT COUNT(*) FROM one_time_prekeys WHERE user_address = b.user_address)
+		FROM key_bundles b WHERE user_address = ?`,
+		userAddress,
+	).Scan(
+		&bundle.IdentityKey, &bundle.SignedPrekey.KeyID, &bundle.SignedPrekey.PublicKey, &bundle.SignedPrekey.Signature,
+		&bundle.SignedPrekeyUpdatedAt, &bundle.OneTimePrekeys,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrKeyBundleNotFound
+		}
+		return nil, err
+	}
+	return bundle, nil
+}
+
+// SaveKeys stores a user's identity key, an optional new signed prekey and
+// more one-time prekeys. A different identity key than the one stored
+// starts a new bundle: it needs a signed prekey, and the one-time prekeys
+// signed for the old identity are deleted. At most maxPrekeys one-time
+// prekeys are kept. It reports whether a stored identity key was replaced.
+func SaveKeys(ctx context.Context, userAddress string, identityKey []byte, signed *SignedPrekey, prekeys []OneTimePrekey, maxPrekeys int) (rotated bool, err error) {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// Lock the bundle so concurrent uploads apply one after the other
+	var storedIdentity []byte
+	err = tx.QueryRowContext(ctx,
+		"SELECT identity_key FROM key_bundles WHERE user_address = ? FOR UPDATE",
+		userAddress,
+	).Scan(&storedIdentity)
+	if err != nil && err != sql.ErrNoRows {
+		return false, err
+	}
+
+	now := clock.Now()
+	switch {
+	case storedIdentity == nil || !bytes.Equal(storedIdentity, identityKey):
+		rotated = storedIdentity != nil
+		if signed == nil {
+			return false, ErrSignedPrekeyRequired
+		}
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO key_bundles (user_address, identity_key, signed_prekey_id, signed_prekey, signed_prekey_signature, signed_prekey_updated_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE identity_key = VALUES(identity_key), signed_prekey_id = VALUES(signed_prekey_id),
+				signed_prekey = VALUES(signed_prekey), signed_prekey_signature = VALUES(signed_prekey_signature),
+				signed_prekey_updated_at = VALUES(signed_prekey_updated_at)`,
+			userAddress, identityKey, signed.KeyID, signed.PublicKey, signed.Signature, now,
+		)
+		if err != nil {
+			return false, err
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM one_time_prekeys WHERE user_address = ?", userAddress); err != nil {
+			return false, err
+		}
+	case signed != nil:
+		_, err = tx.ExecContext(ctx,
+			`UPDATE key_bundles SET signed_prekey_id = ?, signed_prekey = ?, signed_prekey_signature = ?, signed_prekey_updated_at = ?
+			WHERE user_address = ?`,
+			signed.KeyID, signed.PublicKey, signed.Signature, now, userAddress,
+		)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if len(prekeys) == 0 {
+		if err := tx.Commit(); err != nil {
+			return false, err
+		}
+		return rotated, nil
+	}
+
+	var stored int
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM one_time_prekeys WHERE user_address = ?", userAddress).Scan(&stored); err != nil {
+		return false, err
+	}
+	if stored+len(prekeys) > maxPrekeys {
+		return false, ErrTooManyPrekeys
+	}
+
+	placeholders := make([]string, len(prekeys))
+	args := []interface{}{userAddress}
+	for i, prekey := range prekeys {
+		placeholders[i] = "?"
+		args = append(args, prekey.KeyID)
+	}
+	var existing int
+	err = tx.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM one_time_prekeys WHERE user_address = ? AND key_id IN ("+strings.Join(placeholders, ", ")+")",
+		args...,
+	).Scan(&existing)
+	if err != nil {
+		return false, err
+	}
+	if existing > 0 {
+		return false, ErrPrekeyIDExists
+	}
+
+	for _, prekey := range prekeys {
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO one_time_prekeys (user_address, key_id, public_key) VALUES (?, ?, ?)",
+			userAddress, prekey.KeyID, prekey.PublicKey,
+		)
+		if err != nil {
+			return false, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return rotated, nil
+}
+
+// ConsumeKeyBundle retrieves a user's key bundle along with one of their
+// one-time prekeys, which is deleted so no one else gets it. The prekey is
+// nil when none are left. The bundle's OneTimePrekeys counts those left
+// afterwards.
+func ConsumeKeyBundle(ctx context.Context, userAddress string) (*KeyBundle, *OneTimePrekey, error) {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	bundle := &KeyBundle{UserAddress: userAddress}
+	err = tx.QueryRowContext(ctx,
+		`SELECT identity_key, signed_prekey_id, signed_prekey, signed_prekey_signature, signed_prekey_updated_at
+		FROM key_bundles WHERE user_address = ?`,
+		userAddress,
+	).Scan(
+		&bundle.IdentityKey, &bundle.SignedPrekey.KeyID, &bundle.SignedPrekey.PublicKey, &bundle.SignedPrekey.Signature,
+		&bundle.SignedPrekeyUpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, ErrKeyBundleNotFound
+		}
+		return nil, nil, err
+	}
+
+	// Lock the oldest prekey, skipping any another request is taking
+	prekey := &OneTimePrekey{}
+	err = tx.QueryRowContext(ctx,
+		"SELECT key_id, public_key FROM one_time_prekeys WHERE user_address = ? ORDER BY key_id LIMIT 1 FOR UPDATE SKIP LOCKED",
+		userAddress,
+	).Scan(&prekey.KeyID, &prekey.PublicKey)
+	switch {
+	case err == sql.ErrNoRows:
+		prekey = nil
+	case err != nil:
+		return nil, nil, err
+	default:
+		_, err = tx.ExecContext(ctx,
+			"DELETE FROM one_time_prekeys WHERE user_address = ? AND key_id = ?",
+			userAddress, prekey.KeyID,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM one_time_prekeys WHERE user_address = ?", userAddress).Scan(&bundle.OneTimePrekeys); err != nil {
+		return nil, nil, err
+	}
+	return bundle, prekey, tx.Commit()
+}
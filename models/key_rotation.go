@@ -0,0 +1,211 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
+)
+
+var (
+	// ErrKeyRotationNotFound is returned when a key rotation doesn't exist,
+	// or doesn't involve the given peer
+	ErrKeyRotationNotFound = errors.New("key rotation not found")
+)
+
+// KeyRotation tracks a user's switch to a new identity key. Each peer they
+// have a direct conversation with must start a new session with the new
+// key and acknowledge it before the conversation is re-encrypted.
+type KeyRotation struct {
+	ID                  string     `json:"id"`
+	UserAddress         string     `json:"user_address"`
+	IdentityFingerprint string     `json:"identity_fingerprint"`
+	CreatedAt           types.Time `json:"created_at"`
+	// Total and Acknowledged count the affected peers and those done so far
+	Total        int `json:"total"`
+	Acknowledged int `json:"acknowledged"`
+	// Peers is only shown to the rotating user
+	Peers []*KeyRotationPeer `json:"peers,omitempty"`
+}
+
+// KeyRotationPeer is a peer affected by a key rotation
+type KeyRotationPeer struct {
+	PeerAddress    string      `json:"peer_address"`
+	AcknowledgedAt *types.Time `json:"acknowledged_at,omitempty"`
+}
+
+// StartKeyRotation records a user's new identity key and marks every
+// conversation partner as needing to acknowledge it. It replaces the user's
+// previous rotation, whose remaining acknowledgements no longer matter.
+func StartKeyRotation(ctx context.Context, id, userAddress, fingerprint string) (*KeyRotation, error) {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM key_rotations WHERE user_address = ?", userAddress); err != nil {
+		return nil, err
+	}
+
+	now := clock.Now()
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO key_rotations (id, user_address, identity_fingerprint, created_at) VALUES (?, ?, ?, ?)",
+		id, userAddress, fingerprint, now,
+	)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO key_rotation_peers (rotation_id, peer_address)
+		SELECT DISTINCT ?, CASE WHEN sender_address = ? THEN recipient_address ELSE sender_address END
+		FROM messages
+		WHERE (sender_address = ? OR recipient_address = ?) AND sender_address != recipient_address`,
+		id, userAddress, userAddress, userAddress,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rotation := &KeyRotation{
+		ID:                  id,
+		UserAddress:         userAddress,
+		IdentityFingerprint: fingerprint,
+		CreatedAt:           types.NewTime(now),
+	}
+	if rotation.Peers, err = getKeyRotationPeers(ctx, tx, id); err != nil {
+		return nil, err
+	}
+	rotation.Total = len(rotation.Peers)
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return rotation, nil
+}
+
+// getKeyRotationPeers retrieves the peers affected by a rotation
+func getKeyRotationPeers(ctx context.Context, q interface {
+	QueryContext(context.Context, string, ...any) (*sql.Rows, error)
+}, rotationID string) ([]*KeyRotationPeer, error) {
+	rows, err := q.QueryContext(ctx,
+		"SELECT peer_address, acknowledged_at FROM key_rotation_peers WHERE rotation_id = ? ORDER BY peer_address",
+		rotationID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	peers := []*KeyRotationPeer{}
+	for rows.Next() {
+		peer := &KeyRotationPeer{}
+		if err := rows.Scan(&peer.PeerAddress, &peer.AcknowledgedAt); err != nil {
+			return nil, err
+		}
+		peers = append(peers, peer)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return peers, nil
+}
+
+// keyRotationColumns are the columns scanned by scanKeyRotation, with the
+// rotation's progress
+const keyRotationColumns = `r.id, r.user_address, r.identity_fingerprint, r.created_at,
+	(SELECT COUNT(*) FROM key_rotation_peers p WHERE p.rotation_id = r.id),
+	(SELECT COUNT(*) FROM key_rotation_peers p WHERE p.rotation_id = r.id AND p.acknowledged_at IS NOT NULL)`
+
+// scanKeyRotation scans a row of keyRotationColumns
+func scanKeyRotation(row interface{ Scan(...any) error }) (*KeyRotation, error) {
+	rotation := &KeyRotation{}
+	err := row.Scan(&rotation.ID, &rotation.UserAddress, &rotation.IdentityFingerprint, &rotation.CreatedAt, &rotation.Total, &rotation.Acknowledged)
+	if err != nil {
+		return nil, err
+	}
+	return rotation, nil
+}
+
+// GetKeyRotation retrieves a user's latest key rotation with the state of
+// each affected peer
+func GetKeyRotation(ctx context.Context, userAddress string) (*KeyRotation, error) {
+	rotation, err := scanKeyRotation(database.DB.QueryRowContext(ctx,
+		"SELECT "+keyRotationColumns+" FROM key_rotations r WHERE r.user_address = ?",
+		userAddress,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrKeyRotationNotFound
+		}
+		return nil, err
+	}
+
+	if rotation.Peers, err = getKeyRotationPeers(ctx, database.DB, rotation.ID); err != nil {
+		return nil, err
+	}
+	return rotation, nil
+}
+
+// GetPendingKeyRotations lists the rotations of a user's conversation
+// partners that the user hasn't acknowledged yet, oldest first
+func GetPendingKeyRotations(ctx context.Context, peerAddress string) ([]*KeyRotation, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT "+keyRotationColumns+` FROM key_rotations r
+		JOIN key_rotation_peers kp ON kp.rotation_id = r.id
+		WHERE kp.peer_address = ? AND kp.acknowledged_at IS NULL
+		ORDER BY r.created_at, r.id`,
+		peerAddress,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rotations := []*KeyRotation{}
+	for rows.Next() {
+		rotation, err := scanKeyRotation(rows)
+		if err != nil {
+			return nil, err
+		}
+		rotations = append(rotations, rotation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return rotations, nil
+}
+
+// AcknowledgeKeyRotation records that a peer has started a session with the
+// rotating user's new key and returns the rotation's progress. It returns
+// false if the peer had already acknowledged it.
+func AcknowledgeKeyRotation(ctx context.Context, rotationID, peerAddress string) (*KeyRotation, bool, error) {
+	result, err := database.DB.ExecContext(ctx,
+		"UPDATE key_rotation_peers SET acknowledged_at = ? WHERE rotation_id = ? AND peer_address = ? AND acknowledged_at IS NULL",
+		clock.Now(), rotationID, peerAddress,
+	)
+	if err != nil {
+		return nil, false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, false, err
+	}
+
+	rotation, err := scanKeyRotation(database.DB.QueryRowContext(ctx,
+		"SELECT "+keyRotationColumns+` FROM key_rotations r
+		JOIN key_rotation_peers kp ON kp.rotation_id = r.id
+		WHERE r.id = ? AND kp.peer_address = ?`,
+		rotationID, peerAddress,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, false, ErrKeyRotationNotFound
+		}
+		return nil, false, err
+	}
+	return rotation, rowsAffected > 0, nil
+}
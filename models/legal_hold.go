@@ -0,0 +1,363 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
+)
+
+var (
+	// ErrLegalHoldNotFound is returned when an account is not under legal hold
+	ErrLegalHoldNotFound = errors.New("legal hold not found")
+	// ErrUnderLegalHold is returned when deleting data of an account under legal hold
+	ErrUnderLegalHold = errors.New("account is under legal hold")
+)
+
+// LegalHold marks an account whose data must be preserved
+type LegalHold struct {
+	UserAddress string     `json:"user_address"`
+	Reason      string     `json:"reason"`
+	PlacedBy    string     `json:"placed_by"`
+	CreatedAt   types.Time `json:"created_at"`
+}
+
+// PlaceLegalHold puts an account under legal hold, replacing the reason of an
+// existing hold
+func PlaceLegalHold(ctx context.Context, hold *LegalHold) error {
+	_, err := database.DB.ExecContext(ctx,
+		`INSERT INTO legal_holds (user_address, reason, placed_by) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE reason = VALUES(reason), placed_by = VALUES(placed_by)`,
+		hold.UserAddress, hold.Reason, hold.PlacedBy,
+	)
+	return err
+}
+
+// ReleaseLegalHold lifts the legal hold on an account
+func ReleaseLegalHold(ctx context.Context, userAddress string) error {
+	result, err := database.DB.ExecContext(ctx, "DELETE FROM legal_holds WHERE user_address = ?", userAddress)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrLegalHoldNotFound
+	}
+	return nil
+}
+
+// GetLegalHold retrieves the legal hold on an account
+func GetLegalHold(ctx context.Context, userAddress string) (*LegalHold, error) {
+	hold := &LegalHold{}
+	err := database.DB.QueryRowContext(ctx,
+		"SELECT user_address, reason, placed_by, created_at FROM legal_holds WHERE user_address = ?",
+		userAddress,
+	).Scan(&hold.UserAddress, &hold.Reason, &hold.PlacedBy, &hold.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrLegalHoldNotFound
+		}
+		return nil, err
+	}
+	return hold, nil
+}
+
+// GetLegalHolds retrieves all legal holds, newest first
+func GetLegalHolds(ctx context.Context) ([]*LegalHold, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT user_address, reason, placed_by, created_at FROM legal_holds ORDER BY created_at DESC",
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	holds := []*LegalHold{}
+	for rows.Next() {
+		hold := &LegalHold{}
+		if err := rows.Scan(&hold.UserAddress, &hold.Reason, &hold.PlacedBy, &hold.CreatedAt); err != nil {
+			return nil, err
+		}
+		holds = append(holds, hold)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return holds, nil
+}
+
+// CheckLegalHold returns ErrUnderLegalHold if any of the accounts is under
+// legal hold
+func CheckLegalHold(ctx context.Context, addresses ...string) error {
+	for _, address := range addresses {
+		var count int
+		err := database.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM legal_holds WHERE user_address = ?", address).Scan(&count)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrUnderLegalHold
+		}
+	}
+	return nil
+}
+
+// ComplianceAccount is the account metadata included in a compliance export.
+// Keys are deliberately left out.
+type ComplianceAccount struct {
+	ID        int         `json:"id"`
+	Address   string      `json:"address"`
+	Phone     string      `json:"phone"`
+	Username  string      `json:"username,omitempty"`
+	Role      UserRole    `json:"role"`
+	Birthdate *types.Time `json:"birthdate,omitempty"`
+	CreatedAt types.Time  `json:"created_at"`
+	UpdatedAt types.Time  `json:"updated_at"`
+}
+
+// ComplianceExport is everything the server stores about an account under
+// legal hold. Message contents are the end-to-end encrypted ciphertext.
+type ComplianceExport struct {
+	GeneratedAt        types.Time        `json:"generated_at"`
+	Hold               *LegalHold        `json:"hold"`
+	Account            ComplianceAccount `json:"account"`
+	Sessions           []*Session        `json:"sessions"`
+	Contacts           []*Contact        `json:"contacts"`
+	GroupMemberships   []*GroupMember    `json:"group_memberships"`
+	ChannelMemberships []*ChannelMember  `json:"channel_memberships"`
+	SentMessages       []*Message        `json:"sent_messages"`
+	ReceivedMessages   []*Message        `json:"received_messages"`
+	GroupMessages      []*GroupMessage   `json:"group_messages"`
+	ChannelMessages    []*ChannelMessage `json:"channel_messages"`
+	Media              []*Media          `json:"media"`
+	AuditLog           []*AuditEntry     `json:"audit_log"`
+}
+
+// complianceAuditLimit caps the audit entries included in an export
+const complianceAuditLimit = 10000
+
+// BuildComplianceExport collects the export package of an account under legal hold
+func BuildComplianceExport(ctx context.Context, userAddress string) (*ComplianceExport, error) {
+	hold, err := GetLegalHold(ctx, userAddress)
+	if err != nil {
+		return nil, err
+	}
+	user, err := GetUserByAddress(ctx, userAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &ComplianceExport{
+		GeneratedAt: types.NewTime(clock.Now()),
+		Hold:        hold,
+		Account: ComplianceAccount{
+			ID:        user.ID,
+			Address:   user.Address,
+			Phone:     user.Phone,
+			Username:  user.Username,
+			Role:      user.Role,
+			Birthdate: user.Birthdate,
+			CreatedAt: user.CreatedAt,
+			UpdatedAt: user.UpdatedAt,
+		},
+	}
+
+	if export.Sessions, err = getAllUserSessions(ctx, userAddress); err != nil {
+		return nil, err
+	}
+	if export.Contacts, err = GetContacts(ctx, userAddress); err != nil {
+		return nil, err
+	}
+	if export.GroupMemberships, err = getUserGroupMemberships(ctx, userAddress); err != nil {
+		return nil, err
+	}
+	if export.ChannelMemberships, err = getUserChannelMemberships(ctx, userAddress); err != nil {
+		return nil, err
+	}
+	if export.SentMessages, err = GetMessagesBySender(ctx, userAddress); err != nil {
+		return nil, err
+	}
+	if export.ReceivedMessages, err = GetMessagesByRecipient(ctx, userAddress); err != nil {
+		return nil, err
+	}
+	if export.GroupMessages, err = getGroupMessagesBySender(ctx, userAddress); err != nil {
+		return nil, err
+	}
+	if export.ChannelMessages, err = getChannelMessagesBySender(ctx, userAddress); err != nil {
+		return nil, err
+	}
+	if export.Media, err = getMediaByOwner(ctx, userAddress); err != nil {
+		return nil, err
+	}
+	if export.AuditLog, err = GetAuditLogForActor(ctx, userAddress, complianceAuditLimit); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// getAllUserSessions retrieves every session of a user, including revoked ones
+func getAllUserSessions(ctx context.Context, userAddress string) ([]*Session, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		`SELECT id, user_address, device_name, user_agent, ip_address, created_at, last_seen_at, revoked_at, revoke_reason
+		FROM sessions WHERE user_address = ? ORDER BY created_at`,
+		userAddress,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []*Session{}
+	for rows.Next() {
+		session := &Session{}
+		err := rows.Scan(
+			&session.ID, &session.UserAddress, &session.DeviceName, &session.UserAgent, &session.IPAddress,
+			&session.CreatedAt, &session.LastSeenAt, &session.RevokedAt, &session.RevokeReason,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// getUserGroupMemberships retrieves the group memberships of a user
+func getUserGroupMemberships(ctx context.Context, userAddress string) ([]*GroupMember, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT group_id, user_address, role, joined_at FROM group_members WHERE user_address = ? ORDER BY joined_at",
+		userAddress,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []*GroupMember{}
+	for rows.Next() {
+		member := &GroupMember{}
+		if err := rows.Scan(&member.GroupID, &member.UserAddress, &member.Role, &member.JoinedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// getUserChannelMemberships retrieves the channel memberships of a user
+func getUserChannelMemberships(ctx context.Context, userAddress string) ([]*ChannelMember, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT channel_id, user_address, role, joined_at FROM channel_members WHERE user_address = ? ORDER BY joined_at",
+		userAddress,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []*ChannelMember{}
+	for rows.Next() {
+		member := &ChannelMember{}
+		if err := rows.Scan(&member.ChannelID, &member.UserAddress, &member.Role, &member.JoinedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// getGroupMessagesBySender retrieves all group messages sent by a user
+func getGroupMessagesBySender(ctx context.Context, senderAddress string) ([]*GroupMessage, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT id, group_id, sender_address, content, timestamp, block_id, reply_to_message_id, forwarded_from FROM group_messages WHERE sender_address = ? ORDER BY timestamp",
+		senderAddress,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []*GroupMessage{}
+	for rows.Next() {
+		message := &GroupMessage{}
+		err := rows.Scan(
+			&message.ID, &message.GroupID, &message.SenderAddress, &message.Content, &message.Timestamp, &message.BlockID, &message.ReplyToMessageID, &message.ForwardedFrom,
+		)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// getChannelMessagesBySender retrieves all channel messages sent by a user
+func getChannelMessagesBySender(ctx context.Context, senderAddress string) ([]*ChannelMessage, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT id, channel_id, sender_address, encrypted_content, timestamp, block_id, reply_to_message_id, forwarded_from FROM channel_messages WHERE sender_address = ? ORDER BY timestamp",
+		senderAddress,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []*ChannelMessage{}
+	for rows.Next() {
+		message := &ChannelMessage{}
+		err := rows.Scan(
+			&message.ID, &message.ChannelID, &message.SenderAddress, &message.EncryptedContent, &message.Timestamp, &message.BlockID, &message.ReplyToMessageID, &message.ForwardedFrom,
+		)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// getMediaByOwner retrieves the metadata of all files uploaded by a user
+func getMediaByOwner(ctx context.Context, ownerAddress string) ([]*Media, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT id, owner_address, storage_key, file_name, mime_type, size, created_at FROM media WHERE owner_address = ? ORDER BY created_at",
+		ownerAddress,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	media := []*Media{}
+	for rows.Next() {
+		item := &Media{}
+		if err := rows.Scan(&item.ID, &item.OwnerAddress, &item.StorageKey, &item.FileName, &item.MimeType, &item.Size, &item.CreatedAt); err != nil {
+			return nil, err
+		}
+		media = append(media, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return media, nil
+}
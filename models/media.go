@@ -0,0 +1,309 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
+)
+
+var (
+	// ErrMediaNotFound is returned when a media object is not found
+	ErrMediaNotFound = errors.New("media not found")
+	// ErrUploadNotFound is returned when a chunked upload is not found
+	ErrUploadNotFound = errors.New("upload not found")
+	// ErrInvalidAttachment is returned when an attachment doesn't belong to the sender
+	ErrInvalidAttachment = errors.New("invalid attachment")
+)
+
+// AttachmentKind identifies the kind of message an attachment belongs to
+type AttachmentKind string
+
+const (
+	// AttachmentKindDirect is for direct messages
+	AttachmentKindDirect AttachmentKind = "direct"
+	// AttachmentKindGroup is for group messages
+	AttachmentKindGroup AttachmentKind = "group"
+	// AttachmentKindChannel is for channel messages
+	AttachmentKindChannel AttachmentKind = "channel"
+)
+
+// Media represents an uploaded file
+type Media struct {
+	ID           string     `json:"id"`
+	OwnerAddress string     `json:"owner_address"`
+	StorageKey   string     `json:"-"`
+	FileName     string     `json:"file_name"`
+	MimeType     string     `json:"mime_type"`
+	Size         int64      `json:"size"`
+	CreatedAt    types.Time `json:"created_at"`
+}
+
+// MediaUpload tracks a chunked upload in progress
+type MediaUpload struct {
+	ID           string     `json:"id"`
+	OwnerAddress string     `json:"owner_address"`
+	FileName     string     `json:"file_name"`
+	MimeType     string     `json:"mime_type"`
+	Size         int64      `json:"size"`
+	Received     int64      `json:"received"`
+	CreatedAt    types.Time `json:"created_at"`
+}
+
+// CreateMedia creates a new media record
+func CreateMedia(ctx context.Context, media *Media) error {
+	_, err := database.DB.ExecContext(ctx,
+		"INSERT INTO media (id, owner_address, storage_key, file_name, mime_type, size) VALUES (?, ?, ?, ?, ?, ?)",
+		media.ID, media.OwnerAddress, media.StorageKey, media.FileName, media.MimeType, media.Size,
+	)
+	return err
+}
+
+// GetMediaByID retrieves a media record by its ID
+func GetMediaByID(ctx context.Context, id string) (*Media, error) {
+	media := &Media{}
+	err := database.DB.QueryRowContext(ctx,
+		"SELECT id, owner_address, storage_key, file_name, mime_type, size, created_at FROM media WHERE id = ?",
+		id,
+	).Scan(&media.ID, &media.OwnerAddress, &media.StorageKey, &media.FileName, &media.MimeType, &media.Size, &media.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrMediaNotFound
+		}
+		return nil, err
+	}
+	return media, nil
+}
+
+// DeleteMedia deletes a media record
+func DeleteMedia(ctx context.Context, id string) error {
+	_, err := database.DB.ExecContext(ctx, "DELETE FROM media WHERE id = ?", id)
+	return err
+}
+
+// CanAccessMedia checks if a user owns a media object, can see a message it
+// is attached to or is a member of a group it is the photo of. Sticker
+// images are public.
+func CanAccessMedia(ctx context.Context, mediaID, userAddress string) (bool, error) {
+	var count int
+	err := database.DB.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM media WHERE id = ? AND owner_address = ?
+		UNION ALL
+		SELECT COUNT(*) FROM message_attachments a
+		JOIN messages m ON a.kind = 'direct' AND a.message_id = m.id
+		WHERE a.media_id = ? AND (m.sender_address = ? OR m.recipient_address = ?)
+		UNION ALL
+		SELECT COUNT(*) FROM message_attachments a
+		JOIN group_messages gm ON a.kind = 'group' AND a.message_id = gm.id
+		JOIN group_members mem ON mem.group_id = gm.group_id
+		WHERE a.media_id = ? AND mem.user_address = ?
+		UNION ALL
+		SELECT COUNT(*) FROM message_attachments a
+		JOIN channel_messages cm ON a.kind = 'channel' AND a.message_id = cm.id
+		JOIN channel_members mem ON mem.channel_id = cm.channel_id
+		WHERE a.media_id = ? AND mem.user_address = ?
+		UNION ALL
+		SELECT COUNT(*) FROM chat_groups g
+		JOIN group_members mem ON mem.group_id = g.id
+		WHERE g.photo_url = CONCAT('/api/media/', ?) AND mem.user_address = ?
+		UNION ALL
+		SELECT COUNT(*) FROM stickers WHERE media_id = ?
+		ORDER BY 1 DESC LIMIT 1`,
+		mediaID, userAddress,
+		mediaID, userAddress, userAddress,
+		mediaID, userAddress,
+		mediaID, userAddress,
+		mediaID, userAddress,
+		mediaID,
+	).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// GetStorageUsed returns the bytes of media a user stores, counting the
+// full size of their uploads in progress
+func GetStorageUsed(ctx context.Context, ownerAddress string) (int64, error) {
+	var used int64
+	err := database.DB.QueryRowContext(ctx,
+		`SELECT
+			(SELECT COALESCE(SUM(size), 0) FROM media WHERE owner_address = ?) +
+			(SELECT COALESCE(SUM(size), 0) FROM media_uploads WHERE owner_address = ?)`,
+		ownerAddress, ownerAddress,
+	).Scan(&used)
+	return used, err
+}
+
+// CreateMediaUpload starts a chunked upload
+func CreateMediaUpload(ctx context.Context, upload *MediaUpload) error {
+	_, err := database.DB.ExecContext(ctx,
+		"INSERT INTO media_uploads (id, owner_address, file_name, mime_type, size) VALUES (?, ?, ?, ?, ?)",
+		upload.ID, upload.OwnerAddress, upload.FileName, upload.MimeType, upload.Size,
+	)
+	return err
+}
+
+// GetMediaUpload retrieves a chunked upload by its ID
+func GetMediaUpload(ctx context.Context, id string) (*MediaUpload, error) {
+	upload := &MediaUpload{}
+	err := database.DB.QueryRowContext(ctx,
+		"SELECT id, owner_address, file_name, mime_type, size, received, created_at FROM media_uploads WHERE id = ?",
+		id,
+	).Scan(&upload.ID, &upload.OwnerAddress, &upload.FileName, &upload.MimeType, &upload.Size, &upload.Received, &upload.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrUploadNotFound
+		}
+		return nil, err
+	}
+	return upload, nil
+}
+
+// UpdateMediaUploadReceived records how many bytes of an upload have arrived
+func UpdateMediaUploadReceived(ctx context.Context, id string, received int64) error {
+	_, err := database.DB.ExecContext(ctx, "UPDATE media_uploads SET received = ? WHERE id = ?", received, id)
+	return err
+}
+
+// DeleteMediaUpload deletes a chunked upload record
+func DeleteMediaUpload(ctx context.Context, id string) error {
+	_, err := database.DB.ExecContext(ctx, "DELETE FROM media_uploads WHERE id = ?", id)
+	return err
+}
+
+// AttachMedia links media objects to a message. Every media object must be
+// owned by the sender.
+func AttachMedia(ctx context.Context, kind AttachmentKind, messageID, senderAddress string, mediaIDs []string) error {
+	if len(mediaIDs) == 0 {
+		return nil
+	}
+
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, mediaID := range mediaIDs {
+		result, err := tx.ExecContext(ctx,
+			`INSERT INTO message_attachments (kind, message_id, media_id)
+			SELECT ?, ?, id FROM media WHERE id = ? AND owner_address = ?`,
+			kind, messageID, mediaID, senderAddress,
+		)
+		if err != nil {
+			return err
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return ErrInvalidAttachment
+		}
+	}
+
+	return tx.Commit()
+}
+
+// CopyAttachments links the media attached to one message to another, for
+// forwarded messages. It returns the IDs of the linked media.
+func CopyAttachments(ctx context.Context, fromKind AttachmentKind, fromMessageID string, toKind AttachmentKind, toMessageID string) ([]string, error) {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx,
+		"SELECT media_id FROM message_attachments WHERE kind = ? AND message_id = ? ORDER BY id",
+		fromKind, fromMessageID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	var mediaIDs []string
+	for rows.Next() {
+		var mediaID string
+		if err := rows.Scan(&mediaID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		mediaIDs = append(mediaIDs, mediaID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, mediaID := range mediaIDs {
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO message_attachments (kind, message_id, media_id) VALUES (?, ?, ?)",
+			toKind, toMessageID, mediaID,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return mediaIDs, tx.Commit()
+}
+
+// ValidateAttachments checks that every media object exists and is owned by the sender
+func ValidateAttachments(ctx context.Context, senderAddress string, mediaIDs []string) error {
+	for _, mediaID := range mediaIDs {
+		media, err := GetMediaByID(ctx, mediaID)
+		if err != nil {
+			if errors.Is(err, ErrMediaNotFound) {
+				return ErrInvalidAttachment
+			}
+			return err
+		}
+		if media.OwnerAddress != senderAddress {
+			return ErrInvalidAttachment
+		}
+	}
+	return nil
+}
+
+// GetAttachments retrieves the media attached to each of the given messages
+func GetAttachments(ctx context.Context, kind AttachmentKind, messageIDs []string) (map[string][]*Media, error) {
+	attachments := map[string][]*Media{}
+	if len(messageIDs) == 0 {
+		return attachments, nil
+	}
+
+	args := make([]interface{}, 0, len(messageIDs)+1)
+	args = append(args, kind)
+	for _, id := range messageIDs {
+		args = append(args, id)
+	}
+
+	rows, err := database.DB.QueryContext(ctx,
+		`SELECT a.message_id, m.id, m.owner_address, m.storage_key, m.file_name, m.mime_type, m.size, m.created_at
+		FROM message_attachments a JOIN media m ON a.media_id = m.id
+		WHERE a.kind = ? AND a.message_id IN (?`+strings.Repeat(", ?", len(messageIDs)-1)+`)
+		ORDER BY a.id`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var messageID string
+		media := &Media{}
+		err := rows.Scan(&messageID, &media.ID, &media.OwnerAddress, &media.StorageKey, &media.FileName, &media.MimeType, &media.Size, &media.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		attachments[messageID] = append(attachments[messageID], media)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
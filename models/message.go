@@ -1,16 +1,26 @@
 package models
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"time"
 
+	"github.com/piko/piko/clock"
 	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
 )
 
 var (
 	// ErrMessageNotFound is returned when a message is not found
 	ErrMessageNotFound = errors.New("message not found")
+	// ErrEditWindowExpired is returned when a message can no longer be edited
+	ErrEditWindowExpired = errors.New("message edit window has expired")
+	// ErrInvalidReplyTarget is returned when a reply points at a message outside the conversation
+	ErrInvalidReplyTarget = errors.New("reply target is not part of this conversation")
+	// ErrInvalidCursor is returned when a pagination cursor isn't a message
+	// in the mailbox being paged
+	ErrInvalidCursor = errors.New("invalid cursor")
 )
 
 // MessageStatus represents the status of a message
@@ -31,30 +41,60 @@ type Message struct {
 	SenderAddress   string       `json:"sender_address"`
 	RecipientAddress string      `json:"recipient_address"`
 	EncryptedContent []byte      `json:"encrypted_content"`
-	Timestamp       time.Time    `json:"timestamp"`
+	Timestamp       types.Time    `json:"timestamp"`
 	Status          MessageStatus `json:"status"`
-	ExpirationTime  *time.Time   `json:"expiration_time,omitempty"`
+	ExpirationTime  *types.Time   `json:"expiration_time,omitempty"`
 	BlockID         *string      `json:"block_id,omitempty"`
+	EditedAt        *types.Time   `json:"edited_at,omitempty"`
+	ReplyToMessageID *string     `json:"reply_to_message_id,omitempty"`
+	ForwardedFrom   *string      `json:"forwarded_from,omitempty"`
+	SenderSessionID *string      `json:"-"`
+	// StickerID is the sticker a sticker message sends
+	StickerID *string `json:"sticker_id,omitempty"`
+}
+
+// MessageEdit is a previous version of an edited message
+type MessageEdit struct {
+	ID               int        `json:"id"`
+	MessageID        string     `json:"message_id"`
+	EncryptedContent []byte     `json:"encrypted_content"`
+	EditedAt         types.Time `json:"edited_at"`
 }
 
 // CreateMessage creates a new message in the database
-func CreateMessage(message *Message) error {
-	_, err := database.DB.Exec(
-		"INSERT INTO messages (id, sender_address, recipient_address, encrypted_content, status, expiration_time) VALUES (?, ?, ?, ?, ?, ?)",
-		message.ID, message.SenderAddress, message.RecipientAddress, message.EncryptedContent, message.Status, message.ExpirationTime,
+func CreateMessage(ctx context.Context, message *Message) error {
+	_, err := database.DB.ExecContext(ctx,
+		"INSERT INTO messages (id, sender_address, recipient_address, encrypted_content, status, expiration_time, reply_to_message_id, forwarded_from, sender_session_id, sticker_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		message.ID, message.SenderAddress, message.RecipientAddress, message.EncryptedContent, message.Status, message.ExpirationTime, message.ReplyToMessageID, message.ForwardedFrom, message.SenderSessionID, message.StickerID,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	message.Timestamp = types.NewTime(clock.Now())
+	shadowSync(ctx, "create", message.ID)
+	return nil
 }
 
 // GetMessageByID retrieves a message by its ID
-func GetMessageByID(id string) (*Message, error) {
+func GetMessageByID(ctx context.Context, id string) (*Message, error) {
+	message, err := getMessageByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	shadowCompare(message)
+	return message, nil
+}
+
+// getMessageByID reads a message from MySQL without comparing it with the
+// shadow store
+func getMessageByID(ctx context.Context, id string) (*Message, error) {
 	message := &Message{}
 	var status string
-	err := database.DB.QueryRow(
-		"SELECT id, sender_address, recipient_address, encrypted_content, timestamp, status, expiration_time, block_id FROM messages WHERE id = ?",
+	err := database.DB.QueryRowContext(ctx,
+		"SELECT id, sender_address, recipient_address, encrypted_content, timestamp, status, expiration_time, block_id, edited_at, reply_to_message_id, forwarded_from, sender_session_id, sticker_id FROM messages WHERE id = ?",
 		id,
 	).Scan(
-		&message.ID, &message.SenderAddress, &message.RecipientAddress, &message.EncryptedContent, &message.Timestamp, &status, &message.ExpirationTime, &message.BlockID,
+		&message.ID, &message.SenderAddress, &message.RecipientAddress, &message.EncryptedContent, &message.Timestamp, &status, &message.ExpirationTime, &message.BlockID, &message.EditedAt, &message.ReplyToMessageID, &message.ForwardedFrom, &message.SenderSessionID, &message.StickerID,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -67,9 +107,9 @@ func GetMessageByID(id string) (*Message, error) {
 }
 
 // GetMessagesByRecipient retrieves all messages for a recipient
-func GetMessagesByRecipient(recipientAddress string) ([]*Message, error) {
-	rows, err := database.DB.Query(
-		"SELECT id, sender_address, recipient_address, encrypted_content, timestamp, status, expiration_time, block_id FROM messages WHERE recipient_address = ? ORDER BY timestamp DESC",
+func GetMessagesByRecipient(ctx context.Context, recipientAddress string) ([]*Message, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT id, sender_address, recipient_address, encrypted_content, timestamp, status, expiration_time, block_id, edited_at, reply_to_message_id, forwarded_from, sender_session_id, sticker_id FROM messages WHERE recipient_address = ? ORDER BY timestamp DESC",
 		recipientAddress,
 	)
 	if err != nil {
@@ -82,7 +122,7 @@ func GetMessagesByRecipient(recipientAddress string) ([]*Message, error) {
 		message := &Message{}
 		var status string
 		err := rows.Scan(
-			&message.ID, &message.SenderAddress, &message.RecipientAddress, &message.EncryptedContent, &message.Timestamp, &status, &message.ExpirationTime, &message.BlockID,
+			&message.ID, &message.SenderAddress, &message.RecipientAddress, &message.EncryptedContent, &message.Timestamp, &status, &message.ExpirationTime, &message.BlockID, &message.EditedAt, &message.ReplyToMessageID, &message.ForwardedFrom, &message.SenderSessionID, &message.StickerID,
 		)
 		if err != nil {
 			return nil, err
@@ -97,9 +137,9 @@ func GetMessagesByRecipient(recipientAddress string) ([]*Message, error) {
 }
 
 // GetMessagesBySender retrieves all messages sent by a sender
-func GetMessagesBySender(senderAddress string) ([]*Message, error) {
-	rows, err := database.DB.Query(
-		"SELECT id, sender_address, recipient_address, encrypted_content, timestamp, status, expiration_time, block_id FROM messages WHERE sender_address = ? ORDER BY timestamp DESC",
+func GetMessagesBySender(ctx context.Context, senderAddress string) ([]*Message, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT id, sender_address, recipient_address, encrypted_content, timestamp, status, expiration_time, block_id, edited_at, reply_to_message_id, forwarded_from, sender_session_id, sticker_id FROM messages WHERE sender_address = ? ORDER BY timestamp DESC",
 		senderAddress,
 	)
 	if err != nil {
@@ -112,7 +152,7 @@ func GetMessagesBySender(senderAddress string) ([]*Message, error) {
 		message := &Message{}
 		var status string
 		err := rows.Scan(
-			&message.ID, &message.SenderAddress, &message.RecipientAddress, &message.EncryptedContent, &message.Timestamp, &status, &message.ExpirationTime, &message.BlockID,
+			&message.ID, &message.SenderAddress, &message.RecipientAddress, &message.EncryptedContent, &message.Timestamp, &status, &message.ExpirationTime, &message.BlockID, &message.EditedAt, &message.ReplyToMessageID, &message.ForwardedFrom, &message.SenderSessionID, &message.StickerID,
 		)
 		if err != nil {
 			return nil, err
@@ -126,32 +166,357 @@ func GetMessagesBySender(senderAddress string) ([]*Message, error) {
 	return messages, nil
 }
 
-// UpdateMessageStatus updates the status of a message
-func UpdateMessageStatus(id string, status MessageStatus) error {
-	_, err := database.DB.Exec(
-		"UPDATE messages SET status = ? WHERE id = ?",
+// MessagePage selects a page of a mailbox. Before pages back from a
+// message, newest first; After pages forward from one, oldest first. With
+// neither, the page holds the newest messages.
+type MessagePage struct {
+	Before string
+	After  string
+	Limit  int
+}
+
+// GetMessagesByRecipientPage retrieves a page of the messages received by a
+// recipient, and whether there are more past it
+func GetMessagesByRecipientPage(ctx context.Context, recipientAddress string, page MessagePage) ([]*Message, bool, error) {
+	return getMessagePage(ctx, "recipient_address", recipientAddress, page)
+}
+
+// GetMessagesBySenderPage retrieves a page of the messages sent by a sender,
+// and whether there are more past it
+func GetMessagesBySenderPage(ctx context.Context, senderAddress string, page MessagePage) ([]*Message, bool, error) {
+	return getMessagePage(ctx, "sender_address", senderAddress, page)
+}
+
+// getMessagePage retrieves a page of the messages whose column is address.
+// Messages are ordered by (timestamp, id), which the mailbox indexes cover.
+func getMessagePage(ctx context.Context, column, address string, page MessagePage) ([]*Message, bool, error) {
+	args := []interface{}{address}
+	cursorID := page.Before
+	if page.After != "" {
+		cursorID = page.After
+	}
+	var cursor *time.Time
+	if cursorID != "" {
+		var timestamp time.Time
+		err := database.DB.QueryRowContext(ctx,
+			"SELECT timestamp FROM messages WHERE id = ? AND "+column+" = ?",
+			cursorID, address,
+		).Scan(&timestamp)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, false, ErrInvalidCursor
+			}
+			return nil, false, err
+		}
+		cursor = &timestamp
+		args = append(args, timestamp, timestamp, cursorID)
+	}
+
+	// Fetch one extra message to tell whether there are more
+	rows, err := database.DB.QueryContext(ctx, messagePageQuery(column, cursor, page.After != ""), append(args, page.Limit+1)...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	messages := []*Message{}
+	for rows.Next() {
+		message := &Message{}
+		var status string
+		err := rows.Scan(
+			&message.ID, &message.SenderAddress, &message.RecipientAddress, &message.EncryptedContent, &message.Timestamp, &status, &message.ExpirationTime, &message.BlockID, &message.EditedAt, &message.ReplyToMessageID, &message.ForwardedFrom, &message.SenderSessionID, &message.StickerID,
+		)
+		if err != nil {
+			return nil, false, err
+		}
+		message.Status = MessageStatus(status)
+		messages = append(messages, message)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	hasMore := len(messages) > page.Limit
+	if hasMore {
+		messages = messages[:page.Limit]
+	}
+	return messages, hasMore, nil
+}
+
+// messagePageQuery selects a page of the messages whose column is an
+// address. With a cursor it pages forward from it if after is set, otherwise
+// back from it, reading only the partitions on that side of the cursor.
+func messagePageQuery(column string, cursor *time.Time, after bool) string {
+	partition, condition, order := "", "", " ORDER BY timestamp DESC, id DESC"
+	if cursor != nil {
+		comparison := "<"
+		from, to := time.Time{}, *cursor
+		if after {
+			comparison = ">"
+			from, to = *cursor, clock.Now()
+			order = " ORDER BY timestamp, id"
+		}
+		partition = database.PartitionSelection("messages", from, to)
+		condition = " AND (timestamp " + comparison + " ? OR (timestamp = ? AND id " + comparison + " ?))"
+	}
+	return "SELECT id, sender_address, recipient_address, encrypted_content, timestamp, status, expiration_time, block_id, edited_at, reply_to_message_id, forwarded_from, sender_session_id, sticker_id FROM messages" + partition +
+		" WHERE " + column + " = ?" + condition + order + " LIMIT ?"
+}
+
+// UpdateMessageStatus moves a message forward to a status and records the
+// change in its receipts. A message never goes back to an earlier status.
+func UpdateMessageStatus(ctx context.Context, id string, status MessageStatus) error {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Enum values compare by their position in the column definition
+	result, err := tx.ExecContext(ctx,
+		"UPDATE messages SET status = ? WHERE id = ? AND status + 0 < FIELD(?, 'pending', 'delivered', 'read')",
+		status, id, status,
+	)
+	if err != nil {
+		return err
+	}
+	changed, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if changed == 0 {
+		return nil
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO message_receipts (message_id, sender_address, recipient_address, status)
+		SELECT id, sender_address, recipient_address, ? FROM messages WHERE id = ?`,
 		status, id,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	shadowSync(ctx, "status", id)
+	return nil
 }
 
 // UpdateMessageBlockID updates the block ID of a message
-func UpdateMessageBlockID(id string, blockID string) error {
-	_, err := database.DB.Exec(
+func UpdateMessageBlockID(ctx context.Context, id string, blockID string) error {
+	_, err := database.DB.ExecContext(ctx,
 		"UPDATE messages SET block_id = ? WHERE id = ?",
 		blockID, id,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	shadowSync(ctx, "block", id)
+	return nil
+}
+
+// EditMessage replaces the content of a message and keeps the previous
+// version in the edit history
+func EditMessage(ctx context.Context, id string, encryptedContent []byte) (*time.Time, error) {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Archive the current content
+	result, err := tx.ExecContext(ctx,
+		"INSERT INTO message_edits (message_id, encrypted_content) SELECT id, encrypted_content FROM messages WHERE id = ?",
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		return nil, ErrMessageNotFound
+	}
+
+	// Replace the content
+	editedAt := clock.Now()
+	_, err = tx.ExecContext(ctx,
+		"UPDATE messages SET encrypted_content = ?, edited_at = ? WHERE id = ?",
+		encryptedContent, editedAt, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	shadowSync(ctx, "edit", id)
+	return &editedAt, nil
+}
+
+// GetMessageEdits retrieves the previous versions of a message, oldest first
+func GetMessageEdits(ctx context.Context, messageID string) ([]*MessageEdit, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT id, message_id, encrypted_content, edited_at FROM message_edits WHERE message_id = ? ORDER BY id",
+		messageID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	edits := []*MessageEdit{}
+	for rows.Next() {
+		edit := &MessageEdit{}
+		if err := rows.Scan(&edit.ID, &edit.MessageID, &edit.EncryptedContent, &edit.EditedAt); err != nil {
+			return nil, err
+		}
+		edits = append(edits, edit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return edits, nil
 }
 
 // DeleteMessage deletes a message by its ID
-func DeleteMessage(id string) error {
-	_, err := database.DB.Exec("DELETE FROM messages WHERE id = ?", id)
-	return err
+func DeleteMessage(ctx context.Context, id string) error {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM messages WHERE id = ?", id); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM message_receipts WHERE message_id = ?", id); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	shadowDelete("delete", id)
+	return nil
+}
+
+// ConversationSummary summarizes a one-to-one conversation from the point of
+// view of one participant
+type ConversationSummary struct {
+	PeerAddress   string     `json:"peer_address"`
+	LastMessageAt types.Time `json:"last_message_at"`
+	UnreadCount   int        `json:"unread_count"`
+	LastMessage   *Message   `json:"last_message,omitempty"`
+	KeyVerified   bool       `json:"key_verified"`
+}
+
+// GetConversationSummaries retrieves the most recently active conversations of
+// a user, newest first
+func GetConversationSummaries(ctx context.Context, address string, limit int) ([]*ConversationSummary, error) {
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT peer, MAX(timestamp) AS last_message_at,
+		       SUM(CASE WHEN recipient_address = ? AND status != 'read' THEN 1 ELSE 0 END) AS unread_count
+		FROM (
+			SELECT CASE WHEN sender_address = ? THEN recipient_address ELSE sender_address END AS peer,
+			       recipient_address, status, timestamp
+			FROM messages
+			WHERE sender_address = ? OR recipient_address = ?
+		) conversation
+		GROUP BY peer
+		ORDER BY last_message_at DESC
+		LIMIT ?`,
+		address, address, address, address, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := []*ConversationSummary{}
+	for rows.Next() {
+		summary := &ConversationSummary{}
+		if err := rows.Scan(&summary.PeerAddress, &summary.LastMessageAt, &summary.UnreadCount); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, summary)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Attach the latest message of each conversation
+	for _, summary := range summaries {
+		message, err := GetLatestMessageBetween(ctx, address, summary.PeerAddress)
+		if err != nil && !errors.Is(err, ErrMessageNotFound) {
+			return nil, err
+		}
+		summary.LastMessage = message
+
+		key, err := GetConversationKey(ctx, address, summary.PeerAddress)
+		if err != nil && !errors.Is(err, ErrConversationKeyNotFound) {
+			return nil, err
+		}
+		summary.KeyVerified = key != nil && key.Verified
+	}
+
+	return summaries, nil
+}
+
+// GetLatestMessageBetween retrieves the newest message exchanged between two addresses
+func GetLatestMessageBetween(ctx context.Context, address, peerAddress string) (*Message, error) {
+	message := &Message{}
+	var status string
+	err := database.DB.QueryRowContext(ctx,
+		`SELECT id, sender_address, recipient_address, encrypted_content, timestamp, status, expiration_time, block_id, edited_at, reply_to_message_id, forwarded_from, sender_session_id, sticker_id
+		FROM messages
+		WHERE (sender_address = ? AND recipient_address = ?) OR (sender_address = ? AND recipient_address = ?)
+		ORDER BY timestamp DESC LIMIT 1`,
+		address, peerAddress, peerAddress, address,
+	).Scan(
+		&message.ID, &message.SenderAddress, &message.RecipientAddress, &message.EncryptedContent, &message.Timestamp, &status, &message.ExpirationTime, &message.BlockID, &message.EditedAt, &message.ReplyToMessageID, &message.ForwardedFrom, &message.SenderSessionID, &message.StickerID,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrMessageNotFound
+		}
+		return nil, err
+	}
+	message.Status = MessageStatus(status)
+	return message, nil
 }
 
-// DeleteExpiredMessages deletes all expired messages
-func DeleteExpiredMessages() error {
-	_, err := database.DB.Exec("DELETE FROM messages WHERE expiration_time IS NOT NULL AND expiration_time < NOW()")
-	return err
-} 
\ No newline at end of file
+// StreamConversation calls fn for every message exchanged between two
+// addresses, oldest first, without loading the whole conversation into memory
+func StreamConversation(ctx context.Context, address, peerAddress string, fn func(*Message) error) error {
+	rows, err := database.DB.QueryContext(ctx,
+		`SELECT id, sender_address, recipient_address, encrypted_content, timestamp, status, expiration_time, block_id, edited_at, reply_to_message_id, forwarded_from, sender_session_id, sticker_id
+		FROM messages
+		WHERE (sender_address = ? AND recipient_address = ?) OR (sender_address = ? AND recipient_address = ?)
+		ORDER BY timestamp ASC, id ASC`,
+		address, peerAddress, peerAddress, address,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		message := &Message{}
+		var status string
+		err := rows.Scan(
+			&message.ID, &message.SenderAddress, &message.RecipientAddress, &message.EncryptedContent, &message.Timestamp, &status, &message.ExpirationTime, &message.BlockID, &message.EditedAt, &message.ReplyToMessageID, &message.ForwardedFrom, &message.SenderSessionID, &message.StickerID,
+		)
+		if err != nil {
+			return err
+		}
+		message.Status = MessageStatus(status)
+		if err := fn(message); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
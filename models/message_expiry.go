@@ -0,0 +1,102 @@
+package models
+
+import (
+	"context"
+	"strings"
+
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/database"
+)
+
+// expiryBatchSize bounds how many messages one purge query deletes, so a
+// backlog of expired messages doesn't hold locks for long
+const expiryBatchSize = 500
+
+// ExpiredMessage identifies a purged message and its participants
+type ExpiredMessage struct {
+	ID               string
+	SenderAddress    string
+	RecipientAddress string
+}
+
+// DeleteExpiredMessages deletes all expired messages, except those of
+// accounts under legal hold, and returns what it deleted
+func DeleteExpiredMessages(ctx context.Context) ([]*ExpiredMessage, error) {
+	deleted := []*ExpiredMessage{}
+	for {
+		batch, err := deleteExpiredBatch(ctx)
+		if err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, batch...)
+		if len(batch) < expiryBatchSize {
+			return deleted, nil
+		}
+	}
+}
+
+// deleteExpiredBatch deletes up to expiryBatchSize expired messages
+func deleteExpiredBatch(ctx context.Context) ([]*ExpiredMessage, error) {
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT id, sender_address, recipient_address FROM messages
+		WHERE expiration_time IS NOT NULL AND expiration_time < ?
+		AND sender_address NOT IN (SELECT user_address FROM legal_holds)
+		AND recipient_address NOT IN (SELECT user_address FROM legal_holds)
+		LIMIT ?`,
+		clock.Now(), expiryBatchSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	expired := []*ExpiredMessage{}
+	ids := []interface{}{}
+	for rows.Next() {
+		message := &ExpiredMessage{}
+		if err := rows.Scan(&message.ID, &message.SenderAddress, &message.RecipientAddress); err != nil {
+			return nil, err
+		}
+		expired = append(expired, message)
+		ids = append(ids, message.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(expired) == 0 {
+		return expired, nil
+	}
+
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Holds placed since the SELECT still protect their messages
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM messages WHERE id IN (?`+strings.Repeat(", ?", len(ids)-1)+`)
+		AND sender_address NOT IN (SELECT user_address FROM legal_holds)
+		AND recipient_address NOT IN (SELECT user_address FROM legal_holds)`,
+		ids...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM message_receipts WHERE message_id IN (?`+strings.Repeat(", ?", len(ids)-1)+`)
+		AND message_id NOT IN (SELECT id FROM messages)`,
+		ids...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	for _, message := range expired {
+		shadowDelete("expire", message.ID)
+	}
+	return expired, nil
+}
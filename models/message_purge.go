@@ -0,0 +1,106 @@
+package models
+
+import (
+	"context"
+
+	"github.com/piko/piko/database"
+)
+
+// PurgedMessages counts the messages PurgeUserMessages deleted
+type PurgedMessages struct {
+	Direct  int64 `json:"direct"`
+	Group   int64 `json:"group"`
+	Channel int64 `json:"channel"`
+}
+
+// purgeableDirectMessages selects the direct messages of a sender that may
+// be purged: those whose recipient isn't under legal hold
+const purgeableDirectMessages = `SELECT id FROM messages WHERE sender_address = ?
+	AND recipient_address NOT IN (SELECT user_address FROM legal_holds)`
+
+// PurgeUserMessages deletes every direct, group and channel message a user
+// sent, with their receipts and edit history. It returns ErrUnderLegalHold
+// for an account under legal hold, and keeps direct messages to accounts
+// under legal hold.
+func PurgeUserMessages(ctx context.Context, senderAddress string) (*PurgedMessages, error) {
+	if err := CheckLegalHold(ctx, senderAddress); err != nil {
+		return nil, err
+	}
+
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// The IDs are needed to purge the shadow store once committed
+	rows, err := tx.QueryContext(ctx, purgeableDirectMessages+" FOR UPDATE", senderAddress)
+	if err != nil {
+		return nil, err
+	}
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	exec := func(query string) (int64, error) {
+		result, err := tx.ExecContext(ctx, query, senderAddress)
+		if err != nil {
+			return 0, err
+		}
+		return result.RowsAffected()
+	}
+
+	purged := &PurgedMessages{}
+	if _, err := exec("DELETE FROM message_receipts WHERE message_id IN (" + purgeableDirectMessages + ")"); err != nil {
+		return nil, err
+	}
+	if _, err := exec("DELETE FROM message_edits WHERE message_id IN (" + purgeableDirectMessages + ")"); err != nil {
+		return nil, err
+	}
+	purged.Direct, err = exec(`DELETE FROM messages WHERE sender_address = ?
+		AND recipient_address NOT IN (SELECT user_address FROM legal_holds)`)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep the denormalized message counts in step
+	_, err = exec(`UPDATE chat_groups g JOIN (
+		SELECT group_id, COUNT(*) AS n FROM group_messages WHERE sender_address = ? GROUP BY group_id
+	) d ON d.group_id = g.id SET g.message_count = GREATEST(g.message_count - d.n, 0)`)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := exec("DELETE FROM group_message_deliveries WHERE message_id IN (SELECT id FROM group_messages WHERE sender_address = ?)"); err != nil {
+		return nil, err
+	}
+	if purged.Group, err = exec("DELETE FROM group_messages WHERE sender_address = ?"); err != nil {
+		return nil, err
+	}
+
+	_, err = exec(`UPDATE channels c JOIN (
+		SELECT channel_id, COUNT(*) AS n FROM channel_messages WHERE sender_address = ? GROUP BY channel_id
+	) d ON d.channel_id = c.id SET c.message_count = GREATEST(c.message_count - d.n, 0)`)
+	if err != nil {
+		return nil, err
+	}
+	if purged.Channel, err = exec("DELETE FROM channel_messages WHERE sender_address = ?"); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	shadowDelete("purge", ids...)
+	return purged, nil
+}
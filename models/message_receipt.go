@@ -0,0 +1,115 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
+)
+
+// ErrInvalidReceiptCursor is returned for a receipts cursor this server
+// didn't hand out
+var ErrInvalidReceiptCursor = errors.New("invalid receipts cursor")
+
+// MessageReceipt records a direct message moving to a new status
+type MessageReceipt struct {
+	ID               int64         `json:"-"`
+	MessageID        string        `json:"message_id"`
+	RecipientAddress string        `json:"recipient_address"`
+	Status           MessageStatus `json:"status"`
+	Timestamp        types.Time    `json:"timestamp"`
+}
+
+// GetMessageReceipts returns the status changes of a message, oldest first
+func GetMessageReceipts(ctx context.Context, messageID string) ([]*MessageReceipt, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		`SELECT id, message_id, recipient_address, status, timestamp FROM message_receipts
+		WHERE message_id = ? ORDER BY timestamp, id`,
+		messageID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanMessageReceipts(rows)
+}
+
+// ReceiptCursor is the last receipt of a page of a sender's receipts.
+// Receipts are ordered by (timestamp, id), since the receipts of a batch of
+// status changes are recorded with the same timestamp.
+type ReceiptCursor struct {
+	Timestamp time.Time
+	ID        int64
+}
+
+// ReceiptCursorAt is the cursor of the receipts recorded after a time
+func ReceiptCursorAt(since time.Time) ReceiptCursor {
+	return ReceiptCursor{Timestamp: since, ID: math.MaxInt64}
+}
+
+// Cursor returns the cursor of the receipts after this one
+func (r *MessageReceipt) Cursor() ReceiptCursor {
+	return ReceiptCursor{Timestamp: r.Timestamp.Time, ID: r.ID}
+}
+
+// String encodes the cursor for clients, who pass it back as is
+func (c ReceiptCursor) String() string {
+	raw := strconv.FormatInt(c.Timestamp.UnixMicro(), 10) + "." + strconv.FormatInt(c.ID, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseReceiptCursor decodes a cursor made by ReceiptCursor.String
+func ParseReceiptCursor(s string) (ReceiptCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return ReceiptCursor{}, ErrInvalidReceiptCursor
+	}
+	micros, id, ok := strings.Cut(string(raw), ".")
+	if !ok {
+		return ReceiptCursor{}, ErrInvalidReceiptCursor
+	}
+	timestamp, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return ReceiptCursor{}, ErrInvalidReceiptCursor
+	}
+	cursor := ReceiptCursor{Timestamp: time.UnixMicro(timestamp).UTC()}
+	if cursor.ID, err = strconv.ParseInt(id, 10, 64); err != nil {
+		return ReceiptCursor{}, ErrInvalidReceiptCursor
+	}
+	return cursor, nil
+}
+
+// GetReceiptsAfter returns the status changes of messages a user sent that
+// come after a cursor, oldest first
+func GetReceiptsAfter(ctx context.Context, senderAddress string, after ReceiptCursor, limit int) ([]*MessageReceipt, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		`SELECT id, message_id, recipient_address, status, timestamp FROM message_receipts
+		WHERE sender_address = ? AND (timestamp, id) > (?, ?) ORDER BY timestamp, id LIMIT ?`,
+		senderAddress, after.Timestamp, after.ID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanMessageReceipts(rows)
+}
+
+// scanMessageReceipts reads the rows of a receipts query
+func scanMessageReceipts(rows *sql.Rows) ([]*MessageReceipt, error) {
+	receipts := []*MessageReceipt{}
+	for rows.Next() {
+		receipt := &MessageReceipt{}
+		if err := rows.Scan(&receipt.ID, &receipt.MessageID, &receipt.RecipientAddress, &receipt.Status, &receipt.Timestamp); err != nil {
+			return nil, err
+		}
+		receipts = append(receipts, receipt)
+	}
+	return receipts, rows.Err()
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/piko/piko/database/dbtest"
+)
+
+func TestReceiptCursorRoundTrip(t *testing.T) {
+	cursor := ReceiptCursor{Timestamp: time.Date(2023, 6, 15, 11, 46, 10, 100877000, time.UTC), ID: 42}
+
+	parsed, err := ParseReceiptCursor(cursor.String())
+	if err != nil {
+		t.Fatalf("ParseReceiptCursor: %v", err)
+	}
+	if !parsed.Timestamp.Equal(cursor.Timestamp) || parsed.ID != cursor.ID {
+		t.Errorf("got %+v, want %+v", parsed, cursor)
+	}
+
+	for _, invalid := range []string{"not base64!", "MTIz", "YWJjLjQy"} {
+		if _, err := ParseReceiptCursor(invalid); err != ErrInvalidReceiptCursor {
+			t.Errorf("ParseReceiptCursor(%q) = %v, want ErrInvalidReceiptCursor", invalid, err)
+		}
+	}
+}
+
+func TestIntegrationReceiptsPageThroughBatch(t *testing.T) {
+	dbtest.Open(t)
+	ctx := context.Background()
+	sender := testAddress(t, "Sender")
+	recipient := testAddress(t, "Recipient")
+
+	ids := make([]string, 5)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("test-receipt-%d-%d", time.Now().UnixNano(), i)
+		message := &Message{
+			ID:               ids[i],
+			SenderAddress:    sender,
+			RecipientAddress: recipient,
+			EncryptedContent: []byte("ciphertext"),
+			Status:           MessageStatusPending,
+		}
+		if err := CreateMessage(ctx, message); err != nil {
+			t.Fatalf("CreateMessage: %v", err)
+		}
+	}
+
+	// One batch records every receipt with the same timestamp
+	if _, _, err := UpdateMessageStatuses(ctx, recipient, ids, MessageStatusDelivered); err != nil {
+		t.Fatalf("UpdateMessageStatuses: %v", err)
+	}
+
+	// Pages of two split the batch twice
+	seen := map[string]int{}
+	after := ReceiptCursorAt(time.Time{})
+	for page := 0; page < 4; page++ {
+		receipts, err := GetReceiptsAfter(ctx, sender, after, 2)
+		if err != nil {
+			t.Fatalf("GetReceiptsAfter: %v", err)
+		}
+		if len(receipts) == 0 {
+			break
+		}
+		for _, receipt := range receipts {
+			seen[receipt.MessageID]++
+		}
+		after, err = ParseReceiptCursor(receipts[len(receipts)-1].Cursor().String())
+		if err != nil {
+			t.Fatalf("ParseReceiptCursor: %v", err)
+		}
+	}
+
+	if len(seen) != len(ids) {
+		t.Errorf("got receipts of %d messages, want %d", len(seen), len(ids))
+	}
+	for _, id := range ids {
+		if seen[id] != 1 {
+			t.Errorf("got %d receipts of %s, want 1", seen[id], id)
+		}
+	}
+}
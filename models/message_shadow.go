@@ -0,0 +1,139 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"math/rand"
+
+	"github.com/piko/piko/metrics"
+	"github.com/piko/piko/types"
+)
+
+// MessageShadowStore is a second message backend written alongside MySQL
+// during a storage migration. MySQL stays the source of truth; the shadow
+// store is only written and compared, never served from.
+type MessageShadowStore interface {
+	// PutMessage stores or replaces a message
+	PutMessage(message *Message) error
+	// GetMessage returns ErrMessageNotFound for messages it doesn't have
+	GetMessage(id string) (*Message, error)
+	// DeleteMessage removes a message, succeeding if it doesn't exist
+	DeleteMessage(id string) error
+}
+
+var (
+	// messageShadow is nil unless shadow mode is enabled
+	messageShadow         MessageShadowStore
+	messageShadowSampling float64
+
+	shadowWrites = metrics.NewCounterVec(
+		"piko_message_shadow_writes_total",
+		"Message writes mirrored to the shadow backend, by operation and result.",
+		"operation", "result",
+	)
+	shadowReads = metrics.NewCounterVec(
+		"piko_message_shadow_reads_total",
+		"Sampled message reads compared with the shadow backend, by result: match, mismatch, missing or error.",
+		"result",
+	)
+)
+
+// EnableMessageShadow mirrors message writes to store and compares a
+// fraction of reads with it
+func EnableMessageShadow(store MessageShadowStore, readSampleRate float64) {
+	messageShadow = store
+	messageShadowSampling = readSampleRate
+}
+
+// shadowSync copies the current state of a message from MySQL to the shadow
+// store. Shadow failures are counted and logged but never fail the write.
+func shadowSync(ctx context.Context, operation, id string) {
+	if messageShadow == nil {
+		return
+	}
+
+	message, err := getMessageByID(ctx, id)
+	if err == nil {
+		err = messageShadow.PutMessage(message)
+	} else if errors.Is(err, ErrMessageNotFound) {
+		err = messageShadow.DeleteMessage(id)
+	}
+	recordShadowWrite(operation, id, err)
+}
+
+// shadowDelete removes messages from the shadow store
+func shadowDelete(operation string, ids ...string) {
+	if messageShadow == nil {
+		return
+	}
+	for _, id := range ids {
+		recordShadowWrite(operation, id, messageShadow.DeleteMessage(id))
+	}
+}
+
+// recordShadowWrite counts the result of a mirrored write
+func recordShadowWrite(operation, id string, err error) {
+	if err != nil {
+		log.Printf("Shadow %s of message %s failed: %v", operation, id, err)
+		shadowWrites.Inc(operation, "error")
+		return
+	}
+	shadowWrites.Inc(operation, "ok")
+}
+
+// shadowCompare compares a sample of messages read from MySQL with the
+// shadow store's copy, off the request path
+func shadowCompare(message *Message) {
+	if messageShadow == nil || rand.Float64() >= messageShadowSampling {
+		return
+	}
+
+	go func() {
+		shadowed, err := messageShadow.GetMessage(message.ID)
+		switch {
+		case errors.Is(err, ErrMessageNotFound):
+			shadowReads.Inc("missing")
+		case err != nil:
+			log.Printf("Shadow read of message %s failed: %v", message.ID, err)
+			shadowReads.Inc("error")
+		case !sameMessage(message, shadowed):
+			log.Printf("Shadow copy of message %s diverged from MySQL", message.ID)
+			shadowReads.Inc("mismatch")
+		default:
+			shadowReads.Inc("match")
+		}
+	}()
+}
+
+// sameMessage checks if two copies of a message agree on every stored field
+func sameMessage(a, b *Message) bool {
+	return a.ID == b.ID &&
+		a.SenderAddress == b.SenderAddress &&
+		a.RecipientAddress == b.RecipientAddress &&
+		bytes.Equal(a.EncryptedContent, b.EncryptedContent) &&
+		a.Timestamp.Equal(b.Timestamp.Time) &&
+		a.Status == b.Status &&
+		sameTime(a.ExpirationTime, b.ExpirationTime) &&
+		sameString(a.BlockID, b.BlockID) &&
+		sameTime(a.EditedAt, b.EditedAt) &&
+		sameString(a.ReplyToMessageID, b.ReplyToMessageID) &&
+		sameString(a.ForwardedFrom, b.ForwardedFrom) &&
+		sameString(a.SenderSessionID, b.SenderSessionID) &&
+		sameString(a.StickerID, b.StickerID)
+}
+
+func sameTime(a, b *types.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(b.Time)
+}
+
+func sameString(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"context"
+	"strings"
+
+	"github.com/piko/piko/database"
+)
+
+// StatusChange is a direct message whose status an update moved forward
+type StatusChange struct {
+	MessageID     string
+	SenderAddress string
+}
+
+// UpdateMessageStatuses moves the messages a recipient received forward to
+// a status in one statement and records the changes in their receipts, like
+// UpdateMessageStatus does for one message. IDs that aren't messages the
+// recipient received are returned as skipped; messages already at or past
+// the status are left alone and not returned at all.
+func UpdateMessageStatuses(ctx context.Context, recipientAddress string, messageIDs []string, status MessageStatus) ([]StatusChange, []string, error) {
+	if len(messageIDs) == 0 {
+		return []StatusChange{}, []string{}, nil
+	}
+
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	in := "IN (?" + strings.Repeat(", ?", len(messageIDs)-1) + ")"
+	args := make([]interface{}, 0, len(messageIDs)+2)
+	args = append(args, recipientAddress)
+	for _, id := range messageIDs {
+		args = append(args, id)
+	}
+
+	// Lock the recipient's messages among the IDs, noting which will change.
+	// Enum values compare by their position in the column definition.
+	rows, err := tx.QueryContext(ctx,
+		"SELECT id, sender_address, status + 0 < FIELD(?, 'pending', 'delivered', 'read') FROM messages WHERE recipient_address = ? AND id "+in+" FOR UPDATE",
+		append([]interface{}{status}, args...)...,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	received := map[string]bool{}
+	changes := []StatusChange{}
+	for rows.Next() {
+		var change StatusChange
+		var behind bool
+		if err := rows.Scan(&change.MessageID, &change.SenderAddress, &behind); err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		received[change.MessageID] = true
+		if behind {
+			changes = append(changes, change)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	skipped := []string{}
+	for _, id := range messageIDs {
+		if !received[id] {
+			skipped = append(skipped, id)
+		}
+	}
+	if len(changes) == 0 {
+		return changes, skipped, nil
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE messages SET status = ? WHERE recipient_address = ? AND id "+in+" AND status + 0 < FIELD(?, 'pending', 'delivered', 'read')",
+		append(append([]interface{}{status}, args...), status)...,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	changedIn := "IN (?" + strings.Repeat(", ?", len(changes)-1) + ")"
+	changedArgs := make([]interface{}, 0, len(changes)+1)
+	changedArgs = append(changedArgs, status)
+	for _, change := range changes {
+		changedArgs = append(changedArgs, change.MessageID)
+	}
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO message_receipts (message_id, sender_address, recipient_address, status)
+		SELECT id, sender_address, recipient_address, ? FROM messages WHERE id `+changedIn,
+		changedArgs...,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+	for _, change := range changes {
+		shadowSync(ctx, "status", change.MessageID)
+	}
+	return changes, skipped, nil
+}
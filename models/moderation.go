@@ -0,0 +1,288 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
+)
+
+var (
+	// ErrChannelFlagNotFound is returned when a channel has no moderation flag
+	ErrChannelFlagNotFound = errors.New("channel flag not found")
+	// ErrChannelNotThrottled is returned when appealing a channel that isn't throttled
+	ErrChannelNotThrottled = errors.New("channel is not throttled")
+	// ErrAppealExists is returned when a throttled channel was already appealed
+	ErrAppealExists = errors.New("appeal already submitted")
+)
+
+// ChannelFlag is a public channel's standing in the moderation queue. A
+// channel is flagged and throttled when enough people report it.
+type ChannelFlag struct {
+	ChannelID     string      `json:"channel_id"`
+	Throttled     bool        `json:"throttled"`
+	Upheld        bool        `json:"upheld"`
+	ReporterCount int         `json:"reporter_count"`
+	FlaggedAt     types.Time  `json:"flagged_at"`
+	ReleasedAt    *types.Time `json:"released_at,omitempty"`
+	ReviewedBy    *string     `json:"reviewed_by,omitempty"`
+	ReviewedAt    *types.Time `json:"reviewed_at,omitempty"`
+	Appeal        *string     `json:"appeal,omitempty"`
+	AppealedAt    *types.Time `json:"appealed_at,omitempty"`
+}
+
+// ReportThresholds decide when reports throttle a channel. Only open
+// reports made after Since by accounts created before ReportersBefore
+// count, and each reporter counts once.
+type ReportThresholds struct {
+	// ThrottleAt is how many reporters throttle a channel
+	ThrottleAt int
+	// ReleaseAt is how few reporters must be left before a throttled
+	// channel is released. It is lower than ThrottleAt so a channel
+	// doesn't flap around one threshold.
+	ReleaseAt       int
+	Since           time.Time
+	ReportersBefore time.Time
+}
+
+// channelFlagColumns are the columns scanned by scanChannelFlag
+const channelFlagColumns = `channel_id, throttled, upheld, reporter_count, flagged_at, released_at,
+	reviewed_by, reviewed_at, appeal, appealed_at`
+
+// scanChannelFlag scans a row of channelFlagColumns
+func scanChannelFlag(row interface{ Scan(...any) error }) (*ChannelFlag, error) {
+	flag := &ChannelFlag{}
+	err := row.Scan(
+		&flag.ChannelID, &flag.Throttled, &flag.Upheld, &flag.ReporterCount, &flag.FlaggedAt, &flag.ReleasedAt,
+		&flag.ReviewedBy, &flag.ReviewedAt, &flag.Appeal, &flag.AppealedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return flag, nil
+}
+
+// countChannelReporters counts the reporters of a channel that count
+// towards throttling it
+func countChannelReporters(ctx context.Context, tx *sql.Tx, channelID string, thresholds ReportThresholds) (int, error) {
+	var count int
+	err := tx.QueryRowContext(ctx,
+		`SELECT COUNT(DISTINCT r.reporter_address)
+		FROM reports r JOIN users u ON u.address = r.reporter_address
+		WHERE r.target_type = ? AND r.target_id = ? AND r.status = ?
+		AND r.created_at > ? AND u.created_at < ?`,
+		ReportTargetChannel, channelID, ReportStatusOpen, thresholds.Since, thresholds.ReportersBefore,
+	).Scan(&count)
+	return count, err
+}
+
+// EvaluateChannelReports throttles a public channel once enough people
+// have reported it, and releases it once few enough reports are left,
+// unless an admin upheld the throttle. It returns the channel's flag, or
+// nil if it has never been throttled, and whether it was just throttled.
+func EvaluateChannelReports(ctx context.Context, channelID string, thresholds ReportThresholds) (*ChannelFlag, bool, error) {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	var isPublic bool
+	err = tx.QueryRowContext(ctx, "SELECT is_public FROM channels WHERE id = ?", channelID).Scan(&isPublic)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, false, ErrChannelNotFound
+		}
+		return nil, false, err
+	}
+
+	flag, err := scanChannelFlag(tx.QueryRowContext(ctx,
+		"SELECT "+channelFlagColumns+" FROM channel_flags WHERE channel_id = ? FOR UPDATE", channelID))
+	if err != nil && err != sql.ErrNoRows {
+		return nil, false, err
+	}
+
+	count, err := countChannelReporters(ctx, tx, channelID, thresholds)
+	if err != nil {
+		return nil, false, err
+	}
+
+	now := clock.Now()
+	throttled := false
+	switch {
+	case (flag == nil || !flag.Throttled) && isPublic && count >= thresholds.ThrottleAt:
+		// A new flag starts a new review, so earlier decisions and appeals are cleared
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO channel_flags (channel_id, throttled, upheld, reporter_count, flagged_at)
+			VALUES (?, TRUE, FALSE, ?, ?)
+			ON DUPLICATE KEY UPDATE throttled = TRUE, upheld = FALSE, reporter_count = VALUES(reporter_count),
+			flagged_at = VALUES(flagged_at), released_at = NULL, reviewed_by = NULL, reviewed_at = NULL,
+			appeal = NULL, appealed_at = NULL`,
+			channelID, count, now,
+		)
+		throttled = true
+	case flag != nil && flag.Throttled && !flag.Upheld && count <= thresholds.ReleaseAt:
+		_, err = tx.ExecContext(ctx,
+			"UPDATE channel_flags SET throttled = FALSE, reporter_count = ?, released_at = ? WHERE channel_id = ?",
+			count, now, channelID,
+		)
+	case flag != nil:
+		_, err = tx.ExecContext(ctx, "UPDATE channel_flags SET reporter_count = ? WHERE channel_id = ?", count, channelID)
+	default:
+		return nil, false, tx.Commit()
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	flag, err = scanChannelFlag(tx.QueryRowContext(ctx,
+		"SELECT "+channelFlagColumns+" FROM channel_flags WHERE channel_id = ?", channelID))
+	if err != nil {
+		return nil, false, err
+	}
+	return flag, throttled, tx.Commit()
+}
+
+// GetChannelFlag retrieves the moderation flag of a channel
+func GetChannelFlag(ctx context.Context, channelID string) (*ChannelFlag, error) {
+	flag, err := scanChannelFlag(database.DB.QueryRowContext(ctx,
+		"SELECT "+channelFlagColumns+" FROM channel_flags WHERE channel_id = ?", channelID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrChannelFlagNotFound
+		}
+		return nil, err
+	}
+	return flag, nil
+}
+
+// IsChannelThrottled checks if a channel's fan-out is throttled
+func IsChannelThrottled(ctx context.Context, channelID string) (bool, error) {
+	var throttled bool
+	err := database.DB.QueryRowContext(ctx,
+		"SELECT throttled FROM channel_flags WHERE channel_id = ?", channelID,
+	).Scan(&throttled)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return throttled, err
+}
+
+// GetChannelFlagsForReview lists the throttled channels an admin hasn't
+// upheld yet, and upheld ones with an appeal waiting, oldest first
+func GetChannelFlagsForReview(ctx context.Context, limit int) ([]*ChannelFlag, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT "+channelFlagColumns+` FROM channel_flags
+		WHERE throttled = TRUE AND (upheld = FALSE OR (appealed_at IS NOT NULL AND appealed_at > reviewed_at))
+		ORDER BY flagged_at LIMIT ?`,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	flags := []*ChannelFlag{}
+	for rows.Next() {
+		flag, err := scanChannelFlag(rows)
+		if err != nil {
+			return nil, err
+		}
+		flags = append(flags, flag)
+	}
+	return flags, rows.Err()
+}
+
+// AppealChannelFlag records the owner's appeal against their channel being
+// throttled. Each throttle may be appealed once.
+func AppealChannelFlag(ctx context.Context, channelID, appeal string) error {
+	result, err := database.DB.ExecContext(ctx,
+		"UPDATE channel_flags SET appeal = ?, appealed_at = ? WHERE channel_id = ? AND throttled = TRUE AND appeal IS NULL",
+		appeal, clock.Now(), channelID,
+	)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected > 0 {
+		return nil
+	}
+
+	flag, err := GetChannelFlag(ctx, channelID)
+	if err != nil {
+		if errors.Is(err, ErrChannelFlagNotFound) {
+			return ErrChannelNotThrottled
+		}
+		return err
+	}
+	if !flag.Throttled {
+		return ErrChannelNotThrottled
+	}
+	return ErrAppealExists
+}
+
+// ReleaseChannelFlag lifts a channel's throttle after review and dismisses
+// the open reports against it, so they can't throttle it again
+func ReleaseChannelFlag(ctx context.Context, channelID, adminAddress string) error {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := clock.Now()
+	result, err := tx.ExecContext(ctx,
+		`UPDATE channel_flags SET throttled = FALSE, upheld = FALSE, released_at = ?, reviewed_by = ?, reviewed_at = ?
+		WHERE channel_id = ? AND throttled = TRUE`,
+		now, adminAddress, now, channelID,
+	)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrChannelFlagNotFound
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE reports SET status = ?, resolved_by = ?, resolved_at = ?
+		WHERE target_type = ? AND target_id = ? AND status = ?`,
+		ReportStatusDismissed, adminAddress, now, ReportTargetChannel, channelID, ReportStatusOpen,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpholdChannelFlag keeps a channel throttled after review. Upheld
+// throttles aren't released when reports drop off, only by an admin.
+func UpholdChannelFlag(ctx context.Context, channelID, adminAddress string) error {
+	now := clock.Now()
+	result, err := database.DB.ExecContext(ctx,
+		"UPDATE channel_flags SET upheld = TRUE, reviewed_by = ?, reviewed_at = ? WHERE channel_id = ? AND throttled = TRUE",
+		adminAddress, now, channelID,
+	)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrChannelFlagNotFound
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
+)
+
+var (
+	// ErrMuteNotFound is returned when a conversation isn't muted
+	ErrMuteNotFound = errors.New("mute not found")
+)
+
+// Mute silences notifications from one conversation for a user: a peer's
+// direct messages, a group or a channel
+type Mute struct {
+	UserAddress      string           `json:"user_address"`
+	ConversationType ConversationType `json:"conversation_type"`
+	// ConversationID is the peer's address for direct conversations
+	ConversationID string `json:"conversation_id"`
+	// MutedUntil is when the mute ends, or nil if it lasts until cleared
+	MutedUntil *types.Time `json:"muted_until,omitempty"`
+	CreatedAt  types.Time  `json:"created_at"`
+}
+
+// DoNotDisturb silences all of a user's notifications
+type DoNotDisturb struct {
+	UserAddress string `json:"user_address"`
+	// Until is when do not disturb ends, or nil if it lasts until cleared
+	Until     *types.Time `json:"until,omitempty"`
+	CreatedAt types.Time  `json:"created_at"`
+}
+
+// SetMute mutes a conversation, replacing when an earlier mute of it ends
+func SetMute(ctx context.Context, mute *Mute) error {
+	_, err := database.DB.ExecContext(ctx,
+		`INSERT INTO mutes (user_address, conversation_type, conversation_id, muted_until) VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE muted_until = VALUES(muted_until), created_at = CURRENT_TIMESTAMP`,
+		mute.UserAddress, mute.ConversationType, mute.ConversationID, mute.MutedUntil,
+	)
+	return err
+}
+
+// DeleteMute unmutes a conversation
+func DeleteMute(ctx context.Context, userAddress string, conversationType ConversationType, conversationID string) error {
+	result, err := database.DB.ExecContext(ctx,
+		"DELETE FROM mutes WHERE user_address = ? AND conversation_type = ? AND conversation_id = ?",
+		userAddress, conversationType, conversationID,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrMuteNotFound
+	}
+	return nil
+}
+
+// GetMutes returns the conversations a user has muted that haven't ended
+func GetMutes(ctx context.Context, userAddress string) ([]*Mute, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		`SELECT user_address, conversation_type, conversation_id, muted_until, created_at
+		FROM mutes WHERE user_address = ? AND (muted_until IS NULL OR muted_until > ?)
+		ORDER BY created_at DESC`,
+		userAddress, clock.Now(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mutes := []*Mute{}
+	for rows.Next() {
+		mute := &Mute{}
+		if err := rows.Scan(&mute.UserAddress, &mute.ConversationType, &mute.ConversationID, &mute.MutedUntil, &mute.CreatedAt); err != nil {
+			return nil, err
+		}
+		mutes = append(mutes, mute)
+	}
+	return mutes, rows.Err()
+}
+
+// SetDoNotDisturb silences all of a user's notifications until a time, or
+// until cleared if until is nil
+func SetDoNotDisturb(ctx context.Context, userAddress string, until *time.Time) error {
+	_, err := database.DB.ExecContext(ctx,
+		`INSERT INTO do_not_disturb (user_address, dnd_until) VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE dnd_until = VALUES(dnd_until), created_at = CURRENT_TIMESTAMP`,
+		userAddress, until,
+	)
+	return err
+}
+
+// ClearDoNotDisturb turns do not disturb off
+func ClearDoNotDisturb(ctx context.Context, userAddress string) error {
+	_, err := database.DB.ExecContext(ctx, "DELETE FROM do_not_disturb WHERE user_address = ?", userAddress)
+	return err
+}
+
+// GetDoNotDisturb returns a user's do not disturb, or nil if it is off or
+// has ended
+func GetDoNotDisturb(ctx context.Context, userAddress string) (*DoNotDisturb, error) {
+	dnd := &DoNotDisturb{}
+	err := database.DB.QueryRowContext(ctx,
+		`SELECT user_address, dnd_until, created_at FROM do_not_disturb
+		WHERE user_address = ? AND (dnd_until IS NULL OR dnd_until > ?)`,
+		userAddress, clock.Now(),
+	).Scan(&dnd.UserAddress, &dnd.Until, &dnd.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return dnd, nil
+}
+
+// GetMutedUsers returns which of the given users muted a conversation or
+// turned on do not disturb. For direct conversations, conversationID is the
+// peer the users would be notified about.
+func GetMutedUsers(ctx context.Context, conversationType ConversationType, conversationID string, addresses []string) (map[string]bool, error) {
+	muted := map[string]bool{}
+	if len(addresses) == 0 {
+		return muted, nil
+	}
+
+	now := clock.Now()
+	in := "(?" + strings.Repeat(", ?", len(addresses)-1) + ")"
+	args := []interface{}{conversationType, conversationID, now}
+	for _, address := range addresses {
+		args = append(args, address)
+	}
+	args = append(args, now)
+	for _, address := range addresses {
+		args = append(args, address)
+	}
+
+	rows, err := database.DB.QueryContext(ctx,
+		`SELECT user_address FROM mutes
+		WHERE conversation_type = ? AND conversation_id = ? AND (muted_until IS NULL OR muted_until > ?) AND user_address IN `+in+`
+		UNION
+		SELECT user_address FROM do_not_disturb
+		WHERE (dnd_until IS NULL OR dnd_until > ?) AND user_address IN `+in,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var address string
+		if err := rows.Scan(&address); err != nil {
+			return nil, err
+		}
+		muted[address] = true
+	}
+	return muted, rows.Err()
+}
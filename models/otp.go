@@ -1,13 +1,16 @@
 package models
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
-	"math/rand"
+	"strings"
 	"time"
 
+	"github.com/piko/piko/clock"
 	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
 )
 
 var (
@@ -19,6 +22,11 @@ var (
 	ErrOTPInvalid = errors.New("otp invalid")
 	// ErrOTPMaxAttempts is returned when maximum attempts are reached
 	ErrOTPMaxAttempts = errors.New("maximum verification attempts reached")
+	// ErrOTPResendTooSoon is returned when an OTP is resent before the
+	// cooldown since the last one has passed
+	ErrOTPResendTooSoon = errors.New("otp resent too soon")
+	// ErrOTPResendLimit is returned when an OTP has been resent too often
+	ErrOTPResendLimit = errors.New("otp resend limit reached")
 )
 
 // Maximum allowed failed attempts before OTP is invalidated
@@ -26,35 +34,44 @@ const MaxOTPFailedAttempts = 3
 
 // OTP represents a one-time password for phone verification
 type OTP struct {
-	ID             int       `json:"id"`
-	Phone          string    `json:"phone"`
-	Code           string    `json:"code"`
-	CreatedAt      time.Time `json:"created_at"`
-	ExpiresAt      time.Time `json:"expires_at"`
-	Verified       bool      `json:"verified"`
-	FailedAttempts int       `json:"failed_attempts"`
+	ID             int        `json:"id"`
+	Phone          string     `json:"phone"`
+	Code           string     `json:"code"`
+	Email          string     `json:"email,omitempty"`
+	CreatedAt      types.Time `json:"created_at"`
+	ExpiresAt      types.Time `json:"expires_at"`
+	Verified       bool       `json:"verified"`
+	FailedAttempts int        `json:"failed_attempts"`
+	// SentAt is when the current code was sent, and ResendCount how many
+	// times it replaced an earlier one
+	SentAt      types.Time `json:"sent_at"`
+	ResendCount int        `json:"resend_count"`
 }
 
-// GenerateOTP generates a new OTP for a phone number
-func GenerateOTP(phone string, expiryMinutes int) (*OTP, error) {
+// CreateOTP stores a given OTP code for a phone number, replacing any
+// earlier one. email is the address it is sent to, if it goes by email.
+func CreateOTP(ctx context.Context, phone, email string, code string, expiryMinutes int) (*OTP, error) {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
 	// Delete any existing OTPs for this phone number
-	_, err := database.DB.Exec("DELETE FROM otp WHERE phone = ?", phone)
+	_, err = tx.ExecContext(ctx, "DELETE FROM otp WHERE phone = ?", phone)
 	if err != nil {
 		fmt.Printf("Error deleting existing OTPs: %v\n", err)
 		return nil, err
 	}
 
-	// Generate a random 6-digit code
-	code := generateRandomCode(6)
-	fmt.Printf("Generated OTP code for %s: %s\n", phone, code)
-
 	// Calculate expiry time
-	expiresAt := time.Now().Add(time.Duration(expiryMinutes) * time.Minute)
+	now := clock.Now()
+	expiresAt := now.Add(time.Duration(expiryMinutes) * time.Minute)
 
 	// Insert the OTP into the database
-	result, err := database.DB.Exec(
-		"INSERT INTO otp (phone, code, expires_at, failed_attempts) VALUES (?, ?, ?, 0)",
-		phone, code, expiresAt,
+	result, err := tx.ExecContext(ctx,
+		"INSERT INTO otp (phone, code, email, sent_at, expires_at, failed_attempts) VALUES (?, ?, ?, ?, ?, 0)",
+		phone, code, email, now, expiresAt,
 	)
 	if err != nil {
 		fmt.Printf("Error inserting OTP into database: %v\n", err)
@@ -67,14 +84,19 @@ func GenerateOTP(phone string, expiryMinutes int) (*OTP, error) {
 		fmt.Printf("Error getting last insert ID: %v\n", err)
 		return nil, err
 	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
 
 	// Create and return the OTP
 	otp := &OTP{
 		ID:             int(id),
 		Phone:          phone,
 		Code:           code,
-		CreatedAt:      time.Now(),
-		ExpiresAt:      expiresAt,
+		Email:          email,
+		CreatedAt:      types.NewTime(now),
+		SentAt:         types.NewTime(now),
+		ExpiresAt:      types.NewTime(expiresAt),
 		Verified:       false,
 		FailedAttempts: 0,
 	}
@@ -86,26 +108,36 @@ func GenerateOTP(phone string, expiryMinutes int) (*OTP, error) {
 }
 
 // SaveOTP saves an OTP to the database
-func SaveOTP(otp *OTP) error {
+func SaveOTP(ctx context.Context, otp *OTP) error {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	// First, invalidate any existing OTPs for this phone number
-	_, err := database.DB.Exec("UPDATE otp SET verified = TRUE WHERE phone = ? AND verified = FALSE", otp.Phone)
+	_, err = tx.ExecContext(ctx, "UPDATE otp SET verified = TRUE WHERE phone = ? AND verified = FALSE", otp.Phone)
 	if err != nil {
 		return err
 	}
 
 	// Insert new OTP
-	_, err = database.DB.Exec(
+	_, err = tx.ExecContext(ctx,
 		"INSERT INTO otp (phone, code, expires_at, failed_attempts) VALUES (?, ?, ?, 0)",
 		otp.Phone, otp.Code, otp.ExpiresAt,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // VerifyOTP checks if an OTP is valid and marks it as verified if it is
-func VerifyOTP(phone, code string) (bool, error) {
+func VerifyOTP(ctx context.Context, phone, code string) (bool, error) {
 	// Get the OTP from the database
 	var otp OTP
-	err := database.DB.QueryRow(
+	err := database.DB.QueryRowContext(ctx,
 		"SELECT id, phone, code, created_at, expires_at, verified, failed_attempts FROM otp WHERE phone = ? ORDER BY id DESC LIMIT 1",
 		phone,
 	).Scan(&otp.ID, &otp.Phone, &otp.Code, &otp.CreatedAt, &otp.ExpiresAt, &otp.Verified, &otp.FailedAttempts)
@@ -124,7 +156,7 @@ func VerifyOTP(phone, code string) (bool, error) {
 	}
 
 	// Check if the OTP has expired
-	if time.Now().After(otp.ExpiresAt) {
+	if clock.Now().After(otp.ExpiresAt.Time) {
 		return false, ErrOTPExpired
 	}
 
@@ -133,10 +165,11 @@ func VerifyOTP(phone, code string) (bool, error) {
 		return false, ErrOTPMaxAttempts
 	}
 
-	// Check if the code matches
-	if otp.Code != code {
+	// Check if the code matches. Letters in alphanumeric codes may be
+	// typed in either case.
+	if !strings.EqualFold(otp.Code, strings.TrimSpace(code)) {
 		// Increment failed attempts
-		_, err = database.DB.Exec(
+		_, err = database.DB.ExecContext(ctx,
 			"UPDATE otp SET failed_attempts = failed_attempts + 1 WHERE id = ?",
 			otp.ID,
 		)
@@ -147,7 +180,7 @@ func VerifyOTP(phone, code string) (bool, error) {
 		// Check if this attempt exceeds the max attempts
 		if otp.FailedAttempts+1 >= MaxOTPFailedAttempts {
 			// Invalidate the OTP
-			_, err = database.DB.Exec("UPDATE otp SET verified = TRUE WHERE id = ?", otp.ID)
+			_, err = database.DB.ExecContext(ctx, "UPDATE otp SET verified = TRUE WHERE id = ?", otp.ID)
 			if err != nil {
 				return false, err
 			}
@@ -158,7 +191,7 @@ func VerifyOTP(phone, code string) (bool, error) {
 	}
 
 	// Mark the OTP as verified
-	_, err = database.DB.Exec("UPDATE otp SET verified = TRUE WHERE id = ?", otp.ID)
+	_, err = database.DB.ExecContext(ctx, "UPDATE otp SET verified = TRUE WHERE id = ?", otp.ID)
 	if err != nil {
 		return false, err
 	}
@@ -167,23 +200,78 @@ func VerifyOTP(phone, code string) (bool, error) {
 	return true, nil
 }
 
-// DeleteOTP deletes an OTP for a phone number
-func DeleteOTP(phone string) error {
-	_, err := database.DB.Exec("DELETE FROM otp WHERE phone = ?", phone)
-	return err
-}
+// ResendOTP replaces the code of a phone number's latest OTP with a new one
+// that expires expiryMinutes from now, keeping where it is sent. Codes used
+// up by failed attempts can be replaced, verified ones can't. When the last
+// code was sent less than cooldown ago, ErrOTPResendTooSoon is returned
+// with the time left to wait.
+func ResendOTP(ctx context.Context, phone, code string, expiryMinutes int, cooldown time.Duration, maxResends int) (*OTP, time.Duration, error) {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
 
-// generateRandomCode generates a random numeric code of the specified length
-func generateRandomCode(length int) string {
-	const digits = "0123456789"
+	otp := &OTP{}
+	err = tx.QueryRowContext(ctx,
+		"SELECT id, phone, email, created_at, sent_at, verified, failed_attempts, resend_count FROM otp WHERE phone = ? ORDER BY id DESC LIMIT 1 FOR UPDATE",
+		phone,
+	).Scan(&otp.ID, &otp.Phone, &otp.Email, &otp.CreatedAt, &otp.SentAt, &otp.Verified, &otp.FailedAttempts, &otp.ResendCount)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, 0, ErrOTPNotFound
+		}
+		return nil, 0, err
+	}
+	if otp.Verified && otp.FailedAttempts < MaxOTPFailedAttempts {
+		return nil, 0, ErrOTPNotFound
+	}
 
-	// Create a new random source with current time
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	now := clock.Now()
+	if wait := otp.SentAt.Add(cooldown).Sub(now); wait > 0 {
+		return nil, wait, ErrOTPResendTooSoon
+	}
+	if otp.ResendCount >= maxResends {
+		return nil, 0, ErrOTPResendLimit
+	}
 
-	code := make([]byte, length)
-	for i := 0; i < length; i++ {
-		code[i] = digits[r.Intn(len(digits))]
+	expiresAt := now.Add(time.Duration(expiryMinutes) * time.Minute)
+	_, err = tx.ExecContext(ctx,
+		"UPDATE otp SET code = ?, sent_at = ?, expires_at = ?, verified = FALSE, failed_attempts = 0, resend_count = resend_count + 1 WHERE id = ?",
+		code, now, expiresAt, otp.ID,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, 0, err
 	}
 
-	return string(code)
+	otp.Code = code
+	otp.SentAt = types.NewTime(now)
+	otp.ExpiresAt = types.NewTime(expiresAt)
+	otp.Verified = false
+	otp.FailedAttempts = 0
+	otp.ResendCount++
+	return otp, 0, nil
+}
+
+// GetOTPEmail returns the email address the latest OTP of a phone number
+// was sent to, or "" if it went by SMS
+func GetOTPEmail(ctx context.Context, phone string) (string, error) {
+	var email string
+	err := database.DB.QueryRowContext(ctx, "SELECT email FROM otp WHERE phone = ? ORDER BY id DESC LIMIT 1", phone).Scan(&email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", ErrOTPNotFound
+		}
+		return "", err
+	}
+	return email, nil
+}
+
+// DeleteOTP deletes an OTP for a phone number
+func DeleteOTP(ctx context.Context, phone string) error {
+	_, err := database.DB.ExecContext(ctx, "DELETE FROM otp WHERE phone = ?", phone)
+	return err
 }
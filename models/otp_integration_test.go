@@ -0,0 +1,40 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/database/dbtest"
+)
+
+func TestIntegrationOTPExpiry(t *testing.T) {
+	dbtest.Open(t)
+	ctx := context.Background()
+
+	manual := clock.NewManual(time.Now().UTC().Truncate(time.Second))
+	clock.Set(manual)
+	t.Cleanup(func() { clock.Set(clock.System) })
+
+	// A code is accepted until its last moment
+	phone := fmt.Sprintf("+1555%d", time.Now().UnixNano()%1e7)
+	if _, err := CreateOTP(ctx, phone, "", "123456", 5); err != nil {
+		t.Fatalf("CreateOTP: %v", err)
+	}
+	manual.Advance(5 * time.Minute)
+	if valid, err := VerifyOTP(ctx, phone, "123456"); err != nil || !valid {
+		t.Errorf("VerifyOTP at expiry = %v, %v, want true", valid, err)
+	}
+
+	// and refused a second later
+	if _, err := CreateOTP(ctx, phone, "", "654321", 5); err != nil {
+		t.Fatalf("CreateOTP: %v", err)
+	}
+	manual.Advance(5*time.Minute + time.Second)
+	if valid, err := VerifyOTP(ctx, phone, "654321"); !errors.Is(err, ErrOTPExpired) || valid {
+		t.Errorf("VerifyOTP after expiry = %v, %v, want %v", valid, err, ErrOTPExpired)
+	}
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/database"
+)
+
+// usePartitions records monthly partitions for the message tables, as if
+// partitioning were enabled, until the test ends
+func usePartitions(t *testing.T, names ...string) {
+	t.Helper()
+
+	for _, table := range database.PartitionedTables {
+		database.SetPartitions(table, names)
+	}
+	t.Cleanup(func() {
+		for _, table := range database.PartitionedTables {
+			database.SetPartitions(table, nil)
+		}
+	})
+}
+
+func TestMessagePageQuerySelectsPartitions(t *testing.T) {
+	usePartitions(t, "p202404", "p202405", "p202406", "p202407")
+	clock.Set(clock.NewManual(time.Date(2024, 6, 20, 12, 0, 0, 0, time.UTC)))
+	t.Cleanup(func() { clock.Set(clock.System) })
+	cursor := time.Date(2024, 5, 10, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		cursor *time.Time
+		after  bool
+		want   string
+	}{
+		{"newest", nil, false, "FROM messages WHERE recipient_address = ? ORDER BY timestamp DESC, id DESC LIMIT ?"},
+		{"before", &cursor, false, "FROM messages PARTITION (p202404, p202405) WHERE recipient_address = ? AND (timestamp < ? OR (timestamp = ? AND id < ?)) ORDER BY timestamp DESC, id DESC LIMIT ?"},
+		{"after", &cursor, true, "FROM messages PARTITION (p202405, p202406) WHERE recipient_address = ? AND (timestamp > ? OR (timestamp = ? AND id > ?)) ORDER BY timestamp, id LIMIT ?"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := messagePageQuery("recipient_address", tt.cursor, tt.after)
+			if !strings.HasSuffix(query, tt.want) {
+				t.Errorf("got query %q, want it to end with %q", query, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessagePageQueryReadsOverflowPastLastPartition(t *testing.T) {
+	usePartitions(t, "p202405", "p202406")
+	clock.Set(clock.NewManual(time.Date(2024, 8, 2, 0, 0, 0, 0, time.UTC)))
+	t.Cleanup(func() { clock.Set(clock.System) })
+	cursor := time.Date(2024, 6, 30, 23, 0, 0, 0, time.UTC)
+
+	query := messagePageQuery("sender_address", &cursor, true)
+	if want := "FROM messages PARTITION (p202406, pmax) WHERE"; !strings.Contains(query, want) {
+		t.Errorf("got query %q, want it to contain %q", query, want)
+	}
+}
+
+func TestDailyMessageCountsQuerySelectsPartitions(t *testing.T) {
+	first := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2024, 6, 20, 0, 0, 0, 0, time.UTC)
+
+	query := dailyMessageCountsQuery(first, until)
+	if strings.Contains(query, "PARTITION") {
+		t.Errorf("got query %q on unpartitioned tables, want no PARTITION clause", query)
+	}
+
+	usePartitions(t, "p202405", "p202406", "p202407")
+	query = dailyMessageCountsQuery(first, until)
+	for _, want := range []string{
+		"FROM messages PARTITION (p202406) WHERE",
+		"FROM group_messages PARTITION (p202406) WHERE",
+		"FROM channel_messages PARTITION (p202406) WHERE",
+		"DATE_FORMAT(timestamp, '%Y-%m-%d')",
+	} {
+		if !strings.Contains(query, want) {
+			t.Errorf("got query %q, want it to contain %q", query, want)
+		}
+	}
+}
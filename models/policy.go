@@ -0,0 +1,218 @@
+package models
+
+import (
+	"context"
+	"errors"
+
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
+)
+
+var (
+	// ErrPolicyVersionExists is returned when publishing a version twice
+	ErrPolicyVersionExists = errors.New("policy version already exists")
+	// ErrPolicyNotCurrent is returned when accepting a policy that has been superseded
+	ErrPolicyNotCurrent = errors.New("policy is not current")
+)
+
+// PolicyKind is the kind of legal document a policy is
+type PolicyKind string
+
+const (
+	// PolicyKindTerms is the terms of service
+	PolicyKindTerms PolicyKind = "terms"
+	// PolicyKindPrivacy is the privacy policy
+	PolicyKindPrivacy PolicyKind = "privacy"
+)
+
+// Policy is a published version of the terms of service or privacy policy.
+// The latest version of each kind is the current one, which every user must
+// have accepted.
+type Policy struct {
+	ID          int        `json:"id"`
+	Kind        PolicyKind `json:"kind"`
+	Version     string     `json:"version"`
+	URL         string     `json:"url"`
+	PublishedAt types.Time `json:"published_at"`
+}
+
+// PolicyAcceptance records when a user accepted a policy version
+type PolicyAcceptance struct {
+	Policy
+	AcceptedAt types.Time `json:"accepted_at"`
+}
+
+// IsValidPolicyKind checks if a policy kind is supported
+func IsValidPolicyKind(kind PolicyKind) bool {
+	return kind == PolicyKindTerms || kind == PolicyKindPrivacy
+}
+
+// CreatePolicy publishes a new policy version, making it the current one
+func CreatePolicy(ctx context.Context, policy *Policy) error {
+	// Check if the version was already published
+	var count int
+	err := database.DB.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM policies WHERE kind = ? AND version = ?",
+		policy.Kind, policy.Version,
+	).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrPolicyVersionExists
+	}
+
+	result, err := database.DB.ExecContext(ctx,
+		"INSERT INTO policies (kind, version, url) VALUES (?, ?, ?)",
+		policy.Kind, policy.Version, policy.URL,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	policy.ID = int(id)
+	policy.PublishedAt = types.NewTime(clock.Now())
+	return nil
+}
+
+// GetCurrentPolicies retrieves the latest version of each policy kind
+func GetCurrentPolicies(ctx context.Context) ([]*Policy, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		`SELECT id, kind, version, url, published_at FROM policies
+		WHERE id IN (SELECT MAX(id) FROM policies GROUP BY kind)
+		ORDER BY kind`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	policies := []*Policy{}
+	for rows.Next() {
+		policy := &Policy{}
+		if err := rows.Scan(&policy.ID, &policy.Kind, &policy.Version, &policy.URL, &policy.PublishedAt); err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+	return policies, rows.Err()
+}
+
+// GetPendingPolicies retrieves the current policies a user hasn't accepted
+func GetPendingPolicies(ctx context.Context, userID int) ([]*Policy, error) {
+	current, err := GetCurrentPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	accepted := make(map[int]bool)
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT policy_id FROM policy_acceptances WHERE user_id = ?",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var policyID int
+		if err := rows.Scan(&policyID); err != nil {
+			return nil, err
+		}
+		accepted[policyID] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	pending := []*Policy{}
+	for _, policy := range current {
+		if !accepted[policy.ID] {
+			pending = append(pending, policy)
+		}
+	}
+	return pending, nil
+}
+
+// HasPendingPolicies checks if a user has a current policy left to accept
+func HasPendingPolicies(ctx context.Context, userID int) (bool, error) {
+	var count int
+	err := database.DB.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM policies p
+		LEFT JOIN policy_acceptances a ON a.policy_id = p.id AND a.user_id = ?
+		WHERE a.policy_id IS NULL AND p.id IN (SELECT MAX(id) FROM policies GROUP BY kind)`,
+		userID,
+	).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// AcceptPolicies records a user's acceptance of current policies. Accepting
+// a policy twice keeps the original timestamp.
+func AcceptPolicies(ctx context.Context, userID int, policyIDs []int) error {
+	current, err := GetCurrentPolicies(ctx)
+	if err != nil {
+		return err
+	}
+	isCurrent := make(map[int]bool, len(current))
+	for _, policy := range current {
+		isCurrent[policy.ID] = true
+	}
+	for _, policyID := range policyIDs {
+		if !isCurrent[policyID] {
+			return ErrPolicyNotCurrent
+		}
+	}
+
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, policyID := range policyIDs {
+		_, err := tx.ExecContext(ctx,
+			"INSERT IGNORE INTO policy_acceptances (user_id, policy_id) VALUES (?, ?)",
+			userID, policyID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetPolicyAcceptances retrieves every policy version a user has accepted,
+// newest first
+func GetPolicyAcceptances(ctx context.Context, userID int) ([]*PolicyAcceptance, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		`SELECT p.id, p.kind, p.version, p.url, p.published_at, a.accepted_at
+		FROM policy_acceptances a JOIN policies p ON p.id = a.policy_id
+		WHERE a.user_id = ? ORDER BY a.accepted_at DESC, p.id DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	acceptances := []*PolicyAcceptance{}
+	for rows.Next() {
+		acceptance := &PolicyAcceptance{}
+		if err := rows.Scan(
+			&acceptance.ID, &acceptance.Kind, &acceptance.Version, &acceptance.URL,
+			&acceptance.PublishedAt, &acceptance.AcceptedAt,
+		); err != nil {
+			return nil, err
+		}
+		acceptances = append(acceptances, acceptance)
+	}
+	return acceptances, rows.Err()
+}
@@ -0,0 +1,343 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
+)
+
+var (
+	// ErrPollNotFound is returned when a poll is not found
+	ErrPollNotFound = errors.New("poll not found")
+	// ErrPollExists is returned when a message already has a poll
+	ErrPollExists = errors.New("message already has a poll")
+	// ErrPollClosed is returned when voting on a closed poll
+	ErrPollClosed = errors.New("poll is closed")
+	// ErrInvalidPollOption is returned when a vote names an option the poll doesn't have
+	ErrInvalidPollOption = errors.New("invalid poll option")
+	// ErrAlreadyVoted is returned when a member votes twice on a poll
+	ErrAlreadyVoted = errors.New("already voted")
+	// ErrPollVoteNotFound is returned when a member hasn't voted on a poll
+	ErrPollVoteNotFound = errors.New("poll vote not found")
+)
+
+// Poll is a question attached to a group or channel message that members
+// vote on. Votes of anonymous polls are still stored per member, so each
+// member votes once, but only their counts are shown.
+type Poll struct {
+	ID               string           `json:"id"`
+	ConversationType ConversationType `json:"conversation_type"`
+	ConversationID   string           `json:"conversation_id"`
+	MessageID        string           `json:"message_id"`
+	CreatorAddress   string           `json:"creator_address"`
+	Question         string           `json:"question"`
+	Anonymous        bool             `json:"anonymous"`
+	Options          []*PollOption    `json:"options"`
+	TotalVotes       int              `json:"total_votes"`
+	ClosedAt         *types.Time      `json:"closed_at,omitempty"`
+	CreatedAt        types.Time       `json:"created_at"`
+}
+
+// PollOption is one of the answers of a poll with its votes
+type PollOption struct {
+	Position int    `json:"position"`
+	Text     string `json:"text"`
+	Votes    int    `json:"votes"`
+	// Voters are the addresses of the members who chose the option, in
+	// the order they voted. Anonymous polls leave them out.
+	Voters []string `json:"voters,omitempty"`
+}
+
+// CreatePoll creates a poll with the given options, numbered from 0 in order
+func CreatePoll(ctx context.Context, poll *Poll, options []string) error {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var count int
+	err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM polls WHERE message_id = ?", poll.MessageID).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrPollExists
+	}
+
+	now := clock.Now()
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO polls (id, conversation_type, conversation_id, message_id, creator_address, question, anonymous, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		poll.ID, poll.ConversationType, poll.ConversationID, poll.MessageID, poll.CreatorAddress, poll.Question, poll.Anonymous, now,
+	)
+	if err != nil {
+		return err
+	}
+
+	poll.Options = make([]*PollOption, len(options))
+	for i, text := range options {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO poll_options (poll_id, position, text) VALUES (?, ?, ?)", poll.ID, i, text); err != nil {
+			return err
+		}
+		poll.Options[i] = &PollOption{Position: i, Text: text}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	poll.CreatedAt = types.NewTime(now)
+	return nil
+}
+
+// pollColumns are the columns scanned by scanPoll
+const pollColumns = "id, conversation_type, conversation_id, message_id, creator_address, question, anonymous, closed_at, created_at"
+
+// scanPoll scans a row of pollColumns
+func scanPoll(row interface{ Scan(...any) error }) (*Poll, error) {
+	poll := &Poll{}
+	err := row.Scan(&poll.ID, &poll.ConversationType, &poll.ConversationID, &poll.MessageID, &poll.CreatorAddress, &poll.Question, &poll.Anonymous, &poll.ClosedAt, &poll.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return poll, nil
+}
+
+// GetPoll retrieves a poll with its results
+func GetPoll(ctx context.Context, id string) (*Poll, error) {
+	poll, err := scanPoll(database.DB.QueryRowContext(ctx,
+		"SELECT "+pollColumns+" FROM polls WHERE id = ?",
+		id,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrPollNotFound
+		}
+		return nil, err
+	}
+	if err := loadPollResults(ctx, poll); err != nil {
+		return nil, err
+	}
+	return poll, nil
+}
+
+// GetConversationPolls lists the polls of a group or channel with their
+// results, newest first
+func GetConversationPolls(ctx context.Context, conversationType ConversationType, conversationID string, limit int, offset int) ([]*Poll, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT "+pollColumns+" FROM polls WHERE conversation_type = ? AND conversation_id = ? ORDER BY created_at DESC, id LIMIT ? OFFSET ?",
+		conversationType, conversationID, limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	polls := []*Poll{}
+	for rows.Next() {
+		poll, err := scanPoll(rows)
+		if err != nil {
+			return nil, err
+		}
+		polls = append(polls, poll)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, poll := range polls {
+		if err := loadPollResults(ctx, poll); err != nil {
+			return nil, err
+		}
+	}
+	return polls, nil
+}
+
+// loadPollResults sets a poll's options, their votes and, unless the poll
+// is anonymous, who cast them
+func loadPollResults(ctx context.Context, poll *Poll) error {
+	rows, err := database.DB.QueryContext(ctx,
+		`SELECT o.position, o.text, COUNT(v.voter_address) FROM poll_options o
+		LEFT JOIN poll_votes v ON v.poll_id = o.poll_id AND v.option_position = o.position
+		WHERE o.poll_id = ? GROUP BY o.position, o.text ORDER BY o.position`,
+		poll.ID,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	poll.Options = []*PollOption{}
+	poll.TotalVotes = 0
+	byPosition := map[int]*PollOption{}
+	for rows.Next() {
+		option := &PollOption{}
+		if err := rows.Scan(&option.Position, &option.Text, &option.Votes); err != nil {
+			return err
+		}
+		poll.Options = append(poll.Options, option)
+		poll.TotalVotes += option.Votes
+		byPosition[option.Position] = option
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if poll.Anonymous || poll.TotalVotes == 0 {
+		return nil
+	}
+
+	voters, err := database.DB.QueryContext(ctx,
+		"SELECT voter_address, option_position FROM poll_votes WHERE poll_id = ? ORDER BY created_at, voter_address",
+		poll.ID,
+	)
+	if err != nil {
+		return err
+	}
+	defer voters.Close()
+
+	for voters.Next() {
+		var address string
+		var position int
+		if err := voters.Scan(&address, &position); err != nil {
+			return err
+		}
+		if option, ok := byPosition[position]; ok {
+			option.Voters = append(option.Voters, address)
+		}
+	}
+	return voters.Err()
+}
+
+// lockOpenPoll locks a poll for the rest of a transaction, returning
+// ErrPollClosed if it has been closed
+func lockOpenPoll(ctx context.Context, tx *sql.Tx, pollID string) error {
+	var closedAt *types.Time
+	err := tx.QueryRowContext(ctx, "SELECT closed_at FROM polls WHERE id = ? FOR UPDATE", pollID).Scan(&closedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrPollNotFound
+		}
+		return err
+	}
+	if closedAt != nil {
+		return ErrPollClosed
+	}
+	return nil
+}
+
+// CastPollVote records a member's vote on an open poll. Members vote once;
+// to change their answer they retract their vote first.
+func CastPollVote(ctx context.Context, pollID, voterAddress string, position int) error {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the poll so a vote can't race its closing or the same member's
+	// other votes
+	if err := lockOpenPoll(ctx, tx, pollID); err != nil {
+		return err
+	}
+
+	var count int
+	err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM poll_options WHERE poll_id = ? AND position = ?", pollID, position).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrInvalidPollOption
+	}
+
+	err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM poll_votes WHERE poll_id = ? AND voter_address = ?", pollID, voterAddress).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrAlreadyVoted
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO poll_votes (poll_id, voter_address, option_position, created_at) VALUES (?, ?, ?, ?)",
+		pollID, voterAddress, position, clock.Now(),
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RetractPollVote removes a member's vote from an open poll
+func RetractPollVote(ctx context.Context, pollID, voterAddress string) error {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockOpenPoll(ctx, tx, pollID); err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM poll_votes WHERE poll_id = ? AND voter_address = ?", pollID, voterAddress)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrPollVoteNotFound
+	}
+
+	return tx.Commit()
+}
+
+// GetPollVote returns the position of the option a member voted for
+func GetPollVote(ctx context.Context, pollID, voterAddress string) (int, error) {
+	var position int
+	err := database.DB.QueryRowContext(ctx,
+		"SELECT option_position FROM poll_votes WHERE poll_id = ? AND voter_address = ?",
+		pollID, voterAddress,
+	).Scan(&position)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, ErrPollVoteNotFound
+		}
+		return 0, err
+	}
+	return position, nil
+}
+
+// ClosePoll stops a poll from taking votes. Its results stay visible.
+func ClosePoll(ctx context.Context, id string) error {
+	result, err := database.DB.ExecContext(ctx,
+		"UPDATE polls SET closed_at = ? WHERE id = ? AND closed_at IS NULL",
+		clock.Now(), id,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected > 0 {
+		return nil
+	}
+
+	var count int
+	if err := database.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM polls WHERE id = ?", id).Scan(&count); err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrPollNotFound
+	}
+	return ErrPollClosed
+}
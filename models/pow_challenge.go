@@ -0,0 +1,83 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
+)
+
+// PowPurpose is what solving a proof-of-work challenge lets a client do
+type PowPurpose string
+
+const (
+	// PowPurposeSecretChat challenges are solved to create a secret chat
+	PowPurposeSecretChat PowPurpose = "secret_chat"
+	// PowPurposeRegistration challenges are solved to register a phone number
+	PowPurposeRegistration PowPurpose = "registration"
+)
+
+// PowChallenge is a single-use nonce an anonymous client must solve a
+// proof-of-work for before creating a secret chat or registering
+type PowChallenge struct {
+	Nonce      string     `json:"nonce"`
+	Purpose    PowPurpose `json:"purpose"`
+	Difficulty int        `json:"difficulty"`
+	CreatedAt  types.Time `json:"created_at"`
+	ExpiresAt  types.Time `json:"expires_at"`
+}
+
+// CreatePowChallenge stores a new proof-of-work challenge
+func CreatePowChallenge(ctx context.Context, challenge *PowChallenge) error {
+	// Clear out expired challenges so the table doesn't grow unbounded
+	if _, err := database.DB.ExecContext(ctx, "DELETE FROM pow_challenges WHERE expires_at < ?", clock.Now()); err != nil {
+		return err
+	}
+
+	_, err := database.DB.ExecContext(ctx,
+		"INSERT INTO pow_challenges (nonce, purpose, difficulty, expires_at) VALUES (?, ?, ?, ?)",
+		challenge.Nonce, challenge.Purpose, challenge.Difficulty, challenge.ExpiresAt,
+	)
+	return err
+}
+
+// GetPowChallenge retrieves a challenge for a purpose that is unused and
+// not expired
+func GetPowChallenge(ctx context.Context, nonce string, purpose PowPurpose) (*PowChallenge, error) {
+	challenge := &PowChallenge{}
+	err := database.DB.QueryRowContext(ctx,
+		`SELECT nonce, purpose, difficulty, created_at, expires_at FROM pow_challenges
+		WHERE nonce = ? AND purpose = ? AND used = FALSE AND expires_at > ?`,
+		nonce, purpose, clock.Now(),
+	).Scan(&challenge.Nonce, &challenge.Purpose, &challenge.Difficulty, &challenge.CreatedAt, &challenge.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrChallengeInvalid
+		}
+		return nil, err
+	}
+	return challenge, nil
+}
+
+// ConsumePowChallenge marks a challenge as used, so each solution can only
+// be redeemed once
+func ConsumePowChallenge(ctx context.Context, nonce string) error {
+	result, err := database.DB.ExecContext(ctx,
+		"UPDATE pow_challenges SET used = TRUE WHERE nonce = ? AND used = FALSE AND expires_at > ?",
+		nonce, clock.Now(),
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrChallengeInvalid
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/piko/piko/database"
+)
+
+// ReadCursor is the last message a member has read in a group or channel.
+// Messages are ordered by (timestamp, id), so the cursor keeps both.
+type ReadCursor struct {
+	MessageID string
+	Timestamp time.Time
+}
+
+// readScope names the tables and column of a group or channel conversation
+type readScope struct {
+	members  string
+	messages string
+	reads    string
+	column   string
+}
+
+var (
+	groupReadScope   = readScope{members: "group_members", messages: "group_messages", reads: "group_message_reads", column: "group_id"}
+	channelReadScope = readScope{members: "channel_members", messages: "channel_messages", reads: "channel_message_reads", column: "channel_id"}
+)
+
+// MarkGroupRead moves a member's read cursor in a group forward to a
+// message. It returns false if the cursor was already at or past it.
+func MarkGroupRead(ctx context.Context, groupID, userAddress string, message *GroupMessage) (bool, error) {
+	return groupReadScope.markRead(ctx, groupID, userAddress, ReadCursor{MessageID: message.ID, Timestamp: message.Timestamp.Time})
+}
+
+// MarkChannelRead moves a member's read cursor in a channel forward to a
+// message. It returns false if the cursor was already at or past it.
+func MarkChannelRead(ctx context.Context, channelID, userAddress string, message *ChannelMessage) (bool, error) {
+	return channelReadScope.markRead(ctx, channelID, userAddress, ReadCursor{MessageID: message.ID, Timestamp: message.Timestamp.Time})
+}
+
+// GetGroupUnreadCounts returns the number of unread messages from others in
+// each of a user's groups
+func GetGroupUnreadCounts(ctx context.Context, userAddress string) (map[string]int, error) {
+	return groupReadScope.unreadCounts(ctx, userAddress)
+}
+
+// GetChannelUnreadCounts returns the number of unread messages from others
+// in each of a user's channels
+func GetChannelUnreadCounts(ctx context.Context, userAddress string) (map[string]int, error) {
+	return channelReadScope.unreadCounts(ctx, userAddress)
+}
+
+func (s readScope) markRead(ctx context.Context, scopeID, userAddress string, cursor ReadCursor) (bool, error) {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var current ReadCursor
+	err = tx.QueryRowContext(ctx,
+		fmt.Sprintf("SELECT last_read_message_id, last_read_timestamp FROM %s WHERE %s = ? AND user_address = ? FOR UPDATE", s.reads, s.column),
+		scopeID, userAddress,
+	).Scan(&current.MessageID, &current.Timestamp)
+	switch {
+	case err == sql.ErrNoRows:
+		_, err = tx.ExecContext(ctx,
+			fmt.Sprintf("INSERT INTO %s (%s, user_address, last_read_message_id, last_read_timestamp) VALUES (?, ?, ?, ?)", s.reads, s.column),
+			scopeID, userAddress, cursor.MessageID, cursor.Timestamp,
+		)
+	case err != nil:
+		return false, err
+	case !cursor.after(current):
+		return false, nil
+	default:
+		_, err = tx.ExecContext(ctx,
+			fmt.Sprintf("UPDATE %s SET last_read_message_id = ?, last_read_timestamp = ? WHERE %s = ? AND user_address = ?", s.reads, s.column),
+			cursor.MessageID, cursor.Timestamp, scopeID, userAddress,
+		)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// after reports whether c comes after other in message order
+func (c ReadCursor) after(other ReadCursor) bool {
+	if !c.Timestamp.Equal(other.Timestamp) {
+		return c.Timestamp.After(other.Timestamp)
+	}
+	return c.MessageID > other.MessageID
+}
+
+func (s readScope) unreadCounts(ctx context.Context, userAddress string) (map[string]int, error) {
+	rows, err := database.DB.QueryContext(ctx, fmt.Sprintf(
+		`SELECT mb.%[1]s, COUNT(m.id) FROM %[2]s mb
+		LEFT JOIN %[3]s r ON r.%[1]s = mb.%[1]s AND r.user_address = mb.user_address
+		LEFT JOIN %[4]s m ON m.%[1]s = mb.%[1]s AND m.sender_address != mb.user_address
+			AND (r.%[1]s IS NULL OR m.timestamp > r.last_read_timestamp
+				OR (m.timestamp = r.last_read_timestamp AND m.id > r.last_read_message_id))
+		WHERE mb.user_address = ?
+		GROUP BY mb.%[1]s`,
+		s.column, s.members, s.reads, s.messages),
+		userAddress,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var scopeID string
+		var count int
+		if err := rows.Scan(&scopeID, &count); err != nil {
+			return nil, err
+		}
+		counts[scopeID] = count
+	}
+	return counts, rows.Err()
+}
@@ -0,0 +1,163 @@
+package models
+
+import (
+	"context"
+	"errors"
+
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
+)
+
+var (
+	// ErrReportNotFound is returned when a report is not found
+	ErrReportNotFound = errors.New("report not found")
+)
+
+// ReportTarget is the kind of thing a report is about
+type ReportTarget string
+
+const (
+	// ReportTargetUser is a report about a user
+	ReportTargetUser ReportTarget = "user"
+	// ReportTargetMessage is a report about a direct message
+	ReportTargetMessage ReportTarget = "message"
+	// ReportTargetGroup is a report about a group
+	ReportTargetGroup ReportTarget = "group"
+	// ReportTargetChannel is a report about a channel
+	ReportTargetChannel ReportTarget = "channel"
+)
+
+// ReportStatus is where a report is in the moderation queue
+type ReportStatus string
+
+const (
+	// ReportStatusOpen reports are waiting for an admin
+	ReportStatusOpen ReportStatus = "open"
+	// ReportStatusResolved reports were acted on
+	ReportStatusResolved ReportStatus = "resolved"
+	// ReportStatusDismissed reports were reviewed and needed no action
+	ReportStatusDismissed ReportStatus = "dismissed"
+)
+
+// Report is a user's report of abuse, queued for moderation
+type Report struct {
+	ID              int          `json:"id"`
+	ReporterAddress string       `json:"reporter_address"`
+	TargetType      ReportTarget `json:"target_type"`
+	TargetID        string       `json:"target_id"`
+	Reason          string       `json:"reason"`
+	Status          ReportStatus `json:"status"`
+	ResolvedBy      *string      `json:"resolved_by,omitempty"`
+	ResolvedAt      *types.Time  `json:"resolved_at,omitempty"`
+	CreatedAt       types.Time   `json:"created_at"`
+}
+
+// IsValidReportTarget checks if a report target type is supported
+func IsValidReportTarget(target ReportTarget) bool {
+	switch target {
+	case ReportTargetUser, ReportTargetMessage, ReportTargetGroup, ReportTargetChannel:
+		return true
+	}
+	return false
+}
+
+// CreateReport adds a report to the moderation queue
+func CreateReport(ctx context.Context, report *Report) error {
+	result, err := database.DB.ExecContext(ctx,
+		"INSERT INTO reports (reporter_address, target_type, target_id, reason) VALUES (?, ?, ?, ?)",
+		report.ReporterAddress, report.TargetType, report.TargetID, report.Reason,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	report.ID = int(id)
+	report.Status = ReportStatusOpen
+	report.CreatedAt = types.NewTime(clock.Now())
+	return nil
+}
+
+// GetReports retrieves reports with a status, oldest first
+func GetReports(ctx context.Context, status ReportStatus, limit int) ([]*Report, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		`SELECT id, reporter_address, target_type, target_id, reason, status, resolved_by, resolved_at, created_at
+		FROM reports WHERE status = ? ORDER BY id LIMIT ?`,
+		status, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reports := []*Report{}
+	for rows.Next() {
+		report := &Report{}
+		if err := rows.Scan(
+			&report.ID, &report.ReporterAddress, &report.TargetType, &report.TargetID, &report.Reason,
+			&report.Status, &report.ResolvedBy, &report.ResolvedAt, &report.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, rows.Err()
+}
+
+// CloseReport marks an open report as resolved or dismissed by an admin
+func CloseReport(ctx context.Context, id int, status ReportStatus, adminAddress string) error {
+	result, err := database.DB.ExecContext(ctx,
+		"UPDATE reports SET status = ?, resolved_by = ?, resolved_at = NOW() WHERE id = ? AND status = ?",
+		status, adminAddress, id, ReportStatusOpen,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrReportNotFound
+	}
+	return nil
+}
+
+// ServerStats summarizes the server for operators
+type ServerStats struct {
+	Users        int `json:"users"`
+	Messages     int `json:"messages"`
+	Groups       int `json:"groups"`
+	Channels     int `json:"channels"`
+	Blocks       int `json:"blocks"`
+	Transactions int `json:"transactions"`
+	OpenReports  int `json:"open_reports"`
+}
+
+// GetServerStats counts users, conversations, blocks and open reports
+func GetServerStats(ctx context.Context) (*ServerStats, error) {
+	stats := &ServerStats{}
+	counts := []struct {
+		query string
+		dest  *int
+	}{
+		{"SELECT COUNT(*) FROM users", &stats.Users},
+		{"SELECT COUNT(*) FROM messages", &stats.Messages},
+		{"SELECT COUNT(*) FROM chat_groups", &stats.Groups},
+		{"SELECT COUNT(*) FROM channels", &stats.Channels},
+		{"SELECT COUNT(*) FROM blocks", &stats.Blocks},
+		{"SELECT COUNT(*) FROM transactions", &stats.Transactions},
+		{"SELECT COUNT(*) FROM reports WHERE status = 'open'", &stats.OpenReports},
+	}
+	for _, count := range counts {
+		if err := database.DB.QueryRowContext(ctx, count.query).Scan(count.dest); err != nil {
+			return nil, err
+		}
+	}
+	return stats, nil
+}
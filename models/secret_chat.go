@@ -1,13 +1,16 @@
 package models
 
 import (
-	"crypto/rand"
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"time"
 
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/crypto"
 	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
 )
 
 var (
@@ -15,40 +18,44 @@ var (
 	ErrSecretChatNotFound = errors.New("secret chat not found")
 	// ErrSecretChatExpired is returned when a secret chat has expired
 	ErrSecretChatExpired = errors.New("secret chat expired")
+	// ErrSecretChatFull is returned when a secret chat has reached its participant limit
+	ErrSecretChatFull = errors.New("secret chat is full")
 )
 
 // SecretChat represents a temporary anonymous chat room
 type SecretChat struct {
-	ChannelID    string    `json:"channel_id"`
-	CreatedAt    time.Time `json:"created_at"`
-	ExpiresAt    time.Time `json:"expires_at"`
-	MessageCount int       `json:"message_count"`
+	ChannelID    string     `json:"channel_id"`
+	CreatedAt    types.Time `json:"created_at"`
+	ExpiresAt    types.Time `json:"expires_at"`
+	MessageCount int        `json:"message_count"`
+	// MaxParticipants is how many participants may join; 0 means no limit
+	MaxParticipants int `json:"max_participants"`
 }
 
 // SecretChatParticipant represents a participant in a secret chat
 type SecretChatParticipant struct {
-	SessionID    string    `json:"session_id"`
-	ChannelID    string    `json:"channel_id"`
-	DisplayName  string    `json:"display_name"`
-	JoinedAt     time.Time `json:"joined_at"`
-	LastActiveAt time.Time `json:"last_active_at"`
+	SessionID    string     `json:"session_id"`
+	ChannelID    string     `json:"channel_id"`
+	DisplayName  string     `json:"display_name"`
+	JoinedAt     types.Time `json:"joined_at"`
+	LastActiveAt types.Time `json:"last_active_at"`
 }
 
 // SecretChatMessage represents a message in a secret chat
 type SecretChatMessage struct {
-	ID               string    `json:"id"`
-	ChannelID        string    `json:"channel_id"`
-	SessionID        string    `json:"session_id"`
-	DisplayName      string    `json:"display_name"`
-	EncryptedContent []byte    `json:"encrypted_content"`
-	Timestamp        time.Time `json:"timestamp"`
+	ID               string     `json:"id"`
+	ChannelID        string     `json:"channel_id"`
+	SessionID        string     `json:"session_id"`
+	DisplayName      string     `json:"display_name"`
+	EncryptedContent []byte     `json:"encrypted_content"`
+	Timestamp        types.Time `json:"timestamp"`
 }
 
 // GenerateSecretChatID generates a unique ID for a secret chat
 func GenerateSecretChatID() (string, error) {
 	// Generate 6 random bytes
-	randomBytes := make([]byte, 6)
-	if _, err := rand.Read(randomBytes); err != nil {
+	randomBytes, err := crypto.GenerateRandomBytes(6)
+	if err != nil {
 		return "", err
 	}
 
@@ -61,21 +68,22 @@ func GenerateSecretChatID() (string, error) {
 		randomBytes[4]&0xFF), nil
 }
 
-// CreateSecretChat creates a new secret chat
-func CreateSecretChat() (*SecretChat, error) {
+// CreateSecretChat creates a new secret chat that expires after ttl and
+// admits up to maxParticipants participants, or any number if it is 0
+func CreateSecretChat(ctx context.Context, ttl time.Duration, maxParticipants int) (*SecretChat, error) {
 	// Generate channel ID
 	channelID, err := GenerateSecretChatID()
 	if err != nil {
 		return nil, err
 	}
 
-	// Set expiration time to 24 hours from now
-	expiresAt := time.Now().Add(24 * time.Hour)
+	now := clock.Now()
+	expiresAt := now.Add(ttl)
 
 	// Create secret chat in database
-	_, err = database.DB.Exec(
-		"INSERT INTO secret_chats (channel_id, expires_at) VALUES (?, ?)",
-		channelID, expiresAt,
+	_, err = database.DB.ExecContext(ctx,
+		"INSERT INTO secret_chats (channel_id, expires_at, max_participants) VALUES (?, ?, ?)",
+		channelID, expiresAt, maxParticipants,
 	)
 	if err != nil {
 		return nil, err
@@ -83,20 +91,21 @@ func CreateSecretChat() (*SecretChat, error) {
 
 	// Return the created secret chat
 	return &SecretChat{
-		ChannelID:    channelID,
-		CreatedAt:    time.Now(),
-		ExpiresAt:    expiresAt,
-		MessageCount: 0,
+		ChannelID:       channelID,
+		CreatedAt:       types.NewTime(now),
+		ExpiresAt:       types.NewTime(expiresAt),
+		MessageCount:    0,
+		MaxParticipants: maxParticipants,
 	}, nil
 }
 
 // GetSecretChat retrieves a secret chat by its ID
-func GetSecretChat(channelID string) (*SecretChat, error) {
+func GetSecretChat(ctx context.Context, channelID string) (*SecretChat, error) {
 	chat := &SecretChat{}
-	err := database.DB.QueryRow(
-		"SELECT channel_id, created_at, expires_at, (SELECT COUNT(*) FROM secret_chat_messages WHERE channel_id = ?) AS message_count FROM secret_chats WHERE channel_id = ?",
+	err := database.DB.QueryRowContext(ctx,
+		"SELECT channel_id, created_at, expires_at, max_participants, (SELECT COUNT(*) FROM secret_chat_messages WHERE channel_id = ?) AS message_count FROM secret_chats WHERE channel_id = ?",
 		channelID, channelID,
-	).Scan(&chat.ChannelID, &chat.CreatedAt, &chat.ExpiresAt, &chat.MessageCount)
+	).Scan(&chat.ChannelID, &chat.CreatedAt, &chat.ExpiresAt, &chat.MaxParticipants, &chat.MessageCount)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -106,27 +115,59 @@ func GetSecretChat(channelID string) (*SecretChat, error) {
 	}
 
 	// Check if chat has expired
-	if time.Now().After(chat.ExpiresAt) {
+	if clock.Now().After(chat.ExpiresAt.Time) {
 		return nil, ErrSecretChatExpired
 	}
 
 	return chat, nil
 }
 
-// JoinSecretChat adds a participant to a secret chat
-func JoinSecretChat(channelID string, displayName string) (*SecretChatParticipant, error) {
-	// Check if chat exists and is not expired
-	_, err := GetSecretChat(channelID)
+// JoinSecretChat adds a participant to a secret chat, unless it has
+// reached its participant limit
+func JoinSecretChat(ctx context.Context, channelID string, displayName string) (*SecretChatParticipant, error) {
+	tx, err := database.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback()
+
+	// Lock the chat so concurrent joins can't both take the last place
+	var expiresAt time.Time
+	var maxParticipants int
+	err = tx.QueryRowContext(ctx,
+		"SELECT expires_at, max_participants FROM secret_chats WHERE channel_id = ? FOR UPDATE",
+		channelID,
+	).Scan(&expiresAt, &maxParticipants)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrSecretChatNotFound
+		}
+		return nil, err
+	}
+	if clock.Now().After(expiresAt) {
+		return nil, ErrSecretChatExpired
+	}
+
+	if maxParticipants > 0 {
+		var count int
+		err := tx.QueryRowContext(ctx,
+			"SELECT COUNT(*) FROM secret_chat_participants WHERE channel_id = ?",
+			channelID,
+		).Scan(&count)
+		if err != nil {
+			return nil, err
+		}
+		if count >= maxParticipants {
+			return nil, ErrSecretChatFull
+		}
+	}
 
 	// Generate session ID
 	sessionID := GenerateSessionID()
 
 	// Create participant in database
-	now := time.Now()
-	_, err = database.DB.Exec(
+	now := clock.Now()
+	_, err = tx.ExecContext(ctx,
 		"INSERT INTO secret_chat_participants (session_id, channel_id, display_name, joined_at, last_active_at) VALUES (?, ?, ?, ?, ?)",
 		sessionID, channelID, displayName, now, now,
 	)
@@ -134,39 +175,42 @@ func JoinSecretChat(channelID string, displayName string) (*SecretChatParticipan
 		return nil, err
 	}
 
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
 	// Return the created participant
 	return &SecretChatParticipant{
 		SessionID:    sessionID,
 		ChannelID:    channelID,
 		DisplayName:  displayName,
-		JoinedAt:     now,
-		LastActiveAt: now,
+		JoinedAt:     types.NewTime(now),
+		LastActiveAt: types.NewTime(now),
 	}, nil
 }
 
 // GenerateSessionID generates a unique session ID
 func GenerateSessionID() string {
 	// Generate 16 random bytes
-	randomBytes := make([]byte, 16)
-	rand.Read(randomBytes)
+	randomBytes, _ := crypto.GenerateRandomBytes(16)
 
 	// Format as hex string
 	return fmt.Sprintf("%x", randomBytes)
 }
 
 // UpdateParticipantActivity updates the last active timestamp for a participant
-func UpdateParticipantActivity(sessionID string) error {
-	_, err := database.DB.Exec(
+func UpdateParticipantActivity(ctx context.Context, sessionID string) error {
+	_, err := database.DB.ExecContext(ctx,
 		"UPDATE secret_chat_participants SET last_active_at = ? WHERE session_id = ?",
-		time.Now(), sessionID,
+		clock.Now(), sessionID,
 	)
 	return err
 }
 
 // GetParticipant retrieves a participant by session ID
-func GetParticipant(sessionID string) (*SecretChatParticipant, error) {
+func GetParticipant(ctx context.Context, sessionID string) (*SecretChatParticipant, error) {
 	participant := &SecretChatParticipant{}
-	err := database.DB.QueryRow(
+	err := database.DB.QueryRowContext(ctx,
 		"SELECT session_id, channel_id, display_name, joined_at, last_active_at FROM secret_chat_participants WHERE session_id = ?",
 		sessionID,
 	).Scan(&participant.SessionID, &participant.ChannelID, &participant.DisplayName, &participant.JoinedAt, &participant.LastActiveAt)
@@ -182,8 +226,8 @@ func GetParticipant(sessionID string) (*SecretChatParticipant, error) {
 }
 
 // GetParticipantsByChannel retrieves all participants in a channel
-func GetParticipantsByChannel(channelID string) ([]*SecretChatParticipant, error) {
-	rows, err := database.DB.Query(
+func GetParticipantsByChannel(ctx context.Context, channelID string) ([]*SecretChatParticipant, error) {
+	rows, err := database.DB.QueryContext(ctx,
 		"SELECT session_id, channel_id, display_name, joined_at, last_active_at FROM secret_chat_participants WHERE channel_id = ?",
 		channelID,
 	)
@@ -206,26 +250,26 @@ func GetParticipantsByChannel(channelID string) ([]*SecretChatParticipant, error
 }
 
 // CreateSecretChatMessage creates a new message in a secret chat
-func CreateSecretChatMessage(message *SecretChatMessage) error {
+func CreateSecretChatMessage(ctx context.Context, message *SecretChatMessage) error {
 	// Check if chat exists and is not expired
-	_, err := GetSecretChat(message.ChannelID)
+	_, err := GetSecretChat(ctx, message.ChannelID)
 	if err != nil {
 		return err
 	}
 
 	// Get participant info
-	participant, err := GetParticipant(message.SessionID)
+	participant, err := GetParticipant(ctx, message.SessionID)
 	if err != nil {
 		return err
 	}
 
 	// Update participant's last active timestamp
-	if err := UpdateParticipantActivity(message.SessionID); err != nil {
+	if err := UpdateParticipantActivity(ctx, message.SessionID); err != nil {
 		return err
 	}
 
 	// Insert message into database
-	_, err = database.DB.Exec(
+	_, err = database.DB.ExecContext(ctx,
 		"INSERT INTO secret_chat_messages (id, channel_id, session_id, display_name, encrypted_content) VALUES (?, ?, ?, ?, ?)",
 		message.ID, message.ChannelID, message.SessionID, participant.DisplayName, message.EncryptedContent,
 	)
@@ -233,15 +277,15 @@ func CreateSecretChatMessage(message *SecretChatMessage) error {
 }
 
 // GetSecretChatMessages retrieves messages from a secret chat
-func GetSecretChatMessages(channelID string, limit int, offset int) ([]*SecretChatMessage, error) {
+func GetSecretChatMessages(ctx context.Context, channelID string, limit int, offset int) ([]*SecretChatMessage, error) {
 	// Check if chat exists and is not expired
-	_, err := GetSecretChat(channelID)
+	_, err := GetSecretChat(ctx, channelID)
 	if err != nil {
 		return nil, err
 	}
 
 	// Query messages
-	rows, err := database.DB.Query(
+	rows, err := database.DB.QueryContext(ctx,
 		"SELECT id, channel_id, session_id, display_name, encrypted_content, timestamp FROM secret_chat_messages WHERE channel_id = ? ORDER BY timestamp DESC LIMIT ? OFFSET ?",
 		channelID, limit, offset,
 	)
@@ -264,28 +308,28 @@ func GetSecretChatMessages(channelID string, limit int, offset int) ([]*SecretCh
 }
 
 // DeleteSecretChat deletes a secret chat and all its messages
-func DeleteSecretChat(channelID string) error {
+func DeleteSecretChat(ctx context.Context, channelID string) error {
 	// Start a transaction
-	tx, err := database.DB.Begin()
+	tx, err := database.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
 	// Delete messages
-	_, err = tx.Exec("DELETE FROM secret_chat_messages WHERE channel_id = ?", channelID)
+	_, err = tx.ExecContext(ctx, "DELETE FROM secret_chat_messages WHERE channel_id = ?", channelID)
 	if err != nil {
 		return err
 	}
 
 	// Delete participants
-	_, err = tx.Exec("DELETE FROM secret_chat_participants WHERE channel_id = ?", channelID)
+	_, err = tx.ExecContext(ctx, "DELETE FROM secret_chat_participants WHERE channel_id = ?", channelID)
 	if err != nil {
 		return err
 	}
 
 	// Delete chat
-	_, err = tx.Exec("DELETE FROM secret_chats WHERE channel_id = ?", channelID)
+	_, err = tx.ExecContext(ctx, "DELETE FROM secret_chats WHERE channel_id = ?", channelID)
 	if err != nil {
 		return err
 	}
@@ -295,9 +339,9 @@ func DeleteSecretChat(channelID string) error {
 }
 
 // CleanupExpiredSecretChats deletes all expired secret chats
-func CleanupExpiredSecretChats() (int, error) {
+func CleanupExpiredSecretChats(ctx context.Context) (int, error) {
 	// Get expired chat IDs
-	rows, err := database.DB.Query("SELECT channel_id FROM secret_chats WHERE expires_at < ?", time.Now())
+	rows, err := database.DB.QueryContext(ctx, "SELECT channel_id FROM secret_chats WHERE expires_at < ?", clock.Now())
 	if err != nil {
 		return 0, err
 	}
@@ -314,7 +358,7 @@ func CleanupExpiredSecretChats() (int, error) {
 
 	// Delete each expired chat
 	for _, channelID := range expiredChats {
-		if err := DeleteSecretChat(channelID); err != nil {
+		if err := DeleteSecretChat(ctx, channelID); err != nil {
 			return 0, err
 		}
 	}
@@ -0,0 +1,272 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
+)
+
+var (
+	// ErrSessionNotFound is returned when a session is not found
+	ErrSessionNotFound = errors.New("session not found")
+)
+
+// Session revoke reasons
+const (
+	// SessionRevokedRemoteWipe is used when another device wiped the session
+	SessionRevokedRemoteWipe = "remote_wipe"
+	// SessionRevokedSignOut is used when the user signed the session out
+	SessionRevokedSignOut = "sign_out"
+	// SessionRevokedFreeze is used when the user froze their account
+	SessionRevokedFreeze = "emergency_freeze"
+	// SessionRevokedSuspension is used when an operator suspended the account
+	SessionRevokedSuspension = "suspension"
+)
+
+// Session represents a signed-in device
+type Session struct {
+	ID           string      `json:"id"`
+	UserAddress  string      `json:"user_address"`
+	DeviceName   string      `json:"device_name"`
+	UserAgent    string      `json:"user_agent"`
+	IPAddress    string      `json:"ip_address"`
+	CreatedAt    types.Time  `json:"created_at"`
+	LastSeenAt   types.Time  `json:"last_seen_at"`
+	RevokedAt    *types.Time `json:"revoked_at,omitempty"`
+	RevokeReason *string     `json:"revoke_reason,omitempty"`
+}
+
+// CreateSession creates a new session
+func CreateSession(ctx context.Context, session *Session) error {
+	_, err := database.DB.ExecContext(ctx,
+		"INSERT INTO sessions (id, user_address, device_name, user_agent, ip_address) VALUES (?, ?, ?, ?, ?)",
+		session.ID, session.UserAddress, session.DeviceName, session.UserAgent, session.IPAddress,
+	)
+	return err
+}
+
+// GetSessionByID retrieves a session by its ID
+func GetSessionByID(ctx context.Context, id string) (*Session, error) {
+	session := &Session{}
+	err := database.DB.QueryRowContext(ctx,
+		`SELECT id, user_address, device_name, user_agent, ip_address, created_at, last_seen_at, revoked_at, revoke_reason
+		FROM sessions WHERE id = ?`,
+		id,
+	).Scan(
+		&session.ID, &session.UserAddress, &session.DeviceName, &session.UserAgent, &session.IPAddress,
+		&session.CreatedAt, &session.LastSeenAt, &session.RevokedAt, &session.RevokeReason,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+	return session, nil
+}
+
+// GetUserSessions retrieves the active sessions of a user, most recently used first
+func GetUserSessions(ctx context.Context, userAddress string) ([]*Session, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		`SELECT id, user_address, device_name, user_agent, ip_address, created_at, last_seen_at, revoked_at, revoke_reason
+		FROM sessions WHERE user_address = ? AND revoked_at IS NULL ORDER BY last_seen_at DESC`,
+		userAddress,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []*Session{}
+	for rows.Next() {
+		session := &Session{}
+		err := rows.Scan(
+			&session.ID, &session.UserAddress, &session.DeviceName, &session.UserAgent, &session.IPAddress,
+			&session.CreatedAt, &session.LastSeenAt, &session.RevokedAt, &session.RevokeReason,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// IsSessionActive checks if a session exists and has not been revoked
+func IsSessionActive(ctx context.Context, id string) (bool, error) {
+	var count int
+	err := database.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM sessions WHERE id = ? AND revoked_at IS NULL", id).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// TouchSession records that a session was just used
+func TouchSession(ctx context.Context, id string) error {
+	_, err := database.DB.ExecContext(ctx, "UPDATE sessions SET last_seen_at = NOW() WHERE id = ?", id)
+	return err
+}
+
+// GetLastSeen returns when a user last used any of their sessions, or nil
+// if they never signed in
+func GetLastSeen(ctx context.Context, userAddress string) (*types.Time, error) {
+	var lastSeen *types.Time
+	err := database.DB.QueryRowContext(ctx,
+		"SELECT MAX(last_seen_at) FROM sessions WHERE user_address = ?", userAddress,
+	).Scan(&lastSeen)
+	if err != nil {
+		return nil, err
+	}
+	return lastSeen, nil
+}
+
+// RevokeSession revokes a session so its tokens stop working
+func RevokeSession(ctx context.Context, id, reason string) error {
+	result, err := database.DB.ExecContext(ctx,
+		"UPDATE sessions SET revoked_at = NOW(), revoke_reason = ? WHERE id = ? AND revoked_at IS NULL",
+		reason, id,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// RevokeOtherSessions revokes every active session of a user except one,
+// returning the IDs of the revoked sessions
+func RevokeOtherSessions(ctx context.Context, userAddress, keepID, reason string) ([]string, error) {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx,
+		"SELECT id FROM sessions WHERE user_address = ? AND id <> ? AND revoked_at IS NULL FOR UPDATE",
+		userAddress, keepID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE sessions SET revoked_at = NOW(), revoke_reason = ? WHERE user_address = ? AND id <> ? AND revoked_at IS NULL",
+		reason, userAddress, keepID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// GetSessionDeviceNames maps session IDs to their device names
+func GetSessionDeviceNames(ctx context.Context, sessionIDs []string) (map[string]string, error) {
+	names := map[string]string{}
+	if len(sessionIDs) == 0 {
+		return names, nil
+	}
+
+	args := make([]interface{}, len(sessionIDs))
+	for i, id := range sessionIDs {
+		args[i] = id
+	}
+
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT id, device_name FROM sessions WHERE id IN (?"+strings.Repeat(", ?", len(sessionIDs)-1)+")",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		names[id] = name
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// SessionActivity summarizes the messages a session has sent
+type SessionActivity struct {
+	SessionID     string      `json:"session_id"`
+	DeviceName    string      `json:"device_name"`
+	MessagesSent  int         `json:"messages_sent"`
+	LastMessageAt *types.Time `json:"last_message_at,omitempty"`
+	RevokedAt     *types.Time `json:"revoked_at,omitempty"`
+}
+
+// GetSessionActivity summarizes how many messages each of a user's sessions
+// has sent, across direct, group and channel messages
+func GetSessionActivity(ctx context.Context, userAddress string) ([]*SessionActivity, error) {
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT s.id, s.device_name, COUNT(sent.id), MAX(sent.timestamp), s.revoked_at
+		FROM sessions s
+		LEFT JOIN (
+			SELECT id, sender_session_id, timestamp FROM messages WHERE sender_address = ?
+			UNION ALL
+			SELECT id, sender_session_id, timestamp FROM group_messages WHERE sender_address = ?
+			UNION ALL
+			SELECT id, sender_session_id, timestamp FROM channel_messages WHERE sender_address = ?
+		) sent ON sent.sender_session_id = s.id
+		WHERE s.user_address = ?
+		GROUP BY s.id, s.device_name, s.revoked_at, s.created_at
+		ORDER BY s.created_at DESC`,
+		userAddress, userAddress, userAddress, userAddress,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	activity := []*SessionActivity{}
+	for rows.Next() {
+		entry := &SessionActivity{}
+		if err := rows.Scan(&entry.SessionID, &entry.DeviceName, &entry.MessagesSent, &entry.LastMessageAt, &entry.RevokedAt); err != nil {
+			return nil, err
+		}
+		activity = append(activity, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return activity, nil
+}
@@ -0,0 +1,340 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
+)
+
+var (
+	// ErrStickerPackNotFound is returned when a sticker pack is not found
+	ErrStickerPackNotFound = errors.New("sticker pack not found")
+	// ErrStickerNotFound is returned when a sticker is not found
+	ErrStickerNotFound = errors.New("sticker not found")
+	// ErrStickerPackFull is returned when a sticker pack has reached its sticker limit
+	ErrStickerPackFull = errors.New("sticker pack is full")
+	// ErrShortcodeTaken is returned when an emoji pack already has an emoji with the same shortcode
+	ErrShortcodeTaken = errors.New("shortcode already taken")
+)
+
+// StickerPackKind is what a sticker pack holds
+type StickerPackKind string
+
+const (
+	// StickerPackStickers holds stickers, sent as sticker messages. Each
+	// sticker may name the emoji it stands for.
+	StickerPackStickers StickerPackKind = "stickers"
+	// StickerPackEmoji holds custom emoji, which clients write inline in
+	// messages as :shortcode:
+	StickerPackEmoji StickerPackKind = "emoji"
+)
+
+// IsValidStickerPackKind checks if a sticker pack kind is supported
+func IsValidStickerPackKind(kind StickerPackKind) bool {
+	return kind == StickerPackStickers || kind == StickerPackEmoji
+}
+
+// shortcodeRegex matches the shortcodes of custom emoji
+var shortcodeRegex = regexp.MustCompile(`^[a-z0-9_]{2,32}$`)
+
+// IsValidShortcode checks if a custom emoji shortcode is valid
+func IsValidShortcode(shortcode string) bool {
+	return shortcodeRegex.MatchString(shortcode)
+}
+
+// StickerPack is a named set of stickers or custom emoji. Packs are public:
+// anyone who knows a pack's ID can see and install it, and only its creator
+// can change it.
+type StickerPack struct {
+	ID             string          `json:"id"`
+	Name           string          `json:"name"`
+	Kind           StickerPackKind `json:"kind"`
+	CreatorAddress string          `json:"creator_address"`
+	StickerCount   int             `json:"sticker_count"`
+	CreatedAt      types.Time      `json:"created_at"`
+}
+
+// Sticker is an image in a sticker pack
+type Sticker struct {
+	ID      string `json:"id"`
+	PackID  string `json:"pack_id"`
+	MediaID string `json:"media_id"`
+	// Emoji is the emoji a sticker stands for, or a custom emoji's shortcode
+	Emoji     string     `json:"emoji,omitempty"`
+	CreatedAt types.Time `json:"created_at"`
+	// Media is the sticker's image
+	Media *Media `json:"-"`
+}
+
+// CreateStickerPack creates an empty sticker pack and installs it for its
+// creator
+func CreateStickerPack(ctx context.Context, pack *StickerPack) error {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := clock.Now()
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO sticker_packs (id, name, kind, creator_address, created_at) VALUES (?, ?, ?, ?, ?)",
+		pack.ID, pack.Name, pack.Kind, pack.CreatorAddress, now,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO sticker_pack_installs (user_address, pack_id, installed_at) VALUES (?, ?, ?)",
+		pack.CreatorAddress, pack.ID, now,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	pack.StickerCount = 0
+	pack.CreatedAt = types.NewTime(now)
+	return nil
+}
+
+// stickerPackColumns are the columns scanned by scanStickerPack, from
+// sticker_packs aliased as p
+const stickerPackColumns = "p.id, p.name, p.kind, p.creator_address, (SELECT COUNT(*) FROM stickers s WHERE s.pack_id = p.id), p.created_at"
+
+// scanStickerPack scans a row of stickerPackColumns
+func scanStickerPack(row interface{ Scan(...any) error }) (*StickerPack, error) {
+	pack := &StickerPack{}
+	err := row.Scan(&pack.ID, &pack.Name, &pack.Kind, &pack.CreatorAddress, &pack.StickerCount, &pack.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return pack, nil
+}
+
+// GetStickerPack retrieves a sticker pack by its ID
+func GetStickerPack(ctx context.Context, id string) (*StickerPack, error) {
+	pack, err := scanStickerPack(database.DB.QueryRowContext(ctx,
+		"SELECT "+stickerPackColumns+" FROM sticker_packs p WHERE p.id = ?",
+		id,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrStickerPackNotFound
+		}
+		return nil, err
+	}
+	return pack, nil
+}
+
+// GetInstalledStickerPacks lists the sticker packs a user installed, in the
+// order they installed them
+func GetInstalledStickerPacks(ctx context.Context, userAddress string) ([]*StickerPack, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT "+stickerPackColumns+` FROM sticker_packs p
+		JOIN sticker_pack_installs i ON i.pack_id = p.id
+		WHERE i.user_address = ? ORDER BY i.installed_at, p.id`,
+		userAddress,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	packs := []*StickerPack{}
+	for rows.Next() {
+		pack, err := scanStickerPack(rows)
+		if err != nil {
+			return nil, err
+		}
+		packs = append(packs, pack)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return packs, nil
+}
+
+// DeleteStickerPack deletes a sticker pack with its stickers. Messages that
+// sent its stickers keep their sticker IDs, which no longer resolve.
+func DeleteStickerPack(ctx context.Context, id string) error {
+	result, err := database.DB.ExecContext(ctx, "DELETE FROM sticker_packs WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrStickerPackNotFound
+	}
+	return nil
+}
+
+// InstallStickerPack adds a sticker pack to a user's installed packs.
+// Installing a pack twice keeps it where it was.
+func InstallStickerPack(ctx context.Context, userAddress, packID string) error {
+	_, err := database.DB.ExecContext(ctx,
+		`INSERT INTO sticker_pack_installs (user_address, pack_id, installed_at)
+		SELECT ?, id, ? FROM sticker_packs WHERE id = ?
+		ON DUPLICATE KEY UPDATE pack_id = pack_id`,
+		userAddress, clock.Now(), packID,
+	)
+	return err
+}
+
+// UninstallStickerPack removes a sticker pack from a user's installed packs
+func UninstallStickerPack(ctx context.Context, userAddress, packID string) error {
+	_, err := database.DB.ExecContext(ctx,
+		"DELETE FROM sticker_pack_installs WHERE user_address = ? AND pack_id = ?",
+		userAddress, packID,
+	)
+	return err
+}
+
+// IsStickerPackInstalled checks if a user installed a sticker pack
+func IsStickerPackInstalled(ctx context.Context, userAddress, packID string) (bool, error) {
+	var count int
+	err := database.DB.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM sticker_pack_installs WHERE user_address = ? AND pack_id = ?",
+		userAddress, packID,
+	).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// AddSticker adds a sticker to a pack holding fewer than maxStickers
+func AddSticker(ctx context.Context, sticker *Sticker, maxStickers int) error {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the pack so concurrent additions can't pass the limit or reuse a
+	// shortcode
+	var kind StickerPackKind
+	err = tx.QueryRowContext(ctx, "SELECT kind FROM sticker_packs WHERE id = ? FOR UPDATE", sticker.PackID).Scan(&kind)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrStickerPackNotFound
+		}
+		return err
+	}
+
+	var count int
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM stickers WHERE pack_id = ?", sticker.PackID).Scan(&count); err != nil {
+		return err
+	}
+	if count >= maxStickers {
+		return ErrStickerPackFull
+	}
+
+	if kind == StickerPackEmoji {
+		err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM stickers WHERE pack_id = ? AND emoji = ?", sticker.PackID, sticker.Emoji).Scan(&count)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrShortcodeTaken
+		}
+	}
+
+	now := clock.Now()
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO stickers (id, pack_id, media_id, emoji, created_at) VALUES (?, ?, ?, ?, ?)",
+		sticker.ID, sticker.PackID, sticker.MediaID, sticker.Emoji, now,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	sticker.CreatedAt = types.NewTime(now)
+	return nil
+}
+
+// stickerColumns are the columns scanned by scanSticker, from stickers
+// aliased as s joined to their media as m
+const stickerColumns = "s.id, s.pack_id, s.media_id, s.emoji, s.created_at, m.id, m.owner_address, m.storage_key, m.file_name, m.mime_type, m.size, m.created_at"
+
+// scanSticker scans a row of stickerColumns
+func scanSticker(row interface{ Scan(...any) error }) (*Sticker, error) {
+	sticker := &Sticker{Media: &Media{}}
+	err := row.Scan(
+		&sticker.ID, &sticker.PackID, &sticker.MediaID, &sticker.Emoji, &sticker.CreatedAt,
+		&sticker.Media.ID, &sticker.Media.OwnerAddress, &sticker.Media.StorageKey, &sticker.Media.FileName, &sticker.Media.MimeType, &sticker.Media.Size, &sticker.Media.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return sticker, nil
+}
+
+// GetSticker retrieves a sticker with its image
+func GetSticker(ctx context.Context, id string) (*Sticker, error) {
+	sticker, err := scanSticker(database.DB.QueryRowContext(ctx,
+		"SELECT "+stickerColumns+" FROM stickers s JOIN media m ON m.id = s.media_id WHERE s.id = ?",
+		id,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrStickerNotFound
+		}
+		return nil, err
+	}
+	return sticker, nil
+}
+
+// GetStickers lists the stickers of a pack with their images, in the order
+// they were added
+func GetStickers(ctx context.Context, packID string) ([]*Sticker, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT "+stickerColumns+" FROM stickers s JOIN media m ON m.id = s.media_id WHERE s.pack_id = ? ORDER BY s.created_at, s.id",
+		packID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stickers := []*Sticker{}
+	for rows.Next() {
+		sticker, err := scanSticker(rows)
+		if err != nil {
+			return nil, err
+		}
+		stickers = append(stickers, sticker)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return stickers, nil
+}
+
+// DeleteSticker removes a sticker from a pack
+func DeleteSticker(ctx context.Context, packID, stickerID string) error {
+	result, err := database.DB.ExecContext(ctx, "DELETE FROM stickers WHERE id = ? AND pack_id = ?", stickerID, packID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrStickerNotFound
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
+)
+
+var (
+	// ErrTicketNotFound is returned when a support ticket is not found
+	ErrTicketNotFound = errors.New("support ticket not found")
+	// ErrTicketClosed is returned when replying to or closing a closed ticket
+	ErrTicketClosed = errors.New("support ticket is closed")
+)
+
+// SupportBotPhone is the phone column of the support bot account. It isn't
+// a phone number, so no OTP can ever be sent to it.
+const SupportBotPhone = "support-bot"
+
+// TicketCategory is what a support ticket is about
+type TicketCategory string
+
+const (
+	// TicketCategoryBug is a problem with the app or server
+	TicketCategoryBug TicketCategory = "bug"
+	// TicketCategoryFeedback is a suggestion or comment
+	TicketCategoryFeedback TicketCategory = "feedback"
+	// TicketCategoryAccount is a question about the user's account
+	TicketCategoryAccount TicketCategory = "account"
+	// TicketCategoryOther is anything else
+	TicketCategoryOther TicketCategory = "other"
+)
+
+// TicketStatus is where a ticket is in the support queue
+type TicketStatus string
+
+const (
+	// TicketStatusOpen tickets are waiting for or being handled by an admin
+	TicketStatusOpen TicketStatus = "open"
+	// TicketStatusClosed tickets need no more replies
+	TicketStatusClosed TicketStatus = "closed"
+)
+
+// SupportTicket is a user's support request or feedback
+type SupportTicket struct {
+	ID          int            `json:"id"`
+	UserAddress string         `json:"user_address"`
+	Category    TicketCategory `json:"category"`
+	Message     string         `json:"message"`
+	LogsMediaID *string        `json:"logs_media_id,omitempty"`
+	Status      TicketStatus   `json:"status"`
+	ClosedBy    *string        `json:"closed_by,omitempty"`
+	ClosedAt    *types.Time    `json:"closed_at,omitempty"`
+	CreatedAt   types.Time     `json:"created_at"`
+}
+
+// SupportTicketReply is an admin's reply to a ticket
+type SupportTicketReply struct {
+	ID           int        `json:"id"`
+	TicketID     int        `json:"ticket_id"`
+	AdminAddress string     `json:"admin_address"`
+	Message      string     `json:"message"`
+	CreatedAt    types.Time `json:"created_at"`
+}
+
+// IsValidTicketCategory checks if a ticket category is supported
+func IsValidTicketCategory(category TicketCategory) bool {
+	switch category {
+	case TicketCategoryBug, TicketCategoryFeedback, TicketCategoryAccount, TicketCategoryOther:
+		return true
+	}
+	return false
+}
+
+// CreateSupportTicket opens a support ticket
+func CreateSupportTicket(ctx context.Context, ticket *SupportTicket) error {
+	result, err := database.DB.ExecContext(ctx,
+		"INSERT INTO support_tickets (user_address, category, message, logs_media_id) VALUES (?, ?, ?, ?)",
+		ticket.UserAddress, ticket.Category, ticket.Message, ticket.LogsMediaID,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	ticket.ID = int(id)
+	ticket.Status = TicketStatusOpen
+	ticket.CreatedAt = types.NewTime(clock.Now())
+	return nil
+}
+
+// GetSupportTicket retrieves a support ticket by its ID
+func GetSupportTicket(ctx context.Context, id int) (*SupportTicket, error) {
+	ticket := &SupportTicket{}
+	err := database.DB.QueryRowContext(ctx,
+		`SELECT id, user_address, category, message, logs_media_id, status, closed_by, closed_at, created_at
+		FROM support_tickets WHERE id = ?`,
+		id,
+	).Scan(
+		&ticket.ID, &ticket.UserAddress, &ticket.Category, &ticket.Message, &ticket.LogsMediaID,
+		&ticket.Status, &ticket.ClosedBy, &ticket.ClosedAt, &ticket.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTicketNotFound
+		}
+		return nil, err
+	}
+	return ticket, nil
+}
+
+// GetUserSupportTickets retrieves the tickets a user opened, newest first
+func GetUserSupportTickets(ctx context.Context, userAddress string) ([]*SupportTicket, error) {
+	return querySupportTickets(ctx,
+		`SELECT id, user_address, category, message, logs_media_id, status, closed_by, closed_at, created_at
+		FROM support_tickets WHERE user_address = ? ORDER BY id DESC`,
+		userAddress,
+	)
+}
+
+// GetSupportTickets retrieves tickets with a status, oldest first
+func GetSupportTickets(ctx context.Context, status TicketStatus, limit, offset int) ([]*SupportTicket, error) {
+	return querySupportTickets(ctx,
+		`SELECT id, user_address, category, message, logs_media_id, status, closed_by, closed_at, created_at
+		FROM support_tickets WHERE status = ? ORDER BY id LIMIT ? OFFSET ?`,
+		status, limit, offset,
+	)
+}
+
+// querySupportTickets runs a query selecting support ticket rows
+func querySupportTickets(ctx context.Context, query string, args ...interface{}) ([]*SupportTicket, error) {
+	rows, err := database.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tickets := []*SupportTicket{}
+	for rows.Next() {
+		ticket := &SupportTicket{}
+		if err := rows.Scan(
+			&ticket.ID, &ticket.UserAddress, &ticket.Category, &ticket.Message, &ticket.LogsMediaID,
+			&ticket.Status, &ticket.ClosedBy, &ticket.ClosedAt, &ticket.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		tickets = append(tickets, ticket)
+	}
+	return tickets, rows.Err()
+}
+
+// AddSupportTicketReply records an admin's reply to an open ticket
+func AddSupportTicketReply(ctx context.Context, reply *SupportTicketReply) error {
+	result, err := database.DB.ExecContext(ctx,
+		`INSERT INTO support_ticket_replies (ticket_id, admin_address, message)
+		SELECT id, ?, ? FROM support_tickets WHERE id = ? AND status = ?`,
+		reply.AdminAddress, reply.Message, reply.TicketID, TicketStatusOpen,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return openTicketError(ctx, reply.TicketID)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	reply.ID = int(id)
+	reply.CreatedAt = types.NewTime(clock.Now())
+	return nil
+}
+
+// GetSupportTicketReplies retrieves the replies to a ticket, oldest first
+func GetSupportTicketReplies(ctx context.Context, ticketID int) ([]*SupportTicketReply, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT id, ticket_id, admin_address, message, created_at FROM support_ticket_replies WHERE ticket_id = ? ORDER BY id",
+		ticketID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	replies := []*SupportTicketReply{}
+	for rows.Next() {
+		reply := &SupportTicketReply{}
+		if err := rows.Scan(&reply.ID, &reply.TicketID, &reply.AdminAddress, &reply.Message, &reply.CreatedAt); err != nil {
+			return nil, err
+		}
+		replies = append(replies, reply)
+	}
+	return replies, rows.Err()
+}
+
+// CloseSupportTicket marks an open ticket as closed by an admin
+func CloseSupportTicket(ctx context.Context, id int, adminAddress string) error {
+	result, err := database.DB.ExecContext(ctx,
+		"UPDATE support_tickets SET status = ?, closed_by = ?, closed_at = NOW() WHERE id = ? AND status = ?",
+		TicketStatusClosed, adminAddress, id, TicketStatusOpen,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return openTicketError(ctx, id)
+	}
+	return nil
+}
+
+// openTicketError tells apart a missing ticket from a closed one after an
+// update of an open ticket changed nothing
+func openTicketError(ctx context.Context, id int) error {
+	if _, err := GetSupportTicket(ctx, id); err != nil {
+		return err
+	}
+	return ErrTicketClosed
+}
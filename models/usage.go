@@ -0,0 +1,90 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/piko/piko/database"
+)
+
+// usageDateLayout is the layout of DailyMessageCount dates
+const usageDateLayout = "2006-01-02"
+
+// DailyMessageCount is how many messages a user sent on one UTC day
+type DailyMessageCount struct {
+	Date    string `json:"date"`
+	Direct  int    `json:"direct"`
+	Group   int    `json:"group"`
+	Channel int    `json:"channel"`
+	Total   int    `json:"total"`
+}
+
+// GetDailyMessageCounts counts the direct, group and channel messages a user
+// sent on each UTC day from since's day to until's, including days without
+// any, oldest first
+func GetDailyMessageCounts(ctx context.Context, userAddress string, since, until time.Time) ([]*DailyMessageCount, error) {
+	first := since.UTC().Truncate(24 * time.Hour)
+	last := until.UTC().Truncate(24 * time.Hour)
+
+	days := []*DailyMessageCount{}
+	byDate := make(map[string]*DailyMessageCount)
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		count := &DailyMessageCount{Date: day.Format(usageDateLayout)}
+		days = append(days, count)
+		byDate[count.Date] = count
+	}
+
+	rows, err := database.DB.QueryContext(ctx, dailyMessageCountsQuery(first, until),
+		userAddress, first, userAddress, first, userAddress, first,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var kind, date string
+		var n int
+		if err := rows.Scan(&kind, &date, &n); err != nil {
+			return nil, err
+		}
+		count, ok := byDate[date]
+		if !ok {
+			continue
+		}
+		switch kind {
+		case "direct":
+			count.Direct += n
+		case "group":
+			count.Group += n
+		case "channel":
+			count.Channel += n
+		}
+		count.Total += n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return days, nil
+}
+
+// dailyMessageCountsQuery counts a sender's messages per kind and day from
+// first, reading only the partitions up to until's month. Later messages
+// would not be counted anyway.
+func dailyMessageCountsQuery(first, until time.Time) string {
+	return fmt.Sprintf(`
+		SELECT kind, DATE_FORMAT(timestamp, '%%Y-%%m-%%d') AS day, COUNT(*)
+		FROM (
+			SELECT 'direct' AS kind, timestamp FROM messages%s WHERE sender_address = ? AND timestamp >= ?
+			UNION ALL
+			SELECT 'group', timestamp FROM group_messages%s WHERE sender_address = ? AND timestamp >= ?
+			UNION ALL
+			SELECT 'channel', timestamp FROM channel_messages%s WHERE sender_address = ? AND timestamp >= ?
+		) sent
+		GROUP BY kind, day`,
+		database.PartitionSelection("messages", first, until),
+		database.PartitionSelection("group_messages", first, until),
+		database.PartitionSelection("channel_messages", first, until),
+	)
+}
@@ -1,12 +1,16 @@
 package models
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
+	"github.com/piko/piko/utils"
 )
 
 var (
@@ -22,24 +26,41 @@ var (
 	ErrInvalidUsername = errors.New("invalid username format")
 )
 
+// UserRole defines a user's server-wide role
+type UserRole string
+
+const (
+	// UserRoleUser is the role of regular users
+	UserRoleUser UserRole = "user"
+	// UserRoleAdmin is the role of server operators
+	UserRoleAdmin UserRole = "admin"
+	// UserRoleBot is the role of accounts run by the server, like the
+	// support bot. They have no usable login.
+	UserRoleBot UserRole = "bot"
+)
+
 // User represents a user in the system
 type User struct {
-	ID           int       `json:"id"`
-	Phone        string    `json:"phone"`
-	Username     string    `json:"username,omitempty"`
-	PasswordHash string    `json:"-"`
-	PublicKey    []byte    `json:"public_key"`
-	Address      string    `json:"address"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID           int         `json:"id"`
+	Phone        string      `json:"phone"`
+	Username     string      `json:"username,omitempty"`
+	PasswordHash string      `json:"-"`
+	PublicKey    []byte      `json:"public_key"`
+	Address      string      `json:"address"`
+	Role         UserRole    `json:"role"`
+	Birthdate    *types.Time `json:"birthdate,omitempty"`
+	// Email is where OTPs requested with the "email" channel are sent
+	Email     string     `json:"email,omitempty"`
+	CreatedAt types.Time `json:"created_at"`
+	UpdatedAt types.Time `json:"updated_at"`
 }
 
 // CreateUser creates a new user in the database
-func CreateUser(user *User) error {
+func CreateUser(ctx context.Context, user *User) error {
 	// Check if user with same phone exists
 	if user.Phone != "" {
 		var count int
-		err := database.DB.QueryRow("SELECT COUNT(*) FROM users WHERE phone = ?", user.Phone).Scan(&count)
+		err := database.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM users WHERE phone = ?", user.Phone).Scan(&count)
 		if err != nil {
 			return err
 		}
@@ -50,7 +71,7 @@ func CreateUser(user *User) error {
 
 	// Check if user with same address exists
 	var count int
-	err := database.DB.QueryRow("SELECT COUNT(*) FROM users WHERE address = ?", user.Address).Scan(&count)
+	err := database.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM users WHERE address = ?", user.Address).Scan(&count)
 	if err != nil {
 		return err
 	}
@@ -58,8 +79,12 @@ func CreateUser(user *User) error {
 		return ErrAddressAlreadyExists
 	}
 
+	if user.Role == "" {
+		user.Role = UserRoleUser
+	}
+
 	// Start a transaction
-	tx, err := database.DB.Begin()
+	tx, err := database.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
@@ -70,9 +95,9 @@ func CreateUser(user *User) error {
 	}()
 
 	// Insert user into database - username is not set during registration
-	result, err := tx.Exec(
-		"INSERT INTO users (phone, password_hash, public_key, address) VALUES (?, ?, ?, ?)",
-		user.Phone, user.PasswordHash, user.PublicKey, user.Address,
+	result, err := tx.ExecContext(ctx,
+		"INSERT INTO users (phone, phone_hash, password_hash, public_key, address, role, birthdate, email) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		user.Phone, utils.HashPhone(user.Phone), user.PasswordHash, user.PublicKey, user.Address, user.Role, user.Birthdate, user.Email,
 	)
 	if err != nil {
 		return err
@@ -86,7 +111,7 @@ func CreateUser(user *User) error {
 	user.ID = int(id)
 
 	// Create default settings for the user
-	_, err = tx.Exec(`
+	_, err = tx.ExecContext(ctx, `
 		INSERT INTO user_settings (
 			user_id, theme, notification_enabled, sound_enabled,
 			language, auto_download_media, privacy_last_seen,
@@ -105,14 +130,32 @@ func CreateUser(user *User) error {
 	return nil
 }
 
+// AgeOn returns the user's age in whole years on a date, or false if their
+// birthdate is unknown
+func (u *User) AgeOn(date time.Time) (int, bool) {
+	if u.Birthdate == nil {
+		return 0, false
+	}
+	return AgeOn(u.Birthdate.Time, date), true
+}
+
+// AgeOn returns the age in whole years of someone born on birthdate
+func AgeOn(birthdate, date time.Time) int {
+	age := date.Year() - birthdate.Year()
+	if date.Month() < birthdate.Month() || (date.Month() == birthdate.Month() && date.Day() < birthdate.Day()) {
+		age--
+	}
+	return age
+}
+
 // GetUserByID retrieves a user by their ID
-func GetUserByID(id int) (*User, error) {
+func GetUserByID(ctx context.Context, id int) (*User, error) {
 	user := &User{}
-	err := database.DB.QueryRow(
-		"SELECT id, phone, username, password_hash, public_key, address, created_at, updated_at FROM users WHERE id = ?",
+	err := database.DB.QueryRowContext(ctx,
+		"SELECT id, phone, username, password_hash, public_key, address, role, birthdate, email, created_at, updated_at FROM users WHERE id = ?",
 		id,
 	).Scan(
-		&user.ID, &user.Phone, &user.Username, &user.PasswordHash, &user.PublicKey, &user.Address, &user.CreatedAt, &user.UpdatedAt,
+		&user.ID, &user.Phone, &user.Username, &user.PasswordHash, &user.PublicKey, &user.Address, &user.Role, &user.Birthdate, &user.Email, &user.CreatedAt, &user.UpdatedAt,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -124,13 +167,13 @@ func GetUserByID(id int) (*User, error) {
 }
 
 // GetUserByPhone retrieves a user by their phone number
-func GetUserByPhone(phone string) (*User, error) {
+func GetUserByPhone(ctx context.Context, phone string) (*User, error) {
 	user := &User{}
-	err := database.DB.QueryRow(
-		"SELECT id, phone, username, password_hash, public_key, address, created_at, updated_at FROM users WHERE phone = ?",
+	err := database.DB.QueryRowContext(ctx,
+		"SELECT id, phone, username, password_hash, public_key, address, role, birthdate, email, created_at, updated_at FROM users WHERE phone = ?",
 		phone,
 	).Scan(
-		&user.ID, &user.Phone, &user.Username, &user.PasswordHash, &user.PublicKey, &user.Address, &user.CreatedAt, &user.UpdatedAt,
+		&user.ID, &user.Phone, &user.Username, &user.PasswordHash, &user.PublicKey, &user.Address, &user.Role, &user.Birthdate, &user.Email, &user.CreatedAt, &user.UpdatedAt,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -142,13 +185,13 @@ func GetUserByPhone(phone string) (*User, error) {
 }
 
 // GetUserByAddress retrieves a user by their address
-func GetUserByAddress(address string) (*User, error) {
+func GetUserByAddress(ctx context.Context, address string) (*User, error) {
 	user := &User{}
-	err := database.DB.QueryRow(
-		"SELECT id, phone, username, password_hash, public_key, address, created_at, updated_at FROM users WHERE address = ?",
+	err := database.DB.QueryRowContext(ctx,
+		"SELECT id, phone, username, password_hash, public_key, address, role, birthdate, email, created_at, updated_at FROM users WHERE address = ?",
 		address,
 	).Scan(
-		&user.ID, &user.Phone, &user.Username, &user.PasswordHash, &user.PublicKey, &user.Address, &user.CreatedAt, &user.UpdatedAt,
+		&user.ID, &user.Phone, &user.Username, &user.PasswordHash, &user.PublicKey, &user.Address, &user.Role, &user.Birthdate, &user.Email, &user.CreatedAt, &user.UpdatedAt,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -160,13 +203,13 @@ func GetUserByAddress(address string) (*User, error) {
 }
 
 // GetUserByUsername retrieves a user by their username
-func GetUserByUsername(username string) (*User, error) {
+func GetUserByUsername(ctx context.Context, username string) (*User, error) {
 	user := &User{}
-	err := database.DB.QueryRow(
-		"SELECT id, phone, username, password_hash, public_key, address, created_at, updated_at FROM users WHERE username = ?",
+	err := database.DB.QueryRowContext(ctx,
+		"SELECT id, phone, username, password_hash, public_key, address, role, birthdate, email, created_at, updated_at FROM users WHERE username = ?",
 		username,
 	).Scan(
-		&user.ID, &user.Phone, &user.Username, &user.PasswordHash, &user.PublicKey, &user.Address, &user.CreatedAt, &user.UpdatedAt,
+		&user.ID, &user.Phone, &user.Username, &user.PasswordHash, &user.PublicKey, &user.Address, &user.Role, &user.Birthdate, &user.Email, &user.CreatedAt, &user.UpdatedAt,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -178,9 +221,9 @@ func GetUserByUsername(username string) (*User, error) {
 }
 
 // SearchUsers searches for users by username, phone, or address
-func SearchUsers(query string) ([]*User, error) {
-	rows, err := database.DB.Query(
-		"SELECT id, phone, username, password_hash, public_key, address, created_at, updated_at FROM users WHERE username LIKE ? OR phone LIKE ? OR address LIKE ? LIMIT 20",
+func SearchUsers(ctx context.Context, query string) ([]*User, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT id, phone, username, password_hash, public_key, address, role, birthdate, email, created_at, updated_at FROM users WHERE username LIKE ? OR phone LIKE ? OR address LIKE ? LIMIT 20",
 		"%"+query+"%", "%"+query+"%", "%"+query+"%",
 	)
 	if err != nil {
@@ -192,7 +235,45 @@ func SearchUsers(query string) ([]*User, error) {
 	for rows.Next() {
 		user := &User{}
 		err := rows.Scan(
-			&user.ID, &user.Phone, &user.Username, &user.PasswordHash, &user.PublicKey, &user.Address, &user.CreatedAt, &user.UpdatedAt,
+			&user.ID, &user.Phone, &user.Username, &user.PasswordHash, &user.PublicKey, &user.Address, &user.Role, &user.Birthdate, &user.Email, &user.CreatedAt, &user.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// GetUsersByPhoneHashes retrieves the users whose phone numbers hash to
+// any of the given HashPhone hashes
+func GetUsersByPhoneHashes(ctx context.Context, hashes []string) ([]*User, error) {
+	users := []*User{}
+	if len(hashes) == 0 {
+		return users, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(hashes)), ",")
+	args := make([]interface{}, len(hashes))
+	for i, hash := range hashes {
+		args[i] = hash
+	}
+	rows, err := database.DB.QueryContext(ctx,
+		"SELECT id, phone, username, password_hash, public_key, address, role, birthdate, email, created_at, updated_at FROM users WHERE phone_hash IN ("+placeholders+")",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		user := &User{}
+		err := rows.Scan(
+			&user.ID, &user.Phone, &user.Username, &user.PasswordHash, &user.PublicKey, &user.Address, &user.Role, &user.Birthdate, &user.Email, &user.CreatedAt, &user.UpdatedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -206,16 +287,27 @@ func SearchUsers(query string) ([]*User, error) {
 }
 
 // UpdateUser updates a user's information
-func UpdateUser(user *User) error {
-	_, err := database.DB.Exec(
-		"UPDATE users SET phone = ?, username = ?, password_hash = ?, public_key = ? WHERE id = ?",
-		user.Phone, user.Username, user.PasswordHash, user.PublicKey, user.ID,
+func UpdateUser(ctx context.Context, user *User) error {
+	_, err := database.DB.ExecContext(ctx,
+		"UPDATE users SET phone = ?, phone_hash = ?, username = ?, password_hash = ?, public_key = ?, email = ? WHERE id = ?",
+		user.Phone, utils.HashPhone(user.Phone), user.Username, user.PasswordHash, user.PublicKey, user.Email, user.ID,
 	)
 	return err
 }
 
+// PromoteAdmins gives the admin role to the users with the given phone numbers
+func PromoteAdmins(ctx context.Context, phones []string) error {
+	for _, phone := range phones {
+		_, err := database.DB.ExecContext(ctx, "UPDATE users SET role = ? WHERE phone = ?", UserRoleAdmin, phone)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // SetUsername sets or updates a user's username
-func SetUsername(userID int, username string) error {
+func SetUsername(ctx context.Context, userID int, username string) error {
 	// Validate username format
 	if !IsValidUsername(username) {
 		return ErrInvalidUsername
@@ -223,7 +315,7 @@ func SetUsername(userID int, username string) error {
 
 	// Check if username is already taken
 	var count int
-	err := database.DB.QueryRow("SELECT COUNT(*) FROM users WHERE username = ? AND id != ?", username, userID).Scan(&count)
+	err := database.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM users WHERE username = ? AND id != ?", username, userID).Scan(&count)
 	if err != nil {
 		return err
 	}
@@ -232,7 +324,7 @@ func SetUsername(userID int, username string) error {
 	}
 
 	// Update username
-	_, err = database.DB.Exec("UPDATE users SET username = ? WHERE id = ?", username, userID)
+	_, err = database.DB.ExecContext(ctx, "UPDATE users SET username = ? WHERE id = ?", username, userID)
 	return err
 }
 
@@ -248,8 +340,20 @@ func IsValidUsername(username string) bool {
 	return match
 }
 
-// DeleteUser deletes a user by ID
-func DeleteUser(id int) error {
-	_, err := database.DB.Exec("DELETE FROM users WHERE id = ?", id)
+// DeleteUser deletes a user by ID. Accounts under legal hold are kept.
+func DeleteUser(ctx context.Context, id int) error {
+	var address string
+	err := database.DB.QueryRowContext(ctx, "SELECT address FROM users WHERE id = ?", id).Scan(&address)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	if err := CheckLegalHold(ctx, address); err != nil {
+		return err
+	}
+
+	_, err = database.DB.ExecContext(ctx, "DELETE FROM users WHERE id = ?", id)
 	return err
 }
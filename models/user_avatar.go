@@ -1,11 +1,12 @@
 package models
 
 import (
+	"context"
 	"database/sql"
 	"errors"
-	"time"
 
 	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
 )
 
 var (
@@ -15,29 +16,35 @@ var (
 
 // UserAvatar represents a user avatar
 type UserAvatar struct {
-	ID        int       `json:"id"`
-	UserID    int       `json:"user_id"`
-	FilePath  string    `json:"file_path"`
-	FileName  string    `json:"file_name"`
-	FileSize  int       `json:"file_size"`
-	MimeType  string    `json:"mime_type"`
-	Width     int       `json:"width"`
-	Height    int       `json:"height"`
-	IsActive  bool      `json:"is_active"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        int        `json:"id"`
+	UserID    int        `json:"user_id"`
+	FilePath  string     `json:"file_path"`
+	FileName  string     `json:"file_name"`
+	FileSize  int        `json:"file_size"`
+	MimeType  string     `json:"mime_type"`
+	Width     int        `json:"width"`
+	Height    int        `json:"height"`
+	IsActive  bool       `json:"is_active"`
+	CreatedAt types.Time `json:"created_at"`
 }
 
 // CreateAvatar creates a new avatar for a user
-func CreateAvatar(avatar *UserAvatar) error {
+func CreateAvatar(ctx context.Context, avatar *UserAvatar) error {
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	// If this is set as active, deactivate all other avatars for this user
 	if avatar.IsActive {
-		_, err := database.DB.Exec("UPDATE user_avatars SET is_active = FALSE WHERE user_id = ?", avatar.UserID)
+		_, err := tx.ExecContext(ctx, "UPDATE user_avatars SET is_active = FALSE WHERE user_id = ?", avatar.UserID)
 		if err != nil {
 			return err
 		}
 	}
 
-	result, err := database.DB.Exec(`
+	result, err := tx.ExecContext(ctx, `
 		INSERT INTO user_avatars (
 			user_id, file_path, file_name, file_size, 
 			mime_type, width, height, is_active
@@ -55,15 +62,18 @@ func CreateAvatar(avatar *UserAvatar) error {
 	if err != nil {
 		return err
 	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
 
 	avatar.ID = int(id)
 	return nil
 }
 
 // GetAvatarByID retrieves an avatar by ID
-func GetAvatarByID(id int) (*UserAvatar, error) {
+func GetAvatarByID(ctx context.Context, id int) (*UserAvatar, error) {
 	avatar := &UserAvatar{}
-	err := database.DB.QueryRow(`
+	err := database.DB.QueryRowContext(ctx, `
 		SELECT id, user_id, file_path, file_name, file_size, 
 		       mime_type, width, height, is_active, created_at
 		FROM user_avatars 
@@ -85,9 +95,9 @@ func GetAvatarByID(id int) (*UserAvatar, error) {
 }
 
 // GetActiveAvatarForUser retrieves the active avatar for a user
-func GetActiveAvatarForUser(userID int) (*UserAvatar, error) {
+func GetActiveAvatarForUser(ctx context.Context, userID int) (*UserAvatar, error) {
 	avatar := &UserAvatar{}
-	err := database.DB.QueryRow(`
+	err := database.DB.QueryRowContext(ctx, `
 		SELECT id, user_id, file_path, file_name, file_size, 
 		       mime_type, width, height, is_active, created_at
 		FROM user_avatars 
@@ -110,8 +120,8 @@ func GetActiveAvatarForUser(userID int) (*UserAvatar, error) {
 }
 
 // GetAllAvatarsForUser retrieves all avatars for a user
-func GetAllAvatarsForUser(userID int) ([]*UserAvatar, error) {
-	rows, err := database.DB.Query(`
+func GetAllAvatarsForUser(ctx context.Context, userID int) ([]*UserAvatar, error) {
+	rows, err := database.DB.QueryContext(ctx, `
 		SELECT id, user_id, file_path, file_name, file_size, 
 		       mime_type, width, height, is_active, created_at
 		FROM user_avatars 
@@ -146,10 +156,10 @@ func GetAllAvatarsForUser(userID int) ([]*UserAvatar, error) {
 }
 
 // SetActiveAvatar sets an avatar as active and deactivates all others
-func SetActiveAvatar(avatarID int, userID int) error {
+func SetActiveAvatar(ctx context.Context, avatarID int, userID int) error {
 	// First verify that the avatar belongs to the user
 	var count int
-	err := database.DB.QueryRow("SELECT COUNT(*) FROM user_avatars WHERE id = ? AND user_id = ?", avatarID, userID).Scan(&count)
+	err := database.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM user_avatars WHERE id = ? AND user_id = ?", avatarID, userID).Scan(&count)
 	if err != nil {
 		return err
 	}
@@ -157,22 +167,32 @@ func SetActiveAvatar(avatarID int, userID int) error {
 		return ErrAvatarNotFound
 	}
 
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	// Deactivate all avatars for this user
-	_, err = database.DB.Exec("UPDATE user_avatars SET is_active = FALSE WHERE user_id = ?", userID)
+	_, err = tx.ExecContext(ctx, "UPDATE user_avatars SET is_active = FALSE WHERE user_id = ?", userID)
 	if err != nil {
 		return err
 	}
 
 	// Set the specified avatar as active
-	_, err = database.DB.Exec("UPDATE user_avatars SET is_active = TRUE WHERE id = ?", avatarID)
-	return err
+	_, err = tx.ExecContext(ctx, "UPDATE user_avatars SET is_active = TRUE WHERE id = ?", avatarID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // DeleteAvatar deletes an avatar
-func DeleteAvatar(avatarID int, userID int) error {
+func DeleteAvatar(ctx context.Context, avatarID int, userID int) error {
 	// First verify that the avatar belongs to the user
 	var isActive bool
-	err := database.DB.QueryRow("SELECT is_active FROM user_avatars WHERE id = ? AND user_id = ?", avatarID, userID).Scan(&isActive)
+	err := database.DB.QueryRowContext(ctx, "SELECT is_active FROM user_avatars WHERE id = ? AND user_id = ?", avatarID, userID).Scan(&isActive)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return ErrAvatarNotFound
@@ -180,15 +200,21 @@ func DeleteAvatar(avatarID int, userID int) error {
 		return err
 	}
 
+	tx, err := database.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	// Delete the avatar
-	_, err = database.DB.Exec("DELETE FROM user_avatars WHERE id = ?", avatarID)
+	_, err = tx.ExecContext(ctx, "DELETE FROM user_avatars WHERE id = ?", avatarID)
 	if err != nil {
 		return err
 	}
 
 	// If this was the active avatar, set the most recent one as active
 	if isActive {
-		_, err = database.DB.Exec(`
+		_, err = tx.ExecContext(ctx, `
 			UPDATE user_avatars 
 			SET is_active = TRUE 
 			WHERE user_id = ? 
@@ -200,5 +226,5 @@ func DeleteAvatar(avatarID int, userID int) error {
 		}
 	}
 
-	return nil
+	return tx.Commit()
 }
@@ -1,11 +1,12 @@
 package models
 
 import (
+	"context"
 	"database/sql"
 	"errors"
-	"time"
 
 	"github.com/piko/piko/database"
+	"github.com/piko/piko/types"
 )
 
 var (
@@ -47,14 +48,14 @@ type UserSettings struct {
 	PrivacyLastSeen     PrivacyType `json:"privacy_last_seen"`
 	PrivacyProfilePhoto PrivacyType `json:"privacy_profile_photo"`
 	PrivacyStatus       PrivacyType `json:"privacy_status"`
-	CreatedAt           time.Time   `json:"created_at"`
-	UpdatedAt           time.Time   `json:"updated_at"`
+	CreatedAt           types.Time  `json:"created_at"`
+	UpdatedAt           types.Time  `json:"updated_at"`
 }
 
 // GetUserSettings retrieves settings for a user
-func GetUserSettings(userID int) (*UserSettings, error) {
+func GetUserSettings(ctx context.Context, userID int) (*UserSettings, error) {
 	settings := &UserSettings{}
-	err := database.DB.QueryRow(`
+	err := database.DB.QueryRowContext(ctx, `
 		SELECT user_id, nickname, theme, notification_enabled, sound_enabled, 
 		       language, auto_download_media, privacy_last_seen, 
 		       privacy_profile_photo, privacy_status, created_at, updated_at 
@@ -78,9 +79,9 @@ func GetUserSettings(userID int) (*UserSettings, error) {
 }
 
 // CreateDefaultSettings creates default settings for a user
-func CreateDefaultSettings(userID int) (*UserSettings, error) {
+func CreateDefaultSettings(ctx context.Context, userID int) (*UserSettings, error) {
 	// Check if settings already exist
-	_, err := GetUserSettings(userID)
+	_, err := GetUserSettings(ctx, userID)
 	if err == nil {
 		return nil, errors.New("settings already exist for this user")
 	}
@@ -101,7 +102,7 @@ func CreateDefaultSettings(userID int) (*UserSettings, error) {
 		PrivacyStatus:       PrivacyEveryone,
 	}
 
-	_, err = database.DB.Exec(`
+	_, err = database.DB.ExecContext(ctx, `
 		INSERT INTO user_settings (
 			user_id, nickname, theme, notification_enabled, sound_enabled,
 			language, auto_download_media, privacy_last_seen,
@@ -121,8 +122,8 @@ func CreateDefaultSettings(userID int) (*UserSettings, error) {
 }
 
 // UpdateUserSettings updates settings for a user
-func UpdateUserSettings(settings *UserSettings) error {
-	_, err := database.DB.Exec(`
+func UpdateUserSettings(ctx context.Context, settings *UserSettings) error {
+	_, err := database.DB.ExecContext(ctx, `
 		UPDATE user_settings SET
 			nickname = ?,
 			theme = ?,
@@ -145,10 +146,36 @@ func UpdateUserSettings(settings *UserSettings) error {
 }
 
 // UpdateNickname updates only the nickname for a user
-func UpdateNickname(userID int, nickname string) error {
-	_, err := database.DB.Exec(
+func UpdateNickname(ctx context.Context, userID int, nickname string) error {
+	_, err := database.DB.ExecContext(ctx,
 		"UPDATE user_settings SET nickname = ? WHERE user_id = ?",
 		nickname, userID,
 	)
 	return err
 }
+
+// SetAutoDownloadMedia sets whether media is downloaded automatically for a user
+func SetAutoDownloadMedia(ctx context.Context, userID int, enabled bool) error {
+	_, err := database.DB.ExecContext(ctx,
+		"UPDATE user_settings SET auto_download_media = ? WHERE user_id = ?",
+		enabled, userID,
+	)
+	return err
+}
+
+// GetUserLanguage retrieves the language a user picked in their settings,
+// returning ErrSettingsNotFound if they never saved any
+func GetUserLanguage(ctx context.Context, address string) (string, error) {
+	var language string
+	err := database.DB.QueryRowContext(ctx,
+		"SELECT s.language FROM user_settings s JOIN users u ON u.id = s.user_id WHERE u.address = ?",
+		address,
+	).Scan(&language)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", ErrSettingsNotFound
+		}
+		return "", err
+	}
+	return language, nil
+}
@@ -0,0 +1,141 @@
+package notifications
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/piko/piko/config"
+)
+
+const (
+	apnsProductionURL  = "https://api.push.apple.com/3/device/"
+	apnsDevelopmentURL = "https://api.sandbox.push.apple.com/3/device/"
+
+	// apnsTokenLifetime is how long a provider token is reused. Apple
+	// rejects tokens older than an hour.
+	apnsTokenLifetime = 50 * time.Minute
+)
+
+// APNsPusher sends notifications through Apple Push Notification service
+// using token-based authentication
+type APNsPusher struct {
+	cfg     config.APNsConfig
+	key     interface{}
+	baseURL string
+	client  *http.Client
+
+	mu       sync.Mutex
+	jwtToken string
+	issuedAt time.Time
+}
+
+// NewAPNsPusher creates an APNs pusher from a .p8 signing key
+func NewAPNsPusher(cfg config.APNsConfig) (*APNsPusher, error) {
+	if cfg.KeyID == "" || cfg.TeamID == "" || cfg.Topic == "" {
+		return nil, errors.New("keyId, teamId and topic are required")
+	}
+
+	data, err := os.ReadFile(cfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	key, err := jwt.ParseECPrivateKeyFromPEM(data)
+	if err != nil {
+		return nil, err
+	}
+
+	baseURL := apnsDevelopmentURL
+	if cfg.Production {
+		baseURL = apnsProductionURL
+	}
+
+	return &APNsPusher{
+		cfg:     cfg,
+		key:     key,
+		baseURL: baseURL,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Push sends a notification to an APNs device token
+func (p *APNsPusher) Push(token string, notification *Notification) error {
+	authToken, err := p.token()
+	if err != nil {
+		return err
+	}
+
+	payload := map[string]interface{}{
+		"aps": map[string]interface{}{
+			"alert": map[string]string{
+				"title": notification.Title,
+				"body":  notification.Body,
+			},
+			"sound": "default",
+		},
+	}
+	for key, value := range notification.Data {
+		payload[key] = value
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.baseURL+token, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "bearer "+authToken)
+	req.Header.Set("apns-topic", p.cfg.Topic)
+	req.Header.Set("apns-push-type", "alert")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusBadRequest, http.StatusGone:
+		// BadDeviceToken or Unregistered
+		return ErrInvalidToken
+	default:
+		return fmt.Errorf("apns returned status %d", resp.StatusCode)
+	}
+}
+
+// token returns a cached provider token, signing a new one when needed
+func (p *APNsPusher) token() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.jwtToken != "" && time.Since(p.issuedAt) < apnsTokenLifetime {
+		return p.jwtToken, nil
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss": p.cfg.TeamID,
+		"iat": now.Unix(),
+	})
+	token.Header["kid"] = p.cfg.KeyID
+
+	signed, err := token.SignedString(p.key)
+	if err != nil {
+		return "", err
+	}
+
+	p.jwtToken = signed
+	p.issuedAt = now
+	return signed, nil
+}
@@ -0,0 +1,115 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/piko/piko/config"
+	"github.com/piko/piko/models"
+)
+
+var (
+	// ErrInvalidToken is returned by a pusher when the device token is no
+	// longer valid and should be forgotten
+	ErrInvalidToken = errors.New("invalid device token")
+)
+
+// Notification is a push notification sent to a device. Message content is
+// end-to-end encrypted, so only metadata is ever pushed.
+type Notification struct {
+	Title string
+	Body  string
+	Data  map[string]string
+}
+
+// Pusher delivers notifications through one push provider
+type Pusher interface {
+	Push(token string, notification *Notification) error
+}
+
+// Service routes notifications to the right pusher for each device
+type Service struct {
+	pushers map[models.DevicePlatform]Pusher
+}
+
+// NewService creates a notification service from configuration. Providers
+// that are disabled or fail to load are skipped.
+func NewService(cfg config.NotificationsConfig) *Service {
+	service := &Service{pushers: map[models.DevicePlatform]Pusher{}}
+
+	if cfg.FCM.Enabled {
+		pusher, err := NewFCMPusher(cfg.FCM)
+		if err != nil {
+			log.Printf("FCM push disabled: %v", err)
+		} else {
+			service.pushers[models.DevicePlatformFCM] = pusher
+		}
+	}
+
+	if cfg.APNs.Enabled {
+		pusher, err := NewAPNsPusher(cfg.APNs)
+		if err != nil {
+			log.Printf("APNs push disabled: %v", err)
+		} else {
+			service.pushers[models.DevicePlatformAPNs] = pusher
+		}
+	}
+
+	return service
+}
+
+// SetPusher registers a pusher for a platform, replacing any existing one
+func (s *Service) SetPusher(platform models.DevicePlatform, pusher Pusher) {
+	s.pushers[platform] = pusher
+}
+
+// NotifyUser pushes a notification to every registered device of a user
+func (s *Service) NotifyUser(address string, notification *Notification) {
+	if len(s.pushers) == 0 {
+		return
+	}
+
+	devices, err := models.GetUserDevices(context.Background(), address)
+	if err != nil {
+		log.Printf("Error loading devices for %s: %v", address, err)
+		return
+	}
+	s.pushToDevices(devices, notification)
+}
+
+// NotifySession pushes a notification to the devices registered from one session
+func (s *Service) NotifySession(sessionID string, notification *Notification) {
+	if len(s.pushers) == 0 {
+		return
+	}
+
+	devices, err := models.GetSessionDevices(context.Background(), sessionID)
+	if err != nil {
+		log.Printf("Error loading devices for session %s: %v", sessionID, err)
+		return
+	}
+	s.pushToDevices(devices, notification)
+}
+
+// pushToDevices pushes a notification to each device, forgetting tokens the
+// provider no longer accepts
+func (s *Service) pushToDevices(devices []*models.Device, notification *Notification) {
+	for _, device := range devices {
+		pusher, ok := s.pushers[device.Platform]
+		if !ok {
+			continue
+		}
+
+		err := pusher.Push(device.Token, notification)
+		if errors.Is(err, ErrInvalidToken) {
+			if err := models.DeleteDeviceToken(context.Background(), device.Token); err != nil {
+				log.Printf("Error removing invalid device token: %v", err)
+			}
+			continue
+		}
+		if err != nil {
+			log.Printf("Error pushing to %s device of %s: %v", device.Platform, device.UserAddress, err)
+		}
+	}
+}
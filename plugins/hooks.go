@@ -0,0 +1,131 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/piko/piko/models"
+)
+
+// Rejection is returned by a hook to refuse an action. Its reason is shown
+// to the user.
+type Rejection struct {
+	Plugin string
+	Reason string
+}
+
+// Error returns the reason for the rejection
+func (r *Rejection) Error() string {
+	return r.Reason
+}
+
+// Reject refuses the action a hook was called for
+func Reject(reason string) error {
+	return &Rejection{Reason: reason}
+}
+
+// ConversationKind is the kind of conversation a hook was called for
+type ConversationKind string
+
+const (
+	// ConversationDirect is a conversation between two users
+	ConversationDirect ConversationKind = "direct"
+	// ConversationChannel is a channel
+	ConversationChannel ConversationKind = "channel"
+	// ConversationGroup is a group
+	ConversationGroup ConversationKind = "group"
+)
+
+// Message is a message about to be stored
+type Message struct {
+	Kind          ConversationKind
+	ID            string
+	SenderAddress string
+	// ConversationID is the recipient's address for direct messages and the
+	// channel or group ID otherwise
+	ConversationID string
+	// Content is the message body as the client sent it. It's ciphertext
+	// unless the operator knows the conversation to be plaintext. Hooks
+	// may replace it.
+	Content []byte
+}
+
+// MessageHook is called before a message is stored. Returning an error
+// stops the message from being sent.
+type MessageHook interface {
+	BeforeMessagePersist(ctx context.Context, message *Message) error
+}
+
+// MemberJoin is a user about to join a channel or group
+type MemberJoin struct {
+	Kind           ConversationKind
+	ConversationID string
+	UserAddress    string
+	// AddedBy is the admin adding the user, empty when they join by link
+	// or invite
+	AddedBy string
+}
+
+// MemberJoinHook is called before a user joins a channel or group.
+// Returning an error stops them from joining.
+type MemberJoinHook interface {
+	BeforeMemberJoin(ctx context.Context, join *MemberJoin) error
+}
+
+// BlockCommitHook is called after a block and its transactions are stored
+type BlockCommitHook interface {
+	AfterBlockCommit(ctx context.Context, block *models.Block)
+}
+
+// BeforeMessagePersist runs the message hooks of the enabled plugins,
+// stopping at the first error
+func BeforeMessagePersist(ctx context.Context, message *Message) error {
+	for _, plugin := range snapshot() {
+		if hook, ok := plugin.(MessageHook); ok {
+			if err := hook.BeforeMessagePersist(ctx, message); err != nil {
+				return attribute(plugin, err)
+			}
+		}
+	}
+	return nil
+}
+
+// BeforeMemberJoin runs the member join hooks of the enabled plugins,
+// stopping at the first error
+func BeforeMemberJoin(ctx context.Context, join *MemberJoin) error {
+	for _, plugin := range snapshot() {
+		if hook, ok := plugin.(MemberJoinHook); ok {
+			if err := hook.BeforeMemberJoin(ctx, join); err != nil {
+				return attribute(plugin, err)
+			}
+		}
+	}
+	return nil
+}
+
+// AfterBlockCommit runs the block commit hooks of the enabled plugins. A
+// panicking hook is logged so it can't stop block creation.
+func AfterBlockCommit(ctx context.Context, block *models.Block) {
+	for _, plugin := range snapshot() {
+		if hook, ok := plugin.(BlockCommitHook); ok {
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						log.Printf("Plugin %s panicked after block %d: %v", plugin.Name(), block.Height, r)
+					}
+				}()
+				hook.AfterBlockCommit(ctx, block)
+			}()
+		}
+	}
+}
+
+// attribute records which plugin rejected an action
+func attribute(plugin Plugin, err error) error {
+	var rejection *Rejection
+	if errors.As(err, &rejection) && rejection.Plugin == "" {
+		rejection.Plugin = plugin.Name()
+	}
+	return err
+}
@@ -0,0 +1,115 @@
+// Package plugins lets operators add policy to messaging and the chain
+// without forking the handlers. Plugins are compiled into the server: each
+// registers a factory from an init function, and the ones listed under
+// "plugins" in config.json are created at startup and run in that order.
+package plugins
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/piko/piko/config"
+)
+
+// Plugin is implemented by every plugin. A plugin takes part in a hook by
+// also implementing its interface, such as MessageHook.
+type Plugin interface {
+	Name() string
+}
+
+// Factory creates a plugin from its settings in config.json
+type Factory func(settings map[string]interface{}) (Plugin, error)
+
+var (
+	mu        sync.RWMutex
+	factories = map[string]Factory{}
+	enabled   []Plugin
+)
+
+// Register makes a plugin available to enable in config.json. It panics
+// when the name is already taken, so call it from an init function.
+func Register(name string, factory Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := factories[name]; ok {
+		panic(fmt.Sprintf("plugins: %s registered twice", name))
+	}
+	factories[name] = factory
+}
+
+// Available returns the names of the registered plugins, sorted
+func Available() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Init creates the plugins enabled in the configuration, replacing any
+// enabled before. Nothing is enabled if one of them fails.
+func Init(cfgs []config.PluginConfig) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	created := make([]Plugin, 0, len(cfgs))
+	seen := map[string]bool{}
+	for _, cfg := range cfgs {
+		factory, ok := factories[cfg.Name]
+		if !ok {
+			return fmt.Errorf("unknown plugin %q", cfg.Name)
+		}
+		if seen[cfg.Name] {
+			return fmt.Errorf("plugin %q is enabled twice", cfg.Name)
+		}
+		seen[cfg.Name] = true
+
+		plugin, err := factory(cfg.Settings)
+		if err != nil {
+			return fmt.Errorf("plugin %q: %w", cfg.Name, err)
+		}
+		created = append(created, plugin)
+	}
+	enabled = created
+	return nil
+}
+
+// Enabled returns the names of the enabled plugins in the order they run
+func Enabled() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, len(enabled))
+	for i, plugin := range enabled {
+		names[i] = plugin.Name()
+	}
+	return names
+}
+
+// DecodeSettings decodes a plugin's settings into v, which should point to
+// a struct with json tags. Unknown settings are an error so typos are
+// caught at startup.
+func DecodeSettings(settings map[string]interface{}, v interface{}) error {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("invalid settings: %w", err)
+	}
+	return nil
+}
+
+// snapshot returns the enabled plugins for a hook to run through
+func snapshot() []Plugin {
+	mu.RLock()
+	defer mu.RUnlock()
+	return enabled
+}
@@ -0,0 +1,81 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode"
+)
+
+func init() {
+	Register("wordfilter", newWordFilter)
+}
+
+// wordFilterSettings configures the word filter. Messages are end-to-end
+// encrypted unless clients agree otherwise, so it only applies to the
+// channels and groups listed, which the operator knows to be plaintext.
+type wordFilterSettings struct {
+	Words    []string `json:"words"`
+	Channels []string `json:"channels"`
+	Groups   []string `json:"groups"`
+}
+
+// wordFilter rejects messages containing blocked words
+type wordFilter struct {
+	words    map[string]bool
+	channels map[string]bool
+	groups   map[string]bool
+}
+
+// newWordFilter creates the word filter from its settings
+func newWordFilter(settings map[string]interface{}) (Plugin, error) {
+	var s wordFilterSettings
+	if err := DecodeSettings(settings, &s); err != nil {
+		return nil, err
+	}
+	if len(s.Words) == 0 {
+		return nil, errors.New("no words to filter")
+	}
+
+	f := &wordFilter{
+		words:    map[string]bool{},
+		channels: map[string]bool{},
+		groups:   map[string]bool{},
+	}
+	for _, word := range s.Words {
+		f.words[strings.ToLower(word)] = true
+	}
+	for _, id := range s.Channels {
+		f.channels[id] = true
+	}
+	for _, id := range s.Groups {
+		f.groups[id] = true
+	}
+	return f, nil
+}
+
+// Name returns the plugin's name
+func (f *wordFilter) Name() string {
+	return "wordfilter"
+}
+
+// BeforeMessagePersist rejects messages in filtered conversations that
+// contain a blocked word, ignoring case
+func (f *wordFilter) BeforeMessagePersist(ctx context.Context, message *Message) error {
+	switch {
+	case message.Kind == ConversationChannel && f.channels[message.ConversationID]:
+	case message.Kind == ConversationGroup && f.groups[message.ConversationID]:
+	default:
+		return nil
+	}
+
+	words := strings.FieldsFunc(string(message.Content), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for _, word := range words {
+		if f.words[strings.ToLower(word)] {
+			return Reject("Message contains a blocked word")
+		}
+	}
+	return nil
+}
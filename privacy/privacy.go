@@ -0,0 +1,126 @@
+// Package privacy decides who can see the information users protect with
+// their privacy settings: when they were last seen, their profile photo and
+// whether they are online. Each setting lets everyone see the information,
+// only the user's contacts, or nobody. Users they blocked never see it.
+package privacy
+
+import (
+	"context"
+	"errors"
+
+	"github.com/piko/piko/models"
+)
+
+// Field is a piece of information a privacy setting protects
+type Field string
+
+const (
+	// LastSeen is when the user last used the app
+	LastSeen Field = "last_seen"
+	// ProfilePhoto is the user's active avatar
+	ProfilePhoto Field = "profile_photo"
+	// Status is whether the user is online, as sent in presence events
+	Status Field = "status"
+)
+
+// Policy holds a user's privacy settings
+type Policy struct {
+	OwnerAddress string
+	settings     map[Field]models.PrivacyType
+}
+
+// For loads the privacy settings of a user. Users who never saved settings
+// have the defaults, which show everything to everyone.
+func For(ctx context.Context, owner *models.User) (*Policy, error) {
+	policy := &Policy{
+		OwnerAddress: owner.Address,
+		settings: map[Field]models.PrivacyType{
+			LastSeen:     models.PrivacyEveryone,
+			ProfilePhoto: models.PrivacyEveryone,
+			Status:       models.PrivacyEveryone,
+		},
+	}
+
+	settings, err := models.GetUserSettings(ctx, owner.ID)
+	if errors.Is(err, models.ErrSettingsNotFound) {
+		return policy, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	policy.settings[LastSeen] = settings.PrivacyLastSeen
+	policy.settings[ProfilePhoto] = settings.PrivacyProfilePhoto
+	policy.settings[Status] = settings.PrivacyStatus
+	return policy, nil
+}
+
+// ForAddress loads the privacy settings of the user with an address
+func ForAddress(ctx context.Context, address string) (*Policy, error) {
+	owner, err := models.GetUserByAddress(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	return For(ctx, owner)
+}
+
+// Setting returns who the owner lets see a field
+func (p *Policy) Setting(field Field) models.PrivacyType {
+	if setting, ok := p.settings[field]; ok {
+		return setting
+	}
+	return models.PrivacyNobody
+}
+
+// Allows reports whether a viewer may see a field. Owners always see their
+// own information.
+func (p *Policy) Allows(ctx context.Context, field Field, viewerAddress string) (bool, error) {
+	return models.PrivacyAllows(ctx, p.Setting(field), p.OwnerAddress, viewerAddress)
+}
+
+// Audience returns everyone who may see a field, for sending it to many
+// viewers without checking each one
+func (p *Policy) Audience(ctx context.Context, field Field) (Audience, error) {
+	audience := Audience{Owner: p.OwnerAddress}
+	switch p.Setting(field) {
+	case models.PrivacyEveryone:
+		blocked, err := models.GetBlockedAddresses(ctx, p.OwnerAddress)
+		if err != nil {
+			return Audience{}, err
+		}
+		audience.Everyone = true
+		audience.Except = blocked
+	case models.PrivacyContacts:
+		contacts, err := models.GetContactAddresses(ctx, p.OwnerAddress)
+		if err != nil {
+			return Audience{}, err
+		}
+		audience.Only = contacts
+	}
+	return audience, nil
+}
+
+// Audience is who may see a field: everyone except the users in Except, or
+// only the users in Only. The zero Audience is nobody but the owner.
+type Audience struct {
+	Owner    string
+	Everyone bool
+	Except   []string
+	Only     []string
+}
+
+// Includes reports whether a user is in the audience
+func (a Audience) Includes(address string) bool {
+	if address == a.Owner {
+		return true
+	}
+	listed := a.Only
+	if a.Everyone {
+		listed = a.Except
+	}
+	for _, listedAddress := range listed {
+		if listedAddress == address {
+			return !a.Everyone
+		}
+	}
+	return a.Everyone
+}
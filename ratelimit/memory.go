@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/piko/piko/config"
+)
+
+// sweepEvery is how many checks pass between sweeps of idle buckets
+const sweepEvery = 1024
+
+// bucket is the state of one key's token bucket
+type bucket struct {
+	tokens   float64
+	updated  time.Time
+	interval time.Duration
+}
+
+// MemoryLimiter keeps token buckets in process memory. Limits are per
+// instance, so use the Redis backend when running more than one.
+type MemoryLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	checks  int
+}
+
+// NewMemoryLimiter creates an in-memory rate limiter
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{buckets: make(map[string]*bucket)}
+}
+
+// Allow takes a token from key's bucket if one is available
+func (l *MemoryLimiter) Allow(key string, rule config.RateLimitRule) (Result, error) {
+	if rule.Requests <= 0 || rule.Interval <= 0 {
+		return Result{Allowed: true}, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	capacity := float64(rule.Requests)
+	rate := capacity / float64(rule.Interval)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, updated: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(capacity, b.tokens+float64(now.Sub(b.updated))*rate)
+	b.updated = now
+	b.interval = rule.Interval
+
+	l.checks++
+	if l.checks%sweepEvery == 0 {
+		l.sweep(now)
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return Result{Allowed: true, Remaining: int(b.tokens)}, nil
+	}
+	return Result{RetryAfter: time.Duration(math.Ceil((1 - b.tokens) / rate))}, nil
+}
+
+// sweep forgets buckets that have been idle long enough to be full again.
+// Callers hold l.mu.
+func (l *MemoryLimiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= b.interval {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package ratelimit
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/piko/piko/config"
+	"github.com/piko/piko/redis"
+)
+
+// Result is the outcome of a rate limit check
+type Result struct {
+	Allowed bool
+	// RetryAfter is how long to wait before the next request can succeed
+	RetryAfter time.Duration
+	// Remaining is how many more requests would be allowed right now
+	Remaining int
+}
+
+// Limiter is a token bucket rate limiter. Each key has a bucket holding up
+// to rule.Requests tokens that refills completely over rule.Interval.
+type Limiter interface {
+	Allow(key string, rule config.RateLimitRule) (Result, error)
+}
+
+// New creates the rate limiter selected in configuration
+func New(cfg config.RateLimitConfig, redisCfg config.RedisConfig) (Limiter, error) {
+	switch cfg.Backend {
+	case "", "memory":
+		return NewMemoryLimiter(), nil
+	case "redis":
+		return NewRedisLimiter(redis.NewClient(redisCfg)), nil
+	default:
+		return nil, fmt.Errorf("unsupported rate limit backend: %s", cfg.Backend)
+	}
+}
@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/piko/piko/config"
+	"github.com/piko/piko/redis"
+)
+
+// keyPrefix namespaces rate limit buckets in Redis
+const keyPrefix = "piko:ratelimit:"
+
+// tokenBucketScript refills and takes from a bucket atomically. It returns
+// {allowed, retry_after_ms, remaining}.
+const tokenBucketScript = `
+local capacity = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or capacity
+local ts = tonumber(state[2]) or now
+local rate = capacity / interval
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+local allowed = 0
+local wait = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	wait = math.ceil((1 - tokens) / rate)
+end
+redis.call('HMSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], interval)
+return {allowed, wait, math.floor(tokens)}
+`
+
+// RedisLimiter keeps token buckets in Redis so limits are shared by every
+// instance
+type RedisLimiter struct {
+	client *redis.Client
+}
+
+// NewRedisLimiter creates a rate limiter backed by Redis
+func NewRedisLimiter(client *redis.Client) *RedisLimiter {
+	return &RedisLimiter{client: client}
+}
+
+// Allow takes a token from key's bucket if one is available
+func (l *RedisLimiter) Allow(key string, rule config.RateLimitRule) (Result, error) {
+	if rule.Requests <= 0 || rule.Interval <= 0 {
+		return Result{Allowed: true}, nil
+	}
+
+	reply, err := l.client.Do(
+		"EVAL", tokenBucketScript, "1", keyPrefix+key,
+		strconv.Itoa(rule.Requests),
+		strconv.FormatInt(rule.Interval.Milliseconds(), 10),
+		strconv.FormatInt(time.Now().UnixMilli(), 10),
+	)
+	if err != nil {
+		return Result{}, err
+	}
+
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != 3 {
+		return Result{}, redis.ErrUnexpectedReply
+	}
+	allowed, _ := values[0].(int64)
+	wait, _ := values[1].(int64)
+	remaining, _ := values[2].(int64)
+
+	return Result{
+		Allowed:    allowed == 1,
+		RetryAfter: time.Duration(wait) * time.Millisecond,
+		Remaining:  int(remaining),
+	}, nil
+}
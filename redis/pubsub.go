@@ -0,0 +1,129 @@
+package redis
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/piko/piko/config"
+)
+
+// resubscribeDelay is how long a subscriber waits before reconnecting after
+// losing its connection
+const resubscribeDelay = time.Second
+
+// Publish sends a message to every subscriber of a channel
+func (c *Client) Publish(channel, message string) error {
+	_, err := c.Do("PUBLISH", channel, message)
+	return err
+}
+
+// Subscriber receives the messages published to a channel over its own
+// connection, since a subscribed connection can't run other commands. It
+// reconnects after any error until it is closed; messages published while
+// it is reconnecting are lost.
+type Subscriber struct {
+	client  *Client
+	channel string
+	handle  func(message string)
+
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// Subscribe starts passing the messages published to channel to handle, one
+// at a time
+func Subscribe(cfg config.RedisConfig, channel string, handle func(message string)) *Subscriber {
+	s := &Subscriber{
+		client:  NewClient(cfg),
+		channel: channel,
+		handle:  handle,
+		done:    make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Close stops the subscriber
+func (s *Subscriber) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+	return s.client.Close()
+}
+
+// run receives messages, reconnecting until the subscriber is closed
+func (s *Subscriber) run() {
+	for {
+		err := s.receive()
+		select {
+		case <-s.done:
+			return
+		default:
+		}
+		log.Printf("Redis subscription to %s lost, reconnecting: %v", s.channel, err)
+
+		select {
+		case <-s.done:
+			return
+		case <-time.After(resubscribeDelay):
+		}
+	}
+}
+
+// receive subscribes on a new connection and handles messages until the
+// connection fails
+func (s *Subscriber) receive() error {
+	c := s.client
+	c.mu.Lock()
+	c.closeConn()
+	// Close closes done before the connection, so checking here under
+	// c.mu means a connection made after Close is never left open
+	select {
+	case <-s.done:
+		c.mu.Unlock()
+		return nil
+	default:
+	}
+	if err := c.connect(); err != nil {
+		c.mu.Unlock()
+		return err
+	}
+	reply, err := c.roundTrip([]string{"SUBSCRIBE", s.channel})
+	if err == nil {
+		if e, ok := reply.(Error); ok {
+			err = e
+		}
+	}
+	if err != nil {
+		c.closeConn()
+		c.mu.Unlock()
+		return fmt.Errorf("redis SUBSCRIBE failed: %w", err)
+	}
+	conn, reader := c.conn, c.reader
+	c.mu.Unlock()
+
+	// Messages arrive whenever something is published, so reads can't
+	// time out
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		return err
+	}
+	for {
+		reply, err := readReply(reader)
+		if err != nil {
+			return err
+		}
+		// Pushed messages are ["message", channel, payload]
+		items, ok := reply.([]interface{})
+		if !ok || len(items) != 3 {
+			continue
+		}
+		if kind, _ := items[0].(string); kind != "message" {
+			continue
+		}
+		if message, ok := items[2].(string); ok {
+			s.handle(message)
+		}
+	}
+}
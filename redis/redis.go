@@ -0,0 +1,219 @@
+package redis
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/piko/piko/config"
+)
+
+var (
+	// ErrUnexpectedReply is returned when the server sends something that
+	// isn't valid RESP
+	ErrUnexpectedReply = errors.New("unexpected redis reply")
+)
+
+// Error is an error reply sent by the server
+type Error string
+
+func (e Error) Error() string {
+	return string(e)
+}
+
+// Client is a minimal Redis client speaking RESP over a single connection.
+// Commands are serialized; the connection is re-dialled after any I/O error.
+type Client struct {
+	addr     string
+	password string
+	db       int
+	timeout  time.Duration
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// NewClient creates a client for the configured server. No connection is
+// made until the first command.
+func NewClient(cfg config.RedisConfig) *Client {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &Client{
+		addr:     cfg.Addr,
+		password: cfg.Password,
+		db:       cfg.DB,
+		timeout:  timeout,
+	}
+}
+
+// Do sends a command and returns its reply, which is a string, int64, nil,
+// []interface{} or Error
+func (c *Client) Do(args ...string) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		if err := c.connect(); err != nil {
+			return nil, err
+		}
+	}
+
+	reply, err := c.roundTrip(args)
+	if err != nil {
+		c.closeConn()
+		return nil, err
+	}
+	if e, ok := reply.(Error); ok {
+		return nil, e
+	}
+	return reply, nil
+}
+
+// Close closes the underlying connection
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closeConn()
+}
+
+// connect dials the server and authenticates. Callers hold c.mu.
+func (c *Client) connect() error {
+	conn, err := net.DialTimeout("tcp", c.addr, c.timeout)
+	if err != nil {
+		return fmt.Errorf("failed to connect to redis: %w", err)
+	}
+	c.conn = conn
+	c.reader = bufio.NewReader(conn)
+
+	if c.password != "" {
+		if err := c.handshake("AUTH", c.password); err != nil {
+			return err
+		}
+	}
+	if c.db != 0 {
+		if err := c.handshake("SELECT", strconv.Itoa(c.db)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handshake runs a setup command and drops the connection if it fails
+func (c *Client) handshake(args ...string) error {
+	reply, err := c.roundTrip(args)
+	if err == nil {
+		if e, ok := reply.(Error); ok {
+			err = e
+		}
+	}
+	if err != nil {
+		c.closeConn()
+		return fmt.Errorf("redis %s failed: %w", args[0], err)
+	}
+	return nil
+}
+
+// closeConn drops the connection. Callers hold c.mu.
+func (c *Client) closeConn() error {
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	c.reader = nil
+	return err
+}
+
+// roundTrip writes one command and reads its reply. Callers hold c.mu.
+func (c *Client) roundTrip(args []string) (interface{}, error) {
+	if err := c.conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, '\r', '\n')
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, arg...)
+		buf = append(buf, '\r', '\n')
+	}
+	if _, err := c.conn.Write(buf); err != nil {
+		return nil, err
+	}
+
+	return readReply(c.reader)
+}
+
+// readReply parses one RESP reply
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, ErrUnexpectedReply
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return Error(line[1:]), nil
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, ErrUnexpectedReply
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		return string(data[:size]), nil
+	case '*':
+		count, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, ErrUnexpectedReply
+		}
+		if count < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, count)
+		for i := range items {
+			if items[i], err = readReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	default:
+		return nil, ErrUnexpectedReply
+	}
+}
+
+// readLine reads a CRLF-terminated line without the terminator
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", ErrUnexpectedReply
+	}
+	return line[:len(line)-2], nil
+}
@@ -0,0 +1,3575 @@
+// Code generated by sdk-gen. DO NOT EDIT.
+
+package pikosdk
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// WebSocket event types
+const (
+	// EventNewMessage: A direct message was received
+	EventNewMessage = "new_message"
+	// EventMessageStatus: A message's delivery status changed
+	EventMessageStatus = "message_status"
+	// EventNewChannelMessage: A message was posted to a channel
+	EventNewChannelMessage = "new_channel_message"
+	// EventNewGroupMessage: A message was posted to a group
+	EventNewGroupMessage = "new_group_message"
+	// EventMessageEdited: A message was edited by its sender
+	EventMessageEdited = "message_edited"
+	// EventMessageExpired: Direct messages passed their expiration time and were deleted
+	EventMessageExpired = "message_expired"
+	// EventRemoteWipe: This session was wiped from another device
+	EventRemoteWipe = "remote_wipe"
+	// EventSafetyNumberChanged: A contact's key changed
+	EventSafetyNumberChanged = "safety_number_changed"
+	// EventPrekeysLow: You are running out of one-time prekeys
+	EventPrekeysLow = "prekeys_low"
+	// EventGroupEventReminder: A group event you haven't declined starts soon
+	EventGroupEventReminder = "group_event_reminder"
+	// EventPollUpdated: A poll was created in one of your groups or channels, got or lost a vote, or was closed
+	EventPollUpdated = "poll_updated"
+	// EventReconnectSoon: The server is shutting down; reconnect shortly
+	EventReconnectSoon = "reconnect_soon"
+	// EventTyping: A user is typing
+	EventTyping = "typing"
+	// EventPresence: A user came online or went offline
+	EventPresence = "presence"
+	// EventInboxPage: A page of prefetched inbox messages
+	EventInboxPage = "inbox_page"
+	// EventInboxAck: The client acknowledged a prefetched page
+	EventInboxAck = "inbox_ack"
+	// EventInboxDone: Inbox prefetch finished
+	EventInboxDone = "inbox_done"
+	// EventGroupReceived: A group member acknowledged a group message
+	EventGroupReceived = "group_received"
+	// EventGroupRead: A group member read up to a message
+	EventGroupRead = "group_read"
+	// EventChannelRead: A channel member read up to your message
+	EventChannelRead = "channel_read"
+)
+
+// AcceptPoliciesRequest is the AcceptPoliciesRequest object of the Piko API
+type AcceptPoliciesRequest struct {
+	PolicyIDs []int `json:"policy_ids"`
+}
+
+// AccountSuspension is the AccountSuspension object of the Piko API
+type AccountSuspension struct {
+	UserAddress string    `json:"user_address"`
+	Reason      string    `json:"reason"`
+	SuspendedBy string    `json:"suspended_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// AckChannelMessagesRequest is the AckChannelMessagesRequest object of the Piko API
+type AckChannelMessagesRequest struct {
+	MessageIDs []string `json:"message_ids"`
+}
+
+// AckChannelMessagesResponse is the AckChannelMessagesResponse object of the Piko API
+type AckChannelMessagesResponse struct {
+	Counted int `json:"counted"`
+}
+
+// AddBroadcastRecipientsRequest is the AddBroadcastRecipientsRequest object of the Piko API
+type AddBroadcastRecipientsRequest struct {
+	Recipients []string `json:"recipients"`
+}
+
+// AddChannelMemberRequest is the AddChannelMemberRequest object of the Piko API
+type AddChannelMemberRequest struct {
+	UserAddress string `json:"user_address"`
+}
+
+// AddGroupMemberRequest is the AddGroupMemberRequest object of the Piko API
+type AddGroupMemberRequest struct {
+	UserAddress string `json:"user_address"`
+	IsAdmin     bool   `json:"is_admin"`
+}
+
+// AddStickerRequest is the AddStickerRequest object of the Piko API
+type AddStickerRequest struct {
+	MediaID string `json:"media_id"`
+	Emoji   string `json:"emoji,omitempty"`
+}
+
+// AdminConnectionsResponse is the AdminConnectionsResponse object of the Piko API
+type AdminConnectionsResponse struct {
+	Clients           int  `json:"clients"`
+	SecretChatClients int  `json:"secret_chat_clients"`
+	Draining          bool `json:"draining"`
+}
+
+// AdminStatsResponse is the AdminStatsResponse object of the Piko API
+type AdminStatsResponse struct {
+	Users             int `json:"users"`
+	Messages          int `json:"messages"`
+	Groups            int `json:"groups"`
+	Channels          int `json:"channels"`
+	Blocks            int `json:"blocks"`
+	Transactions      int `json:"transactions"`
+	OpenReports       int `json:"open_reports"`
+	ConnectedClients  int `json:"connected_clients"`
+	SecretChatClients int `json:"secret_chat_clients"`
+	BlockchainHeight  int `json:"blockchain_height"`
+}
+
+// AdminUserResponse is the AdminUserResponse object of the Piko API
+type AdminUserResponse struct {
+	ID             int                `json:"id"`
+	Address        string             `json:"address"`
+	Phone          string             `json:"phone"`
+	Username       string             `json:"username,omitempty"`
+	Role           string             `json:"role"`
+	CreatedAt      time.Time          `json:"created_at"`
+	LastSeenAt     *time.Time         `json:"last_seen_at,omitempty"`
+	Online         bool               `json:"online"`
+	ActiveSessions int                `json:"active_sessions"`
+	FrozenUntil    *time.Time         `json:"frozen_until,omitempty"`
+	Suspension     *AccountSuspension `json:"suspension,omitempty"`
+	LegalHold      bool               `json:"legal_hold"`
+}
+
+// AppealChannelFlagRequest is the AppealChannelFlagRequest object of the Piko API
+type AppealChannelFlagRequest struct {
+	Reason string `json:"reason"`
+}
+
+// AppendDocUpdateRequest is the AppendDocUpdateRequest object of the Piko API
+type AppendDocUpdateRequest struct {
+	Update string `json:"update"`
+}
+
+// AuditEntry is the AuditEntry object of the Piko API
+type AuditEntry struct {
+	ID               int       `json:"id"`
+	ActorAddress     string    `json:"actor_address"`
+	Action           string    `json:"action"`
+	Target           string    `json:"target"`
+	IPAddress        string    `json:"ip_address"`
+	Details          string    `json:"details,omitempty"`
+	ConversationType string    `json:"conversation_type,omitempty"`
+	ConversationID   string    `json:"conversation_id,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// AuthResponse is the AuthResponse object of the Piko API
+type AuthResponse struct {
+	Token       string `json:"token"`
+	Address     string `json:"address"`
+	SessionID   string `json:"session_id"`
+	DeviceToken string `json:"device_token,omitempty"`
+}
+
+// AvatarResponse is the AvatarResponse object of the Piko API
+type AvatarResponse struct {
+	ID           int       `json:"id"`
+	UserID       int       `json:"user_id"`
+	FilePath     string    `json:"file_path"`
+	FileName     string    `json:"file_name"`
+	FileSize     int       `json:"file_size"`
+	MimeType     string    `json:"mime_type"`
+	Width        int       `json:"width"`
+	Height       int       `json:"height"`
+	IsActive     bool      `json:"is_active"`
+	CreatedAt    time.Time `json:"created_at"`
+	URL          string    `json:"url"`
+	URLExpiresAt time.Time `json:"url_expires_at"`
+}
+
+// Block is the Block object of the Piko API
+type Block struct {
+	ID           string         `json:"id"`
+	PreviousHash *string        `json:"previous_hash,omitempty"`
+	Timestamp    time.Time      `json:"timestamp"`
+	MerkleRoot   string         `json:"merkle_root"`
+	Nonce        int64          `json:"nonce"`
+	Height       int            `json:"height"`
+	Transactions []*Transaction `json:"transactions,omitempty"`
+}
+
+// BlocksResponse is the BlocksResponse object of the Piko API
+type BlocksResponse struct {
+	Blocks []*Block `json:"blocks"`
+	Total  int      `json:"total"`
+	Limit  int      `json:"limit"`
+	Offset int      `json:"offset"`
+}
+
+// Broadcast is the Broadcast object of the Piko API
+type Broadcast struct {
+	ID         string               `json:"id"`
+	ListID     string               `json:"list_id"`
+	CreatedAt  time.Time            `json:"created_at"`
+	Deliveries []*BroadcastDelivery `json:"deliveries"`
+}
+
+// BroadcastDelivery is the BroadcastDelivery object of the Piko API
+type BroadcastDelivery struct {
+	RecipientAddress string  `json:"recipient_address"`
+	MessageID        *string `json:"message_id,omitempty"`
+	Status           string  `json:"status"`
+	Error            *string `json:"error,omitempty"`
+}
+
+// BroadcastList is the BroadcastList object of the Piko API
+type BroadcastList struct {
+	ID             string    `json:"id"`
+	OwnerAddress   string    `json:"owner_address"`
+	Name           string    `json:"name"`
+	RecipientCount int       `json:"recipient_count"`
+	CreatedAt      time.Time `json:"created_at"`
+	Recipients     []string  `json:"recipients,omitempty"`
+}
+
+// ChainReport is the ChainReport object of the Piko API
+type ChainReport struct {
+	Valid          bool   `json:"valid"`
+	BlocksChecked  int    `json:"blocks_checked"`
+	InvalidHeight  *int   `json:"invalid_height,omitempty"`
+	InvalidBlockID string `json:"invalid_block_id,omitempty"`
+	Reason         string `json:"reason,omitempty"`
+}
+
+// ChallengeResponse is the ChallengeResponse object of the Piko API
+type ChallengeResponse struct {
+	Nonce     string    `json:"nonce"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ChannelFlag is the ChannelFlag object of the Piko API
+type ChannelFlag struct {
+	ChannelID     string     `json:"channel_id"`
+	Throttled     bool       `json:"throttled"`
+	Upheld        bool       `json:"upheld"`
+	ReporterCount int        `json:"reporter_count"`
+	FlaggedAt     time.Time  `json:"flagged_at"`
+	ReleasedAt    *time.Time `json:"released_at,omitempty"`
+	ReviewedBy    *string    `json:"reviewed_by,omitempty"`
+	ReviewedAt    *time.Time `json:"reviewed_at,omitempty"`
+	Appeal        *string    `json:"appeal,omitempty"`
+	AppealedAt    *time.Time `json:"appealed_at,omitempty"`
+}
+
+// ChannelInviteLinkResponse is the ChannelInviteLinkResponse object of the Piko API
+type ChannelInviteLinkResponse struct {
+	Token string `json:"token"`
+	Path  string `json:"path"`
+}
+
+// ChannelMember is the ChannelMember object of the Piko API
+type ChannelMember struct {
+	ChannelID   string    `json:"channel_id"`
+	UserAddress string    `json:"user_address"`
+	Role        string    `json:"role"`
+	JoinedAt    time.Time `json:"joined_at"`
+}
+
+// ChannelMemberResponse is the ChannelMemberResponse object of the Piko API
+type ChannelMemberResponse struct {
+	UserAddress string    `json:"user_address"`
+	Role        string    `json:"role"`
+	JoinedAt    time.Time `json:"joined_at"`
+}
+
+// ChannelMessage is the ChannelMessage object of the Piko API
+type ChannelMessage struct {
+	ID                   string     `json:"id"`
+	ChannelID            string     `json:"channel_id"`
+	SenderAddress        string     `json:"sender_address"`
+	EncryptedContent     []byte     `json:"encrypted_content"`
+	Timestamp            time.Time  `json:"timestamp"`
+	BlockID              *string    `json:"block_id,omitempty"`
+	ReplyToMessageID     *string    `json:"reply_to_message_id,omitempty"`
+	ForwardedFrom        *string    `json:"forwarded_from,omitempty"`
+	ForwardedFromChannel *string    `json:"forwarded_from_channel,omitempty"`
+	EditedAt             *time.Time `json:"edited_at,omitempty"`
+	StickerID            *string    `json:"sticker_id,omitempty"`
+}
+
+// ChannelMessageReach is the ChannelMessageReach object of the Piko API
+type ChannelMessageReach struct {
+	MessageID string    `json:"message_id"`
+	Timestamp time.Time `json:"timestamp"`
+	Reach     int       `json:"reach"`
+}
+
+// ChannelMessageRequest is the ChannelMessageRequest object of the Piko API
+type ChannelMessageRequest struct {
+	EncryptedContent string   `json:"encrypted_content"`
+	ReplyToMessageID string   `json:"reply_to_message_id,omitempty"`
+	AttachmentIDs    []string `json:"attachment_ids,omitempty"`
+	StickerID        string   `json:"sticker_id,omitempty"`
+}
+
+// ChannelMessageResponse is the ChannelMessageResponse object of the Piko API
+type ChannelMessageResponse struct {
+	ID                   string                `json:"id"`
+	ChannelID            string                `json:"channel_id"`
+	SenderAddress        string                `json:"sender_address"`
+	EncryptedContent     string                `json:"encrypted_content"`
+	Timestamp            time.Time             `json:"timestamp"`
+	BlockID              string                `json:"block_id,omitempty"`
+	ReplyToMessageID     string                `json:"reply_to_message_id,omitempty"`
+	StickerID            string                `json:"sticker_id,omitempty"`
+	ForwardedFrom        string                `json:"forwarded_from,omitempty"`
+	ForwardedFromChannel string                `json:"forwarded_from_channel,omitempty"`
+	Attachments          []MediaResponse       `json:"attachments,omitempty"`
+	SenderDevice         *SenderDeviceResponse `json:"sender_device,omitempty"`
+	Edited               bool                  `json:"edited"`
+	EditedAt             *time.Time            `json:"edited_at,omitempty"`
+}
+
+// ChannelResponse is the ChannelResponse object of the Piko API
+type ChannelResponse struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	AdminAddress string    `json:"admin_address"`
+	IsPublic     bool      `json:"is_public"`
+	CreatedAt    time.Time `json:"created_at"`
+	MemberCount  int       `json:"member_count"`
+	MessageCount int       `json:"message_count"`
+	UnreadCount  int       `json:"unread_count"`
+}
+
+// ChannelStats is the ChannelStats object of the Piko API
+type ChannelStats struct {
+	MemberCount  int                    `json:"member_count"`
+	MessageCount int                    `json:"message_count"`
+	Messages     []*ChannelMessageReach `json:"messages"`
+}
+
+// ChannelWebViewRequest is the ChannelWebViewRequest object of the Piko API
+type ChannelWebViewRequest struct {
+	Slug string `json:"slug"`
+}
+
+// ChannelWebViewResponse is the ChannelWebViewResponse object of the Piko API
+type ChannelWebViewResponse struct {
+	Slug string `json:"slug"`
+	Path string `json:"path,omitempty"`
+}
+
+// ClientInfo is the ClientInfo object of the Piko API
+type ClientInfo struct {
+	Address     string    `json:"address"`
+	Encoding    string    `json:"encoding"`
+	ConnectedAt time.Time `json:"connected_at"`
+}
+
+// CollabDoc is the CollabDoc object of the Piko API
+type CollabDoc struct {
+	ID               string    `json:"id"`
+	ConversationType string    `json:"conversation_type"`
+	ConversationID   string    `json:"conversation_id"`
+	CreatorAddress   string    `json:"creator_address"`
+	Version          int64     `json:"version"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// ComplianceAccount is the ComplianceAccount object of the Piko API
+type ComplianceAccount struct {
+	ID        int        `json:"id"`
+	Address   string     `json:"address"`
+	Phone     string     `json:"phone"`
+	Username  string     `json:"username,omitempty"`
+	Role      string     `json:"role"`
+	Birthdate *time.Time `json:"birthdate,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// ComplianceExport is the ComplianceExport object of the Piko API
+type ComplianceExport struct {
+	GeneratedAt        time.Time         `json:"generated_at"`
+	Hold               *LegalHold        `json:"hold"`
+	Account            ComplianceAccount `json:"account"`
+	Sessions           []*Session        `json:"sessions"`
+	Contacts           []*Contact        `json:"contacts"`
+	GroupMemberships   []*GroupMember    `json:"group_memberships"`
+	ChannelMemberships []*ChannelMember  `json:"channel_memberships"`
+	SentMessages       []*Message        `json:"sent_messages"`
+	ReceivedMessages   []*Message        `json:"received_messages"`
+	GroupMessages      []*GroupMessage   `json:"group_messages"`
+	ChannelMessages    []*ChannelMessage `json:"channel_messages"`
+	Media              []*Media          `json:"media"`
+	AuditLog           []*AuditEntry     `json:"audit_log"`
+}
+
+// ConsensusParams is the ConsensusParams object of the Piko API
+type ConsensusParams struct {
+	ActivationHeight int       `json:"activation_height"`
+	Difficulty       int       `json:"difficulty"`
+	TransactionTypes []string  `json:"transaction_types"`
+	Description      string    `json:"description"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// ConsensusParamsResponse is the ConsensusParamsResponse object of the Piko API
+type ConsensusParamsResponse struct {
+	Height   int                `json:"height"`
+	Next     *ConsensusParams   `json:"next"`
+	Schedule []*ConsensusParams `json:"schedule"`
+}
+
+// Contact is the Contact object of the Piko API
+type Contact struct {
+	ContactAddress string    `json:"address"`
+	Alias          string    `json:"alias,omitempty"`
+	Labels         []string  `json:"labels"`
+	Blocked        bool      `json:"blocked"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// ContactName is the ContactName object of the Piko API
+type ContactName struct {
+	Alias  string   `json:"alias,omitempty"`
+	Labels []string `json:"labels,omitempty"`
+}
+
+// CreateBroadcastListRequest is the CreateBroadcastListRequest object of the Piko API
+type CreateBroadcastListRequest struct {
+	Name       string   `json:"name"`
+	Recipients []string `json:"recipients,omitempty"`
+}
+
+// CreateChannelRequest is the CreateChannelRequest object of the Piko API
+type CreateChannelRequest struct {
+	Name     string `json:"name"`
+	IsPublic bool   `json:"is_public"`
+}
+
+// CreateDocRequest is the CreateDocRequest object of the Piko API
+type CreateDocRequest struct {
+	ConversationType string `json:"conversation_type"`
+	ConversationID   string `json:"conversation_id"`
+}
+
+// CreateGroupEventRequest is the CreateGroupEventRequest object of the Piko API
+type CreateGroupEventRequest struct {
+	Title       string    `json:"title"`
+	Description string    `json:"description,omitempty"`
+	StartsAt    time.Time `json:"starts_at"`
+}
+
+// CreateGroupInviteRequest is the CreateGroupInviteRequest object of the Piko API
+type CreateGroupInviteRequest struct {
+	MaxUses   *int       `json:"max_uses,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// CreateGroupRequest is the CreateGroupRequest object of the Piko API
+type CreateGroupRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	PhotoURL    string `json:"photo_url,omitempty"`
+}
+
+// CreateGroupTopicRequest is the CreateGroupTopicRequest object of the Piko API
+type CreateGroupTopicRequest struct {
+	Name string `json:"name"`
+}
+
+// CreateMediaUploadRequest is the CreateMediaUploadRequest object of the Piko API
+type CreateMediaUploadRequest struct {
+	FileName string `json:"file_name"`
+	MimeType string `json:"mime_type"`
+	Size     int64  `json:"size"`
+}
+
+// CreatePollRequest is the CreatePollRequest object of the Piko API
+type CreatePollRequest struct {
+	ConversationType string   `json:"conversation_type"`
+	ConversationID   string   `json:"conversation_id"`
+	MessageID        string   `json:"message_id"`
+	Question         string   `json:"question"`
+	Options          []string `json:"options"`
+	Anonymous        bool     `json:"anonymous,omitempty"`
+}
+
+// CreateReportRequest is the CreateReportRequest object of the Piko API
+type CreateReportRequest struct {
+	TargetType string `json:"target_type"`
+	TargetID   string `json:"target_id"`
+	Reason     string `json:"reason"`
+}
+
+// CreateSecretChatRequest is the CreateSecretChatRequest object of the Piko API
+type CreateSecretChatRequest struct {
+	Challenge       string `json:"challenge,omitempty"`
+	Solution        string `json:"solution,omitempty"`
+	TTLSeconds      int64  `json:"ttl_seconds,omitempty"`
+	MaxParticipants int    `json:"max_participants,omitempty"`
+}
+
+// CreateSecretChatResponse is the CreateSecretChatResponse object of the Piko API
+type CreateSecretChatResponse struct {
+	ChannelID       string    `json:"channel_id"`
+	ExpiresAt       time.Time `json:"expires_at"`
+	ExpiresIn       int64     `json:"expires_in"`
+	MaxParticipants int       `json:"max_participants"`
+}
+
+// CreateStickerPackRequest is the CreateStickerPackRequest object of the Piko API
+type CreateStickerPackRequest struct {
+	Name string `json:"name"`
+	Kind string `json:"kind,omitempty"`
+}
+
+// CreateSupportTicketRequest is the CreateSupportTicketRequest object of the Piko API
+type CreateSupportTicketRequest struct {
+	Category    string `json:"category"`
+	Message     string `json:"message"`
+	LogsMediaID string `json:"logs_media_id,omitempty"`
+}
+
+// CreateSupportTicketResponse is the CreateSupportTicketResponse object of the Piko API
+type CreateSupportTicketResponse struct {
+	Ticket            *SupportTicket `json:"ticket"`
+	SupportBotAddress string         `json:"support_bot_address"`
+}
+
+// CrosspostRequest is the CrosspostRequest object of the Piko API
+type CrosspostRequest struct {
+	MessageID       string `json:"message_id"`
+	TargetChannelID string `json:"target_channel_id"`
+}
+
+// CrosspostResponse is the CrosspostResponse object of the Piko API
+type CrosspostResponse struct {
+	ID                   string `json:"id"`
+	ChannelID            string `json:"channel_id"`
+	ForwardedFrom        string `json:"forwarded_from"`
+	ForwardedFromChannel string `json:"forwarded_from_channel"`
+	Link                 string `json:"link"`
+}
+
+// DailyMessageCount is the DailyMessageCount object of the Piko API
+type DailyMessageCount struct {
+	Date    string `json:"date"`
+	Direct  int    `json:"direct"`
+	Group   int    `json:"group"`
+	Channel int    `json:"channel"`
+	Total   int    `json:"total"`
+}
+
+// DeliverySLA is the DeliverySLA object of the Piko API
+type DeliverySLA struct {
+	Hour    time.Time `json:"hour"`
+	Kind    string    `json:"kind"`
+	Samples int       `json:"samples"`
+	P50     int64     `json:"p50_ms"`
+	P95     int64     `json:"p95_ms"`
+	P99     int64     `json:"p99_ms"`
+	Max     int64     `json:"max_ms"`
+	Alerted bool      `json:"alerted"`
+}
+
+// DiscoverChannelResponse is the DiscoverChannelResponse object of the Piko API
+type DiscoverChannelResponse struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	AdminAddress string    `json:"admin_address"`
+	IsPublic     bool      `json:"is_public"`
+	CreatedAt    time.Time `json:"created_at"`
+	MemberCount  int       `json:"member_count"`
+	MessageCount int       `json:"message_count"`
+	UnreadCount  int       `json:"unread_count"`
+	InviteToken  string    `json:"invite_token"`
+}
+
+// DiscoverContactsRequest is the DiscoverContactsRequest object of the Piko API
+type DiscoverContactsRequest struct {
+	Hashes []string `json:"hashes"`
+}
+
+// DiscoveredContact is the DiscoveredContact object of the Piko API
+type DiscoveredContact struct {
+	Hash     string `json:"hash"`
+	Address  string `json:"address"`
+	Username string `json:"username,omitempty"`
+}
+
+// DoNotDisturb is the DoNotDisturb object of the Piko API
+type DoNotDisturb struct {
+	UserAddress string     `json:"user_address"`
+	Until       *time.Time `json:"until,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// DoNotDisturbRequest is the DoNotDisturbRequest object of the Piko API
+type DoNotDisturbRequest struct {
+	Until *time.Time `json:"until,omitempty"`
+}
+
+// DocUpdateResponse is the DocUpdateResponse object of the Piko API
+type DocUpdateResponse struct {
+	DocID         string    `json:"doc_id"`
+	Version       int64     `json:"version"`
+	SenderAddress string    `json:"sender_address"`
+	Update        string    `json:"update"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// DocUpdatesResponse is the DocUpdatesResponse object of the Piko API
+type DocUpdatesResponse struct {
+	DocID   string              `json:"doc_id"`
+	Version int64               `json:"version"`
+	Updates []DocUpdateResponse `json:"updates"`
+	HasMore bool                `json:"has_more"`
+}
+
+// EditGroupMessageRequest is the EditGroupMessageRequest object of the Piko API
+type EditGroupMessageRequest struct {
+	Content string `json:"content"`
+}
+
+// EditMessageRequest is the EditMessageRequest object of the Piko API
+type EditMessageRequest struct {
+	EncryptedContent string `json:"encrypted_content"`
+}
+
+// Event is the Event object of the Piko API
+type Event struct {
+	Type    string                 `json:"type"`
+	Payload map[string]interface{} `json:"payload"`
+	From    string                 `json:"from,omitempty"`
+	To      string                 `json:"to,omitempty"`
+}
+
+// ForwardMessageRequest is the ForwardMessageRequest object of the Piko API
+type ForwardMessageRequest struct {
+	RecipientAddress string `json:"recipient_address,omitempty"`
+	GroupID          string `json:"group_id,omitempty"`
+	ChannelID        string `json:"channel_id,omitempty"`
+}
+
+// ForwardMessageResponse is the ForwardMessageResponse object of the Piko API
+type ForwardMessageResponse struct {
+	ID            string `json:"id"`
+	ForwardedFrom string `json:"forwarded_from"`
+}
+
+// FreezeAccountRequest is the FreezeAccountRequest object of the Piko API
+type FreezeAccountRequest struct {
+	Phone        string `json:"phone,omitempty"`
+	RecoveryCode string `json:"recovery_code,omitempty"`
+}
+
+// FreezeAccountResponse is the FreezeAccountResponse object of the Piko API
+type FreezeAccountResponse struct {
+	Message     string    `json:"message"`
+	FrozenUntil time.Time `json:"frozen_until"`
+	Revoked     int       `json:"revoked"`
+}
+
+// GrantGuestPassRequest is the GrantGuestPassRequest object of the Piko API
+type GrantGuestPassRequest struct {
+	UserAddress string    `json:"user_address"`
+	Access      string    `json:"access,omitempty"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// GroupEvent is the GroupEvent object of the Piko API
+type GroupEvent struct {
+	ID             string     `json:"id"`
+	GroupID        string     `json:"group_id"`
+	CreatorAddress string     `json:"creator_address"`
+	Title          string     `json:"title"`
+	Description    string     `json:"description,omitempty"`
+	StartsAt       time.Time  `json:"starts_at"`
+	RemindedAt     *time.Time `json:"reminded_at,omitempty"`
+	StartedAt      *time.Time `json:"started_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	Going          int        `json:"going"`
+	Maybe          int        `json:"maybe"`
+	NotGoing       int        `json:"not_going"`
+}
+
+// GroupEventRSVP is the GroupEventRSVP object of the Piko API
+type GroupEventRSVP struct {
+	UserAddress string    `json:"user_address"`
+	Status      string    `json:"status"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// GroupEventResponse is the GroupEventResponse object of the Piko API
+type GroupEventResponse struct {
+	ID             string            `json:"id"`
+	GroupID        string            `json:"group_id"`
+	CreatorAddress string            `json:"creator_address"`
+	Title          string            `json:"title"`
+	Description    string            `json:"description,omitempty"`
+	StartsAt       time.Time         `json:"starts_at"`
+	RemindedAt     *time.Time        `json:"reminded_at,omitempty"`
+	StartedAt      *time.Time        `json:"started_at,omitempty"`
+	CreatedAt      time.Time         `json:"created_at"`
+	Going          int               `json:"going"`
+	Maybe          int               `json:"maybe"`
+	NotGoing       int               `json:"not_going"`
+	RSVPs          []*GroupEventRSVP `json:"rsvps"`
+}
+
+// GroupInviteResponse is the GroupInviteResponse object of the Piko API
+type GroupInviteResponse struct {
+	Token     string     `json:"token"`
+	GroupID   string     `json:"group_id"`
+	CreatedBy string     `json:"created_by"`
+	MaxUses   *int       `json:"max_uses,omitempty"`
+	Uses      int        `json:"uses"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	Path      string     `json:"path"`
+}
+
+// GroupJoinRequest is the GroupJoinRequest object of the Piko API
+type GroupJoinRequest struct {
+	GroupID     string     `json:"group_id"`
+	UserAddress string     `json:"user_address"`
+	Message     string     `json:"message,omitempty"`
+	Status      string     `json:"status"`
+	DecidedBy   *string    `json:"decided_by,omitempty"`
+	DecidedAt   *time.Time `json:"decided_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// GroupMember is the GroupMember object of the Piko API
+type GroupMember struct {
+	GroupID     string    `json:"group_id"`
+	UserAddress string    `json:"user_address"`
+	Role        string    `json:"role"`
+	JoinedAt    time.Time `json:"joined_at"`
+}
+
+// GroupMemberResponse is the GroupMemberResponse object of the Piko API
+type GroupMemberResponse struct {
+	UserAddress string    `json:"user_address"`
+	Role        string    `json:"role"`
+	JoinedAt    time.Time `json:"joined_at"`
+}
+
+// GroupMessage is the GroupMessage object of the Piko API
+type GroupMessage struct {
+	ID               string     `json:"id"`
+	GroupID          string     `json:"group_id"`
+	SenderAddress    string     `json:"sender_address"`
+	Content          []byte     `json:"content"`
+	Timestamp        time.Time  `json:"timestamp"`
+	BlockID          *string    `json:"block_id,omitempty"`
+	ReplyToMessageID *string    `json:"reply_to_message_id,omitempty"`
+	ForwardedFrom    *string    `json:"forwarded_from,omitempty"`
+	System           bool       `json:"system,omitempty"`
+	EditedAt         *time.Time `json:"edited_at,omitempty"`
+	TopicID          *string    `json:"topic_id,omitempty"`
+	StickerID        *string    `json:"sticker_id,omitempty"`
+}
+
+// GroupMessageResponse is the GroupMessageResponse object of the Piko API
+type GroupMessageResponse struct {
+	ID               string                `json:"id"`
+	GroupID          string                `json:"group_id"`
+	SenderAddress    string                `json:"sender_address"`
+	Content          string                `json:"content"`
+	Timestamp        time.Time             `json:"timestamp"`
+	ReplyToMessageID *string               `json:"reply_to_message_id,omitempty"`
+	StickerID        *string               `json:"sticker_id,omitempty"`
+	ForwardedFrom    *string               `json:"forwarded_from,omitempty"`
+	Attachments      []MediaResponse       `json:"attachments,omitempty"`
+	SenderDevice     *SenderDeviceResponse `json:"sender_device,omitempty"`
+	System           bool                  `json:"system,omitempty"`
+	Edited           bool                  `json:"edited"`
+	EditedAt         *time.Time            `json:"edited_at,omitempty"`
+	TopicID          *string               `json:"topic_id,omitempty"`
+	Contact          *ContactName          `json:"contact,omitempty"`
+}
+
+// GroupResponse is the GroupResponse object of the Piko API
+type GroupResponse struct {
+	ID                  string `json:"id"`
+	Name                string `json:"name"`
+	Description         string `json:"description"`
+	PhotoURL            string `json:"photo_url,omitempty"`
+	CreatedBy           string `json:"created_by"`
+	MemberCount         int    `json:"member_count"`
+	MessageCount        int    `json:"message_count"`
+	UnreadCount         int    `json:"unread_count"`
+	TopicsEnabled       bool   `json:"topics_enabled"`
+	JoinRequestsEnabled bool   `json:"join_requests_enabled"`
+}
+
+// GroupTopic is the GroupTopic object of the Piko API
+type GroupTopic struct {
+	ID             string     `json:"id"`
+	GroupID        string     `json:"group_id"`
+	Name           string     `json:"name"`
+	CreatorAddress string     `json:"creator_address"`
+	ClosedAt       *time.Time `json:"closed_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	MessageCount   int        `json:"message_count"`
+	LastMessageAt  *time.Time `json:"last_message_at,omitempty"`
+	UnreadCount    int        `json:"unread_count"`
+}
+
+// GuestPass is the GuestPass object of the Piko API
+type GuestPass struct {
+	GroupID     string    `json:"group_id"`
+	UserAddress string    `json:"user_address"`
+	Access      string    `json:"access"`
+	GrantedBy   string    `json:"granted_by"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// JoinGroupRequest is the JoinGroupRequest object of the Piko API
+type JoinGroupRequest struct {
+	Message string `json:"message,omitempty"`
+}
+
+// JoinSecretChatRequest is the JoinSecretChatRequest object of the Piko API
+type JoinSecretChatRequest struct {
+	ChannelID   string `json:"channel_id"`
+	DisplayName string `json:"display_name"`
+}
+
+// JoinSecretChatResponse is the JoinSecretChatResponse object of the Piko API
+type JoinSecretChatResponse struct {
+	SessionID       string    `json:"session_id"`
+	ChannelID       string    `json:"channel_id"`
+	ExpiresAt       time.Time `json:"expires_at"`
+	WebSocketURL    string    `json:"websocket_url"`
+	ExpiresIn       int64     `json:"expires_in"`
+	MaxParticipants int       `json:"max_participants"`
+}
+
+// KeyBundleResponse is the KeyBundleResponse object of the Piko API
+type KeyBundleResponse struct {
+	Address       string                 `json:"address"`
+	IdentityKey   string                 `json:"identity_key"`
+	SignedPrekey  SignedPrekeyResponse   `json:"signed_prekey"`
+	OneTimePrekey *OneTimePrekeyResponse `json:"one_time_prekey,omitempty"`
+}
+
+// KeyRotation is the KeyRotation object of the Piko API
+type KeyRotation struct {
+	ID                  string             `json:"id"`
+	UserAddress         string             `json:"user_address"`
+	IdentityFingerprint string             `json:"identity_fingerprint"`
+	CreatedAt           time.Time          `json:"created_at"`
+	Total               int                `json:"total"`
+	Acknowledged        int                `json:"acknowledged"`
+	Peers               []*KeyRotationPeer `json:"peers,omitempty"`
+}
+
+// KeyRotationPeer is the KeyRotationPeer object of the Piko API
+type KeyRotationPeer struct {
+	PeerAddress    string     `json:"peer_address"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+}
+
+// KeyStatusResponse is the KeyStatusResponse object of the Piko API
+type KeyStatusResponse struct {
+	IdentityKey           string    `json:"identity_key"`
+	SignedPrekeyID        uint32    `json:"signed_prekey_id"`
+	SignedPrekeyUpdatedAt time.Time `json:"signed_prekey_updated_at"`
+	OneTimePrekeys        int       `json:"one_time_prekeys"`
+	RotateSignedPrekey    bool      `json:"rotate_signed_prekey"`
+	UploadPrekeys         int       `json:"upload_prekeys"`
+}
+
+// LegalHold is the LegalHold object of the Piko API
+type LegalHold struct {
+	UserAddress string    `json:"user_address"`
+	Reason      string    `json:"reason"`
+	PlacedBy    string    `json:"placed_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// LoginRequest is the LoginRequest object of the Piko API
+type LoginRequest struct {
+	Phone   string `json:"phone"`
+	Channel string `json:"channel,omitempty"`
+}
+
+// MarkReadRequest is the MarkReadRequest object of the Piko API
+type MarkReadRequest struct {
+	MessageID string `json:"message_id"`
+}
+
+// Media is the Media object of the Piko API
+type Media struct {
+	ID           string    `json:"id"`
+	OwnerAddress string    `json:"owner_address"`
+	FileName     string    `json:"file_name"`
+	MimeType     string    `json:"mime_type"`
+	Size         int64     `json:"size"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// MediaResponse is the MediaResponse object of the Piko API
+type MediaResponse struct {
+	ID           string    `json:"id"`
+	FileName     string    `json:"file_name"`
+	MimeType     string    `json:"mime_type"`
+	Size         int64     `json:"size"`
+	URL          string    `json:"url"`
+	URLExpiresAt time.Time `json:"url_expires_at"`
+}
+
+// MediaUploadResponse is the MediaUploadResponse object of the Piko API
+type MediaUploadResponse struct {
+	UploadID  string `json:"upload_id"`
+	Size      int64  `json:"size"`
+	Received  int64  `json:"received"`
+	ChunkSize int64  `json:"chunk_size"`
+}
+
+// Message is the Message object of the Piko API
+type Message struct {
+	ID               string     `json:"id"`
+	SenderAddress    string     `json:"sender_address"`
+	RecipientAddress string     `json:"recipient_address"`
+	EncryptedContent []byte     `json:"encrypted_content"`
+	Timestamp        time.Time  `json:"timestamp"`
+	Status           string     `json:"status"`
+	ExpirationTime   *time.Time `json:"expiration_time,omitempty"`
+	BlockID          *string    `json:"block_id,omitempty"`
+	EditedAt         *time.Time `json:"edited_at,omitempty"`
+	ReplyToMessageID *string    `json:"reply_to_message_id,omitempty"`
+	ForwardedFrom    *string    `json:"forwarded_from,omitempty"`
+	StickerID        *string    `json:"sticker_id,omitempty"`
+}
+
+// MessageEditResponse is the MessageEditResponse object of the Piko API
+type MessageEditResponse struct {
+	EncryptedContent string    `json:"encrypted_content"`
+	EditedAt         time.Time `json:"edited_at"`
+}
+
+// MessageLinkResponse is the MessageLinkResponse object of the Piko API
+type MessageLinkResponse struct {
+	Link string `json:"link"`
+	Path string `json:"path"`
+}
+
+// MessageReceipt is the MessageReceipt object of the Piko API
+type MessageReceipt struct {
+	MessageID        string    `json:"message_id"`
+	RecipientAddress string    `json:"recipient_address"`
+	Status           string    `json:"status"`
+	Timestamp        time.Time `json:"timestamp"`
+}
+
+// MessageReceiptsResponse is the MessageReceiptsResponse object of the Piko API
+type MessageReceiptsResponse struct {
+	MessageID string            `json:"message_id"`
+	Status    string            `json:"status"`
+	SentAt    time.Time         `json:"sent_at"`
+	Receipts  []*MessageReceipt `json:"receipts"`
+}
+
+// MessageResponse is the MessageResponse object of the Piko API
+type MessageResponse struct {
+	ID               string                `json:"id"`
+	SenderAddress    string                `json:"sender_address"`
+	RecipientAddress string                `json:"recipient_address"`
+	EncryptedContent string                `json:"encrypted_content"`
+	Timestamp        time.Time             `json:"timestamp"`
+	Status           string                `json:"status"`
+	ExpirationTime   *time.Time            `json:"expiration_time,omitempty"`
+	BlockID          *string               `json:"block_id,omitempty"`
+	EditedAt         *time.Time            `json:"edited_at,omitempty"`
+	ReplyToMessageID *string               `json:"reply_to_message_id,omitempty"`
+	StickerID        *string               `json:"sticker_id,omitempty"`
+	ForwardedFrom    *string               `json:"forwarded_from,omitempty"`
+	Attachments      []MediaResponse       `json:"attachments,omitempty"`
+	SenderDevice     *SenderDeviceResponse `json:"sender_device,omitempty"`
+	Contact          *ContactName          `json:"contact,omitempty"`
+}
+
+// MessagesResponse is the MessagesResponse object of the Piko API
+type MessagesResponse struct {
+	Messages []MessageResponse `json:"messages"`
+	HasMore  bool              `json:"has_more"`
+}
+
+// Mute is the Mute object of the Piko API
+type Mute struct {
+	UserAddress      string     `json:"user_address"`
+	ConversationType string     `json:"conversation_type"`
+	ConversationID   string     `json:"conversation_id"`
+	MutedUntil       *time.Time `json:"muted_until,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// MuteRequest is the MuteRequest object of the Piko API
+type MuteRequest struct {
+	ConversationType string     `json:"conversation_type"`
+	ConversationID   string     `json:"conversation_id"`
+	MutedUntil       *time.Time `json:"muted_until,omitempty"`
+}
+
+// MutesResponse is the MutesResponse object of the Piko API
+type MutesResponse struct {
+	Mutes        []*Mute       `json:"mutes"`
+	DoNotDisturb *DoNotDisturb `json:"do_not_disturb,omitempty"`
+}
+
+// OneTimePrekeyRequest is the OneTimePrekeyRequest object of the Piko API
+type OneTimePrekeyRequest struct {
+	KeyID     uint32 `json:"key_id"`
+	PublicKey string `json:"public_key"`
+}
+
+// OneTimePrekeyResponse is the OneTimePrekeyResponse object of the Piko API
+type OneTimePrekeyResponse struct {
+	KeyID     uint32 `json:"key_id"`
+	PublicKey string `json:"public_key"`
+}
+
+// PINStatusResponse is the PINStatusResponse object of the Piko API
+type PINStatusResponse struct {
+	Enabled     bool       `json:"enabled"`
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+}
+
+// PendingPoliciesResponse is the PendingPoliciesResponse object of the Piko API
+type PendingPoliciesResponse struct {
+	Pending []*Policy `json:"pending"`
+}
+
+// PlaceLegalHoldRequest is the PlaceLegalHoldRequest object of the Piko API
+type PlaceLegalHoldRequest struct {
+	Reason string `json:"reason"`
+}
+
+// Policy is the Policy object of the Piko API
+type Policy struct {
+	ID          int       `json:"id"`
+	Kind        string    `json:"kind"`
+	Version     string    `json:"version"`
+	URL         string    `json:"url"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// PolicyAcceptance is the PolicyAcceptance object of the Piko API
+type PolicyAcceptance struct {
+	ID          int       `json:"id"`
+	Kind        string    `json:"kind"`
+	Version     string    `json:"version"`
+	URL         string    `json:"url"`
+	PublishedAt time.Time `json:"published_at"`
+	AcceptedAt  time.Time `json:"accepted_at"`
+}
+
+// PollOption is the PollOption object of the Piko API
+type PollOption struct {
+	Position int      `json:"position"`
+	Text     string   `json:"text"`
+	Votes    int      `json:"votes"`
+	Voters   []string `json:"voters,omitempty"`
+}
+
+// PollResponse is the PollResponse object of the Piko API
+type PollResponse struct {
+	ID               string        `json:"id"`
+	ConversationType string        `json:"conversation_type"`
+	ConversationID   string        `json:"conversation_id"`
+	MessageID        string        `json:"message_id"`
+	CreatorAddress   string        `json:"creator_address"`
+	Question         string        `json:"question"`
+	Anonymous        bool          `json:"anonymous"`
+	Options          []*PollOption `json:"options"`
+	TotalVotes       int           `json:"total_votes"`
+	ClosedAt         *time.Time    `json:"closed_at,omitempty"`
+	CreatedAt        time.Time     `json:"created_at"`
+	MyVote           *int          `json:"my_vote,omitempty"`
+}
+
+// PublishPolicyRequest is the PublishPolicyRequest object of the Piko API
+type PublishPolicyRequest struct {
+	Kind    string `json:"kind"`
+	Version string `json:"version"`
+	URL     string `json:"url"`
+}
+
+// PurgedMessages is the PurgedMessages object of the Piko API
+type PurgedMessages struct {
+	Direct  int64 `json:"direct"`
+	Group   int64 `json:"group"`
+	Channel int64 `json:"channel"`
+}
+
+// RSVPRequest is the RSVPRequest object of the Piko API
+type RSVPRequest struct {
+	Status string `json:"status"`
+}
+
+// ReceiptsResponse is the ReceiptsResponse object of the Piko API
+type ReceiptsResponse struct {
+	Receipts   []*MessageReceipt `json:"receipts"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+	HasMore    bool              `json:"has_more"`
+}
+
+// RecoveryCodeResponse is the RecoveryCodeResponse object of the Piko API
+type RecoveryCodeResponse struct {
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// RegisterDeviceRequest is the RegisterDeviceRequest object of the Piko API
+type RegisterDeviceRequest struct {
+	Token    string `json:"token"`
+	Platform string `json:"platform"`
+}
+
+// RegisterRequest is the RegisterRequest object of the Piko API
+type RegisterRequest struct {
+	Phone        string `json:"phone"`
+	Channel      string `json:"channel,omitempty"`
+	Email        string `json:"email,omitempty"`
+	Challenge    string `json:"challenge,omitempty"`
+	Solution     string `json:"solution,omitempty"`
+	CaptchaToken string `json:"captcha_token,omitempty"`
+}
+
+// RegistrationChallengeResponse is the RegistrationChallengeResponse object of the Piko API
+type RegistrationChallengeResponse struct {
+	Type       string     `json:"type"`
+	Nonce      string     `json:"nonce,omitempty"`
+	Difficulty int        `json:"difficulty,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	SiteKey    string     `json:"site_key,omitempty"`
+}
+
+// RemovePINRequest is the RemovePINRequest object of the Piko API
+type RemovePINRequest struct {
+	CurrentPIN string `json:"current_pin"`
+}
+
+// RenameBroadcastListRequest is the RenameBroadcastListRequest object of the Piko API
+type RenameBroadcastListRequest struct {
+	Name string `json:"name"`
+}
+
+// Report is the Report object of the Piko API
+type Report struct {
+	ID              int        `json:"id"`
+	ReporterAddress string     `json:"reporter_address"`
+	TargetType      string     `json:"target_type"`
+	TargetID        string     `json:"target_id"`
+	Reason          string     `json:"reason"`
+	Status          string     `json:"status"`
+	ResolvedBy      *string    `json:"resolved_by,omitempty"`
+	ResolvedAt      *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// ResendOTPRequest is the ResendOTPRequest object of the Piko API
+type ResendOTPRequest struct {
+	Phone string `json:"phone"`
+}
+
+// ResendOTPResponse is the ResendOTPResponse object of the Piko API
+type ResendOTPResponse struct {
+	Message     string `json:"message"`
+	ExpiresIn   int    `json:"expires_in"`
+	ResendsLeft int    `json:"resends_left"`
+	RetryAfter  int    `json:"retry_after"`
+}
+
+// ResolveReportRequest is the ResolveReportRequest object of the Piko API
+type ResolveReportRequest struct {
+	Status string `json:"status"`
+}
+
+// ResolvedMessageLinkResponse is the ResolvedMessageLinkResponse object of the Piko API
+type ResolvedMessageLinkResponse struct {
+	Link      string                  `json:"link"`
+	Channel   ChannelResponse         `json:"channel"`
+	MessageID string                  `json:"message_id"`
+	Message   *ChannelMessageResponse `json:"message,omitempty"`
+}
+
+// ReviewChannelFlagRequest is the ReviewChannelFlagRequest object of the Piko API
+type ReviewChannelFlagRequest struct {
+	Action string `json:"action"`
+}
+
+// SafetyNumberResponse is the SafetyNumberResponse object of the Piko API
+type SafetyNumberResponse struct {
+	PeerAddress        string     `json:"peer_address"`
+	SafetyNumber       string     `json:"safety_number"`
+	PeerKeyFingerprint string     `json:"peer_key_fingerprint"`
+	Verified           bool       `json:"verified"`
+	VerifiedAt         *time.Time `json:"verified_at,omitempty"`
+}
+
+// SaveContactRequest is the SaveContactRequest object of the Piko API
+type SaveContactRequest struct {
+	Address string    `json:"address"`
+	Alias   string    `json:"alias,omitempty"`
+	Labels  *[]string `json:"labels,omitempty"`
+	Blocked bool      `json:"blocked"`
+}
+
+// SecretChatChallengeResponse is the SecretChatChallengeResponse object of the Piko API
+type SecretChatChallengeResponse struct {
+	Nonce      string    `json:"nonce"`
+	Difficulty int       `json:"difficulty"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// SecretChatMessageRequest is the SecretChatMessageRequest object of the Piko API
+type SecretChatMessageRequest struct {
+	SessionID        string `json:"session_id"`
+	EncryptedContent string `json:"encrypted_content"`
+}
+
+// SecretChatMessageResponse is the SecretChatMessageResponse object of the Piko API
+type SecretChatMessageResponse struct {
+	ID               string    `json:"id"`
+	ChannelID        string    `json:"channel_id"`
+	DisplayName      string    `json:"display_name"`
+	EncryptedContent string    `json:"encrypted_content"`
+	Timestamp        time.Time `json:"timestamp"`
+}
+
+// SecurityLogResponse is the SecurityLogResponse object of the Piko API
+type SecurityLogResponse struct {
+	Events         []*AuditEntry      `json:"events"`
+	DeviceActivity []*SessionActivity `json:"device_activity"`
+}
+
+// SendBroadcastRequest is the SendBroadcastRequest object of the Piko API
+type SendBroadcastRequest struct {
+	EncryptedContent string   `json:"encrypted_content"`
+	TTL              *int64   `json:"ttl,omitempty"`
+	AttachmentIDs    []string `json:"attachment_ids,omitempty"`
+}
+
+// SendGroupMessageRequest is the SendGroupMessageRequest object of the Piko API
+type SendGroupMessageRequest struct {
+	Content          string   `json:"content"`
+	ReplyToMessageID string   `json:"reply_to_message_id,omitempty"`
+	AttachmentIDs    []string `json:"attachment_ids,omitempty"`
+	TopicID          string   `json:"topic_id,omitempty"`
+	StickerID        string   `json:"sticker_id,omitempty"`
+}
+
+// SendMessageRequest is the SendMessageRequest object of the Piko API
+type SendMessageRequest struct {
+	RecipientAddress string   `json:"recipient_address"`
+	EncryptedContent string   `json:"encrypted_content"`
+	TTL              *int64   `json:"ttl,omitempty"`
+	ReplyToMessageID string   `json:"reply_to_message_id,omitempty"`
+	AttachmentIDs    []string `json:"attachment_ids,omitempty"`
+	StickerID        string   `json:"sticker_id,omitempty"`
+}
+
+// SenderDeviceResponse is the SenderDeviceResponse object of the Piko API
+type SenderDeviceResponse struct {
+	SessionID  string `json:"session_id"`
+	DeviceName string `json:"device_name"`
+}
+
+// Session is the Session object of the Piko API
+type Session struct {
+	ID           string     `json:"id"`
+	UserAddress  string     `json:"user_address"`
+	DeviceName   string     `json:"device_name"`
+	UserAgent    string     `json:"user_agent"`
+	IPAddress    string     `json:"ip_address"`
+	CreatedAt    time.Time  `json:"created_at"`
+	LastSeenAt   time.Time  `json:"last_seen_at"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	RevokeReason *string    `json:"revoke_reason,omitempty"`
+}
+
+// SessionActivity is the SessionActivity object of the Piko API
+type SessionActivity struct {
+	SessionID     string     `json:"session_id"`
+	DeviceName    string     `json:"device_name"`
+	MessagesSent  int        `json:"messages_sent"`
+	LastMessageAt *time.Time `json:"last_message_at,omitempty"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+}
+
+// SessionResponse is the SessionResponse object of the Piko API
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	DeviceName string    `json:"device_name"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Current    bool      `json:"current"`
+}
+
+// SetGroupJoinModeRequest is the SetGroupJoinModeRequest object of the Piko API
+type SetGroupJoinModeRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+// SetGroupTopicModeRequest is the SetGroupTopicModeRequest object of the Piko API
+type SetGroupTopicModeRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+// SetPINRequest is the SetPINRequest object of the Piko API
+type SetPINRequest struct {
+	PIN        string `json:"pin"`
+	CurrentPIN string `json:"current_pin,omitempty"`
+}
+
+// SetUsernameRequest is the SetUsernameRequest object of the Piko API
+type SetUsernameRequest struct {
+	Username string `json:"username"`
+}
+
+// SignedPrekeyRequest is the SignedPrekeyRequest object of the Piko API
+type SignedPrekeyRequest struct {
+	KeyID     uint32 `json:"key_id"`
+	PublicKey string `json:"public_key"`
+	Signature string `json:"signature"`
+}
+
+// SignedPrekeyResponse is the SignedPrekeyResponse object of the Piko API
+type SignedPrekeyResponse struct {
+	KeyID     uint32 `json:"key_id"`
+	PublicKey string `json:"public_key"`
+	Signature string `json:"signature"`
+}
+
+// StickerPackResponse is the StickerPackResponse object of the Piko API
+type StickerPackResponse struct {
+	ID             string            `json:"id"`
+	Name           string            `json:"name"`
+	Kind           string            `json:"kind"`
+	CreatorAddress string            `json:"creator_address"`
+	StickerCount   int               `json:"sticker_count"`
+	CreatedAt      time.Time         `json:"created_at"`
+	Stickers       []StickerResponse `json:"stickers"`
+	Installed      bool              `json:"installed"`
+}
+
+// StickerResponse is the StickerResponse object of the Piko API
+type StickerResponse struct {
+	ID        string        `json:"id"`
+	PackID    string        `json:"pack_id"`
+	MediaID   string        `json:"media_id"`
+	Emoji     string        `json:"emoji,omitempty"`
+	CreatedAt time.Time     `json:"created_at"`
+	Image     MediaResponse `json:"image"`
+}
+
+// StorageUsageResponse is the StorageUsageResponse object of the Piko API
+type StorageUsageResponse struct {
+	Used  int64 `json:"used"`
+	Limit int64 `json:"limit"`
+}
+
+// SupportTicket is the SupportTicket object of the Piko API
+type SupportTicket struct {
+	ID          int        `json:"id"`
+	UserAddress string     `json:"user_address"`
+	Category    string     `json:"category"`
+	Message     string     `json:"message"`
+	LogsMediaID *string    `json:"logs_media_id,omitempty"`
+	Status      string     `json:"status"`
+	ClosedBy    *string    `json:"closed_by,omitempty"`
+	ClosedAt    *time.Time `json:"closed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// SupportTicketReply is the SupportTicketReply object of the Piko API
+type SupportTicketReply struct {
+	ID           int       `json:"id"`
+	TicketID     int       `json:"ticket_id"`
+	AdminAddress string    `json:"admin_address"`
+	Message      string    `json:"message"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// SupportTicketReplyRequest is the SupportTicketReplyRequest object of the Piko API
+type SupportTicketReplyRequest struct {
+	Message string `json:"message"`
+}
+
+// SupportTicketResponse is the SupportTicketResponse object of the Piko API
+type SupportTicketResponse struct {
+	ID          int                   `json:"id"`
+	UserAddress string                `json:"user_address"`
+	Category    string                `json:"category"`
+	Message     string                `json:"message"`
+	LogsMediaID *string               `json:"logs_media_id,omitempty"`
+	Status      string                `json:"status"`
+	ClosedBy    *string               `json:"closed_by,omitempty"`
+	ClosedAt    *time.Time            `json:"closed_at,omitempty"`
+	CreatedAt   time.Time             `json:"created_at"`
+	Logs        *MediaResponse        `json:"logs,omitempty"`
+	Replies     []*SupportTicketReply `json:"replies"`
+}
+
+// SuspendAccountRequest is the SuspendAccountRequest object of the Piko API
+type SuspendAccountRequest struct {
+	Reason string `json:"reason"`
+}
+
+// Transaction is the Transaction object of the Piko API
+type Transaction struct {
+	Hash      string    `json:"hash"`
+	BlockID   string    `json:"block_id"`
+	Type      string    `json:"type"`
+	DataID    string    `json:"data_id"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// TransactionsResponse is the TransactionsResponse object of the Piko API
+type TransactionsResponse struct {
+	Transactions []*Transaction `json:"transactions"`
+	Total        int            `json:"total"`
+	Limit        int            `json:"limit"`
+	Offset       int            `json:"offset"`
+}
+
+// TransferGroupOwnershipRequest is the TransferGroupOwnershipRequest object of the Piko API
+type TransferGroupOwnershipRequest struct {
+	NewOwnerAddress string `json:"new_owner_address"`
+}
+
+// UpdateChannelMemberRoleRequest is the UpdateChannelMemberRoleRequest object of the Piko API
+type UpdateChannelMemberRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// UpdateChannelRequest is the UpdateChannelRequest object of the Piko API
+type UpdateChannelRequest struct {
+	Name     string `json:"name"`
+	IsPublic *bool  `json:"is_public,omitempty"`
+}
+
+// UpdateGroupMemberRoleRequest is the UpdateGroupMemberRoleRequest object of the Piko API
+type UpdateGroupMemberRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// UpdateMessageStatusesRequest is the UpdateMessageStatusesRequest object of the Piko API
+type UpdateMessageStatusesRequest struct {
+	MessageIDs []string `json:"message_ids"`
+	Status     string   `json:"status"`
+}
+
+// UpdateMessageStatusesResponse is the UpdateMessageStatusesResponse object of the Piko API
+type UpdateMessageStatusesResponse struct {
+	Updated []string `json:"updated"`
+	Skipped []string `json:"skipped"`
+}
+
+// UpdateNicknameRequest is the UpdateNicknameRequest object of the Piko API
+type UpdateNicknameRequest struct {
+	Nickname string `json:"nickname"`
+}
+
+// UpdateProfileRequest is the UpdateProfileRequest object of the Piko API
+type UpdateProfileRequest struct {
+	Phone string `json:"phone,omitempty"`
+	Email string `json:"email,omitempty"`
+}
+
+// UpdateUserSettingsRequest is the UpdateUserSettingsRequest object of the Piko API
+type UpdateUserSettingsRequest struct {
+	Nickname            string `json:"nickname"`
+	Theme               string `json:"theme"`
+	NotificationEnabled *bool  `json:"notification_enabled"`
+	SoundEnabled        *bool  `json:"sound_enabled"`
+	Language            string `json:"language"`
+	AutoDownloadMedia   *bool  `json:"auto_download_media"`
+	PrivacyLastSeen     string `json:"privacy_last_seen"`
+	PrivacyProfilePhoto string `json:"privacy_profile_photo"`
+	PrivacyStatus       string `json:"privacy_status"`
+}
+
+// UploadKeysRequest is the UploadKeysRequest object of the Piko API
+type UploadKeysRequest struct {
+	IdentityKey    string                 `json:"identity_key,omitempty"`
+	SignedPrekey   *SignedPrekeyRequest   `json:"signed_prekey,omitempty"`
+	OneTimePrekeys []OneTimePrekeyRequest `json:"one_time_prekeys,omitempty"`
+}
+
+// UsageResponse is the UsageResponse object of the Piko API
+type UsageResponse struct {
+	Since          time.Time            `json:"since"`
+	Days           int                  `json:"days"`
+	MessagesPerDay []*DailyMessageCount `json:"messages_per_day"`
+	MessagesSent   int                  `json:"messages_sent"`
+	Devices        []*SessionActivity   `json:"devices"`
+	PushDevices    int                  `json:"push_devices"`
+	Storage        StorageUsageResponse `json:"storage"`
+	Actions        map[string]int       `json:"actions"`
+}
+
+// User is the User object of the Piko API
+type User struct {
+	ID        int        `json:"id"`
+	Phone     string     `json:"phone"`
+	Username  string     `json:"username,omitempty"`
+	PublicKey []byte     `json:"public_key"`
+	Address   string     `json:"address"`
+	Role      string     `json:"role"`
+	Birthdate *time.Time `json:"birthdate,omitempty"`
+	Email     string     `json:"email,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// UserResponse is the UserResponse object of the Piko API
+type UserResponse struct {
+	Address    string          `json:"address"`
+	Username   string          `json:"username,omitempty"`
+	Phone      string          `json:"phone,omitempty"`
+	Alias      string          `json:"alias,omitempty"`
+	Labels     []string        `json:"labels,omitempty"`
+	LastSeenAt *time.Time      `json:"last_seen_at,omitempty"`
+	Avatar     *AvatarResponse `json:"avatar,omitempty"`
+}
+
+// UserSettings is the UserSettings object of the Piko API
+type UserSettings struct {
+	UserID              int       `json:"user_id"`
+	Nickname            string    `json:"nickname"`
+	Theme               string    `json:"theme"`
+	NotificationEnabled bool      `json:"notification_enabled"`
+	SoundEnabled        bool      `json:"sound_enabled"`
+	Language            string    `json:"language"`
+	AutoDownloadMedia   bool      `json:"auto_download_media"`
+	PrivacyLastSeen     string    `json:"privacy_last_seen"`
+	PrivacyProfilePhoto string    `json:"privacy_profile_photo"`
+	PrivacyStatus       string    `json:"privacy_status"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+// VerifyOTPRequest is the VerifyOTPRequest object of the Piko API
+type VerifyOTPRequest struct {
+	Phone            string `json:"phone"`
+	Code             string `json:"code"`
+	AcceptedPolicies []int  `json:"accepted_policies,omitempty"`
+	Birthdate        string `json:"birthdate,omitempty"`
+	PIN              string `json:"pin,omitempty"`
+	DeviceToken      string `json:"device_token,omitempty"`
+}
+
+// VerifySafetyNumberRequest is the VerifySafetyNumberRequest object of the Piko API
+type VerifySafetyNumberRequest struct {
+	Verified     bool   `json:"verified"`
+	SafetyNumber string `json:"safety_number"`
+}
+
+// VerifySignatureRequest is the VerifySignatureRequest object of the Piko API
+type VerifySignatureRequest struct {
+	PublicKey   string `json:"public_key"`
+	Nonce       string `json:"nonce"`
+	Signature   string `json:"signature"`
+	PIN         string `json:"pin,omitempty"`
+	DeviceToken string `json:"device_token,omitempty"`
+}
+
+// VotePollRequest is the VotePollRequest object of the Piko API
+type VotePollRequest struct {
+	Option int `json:"option"`
+}
+
+// WebChannelPost is the WebChannelPost object of the Piko API
+type WebChannelPost struct {
+	ID        string     `json:"id"`
+	Text      string     `json:"text"`
+	Timestamp time.Time  `json:"timestamp"`
+	EditedAt  *time.Time `json:"edited_at,omitempty"`
+}
+
+// WebChannelResponse is the WebChannelResponse object of the Piko API
+type WebChannelResponse struct {
+	Name        string           `json:"name"`
+	Slug        string           `json:"slug"`
+	MemberCount int              `json:"member_count"`
+	Posts       []WebChannelPost `json:"posts"`
+	Page        int              `json:"page"`
+	HasNext     bool             `json:"has_next"`
+	HasPrev     bool             `json:"has_prev"`
+}
+
+// GetRegistrationChallenge calls GET /api/auth/register/challenge.
+func (c *Client) GetRegistrationChallenge(ctx context.Context) (*RegistrationChallengeResponse, error) {
+	var out RegistrationChallengeResponse
+	if err := c.do(ctx, "GET", "/api/auth/register/challenge", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Register calls POST /api/auth/register.
+func (c *Client) Register(ctx context.Context, req *RegisterRequest) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "POST", "/api/auth/register", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// VerifyRegister calls POST /api/auth/verify-register.
+func (c *Client) VerifyRegister(ctx context.Context, req *VerifyOTPRequest) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "POST", "/api/auth/verify-register", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Login calls POST /api/auth/login.
+func (c *Client) Login(ctx context.Context, req *LoginRequest) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "POST", "/api/auth/login", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// VerifyLogin calls POST /api/auth/verify-login.
+func (c *Client) VerifyLogin(ctx context.Context, req *VerifyOTPRequest) (*AuthResponse, error) {
+	var out AuthResponse
+	if err := c.do(ctx, "POST", "/api/auth/verify-login", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ResendOTP calls POST /api/auth/resend-otp.
+func (c *Client) ResendOTP(ctx context.Context, req *ResendOTPRequest) (*ResendOTPResponse, error) {
+	var out ResendOTPResponse
+	if err := c.do(ctx, "POST", "/api/auth/resend-otp", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetChallenge calls GET /api/auth/challenge.
+func (c *Client) GetChallenge(ctx context.Context) (*ChallengeResponse, error) {
+	var out ChallengeResponse
+	if err := c.do(ctx, "GET", "/api/auth/challenge", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// VerifySignature calls POST /api/auth/verify-signature.
+func (c *Client) VerifySignature(ctx context.Context, req *VerifySignatureRequest) (*AuthResponse, error) {
+	var out AuthResponse
+	if err := c.do(ctx, "POST", "/api/auth/verify-signature", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetPolicies calls GET /api/policies.
+func (c *Client) GetPolicies(ctx context.Context) ([]Policy, error) {
+	var out []Policy
+	if err := c.do(ctx, "GET", "/api/policies", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AcceptPolicies calls POST /api/policies/accept. It requires a token.
+func (c *Client) AcceptPolicies(ctx context.Context, req *AcceptPoliciesRequest) (*PendingPoliciesResponse, error) {
+	var out PendingPoliciesResponse
+	if err := c.do(ctx, "POST", "/api/policies/accept", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetPolicyAcceptances calls GET /api/policies/acceptances. It requires a token.
+func (c *Client) GetPolicyAcceptances(ctx context.Context) ([]PolicyAcceptance, error) {
+	var out []PolicyAcceptance
+	if err := c.do(ctx, "GET", "/api/policies/acceptances", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetProfile calls GET /api/profile. It requires a token.
+func (c *Client) GetProfile(ctx context.Context) (*User, error) {
+	var out User
+	if err := c.do(ctx, "GET", "/api/profile", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateProfile calls PUT /api/profile. It requires a token.
+func (c *Client) UpdateProfile(ctx context.Context, req *UpdateProfileRequest) (*User, error) {
+	var out User
+	if err := c.do(ctx, "PUT", "/api/profile", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SetUsername calls PUT /api/profile/username. It requires a token.
+func (c *Client) SetUsername(ctx context.Context, req *SetUsernameRequest) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "PUT", "/api/profile/username", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetUsage calls GET /api/profile/usage. It requires a token.
+func (c *Client) GetUsage(ctx context.Context, query url.Values) (*UsageResponse, error) {
+	var out UsageResponse
+	if err := c.do(ctx, "GET", "/api/profile/usage", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SearchUsers calls GET /api/users/search. It requires a token.
+func (c *Client) SearchUsers(ctx context.Context, query url.Values) ([]UserResponse, error) {
+	var out []UserResponse
+	if err := c.do(ctx, "GET", "/api/users/search", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetUser calls GET /api/users/:address. It requires a token.
+func (c *Client) GetUser(ctx context.Context, address string) (*UserResponse, error) {
+	var out UserResponse
+	if err := c.do(ctx, "GET", "/api/users/"+url.PathEscape(address), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetUserAvatar calls GET /api/users/:address/avatar. It requires a token.
+func (c *Client) GetUserAvatar(ctx context.Context, address string) (*AvatarResponse, error) {
+	var out AvatarResponse
+	if err := c.do(ctx, "GET", "/api/users/"+url.PathEscape(address)+"/avatar", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetSessions calls GET /api/sessions. It requires a token.
+func (c *Client) GetSessions(ctx context.Context) ([]SessionResponse, error) {
+	var out []SessionResponse
+	if err := c.do(ctx, "GET", "/api/sessions", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RevokeOtherSessions calls DELETE /api/sessions. It requires a token.
+func (c *Client) RevokeOtherSessions(ctx context.Context) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "DELETE", "/api/sessions", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RevokeSession calls DELETE /api/sessions/:id. It requires a token.
+func (c *Client) RevokeSession(ctx context.Context, id string) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "DELETE", "/api/sessions/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// WipeSession calls POST /api/sessions/:id/wipe. It requires a token.
+func (c *Client) WipeSession(ctx context.Context, id string) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "POST", "/api/sessions/"+url.PathEscape(id)+"/wipe", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetSecurityLog calls GET /api/security/log. It requires a token.
+func (c *Client) GetSecurityLog(ctx context.Context) (*SecurityLogResponse, error) {
+	var out SecurityLogResponse
+	if err := c.do(ctx, "GET", "/api/security/log", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetPINStatus calls GET /api/security/pin. It requires a token.
+func (c *Client) GetPINStatus(ctx context.Context) (*PINStatusResponse, error) {
+	var out PINStatusResponse
+	if err := c.do(ctx, "GET", "/api/security/pin", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SetPIN calls PUT /api/security/pin. It requires a token.
+func (c *Client) SetPIN(ctx context.Context, req *SetPINRequest) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "PUT", "/api/security/pin", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RemovePIN calls DELETE /api/security/pin. It requires a token.
+func (c *Client) RemovePIN(ctx context.Context, req *RemovePINRequest) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "DELETE", "/api/security/pin", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CreateRecoveryCode calls POST /api/security/recovery-code. It requires a token.
+func (c *Client) CreateRecoveryCode(ctx context.Context) (*RecoveryCodeResponse, error) {
+	var out RecoveryCodeResponse
+	if err := c.do(ctx, "POST", "/api/security/recovery-code", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// FreezeAccount calls POST /api/security/freeze.
+func (c *Client) FreezeAccount(ctx context.Context, req *FreezeAccountRequest) (*FreezeAccountResponse, error) {
+	var out FreezeAccountResponse
+	if err := c.do(ctx, "POST", "/api/security/freeze", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SaveContact calls POST /api/contacts. It requires a token.
+func (c *Client) SaveContact(ctx context.Context, req *SaveContactRequest) (*Contact, error) {
+	var out Contact
+	if err := c.do(ctx, "POST", "/api/contacts", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetContacts calls GET /api/contacts. It requires a token.
+func (c *Client) GetContacts(ctx context.Context, query url.Values) ([]Contact, error) {
+	var out []Contact
+	if err := c.do(ctx, "GET", "/api/contacts", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DiscoverContacts calls POST /api/contacts/discover. It requires a token.
+func (c *Client) DiscoverContacts(ctx context.Context, req *DiscoverContactsRequest) ([]DiscoveredContact, error) {
+	var out []DiscoveredContact
+	if err := c.do(ctx, "POST", "/api/contacts/discover", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DeleteContact calls DELETE /api/contacts/:address. It requires a token.
+func (c *Client) DeleteContact(ctx context.Context, address string) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "DELETE", "/api/contacts/"+url.PathEscape(address), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CreateReport calls POST /api/reports. It requires a token.
+func (c *Client) CreateReport(ctx context.Context, req *CreateReportRequest) (*Report, error) {
+	var out Report
+	if err := c.do(ctx, "POST", "/api/reports", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateSupportTicket calls POST /api/support. It requires a token.
+func (c *Client) CreateSupportTicket(ctx context.Context, req *CreateSupportTicketRequest) (*CreateSupportTicketResponse, error) {
+	var out CreateSupportTicketResponse
+	if err := c.do(ctx, "POST", "/api/support", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetSupportTickets calls GET /api/support. It requires a token.
+func (c *Client) GetSupportTickets(ctx context.Context) ([]SupportTicket, error) {
+	var out []SupportTicket
+	if err := c.do(ctx, "GET", "/api/support", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RegisterDevice calls POST /api/devices. It requires a token.
+func (c *Client) RegisterDevice(ctx context.Context, req *RegisterDeviceRequest) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "POST", "/api/devices", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UnregisterDevice calls DELETE /api/devices/:token. It requires a token.
+func (c *Client) UnregisterDevice(ctx context.Context, token string) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "DELETE", "/api/devices/"+url.PathEscape(token), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetUserSettings calls GET /api/settings. It requires a token.
+func (c *Client) GetUserSettings(ctx context.Context) (*UserSettings, error) {
+	var out UserSettings
+	if err := c.do(ctx, "GET", "/api/settings", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateUserSettings calls PUT /api/settings. It requires a token.
+func (c *Client) UpdateUserSettings(ctx context.Context, req *UpdateUserSettingsRequest) (*UserSettings, error) {
+	var out UserSettings
+	if err := c.do(ctx, "PUT", "/api/settings", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateNickname calls PUT /api/settings/nickname. It requires a token.
+func (c *Client) UpdateNickname(ctx context.Context, req *UpdateNicknameRequest) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "PUT", "/api/settings/nickname", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SetDoNotDisturb calls PUT /api/settings/dnd. It requires a token.
+func (c *Client) SetDoNotDisturb(ctx context.Context, req *DoNotDisturbRequest) (*DoNotDisturb, error) {
+	var out DoNotDisturb
+	if err := c.do(ctx, "PUT", "/api/settings/dnd", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ClearDoNotDisturb calls DELETE /api/settings/dnd. It requires a token.
+func (c *Client) ClearDoNotDisturb(ctx context.Context) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "DELETE", "/api/settings/dnd", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetMutes calls GET /api/mutes. It requires a token.
+func (c *Client) GetMutes(ctx context.Context) (*MutesResponse, error) {
+	var out MutesResponse
+	if err := c.do(ctx, "GET", "/api/mutes", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// MuteConversation calls PUT /api/mutes. It requires a token.
+func (c *Client) MuteConversation(ctx context.Context, req *MuteRequest) (*Mute, error) {
+	var out Mute
+	if err := c.do(ctx, "PUT", "/api/mutes", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UnmuteConversation calls DELETE /api/mutes/:conversation_type/:conversation_id. It requires a token.
+func (c *Client) UnmuteConversation(ctx context.Context, conversationType string, conversationID string) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "DELETE", "/api/mutes/"+url.PathEscape(conversationType)+"/"+url.PathEscape(conversationID), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UploadAvatar calls POST /api/avatars. It requires a token.
+func (c *Client) UploadAvatar(ctx context.Context, contentType string, body io.Reader) (*AvatarResponse, error) {
+	var out AvatarResponse
+	if err := c.upload(ctx, "POST", "/api/avatars", nil, contentType, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetUserAvatars calls GET /api/avatars. It requires a token.
+func (c *Client) GetUserAvatars(ctx context.Context) ([]AvatarResponse, error) {
+	var out []AvatarResponse
+	if err := c.do(ctx, "GET", "/api/avatars", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetActiveAvatar calls GET /api/avatars/active. It requires a token.
+func (c *Client) GetActiveAvatar(ctx context.Context) (*AvatarResponse, error) {
+	var out AvatarResponse
+	if err := c.do(ctx, "GET", "/api/avatars/active", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SetActiveAvatar calls PUT /api/avatars/:id/active. It requires a token.
+func (c *Client) SetActiveAvatar(ctx context.Context, id string) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "PUT", "/api/avatars/"+url.PathEscape(id)+"/active", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DeleteAvatar calls DELETE /api/avatars/:id. It requires a token.
+func (c *Client) DeleteAvatar(ctx context.Context, id string) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "DELETE", "/api/avatars/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ServeAvatar calls GET /api/avatars/:id/file and returns the raw response. The caller must close its body.
+func (c *Client) ServeAvatar(ctx context.Context, id string, query url.Values) (*http.Response, error) {
+	return c.download(ctx, "GET", "/api/avatars/"+url.PathEscape(id)+"/file", query)
+}
+
+// SendMessage calls POST /api/messages. It requires a token.
+func (c *Client) SendMessage(ctx context.Context, req *SendMessageRequest) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "POST", "/api/messages", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetInbox calls GET /api/messages/inbox. It requires a token.
+func (c *Client) GetInbox(ctx context.Context, query url.Values) (*MessagesResponse, error) {
+	var out MessagesResponse
+	if err := c.do(ctx, "GET", "/api/messages/inbox", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetSentMessages calls GET /api/messages/sent. It requires a token.
+func (c *Client) GetSentMessages(ctx context.Context, query url.Values) (*MessagesResponse, error) {
+	var out MessagesResponse
+	if err := c.do(ctx, "GET", "/api/messages/sent", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateMessageStatuses calls POST /api/messages/status. It requires a token.
+func (c *Client) UpdateMessageStatuses(ctx context.Context, req *UpdateMessageStatusesRequest) (*UpdateMessageStatusesResponse, error) {
+	var out UpdateMessageStatusesResponse
+	if err := c.do(ctx, "POST", "/api/messages/status", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetMessage calls GET /api/messages/:id. It requires a token.
+func (c *Client) GetMessage(ctx context.Context, id string) (*MessageResponse, error) {
+	var out MessageResponse
+	if err := c.do(ctx, "GET", "/api/messages/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// EditMessage calls PUT /api/messages/:id. It requires a token.
+func (c *Client) EditMessage(ctx context.Context, id string, req *EditMessageRequest) (*MessageResponse, error) {
+	var out MessageResponse
+	if err := c.do(ctx, "PUT", "/api/messages/"+url.PathEscape(id), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetMessageEdits calls GET /api/messages/:id/edits. It requires a token.
+func (c *Client) GetMessageEdits(ctx context.Context, id string) ([]MessageEditResponse, error) {
+	var out []MessageEditResponse
+	if err := c.do(ctx, "GET", "/api/messages/"+url.PathEscape(id)+"/edits", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetMessageReceipts calls GET /api/messages/:id/receipts. It requires a token.
+func (c *Client) GetMessageReceipts(ctx context.Context, id string) (*MessageReceiptsResponse, error) {
+	var out MessageReceiptsResponse
+	if err := c.do(ctx, "GET", "/api/messages/"+url.PathEscape(id)+"/receipts", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ForwardMessage calls POST /api/messages/:id/forward. It requires a token.
+func (c *Client) ForwardMessage(ctx context.Context, id string, req *ForwardMessageRequest) (*ForwardMessageResponse, error) {
+	var out ForwardMessageResponse
+	if err := c.do(ctx, "POST", "/api/messages/"+url.PathEscape(id)+"/forward", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteMessage calls DELETE /api/messages/:id. It requires a token.
+func (c *Client) DeleteMessage(ctx context.Context, id string) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "DELETE", "/api/messages/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetReceipts calls GET /api/receipts. It requires a token.
+func (c *Client) GetReceipts(ctx context.Context, query url.Values) (*ReceiptsResponse, error) {
+	var out ReceiptsResponse
+	if err := c.do(ctx, "GET", "/api/receipts", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateBroadcastList calls POST /api/broadcasts. It requires a token.
+func (c *Client) CreateBroadcastList(ctx context.Context, req *CreateBroadcastListRequest) (*BroadcastList, error) {
+	var out BroadcastList
+	if err := c.do(ctx, "POST", "/api/broadcasts", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetBroadcastLists calls GET /api/broadcasts. It requires a token.
+func (c *Client) GetBroadcastLists(ctx context.Context) ([]BroadcastList, error) {
+	var out []BroadcastList
+	if err := c.do(ctx, "GET", "/api/broadcasts", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetBroadcastList calls GET /api/broadcasts/:id. It requires a token.
+func (c *Client) GetBroadcastList(ctx context.Context, id string) (*BroadcastList, error) {
+	var out BroadcastList
+	if err := c.do(ctx, "GET", "/api/broadcasts/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RenameBroadcastList calls PUT /api/broadcasts/:id. It requires a token.
+func (c *Client) RenameBroadcastList(ctx context.Context, id string, req *RenameBroadcastListRequest) (*BroadcastList, error) {
+	var out BroadcastList
+	if err := c.do(ctx, "PUT", "/api/broadcasts/"+url.PathEscape(id), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteBroadcastList calls DELETE /api/broadcasts/:id. It requires a token.
+func (c *Client) DeleteBroadcastList(ctx context.Context, id string) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "DELETE", "/api/broadcasts/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AddBroadcastRecipients calls POST /api/broadcasts/:id/recipients. It requires a token.
+func (c *Client) AddBroadcastRecipients(ctx context.Context, id string, req *AddBroadcastRecipientsRequest) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "POST", "/api/broadcasts/"+url.PathEscape(id)+"/recipients", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RemoveBroadcastRecipient calls DELETE /api/broadcasts/:id/recipients/:address. It requires a token.
+func (c *Client) RemoveBroadcastRecipient(ctx context.Context, id string, address string) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "DELETE", "/api/broadcasts/"+url.PathEscape(id)+"/recipients/"+url.PathEscape(address), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SendBroadcast calls POST /api/broadcasts/:id/messages. It requires a token.
+func (c *Client) SendBroadcast(ctx context.Context, id string, req *SendBroadcastRequest) (*Broadcast, error) {
+	var out Broadcast
+	if err := c.do(ctx, "POST", "/api/broadcasts/"+url.PathEscape(id)+"/messages", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetBroadcast calls GET /api/broadcasts/:id/messages/:broadcast_id. It requires a token.
+func (c *Client) GetBroadcast(ctx context.Context, id string, broadcastID string) (*Broadcast, error) {
+	var out Broadcast
+	if err := c.do(ctx, "GET", "/api/broadcasts/"+url.PathEscape(id)+"/messages/"+url.PathEscape(broadcastID), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateDoc calls POST /api/docs. It requires a token.
+func (c *Client) CreateDoc(ctx context.Context, req *CreateDocRequest) (*CollabDoc, error) {
+	var out CollabDoc
+	if err := c.do(ctx, "POST", "/api/docs", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetDoc calls GET /api/docs/:id. It requires a token.
+func (c *Client) GetDoc(ctx context.Context, id string) (*CollabDoc, error) {
+	var out CollabDoc
+	if err := c.do(ctx, "GET", "/api/docs/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AppendDocUpdate calls POST /api/docs/:id/updates. It requires a token.
+func (c *Client) AppendDocUpdate(ctx context.Context, id string, req *AppendDocUpdateRequest) (*DocUpdateResponse, error) {
+	var out DocUpdateResponse
+	if err := c.do(ctx, "POST", "/api/docs/"+url.PathEscape(id)+"/updates", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetDocUpdates calls GET /api/docs/:id/updates. It requires a token.
+func (c *Client) GetDocUpdates(ctx context.Context, id string, query url.Values) (*DocUpdatesResponse, error) {
+	var out DocUpdatesResponse
+	if err := c.do(ctx, "GET", "/api/docs/"+url.PathEscape(id)+"/updates", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreatePoll calls POST /api/polls. It requires a token.
+func (c *Client) CreatePoll(ctx context.Context, req *CreatePollRequest) (*PollResponse, error) {
+	var out PollResponse
+	if err := c.do(ctx, "POST", "/api/polls", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetPoll calls GET /api/polls/:id. It requires a token.
+func (c *Client) GetPoll(ctx context.Context, id string) (*PollResponse, error) {
+	var out PollResponse
+	if err := c.do(ctx, "GET", "/api/polls/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// VotePoll calls PUT /api/polls/:id/vote. It requires a token.
+func (c *Client) VotePoll(ctx context.Context, id string, req *VotePollRequest) (*PollResponse, error) {
+	var out PollResponse
+	if err := c.do(ctx, "PUT", "/api/polls/"+url.PathEscape(id)+"/vote", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RetractPollVote calls DELETE /api/polls/:id/vote. It requires a token.
+func (c *Client) RetractPollVote(ctx context.Context, id string) (*PollResponse, error) {
+	var out PollResponse
+	if err := c.do(ctx, "DELETE", "/api/polls/"+url.PathEscape(id)+"/vote", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ClosePoll calls POST /api/polls/:id/close. It requires a token.
+func (c *Client) ClosePoll(ctx context.Context, id string) (*PollResponse, error) {
+	var out PollResponse
+	if err := c.do(ctx, "POST", "/api/polls/"+url.PathEscape(id)+"/close", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateStickerPack calls POST /api/sticker-packs. It requires a token.
+func (c *Client) CreateStickerPack(ctx context.Context, req *CreateStickerPackRequest) (*StickerPackResponse, error) {
+	var out StickerPackResponse
+	if err := c.do(ctx, "POST", "/api/sticker-packs", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetStickerPacks calls GET /api/sticker-packs. It requires a token.
+func (c *Client) GetStickerPacks(ctx context.Context) ([]StickerPackResponse, error) {
+	var out []StickerPackResponse
+	if err := c.do(ctx, "GET", "/api/sticker-packs", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetStickerPack calls GET /api/sticker-packs/:id. It requires a token.
+func (c *Client) GetStickerPack(ctx context.Context, id string) (*StickerPackResponse, error) {
+	var out StickerPackResponse
+	if err := c.do(ctx, "GET", "/api/sticker-packs/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteStickerPack calls DELETE /api/sticker-packs/:id. It requires a token.
+func (c *Client) DeleteStickerPack(ctx context.Context, id string) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "DELETE", "/api/sticker-packs/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AddSticker calls POST /api/sticker-packs/:id/stickers. It requires a token.
+func (c *Client) AddSticker(ctx context.Context, id string, req *AddStickerRequest) (*StickerResponse, error) {
+	var out StickerResponse
+	if err := c.do(ctx, "POST", "/api/sticker-packs/"+url.PathEscape(id)+"/stickers", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteSticker calls DELETE /api/sticker-packs/:id/stickers/:sticker_id. It requires a token.
+func (c *Client) DeleteSticker(ctx context.Context, id string, stickerID string) (*StickerPackResponse, error) {
+	var out StickerPackResponse
+	if err := c.do(ctx, "DELETE", "/api/sticker-packs/"+url.PathEscape(id)+"/stickers/"+url.PathEscape(stickerID), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// InstallStickerPack calls PUT /api/sticker-packs/:id/install. It requires a token.
+func (c *Client) InstallStickerPack(ctx context.Context, id string) (*StickerPackResponse, error) {
+	var out StickerPackResponse
+	if err := c.do(ctx, "PUT", "/api/sticker-packs/"+url.PathEscape(id)+"/install", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UninstallStickerPack calls DELETE /api/sticker-packs/:id/install. It requires a token.
+func (c *Client) UninstallStickerPack(ctx context.Context, id string) (*StickerPackResponse, error) {
+	var out StickerPackResponse
+	if err := c.do(ctx, "DELETE", "/api/sticker-packs/"+url.PathEscape(id)+"/install", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetSticker calls GET /api/stickers/:id. It requires a token.
+func (c *Client) GetSticker(ctx context.Context, id string) (*StickerResponse, error) {
+	var out StickerResponse
+	if err := c.do(ctx, "GET", "/api/stickers/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetSafetyNumber calls GET /api/conversations/:address/safety-number. It requires a token.
+func (c *Client) GetSafetyNumber(ctx context.Context, address string) (*SafetyNumberResponse, error) {
+	var out SafetyNumberResponse
+	if err := c.do(ctx, "GET", "/api/conversations/"+url.PathEscape(address)+"/safety-number", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetDirectDocs calls GET /api/conversations/:address/docs. It requires a token.
+func (c *Client) GetDirectDocs(ctx context.Context, address string) ([]CollabDoc, error) {
+	var out []CollabDoc
+	if err := c.do(ctx, "GET", "/api/conversations/"+url.PathEscape(address)+"/docs", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// VerifySafetyNumber calls PUT /api/conversations/:address/safety-number. It requires a token.
+func (c *Client) VerifySafetyNumber(ctx context.Context, address string, req *VerifySafetyNumberRequest) (*SafetyNumberResponse, error) {
+	var out SafetyNumberResponse
+	if err := c.do(ctx, "PUT", "/api/conversations/"+url.PathEscape(address)+"/safety-number", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UploadKeys calls POST /api/keys. It requires a token.
+func (c *Client) UploadKeys(ctx context.Context, req *UploadKeysRequest) (*KeyStatusResponse, error) {
+	var out KeyStatusResponse
+	if err := c.do(ctx, "POST", "/api/keys", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetKeyStatus calls GET /api/keys. It requires a token.
+func (c *Client) GetKeyStatus(ctx context.Context) (*KeyStatusResponse, error) {
+	var out KeyStatusResponse
+	if err := c.do(ctx, "GET", "/api/keys", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetKeyRotation calls GET /api/keys/rotation. It requires a token.
+func (c *Client) GetKeyRotation(ctx context.Context) (*KeyRotation, error) {
+	var out KeyRotation
+	if err := c.do(ctx, "GET", "/api/keys/rotation", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetPendingKeyRotations calls GET /api/keys/rotations/pending. It requires a token.
+func (c *Client) GetPendingKeyRotations(ctx context.Context) ([]KeyRotation, error) {
+	var out []KeyRotation
+	if err := c.do(ctx, "GET", "/api/keys/rotations/pending", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AcknowledgeKeyRotation calls POST /api/keys/rotations/:id/ack. It requires a token.
+func (c *Client) AcknowledgeKeyRotation(ctx context.Context, id string) (*KeyRotation, error) {
+	var out KeyRotation
+	if err := c.do(ctx, "POST", "/api/keys/rotations/"+url.PathEscape(id)+"/ack", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetKeyBundle calls GET /api/keys/:address. It requires a token.
+func (c *Client) GetKeyBundle(ctx context.Context, address string) (*KeyBundleResponse, error) {
+	var out KeyBundleResponse
+	if err := c.do(ctx, "GET", "/api/keys/"+url.PathEscape(address), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ExportConversation calls GET /api/conversations/:address/export and returns the raw response. The caller must close its body. It requires a token.
+func (c *Client) ExportConversation(ctx context.Context, address string) (*http.Response, error) {
+	return c.download(ctx, "GET", "/api/conversations/"+url.PathEscape(address)+"/export", nil)
+}
+
+// UploadMedia calls POST /api/media. It requires a token.
+func (c *Client) UploadMedia(ctx context.Context, contentType string, body io.Reader) (*MediaResponse, error) {
+	var out MediaResponse
+	if err := c.upload(ctx, "POST", "/api/media", nil, contentType, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateMediaUpload calls POST /api/media/uploads. It requires a token.
+func (c *Client) CreateMediaUpload(ctx context.Context, req *CreateMediaUploadRequest) (*MediaUploadResponse, error) {
+	var out MediaUploadResponse
+	if err := c.do(ctx, "POST", "/api/media/uploads", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UploadMediaChunk calls PUT /api/media/uploads/:id. It requires a token.
+func (c *Client) UploadMediaChunk(ctx context.Context, id string, contentType string, body io.Reader, query url.Values) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.upload(ctx, "PUT", "/api/media/uploads/"+url.PathEscape(id), query, contentType, body, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetMedia calls GET /api/media/:id. It requires a token.
+func (c *Client) GetMedia(ctx context.Context, id string) (*MediaResponse, error) {
+	var out MediaResponse
+	if err := c.do(ctx, "GET", "/api/media/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DownloadMedia calls GET /api/media/:id/download and returns the raw response. The caller must close its body.
+func (c *Client) DownloadMedia(ctx context.Context, id string, query url.Values) (*http.Response, error) {
+	return c.download(ctx, "GET", "/api/media/"+url.PathEscape(id)+"/download", query)
+}
+
+// CreateChannel calls POST /api/channels. It requires a token.
+func (c *Client) CreateChannel(ctx context.Context, req *CreateChannelRequest) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "POST", "/api/channels", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetChannels calls GET /api/channels. It requires a token.
+func (c *Client) GetChannels(ctx context.Context) ([]ChannelResponse, error) {
+	var out []ChannelResponse
+	if err := c.do(ctx, "GET", "/api/channels", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DiscoverChannels calls GET /api/channels/discover. It requires a token.
+func (c *Client) DiscoverChannels(ctx context.Context, query url.Values) ([]DiscoverChannelResponse, error) {
+	var out []DiscoverChannelResponse
+	if err := c.do(ctx, "GET", "/api/channels/discover", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// JoinChannelByLink calls POST /api/channels/join/:token. It requires a token.
+func (c *Client) JoinChannelByLink(ctx context.Context, token string) (*ChannelResponse, error) {
+	var out ChannelResponse
+	if err := c.do(ctx, "POST", "/api/channels/join/"+url.PathEscape(token), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetChannel calls GET /api/channels/:id. It requires a token.
+func (c *Client) GetChannel(ctx context.Context, id string) (*ChannelResponse, error) {
+	var out ChannelResponse
+	if err := c.do(ctx, "GET", "/api/channels/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateChannel calls PUT /api/channels/:id. It requires a token.
+func (c *Client) UpdateChannel(ctx context.Context, id string, req *UpdateChannelRequest) (*ChannelResponse, error) {
+	var out ChannelResponse
+	if err := c.do(ctx, "PUT", "/api/channels/"+url.PathEscape(id), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteChannel calls DELETE /api/channels/:id. It requires a token.
+func (c *Client) DeleteChannel(ctx context.Context, id string) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "DELETE", "/api/channels/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AddChannelMember calls POST /api/channels/:id/members. It requires a token.
+func (c *Client) AddChannelMember(ctx context.Context, id string, req *AddChannelMemberRequest) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "POST", "/api/channels/"+url.PathEscape(id)+"/members", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetChannelMembers calls GET /api/channels/:id/members. It requires a token.
+func (c *Client) GetChannelMembers(ctx context.Context, id string) ([]ChannelMemberResponse, error) {
+	var out []ChannelMemberResponse
+	if err := c.do(ctx, "GET", "/api/channels/"+url.PathEscape(id)+"/members", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetChannelAuditLog calls GET /api/channels/:id/audit-log. It requires a token.
+func (c *Client) GetChannelAuditLog(ctx context.Context, id string, query url.Values) ([]AuditEntry, error) {
+	var out []AuditEntry
+	if err := c.do(ctx, "GET", "/api/channels/"+url.PathEscape(id)+"/audit-log", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CreateChannelInviteLink calls POST /api/channels/:id/invite-link. It requires a token.
+func (c *Client) CreateChannelInviteLink(ctx context.Context, id string) (*ChannelInviteLinkResponse, error) {
+	var out ChannelInviteLinkResponse
+	if err := c.do(ctx, "POST", "/api/channels/"+url.PathEscape(id)+"/invite-link", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// RemoveChannelMember calls DELETE /api/channels/:id/members/:address. It requires a token.
+func (c *Client) RemoveChannelMember(ctx context.Context, id string, address string) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "DELETE", "/api/channels/"+url.PathEscape(id)+"/members/"+url.PathEscape(address), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UpdateChannelMemberRole calls PUT /api/channels/:id/members/:address/role. It requires a token.
+func (c *Client) UpdateChannelMemberRole(ctx context.Context, id string, address string, req *UpdateChannelMemberRoleRequest) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "PUT", "/api/channels/"+url.PathEscape(id)+"/members/"+url.PathEscape(address)+"/role", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SendChannelMessage calls POST /api/channels/:id/messages. It requires a token.
+func (c *Client) SendChannelMessage(ctx context.Context, id string, req *ChannelMessageRequest) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "POST", "/api/channels/"+url.PathEscape(id)+"/messages", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetChannelMessages calls GET /api/channels/:id/messages. It requires a token.
+func (c *Client) GetChannelMessages(ctx context.Context, id string, query url.Values) ([]ChannelMessageResponse, error) {
+	var out []ChannelMessageResponse
+	if err := c.do(ctx, "GET", "/api/channels/"+url.PathEscape(id)+"/messages", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// EditChannelMessage calls PUT /api/channels/:id/messages/:message_id. It requires a token.
+func (c *Client) EditChannelMessage(ctx context.Context, id string, messageID string, req *EditMessageRequest) (*ChannelMessageResponse, error) {
+	var out ChannelMessageResponse
+	if err := c.do(ctx, "PUT", "/api/channels/"+url.PathEscape(id)+"/messages/"+url.PathEscape(messageID), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetChannelMessageLink calls GET /api/channels/:id/messages/:message_id/link. It requires a token.
+func (c *Client) GetChannelMessageLink(ctx context.Context, id string, messageID string) (*MessageLinkResponse, error) {
+	var out MessageLinkResponse
+	if err := c.do(ctx, "GET", "/api/channels/"+url.PathEscape(id)+"/messages/"+url.PathEscape(messageID)+"/link", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CrosspostChannelMessage calls POST /api/channels/:id/crosspost. It requires a token.
+func (c *Client) CrosspostChannelMessage(ctx context.Context, id string, req *CrosspostRequest) (*CrosspostResponse, error) {
+	var out CrosspostResponse
+	if err := c.do(ctx, "POST", "/api/channels/"+url.PathEscape(id)+"/crosspost", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ResolveMessageLink calls GET /api/links/channel/:id/:message_id.
+func (c *Client) ResolveMessageLink(ctx context.Context, id string, messageID string) (*ResolvedMessageLinkResponse, error) {
+	var out ResolvedMessageLinkResponse
+	if err := c.do(ctx, "GET", "/api/links/channel/"+url.PathEscape(id)+"/"+url.PathEscape(messageID), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// MarkChannelRead calls POST /api/channels/:id/read. It requires a token.
+func (c *Client) MarkChannelRead(ctx context.Context, id string, req *MarkReadRequest) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "POST", "/api/channels/"+url.PathEscape(id)+"/read", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetChannelDocs calls GET /api/channels/:id/docs. It requires a token.
+func (c *Client) GetChannelDocs(ctx context.Context, id string) ([]CollabDoc, error) {
+	var out []CollabDoc
+	if err := c.do(ctx, "GET", "/api/channels/"+url.PathEscape(id)+"/docs", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetChannelPolls calls GET /api/channels/:id/polls. It requires a token.
+func (c *Client) GetChannelPolls(ctx context.Context, id string, query url.Values) ([]PollResponse, error) {
+	var out []PollResponse
+	if err := c.do(ctx, "GET", "/api/channels/"+url.PathEscape(id)+"/polls", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AckChannelMessages calls POST /api/channels/:id/ack. It requires a token.
+func (c *Client) AckChannelMessages(ctx context.Context, id string, req *AckChannelMessagesRequest) (*AckChannelMessagesResponse, error) {
+	var out AckChannelMessagesResponse
+	if err := c.do(ctx, "POST", "/api/channels/"+url.PathEscape(id)+"/ack", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetChannelStats calls GET /api/channels/:id/stats. It requires a token.
+func (c *Client) GetChannelStats(ctx context.Context, id string, query url.Values) (*ChannelStats, error) {
+	var out ChannelStats
+	if err := c.do(ctx, "GET", "/api/channels/"+url.PathEscape(id)+"/stats", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetChannelFlag calls GET /api/channels/:id/moderation. It requires a token.
+func (c *Client) GetChannelFlag(ctx context.Context, id string) (*ChannelFlag, error) {
+	var out ChannelFlag
+	if err := c.do(ctx, "GET", "/api/channels/"+url.PathEscape(id)+"/moderation", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AppealChannelFlag calls POST /api/channels/:id/appeal. It requires a token.
+func (c *Client) AppealChannelFlag(ctx context.Context, id string, req *AppealChannelFlagRequest) (*ChannelFlag, error) {
+	var out ChannelFlag
+	if err := c.do(ctx, "POST", "/api/channels/"+url.PathEscape(id)+"/appeal", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetChannelWebView calls GET /api/channels/:id/web-view. It requires a token.
+func (c *Client) GetChannelWebView(ctx context.Context, id string) (*ChannelWebViewResponse, error) {
+	var out ChannelWebViewResponse
+	if err := c.do(ctx, "GET", "/api/channels/"+url.PathEscape(id)+"/web-view", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SetChannelWebView calls PUT /api/channels/:id/web-view. It requires a token.
+func (c *Client) SetChannelWebView(ctx context.Context, id string, req *ChannelWebViewRequest) (*ChannelWebViewResponse, error) {
+	var out ChannelWebViewResponse
+	if err := c.do(ctx, "PUT", "/api/channels/"+url.PathEscape(id)+"/web-view", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteChannelWebView calls DELETE /api/channels/:id/web-view. It requires a token.
+func (c *Client) DeleteChannelWebView(ctx context.Context, id string) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "DELETE", "/api/channels/"+url.PathEscape(id)+"/web-view", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DeleteChannelMessage calls DELETE /api/channels/:channel_id/messages/:message_id. It requires a token.
+func (c *Client) DeleteChannelMessage(ctx context.Context, channelID string, messageID string) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "DELETE", "/api/channels/"+url.PathEscape(channelID)+"/messages/"+url.PathEscape(messageID), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetWebChannel calls GET /api/web/channels/:slug.
+func (c *Client) GetWebChannel(ctx context.Context, slug string, query url.Values) (*WebChannelResponse, error) {
+	var out WebChannelResponse
+	if err := c.do(ctx, "GET", "/api/web/channels/"+url.PathEscape(slug), query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetChannelWebPage calls GET /c/:slug and returns the raw response. The caller must close its body.
+func (c *Client) GetChannelWebPage(ctx context.Context, slug string, query url.Values) (*http.Response, error) {
+	return c.download(ctx, "GET", "/c/"+url.PathEscape(slug), query)
+}
+
+// ListBlocks calls GET /api/blocks. It requires a token.
+func (c *Client) ListBlocks(ctx context.Context, query url.Values) (*BlocksResponse, error) {
+	var out BlocksResponse
+	if err := c.do(ctx, "GET", "/api/blocks", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetBlock calls GET /api/blocks/:id. It requires a token.
+func (c *Client) GetBlock(ctx context.Context, id string) (*Block, error) {
+	var out Block
+	if err := c.do(ctx, "GET", "/api/blocks/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetBlockByHeight calls GET /api/blocks/height/:height. It requires a token.
+func (c *Client) GetBlockByHeight(ctx context.Context, height string) (*Block, error) {
+	var out Block
+	if err := c.do(ctx, "GET", "/api/blocks/height/"+url.PathEscape(height), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListBlockTransactions calls GET /api/blocks/:id/transactions. It requires a token.
+func (c *Client) ListBlockTransactions(ctx context.Context, id string, query url.Values) (*TransactionsResponse, error) {
+	var out TransactionsResponse
+	if err := c.do(ctx, "GET", "/api/blocks/"+url.PathEscape(id)+"/transactions", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListTransactions calls GET /api/transactions. It requires a token.
+func (c *Client) ListTransactions(ctx context.Context, query url.Values) (*TransactionsResponse, error) {
+	var out TransactionsResponse
+	if err := c.do(ctx, "GET", "/api/transactions", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetTransaction calls GET /api/transactions/:hash. It requires a token.
+func (c *Client) GetTransaction(ctx context.Context, hash string) (*Transaction, error) {
+	var out Transaction
+	if err := c.do(ctx, "GET", "/api/transactions/"+url.PathEscape(hash), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ExploreAddress calls GET /api/explore/:address. It requires a token.
+func (c *Client) ExploreAddress(ctx context.Context, address string) ([]Transaction, error) {
+	var out []Transaction
+	if err := c.do(ctx, "GET", "/api/explore/"+url.PathEscape(address), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetProof calls GET /api/proof/:message_id. It requires a token.
+func (c *Client) GetProof(ctx context.Context, messageID string) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "GET", "/api/proof/"+url.PathEscape(messageID), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetBlockchainStats calls GET /api/blockchain/stats. It requires a token.
+func (c *Client) GetBlockchainStats(ctx context.Context) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "GET", "/api/blockchain/stats", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetConsensusParams calls GET /api/blockchain/params. It requires a token.
+func (c *Client) GetConsensusParams(ctx context.Context) (*ConsensusParamsResponse, error) {
+	var out ConsensusParamsResponse
+	if err := c.do(ctx, "GET", "/api/blockchain/params", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// VerifyChain calls GET /api/blockchain/verify. It requires a token.
+func (c *Client) VerifyChain(ctx context.Context) (*ChainReport, error) {
+	var out ChainReport
+	if err := c.do(ctx, "GET", "/api/blockchain/verify", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetSecretChatChallenge calls GET /api/secret-chat/challenge.
+func (c *Client) GetSecretChatChallenge(ctx context.Context) (*SecretChatChallengeResponse, error) {
+	var out SecretChatChallengeResponse
+	if err := c.do(ctx, "GET", "/api/secret-chat/challenge", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateSecretChat calls POST /api/secret-chat/create.
+func (c *Client) CreateSecretChat(ctx context.Context, req *CreateSecretChatRequest) (*CreateSecretChatResponse, error) {
+	var out CreateSecretChatResponse
+	if err := c.do(ctx, "POST", "/api/secret-chat/create", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// JoinSecretChat calls POST /api/secret-chat/join.
+func (c *Client) JoinSecretChat(ctx context.Context, req *JoinSecretChatRequest) (*JoinSecretChatResponse, error) {
+	var out JoinSecretChatResponse
+	if err := c.do(ctx, "POST", "/api/secret-chat/join", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SendSecretChatMessage calls POST /api/secret-chat/send.
+func (c *Client) SendSecretChatMessage(ctx context.Context, req *SecretChatMessageRequest) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "POST", "/api/secret-chat/send", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetSecretChatMessages calls GET /api/secret-chat/messages/:channel_id.
+func (c *Client) GetSecretChatMessages(ctx context.Context, channelID string, query url.Values) ([]SecretChatMessageResponse, error) {
+	var out []SecretChatMessageResponse
+	if err := c.do(ctx, "GET", "/api/secret-chat/messages/"+url.PathEscape(channelID), query, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DeleteSecretChat calls DELETE /api/secret-chat/:channel_id.
+func (c *Client) DeleteSecretChat(ctx context.Context, channelID string, query url.Values) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "DELETE", "/api/secret-chat/"+url.PathEscape(channelID), query, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SecretChatWebSocketURL returns the URL of the /ws/secret/:session_id WebSocket.
+func (c *Client) SecretChatWebSocketURL(sessionID string) string {
+	return c.webSocketURL("/ws/secret/"+url.PathEscape(sessionID), nil)
+}
+
+// WebSocketURL returns the URL of the /ws WebSocket.
+func (c *Client) WebSocketURL(query url.Values) string {
+	return c.webSocketURL("/ws", query)
+}
+
+// CreateGroup calls POST /api/groups. It requires a token.
+func (c *Client) CreateGroup(ctx context.Context, req *CreateGroupRequest) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "POST", "/api/groups", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetGroups calls GET /api/groups. It requires a token.
+func (c *Client) GetGroups(ctx context.Context) ([]GroupResponse, error) {
+	var out []GroupResponse
+	if err := c.do(ctx, "GET", "/api/groups", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// JoinGroupByInvite calls POST /api/groups/join/:token. It requires a token.
+func (c *Client) JoinGroupByInvite(ctx context.Context, token string) (*GroupResponse, error) {
+	var out GroupResponse
+	if err := c.do(ctx, "POST", "/api/groups/join/"+url.PathEscape(token), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetGroup calls GET /api/groups/:id. It requires a token.
+func (c *Client) GetGroup(ctx context.Context, id string) (*GroupResponse, error) {
+	var out GroupResponse
+	if err := c.do(ctx, "GET", "/api/groups/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateGroup calls PUT /api/groups/:id. It requires a token.
+func (c *Client) UpdateGroup(ctx context.Context, id string, req *CreateGroupRequest) (*GroupResponse, error) {
+	var out GroupResponse
+	if err := c.do(ctx, "PUT", "/api/groups/"+url.PathEscape(id), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteGroup calls DELETE /api/groups/:id. It requires a token.
+func (c *Client) DeleteGroup(ctx context.Context, id string) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "DELETE", "/api/groups/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ExportGroup calls GET /api/groups/:id/export and returns the raw response. The caller must close its body. It requires a token.
+func (c *Client) ExportGroup(ctx context.Context, id string, query url.Values) (*http.Response, error) {
+	return c.download(ctx, "GET", "/api/groups/"+url.PathEscape(id)+"/export", query)
+}
+
+// GetGroupAuditLog calls GET /api/groups/:id/audit-log. It requires a token.
+func (c *Client) GetGroupAuditLog(ctx context.Context, id string, query url.Values) ([]AuditEntry, error) {
+	var out []AuditEntry
+	if err := c.do(ctx, "GET", "/api/groups/"+url.PathEscape(id)+"/audit-log", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetGroupMembers calls GET /api/groups/:id/members. It requires a token.
+func (c *Client) GetGroupMembers(ctx context.Context, id string) ([]GroupMemberResponse, error) {
+	var out []GroupMemberResponse
+	if err := c.do(ctx, "GET", "/api/groups/"+url.PathEscape(id)+"/members", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AddGroupMember calls POST /api/groups/:id/members. It requires a token.
+func (c *Client) AddGroupMember(ctx context.Context, id string, req *AddGroupMemberRequest) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "POST", "/api/groups/"+url.PathEscape(id)+"/members", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RemoveGroupMember calls DELETE /api/groups/:id/members/:address. It requires a token.
+func (c *Client) RemoveGroupMember(ctx context.Context, id string, address string) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "DELETE", "/api/groups/"+url.PathEscape(id)+"/members/"+url.PathEscape(address), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UpdateGroupMemberRole calls PUT /api/groups/:id/members/:address/role. It requires a token.
+func (c *Client) UpdateGroupMemberRole(ctx context.Context, id string, address string, req *UpdateGroupMemberRoleRequest) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "PUT", "/api/groups/"+url.PathEscape(id)+"/members/"+url.PathEscape(address)+"/role", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TransferGroupOwnership calls POST /api/groups/:id/transfer-ownership. It requires a token.
+func (c *Client) TransferGroupOwnership(ctx context.Context, id string, req *TransferGroupOwnershipRequest) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "POST", "/api/groups/"+url.PathEscape(id)+"/transfer-ownership", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SetGroupJoinMode calls PUT /api/groups/:id/join-mode. It requires a token.
+func (c *Client) SetGroupJoinMode(ctx context.Context, id string, req *SetGroupJoinModeRequest) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "PUT", "/api/groups/"+url.PathEscape(id)+"/join-mode", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RequestToJoinGroup calls POST /api/groups/:id/join. It requires a token.
+func (c *Client) RequestToJoinGroup(ctx context.Context, id string, req *JoinGroupRequest) (*GroupJoinRequest, error) {
+	var out GroupJoinRequest
+	if err := c.do(ctx, "POST", "/api/groups/"+url.PathEscape(id)+"/join", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetGroupJoinRequests calls GET /api/groups/:id/join-requests. It requires a token.
+func (c *Client) GetGroupJoinRequests(ctx context.Context, id string) ([]GroupJoinRequest, error) {
+	var out []GroupJoinRequest
+	if err := c.do(ctx, "GET", "/api/groups/"+url.PathEscape(id)+"/join-requests", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ApproveGroupJoinRequest calls POST /api/groups/:id/join-requests/:address/approve. It requires a token.
+func (c *Client) ApproveGroupJoinRequest(ctx context.Context, id string, address string) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "POST", "/api/groups/"+url.PathEscape(id)+"/join-requests/"+url.PathEscape(address)+"/approve", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RejectGroupJoinRequest calls POST /api/groups/:id/join-requests/:address/reject. It requires a token.
+func (c *Client) RejectGroupJoinRequest(ctx context.Context, id string, address string) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "POST", "/api/groups/"+url.PathEscape(id)+"/join-requests/"+url.PathEscape(address)+"/reject", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GrantGuestPass calls POST /api/groups/:id/guests. It requires a token.
+func (c *Client) GrantGuestPass(ctx context.Context, id string, req *GrantGuestPassRequest) (*GuestPass, error) {
+	var out GuestPass
+	if err := c.do(ctx, "POST", "/api/groups/"+url.PathEscape(id)+"/guests", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetGuestPasses calls GET /api/groups/:id/guests. It requires a token.
+func (c *Client) GetGuestPasses(ctx context.Context, id string) ([]GuestPass, error) {
+	var out []GuestPass
+	if err := c.do(ctx, "GET", "/api/groups/"+url.PathEscape(id)+"/guests", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RevokeGuestPass calls DELETE /api/groups/:id/guests/:address. It requires a token.
+func (c *Client) RevokeGuestPass(ctx context.Context, id string, address string) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "DELETE", "/api/groups/"+url.PathEscape(id)+"/guests/"+url.PathEscape(address), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CreateGroupInvite calls POST /api/groups/:id/invites. It requires a token.
+func (c *Client) CreateGroupInvite(ctx context.Context, id string, req *CreateGroupInviteRequest) (*GroupInviteResponse, error) {
+	var out GroupInviteResponse
+	if err := c.do(ctx, "POST", "/api/groups/"+url.PathEscape(id)+"/invites", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetGroupInvites calls GET /api/groups/:id/invites. It requires a token.
+func (c *Client) GetGroupInvites(ctx context.Context, id string) ([]GroupInviteResponse, error) {
+	var out []GroupInviteResponse
+	if err := c.do(ctx, "GET", "/api/groups/"+url.PathEscape(id)+"/invites", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RevokeGroupInvite calls DELETE /api/groups/:id/invites/:token. It requires a token.
+func (c *Client) RevokeGroupInvite(ctx context.Context, id string, token string) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "DELETE", "/api/groups/"+url.PathEscape(id)+"/invites/"+url.PathEscape(token), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SendGroupMessage calls POST /api/groups/:id/messages. It requires a token.
+func (c *Client) SendGroupMessage(ctx context.Context, id string, req *SendGroupMessageRequest) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "POST", "/api/groups/"+url.PathEscape(id)+"/messages", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetGroupMessages calls GET /api/groups/:id/messages. It requires a token.
+func (c *Client) GetGroupMessages(ctx context.Context, id string, query url.Values) ([]GroupMessageResponse, error) {
+	var out []GroupMessageResponse
+	if err := c.do(ctx, "GET", "/api/groups/"+url.PathEscape(id)+"/messages", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// EditGroupMessage calls PUT /api/groups/:id/messages/:message_id. It requires a token.
+func (c *Client) EditGroupMessage(ctx context.Context, id string, messageID string, req *EditGroupMessageRequest) (*GroupMessageResponse, error) {
+	var out GroupMessageResponse
+	if err := c.do(ctx, "PUT", "/api/groups/"+url.PathEscape(id)+"/messages/"+url.PathEscape(messageID), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// MarkGroupRead calls POST /api/groups/:id/read. It requires a token.
+func (c *Client) MarkGroupRead(ctx context.Context, id string, req *MarkReadRequest) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "POST", "/api/groups/"+url.PathEscape(id)+"/read", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetGroupDocs calls GET /api/groups/:id/docs. It requires a token.
+func (c *Client) GetGroupDocs(ctx context.Context, id string) ([]CollabDoc, error) {
+	var out []CollabDoc
+	if err := c.do(ctx, "GET", "/api/groups/"+url.PathEscape(id)+"/docs", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetGroupPolls calls GET /api/groups/:id/polls. It requires a token.
+func (c *Client) GetGroupPolls(ctx context.Context, id string, query url.Values) ([]PollResponse, error) {
+	var out []PollResponse
+	if err := c.do(ctx, "GET", "/api/groups/"+url.PathEscape(id)+"/polls", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SetGroupTopicMode calls PUT /api/groups/:id/topic-mode. It requires a token.
+func (c *Client) SetGroupTopicMode(ctx context.Context, id string, req *SetGroupTopicModeRequest) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "PUT", "/api/groups/"+url.PathEscape(id)+"/topic-mode", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CreateGroupTopic calls POST /api/groups/:id/topics. It requires a token.
+func (c *Client) CreateGroupTopic(ctx context.Context, id string, req *CreateGroupTopicRequest) (*GroupTopic, error) {
+	var out GroupTopic
+	if err := c.do(ctx, "POST", "/api/groups/"+url.PathEscape(id)+"/topics", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetGroupTopics calls GET /api/groups/:id/topics. It requires a token.
+func (c *Client) GetGroupTopics(ctx context.Context, id string) ([]GroupTopic, error) {
+	var out []GroupTopic
+	if err := c.do(ctx, "GET", "/api/groups/"+url.PathEscape(id)+"/topics", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CloseGroupTopic calls POST /api/groups/:id/topics/:topic_id/close. It requires a token.
+func (c *Client) CloseGroupTopic(ctx context.Context, id string, topicID string) (*GroupTopic, error) {
+	var out GroupTopic
+	if err := c.do(ctx, "POST", "/api/groups/"+url.PathEscape(id)+"/topics/"+url.PathEscape(topicID)+"/close", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ReopenGroupTopic calls POST /api/groups/:id/topics/:topic_id/reopen. It requires a token.
+func (c *Client) ReopenGroupTopic(ctx context.Context, id string, topicID string) (*GroupTopic, error) {
+	var out GroupTopic
+	if err := c.do(ctx, "POST", "/api/groups/"+url.PathEscape(id)+"/topics/"+url.PathEscape(topicID)+"/reopen", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// MarkGroupTopicRead calls POST /api/groups/:id/topics/:topic_id/read. It requires a token.
+func (c *Client) MarkGroupTopicRead(ctx context.Context, id string, topicID string, req *MarkReadRequest) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "POST", "/api/groups/"+url.PathEscape(id)+"/topics/"+url.PathEscape(topicID)+"/read", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CreateGroupEvent calls POST /api/groups/:id/events. It requires a token.
+func (c *Client) CreateGroupEvent(ctx context.Context, id string, req *CreateGroupEventRequest) (*GroupEventResponse, error) {
+	var out GroupEventResponse
+	if err := c.do(ctx, "POST", "/api/groups/"+url.PathEscape(id)+"/events", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetGroupEvents calls GET /api/groups/:id/events. It requires a token.
+func (c *Client) GetGroupEvents(ctx context.Context, id string, query url.Values) ([]GroupEvent, error) {
+	var out []GroupEvent
+	if err := c.do(ctx, "GET", "/api/groups/"+url.PathEscape(id)+"/events", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetGroupEvent calls GET /api/groups/:id/events/:event_id. It requires a token.
+func (c *Client) GetGroupEvent(ctx context.Context, id string, eventID string) (*GroupEventResponse, error) {
+	var out GroupEventResponse
+	if err := c.do(ctx, "GET", "/api/groups/"+url.PathEscape(id)+"/events/"+url.PathEscape(eventID), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteGroupEvent calls DELETE /api/groups/:id/events/:event_id. It requires a token.
+func (c *Client) DeleteGroupEvent(ctx context.Context, id string, eventID string) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "DELETE", "/api/groups/"+url.PathEscape(id)+"/events/"+url.PathEscape(eventID), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RSVPGroupEvent calls PUT /api/groups/:id/events/:event_id/rsvp. It requires a token.
+func (c *Client) RSVPGroupEvent(ctx context.Context, id string, eventID string, req *RSVPRequest) (*GroupEventResponse, error) {
+	var out GroupEventResponse
+	if err := c.do(ctx, "PUT", "/api/groups/"+url.PathEscape(id)+"/events/"+url.PathEscape(eventID)+"/rsvp", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteGroupEventRSVP calls DELETE /api/groups/:id/events/:event_id/rsvp. It requires a token.
+func (c *Client) DeleteGroupEventRSVP(ctx context.Context, id string, eventID string) (*GroupEventResponse, error) {
+	var out GroupEventResponse
+	if err := c.do(ctx, "DELETE", "/api/groups/"+url.PathEscape(id)+"/events/"+url.PathEscape(eventID)+"/rsvp", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetAdminStats calls GET /api/admin/stats. It requires a token.
+func (c *Client) GetAdminStats(ctx context.Context) (*AdminStatsResponse, error) {
+	var out AdminStatsResponse
+	if err := c.do(ctx, "GET", "/api/admin/stats", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetAdminBlocks calls GET /api/admin/blocks. It requires a token.
+func (c *Client) GetAdminBlocks(ctx context.Context, query url.Values) ([]Block, error) {
+	var out []Block
+	if err := c.do(ctx, "GET", "/api/admin/blocks", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetAdminBlockchain calls GET /api/admin/blockchain. It requires a token.
+func (c *Client) GetAdminBlockchain(ctx context.Context) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "GET", "/api/admin/blockchain", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetAdminClients calls GET /api/admin/clients. It requires a token.
+func (c *Client) GetAdminClients(ctx context.Context) ([]ClientInfo, error) {
+	var out []ClientInfo
+	if err := c.do(ctx, "GET", "/api/admin/clients", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetAdminConnections calls GET /api/admin/connections. It requires a token.
+func (c *Client) GetAdminConnections(ctx context.Context) (*AdminConnectionsResponse, error) {
+	var out AdminConnectionsResponse
+	if err := c.do(ctx, "GET", "/api/admin/connections", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// LookupUser calls GET /api/admin/users/lookup. It requires a token.
+func (c *Client) LookupUser(ctx context.Context, query url.Values) (*AdminUserResponse, error) {
+	var out AdminUserResponse
+	if err := c.do(ctx, "GET", "/api/admin/users/lookup", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SuspendAccount calls PUT /api/admin/users/:address/suspension. It requires a token.
+func (c *Client) SuspendAccount(ctx context.Context, address string, req *SuspendAccountRequest) (*AccountSuspension, error) {
+	var out AccountSuspension
+	if err := c.do(ctx, "PUT", "/api/admin/users/"+url.PathEscape(address)+"/suspension", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UnsuspendAccount calls DELETE /api/admin/users/:address/suspension. It requires a token.
+func (c *Client) UnsuspendAccount(ctx context.Context, address string) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "DELETE", "/api/admin/users/"+url.PathEscape(address)+"/suspension", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PurgeUserMessages calls DELETE /api/admin/users/:address/messages. It requires a token.
+func (c *Client) PurgeUserMessages(ctx context.Context, address string) (*PurgedMessages, error) {
+	var out PurgedMessages
+	if err := c.do(ctx, "DELETE", "/api/admin/users/"+url.PathEscape(address)+"/messages", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetAdminReports calls GET /api/admin/reports. It requires a token.
+func (c *Client) GetAdminReports(ctx context.Context, query url.Values) ([]Report, error) {
+	var out []Report
+	if err := c.do(ctx, "GET", "/api/admin/reports", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ResolveReport calls PUT /api/admin/reports/:id. It requires a token.
+func (c *Client) ResolveReport(ctx context.Context, id string, req *ResolveReportRequest) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "PUT", "/api/admin/reports/"+url.PathEscape(id), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetChannelFlags calls GET /api/admin/channel-flags. It requires a token.
+func (c *Client) GetChannelFlags(ctx context.Context, query url.Values) ([]ChannelFlag, error) {
+	var out []ChannelFlag
+	if err := c.do(ctx, "GET", "/api/admin/channel-flags", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ReviewChannelFlag calls PUT /api/admin/channel-flags/:id. It requires a token.
+func (c *Client) ReviewChannelFlag(ctx context.Context, id string, req *ReviewChannelFlagRequest) (*ChannelFlag, error) {
+	var out ChannelFlag
+	if err := c.do(ctx, "PUT", "/api/admin/channel-flags/"+url.PathEscape(id), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetDeliverySLA calls GET /api/admin/delivery-sla. It requires a token.
+func (c *Client) GetDeliverySLA(ctx context.Context, query url.Values) ([]DeliverySLA, error) {
+	var out []DeliverySLA
+	if err := c.do(ctx, "GET", "/api/admin/delivery-sla", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetAdminSupportTickets calls GET /api/admin/support. It requires a token.
+func (c *Client) GetAdminSupportTickets(ctx context.Context, query url.Values) ([]SupportTicket, error) {
+	var out []SupportTicket
+	if err := c.do(ctx, "GET", "/api/admin/support", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetAdminSupportTicket calls GET /api/admin/support/:id. It requires a token.
+func (c *Client) GetAdminSupportTicket(ctx context.Context, id string) (*SupportTicketResponse, error) {
+	var out SupportTicketResponse
+	if err := c.do(ctx, "GET", "/api/admin/support/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ReplySupportTicket calls POST /api/admin/support/:id/replies. It requires a token.
+func (c *Client) ReplySupportTicket(ctx context.Context, id string, req *SupportTicketReplyRequest) (*SupportTicketReply, error) {
+	var out SupportTicketReply
+	if err := c.do(ctx, "POST", "/api/admin/support/"+url.PathEscape(id)+"/replies", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CloseSupportTicket calls POST /api/admin/support/:id/close. It requires a token.
+func (c *Client) CloseSupportTicket(ctx context.Context, id string) (*SupportTicket, error) {
+	var out SupportTicket
+	if err := c.do(ctx, "POST", "/api/admin/support/"+url.PathEscape(id)+"/close", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// PublishPolicy calls POST /api/admin/policies. It requires a token.
+func (c *Client) PublishPolicy(ctx context.Context, req *PublishPolicyRequest) (*Policy, error) {
+	var out Policy
+	if err := c.do(ctx, "POST", "/api/admin/policies", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetLegalHolds calls GET /api/admin/legal-holds. It requires a token.
+func (c *Client) GetLegalHolds(ctx context.Context) ([]LegalHold, error) {
+	var out []LegalHold
+	if err := c.do(ctx, "GET", "/api/admin/legal-holds", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PlaceLegalHold calls PUT /api/admin/legal-holds/:address. It requires a token.
+func (c *Client) PlaceLegalHold(ctx context.Context, address string, req *PlaceLegalHoldRequest) (*LegalHold, error) {
+	var out LegalHold
+	if err := c.do(ctx, "PUT", "/api/admin/legal-holds/"+url.PathEscape(address), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ReleaseLegalHold calls DELETE /api/admin/legal-holds/:address. It requires a token.
+func (c *Client) ReleaseLegalHold(ctx context.Context, address string) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "DELETE", "/api/admin/legal-holds/"+url.PathEscape(address), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ExportLegalHold calls GET /api/admin/legal-holds/:address/export. It requires a token.
+func (c *Client) ExportLegalHold(ctx context.Context, address string) (*ComplianceExport, error) {
+	var out ComplianceExport
+	if err := c.do(ctx, "GET", "/api/admin/legal-holds/"+url.PathEscape(address)+"/export", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetOpenAPI calls GET /api/openapi.json.
+func (c *Client) GetOpenAPI(ctx context.Context) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "GET", "/api/openapi.json", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetAPIDocs calls GET /api/docs and returns the raw response. The caller must close its body.
+func (c *Client) GetAPIDocs(ctx context.Context) (*http.Response, error) {
+	return c.download(ctx, "GET", "/api/docs", nil)
+}
@@ -0,0 +1,159 @@
+// Package pikosdk is a Go client for the Piko API.
+//
+// The endpoint methods, request and response types and WebSocket event
+// types in api_gen.go are generated from the api package by cmd/sdk-gen.
+// Regenerate them with go generate ./api after changing a route.
+package pikosdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Client calls the Piko API
+type Client struct {
+	// BaseURL is the server's address, e.g. https://piko.example.com
+	BaseURL string
+
+	// Token is the JWT sent with every request. Set it after logging in.
+	Token string
+
+	// HTTPClient is used for requests, http.DefaultClient when nil
+	HTTPClient *http.Client
+}
+
+// NewClient creates a client for the server at baseURL
+func NewClient(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// Error is returned when the API responds with an error status
+type Error struct {
+	StatusCode int
+	// Code says what went wrong, e.g. "OTP_EXPIRED". Check it rather than
+	// Message, which is translated.
+	Code    string
+	Message string
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("piko: %d %s: %s", e.StatusCode, e.Code, e.Message)
+	}
+	return fmt.Sprintf("piko: %d %s", e.StatusCode, e.Message)
+}
+
+// do sends a request with an optional JSON body and decodes the JSON response into out
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	var reader io.Reader
+	contentType := ""
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(encoded)
+		contentType = "application/json"
+	}
+	return c.upload(ctx, method, path, query, contentType, reader, out)
+}
+
+// upload sends a request with a raw body and decodes the data of the JSON
+// response into out
+func (c *Client) upload(ctx context.Context, method, path string, query url.Values, contentType string, body io.Reader, out interface{}) error {
+	resp, err := c.send(ctx, method, path, query, contentType, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return err
+	}
+	return json.Unmarshal(envelope.Data, out)
+}
+
+// download sends a request and returns the response for the caller to read
+func (c *Client) download(ctx context.Context, method, path string, query url.Values) (*http.Response, error) {
+	resp, err := c.send(ctx, method, path, query, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkResponse(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp, nil
+}
+
+// send builds and sends a request
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, contentType string, body io.Reader) (*http.Response, error) {
+	target := c.BaseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return httpClient.Do(req)
+}
+
+// webSocketURL returns the ws:// or wss:// URL of a WebSocket route
+func (c *Client) webSocketURL(path string, query url.Values) string {
+	base := c.BaseURL
+	switch {
+	case strings.HasPrefix(base, "https://"):
+		base = "wss://" + strings.TrimPrefix(base, "https://")
+	case strings.HasPrefix(base, "http://"):
+		base = "ws://" + strings.TrimPrefix(base, "http://")
+	}
+
+	target := base + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	return target
+}
+
+// checkResponse turns an error status into an *Error
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	var body struct {
+		Code  string `json:"code"`
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Error == "" {
+		body.Error = http.StatusText(resp.StatusCode)
+	}
+	return &Error{StatusCode: resp.StatusCode, Code: body.Code, Message: body.Error}
+}
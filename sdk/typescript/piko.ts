@@ -0,0 +1,2597 @@
+// Code generated by sdk-gen. DO NOT EDIT.
+
+/** WebSocket event types */
+export const Events = {
+  /** A direct message was received */
+  NewMessage: "new_message",
+  /** A message's delivery status changed */
+  MessageStatus: "message_status",
+  /** A message was posted to a channel */
+  NewChannelMessage: "new_channel_message",
+  /** A message was posted to a group */
+  NewGroupMessage: "new_group_message",
+  /** A message was edited by its sender */
+  MessageEdited: "message_edited",
+  /** Direct messages passed their expiration time and were deleted */
+  MessageExpired: "message_expired",
+  /** This session was wiped from another device */
+  RemoteWipe: "remote_wipe",
+  /** A contact's key changed */
+  SafetyNumberChanged: "safety_number_changed",
+  /** You are running out of one-time prekeys */
+  PrekeysLow: "prekeys_low",
+  /** A group event you haven't declined starts soon */
+  GroupEventReminder: "group_event_reminder",
+  /** A poll was created in one of your groups or channels, got or lost a vote, or was closed */
+  PollUpdated: "poll_updated",
+  /** The server is shutting down; reconnect shortly */
+  ReconnectSoon: "reconnect_soon",
+  /** A user is typing */
+  Typing: "typing",
+  /** A user came online or went offline */
+  Presence: "presence",
+  /** A page of prefetched inbox messages */
+  InboxPage: "inbox_page",
+  /** The client acknowledged a prefetched page */
+  InboxAck: "inbox_ack",
+  /** Inbox prefetch finished */
+  InboxDone: "inbox_done",
+  /** A group member acknowledged a group message */
+  GroupReceived: "group_received",
+  /** A group member read up to a message */
+  GroupRead: "group_read",
+  /** A channel member read up to your message */
+  ChannelRead: "channel_read",
+} as const;
+
+export type EventType = (typeof Events)[keyof typeof Events];
+
+export interface AcceptPoliciesRequest {
+  policy_ids: number[];
+}
+
+export interface AccountSuspension {
+  user_address: string;
+  reason: string;
+  suspended_by: string;
+  created_at: string;
+}
+
+export interface AckChannelMessagesRequest {
+  message_ids: string[];
+}
+
+export interface AckChannelMessagesResponse {
+  counted: number;
+}
+
+export interface AddBroadcastRecipientsRequest {
+  recipients: string[];
+}
+
+export interface AddChannelMemberRequest {
+  user_address: string;
+}
+
+export interface AddGroupMemberRequest {
+  user_address: string;
+  is_admin: boolean;
+}
+
+export interface AddStickerRequest {
+  media_id: string;
+  emoji?: string;
+}
+
+export interface AdminConnectionsResponse {
+  clients: number;
+  secret_chat_clients: number;
+  draining: boolean;
+}
+
+export interface AdminStatsResponse {
+  users: number;
+  messages: number;
+  groups: number;
+  channels: number;
+  blocks: number;
+  transactions: number;
+  open_reports: number;
+  connected_clients: number;
+  secret_chat_clients: number;
+  blockchain_height: number;
+}
+
+export interface AdminUserResponse {
+  id: number;
+  address: string;
+  phone: string;
+  username?: string;
+  role: string;
+  created_at: string;
+  last_seen_at?: string;
+  online: boolean;
+  active_sessions: number;
+  frozen_until?: string;
+  suspension?: AccountSuspension;
+  legal_hold: boolean;
+}
+
+export interface AppealChannelFlagRequest {
+  reason: string;
+}
+
+export interface AppendDocUpdateRequest {
+  update: string;
+}
+
+export interface AuditEntry {
+  id: number;
+  actor_address: string;
+  action: string;
+  target: string;
+  ip_address: string;
+  details?: string;
+  conversation_type?: string;
+  conversation_id?: string;
+  created_at: string;
+}
+
+export interface AuthResponse {
+  token: string;
+  address: string;
+  session_id: string;
+  device_token?: string;
+}
+
+export interface AvatarResponse {
+  id: number;
+  user_id: number;
+  file_path: string;
+  file_name: string;
+  file_size: number;
+  mime_type: string;
+  width: number;
+  height: number;
+  is_active: boolean;
+  created_at: string;
+  url: string;
+  url_expires_at: string;
+}
+
+export interface Block {
+  id: string;
+  previous_hash?: string;
+  timestamp: string;
+  merkle_root: string;
+  nonce: number;
+  height: number;
+  transactions?: Transaction[];
+}
+
+export interface BlocksResponse {
+  blocks: Block[];
+  total: number;
+  limit: number;
+  offset: number;
+}
+
+export interface Broadcast {
+  id: string;
+  list_id: string;
+  created_at: string;
+  deliveries: BroadcastDelivery[];
+}
+
+export interface BroadcastDelivery {
+  recipient_address: string;
+  message_id?: string;
+  status: string;
+  error?: string;
+}
+
+export interface BroadcastList {
+  id: string;
+  owner_address: string;
+  name: string;
+  recipient_count: number;
+  created_at: string;
+  recipients?: string[];
+}
+
+export interface ChainReport {
+  valid: boolean;
+  blocks_checked: number;
+  invalid_height?: number;
+  invalid_block_id?: string;
+  reason?: string;
+}
+
+export interface ChallengeResponse {
+  nonce: string;
+  expires_at: string;
+}
+
+export interface ChannelFlag {
+  channel_id: string;
+  throttled: boolean;
+  upheld: boolean;
+  reporter_count: number;
+  flagged_at: string;
+  released_at?: string;
+  reviewed_by?: string;
+  reviewed_at?: string;
+  appeal?: string;
+  appealed_at?: string;
+}
+
+export interface ChannelInviteLinkResponse {
+  token: string;
+  path: string;
+}
+
+export interface ChannelMember {
+  channel_id: string;
+  user_address: string;
+  role: string;
+  joined_at: string;
+}
+
+export interface ChannelMemberResponse {
+  user_address: string;
+  role: string;
+  joined_at: string;
+}
+
+export interface ChannelMessage {
+  id: string;
+  channel_id: string;
+  sender_address: string;
+  encrypted_content: string;
+  timestamp: string;
+  block_id?: string;
+  reply_to_message_id?: string;
+  forwarded_from?: string;
+  forwarded_from_channel?: string;
+  edited_at?: string;
+  sticker_id?: string;
+}
+
+export interface ChannelMessageReach {
+  message_id: string;
+  timestamp: string;
+  reach: number;
+}
+
+export interface ChannelMessageRequest {
+  encrypted_content: string;
+  reply_to_message_id?: string;
+  attachment_ids?: string[];
+  sticker_id?: string;
+}
+
+export interface ChannelMessageResponse {
+  id: string;
+  channel_id: string;
+  sender_address: string;
+  encrypted_content: string;
+  timestamp: string;
+  block_id?: string;
+  reply_to_message_id?: string;
+  sticker_id?: string;
+  forwarded_from?: string;
+  forwarded_from_channel?: string;
+  attachments?: MediaResponse[];
+  sender_device?: SenderDeviceResponse;
+  edited: boolean;
+  edited_at?: string;
+}
+
+export interface ChannelResponse {
+  id: string;
+  name: string;
+  admin_address: string;
+  is_public: boolean;
+  created_at: string;
+  member_count: number;
+  message_count: number;
+  unread_count: number;
+}
+
+export interface ChannelStats {
+  member_count: number;
+  message_count: number;
+  messages: ChannelMessageReach[];
+}
+
+export interface ChannelWebViewRequest {
+  slug: string;
+}
+
+export interface ChannelWebViewResponse {
+  slug: string;
+  path?: string;
+}
+
+export interface ClientInfo {
+  address: string;
+  encoding: string;
+  connected_at: string;
+}
+
+export interface CollabDoc {
+  id: string;
+  conversation_type: string;
+  conversation_id: string;
+  creator_address: string;
+  version: number;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface ComplianceAccount {
+  id: number;
+  address: string;
+  phone: string;
+  username?: string;
+  role: string;
+  birthdate?: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface ComplianceExport {
+  generated_at: string;
+  hold: LegalHold | null;
+  account: ComplianceAccount;
+  sessions: Session[];
+  contacts: Contact[];
+  group_memberships: GroupMember[];
+  channel_memberships: ChannelMember[];
+  sent_messages: Message[];
+  received_messages: Message[];
+  group_messages: GroupMessage[];
+  channel_messages: ChannelMessage[];
+  media: Media[];
+  audit_log: AuditEntry[];
+}
+
+export interface ConsensusParams {
+  activation_height: number;
+  difficulty: number;
+  transaction_types: string[];
+  description: string;
+  created_at: string;
+}
+
+export interface ConsensusParamsResponse {
+  height: number;
+  next: ConsensusParams | null;
+  schedule: ConsensusParams[];
+}
+
+export interface Contact {
+  address: string;
+  alias?: string;
+  labels: string[];
+  blocked: boolean;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface ContactName {
+  alias?: string;
+  labels?: string[];
+}
+
+export interface CreateBroadcastListRequest {
+  name: string;
+  recipients?: string[];
+}
+
+export interface CreateChannelRequest {
+  name: string;
+  is_public: boolean;
+}
+
+export interface CreateDocRequest {
+  conversation_type: string;
+  conversation_id: string;
+}
+
+export interface CreateGroupEventRequest {
+  title: string;
+  description?: string;
+  starts_at: string;
+}
+
+export interface CreateGroupInviteRequest {
+  max_uses?: number;
+  expires_at?: string;
+}
+
+export interface CreateGroupRequest {
+  name: string;
+  description: string;
+  photo_url?: string;
+}
+
+export interface CreateGroupTopicRequest {
+  name: string;
+}
+
+export interface CreateMediaUploadRequest {
+  file_name: string;
+  mime_type: string;
+  size: number;
+}
+
+export interface CreatePollRequest {
+  conversation_type: string;
+  conversation_id: string;
+  message_id: string;
+  question: string;
+  options: string[];
+  anonymous?: boolean;
+}
+
+export interface CreateReportRequest {
+  target_type: string;
+  target_id: string;
+  reason: string;
+}
+
+export interface CreateSecretChatRequest {
+  challenge?: string;
+  solution?: string;
+  ttl_seconds?: number;
+  max_participants?: number;
+}
+
+export interface CreateSecretChatResponse {
+  channel_id: string;
+  expires_at: string;
+  expires_in: number;
+  max_participants: number;
+}
+
+export interface CreateStickerPackRequest {
+  name: string;
+  kind?: string;
+}
+
+export interface CreateSupportTicketRequest {
+  category: string;
+  message: string;
+  logs_media_id?: string;
+}
+
+export interface CreateSupportTicketResponse {
+  ticket: SupportTicket | null;
+  support_bot_address: string;
+}
+
+export interface CrosspostRequest {
+  message_id: string;
+  target_channel_id: string;
+}
+
+export interface CrosspostResponse {
+  id: string;
+  channel_id: string;
+  forwarded_from: string;
+  forwarded_from_channel: string;
+  link: string;
+}
+
+export interface DailyMessageCount {
+  date: string;
+  direct: number;
+  group: number;
+  channel: number;
+  total: number;
+}
+
+export interface DeliverySLA {
+  hour: string;
+  kind: string;
+  samples: number;
+  p50_ms: number;
+  p95_ms: number;
+  p99_ms: number;
+  max_ms: number;
+  alerted: boolean;
+}
+
+export interface DiscoverChannelResponse {
+  id: string;
+  name: string;
+  admin_address: string;
+  is_public: boolean;
+  created_at: string;
+  member_count: number;
+  message_count: number;
+  unread_count: number;
+  invite_token: string;
+}
+
+export interface DiscoverContactsRequest {
+  hashes: string[];
+}
+
+export interface DiscoveredContact {
+  hash: string;
+  address: string;
+  username?: string;
+}
+
+export interface DoNotDisturb {
+  user_address: string;
+  until?: string;
+  created_at: string;
+}
+
+export interface DoNotDisturbRequest {
+  until?: string;
+}
+
+export interface DocUpdateResponse {
+  doc_id: string;
+  version: number;
+  sender_address: string;
+  update: string;
+  created_at: string;
+}
+
+export interface DocUpdatesResponse {
+  doc_id: string;
+  version: number;
+  updates: DocUpdateResponse[];
+  has_more: boolean;
+}
+
+export interface EditGroupMessageRequest {
+  content: string;
+}
+
+export interface EditMessageRequest {
+  encrypted_content: string;
+}
+
+export interface Event {
+  type: string;
+  payload: Record<string, unknown>;
+  from?: string;
+  to?: string;
+}
+
+export interface ForwardMessageRequest {
+  recipient_address?: string;
+  group_id?: string;
+  channel_id?: string;
+}
+
+export interface ForwardMessageResponse {
+  id: string;
+  forwarded_from: string;
+}
+
+export interface FreezeAccountRequest {
+  phone?: string;
+  recovery_code?: string;
+}
+
+export interface FreezeAccountResponse {
+  message: string;
+  frozen_until: string;
+  revoked: number;
+}
+
+export interface GrantGuestPassRequest {
+  user_address: string;
+  access?: string;
+  expires_at: string;
+}
+
+export interface GroupEvent {
+  id: string;
+  group_id: string;
+  creator_address: string;
+  title: string;
+  description?: string;
+  starts_at: string;
+  reminded_at?: string;
+  started_at?: string;
+  created_at: string;
+  going: number;
+  maybe: number;
+  not_going: number;
+}
+
+export interface GroupEventRSVP {
+  user_address: string;
+  status: string;
+  updated_at: string;
+}
+
+export interface GroupEventResponse {
+  id: string;
+  group_id: string;
+  creator_address: string;
+  title: string;
+  description?: string;
+  starts_at: string;
+  reminded_at?: string;
+  started_at?: string;
+  created_at: string;
+  going: number;
+  maybe: number;
+  not_going: number;
+  rsvps: GroupEventRSVP[];
+}
+
+export interface GroupInviteResponse {
+  token: string;
+  group_id: string;
+  created_by: string;
+  max_uses?: number;
+  uses: number;
+  expires_at?: string;
+  revoked_at?: string;
+  created_at: string;
+  path: string;
+}
+
+export interface GroupJoinRequest {
+  group_id: string;
+  user_address: string;
+  message?: string;
+  status: string;
+  decided_by?: string;
+  decided_at?: string;
+  created_at: string;
+}
+
+export interface GroupMember {
+  group_id: string;
+  user_address: string;
+  role: string;
+  joined_at: string;
+}
+
+export interface GroupMemberResponse {
+  user_address: string;
+  role: string;
+  joined_at: string;
+}
+
+export interface GroupMessage {
+  id: string;
+  group_id: string;
+  sender_address: string;
+  content: string;
+  timestamp: string;
+  block_id?: string;
+  reply_to_message_id?: string;
+  forwarded_from?: string;
+  system?: boolean;
+  edited_at?: string;
+  topic_id?: string;
+  sticker_id?: string;
+}
+
+export interface GroupMessageResponse {
+  id: string;
+  group_id: string;
+  sender_address: string;
+  content: string;
+  timestamp: string;
+  reply_to_message_id?: string;
+  sticker_id?: string;
+  forwarded_from?: string;
+  attachments?: MediaResponse[];
+  sender_device?: SenderDeviceResponse;
+  system?: boolean;
+  edited: boolean;
+  edited_at?: string;
+  topic_id?: string;
+  contact?: ContactName;
+}
+
+export interface GroupResponse {
+  id: string;
+  name: string;
+  description: string;
+  photo_url?: string;
+  created_by: string;
+  member_count: number;
+  message_count: number;
+  unread_count: number;
+  topics_enabled: boolean;
+  join_requests_enabled: boolean;
+}
+
+export interface GroupTopic {
+  id: string;
+  group_id: string;
+  name: string;
+  creator_address: string;
+  closed_at?: string;
+  created_at: string;
+  message_count: number;
+  last_message_at?: string;
+  unread_count: number;
+}
+
+export interface GuestPass {
+  group_id: string;
+  user_address: string;
+  access: string;
+  granted_by: string;
+  expires_at: string;
+  created_at: string;
+}
+
+export interface JoinGroupRequest {
+  message?: string;
+}
+
+export interface JoinSecretChatRequest {
+  channel_id: string;
+  display_name: string;
+}
+
+export interface JoinSecretChatResponse {
+  session_id: string;
+  channel_id: string;
+  expires_at: string;
+  websocket_url: string;
+  expires_in: number;
+  max_participants: number;
+}
+
+export interface KeyBundleResponse {
+  address: string;
+  identity_key: string;
+  signed_prekey: SignedPrekeyResponse;
+  one_time_prekey?: OneTimePrekeyResponse;
+}
+
+export interface KeyRotation {
+  id: string;
+  user_address: string;
+  identity_fingerprint: string;
+  created_at: string;
+  total: number;
+  acknowledged: number;
+  peers?: KeyRotationPeer[];
+}
+
+export interface KeyRotationPeer {
+  peer_address: string;
+  acknowledged_at?: string;
+}
+
+export interface KeyStatusResponse {
+  identity_key: string;
+  signed_prekey_id: number;
+  signed_prekey_updated_at: string;
+  one_time_prekeys: number;
+  rotate_signed_prekey: boolean;
+  upload_prekeys: number;
+}
+
+export interface LegalHold {
+  user_address: string;
+  reason: string;
+  placed_by: string;
+  created_at: string;
+}
+
+export interface LoginRequest {
+  phone: string;
+  channel?: string;
+}
+
+export interface MarkReadRequest {
+  message_id: string;
+}
+
+export interface Media {
+  id: string;
+  owner_address: string;
+  file_name: string;
+  mime_type: string;
+  size: number;
+  created_at: string;
+}
+
+export interface MediaResponse {
+  id: string;
+  file_name: string;
+  mime_type: string;
+  size: number;
+  url: string;
+  url_expires_at: string;
+}
+
+export interface MediaUploadResponse {
+  upload_id: string;
+  size: number;
+  received: number;
+  chunk_size: number;
+}
+
+export interface Message {
+  id: string;
+  sender_address: string;
+  recipient_address: string;
+  encrypted_content: string;
+  timestamp: string;
+  status: string;
+  expiration_time?: string;
+  block_id?: string;
+  edited_at?: string;
+  reply_to_message_id?: string;
+  forwarded_from?: string;
+  sticker_id?: string;
+}
+
+export interface MessageEditResponse {
+  encrypted_content: string;
+  edited_at: string;
+}
+
+export interface MessageLinkResponse {
+  link: string;
+  path: string;
+}
+
+export interface MessageReceipt {
+  message_id: string;
+  recipient_address: string;
+  status: string;
+  timestamp: string;
+}
+
+export interface MessageReceiptsResponse {
+  message_id: string;
+  status: string;
+  sent_at: string;
+  receipts: MessageReceipt[];
+}
+
+export interface MessageResponse {
+  id: string;
+  sender_address: string;
+  recipient_address: string;
+  encrypted_content: string;
+  timestamp: string;
+  status: string;
+  expiration_time?: string;
+  block_id?: string;
+  edited_at?: string;
+  reply_to_message_id?: string;
+  sticker_id?: string;
+  forwarded_from?: string;
+  attachments?: MediaResponse[];
+  sender_device?: SenderDeviceResponse;
+  contact?: ContactName;
+}
+
+export interface MessagesResponse {
+  messages: MessageResponse[];
+  has_more: boolean;
+}
+
+export interface Mute {
+  user_address: string;
+  conversation_type: string;
+  conversation_id: string;
+  muted_until?: string;
+  created_at: string;
+}
+
+export interface MuteRequest {
+  conversation_type: string;
+  conversation_id: string;
+  muted_until?: string;
+}
+
+export interface MutesResponse {
+  mutes: Mute[];
+  do_not_disturb?: DoNotDisturb;
+}
+
+export interface OneTimePrekeyRequest {
+  key_id: number;
+  public_key: string;
+}
+
+export interface OneTimePrekeyResponse {
+  key_id: number;
+  public_key: string;
+}
+
+export interface PINStatusResponse {
+  enabled: boolean;
+  locked_until?: string;
+  updated_at?: string;
+}
+
+export interface PendingPoliciesResponse {
+  pending: Policy[];
+}
+
+export interface PlaceLegalHoldRequest {
+  reason: string;
+}
+
+export interface Policy {
+  id: number;
+  kind: string;
+  version: string;
+  url: string;
+  published_at: string;
+}
+
+export interface PolicyAcceptance {
+  id: number;
+  kind: string;
+  version: string;
+  url: string;
+  published_at: string;
+  accepted_at: string;
+}
+
+export interface PollOption {
+  position: number;
+  text: string;
+  votes: number;
+  voters?: string[];
+}
+
+export interface PollResponse {
+  id: string;
+  conversation_type: string;
+  conversation_id: string;
+  message_id: string;
+  creator_address: string;
+  question: string;
+  anonymous: boolean;
+  options: PollOption[];
+  total_votes: number;
+  closed_at?: string;
+  created_at: string;
+  my_vote?: number;
+}
+
+export interface PublishPolicyRequest {
+  kind: string;
+  version: string;
+  url: string;
+}
+
+export interface PurgedMessages {
+  direct: number;
+  group: number;
+  channel: number;
+}
+
+export interface RSVPRequest {
+  status: string;
+}
+
+export interface ReceiptsResponse {
+  receipts: MessageReceipt[];
+  next_cursor?: string;
+  has_more: boolean;
+}
+
+export interface RecoveryCodeResponse {
+  recovery_code: string;
+}
+
+export interface RegisterDeviceRequest {
+  token: string;
+  platform: string;
+}
+
+export interface RegisterRequest {
+  phone: string;
+  channel?: string;
+  email?: string;
+  challenge?: string;
+  solution?: string;
+  captcha_token?: string;
+}
+
+export interface RegistrationChallengeResponse {
+  type: string;
+  nonce?: string;
+  difficulty?: number;
+  expires_at?: string;
+  site_key?: string;
+}
+
+export interface RemovePINRequest {
+  current_pin: string;
+}
+
+export interface RenameBroadcastListRequest {
+  name: string;
+}
+
+export interface Report {
+  id: number;
+  reporter_address: string;
+  target_type: string;
+  target_id: string;
+  reason: string;
+  status: string;
+  resolved_by?: string;
+  resolved_at?: string;
+  created_at: string;
+}
+
+export interface ResendOTPRequest {
+  phone: string;
+}
+
+export interface ResendOTPResponse {
+  message: string;
+  expires_in: number;
+  resends_left: number;
+  retry_after: number;
+}
+
+export interface ResolveReportRequest {
+  status: string;
+}
+
+export interface ResolvedMessageLinkResponse {
+  link: string;
+  channel: ChannelResponse;
+  message_id: string;
+  message?: ChannelMessageResponse;
+}
+
+export interface ReviewChannelFlagRequest {
+  action: string;
+}
+
+export interface SafetyNumberResponse {
+  peer_address: string;
+  safety_number: string;
+  peer_key_fingerprint: string;
+  verified: boolean;
+  verified_at?: string;
+}
+
+export interface SaveContactRequest {
+  address: string;
+  alias?: string;
+  labels?: string[];
+  blocked: boolean;
+}
+
+export interface SecretChatChallengeResponse {
+  nonce: string;
+  difficulty: number;
+  expires_at: string;
+}
+
+export interface SecretChatMessageRequest {
+  session_id: string;
+  encrypted_content: string;
+}
+
+export interface SecretChatMessageResponse {
+  id: string;
+  channel_id: string;
+  display_name: string;
+  encrypted_content: string;
+  timestamp: string;
+}
+
+export interface SecurityLogResponse {
+  events: AuditEntry[];
+  device_activity: SessionActivity[];
+}
+
+export interface SendBroadcastRequest {
+  encrypted_content: string;
+  ttl?: number;
+  attachment_ids?: string[];
+}
+
+export interface SendGroupMessageRequest {
+  content: string;
+  reply_to_message_id?: string;
+  attachment_ids?: string[];
+  topic_id?: string;
+  sticker_id?: string;
+}
+
+export interface SendMessageRequest {
+  recipient_address: string;
+  encrypted_content: string;
+  ttl?: number;
+  reply_to_message_id?: string;
+  attachment_ids?: string[];
+  sticker_id?: string;
+}
+
+export interface SenderDeviceResponse {
+  session_id: string;
+  device_name: string;
+}
+
+export interface Session {
+  id: string;
+  user_address: string;
+  device_name: string;
+  user_agent: string;
+  ip_address: string;
+  created_at: string;
+  last_seen_at: string;
+  revoked_at?: string;
+  revoke_reason?: string;
+}
+
+export interface SessionActivity {
+  session_id: string;
+  device_name: string;
+  messages_sent: number;
+  last_message_at?: string;
+  revoked_at?: string;
+}
+
+export interface SessionResponse {
+  id: string;
+  device_name: string;
+  user_agent: string;
+  ip_address: string;
+  created_at: string;
+  last_seen_at: string;
+  current: boolean;
+}
+
+export interface SetGroupJoinModeRequest {
+  enabled: boolean;
+}
+
+export interface SetGroupTopicModeRequest {
+  enabled: boolean;
+}
+
+export interface SetPINRequest {
+  pin: string;
+  current_pin?: string;
+}
+
+export interface SetUsernameRequest {
+  username: string;
+}
+
+export interface SignedPrekeyRequest {
+  key_id: number;
+  public_key: string;
+  signature: string;
+}
+
+export interface SignedPrekeyResponse {
+  key_id: number;
+  public_key: string;
+  signature: string;
+}
+
+export interface StickerPackResponse {
+  id: string;
+  name: string;
+  kind: string;
+  creator_address: string;
+  sticker_count: number;
+  created_at: string;
+  stickers: StickerResponse[];
+  installed: boolean;
+}
+
+export interface StickerResponse {
+  id: string;
+  pack_id: string;
+  media_id: string;
+  emoji?: string;
+  created_at: string;
+  image: MediaResponse;
+}
+
+export interface StorageUsageResponse {
+  used: number;
+  limit: number;
+}
+
+export interface SupportTicket {
+  id: number;
+  user_address: string;
+  category: string;
+  message: string;
+  logs_media_id?: string;
+  status: string;
+  closed_by?: string;
+  closed_at?: string;
+  created_at: string;
+}
+
+export interface SupportTicketReply {
+  id: number;
+  ticket_id: number;
+  admin_address: string;
+  message: string;
+  created_at: string;
+}
+
+export interface SupportTicketReplyRequest {
+  message: string;
+}
+
+export interface SupportTicketResponse {
+  id: number;
+  user_address: string;
+  category: string;
+  message: string;
+  logs_media_id?: string;
+  status: string;
+  closed_by?: string;
+  closed_at?: string;
+  created_at: string;
+  logs?: MediaResponse;
+  replies: SupportTicketReply[];
+}
+
+export interface SuspendAccountRequest {
+  reason: string;
+}
+
+export interface Transaction {
+  hash: string;
+  block_id: string;
+  type: string;
+  data_id: string;
+  timestamp: string;
+}
+
+export interface TransactionsResponse {
+  transactions: Transaction[];
+  total: number;
+  limit: number;
+  offset: number;
+}
+
+export interface TransferGroupOwnershipRequest {
+  new_owner_address: string;
+}
+
+export interface UpdateChannelMemberRoleRequest {
+  role: string;
+}
+
+export interface UpdateChannelRequest {
+  name: string;
+  is_public?: boolean;
+}
+
+export interface UpdateGroupMemberRoleRequest {
+  role: string;
+}
+
+export interface UpdateMessageStatusesRequest {
+  message_ids: string[];
+  status: string;
+}
+
+export interface UpdateMessageStatusesResponse {
+  updated: string[];
+  skipped: string[];
+}
+
+export interface UpdateNicknameRequest {
+  nickname: string;
+}
+
+export interface UpdateProfileRequest {
+  phone?: string;
+  email?: string;
+}
+
+export interface UpdateUserSettingsRequest {
+  nickname: string;
+  theme: string;
+  notification_enabled: boolean | null;
+  sound_enabled: boolean | null;
+  language: string;
+  auto_download_media: boolean | null;
+  privacy_last_seen: string;
+  privacy_profile_photo: string;
+  privacy_status: string;
+}
+
+export interface UploadKeysRequest {
+  identity_key?: string;
+  signed_prekey?: SignedPrekeyRequest;
+  one_time_prekeys?: OneTimePrekeyRequest[];
+}
+
+export interface UsageResponse {
+  since: string;
+  days: number;
+  messages_per_day: DailyMessageCount[];
+  messages_sent: number;
+  devices: SessionActivity[];
+  push_devices: number;
+  storage: StorageUsageResponse;
+  actions: Record<string, number>;
+}
+
+export interface User {
+  id: number;
+  phone: string;
+  username?: string;
+  public_key: string;
+  address: string;
+  role: string;
+  birthdate?: string;
+  email?: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface UserResponse {
+  address: string;
+  username?: string;
+  phone?: string;
+  alias?: string;
+  labels?: string[];
+  last_seen_at?: string;
+  avatar?: AvatarResponse;
+}
+
+export interface UserSettings {
+  user_id: number;
+  nickname: string;
+  theme: string;
+  notification_enabled: boolean;
+  sound_enabled: boolean;
+  language: string;
+  auto_download_media: boolean;
+  privacy_last_seen: string;
+  privacy_profile_photo: string;
+  privacy_status: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface VerifyOTPRequest {
+  phone: string;
+  code: string;
+  accepted_policies?: number[];
+  birthdate?: string;
+  pin?: string;
+  device_token?: string;
+}
+
+export interface VerifySafetyNumberRequest {
+  verified: boolean;
+  safety_number: string;
+}
+
+export interface VerifySignatureRequest {
+  public_key: string;
+  nonce: string;
+  signature: string;
+  pin?: string;
+  device_token?: string;
+}
+
+export interface VotePollRequest {
+  option: number;
+}
+
+export interface WebChannelPost {
+  id: string;
+  text: string;
+  timestamp: string;
+  edited_at?: string;
+}
+
+export interface WebChannelResponse {
+  name: string;
+  slug: string;
+  member_count: number;
+  posts: WebChannelPost[];
+  page: number;
+  has_next: boolean;
+  has_prev: boolean;
+}
+
+/** Error thrown when the API responds with an error status */
+export class PikoError extends Error {
+  /** What went wrong, e.g. "OTP_EXPIRED". Check it rather than the message, which is translated. */
+  constructor(public readonly status: number, message: string, public readonly code?: string) {
+    super(message);
+    this.name = "PikoError";
+  }
+}
+
+/** Query string parameters */
+export type Query = Record<string, string>;
+
+/** Client for the Piko API */
+export class PikoClient {
+  /** JWT sent with every request. Set it after logging in. */
+  token?: string;
+
+  constructor(public readonly baseURL: string, private readonly fetcher: typeof fetch = fetch) {
+    this.baseURL = baseURL.replace(/\/$/, "");
+  }
+
+  private url(path: string, query?: Query): string {
+    const search = query ? new URLSearchParams(query).toString() : "";
+    return this.baseURL + path + (search ? "?" + search : "");
+  }
+
+  private async send(method: string, path: string, query?: Query, body?: BodyInit, contentType?: string): Promise<Response> {
+    const headers: Record<string, string> = {};
+    if (contentType) {
+      headers["Content-Type"] = contentType;
+    }
+    if (this.token) {
+      headers["Authorization"] = "Bearer " + this.token;
+    }
+
+    const response = await this.fetcher(this.url(path, query), { method, headers, body });
+    if (!response.ok) {
+      let message = response.statusText;
+      let code: string | undefined;
+      try {
+        const error = await response.json();
+        if (error && typeof error.error === "string") {
+          message = error.error;
+        }
+        if (error && typeof error.code === "string") {
+          code = error.code;
+        }
+      } catch {
+        // Not a JSON error body
+      }
+      throw new PikoError(response.status, message, code);
+    }
+    return response;
+  }
+
+  private async request<T>(method: string, path: string, query?: Query, body?: unknown): Promise<T> {
+    const response = body === undefined
+      ? await this.send(method, path, query)
+      : await this.send(method, path, query, JSON.stringify(body), "application/json");
+    return (await response.json()).data as T;
+  }
+
+  private async upload<T>(method: string, path: string, body: BodyInit, contentType?: string, query?: Query): Promise<T> {
+    const response = await this.send(method, path, query, body, contentType);
+    return (await response.json()).data as T;
+  }
+
+  private wsURL(path: string, query?: Query): string {
+    return this.url(path, query).replace(/^http/, "ws");
+  }
+
+  /** GET /api/auth/register/challenge */
+  getRegistrationChallenge(): Promise<RegistrationChallengeResponse> {
+    return this.request("GET", "/api/auth/register/challenge");
+  }
+
+  /** POST /api/auth/register */
+  register(req: RegisterRequest): Promise<Record<string, unknown>> {
+    return this.request("POST", "/api/auth/register", undefined, req);
+  }
+
+  /** POST /api/auth/verify-register */
+  verifyRegister(req: VerifyOTPRequest): Promise<Record<string, unknown>> {
+    return this.request("POST", "/api/auth/verify-register", undefined, req);
+  }
+
+  /** POST /api/auth/login */
+  login(req: LoginRequest): Promise<Record<string, unknown>> {
+    return this.request("POST", "/api/auth/login", undefined, req);
+  }
+
+  /** POST /api/auth/verify-login */
+  verifyLogin(req: VerifyOTPRequest): Promise<AuthResponse> {
+    return this.request("POST", "/api/auth/verify-login", undefined, req);
+  }
+
+  /** POST /api/auth/resend-otp */
+  resendOTP(req: ResendOTPRequest): Promise<ResendOTPResponse> {
+    return this.request("POST", "/api/auth/resend-otp", undefined, req);
+  }
+
+  /** GET /api/auth/challenge */
+  getChallenge(): Promise<ChallengeResponse> {
+    return this.request("GET", "/api/auth/challenge");
+  }
+
+  /** POST /api/auth/verify-signature */
+  verifySignature(req: VerifySignatureRequest): Promise<AuthResponse> {
+    return this.request("POST", "/api/auth/verify-signature", undefined, req);
+  }
+
+  /** GET /api/policies */
+  getPolicies(): Promise<Policy[]> {
+    return this.request("GET", "/api/policies");
+  }
+
+  /** POST /api/policies/accept */
+  acceptPolicies(req: AcceptPoliciesRequest): Promise<PendingPoliciesResponse> {
+    return this.request("POST", "/api/policies/accept", undefined, req);
+  }
+
+  /** GET /api/policies/acceptances */
+  getPolicyAcceptances(): Promise<PolicyAcceptance[]> {
+    return this.request("GET", "/api/policies/acceptances");
+  }
+
+  /** GET /api/profile */
+  getProfile(): Promise<User> {
+    return this.request("GET", "/api/profile");
+  }
+
+  /** PUT /api/profile */
+  updateProfile(req: UpdateProfileRequest): Promise<User> {
+    return this.request("PUT", "/api/profile", undefined, req);
+  }
+
+  /** PUT /api/profile/username */
+  setUsername(req: SetUsernameRequest): Promise<Record<string, unknown>> {
+    return this.request("PUT", "/api/profile/username", undefined, req);
+  }
+
+  /** GET /api/profile/usage */
+  getUsage(query?: Query): Promise<UsageResponse> {
+    return this.request("GET", "/api/profile/usage", query);
+  }
+
+  /** GET /api/users/search */
+  searchUsers(query?: Query): Promise<UserResponse[]> {
+    return this.request("GET", "/api/users/search", query);
+  }
+
+  /** GET /api/users/:address */
+  getUser(address: string): Promise<UserResponse> {
+    return this.request("GET", `/api/users/${encodeURIComponent(address)}`);
+  }
+
+  /** GET /api/users/:address/avatar */
+  getUserAvatar(address: string): Promise<AvatarResponse> {
+    return this.request("GET", `/api/users/${encodeURIComponent(address)}/avatar`);
+  }
+
+  /** GET /api/sessions */
+  getSessions(): Promise<SessionResponse[]> {
+    return this.request("GET", "/api/sessions");
+  }
+
+  /** DELETE /api/sessions */
+  revokeOtherSessions(): Promise<Record<string, unknown>> {
+    return this.request("DELETE", "/api/sessions");
+  }
+
+  /** DELETE /api/sessions/:id */
+  revokeSession(id: string): Promise<Record<string, unknown>> {
+    return this.request("DELETE", `/api/sessions/${encodeURIComponent(id)}`);
+  }
+
+  /** POST /api/sessions/:id/wipe */
+  wipeSession(id: string): Promise<Record<string, unknown>> {
+    return this.request("POST", `/api/sessions/${encodeURIComponent(id)}/wipe`);
+  }
+
+  /** GET /api/security/log */
+  getSecurityLog(): Promise<SecurityLogResponse> {
+    return this.request("GET", "/api/security/log");
+  }
+
+  /** GET /api/security/pin */
+  getPINStatus(): Promise<PINStatusResponse> {
+    return this.request("GET", "/api/security/pin");
+  }
+
+  /** PUT /api/security/pin */
+  setPIN(req: SetPINRequest): Promise<Record<string, unknown>> {
+    return this.request("PUT", "/api/security/pin", undefined, req);
+  }
+
+  /** DELETE /api/security/pin */
+  removePIN(req: RemovePINRequest): Promise<Record<string, unknown>> {
+    return this.request("DELETE", "/api/security/pin", undefined, req);
+  }
+
+  /** POST /api/security/recovery-code */
+  createRecoveryCode(): Promise<RecoveryCodeResponse> {
+    return this.request("POST", "/api/security/recovery-code");
+  }
+
+  /** POST /api/security/freeze */
+  freezeAccount(req: FreezeAccountRequest): Promise<FreezeAccountResponse> {
+    return this.request("POST", "/api/security/freeze", undefined, req);
+  }
+
+  /** POST /api/contacts */
+  saveContact(req: SaveContactRequest): Promise<Contact> {
+    return this.request("POST", "/api/contacts", undefined, req);
+  }
+
+  /** GET /api/contacts */
+  getContacts(query?: Query): Promise<Contact[]> {
+    return this.request("GET", "/api/contacts", query);
+  }
+
+  /** POST /api/contacts/discover */
+  discoverContacts(req: DiscoverContactsRequest): Promise<DiscoveredContact[]> {
+    return this.request("POST", "/api/contacts/discover", undefined, req);
+  }
+
+  /** DELETE /api/contacts/:address */
+  deleteContact(address: string): Promise<Record<string, unknown>> {
+    return this.request("DELETE", `/api/contacts/${encodeURIComponent(address)}`);
+  }
+
+  /** POST /api/reports */
+  createReport(req: CreateReportRequest): Promise<Report> {
+    return this.request("POST", "/api/reports", undefined, req);
+  }
+
+  /** POST /api/support */
+  createSupportTicket(req: CreateSupportTicketRequest): Promise<CreateSupportTicketResponse> {
+    return this.request("POST", "/api/support", undefined, req);
+  }
+
+  /** GET /api/support */
+  getSupportTickets(): Promise<SupportTicket[]> {
+    return this.request("GET", "/api/support");
+  }
+
+  /** POST /api/devices */
+  registerDevice(req: RegisterDeviceRequest): Promise<Record<string, unknown>> {
+    return this.request("POST", "/api/devices", undefined, req);
+  }
+
+  /** DELETE /api/devices/:token */
+  unregisterDevice(token: string): Promise<Record<string, unknown>> {
+    return this.request("DELETE", `/api/devices/${encodeURIComponent(token)}`);
+  }
+
+  /** GET /api/settings */
+  getUserSettings(): Promise<UserSettings> {
+    return this.request("GET", "/api/settings");
+  }
+
+  /** PUT /api/settings */
+  updateUserSettings(req: UpdateUserSettingsRequest): Promise<UserSettings> {
+    return this.request("PUT", "/api/settings", undefined, req);
+  }
+
+  /** PUT /api/settings/nickname */
+  updateNickname(req: UpdateNicknameRequest): Promise<Record<string, unknown>> {
+    return this.request("PUT", "/api/settings/nickname", undefined, req);
+  }
+
+  /** PUT /api/settings/dnd */
+  setDoNotDisturb(req: DoNotDisturbRequest): Promise<DoNotDisturb> {
+    return this.request("PUT", "/api/settings/dnd", undefined, req);
+  }
+
+  /** DELETE /api/settings/dnd */
+  clearDoNotDisturb(): Promise<Record<string, unknown>> {
+    return this.request("DELETE", "/api/settings/dnd");
+  }
+
+  /** GET /api/mutes */
+  getMutes(): Promise<MutesResponse> {
+    return this.request("GET", "/api/mutes");
+  }
+
+  /** PUT /api/mutes */
+  muteConversation(req: MuteRequest): Promise<Mute> {
+    return this.request("PUT", "/api/mutes", undefined, req);
+  }
+
+  /** DELETE /api/mutes/:conversation_type/:conversation_id */
+  unmuteConversation(conversationType: string, conversationID: string): Promise<Record<string, unknown>> {
+    return this.request("DELETE", `/api/mutes/${encodeURIComponent(conversationType)}/${encodeURIComponent(conversationID)}`);
+  }
+
+  /** POST /api/avatars */
+  uploadAvatar(body: BodyInit, contentType?: string): Promise<AvatarResponse> {
+    return this.upload("POST", "/api/avatars", body, contentType, undefined);
+  }
+
+  /** GET /api/avatars */
+  getUserAvatars(): Promise<AvatarResponse[]> {
+    return this.request("GET", "/api/avatars");
+  }
+
+  /** GET /api/avatars/active */
+  getActiveAvatar(): Promise<AvatarResponse> {
+    return this.request("GET", "/api/avatars/active");
+  }
+
+  /** PUT /api/avatars/:id/active */
+  setActiveAvatar(id: string): Promise<Record<string, unknown>> {
+    return this.request("PUT", `/api/avatars/${encodeURIComponent(id)}/active`);
+  }
+
+  /** DELETE /api/avatars/:id */
+  deleteAvatar(id: string): Promise<Record<string, unknown>> {
+    return this.request("DELETE", `/api/avatars/${encodeURIComponent(id)}`);
+  }
+
+  /** GET /api/avatars/:id/file */
+  serveAvatar(id: string, query?: Query): Promise<Response> {
+    return this.send("GET", `/api/avatars/${encodeURIComponent(id)}/file`, query);
+  }
+
+  /** POST /api/messages */
+  sendMessage(req: SendMessageRequest): Promise<Record<string, unknown>> {
+    return this.request("POST", "/api/messages", undefined, req);
+  }
+
+  /** GET /api/messages/inbox */
+  getInbox(query?: Query): Promise<MessagesResponse> {
+    return this.request("GET", "/api/messages/inbox", query);
+  }
+
+  /** GET /api/messages/sent */
+  getSentMessages(query?: Query): Promise<MessagesResponse> {
+    return this.request("GET", "/api/messages/sent", query);
+  }
+
+  /** POST /api/messages/status */
+  updateMessageStatuses(req: UpdateMessageStatusesRequest): Promise<UpdateMessageStatusesResponse> {
+    return this.request("POST", "/api/messages/status", undefined, req);
+  }
+
+  /** GET /api/messages/:id */
+  getMessage(id: string): Promise<MessageResponse> {
+    return this.request("GET", `/api/messages/${encodeURIComponent(id)}`);
+  }
+
+  /** PUT /api/messages/:id */
+  editMessage(id: string, req: EditMessageRequest): Promise<MessageResponse> {
+    return this.request("PUT", `/api/messages/${encodeURIComponent(id)}`, undefined, req);
+  }
+
+  /** GET /api/messages/:id/edits */
+  getMessageEdits(id: string): Promise<MessageEditResponse[]> {
+    return this.request("GET", `/api/messages/${encodeURIComponent(id)}/edits`);
+  }
+
+  /** GET /api/messages/:id/receipts */
+  getMessageReceipts(id: string): Promise<MessageReceiptsResponse> {
+    return this.request("GET", `/api/messages/${encodeURIComponent(id)}/receipts`);
+  }
+
+  /** POST /api/messages/:id/forward */
+  forwardMessage(id: string, req: ForwardMessageRequest): Promise<ForwardMessageResponse> {
+    return this.request("POST", `/api/messages/${encodeURIComponent(id)}/forward`, undefined, req);
+  }
+
+  /** DELETE /api/messages/:id */
+  deleteMessage(id: string): Promise<Record<string, unknown>> {
+    return this.request("DELETE", `/api/messages/${encodeURIComponent(id)}`);
+  }
+
+  /** GET /api/receipts */
+  getReceipts(query?: Query): Promise<ReceiptsResponse> {
+    return this.request("GET", "/api/receipts", query);
+  }
+
+  /** POST /api/broadcasts */
+  createBroadcastList(req: CreateBroadcastListRequest): Promise<BroadcastList> {
+    return this.request("POST", "/api/broadcasts", undefined, req);
+  }
+
+  /** GET /api/broadcasts */
+  getBroadcastLists(): Promise<BroadcastList[]> {
+    return this.request("GET", "/api/broadcasts");
+  }
+
+  /** GET /api/broadcasts/:id */
+  getBroadcastList(id: string): Promise<BroadcastList> {
+    return this.request("GET", `/api/broadcasts/${encodeURIComponent(id)}`);
+  }
+
+  /** PUT /api/broadcasts/:id */
+  renameBroadcastList(id: string, req: RenameBroadcastListRequest): Promise<BroadcastList> {
+    return this.request("PUT", `/api/broadcasts/${encodeURIComponent(id)}`, undefined, req);
+  }
+
+  /** DELETE /api/broadcasts/:id */
+  deleteBroadcastList(id: string): Promise<Record<string, unknown>> {
+    return this.request("DELETE", `/api/broadcasts/${encodeURIComponent(id)}`);
+  }
+
+  /** POST /api/broadcasts/:id/recipients */
+  addBroadcastRecipients(id: string, req: AddBroadcastRecipientsRequest): Promise<Record<string, unknown>> {
+    return this.request("POST", `/api/broadcasts/${encodeURIComponent(id)}/recipients`, undefined, req);
+  }
+
+  /** DELETE /api/broadcasts/:id/recipients/:address */
+  removeBroadcastRecipient(id: string, address: string): Promise<Record<string, unknown>> {
+    return this.request("DELETE", `/api/broadcasts/${encodeURIComponent(id)}/recipients/${encodeURIComponent(address)}`);
+  }
+
+  /** POST /api/broadcasts/:id/messages */
+  sendBroadcast(id: string, req: SendBroadcastRequest): Promise<Broadcast> {
+    return this.request("POST", `/api/broadcasts/${encodeURIComponent(id)}/messages`, undefined, req);
+  }
+
+  /** GET /api/broadcasts/:id/messages/:broadcast_id */
+  getBroadcast(id: string, broadcastID: string): Promise<Broadcast> {
+    return this.request("GET", `/api/broadcasts/${encodeURIComponent(id)}/messages/${encodeURIComponent(broadcastID)}`);
+  }
+
+  /** POST /api/docs */
+  createDoc(req: CreateDocRequest): Promise<CollabDoc> {
+    return this.request("POST", "/api/docs", undefined, req);
+  }
+
+  /** GET /api/docs/:id */
+  getDoc(id: string): Promise<CollabDoc> {
+    return this.request("GET", `/api/docs/${encodeURIComponent(id)}`);
+  }
+
+  /** POST /api/docs/:id/updates */
+  appendDocUpdate(id: string, req: AppendDocUpdateRequest): Promise<DocUpdateResponse> {
+    return this.request("POST", `/api/docs/${encodeURIComponent(id)}/updates`, undefined, req);
+  }
+
+  /** GET /api/docs/:id/updates */
+  getDocUpdates(id: string, query?: Query): Promise<DocUpdatesResponse> {
+    return this.request("GET", `/api/docs/${encodeURIComponent(id)}/updates`, query);
+  }
+
+  /** POST /api/polls */
+  createPoll(req: CreatePollRequest): Promise<PollResponse> {
+    return this.request("POST", "/api/polls", undefined, req);
+  }
+
+  /** GET /api/polls/:id */
+  getPoll(id: string): Promise<PollResponse> {
+    return this.request("GET", `/api/polls/${encodeURIComponent(id)}`);
+  }
+
+  /** PUT /api/polls/:id/vote */
+  votePoll(id: string, req: VotePollRequest): Promise<PollResponse> {
+    return this.request("PUT", `/api/polls/${encodeURIComponent(id)}/vote`, undefined, req);
+  }
+
+  /** DELETE /api/polls/:id/vote */
+  retractPollVote(id: string): Promise<PollResponse> {
+    return this.request("DELETE", `/api/polls/${encodeURIComponent(id)}/vote`);
+  }
+
+  /** POST /api/polls/:id/close */
+  closePoll(id: string): Promise<PollResponse> {
+    return this.request("POST", `/api/polls/${encodeURIComponent(id)}/close`);
+  }
+
+  /** POST /api/sticker-packs */
+  createStickerPack(req: CreateStickerPackRequest): Promise<StickerPackResponse> {
+    return this.request("POST", "/api/sticker-packs", undefined, req);
+  }
+
+  /** GET /api/sticker-packs */
+  getStickerPacks(): Promise<StickerPackResponse[]> {
+    return this.request("GET", "/api/sticker-packs");
+  }
+
+  /** GET /api/sticker-packs/:id */
+  getStickerPack(id: string): Promise<StickerPackResponse> {
+    return this.request("GET", `/api/sticker-packs/${encodeURIComponent(id)}`);
+  }
+
+  /** DELETE /api/sticker-packs/:id */
+  deleteStickerPack(id: string): Promise<Record<string, unknown>> {
+    return this.request("DELETE", `/api/sticker-packs/${encodeURIComponent(id)}`);
+  }
+
+  /** POST /api/sticker-packs/:id/stickers */
+  addSticker(id: string, req: AddStickerRequest): Promise<StickerResponse> {
+    return this.request("POST", `/api/sticker-packs/${encodeURIComponent(id)}/stickers`, undefined, req);
+  }
+
+  /** DELETE /api/sticker-packs/:id/stickers/:sticker_id */
+  deleteSticker(id: string, stickerID: string): Promise<StickerPackResponse> {
+    return this.request("DELETE", `/api/sticker-packs/${encodeURIComponent(id)}/stickers/${encodeURIComponent(stickerID)}`);
+  }
+
+  /** PUT /api/sticker-packs/:id/install */
+  installStickerPack(id: string): Promise<StickerPackResponse> {
+    return this.request("PUT", `/api/sticker-packs/${encodeURIComponent(id)}/install`);
+  }
+
+  /** DELETE /api/sticker-packs/:id/install */
+  uninstallStickerPack(id: string): Promise<StickerPackResponse> {
+    return this.request("DELETE", `/api/sticker-packs/${encodeURIComponent(id)}/install`);
+  }
+
+  /** GET /api/stickers/:id */
+  getSticker(id: string): Promise<StickerResponse> {
+    return this.request("GET", `/api/stickers/${encodeURIComponent(id)}`);
+  }
+
+  /** GET /api/conversations/:address/safety-number */
+  getSafetyNumber(address: string): Promise<SafetyNumberResponse> {
+    return this.request("GET", `/api/conversations/${encodeURIComponent(address)}/safety-number`);
+  }
+
+  /** GET /api/conversations/:address/docs */
+  getDirectDocs(address: string): Promise<CollabDoc[]> {
+    return this.request("GET", `/api/conversations/${encodeURIComponent(address)}/docs`);
+  }
+
+  /** PUT /api/conversations/:address/safety-number */
+  verifySafetyNumber(address: string, req: VerifySafetyNumberRequest): Promise<SafetyNumberResponse> {
+    return this.request("PUT", `/api/conversations/${encodeURIComponent(address)}/safety-number`, undefined, req);
+  }
+
+  /** POST /api/keys */
+  uploadKeys(req: UploadKeysRequest): Promise<KeyStatusResponse> {
+    return this.request("POST", "/api/keys", undefined, req);
+  }
+
+  /** GET /api/keys */
+  getKeyStatus(): Promise<KeyStatusResponse> {
+    return this.request("GET", "/api/keys");
+  }
+
+  /** GET /api/keys/rotation */
+  getKeyRotation(): Promise<KeyRotation> {
+    return this.request("GET", "/api/keys/rotation");
+  }
+
+  /** GET /api/keys/rotations/pending */
+  getPendingKeyRotations(): Promise<KeyRotation[]> {
+    return this.request("GET", "/api/keys/rotations/pending");
+  }
+
+  /** POST /api/keys/rotations/:id/ack */
+  acknowledgeKeyRotation(id: string): Promise<KeyRotation> {
+    return this.request("POST", `/api/keys/rotations/${encodeURIComponent(id)}/ack`);
+  }
+
+  /** GET /api/keys/:address */
+  getKeyBundle(address: string): Promise<KeyBundleResponse> {
+    return this.request("GET", `/api/keys/${encodeURIComponent(address)}`);
+  }
+
+  /** GET /api/conversations/:address/export */
+  exportConversation(address: string): Promise<Response> {
+    return this.send("GET", `/api/conversations/${encodeURIComponent(address)}/export`);
+  }
+
+  /** POST /api/media */
+  uploadMedia(body: BodyInit, contentType?: string): Promise<MediaResponse> {
+    return this.upload("POST", "/api/media", body, contentType, undefined);
+  }
+
+  /** POST /api/media/uploads */
+  createMediaUpload(req: CreateMediaUploadRequest): Promise<MediaUploadResponse> {
+    return this.request("POST", "/api/media/uploads", undefined, req);
+  }
+
+  /** PUT /api/media/uploads/:id */
+  uploadMediaChunk(id: string, body: BodyInit, contentType?: string, query?: Query): Promise<Record<string, unknown>> {
+    return this.upload("PUT", `/api/media/uploads/${encodeURIComponent(id)}`, body, contentType, query);
+  }
+
+  /** GET /api/media/:id */
+  getMedia(id: string): Promise<MediaResponse> {
+    return this.request("GET", `/api/media/${encodeURIComponent(id)}`);
+  }
+
+  /** GET /api/media/:id/download */
+  downloadMedia(id: string, query?: Query): Promise<Response> {
+    return this.send("GET", `/api/media/${encodeURIComponent(id)}/download`, query);
+  }
+
+  /** POST /api/channels */
+  createChannel(req: CreateChannelRequest): Promise<Record<string, unknown>> {
+    return this.request("POST", "/api/channels", undefined, req);
+  }
+
+  /** GET /api/channels */
+  getChannels(): Promise<ChannelResponse[]> {
+    return this.request("GET", "/api/channels");
+  }
+
+  /** GET /api/channels/discover */
+  discoverChannels(query?: Query): Promise<DiscoverChannelResponse[]> {
+    return this.request("GET", "/api/channels/discover", query);
+  }
+
+  /** POST /api/channels/join/:token */
+  joinChannelByLink(token: string): Promise<ChannelResponse> {
+    return this.request("POST", `/api/channels/join/${encodeURIComponent(token)}`);
+  }
+
+  /** GET /api/channels/:id */
+  getChannel(id: string): Promise<ChannelResponse> {
+    return this.request("GET", `/api/channels/${encodeURIComponent(id)}`);
+  }
+
+  /** PUT /api/channels/:id */
+  updateChannel(id: string, req: UpdateChannelRequest): Promise<ChannelResponse> {
+    return this.request("PUT", `/api/channels/${encodeURIComponent(id)}`, undefined, req);
+  }
+
+  /** DELETE /api/channels/:id */
+  deleteChannel(id: string): Promise<Record<string, unknown>> {
+    return this.request("DELETE", `/api/channels/${encodeURIComponent(id)}`);
+  }
+
+  /** POST /api/channels/:id/members */
+  addChannelMember(id: string, req: AddChannelMemberRequest): Promise<Record<string, unknown>> {
+    return this.request("POST", `/api/channels/${encodeURIComponent(id)}/members`, undefined, req);
+  }
+
+  /** GET /api/channels/:id/members */
+  getChannelMembers(id: string): Promise<ChannelMemberResponse[]> {
+    return this.request("GET", `/api/channels/${encodeURIComponent(id)}/members`);
+  }
+
+  /** GET /api/channels/:id/audit-log */
+  getChannelAuditLog(id: string, query?: Query): Promise<AuditEntry[]> {
+    return this.request("GET", `/api/channels/${encodeURIComponent(id)}/audit-log`, query);
+  }
+
+  /** POST /api/channels/:id/invite-link */
+  createChannelInviteLink(id: string): Promise<ChannelInviteLinkResponse> {
+    return this.request("POST", `/api/channels/${encodeURIComponent(id)}/invite-link`);
+  }
+
+  /** DELETE /api/channels/:id/members/:address */
+  removeChannelMember(id: string, address: string): Promise<Record<string, unknown>> {
+    return this.request("DELETE", `/api/channels/${encodeURIComponent(id)}/members/${encodeURIComponent(address)}`);
+  }
+
+  /** PUT /api/channels/:id/members/:address/role */
+  updateChannelMemberRole(id: string, address: string, req: UpdateChannelMemberRoleRequest): Promise<Record<string, unknown>> {
+    return this.request("PUT", `/api/channels/${encodeURIComponent(id)}/members/${encodeURIComponent(address)}/role`, undefined, req);
+  }
+
+  /** POST /api/channels/:id/messages */
+  sendChannelMessage(id: string, req: ChannelMessageRequest): Promise<Record<string, unknown>> {
+    return this.request("POST", `/api/channels/${encodeURIComponent(id)}/messages`, undefined, req);
+  }
+
+  /** GET /api/channels/:id/messages */
+  getChannelMessages(id: string, query?: Query): Promise<ChannelMessageResponse[]> {
+    return this.request("GET", `/api/channels/${encodeURIComponent(id)}/messages`, query);
+  }
+
+  /** PUT /api/channels/:id/messages/:message_id */
+  editChannelMessage(id: string, messageID: string, req: EditMessageRequest): Promise<ChannelMessageResponse> {
+    return this.request("PUT", `/api/channels/${encodeURIComponent(id)}/messages/${encodeURIComponent(messageID)}`, undefined, req);
+  }
+
+  /** GET /api/channels/:id/messages/:message_id/link */
+  getChannelMessageLink(id: string, messageID: string): Promise<MessageLinkResponse> {
+    return this.request("GET", `/api/channels/${encodeURIComponent(id)}/messages/${encodeURIComponent(messageID)}/link`);
+  }
+
+  /** POST /api/channels/:id/crosspost */
+  crosspostChannelMessage(id: string, req: CrosspostRequest): Promise<CrosspostResponse> {
+    return this.request("POST", `/api/channels/${encodeURIComponent(id)}/crosspost`, undefined, req);
+  }
+
+  /** GET /api/links/channel/:id/:message_id */
+  resolveMessageLink(id: string, messageID: string): Promise<ResolvedMessageLinkResponse> {
+    return this.request("GET", `/api/links/channel/${encodeURIComponent(id)}/${encodeURIComponent(messageID)}`);
+  }
+
+  /** POST /api/channels/:id/read */
+  markChannelRead(id: string, req: MarkReadRequest): Promise<Record<string, unknown>> {
+    return this.request("POST", `/api/channels/${encodeURIComponent(id)}/read`, undefined, req);
+  }
+
+  /** GET /api/channels/:id/docs */
+  getChannelDocs(id: string): Promise<CollabDoc[]> {
+    return this.request("GET", `/api/channels/${encodeURIComponent(id)}/docs`);
+  }
+
+  /** GET /api/channels/:id/polls */
+  getChannelPolls(id: string, query?: Query): Promise<PollResponse[]> {
+    return this.request("GET", `/api/channels/${encodeURIComponent(id)}/polls`, query);
+  }
+
+  /** POST /api/channels/:id/ack */
+  ackChannelMessages(id: string, req: AckChannelMessagesRequest): Promise<AckChannelMessagesResponse> {
+    return this.request("POST", `/api/channels/${encodeURIComponent(id)}/ack`, undefined, req);
+  }
+
+  /** GET /api/channels/:id/stats */
+  getChannelStats(id: string, query?: Query): Promise<ChannelStats> {
+    return this.request("GET", `/api/channels/${encodeURIComponent(id)}/stats`, query);
+  }
+
+  /** GET /api/channels/:id/moderation */
+  getChannelFlag(id: string): Promise<ChannelFlag> {
+    return this.request("GET", `/api/channels/${encodeURIComponent(id)}/moderation`);
+  }
+
+  /** POST /api/channels/:id/appeal */
+  appealChannelFlag(id: string, req: AppealChannelFlagRequest): Promise<ChannelFlag> {
+    return this.request("POST", `/api/channels/${encodeURIComponent(id)}/appeal`, undefined, req);
+  }
+
+  /** GET /api/channels/:id/web-view */
+  getChannelWebView(id: string): Promise<ChannelWebViewResponse> {
+    return this.request("GET", `/api/channels/${encodeURIComponent(id)}/web-view`);
+  }
+
+  /** PUT /api/channels/:id/web-view */
+  setChannelWebView(id: string, req: ChannelWebViewRequest): Promise<ChannelWebViewResponse> {
+    return this.request("PUT", `/api/channels/${encodeURIComponent(id)}/web-view`, undefined, req);
+  }
+
+  /** DELETE /api/channels/:id/web-view */
+  deleteChannelWebView(id: string): Promise<Record<string, unknown>> {
+    return this.request("DELETE", `/api/channels/${encodeURIComponent(id)}/web-view`);
+  }
+
+  /** DELETE /api/channels/:channel_id/messages/:message_id */
+  deleteChannelMessage(channelID: string, messageID: string): Promise<Record<string, unknown>> {
+    return this.request("DELETE", `/api/channels/${encodeURIComponent(channelID)}/messages/${encodeURIComponent(messageID)}`);
+  }
+
+  /** GET /api/web/channels/:slug */
+  getWebChannel(slug: string, query?: Query): Promise<WebChannelResponse> {
+    return this.request("GET", `/api/web/channels/${encodeURIComponent(slug)}`, query);
+  }
+
+  /** GET /c/:slug */
+  getChannelWebPage(slug: string, query?: Query): Promise<Response> {
+    return this.send("GET", `/c/${encodeURIComponent(slug)}`, query);
+  }
+
+  /** GET /api/blocks */
+  listBlocks(query?: Query): Promise<BlocksResponse> {
+    return this.request("GET", "/api/blocks", query);
+  }
+
+  /** GET /api/blocks/:id */
+  getBlock(id: string): Promise<Block> {
+    return this.request("GET", `/api/blocks/${encodeURIComponent(id)}`);
+  }
+
+  /** GET /api/blocks/height/:height */
+  getBlockByHeight(height: string): Promise<Block> {
+    return this.request("GET", `/api/blocks/height/${encodeURIComponent(height)}`);
+  }
+
+  /** GET /api/blocks/:id/transactions */
+  listBlockTransactions(id: string, query?: Query): Promise<TransactionsResponse> {
+    return this.request("GET", `/api/blocks/${encodeURIComponent(id)}/transactions`, query);
+  }
+
+  /** GET /api/transactions */
+  listTransactions(query?: Query): Promise<TransactionsResponse> {
+    return this.request("GET", "/api/transactions", query);
+  }
+
+  /** GET /api/transactions/:hash */
+  getTransaction(hash: string): Promise<Transaction> {
+    return this.request("GET", `/api/transactions/${encodeURIComponent(hash)}`);
+  }
+
+  /** GET /api/explore/:address */
+  exploreAddress(address: string): Promise<Transaction[]> {
+    return this.request("GET", `/api/explore/${encodeURIComponent(address)}`);
+  }
+
+  /** GET /api/proof/:message_id */
+  getProof(messageID: string): Promise<Record<string, unknown>> {
+    return this.request("GET", `/api/proof/${encodeURIComponent(messageID)}`);
+  }
+
+  /** GET /api/blockchain/stats */
+  getBlockchainStats(): Promise<Record<string, unknown>> {
+    return this.request("GET", "/api/blockchain/stats");
+  }
+
+  /** GET /api/blockchain/params */
+  getConsensusParams(): Promise<ConsensusParamsResponse> {
+    return this.request("GET", "/api/blockchain/params");
+  }
+
+  /** GET /api/blockchain/verify */
+  verifyChain(): Promise<ChainReport> {
+    return this.request("GET", "/api/blockchain/verify");
+  }
+
+  /** GET /api/secret-chat/challenge */
+  getSecretChatChallenge(): Promise<SecretChatChallengeResponse> {
+    return this.request("GET", "/api/secret-chat/challenge");
+  }
+
+  /** POST /api/secret-chat/create */
+  createSecretChat(req: CreateSecretChatRequest): Promise<CreateSecretChatResponse> {
+    return this.request("POST", "/api/secret-chat/create", undefined, req);
+  }
+
+  /** POST /api/secret-chat/join */
+  joinSecretChat(req: JoinSecretChatRequest): Promise<JoinSecretChatResponse> {
+    return this.request("POST", "/api/secret-chat/join", undefined, req);
+  }
+
+  /** POST /api/secret-chat/send */
+  sendSecretChatMessage(req: SecretChatMessageRequest): Promise<Record<string, unknown>> {
+    return this.request("POST", "/api/secret-chat/send", undefined, req);
+  }
+
+  /** GET /api/secret-chat/messages/:channel_id */
+  getSecretChatMessages(channelID: string, query?: Query): Promise<SecretChatMessageResponse[]> {
+    return this.request("GET", `/api/secret-chat/messages/${encodeURIComponent(channelID)}`, query);
+  }
+
+  /** DELETE /api/secret-chat/:channel_id */
+  deleteSecretChat(channelID: string, query?: Query): Promise<Record<string, unknown>> {
+    return this.request("DELETE", `/api/secret-chat/${encodeURIComponent(channelID)}`, query);
+  }
+
+  /** GET /ws/secret/:session_id */
+  secretChatWebSocketURL(sessionID: string): string {
+    return this.wsURL(`/ws/secret/${encodeURIComponent(sessionID)}`);
+  }
+
+  /** GET /ws */
+  webSocketURL(query?: Query): string {
+    return this.wsURL("/ws", query);
+  }
+
+  /** POST /api/groups */
+  createGroup(req: CreateGroupRequest): Promise<Record<string, unknown>> {
+    return this.request("POST", "/api/groups", undefined, req);
+  }
+
+  /** GET /api/groups */
+  getGroups(): Promise<GroupResponse[]> {
+    return this.request("GET", "/api/groups");
+  }
+
+  /** POST /api/groups/join/:token */
+  joinGroupByInvite(token: string): Promise<GroupResponse> {
+    return this.request("POST", `/api/groups/join/${encodeURIComponent(token)}`);
+  }
+
+  /** GET /api/groups/:id */
+  getGroup(id: string): Promise<GroupResponse> {
+    return this.request("GET", `/api/groups/${encodeURIComponent(id)}`);
+  }
+
+  /** PUT /api/groups/:id */
+  updateGroup(id: string, req: CreateGroupRequest): Promise<GroupResponse> {
+    return this.request("PUT", `/api/groups/${encodeURIComponent(id)}`, undefined, req);
+  }
+
+  /** DELETE /api/groups/:id */
+  deleteGroup(id: string): Promise<Record<string, unknown>> {
+    return this.request("DELETE", `/api/groups/${encodeURIComponent(id)}`);
+  }
+
+  /** GET /api/groups/:id/export */
+  exportGroup(id: string, query?: Query): Promise<Response> {
+    return this.send("GET", `/api/groups/${encodeURIComponent(id)}/export`, query);
+  }
+
+  /** GET /api/groups/:id/audit-log */
+  getGroupAuditLog(id: string, query?: Query): Promise<AuditEntry[]> {
+    return this.request("GET", `/api/groups/${encodeURIComponent(id)}/audit-log`, query);
+  }
+
+  /** GET /api/groups/:id/members */
+  getGroupMembers(id: string): Promise<GroupMemberResponse[]> {
+    return this.request("GET", `/api/groups/${encodeURIComponent(id)}/members`);
+  }
+
+  /** POST /api/groups/:id/members */
+  addGroupMember(id: string, req: AddGroupMemberRequest): Promise<Record<string, unknown>> {
+    return this.request("POST", `/api/groups/${encodeURIComponent(id)}/members`, undefined, req);
+  }
+
+  /** DELETE /api/groups/:id/members/:address */
+  removeGroupMember(id: string, address: string): Promise<Record<string, unknown>> {
+    return this.request("DELETE", `/api/groups/${encodeURIComponent(id)}/members/${encodeURIComponent(address)}`);
+  }
+
+  /** PUT /api/groups/:id/members/:address/role */
+  updateGroupMemberRole(id: string, address: string, req: UpdateGroupMemberRoleRequest): Promise<Record<string, unknown>> {
+    return this.request("PUT", `/api/groups/${encodeURIComponent(id)}/members/${encodeURIComponent(address)}/role`, undefined, req);
+  }
+
+  /** POST /api/groups/:id/transfer-ownership */
+  transferGroupOwnership(id: string, req: TransferGroupOwnershipRequest): Promise<Record<string, unknown>> {
+    return this.request("POST", `/api/groups/${encodeURIComponent(id)}/transfer-ownership`, undefined, req);
+  }
+
+  /** PUT /api/groups/:id/join-mode */
+  setGroupJoinMode(id: string, req: SetGroupJoinModeRequest): Promise<Record<string, unknown>> {
+    return this.request("PUT", `/api/groups/${encodeURIComponent(id)}/join-mode`, undefined, req);
+  }
+
+  /** POST /api/groups/:id/join */
+  requestToJoinGroup(id: string, req: JoinGroupRequest): Promise<GroupJoinRequest> {
+    return this.request("POST", `/api/groups/${encodeURIComponent(id)}/join`, undefined, req);
+  }
+
+  /** GET /api/groups/:id/join-requests */
+  getGroupJoinRequests(id: string): Promise<GroupJoinRequest[]> {
+    return this.request("GET", `/api/groups/${encodeURIComponent(id)}/join-requests`);
+  }
+
+  /** POST /api/groups/:id/join-requests/:address/approve */
+  approveGroupJoinRequest(id: string, address: string): Promise<Record<string, unknown>> {
+    return this.request("POST", `/api/groups/${encodeURIComponent(id)}/join-requests/${encodeURIComponent(address)}/approve`);
+  }
+
+  /** POST /api/groups/:id/join-requests/:address/reject */
+  rejectGroupJoinRequest(id: string, address: string): Promise<Record<string, unknown>> {
+    return this.request("POST", `/api/groups/${encodeURIComponent(id)}/join-requests/${encodeURIComponent(address)}/reject`);
+  }
+
+  /** POST /api/groups/:id/guests */
+  grantGuestPass(id: string, req: GrantGuestPassRequest): Promise<GuestPass> {
+    return this.request("POST", `/api/groups/${encodeURIComponent(id)}/guests`, undefined, req);
+  }
+
+  /** GET /api/groups/:id/guests */
+  getGuestPasses(id: string): Promise<GuestPass[]> {
+    return this.request("GET", `/api/groups/${encodeURIComponent(id)}/guests`);
+  }
+
+  /** DELETE /api/groups/:id/guests/:address */
+  revokeGuestPass(id: string, address: string): Promise<Record<string, unknown>> {
+    return this.request("DELETE", `/api/groups/${encodeURIComponent(id)}/guests/${encodeURIComponent(address)}`);
+  }
+
+  /** POST /api/groups/:id/invites */
+  createGroupInvite(id: string, req: CreateGroupInviteRequest): Promise<GroupInviteResponse> {
+    return this.request("POST", `/api/groups/${encodeURIComponent(id)}/invites`, undefined, req);
+  }
+
+  /** GET /api/groups/:id/invites */
+  getGroupInvites(id: string): Promise<GroupInviteResponse[]> {
+    return this.request("GET", `/api/groups/${encodeURIComponent(id)}/invites`);
+  }
+
+  /** DELETE /api/groups/:id/invites/:token */
+  revokeGroupInvite(id: string, token: string): Promise<Record<string, unknown>> {
+    return this.request("DELETE", `/api/groups/${encodeURIComponent(id)}/invites/${encodeURIComponent(token)}`);
+  }
+
+  /** POST /api/groups/:id/messages */
+  sendGroupMessage(id: string, req: SendGroupMessageRequest): Promise<Record<string, unknown>> {
+    return this.request("POST", `/api/groups/${encodeURIComponent(id)}/messages`, undefined, req);
+  }
+
+  /** GET /api/groups/:id/messages */
+  getGroupMessages(id: string, query?: Query): Promise<GroupMessageResponse[]> {
+    return this.request("GET", `/api/groups/${encodeURIComponent(id)}/messages`, query);
+  }
+
+  /** PUT /api/groups/:id/messages/:message_id */
+  editGroupMessage(id: string, messageID: string, req: EditGroupMessageRequest): Promise<GroupMessageResponse> {
+    return this.request("PUT", `/api/groups/${encodeURIComponent(id)}/messages/${encodeURIComponent(messageID)}`, undefined, req);
+  }
+
+  /** POST /api/groups/:id/read */
+  markGroupRead(id: string, req: MarkReadRequest): Promise<Record<string, unknown>> {
+    return this.request("POST", `/api/groups/${encodeURIComponent(id)}/read`, undefined, req);
+  }
+
+  /** GET /api/groups/:id/docs */
+  getGroupDocs(id: string): Promise<CollabDoc[]> {
+    return this.request("GET", `/api/groups/${encodeURIComponent(id)}/docs`);
+  }
+
+  /** GET /api/groups/:id/polls */
+  getGroupPolls(id: string, query?: Query): Promise<PollResponse[]> {
+    return this.request("GET", `/api/groups/${encodeURIComponent(id)}/polls`, query);
+  }
+
+  /** PUT /api/groups/:id/topic-mode */
+  setGroupTopicMode(id: string, req: SetGroupTopicModeRequest): Promise<Record<string, unknown>> {
+    return this.request("PUT", `/api/groups/${encodeURIComponent(id)}/topic-mode`, undefined, req);
+  }
+
+  /** POST /api/groups/:id/topics */
+  createGroupTopic(id: string, req: CreateGroupTopicRequest): Promise<GroupTopic> {
+    return this.request("POST", `/api/groups/${encodeURIComponent(id)}/topics`, undefined, req);
+  }
+
+  /** GET /api/groups/:id/topics */
+  getGroupTopics(id: string): Promise<GroupTopic[]> {
+    return this.request("GET", `/api/groups/${encodeURIComponent(id)}/topics`);
+  }
+
+  /** POST /api/groups/:id/topics/:topic_id/close */
+  closeGroupTopic(id: string, topicID: string): Promise<GroupTopic> {
+    return this.request("POST", `/api/groups/${encodeURIComponent(id)}/topics/${encodeURIComponent(topicID)}/close`);
+  }
+
+  /** POST /api/groups/:id/topics/:topic_id/reopen */
+  reopenGroupTopic(id: string, topicID: string): Promise<GroupTopic> {
+    return this.request("POST", `/api/groups/${encodeURIComponent(id)}/topics/${encodeURIComponent(topicID)}/reopen`);
+  }
+
+  /** POST /api/groups/:id/topics/:topic_id/read */
+  markGroupTopicRead(id: string, topicID: string, req: MarkReadRequest): Promise<Record<string, unknown>> {
+    return this.request("POST", `/api/groups/${encodeURIComponent(id)}/topics/${encodeURIComponent(topicID)}/read`, undefined, req);
+  }
+
+  /** POST /api/groups/:id/events */
+  createGroupEvent(id: string, req: CreateGroupEventRequest): Promise<GroupEventResponse> {
+    return this.request("POST", `/api/groups/${encodeURIComponent(id)}/events`, undefined, req);
+  }
+
+  /** GET /api/groups/:id/events */
+  getGroupEvents(id: string, query?: Query): Promise<GroupEvent[]> {
+    return this.request("GET", `/api/groups/${encodeURIComponent(id)}/events`, query);
+  }
+
+  /** GET /api/groups/:id/events/:event_id */
+  getGroupEvent(id: string, eventID: string): Promise<GroupEventResponse> {
+    return this.request("GET", `/api/groups/${encodeURIComponent(id)}/events/${encodeURIComponent(eventID)}`);
+  }
+
+  /** DELETE /api/groups/:id/events/:event_id */
+  deleteGroupEvent(id: string, eventID: string): Promise<Record<string, unknown>> {
+    return this.request("DELETE", `/api/groups/${encodeURIComponent(id)}/events/${encodeURIComponent(eventID)}`);
+  }
+
+  /** PUT /api/groups/:id/events/:event_id/rsvp */
+  rSVPGroupEvent(id: string, eventID: string, req: RSVPRequest): Promise<GroupEventResponse> {
+    return this.request("PUT", `/api/groups/${encodeURIComponent(id)}/events/${encodeURIComponent(eventID)}/rsvp`, undefined, req);
+  }
+
+  /** DELETE /api/groups/:id/events/:event_id/rsvp */
+  deleteGroupEventRSVP(id: string, eventID: string): Promise<GroupEventResponse> {
+    return this.request("DELETE", `/api/groups/${encodeURIComponent(id)}/events/${encodeURIComponent(eventID)}/rsvp`);
+  }
+
+  /** GET /api/admin/stats */
+  getAdminStats(): Promise<AdminStatsResponse> {
+    return this.request("GET", "/api/admin/stats");
+  }
+
+  /** GET /api/admin/blocks */
+  getAdminBlocks(query?: Query): Promise<Block[]> {
+    return this.request("GET", "/api/admin/blocks", query);
+  }
+
+  /** GET /api/admin/blockchain */
+  getAdminBlockchain(): Promise<Record<string, unknown>> {
+    return this.request("GET", "/api/admin/blockchain");
+  }
+
+  /** GET /api/admin/clients */
+  getAdminClients(): Promise<ClientInfo[]> {
+    return this.request("GET", "/api/admin/clients");
+  }
+
+  /** GET /api/admin/connections */
+  getAdminConnections(): Promise<AdminConnectionsResponse> {
+    return this.request("GET", "/api/admin/connections");
+  }
+
+  /** GET /api/admin/users/lookup */
+  lookupUser(query?: Query): Promise<AdminUserResponse> {
+    return this.request("GET", "/api/admin/users/lookup", query);
+  }
+
+  /** PUT /api/admin/users/:address/suspension */
+  suspendAccount(address: string, req: SuspendAccountRequest): Promise<AccountSuspension> {
+    return this.request("PUT", `/api/admin/users/${encodeURIComponent(address)}/suspension`, undefined, req);
+  }
+
+  /** DELETE /api/admin/users/:address/suspension */
+  unsuspendAccount(address: string): Promise<Record<string, unknown>> {
+    return this.request("DELETE", `/api/admin/users/${encodeURIComponent(address)}/suspension`);
+  }
+
+  /** DELETE /api/admin/users/:address/messages */
+  purgeUserMessages(address: string): Promise<PurgedMessages> {
+    return this.request("DELETE", `/api/admin/users/${encodeURIComponent(address)}/messages`);
+  }
+
+  /** GET /api/admin/reports */
+  getAdminReports(query?: Query): Promise<Report[]> {
+    return this.request("GET", "/api/admin/reports", query);
+  }
+
+  /** PUT /api/admin/reports/:id */
+  resolveReport(id: string, req: ResolveReportRequest): Promise<Record<string, unknown>> {
+    return this.request("PUT", `/api/admin/reports/${encodeURIComponent(id)}`, undefined, req);
+  }
+
+  /** GET /api/admin/channel-flags */
+  getChannelFlags(query?: Query): Promise<ChannelFlag[]> {
+    return this.request("GET", "/api/admin/channel-flags", query);
+  }
+
+  /** PUT /api/admin/channel-flags/:id */
+  reviewChannelFlag(id: string, req: ReviewChannelFlagRequest): Promise<ChannelFlag> {
+    return this.request("PUT", `/api/admin/channel-flags/${encodeURIComponent(id)}`, undefined, req);
+  }
+
+  /** GET /api/admin/delivery-sla */
+  getDeliverySLA(query?: Query): Promise<DeliverySLA[]> {
+    return this.request("GET", "/api/admin/delivery-sla", query);
+  }
+
+  /** GET /api/admin/support */
+  getAdminSupportTickets(query?: Query): Promise<SupportTicket[]> {
+    return this.request("GET", "/api/admin/support", query);
+  }
+
+  /** GET /api/admin/support/:id */
+  getAdminSupportTicket(id: string): Promise<SupportTicketResponse> {
+    return this.request("GET", `/api/admin/support/${encodeURIComponent(id)}`);
+  }
+
+  /** POST /api/admin/support/:id/replies */
+  replySupportTicket(id: string, req: SupportTicketReplyRequest): Promise<SupportTicketReply> {
+    return this.request("POST", `/api/admin/support/${encodeURIComponent(id)}/replies`, undefined, req);
+  }
+
+  /** POST /api/admin/support/:id/close */
+  closeSupportTicket(id: string): Promise<SupportTicket> {
+    return this.request("POST", `/api/admin/support/${encodeURIComponent(id)}/close`);
+  }
+
+  /** POST /api/admin/policies */
+  publishPolicy(req: PublishPolicyRequest): Promise<Policy> {
+    return this.request("POST", "/api/admin/policies", undefined, req);
+  }
+
+  /** GET /api/admin/legal-holds */
+  getLegalHolds(): Promise<LegalHold[]> {
+    return this.request("GET", "/api/admin/legal-holds");
+  }
+
+  /** PUT /api/admin/legal-holds/:address */
+  placeLegalHold(address: string, req: PlaceLegalHoldRequest): Promise<LegalHold> {
+    return this.request("PUT", `/api/admin/legal-holds/${encodeURIComponent(address)}`, undefined, req);
+  }
+
+  /** DELETE /api/admin/legal-holds/:address */
+  releaseLegalHold(address: string): Promise<Record<string, unknown>> {
+    return this.request("DELETE", `/api/admin/legal-holds/${encodeURIComponent(address)}`);
+  }
+
+  /** GET /api/admin/legal-holds/:address/export */
+  exportLegalHold(address: string): Promise<ComplianceExport> {
+    return this.request("GET", `/api/admin/legal-holds/${encodeURIComponent(address)}/export`);
+  }
+
+  /** GET /api/openapi.json */
+  getOpenAPI(): Promise<Record<string, unknown>> {
+    return this.request("GET", "/api/openapi.json");
+  }
+
+  /** GET /api/docs */
+  getAPIDocs(): Promise<Response> {
+    return this.send("GET", "/api/docs");
+  }
+}
@@ -0,0 +1,246 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/piko/piko/config"
+)
+
+// S3Backend stores objects in an S3-compatible bucket using signature v4
+type S3Backend struct {
+	cfg    config.S3Config
+	client *http.Client
+}
+
+const (
+	// minPartSize is the smallest part S3 accepts in a multipart upload,
+	// other than the last
+	minPartSize = 5 * 1024 * 1024
+	// defaultPartSize is the part size used when none is configured
+	defaultPartSize = 16 * 1024 * 1024
+)
+
+// NewS3Backend creates an S3 backend
+func NewS3Backend(cfg config.S3Config) (*S3Backend, error) {
+	if cfg.Bucket == "" || cfg.Region == "" || cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, errors.New("bucket, region and credentials are required for S3 storage")
+	}
+	switch cfg.ServerSideEncryption {
+	case "", "AES256":
+		if cfg.KMSKeyID != "" {
+			return nil, errors.New("a KMS key can only be used with aws:kms server-side encryption")
+		}
+	case "aws:kms":
+	default:
+		return nil, fmt.Errorf("unsupported server-side encryption: %s", cfg.ServerSideEncryption)
+	}
+	if cfg.PartSize == 0 {
+		cfg.PartSize = defaultPartSize
+	}
+	if cfg.PartSize < minPartSize {
+		return nil, fmt.Errorf("S3 part size must be at least %d bytes", minPartSize)
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", cfg.Region)
+	}
+	return &S3Backend{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 5 * time.Minute},
+	}, nil
+}
+
+// objectURL returns the URL of an object, path-style or virtual-hosted
+func (b *S3Backend) objectURL(key string) (*url.URL, error) {
+	endpoint, err := url.Parse(b.cfg.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	escaped := (&url.URL{Path: key}).EscapedPath()
+	if b.cfg.UsePathStyle {
+		endpoint.Path = "/" + b.cfg.Bucket + "/" + strings.TrimPrefix(escaped, "/")
+	} else {
+		endpoint.Host = b.cfg.Bucket + "." + endpoint.Host
+		endpoint.Path = "/" + strings.TrimPrefix(escaped, "/")
+	}
+	endpoint.RawPath = endpoint.Path
+	return endpoint, nil
+}
+
+// setEncryption asks S3 to encrypt the object a request stores
+func (b *S3Backend) setEncryption(req *http.Request) {
+	if b.cfg.ServerSideEncryption == "" {
+		return
+	}
+	req.Header.Set("X-Amz-Server-Side-Encryption", b.cfg.ServerSideEncryption)
+	if b.cfg.KMSKeyID != "" {
+		req.Header.Set("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id", b.cfg.KMSKeyID)
+	}
+}
+
+// Put uploads an object, in parts if it is larger than the part size
+func (b *S3Backend) Put(key string, r io.Reader, size int64, contentType string) error {
+	if size > b.cfg.PartSize {
+		return b.putMultipart(key, r, size, contentType)
+	}
+
+	u, err := b.objectURL(key)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPut, u.String(), io.LimitReader(r, size))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+	b.setEncryption(req)
+	b.sign(req, "UNSIGNED-PAYLOAD")
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("s3 put returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Get downloads an object
+func (b *S3Backend) Get(key string) (io.ReadCloser, error) {
+	u, err := b.objectURL(key)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	b.sign(req, emptyPayloadHash)
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, ErrObjectNotFound
+	default:
+		resp.Body.Close()
+		return nil, fmt.Errorf("s3 get returned status %d", resp.StatusCode)
+	}
+}
+
+// Delete removes an object
+func (b *S3Backend) Delete(key string) error {
+	u, err := b.objectURL(key)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodDelete, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	b.sign(req, emptyPayloadHash)
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("s3 delete returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// emptyPayloadHash is the SHA-256 of an empty body
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// sign adds AWS signature version 4 headers to a request
+func (b *S3Backend) sign(req *http.Request, payloadHash string) {
+	now := time.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("Host", req.URL.Host)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	// S3 refuses requests with x-amz-* headers left out of the signature
+	names := []string{"host"}
+	for name := range req.Header {
+		if name = strings.ToLower(name); strings.HasPrefix(name, "x-amz-") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	signedHeaders := strings.Join(names, ";")
+	canonicalHeaders := ""
+	for _, name := range names {
+		value := req.URL.Host
+		if name != "host" {
+			value = strings.TrimSpace(req.Header.Get(name))
+		}
+		canonicalHeaders += name + ":" + value + "\n"
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + b.cfg.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+b.cfg.SecretAccessKey), date)
+	key = hmacSHA256(key, b.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		b.cfg.AccessKeyID, scope, signedHeaders, signature,
+	))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
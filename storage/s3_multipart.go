@@ -0,0 +1,215 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// completedPart is an uploaded part listed when completing a multipart
+// upload
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+// completeMultipartUpload is the body of a CompleteMultipartUpload request
+type completeMultipartUpload struct {
+	XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+	Parts   []completedPart `xml:"Part"`
+}
+
+// initiateMultipartUploadResult is the answer to CreateMultipartUpload
+type initiateMultipartUploadResult struct {
+	UploadID string `xml:"UploadId"`
+}
+
+// s3Error is the body S3 answers failed requests with
+type s3Error struct {
+	XMLName xml.Name `xml:"Error"`
+	Code    string   `xml:"Code"`
+	Message string   `xml:"Message"`
+}
+
+// putMultipart uploads an object in parts of the configured size, so large
+// media don't have to go up in one request. Parts are read into memory one
+// at a time.
+func (b *S3Backend) putMultipart(key string, r io.Reader, size int64, contentType string) error {
+	uploadID, err := b.createMultipartUpload(key, contentType)
+	if err != nil {
+		return err
+	}
+
+	parts := []completedPart{}
+	buf := make([]byte, b.cfg.PartSize)
+	for number := 1; size > 0; number++ {
+		n := b.cfg.PartSize
+		if size < n {
+			n = size
+		}
+		if _, err := io.ReadFull(r, buf[:n]); err != nil {
+			b.abortMultipartUpload(key, uploadID)
+			return err
+		}
+		etag, err := b.uploadPart(key, uploadID, number, buf[:n])
+		if err != nil {
+			b.abortMultipartUpload(key, uploadID)
+			return err
+		}
+		parts = append(parts, completedPart{PartNumber: number, ETag: etag})
+		size -= n
+	}
+
+	if err := b.completeMultipartUpload(key, uploadID, parts); err != nil {
+		b.abortMultipartUpload(key, uploadID)
+		return err
+	}
+	return nil
+}
+
+// multipartURL returns the URL of an object with a multipart upload query
+func (b *S3Backend) multipartURL(key string, query url.Values) (string, error) {
+	u, err := b.objectURL(key)
+	if err != nil {
+		return "", err
+	}
+	// Encode sorts the keys, as the signature's canonical query needs
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// createMultipartUpload starts a multipart upload and returns its ID
+func (b *S3Backend) createMultipartUpload(key, contentType string) (string, error) {
+	u, err := b.multipartURL(key, url.Values{"uploads": {""}})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, u, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", contentType)
+	b.setEncryption(req)
+	b.sign(req, emptyPayloadHash)
+
+	var result initiateMultipartUploadResult
+	if err := b.doXML(req, "create multipart upload", &result); err != nil {
+		return "", err
+	}
+	if result.UploadID == "" {
+		return "", fmt.Errorf("s3 create multipart upload returned no upload ID")
+	}
+	return result.UploadID, nil
+}
+
+// uploadPart uploads one part and returns its ETag
+func (b *S3Backend) uploadPart(key, uploadID string, number int, data []byte) (string, error) {
+	u, err := b.multipartURL(key, url.Values{
+		"partNumber": {strconv.Itoa(number)},
+		"uploadId":   {uploadID},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest(http.MethodPut, u, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	b.sign(req, hashHex(data))
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("s3 upload part %d returned status %d", number, resp.StatusCode)
+	}
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		return "", fmt.Errorf("s3 upload part %d returned no ETag", number)
+	}
+	return etag, nil
+}
+
+// completeMultipartUpload assembles the uploaded parts into the object
+func (b *S3Backend) completeMultipartUpload(key, uploadID string, parts []completedPart) error {
+	u, err := b.multipartURL(key, url.Values{"uploadId": {uploadID}})
+	if err != nil {
+		return err
+	}
+	body, err := xml.Marshal(completeMultipartUpload{Parts: parts})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/xml")
+	b.sign(req, hashHex(body))
+
+	// S3 can fail a completion after answering 200, with an error body
+	return b.doXML(req, "complete multipart upload", &struct{}{})
+}
+
+// abortMultipartUpload discards the parts of a failed upload, so they stop
+// taking up storage. Failing is only logged: the upload already failed.
+func (b *S3Backend) abortMultipartUpload(key, uploadID string) {
+	u, err := b.multipartURL(key, url.Values{"uploadId": {uploadID}})
+	if err != nil {
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodDelete, u, nil)
+	if err != nil {
+		return
+	}
+	b.sign(req, emptyPayloadHash)
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		log.Printf("Error aborting S3 multipart upload of %s: %v", key, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		log.Printf("Error aborting S3 multipart upload of %s: status %d", key, resp.StatusCode)
+	}
+}
+
+// doXML sends a request and decodes its XML answer into v, failing on
+// error statuses and error bodies
+func (b *S3Backend) doXML(req *http.Request, operation string, v interface{}) error {
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("s3 %s returned status %d", operation, resp.StatusCode)
+	}
+
+	var s3Err s3Error
+	if xml.Unmarshal(body, &s3Err) == nil {
+		return fmt.Errorf("s3 %s failed: %s: %s", operation, s3Err.Code, s3Err.Message)
+	}
+	if len(body) == 0 {
+		return nil
+	}
+	return xml.Unmarshal(body, v)
+}
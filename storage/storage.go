@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/piko/piko/config"
+)
+
+var (
+	// ErrObjectNotFound is returned when a stored object doesn't exist
+	ErrObjectNotFound = errors.New("object not found")
+)
+
+// Backend stores binary objects by key
+type Backend interface {
+	// Put stores size bytes read from r under key
+	Put(key string, r io.Reader, size int64, contentType string) error
+	// Get opens the object stored under key
+	Get(key string) (io.ReadCloser, error)
+	// Delete removes the object stored under key
+	Delete(key string) error
+}
+
+// New creates the storage backend selected in configuration
+func New(cfg config.StorageConfig) (Backend, error) {
+	switch cfg.Backend {
+	case "", "local":
+		return NewLocalBackend(cfg.LocalDir)
+	case "s3":
+		return NewS3Backend(cfg.S3)
+	default:
+		return nil, fmt.Errorf("unsupported storage backend: %s", cfg.Backend)
+	}
+}
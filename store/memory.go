@@ -0,0 +1,269 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/types"
+)
+
+// Memory returns in-memory stores holding the given users and messages, for
+// unit tests. They return the same errors as the SQL stores.
+func Memory(users []*models.User, messages []*models.Message) Stores {
+	return Stores{
+		Users:      NewMemoryUsers(users...),
+		Messages:   NewMemoryMessages(messages...),
+		LegalHolds: NewMemoryLegalHolds(),
+		Contacts:   NewMemoryContacts(),
+	}
+}
+
+// MemoryUsers is a UserStore kept in memory
+type MemoryUsers struct {
+	mu    sync.Mutex
+	users []*models.User
+}
+
+// NewMemoryUsers creates a MemoryUsers holding copies of users
+func NewMemoryUsers(users ...*models.User) *MemoryUsers {
+	store := &MemoryUsers{}
+	for _, user := range users {
+		store.Add(user)
+	}
+	return store
+}
+
+// Add stores a copy of a user
+func (s *MemoryUsers) Add(user *models.User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *user
+	s.users = append(s.users, &copied)
+}
+
+// find returns a copy of the first user matching, or ErrUserNotFound
+func (s *MemoryUsers) find(match func(*models.User) bool) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, user := range s.users {
+		if match(user) {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, models.ErrUserNotFound
+}
+
+func (s *MemoryUsers) GetByID(ctx context.Context, id int) (*models.User, error) {
+	return s.find(func(user *models.User) bool { return user.ID == id })
+}
+
+func (s *MemoryUsers) GetByAddress(ctx context.Context, address string) (*models.User, error) {
+	return s.find(func(user *models.User) bool { return user.Address == address })
+}
+
+func (s *MemoryUsers) GetByPhone(ctx context.Context, phone string) (*models.User, error) {
+	return s.find(func(user *models.User) bool { return user.Phone == phone })
+}
+
+func (s *MemoryUsers) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	return s.find(func(user *models.User) bool { return user.Username != "" && user.Username == username })
+}
+
+func (s *MemoryUsers) Search(ctx context.Context, query string) ([]*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	users := []*models.User{}
+	for _, user := range s.users {
+		if len(users) == 20 {
+			break
+		}
+		if strings.Contains(user.Username, query) || strings.Contains(user.Phone, query) || strings.Contains(user.Address, query) {
+			copied := *user
+			users = append(users, &copied)
+		}
+	}
+	return users, nil
+}
+
+func (s *MemoryUsers) SetUsername(ctx context.Context, userID int, username string) error {
+	if !models.IsValidUsername(username) {
+		return models.ErrInvalidUsername
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var target *models.User
+	for _, user := range s.users {
+		if user.ID == userID {
+			target = user
+		} else if user.Username == username {
+			return models.ErrUsernameAlreadyExists
+		}
+	}
+	// Like the UPDATE it stands in for, setting the username of a missing
+	// user does nothing
+	if target != nil {
+		target.Username = username
+	}
+	return nil
+}
+
+// MemoryMessages is a MessageStore kept in memory
+type MemoryMessages struct {
+	mu       sync.Mutex
+	messages map[string]*models.Message
+	edits    map[string][]*models.MessageEdit
+	editID   int
+}
+
+// NewMemoryMessages creates a MemoryMessages holding copies of messages
+func NewMemoryMessages(messages ...*models.Message) *MemoryMessages {
+	store := &MemoryMessages{
+		messages: make(map[string]*models.Message),
+		edits:    make(map[string][]*models.MessageEdit),
+	}
+	for _, message := range messages {
+		store.Add(message)
+	}
+	return store
+}
+
+// Add stores a copy of a message
+func (s *MemoryMessages) Add(message *models.Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *message
+	s.messages[message.ID] = &copied
+}
+
+func (s *MemoryMessages) Get(ctx context.Context, id string) (*models.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	message, ok := s.messages[id]
+	if !ok {
+		return nil, models.ErrMessageNotFound
+	}
+	copied := *message
+	return &copied, nil
+}
+
+func (s *MemoryMessages) Edit(ctx context.Context, id string, encryptedContent []byte) (*time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	message, ok := s.messages[id]
+	if !ok {
+		return nil, models.ErrMessageNotFound
+	}
+
+	editedAt := clock.Now()
+	s.editID++
+	s.edits[id] = append(s.edits[id], &models.MessageEdit{
+		ID:               s.editID,
+		MessageID:        id,
+		EncryptedContent: message.EncryptedContent,
+		EditedAt:         types.NewTime(editedAt),
+	})
+	message.EncryptedContent = encryptedContent
+	edited := types.NewTime(editedAt)
+	message.EditedAt = &edited
+	return &editedAt, nil
+}
+
+func (s *MemoryMessages) Edits(ctx context.Context, messageID string) ([]*models.MessageEdit, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	edits := []*models.MessageEdit{}
+	for _, edit := range s.edits[messageID] {
+		copied := *edit
+		edits = append(edits, &copied)
+	}
+	return edits, nil
+}
+
+func (s *MemoryMessages) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.messages, id)
+	return nil
+}
+
+// MemoryLegalHolds is a LegalHoldStore kept in memory
+type MemoryLegalHolds struct {
+	mu    sync.Mutex
+	holds map[string]bool
+}
+
+// NewMemoryLegalHolds creates a MemoryLegalHolds with the given accounts
+// under legal hold
+func NewMemoryLegalHolds(addresses ...string) *MemoryLegalHolds {
+	store := &MemoryLegalHolds{holds: make(map[string]bool)}
+	for _, address := range addresses {
+		store.holds[address] = true
+	}
+	return store
+}
+
+func (s *MemoryLegalHolds) Check(ctx context.Context, addresses ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, address := range addresses {
+		if s.holds[address] {
+			return models.ErrUnderLegalHold
+		}
+	}
+	return nil
+}
+
+// MemoryContacts is a ContactStore kept in memory
+type MemoryContacts struct {
+	mu    sync.Mutex
+	names map[string]map[string]*models.ContactName
+}
+
+// NewMemoryContacts creates an empty MemoryContacts
+func NewMemoryContacts() *MemoryContacts {
+	return &MemoryContacts{names: make(map[string]map[string]*models.ContactName)}
+}
+
+// SetName sets what an owner calls one of their contacts
+func (s *MemoryContacts) SetName(ownerAddress, contactAddress, alias string, labels ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.names[ownerAddress] == nil {
+		s.names[ownerAddress] = make(map[string]*models.ContactName)
+	}
+	sorted := append([]string(nil), labels...)
+	sort.Strings(sorted)
+	s.names[ownerAddress][contactAddress] = &models.ContactName{Alias: alias, Labels: sorted}
+}
+
+func (s *MemoryContacts) Names(ctx context.Context, ownerAddress string, addresses []string) (map[string]*models.ContactName, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := map[string]*models.ContactName{}
+	for _, address := range addresses {
+		if name, ok := s.names[ownerAddress][address]; ok && (name.Alias != "" || len(name.Labels) > 0) {
+			copied := *name
+			names[address] = &copied
+		}
+	}
+	return names, nil
+}
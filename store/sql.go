@@ -0,0 +1,78 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/piko/piko/models"
+)
+
+// SQL returns the stores backed by the models package and MySQL
+func SQL() Stores {
+	return Stores{
+		Users:      sqlUsers{},
+		Messages:   sqlMessages{},
+		LegalHolds: sqlLegalHolds{},
+		Contacts:   sqlContacts{},
+	}
+}
+
+// sqlUsers is the UserStore backed by the users table
+type sqlUsers struct{}
+
+func (sqlUsers) GetByID(ctx context.Context, id int) (*models.User, error) {
+	return models.GetUserByID(ctx, id)
+}
+
+func (sqlUsers) GetByAddress(ctx context.Context, address string) (*models.User, error) {
+	return models.GetUserByAddress(ctx, address)
+}
+
+func (sqlUsers) GetByPhone(ctx context.Context, phone string) (*models.User, error) {
+	return models.GetUserByPhone(ctx, phone)
+}
+
+func (sqlUsers) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	return models.GetUserByUsername(ctx, username)
+}
+
+func (sqlUsers) Search(ctx context.Context, query string) ([]*models.User, error) {
+	return models.SearchUsers(ctx, query)
+}
+
+func (sqlUsers) SetUsername(ctx context.Context, userID int, username string) error {
+	return models.SetUsername(ctx, userID, username)
+}
+
+// sqlMessages is the MessageStore backed by the messages table
+type sqlMessages struct{}
+
+func (sqlMessages) Get(ctx context.Context, id string) (*models.Message, error) {
+	return models.GetMessageByID(ctx, id)
+}
+
+func (sqlMessages) Edit(ctx context.Context, id string, encryptedContent []byte) (*time.Time, error) {
+	return models.EditMessage(ctx, id, encryptedContent)
+}
+
+func (sqlMessages) Edits(ctx context.Context, messageID string) ([]*models.MessageEdit, error) {
+	return models.GetMessageEdits(ctx, messageID)
+}
+
+func (sqlMessages) Delete(ctx context.Context, id string) error {
+	return models.DeleteMessage(ctx, id)
+}
+
+// sqlLegalHolds is the LegalHoldStore backed by the legal_holds table
+type sqlLegalHolds struct{}
+
+func (sqlLegalHolds) Check(ctx context.Context, addresses ...string) error {
+	return models.CheckLegalHold(ctx, addresses...)
+}
+
+// sqlContacts is the ContactStore backed by the contacts table
+type sqlContacts struct{}
+
+func (sqlContacts) Names(ctx context.Context, ownerAddress string, addresses []string) (map[string]*models.ContactName, error) {
+	return models.GetContactNames(ctx, ownerAddress, addresses)
+}
@@ -0,0 +1,61 @@
+// Package store puts interfaces in front of the models handlers read and
+// write, so handlers can run against in-memory fakes in unit tests instead
+// of MySQL. SQL returns the stores backed by the models package.
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/piko/piko/models"
+)
+
+// UserStore finds users and changes their usernames
+type UserStore interface {
+	// GetByID, GetByAddress, GetByPhone and GetByUsername return
+	// models.ErrUserNotFound when no user matches
+	GetByID(ctx context.Context, id int) (*models.User, error)
+	GetByAddress(ctx context.Context, address string) (*models.User, error)
+	GetByPhone(ctx context.Context, phone string) (*models.User, error)
+	GetByUsername(ctx context.Context, username string) (*models.User, error)
+	// Search finds up to 20 users whose username, phone or address
+	// contains query
+	Search(ctx context.Context, query string) ([]*models.User, error)
+	// SetUsername returns models.ErrInvalidUsername or
+	// models.ErrUsernameAlreadyExists when the username can't be used
+	SetUsername(ctx context.Context, userID int, username string) error
+}
+
+// MessageStore reads, edits and deletes direct messages
+type MessageStore interface {
+	// Get returns models.ErrMessageNotFound when no message has the ID
+	Get(ctx context.Context, id string) (*models.Message, error)
+	// Edit replaces a message's content, keeping the previous version in
+	// its edit history, and returns when it was edited
+	Edit(ctx context.Context, id string, encryptedContent []byte) (*time.Time, error)
+	// Edits returns a message's previous versions, oldest first
+	Edits(ctx context.Context, messageID string) ([]*models.MessageEdit, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// LegalHoldStore tells which accounts are under legal hold
+type LegalHoldStore interface {
+	// Check returns models.ErrUnderLegalHold if any of the accounts is
+	// under legal hold
+	Check(ctx context.Context, addresses ...string) error
+}
+
+// ContactStore reads what users call their contacts
+type ContactStore interface {
+	// Names maps the given addresses to the alias and labels their owner
+	// gave them, leaving out addresses that aren't named contacts
+	Names(ctx context.Context, ownerAddress string, addresses []string) (map[string]*models.ContactName, error)
+}
+
+// Stores are the stores handlers use
+type Stores struct {
+	Users      UserStore
+	Messages   MessageStore
+	LegalHolds LegalHoldStore
+	Contacts   ContactStore
+}
@@ -0,0 +1,105 @@
+// Package types holds value types shared by the models, handlers and
+// WebSocket payloads.
+package types
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"time"
+)
+
+// TimeLayout is the layout of every timestamp the API sends and accepts
+const TimeLayout = time.RFC3339Nano
+
+// Time is a timestamp that is stored and serialized in UTC. It marshals to
+// JSON as an RFC 3339 string with nanoseconds, whatever location the
+// wrapped time is in.
+type Time struct {
+	time.Time
+}
+
+// NewTime wraps t
+func NewTime(t time.Time) Time {
+	return Time{t}
+}
+
+// NewTimePtr wraps a nullable time, keeping nil as nil
+func NewTimePtr(t *time.Time) *Time {
+	if t == nil {
+		return nil
+	}
+	return &Time{*t}
+}
+
+// FormatTime formats t the way Time marshals it, for payloads built as maps
+func FormatTime(t time.Time) string {
+	return t.UTC().Format(TimeLayout)
+}
+
+// String formats the time the way it's serialized
+func (t Time) String() string {
+	return FormatTime(t.Time)
+}
+
+// MarshalJSON encodes the time as an RFC 3339 string in UTC
+func (t Time) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + FormatTime(t.Time) + `"`), nil
+}
+
+// UnmarshalJSON decodes an RFC 3339 string in any offset, converting it to
+// UTC
+func (t *Time) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	if len(data) < 2 || data[0] != '"' || data[len(data)-1] != '"' {
+		return fmt.Errorf("types: timestamp must be a string, got %s", data)
+	}
+	parsed, err := time.Parse(TimeLayout, string(data[1:len(data)-1]))
+	if err != nil {
+		return err
+	}
+	t.Time = parsed.UTC()
+	return nil
+}
+
+// Value stores the time in UTC
+func (t Time) Value() (driver.Value, error) {
+	return t.Time.UTC(), nil
+}
+
+// Scan reads a time column as UTC. Drivers that hand back text get it
+// parsed with the layouts MySQL and SQLite use.
+func (t *Time) Scan(src any) error {
+	switch v := src.(type) {
+	case time.Time:
+		t.Time = v.UTC()
+		return nil
+	case []byte:
+		return t.parse(string(v))
+	case string:
+		return t.parse(v)
+	case nil:
+		t.Time = time.Time{}
+		return nil
+	}
+	return fmt.Errorf("types: can't scan %T into a timestamp", src)
+}
+
+// columnLayouts are the text layouts of time columns
+var columnLayouts = []string{
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	TimeLayout,
+	"2006-01-02",
+}
+
+func (t *Time) parse(value string) error {
+	for _, layout := range columnLayouts {
+		if parsed, err := time.ParseInLocation(layout, value, time.UTC); err == nil {
+			t.Time = parsed.UTC()
+			return nil
+		}
+	}
+	return fmt.Errorf("types: can't parse timestamp %q", value)
+}
@@ -1,23 +1,19 @@
 package utils
 
 import (
-	"crypto/rand"
+	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
 	"fmt"
-	"io"
-	"time"
+
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/crypto"
 )
 
 // GenerateRandomBytes generates a random byte slice of the specified length
 func GenerateRandomBytes(length int) ([]byte, error) {
-	bytes := make([]byte, length)
-	_, err := io.ReadFull(rand.Reader, bytes)
-	if err != nil {
-		return nil, err
-	}
-	return bytes, nil
+	return crypto.GenerateRandomBytes(length)
 }
 
 // GenerateRandomString generates a random string of the specified length
@@ -43,8 +39,12 @@ func HashSHA256String(data string) string {
 
 // GenerateUniqueID generates a unique ID based on timestamp and random data
 func GenerateUniqueID() string {
+	if id, ok := sortableID(); ok {
+		return id
+	}
+
 	// Combine current timestamp with random data
-	timestamp := time.Now().UnixNano()
+	timestamp := clock.Now().UnixNano()
 	random, _ := GenerateRandomBytes(8) // Ignore error for simplicity
 	
 	// Create a hash of the combined data
@@ -54,8 +54,12 @@ func GenerateUniqueID() string {
 
 // GenerateMessageID generates a unique ID for a message
 func GenerateMessageID(senderAddress, recipientAddress string) string {
+	if id, ok := sortableID(); ok {
+		return id
+	}
+
 	// Combine sender, recipient, and timestamp with random data
-	timestamp := time.Now().UnixNano()
+	timestamp := clock.Now().UnixNano()
 	random, _ := GenerateRandomBytes(4) // Ignore error for simplicity
 	
 	// Create a hash of the combined data
@@ -65,11 +69,31 @@ func GenerateMessageID(senderAddress, recipientAddress string) string {
 
 // GenerateChannelID generates a unique ID for a channel
 func GenerateChannelID(adminAddress, name string) string {
+	if id, ok := sortableID(); ok {
+		return id
+	}
+
 	// Combine admin address, channel name, and timestamp with random data
-	timestamp := time.Now().UnixNano()
+	timestamp := clock.Now().UnixNano()
 	random, _ := GenerateRandomBytes(4) // Ignore error for simplicity
 	
 	// Create a hash of the combined data
 	data := fmt.Sprintf("%s%s%d%x", adminAddress, name, timestamp, random)
 	return HashSHA256String(data)
-} 
\ No newline at end of file
+}
+
+// SignURL returns a signature authorizing access to path until expires
+func SignURL(secret, path string, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fmt.Sprintf("%s:%d", path, expires)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyURLSignature checks a signature produced by SignURL and that it hasn't expired
+func VerifyURLSignature(secret, path string, expires int64, signature string) bool {
+	if clock.Now().Unix() > expires {
+		return false
+	}
+	expected := SignURL(secret, path, expires)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
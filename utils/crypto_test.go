@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/piko/piko/clock"
+)
+
+func TestVerifyURLSignatureExpires(t *testing.T) {
+	manual := clock.NewManual(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	clock.Set(manual)
+	t.Cleanup(func() { clock.Set(clock.System) })
+
+	const secret, path = "secret", "/api/media/m1/download"
+	expires := clock.Now().Add(time.Hour).Unix()
+	signature := SignURL(secret, path, expires)
+
+	manual.Advance(time.Hour)
+	if !VerifyURLSignature(secret, path, expires, signature) {
+		t.Error("link refused at its expiry, want accepted")
+	}
+
+	manual.Advance(time.Second)
+	if VerifyURLSignature(secret, path, expires, signature) {
+		t.Error("link accepted after its expiry, want refused")
+	}
+}
@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/piko/piko/config"
+	"github.com/piko/piko/metrics"
+)
+
+var (
+	// ErrEmailFailed is returned when sending an email fails
+	ErrEmailFailed = errors.New("failed to send email")
+)
+
+// EmailConfig represents email service configuration
+type EmailConfig struct {
+	Provider    string
+	IsEnabled   bool
+	Host        string
+	Port        int
+	Username    string
+	Password    string
+	From        string
+	OTPSubject  string
+	OTPTemplate string
+}
+
+// FromConfigEmail converts config.MailerConfig to utils.EmailConfig
+func FromConfigEmail(cfg *config.MailerConfig) *EmailConfig {
+	return &EmailConfig{
+		Provider:    cfg.Provider,
+		IsEnabled:   cfg.IsEnabled,
+		Host:        cfg.Host,
+		Port:        cfg.Port,
+		Username:    cfg.Username,
+		Password:    cfg.Password,
+		From:        cfg.From,
+		OTPSubject:  cfg.OTPSubject,
+		OTPTemplate: cfg.OTPTemplate,
+	}
+}
+
+// EmailProvider sends plain text emails through one mail service
+type EmailProvider interface {
+	Send(to, subject, body string) error
+}
+
+// EmailProviderFactory creates a provider from the email configuration
+type EmailProviderFactory func(config *EmailConfig) (EmailProvider, error)
+
+var (
+	emailProvidersMu sync.RWMutex
+	emailProviders   = map[string]EmailProviderFactory{}
+)
+
+// RegisterEmailProvider makes a provider available to select as
+// mailer.provider in config.json. It panics when the name is already taken,
+// so call it from an init function.
+func RegisterEmailProvider(name string, factory EmailProviderFactory) {
+	emailProvidersMu.Lock()
+	defer emailProvidersMu.Unlock()
+	if _, ok := emailProviders[name]; ok {
+		panic(fmt.Sprintf("email: provider %s registered twice", name))
+	}
+	emailProviders[name] = factory
+}
+
+// EmailProviders returns the names of the registered providers, sorted
+func EmailProviders() []string {
+	emailProvidersMu.RLock()
+	defer emailProvidersMu.RUnlock()
+	names := make([]string, 0, len(emailProviders))
+	for name := range emailProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewEmailProvider creates the provider selected in the configuration
+func NewEmailProvider(config *EmailConfig) (EmailProvider, error) {
+	emailProvidersMu.RLock()
+	factory, ok := emailProviders[config.Provider]
+	emailProvidersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unsupported email provider: %s", config.Provider)
+	}
+	return factory(config)
+}
+
+// SendEmail sends a plain text email to the specified address
+func SendEmail(config *EmailConfig, to, subject, body string) error {
+	// Addresses and subjects end up in headers, so they can't span lines
+	if strings.ContainsAny(to, "\r\n") || strings.ContainsAny(subject, "\r\n") {
+		return ErrEmailFailed
+	}
+
+	// If email is disabled, just log the message and return success
+	if !config.IsEnabled || config.Provider == "mock" {
+		log.Printf("[MOCK EMAIL] To: %s, Subject: %s, Body: %s", to, subject, body)
+		return nil
+	}
+
+	provider, err := NewEmailProvider(config)
+	if err != nil {
+		return err
+	}
+	return provider.Send(to, subject, body)
+}
+
+// emailSends counts OTP deliveries handed to the email provider
+var emailSends = metrics.NewCounterVec(
+	"piko_email_sends_total",
+	"OTP email send attempts, by provider and result.",
+	"provider", "result",
+)
+
+// SendOTPEmail sends an OTP code to the specified email address
+func SendOTPEmail(config *EmailConfig, to, code string) error {
+	provider := config.Provider
+	if !config.IsEnabled {
+		provider = "mock"
+	}
+
+	subject := config.OTPSubject
+	if subject == "" {
+		subject = "Your PIKO verification code"
+	}
+	err := SendEmail(config, to, subject, otpMessage(config.OTPTemplate, code))
+	if err != nil {
+		emailSends.Inc(provider, "failure")
+	} else {
+		emailSends.Inc(provider, "success")
+	}
+	return err
+}
@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+func init() {
+	RegisterEmailProvider("smtp", newSMTPProvider)
+}
+
+// smtpProvider sends email through an SMTP server. Port 465 uses implicit
+// TLS; other ports upgrade with STARTTLS when the server offers it.
+type smtpProvider struct {
+	config *EmailConfig
+	from   *mail.Address
+}
+
+// newSMTPProvider creates the SMTP provider
+func newSMTPProvider(config *EmailConfig) (EmailProvider, error) {
+	if config.Host == "" || config.From == "" {
+		return nil, errors.New("smtp: host and from are required")
+	}
+	from, err := mail.ParseAddress(config.From)
+	if err != nil {
+		return nil, fmt.Errorf("smtp: invalid from address: %w", err)
+	}
+	return &smtpProvider{config: config, from: from}, nil
+}
+
+// Send sends a plain text email
+func (p *smtpProvider) Send(to, subject, body string) error {
+	recipient, err := mail.ParseAddress(to)
+	if err != nil {
+		return ErrEmailFailed
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", p.from.String())
+	fmt.Fprintf(&msg, "To: %s\r\n", recipient.String())
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	msg.WriteString(body)
+	msg.WriteString("\r\n")
+
+	port := p.config.Port
+	if port == 0 {
+		port = 587
+	}
+	addr := net.JoinHostPort(p.config.Host, strconv.Itoa(port))
+
+	var auth smtp.Auth
+	if p.config.Username != "" {
+		auth = smtp.PlainAuth("", p.config.Username, p.config.Password, p.config.Host)
+	}
+
+	if port == 465 {
+		err = p.sendImplicitTLS(addr, auth, recipient.Address, msg.Bytes())
+	} else {
+		err = smtp.SendMail(addr, auth, p.from.Address, []string{recipient.Address}, msg.Bytes())
+	}
+	if err != nil {
+		log.Printf("SMTP error: %v", err)
+		return ErrEmailFailed
+	}
+	return nil
+}
+
+// sendImplicitTLS sends a message over a connection that is TLS from the
+// start, which smtp.SendMail doesn't support
+func (p *smtpProvider) sendImplicitTLS(addr string, auth smtp.Auth, to string, msg []byte) error {
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	conn, err := tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: p.config.Host})
+	if err != nil {
+		return err
+	}
+	client, err := smtp.NewClient(conn, p.config.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(p.from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
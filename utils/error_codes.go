@@ -0,0 +1,181 @@
+package utils
+
+import "github.com/gofiber/fiber/v2"
+
+// Error codes tell clients what went wrong, in the "code" field of error
+// responses. Messages are translated and may be reworded, so clients
+// should check the code instead.
+const (
+	// Requests
+	CodeBadRequest         = "BAD_REQUEST"
+	CodeInvalidRequestBody = "INVALID_REQUEST_BODY"
+	CodeValidationFailed   = "VALIDATION_FAILED"
+	CodeUnauthorized       = "UNAUTHORIZED"
+	CodeForbidden          = "FORBIDDEN"
+	CodeNotFound           = "NOT_FOUND"
+	CodeMethodNotAllowed   = "METHOD_NOT_ALLOWED"
+	CodeRequestTooLarge    = "REQUEST_TOO_LARGE"
+	CodeRateLimited        = "RATE_LIMITED"
+	CodeIPBlocked          = "IP_BLOCKED"
+	CodeInternal           = "INTERNAL_ERROR"
+	CodeServiceUnavailable = "SERVICE_UNAVAILABLE"
+	CodeShuttingDown       = "SHUTTING_DOWN"
+
+	// Accounts and sign-in
+	CodeOTPNotFound              = "OTP_NOT_FOUND"
+	CodeOTPExpired               = "OTP_EXPIRED"
+	CodeInvalidOTP               = "INVALID_OTP"
+	CodeOTPAttemptsExceeded      = "OTP_ATTEMPTS_EXCEEDED"
+	CodeOTPResendTooSoon         = "OTP_RESEND_TOO_SOON"
+	CodeOTPResendLimit           = "OTP_RESEND_LIMIT"
+	CodeEmailNotSet              = "EMAIL_NOT_SET"
+	CodeInvalidVerificationCode  = "INVALID_VERIFICATION_CODE"
+	CodeInvalidRecoveryCode      = "INVALID_RECOVERY_CODE"
+	CodeChallengeInvalid         = "CHALLENGE_INVALID"
+	CodeInvalidProofOfWork       = "INVALID_PROOF_OF_WORK"
+	CodeCaptchaFailed            = "CAPTCHA_FAILED"
+	CodeInvalidSignature         = "INVALID_SIGNATURE"
+	CodeUnknownPublicKey         = "UNKNOWN_PUBLIC_KEY"
+	CodeInvalidSession           = "INVALID_SESSION"
+	CodeSessionRevoked           = "SESSION_REVOKED"
+	CodeSessionNotFound          = "SESSION_NOT_FOUND"
+	CodeCurrentSession           = "CURRENT_SESSION"
+	CodeReauthenticationRequired = "REAUTHENTICATION_REQUIRED"
+	CodePINRequired              = "PIN_REQUIRED"
+	CodePINNotSet                = "PIN_NOT_SET"
+	CodeInvalidPIN               = "INVALID_PIN"
+	CodePINLocked                = "PIN_LOCKED"
+	CodePhoneTaken               = "PHONE_TAKEN"
+	CodeUsernameTaken            = "USERNAME_TAKEN"
+	CodeAddressTaken             = "ADDRESS_TAKEN"
+	CodeUserNotFound             = "USER_NOT_FOUND"
+	CodeDeviceNotFound           = "DEVICE_NOT_FOUND"
+	CodeAccountFrozen            = "ACCOUNT_FROZEN"
+	CodeAccountSuspended         = "ACCOUNT_SUSPENDED"
+	CodeSuspensionNotFound       = "SUSPENSION_NOT_FOUND"
+	CodeAgeRequirementNotMet     = "AGE_REQUIREMENT_NOT_MET"
+	CodeRestrictedMode           = "RESTRICTED_MODE"
+	CodeAdminRequired            = "ADMIN_REQUIRED"
+	CodePolicyAcceptanceRequired = "POLICY_ACCEPTANCE_REQUIRED"
+	CodePolicyOutdated           = "POLICY_OUTDATED"
+	CodePolicyVersionExists      = "POLICY_VERSION_EXISTS"
+	CodeLegalHold                = "LEGAL_HOLD"
+	CodeLegalHoldNotFound        = "LEGAL_HOLD_NOT_FOUND"
+
+	// Messages, keys and the blockchain
+	CodeMessageNotFound       = "MESSAGE_NOT_FOUND"
+	CodeMessageNotInBlock     = "MESSAGE_NOT_IN_BLOCK"
+	CodeNotMessageSender      = "NOT_MESSAGE_SENDER"
+	CodeEditWindowExpired     = "EDIT_WINDOW_EXPIRED"
+	CodeMessagingNotAllowed   = "MESSAGING_NOT_ALLOWED"
+	CodeForwardNotAllowed     = "FORWARD_NOT_ALLOWED"
+	CodeRecipientNotFound     = "RECIPIENT_NOT_FOUND"
+	CodeContentTooLarge       = "CONTENT_TOO_LARGE"
+	CodeTooManyAttachments    = "TOO_MANY_ATTACHMENTS"
+	CodePluginRejected        = "PLUGIN_REJECTED"
+	CodeContactNotFound       = "CONTACT_NOT_FOUND"
+	CodeMuteNotFound          = "MUTE_NOT_FOUND"
+	CodeSafetyNumberMismatch  = "SAFETY_NUMBER_MISMATCH"
+	CodeKeysNotFound          = "KEYS_NOT_FOUND"
+	CodePrekeyExists          = "PREKEY_EXISTS"
+	CodeTooManyPrekeys        = "TOO_MANY_PREKEYS"
+	CodeKeyRotationNotFound   = "KEY_ROTATION_NOT_FOUND"
+	CodeSecretChatNotFound    = "SECRET_CHAT_NOT_FOUND"
+	CodeSecretChatExpired     = "SECRET_CHAT_EXPIRED"
+	CodeSecretChatFull        = "SECRET_CHAT_FULL"
+	CodeBroadcastListNotFound = "BROADCAST_LIST_NOT_FOUND"
+	CodeBroadcastNotFound     = "BROADCAST_NOT_FOUND"
+	CodeBroadcastListFull     = "BROADCAST_LIST_FULL"
+	CodeBroadcastListEmpty    = "BROADCAST_LIST_EMPTY"
+	CodeDocumentNotFound      = "DOCUMENT_NOT_FOUND"
+	CodeBlockNotFound         = "BLOCK_NOT_FOUND"
+	CodeTransactionNotFound   = "TRANSACTION_NOT_FOUND"
+
+	// Groups and channels
+	CodeGroupNotFound             = "GROUP_NOT_FOUND"
+	CodeGroupFull                 = "GROUP_FULL"
+	CodeNotGroupMember            = "NOT_GROUP_MEMBER"
+	CodeNotGroupAdmin             = "NOT_GROUP_ADMIN"
+	CodeNotGroupOwner             = "NOT_GROUP_OWNER"
+	CodeAlreadyGroupMember        = "ALREADY_GROUP_MEMBER"
+	CodeMemberNotFound            = "MEMBER_NOT_FOUND"
+	CodeOwnershipTransferRequired = "OWNERSHIP_TRANSFER_REQUIRED"
+	CodeLastAdmin                 = "LAST_ADMIN"
+	CodeGuestPassNotFound         = "GUEST_PASS_NOT_FOUND"
+	CodeGuestPassReadOnly         = "GUEST_PASS_READ_ONLY"
+	CodeInviteInvalid             = "INVITE_INVALID"
+	CodeInviteNotFound            = "INVITE_NOT_FOUND"
+	CodeJoinRequestNotFound       = "JOIN_REQUEST_NOT_FOUND"
+	CodeJoinRequestPending        = "JOIN_REQUEST_PENDING"
+	CodeJoinRequestsDisabled      = "JOIN_REQUESTS_DISABLED"
+	CodeEventNotFound             = "EVENT_NOT_FOUND"
+	CodeTopicNotFound             = "TOPIC_NOT_FOUND"
+	CodeTopicClosed               = "TOPIC_CLOSED"
+	CodeTopicsDisabled            = "TOPICS_DISABLED"
+	CodeChannelNotFound           = "CHANNEL_NOT_FOUND"
+	CodeChannelExists             = "CHANNEL_EXISTS"
+	CodeChannelFull               = "CHANNEL_FULL"
+	CodeNotChannelMember          = "NOT_CHANNEL_MEMBER"
+	CodeNotChannelAdmin           = "NOT_CHANNEL_ADMIN"
+	CodeNotChannelOwner           = "NOT_CHANNEL_OWNER"
+	CodeAlreadyChannelMember      = "ALREADY_CHANNEL_MEMBER"
+	CodeOwnerProtected            = "OWNER_PROTECTED"
+	CodeChannelNotPublic          = "CHANNEL_NOT_PUBLIC"
+	CodeSlugTaken                 = "SLUG_TAKEN"
+	CodeChannelNotFlagged         = "CHANNEL_NOT_FLAGGED"
+	CodeChannelNotThrottled       = "CHANNEL_NOT_THROTTLED"
+	CodeAlreadyAppealed           = "ALREADY_APPEALED"
+	CodePollNotFound              = "POLL_NOT_FOUND"
+	CodePollExists                = "POLL_EXISTS"
+	CodePollClosed                = "POLL_CLOSED"
+	CodeAlreadyVoted              = "ALREADY_VOTED"
+	CodePollVoteNotFound          = "POLL_VOTE_NOT_FOUND"
+
+	// Media
+	CodeMediaNotFound       = "MEDIA_NOT_FOUND"
+	CodeUploadNotFound      = "UPLOAD_NOT_FOUND"
+	CodeFileTooLarge        = "FILE_TOO_LARGE"
+	CodeFileTypeNotAllowed  = "FILE_TYPE_NOT_ALLOWED"
+	CodeChunkOffsetMismatch = "CHUNK_OFFSET_MISMATCH"
+	CodeQuotaExceeded       = "QUOTA_EXCEEDED"
+	CodeDownloadLinkInvalid = "DOWNLOAD_LINK_INVALID"
+	CodeInvalidPhotoURL     = "INVALID_PHOTO_URL"
+	CodePhotoURLUnreachable = "PHOTO_URL_UNREACHABLE"
+	CodeAvatarNotFound      = "AVATAR_NOT_FOUND"
+
+	// Stickers
+	CodeStickerPackNotFound = "STICKER_PACK_NOT_FOUND"
+	CodeStickerNotFound     = "STICKER_NOT_FOUND"
+	CodeStickerPackFull     = "STICKER_PACK_FULL"
+	CodeShortcodeTaken      = "SHORTCODE_TAKEN"
+
+	// Support and moderation
+	CodeTicketNotFound = "TICKET_NOT_FOUND"
+	CodeTicketClosed   = "TICKET_CLOSED"
+	CodeReportNotFound = "REPORT_NOT_FOUND"
+)
+
+// CodeForStatus returns the generic code of an error status, for errors
+// without a more specific one
+func CodeForStatus(statusCode int) string {
+	switch statusCode {
+	case fiber.StatusUnauthorized:
+		return CodeUnauthorized
+	case fiber.StatusForbidden:
+		return CodeForbidden
+	case fiber.StatusNotFound:
+		return CodeNotFound
+	case fiber.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case fiber.StatusRequestEntityTooLarge:
+		return CodeRequestTooLarge
+	case fiber.StatusTooManyRequests:
+		return CodeRateLimited
+	case fiber.StatusServiceUnavailable:
+		return CodeServiceUnavailable
+	}
+	if statusCode >= fiber.StatusInternalServerError {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
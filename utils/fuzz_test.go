@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/piko/piko/crypto"
+)
+
+// FuzzIsValidAddress checks that request validation accepts only addresses
+// the crypto package also considers valid
+func FuzzIsValidAddress(f *testing.F) {
+	f.Add("")
+	f.Add("1111111111111111111111111111111111111111111111")
+	f.Add("0OIl00000000000000000000000000000000000000000l")
+	f.Add("PikoPikoPikoPikoPikoPikoPikoPikoPikoPikoPikoPi\n")
+
+	f.Fuzz(func(t *testing.T, address string) {
+		if IsValidAddress(address) && !crypto.ValidateAddress(address, len(address)) {
+			t.Fatalf("%q passes request validation but isn't a valid address", address)
+		}
+	})
+}
+
+// FuzzIsValidPhone checks that accepted phone numbers are plain digits
+// with an optional leading plus
+func FuzzIsValidPhone(f *testing.F) {
+	f.Add("+989121234567")
+	f.Add("09121234567\n")
+	f.Add("+")
+	f.Add("١٢٣٤٥٦٧٨٩٠١")
+
+	f.Fuzz(func(t *testing.T, phone string) {
+		if !IsValidPhone(phone) {
+			return
+		}
+		for i, r := range phone {
+			if (r < '0' || r > '9') && !(i == 0 && r == '+') {
+				t.Fatalf("accepted %q with character %q", phone, r)
+			}
+		}
+	})
+}
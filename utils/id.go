@@ -0,0 +1,266 @@
+package utils
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/piko/piko/clock"
+	"github.com/piko/piko/config"
+	"github.com/piko/piko/crypto"
+)
+
+var (
+	// ErrClockMovedBackwards is returned by a Snowflake generator when the
+	// system clock goes back further than it is willing to wait out
+	ErrClockMovedBackwards = errors.New("clock moved backwards")
+)
+
+// IDGenerator produces unique identifiers for stored records
+type IDGenerator interface {
+	NewID() (string, error)
+}
+
+// idGenerator is the generator used by NewID
+var idGenerator IDGenerator = RandomIDGenerator{}
+
+// InitIDGenerator selects the ID generation strategy from configuration
+func InitIDGenerator(cfg config.IDConfig) error {
+	generator, err := NewIDGenerator(cfg)
+	if err != nil {
+		return err
+	}
+	idGenerator = generator
+	return nil
+}
+
+// NewIDGenerator creates the generator for a configured strategy
+func NewIDGenerator(cfg config.IDConfig) (IDGenerator, error) {
+	switch cfg.Strategy {
+	case "", "random":
+		return RandomIDGenerator{}, nil
+	case "ulid":
+		return &ULIDGenerator{}, nil
+	case "snowflake":
+		return NewSnowflakeGenerator(cfg.NodeID)
+	default:
+		return nil, fmt.Errorf("unsupported ID strategy: %s", cfg.Strategy)
+	}
+}
+
+// NewID returns a new identifier from the configured generator
+func NewID() (string, error) {
+	return idGenerator.NewID()
+}
+
+// sortableID returns a new ID when a time-sortable strategy is configured
+func sortableID() (string, bool) {
+	if _, ok := idGenerator.(RandomIDGenerator); ok {
+		return "", false
+	}
+	id, err := idGenerator.NewID()
+	if err != nil {
+		return "", false
+	}
+	return id, true
+}
+
+// IDTime returns the creation time encoded in a ULID or Snowflake ID.
+// Random IDs carry no time, so ok is false for them.
+func IDTime(id string) (t time.Time, ok bool) {
+	switch {
+	case len(id) == ulidLength:
+		return ulidTime(id)
+	case len(id) == snowflakeLength && isDigits(id):
+		value, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return time.UnixMilli(value>>snowflakeTimeShift + snowflakeEpoch), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// RandomIDGenerator produces 64-character random hex IDs. This is the
+// original ID format; IDs made by other strategies sit alongside these.
+type RandomIDGenerator struct{}
+
+// NewID returns a random 32-byte hex identifier
+func (RandomIDGenerator) NewID() (string, error) {
+	idBytes, err := GenerateRandomBytes(32)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(idBytes), nil
+}
+
+const (
+	// ulidLength is the length of an encoded ULID
+	ulidLength = 26
+
+	// crockford is the Base32 alphabet used by ULIDs
+	crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+)
+
+// ULIDGenerator produces ULIDs: a 48-bit millisecond timestamp followed by
+// 80 random bits, Crockford Base32 encoded. IDs from one generator are
+// strictly increasing, even within the same millisecond.
+type ULIDGenerator struct {
+	mu     sync.Mutex
+	lastMs uint64
+	last   [10]byte
+}
+
+// NewID returns a new ULID
+func (g *ULIDGenerator) NewID() (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := uint64(clock.Now().UnixMilli())
+	if ms <= g.lastMs {
+		// Same millisecond (or the clock stepped back): increment the
+		// previous random part so ordering is preserved
+		ms = g.lastMs
+		if !incrementBytes(g.last[:]) {
+			return "", errors.New("ulid random part overflowed")
+		}
+	} else {
+		if err := crypto.ReadRandom(g.last[:]); err != nil {
+			return "", err
+		}
+		g.lastMs = ms
+	}
+
+	var raw [16]byte
+	raw[0] = byte(ms >> 40)
+	raw[1] = byte(ms >> 32)
+	raw[2] = byte(ms >> 24)
+	raw[3] = byte(ms >> 16)
+	raw[4] = byte(ms >> 8)
+	raw[5] = byte(ms)
+	copy(raw[6:], g.last[:])
+
+	return encodeULID(raw), nil
+}
+
+// encodeULID encodes 128 bits as 26 Crockford Base32 characters
+func encodeULID(raw [16]byte) string {
+	hi := binary.BigEndian.Uint64(raw[:8])
+	lo := binary.BigEndian.Uint64(raw[8:])
+
+	out := make([]byte, ulidLength)
+	for i := ulidLength - 1; i >= 0; i-- {
+		out[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out)
+}
+
+// ulidTime decodes the timestamp of a ULID
+func ulidTime(id string) (time.Time, bool) {
+	var ms uint64
+	for i := 0; i < 10; i++ {
+		index := strings.IndexByte(crockford, id[i])
+		if index < 0 {
+			return time.Time{}, false
+		}
+		ms = ms<<5 | uint64(index)
+	}
+	return time.UnixMilli(int64(ms)), true
+}
+
+// incrementBytes adds one to a big-endian number, reporting false on overflow
+func incrementBytes(b []byte) bool {
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i]++
+		if b[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	// snowflakeEpoch is the custom epoch (2024-01-01 UTC) in milliseconds
+	snowflakeEpoch = 1704067200000
+
+	// snowflakeLength is the zero-padded width of a Snowflake ID, so IDs
+	// sort correctly as strings
+	snowflakeLength = 19
+
+	snowflakeNodeBits     = 10
+	snowflakeSequenceBits = 12
+	snowflakeTimeShift    = snowflakeNodeBits + snowflakeSequenceBits
+	snowflakeMaxNode      = 1<<snowflakeNodeBits - 1
+	snowflakeMaxSequence  = 1<<snowflakeSequenceBits - 1
+
+	// snowflakeMaxClockDrift is how far back the clock may step before
+	// NewID gives up instead of waiting
+	snowflakeMaxClockDrift = 5 * time.Millisecond
+)
+
+// SnowflakeGenerator produces 64-bit Snowflake IDs: 41 bits of milliseconds
+// since 2024-01-01, a 10-bit node ID and a 12-bit sequence. Each instance
+// must be given its own node ID.
+type SnowflakeGenerator struct {
+	nodeID   int64
+	mu       sync.Mutex
+	lastMs   int64
+	sequence int64
+}
+
+// NewSnowflakeGenerator creates a Snowflake generator for a node
+func NewSnowflakeGenerator(nodeID int) (*SnowflakeGenerator, error) {
+	if nodeID < 0 || nodeID > snowflakeMaxNode {
+		return nil, fmt.Errorf("snowflake node ID must be between 0 and %d", snowflakeMaxNode)
+	}
+	return &SnowflakeGenerator{nodeID: int64(nodeID)}, nil
+}
+
+// NewID returns a new zero-padded decimal Snowflake ID
+func (g *SnowflakeGenerator) NewID() (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := clock.Now().UnixMilli() - snowflakeEpoch
+	if ms < g.lastMs {
+		if time.Duration(g.lastMs-ms)*time.Millisecond > snowflakeMaxClockDrift {
+			return "", ErrClockMovedBackwards
+		}
+		ms = g.lastMs
+	}
+
+	if ms == g.lastMs {
+		g.sequence = (g.sequence + 1) & snowflakeMaxSequence
+		if g.sequence == 0 {
+			// Sequence exhausted for this millisecond, wait for the next
+			for ms <= g.lastMs {
+				time.Sleep(100 * time.Microsecond)
+				ms = clock.Now().UnixMilli() - snowflakeEpoch
+			}
+		}
+	} else {
+		g.sequence = 0
+	}
+	g.lastMs = ms
+
+	id := ms<<snowflakeTimeShift | g.nodeID<<snowflakeSequenceBits | g.sequence
+	return fmt.Sprintf("%0*d", snowflakeLength, id), nil
+}
+
+// isDigits reports whether s consists only of ASCII digits
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
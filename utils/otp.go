@@ -2,20 +2,60 @@ package utils
 
 import (
 	"crypto/rand"
+	"fmt"
 	"math/big"
+
+	"github.com/piko/piko/crypto"
+)
+
+// OTP character sets
+const (
+	// OTPNumeric codes are digits only
+	OTPNumeric = "numeric"
+	// OTPAlphanumeric codes are digits and upper case letters, leaving out
+	// 0, 1, I and O, which are easily mistaken for each other
+	OTPAlphanumeric = "alphanumeric"
 )
 
-// GenerateOTP generates a random OTP code of the specified length
-func GenerateOTP(length int) (string, error) {
-	const digits = "0123456789"
+// OTP code length bounds
+const (
+	MinOTPLength = 4
+	MaxOTPLength = 16
+)
+
+// otpAlphabets are the characters codes of each character set are made of
+var otpAlphabets = map[string]string{
+	OTPNumeric:      "0123456789",
+	OTPAlphanumeric: "23456789ABCDEFGHJKLMNPQRSTUVWXYZ",
+}
+
+// ValidateOTPFormat checks that codes of the given length and character
+// set can be generated
+func ValidateOTPFormat(length int, charset string) error {
+	if length < MinOTPLength || length > MaxOTPLength {
+		return fmt.Errorf("OTP length must be between %d and %d, got %d", MinOTPLength, MaxOTPLength, length)
+	}
+	if _, ok := otpAlphabets[charset]; !ok {
+		return fmt.Errorf("unknown OTP character set %q, want %q or %q", charset, OTPNumeric, OTPAlphanumeric)
+	}
+	return nil
+}
+
+// GenerateOTP generates a random OTP code of the specified length from the
+// given character set
+func GenerateOTP(length int, charset string) (string, error) {
+	if err := ValidateOTPFormat(length, charset); err != nil {
+		return "", err
+	}
+	alphabet := otpAlphabets[charset]
 	result := make([]byte, length)
 
 	for i := 0; i < length; i++ {
-		num, err := rand.Int(rand.Reader, big.NewInt(int64(len(digits))))
+		num, err := rand.Int(crypto.Rand(), big.NewInt(int64(len(alphabet))))
 		if err != nil {
 			return "", err
 		}
-		result[i] = digits[num.Int64()]
+		result[i] = alphabet[num.Int64()]
 	}
 
 	return string(result), nil
@@ -10,6 +10,10 @@ import (
 type PaginationParams struct {
 	Page  int
 	Limit int
+	// Before and After are the message IDs cursor paginated lists page
+	// back and forward from
+	Before string
+	After  string
 }
 
 // GetPaginationParams extracts pagination parameters from the request
@@ -37,8 +41,10 @@ func GetPaginationParams(c *fiber.Ctx) PaginationParams {
 	}
 	
 	return PaginationParams{
-		Page:  page,
-		Limit: limit,
+		Page:   page,
+		Limit:  limit,
+		Before: c.Query("before"),
+		After:  c.Query("after"),
 	}
 }
 
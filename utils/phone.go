@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// CanonicalPhone returns a phone number in the "+<digits>" form clients
+// hash for contact discovery
+func CanonicalPhone(phone string) string {
+	return "+" + strings.TrimPrefix(phone, "+")
+}
+
+// HashPhone returns the lower case hex SHA-256 of a phone number's
+// canonical form
+func HashPhone(phone string) string {
+	sum := sha256.Sum256([]byte(CanonicalPhone(phone)))
+	return hex.EncodeToString(sum[:])
+}
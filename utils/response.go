@@ -18,53 +18,52 @@ func SuccessResponse(c *fiber.Ctx, statusCode int, data interface{}) error {
 	})
 }
 
-// ErrorResponse sends a standardized error response
-func ErrorResponse(c *fiber.Ctx, statusCode int, message string) error {
-	return c.Status(statusCode).JSON(fiber.Map{
+// ErrorResponse sends a standardized error response. The code tells
+// clients what went wrong; the message is for people and is translated
+// to the request's locale.
+func ErrorResponse(c *fiber.Ctx, statusCode int, code, message string) error {
+	return ErrorResponseWith(c, statusCode, code, message, nil)
+}
+
+// ErrorResponseWith sends a standardized error response with fields that
+// describe the error, next to its message
+func ErrorResponseWith(c *fiber.Ctx, statusCode int, code, message string, fields fiber.Map) error {
+	body := fiber.Map{
 		"status": RESPONSE_ERROR,
+		"code":   code,
 		"error":  message,
-	})
+	}
+	for key, value := range fields {
+		body[key] = value
+	}
+	return c.Status(statusCode).JSON(body)
 }
 
 // ValidationErrorResponse sends a standardized validation error response
 func ValidationErrorResponse(c *fiber.Ctx, errors map[string]string) error {
-	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-		"status": RESPONSE_ERROR,
-		"error":  "Validation failed",
+	return ErrorResponseWith(c, fiber.StatusBadRequest, CodeValidationFailed, "Validation failed", fiber.Map{
 		"errors": errors,
 	})
 }
 
 // NotFoundResponse sends a standardized not found response
 func NotFoundResponse(c *fiber.Ctx, resource string) error {
-	return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-		"status": RESPONSE_ERROR,
-		"error":  resource + " not found",
-	})
+	return ErrorResponse(c, fiber.StatusNotFound, CodeNotFound, resource+" not found")
 }
 
 // UnauthorizedResponse sends a standardized unauthorized response
 func UnauthorizedResponse(c *fiber.Ctx) error {
-	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-		"status": RESPONSE_ERROR,
-		"error":  "Unauthorized access",
-	})
+	return ErrorResponse(c, fiber.StatusUnauthorized, CodeUnauthorized, "Unauthorized")
 }
 
 // ForbiddenResponse sends a standardized forbidden response
 func ForbiddenResponse(c *fiber.Ctx) error {
-	return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-		"status": RESPONSE_ERROR,
-		"error":  "Access forbidden",
-	})
+	return ErrorResponse(c, fiber.StatusForbidden, CodeForbidden, "Access denied")
 }
 
 // InternalErrorResponse sends a standardized internal server error response
 func InternalErrorResponse(c *fiber.Ctx) error {
-	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-		"status": RESPONSE_ERROR,
-		"error":  "Internal server error",
-	})
+	return ErrorResponse(c, fiber.StatusInternalServerError, CodeInternal, "Internal server error")
 }
 
 // CreatedResponse sends a standardized resource created response
@@ -4,12 +4,12 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"net/http"
-	"net/url"
+	"sort"
 	"strings"
+	"sync"
 
-	ippanel "github.com/ippanel/go-rest-sdk/v2"
 	"github.com/piko/piko/config"
+	"github.com/piko/piko/metrics"
 )
 
 var (
@@ -25,6 +25,8 @@ type SMSConfig struct {
 	BaseURL     string
 	IsEnabled   bool
 	PatternCode string
+	OTPTemplate string
+	SNS         config.SNSConfig
 }
 
 // FromConfigSMS converts config.SMSConfig to utils.SMSConfig
@@ -36,6 +38,8 @@ func FromConfigSMS(cfg *config.SMSConfig) *SMSConfig {
 		BaseURL:     cfg.BaseURL,
 		IsEnabled:   cfg.IsEnabled,
 		PatternCode: cfg.PatternCode,
+		OTPTemplate: cfg.OTPTemplate,
+		SNS:         cfg.SNS,
 	}
 }
 
@@ -49,204 +53,133 @@ func DefaultSMSConfig() *SMSConfig {
 		BaseURL:     "https://edge.ippanel.com/v1",
 		IsEnabled:   false, // Disabled by default
 		PatternCode: "9muuwhyyw2s1ag5",
+		OTPTemplate: defaultOTPTemplate,
 	}
 }
 
-// SendSMS sends an SMS message to the specified phone number
-func SendSMS(config *SMSConfig, phone, message string) error {
-	// If SMS service is disabled, just log the message and return success
-	if !config.IsEnabled || config.Provider == "mock" {
-		log.Printf("[MOCK SMS] To: %s, Message: %s", phone, message)
-		return nil
-	}
+// SMSProvider sends text messages through one SMS service
+type SMSProvider interface {
+	Send(phone, message string) error
+}
 
-	// Different providers have different APIs
-	switch config.Provider {
-	case "ippanel":
-		return sendIPPanelSMS(config, phone, message)
-	case "twilio":
-		return sendTwilioSMS(config, phone, message)
-	case "nexmo":
-		return sendNexmoSMS(config, phone, message)
-	default:
-		return fmt.Errorf("unsupported SMS provider: %s", config.Provider)
-	}
+// OTPProvider is implemented by providers that send OTP codes with a
+// template of their own, such as IPPanel patterns, instead of otpTemplate
+type OTPProvider interface {
+	SendOTP(phone, code string) error
 }
 
-// SendOTP sends an OTP code to the specified phone number
-func SendOTP(config *SMSConfig, phone, code string) error {
-	log.Printf("SendOTP called with phone=%s, code=%s, provider=%s, isEnabled=%v\n",
-		phone, code, config.Provider, config.IsEnabled)
+// SMSProviderFactory creates a provider from the SMS configuration
+type SMSProviderFactory func(config *SMSConfig) (SMSProvider, error)
 
-	// If SMS service is disabled or provider is mock, just log the message and return success
-	if !config.IsEnabled || config.Provider == "mock" {
-		log.Printf("[MOCK SMS] To: %s, OTP Code: %s", phone, code)
-		return nil
-	}
+var (
+	smsProvidersMu sync.RWMutex
+	smsProviders   = map[string]SMSProviderFactory{}
+)
 
-	// Always use pattern SMS for OTP with IPPanel
-	if config.Provider == "ippanel" {
-		return sendIPPanelPatternSMS(config, phone, code)
+// RegisterSMSProvider makes a provider available to select as sms.provider
+// in config.json. It panics when the name is already taken, so call it from
+// an init function.
+func RegisterSMSProvider(name string, factory SMSProviderFactory) {
+	smsProvidersMu.Lock()
+	defer smsProvidersMu.Unlock()
+	if _, ok := smsProviders[name]; ok {
+		panic(fmt.Sprintf("sms: provider %s registered twice", name))
 	}
-
-	// For other providers, use regular SMS
-	message := fmt.Sprintf("Your PIKO verification code is: %s", code)
-	return SendSMS(config, phone, message)
+	smsProviders[name] = factory
 }
 
-// sendIPPanelPatternSMS sends an OTP using IPPanel's pattern SMS API with SDK
-func sendIPPanelPatternSMS(config *SMSConfig, phone, code string) error {
-	log.Printf("Sending pattern SMS via IPPanel to %s with code %s", phone, code)
-
-	// Format phone number (ensure it starts with country code)
-	formattedPhone := formatPhoneNumber(phone)
-	log.Printf("Formatted phone number: %s", formattedPhone)
-
-	// Create IPPanel client
-	smsClient := ippanel.New(config.APIKey)
-
-	// Prepare pattern values - note the spelling "verfication-code" as per the pattern
-	patternValues := map[string]string{
-		"verfication-code": code,
+// SMSProviders returns the names of the registered providers, sorted
+func SMSProviders() []string {
+	smsProvidersMu.RLock()
+	defer smsProvidersMu.RUnlock()
+	names := make([]string, 0, len(smsProviders))
+	for name := range smsProviders {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+	return names
+}
 
-	log.Printf("Using pattern code: %s, sender: %s", config.PatternCode, config.SenderID)
-
-	// Send pattern SMS
-	messageID, err := smsClient.SendPattern(
-		config.PatternCode,
-		config.SenderID,
-		formattedPhone,
-		patternValues,
-	)
-
-	if err != nil {
-		log.Printf("IPPanel SDK error: %v", err)
-		// If it's an IPPanel specific error, log more details
-		if ipErr, ok := err.(ippanel.Error); ok {
-			log.Printf("IPPanel error code: %d, message: %v", ipErr.Code, ipErr.Message)
-		}
-		return ErrSMSFailed
+// NewSMSProvider creates the provider selected in the configuration
+func NewSMSProvider(config *SMSConfig) (SMSProvider, error) {
+	smsProvidersMu.RLock()
+	factory, ok := smsProviders[config.Provider]
+	smsProvidersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unsupported SMS provider: %s", config.Provider)
 	}
-
-	log.Printf("Pattern SMS sent successfully, message ID: %v", messageID)
-	return nil
+	return factory(config)
 }
 
-// sendIPPanelSMS sends a regular SMS using IPPanel API with SDK
-func sendIPPanelSMS(config *SMSConfig, phone, message string) error {
-	log.Printf("Sending regular SMS via IPPanel to %s", phone)
-
-	// Format phone number (ensure it starts with country code)
-	formattedPhone := formatPhoneNumber(phone)
-	log.Printf("Formatted phone number: %s", formattedPhone)
-
-	// Create IPPanel client
-	smsClient := ippanel.New(config.APIKey)
-
-	// Send SMS
-	messageID, err := smsClient.Send(
-		config.SenderID,
-		[]string{formattedPhone},
-		message,
-		"", // Empty summary parameter
-	)
+// SendSMS sends an SMS message to the specified phone number
+func SendSMS(config *SMSConfig, phone, message string) error {
+	// If SMS service is disabled, just log the message and return success
+	if !config.IsEnabled || config.Provider == "mock" {
+		log.Printf("[MOCK SMS] To: %s, Message: %s", phone, message)
+		return nil
+	}
 
+	provider, err := NewSMSProvider(config)
 	if err != nil {
-		log.Printf("IPPanel SDK error: %v", err)
-		return ErrSMSFailed
+		return err
 	}
-
-	log.Printf("SMS sent successfully, message ID: %v", messageID)
-	return nil
+	return provider.Send(phone, message)
 }
 
-// formatPhoneNumber ensures the phone number is in the correct format for IPPanel
-func formatPhoneNumber(phone string) string {
-	// Remove any spaces
-	phone = strings.ReplaceAll(phone, " ", "")
-
-	// Remove any plus sign
-	phone = strings.ReplaceAll(phone, "+", "")
+// smsSends counts OTP deliveries handed to the SMS provider
+var smsSends = metrics.NewCounterVec(
+	"piko_sms_sends_total",
+	"OTP SMS send attempts, by provider and result.",
+	"provider", "result",
+)
 
-	// If the number starts with 0, remove it and add country code
-	if strings.HasPrefix(phone, "0") {
-		phone = "98" + phone[1:]
+// SendOTP sends an OTP code to the specified phone number
+func SendOTP(config *SMSConfig, phone, code string) error {
+	provider := config.Provider
+	if !config.IsEnabled {
+		provider = "mock"
 	}
 
-	// If the number doesn't start with country code, add it
-	if !strings.HasPrefix(phone, "98") {
-		phone = "98" + phone
+	err := sendOTP(config, phone, code)
+	if err != nil {
+		smsSends.Inc(provider, "failure")
+	} else {
+		smsSends.Inc(provider, "success")
 	}
-
-	return phone
+	return err
 }
 
-// sendTwilioSMS sends an SMS using Twilio
-func sendTwilioSMS(config *SMSConfig, phone, message string) error {
-	// Prepare the form data
-	formData := url.Values{}
-	formData.Set("To", phone)
-	formData.Set("From", config.SenderID)
-	formData.Set("Body", message)
+// sendOTP sends an OTP code with the configured provider
+func sendOTP(config *SMSConfig, phone, code string) error {
+	log.Printf("SendOTP called with phone=%s, code=%s, provider=%s, isEnabled=%v\n",
+		phone, code, config.Provider, config.IsEnabled)
 
-	// Create the request
-	req, err := http.NewRequest("POST", config.BaseURL+"/2010-04-01/Accounts/"+config.APIKey+"/Messages.json", strings.NewReader(formData.Encode()))
-	if err != nil {
-		return err
+	// If SMS service is disabled or provider is mock, just log the message and return success
+	if !config.IsEnabled || config.Provider == "mock" {
+		log.Printf("[MOCK SMS] To: %s, OTP Code: %s", phone, code)
+		return nil
 	}
 
-	// Set headers
-	req.SetBasicAuth(config.APIKey, config.APIKey)
-	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-
-	// Send the request
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	provider, err := NewSMSProvider(config)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
 
-	// Check the response
-	if resp.StatusCode != http.StatusCreated {
-		return ErrSMSFailed
+	// Providers with OTP templates of their own use them, the others get
+	// a regular SMS
+	if otpProvider, ok := provider.(OTPProvider); ok {
+		return otpProvider.SendOTP(phone, code)
 	}
-
-	return nil
+	return provider.Send(phone, otpMessage(config.OTPTemplate, code))
 }
 
-// sendNexmoSMS sends an SMS using Nexmo/Vonage
-func sendNexmoSMS(config *SMSConfig, phone, message string) error {
-	// Prepare the form data
-	formData := url.Values{}
-	formData.Set("to", phone)
-	formData.Set("from", config.SenderID)
-	formData.Set("text", message)
-	formData.Set("api_key", config.APIKey)
-	formData.Set("api_secret", config.APIKey)
-
-	// Create the request
-	req, err := http.NewRequest("POST", config.BaseURL+"/sms/json", strings.NewReader(formData.Encode()))
-	if err != nil {
-		return err
-	}
-
-	// Set headers
-	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-
-	// Send the request
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
+// defaultOTPTemplate is the OTP message used when none is configured
+const defaultOTPTemplate = "Your PIKO verification code is: {code}"
 
-	// Check the response
-	if resp.StatusCode != http.StatusOK {
-		return ErrSMSFailed
+// otpMessage fills in the code of an OTP message template
+func otpMessage(template, code string) string {
+	if template == "" {
+		template = defaultOTPTemplate
 	}
-
-	return nil
+	return strings.ReplaceAll(template, "{code}", code)
 }
@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"log"
+	"strings"
+
+	ippanel "github.com/ippanel/go-rest-sdk/v2"
+)
+
+func init() {
+	RegisterSMSProvider("ippanel", newIPPanelProvider)
+}
+
+// ipPanelProvider sends SMS through IPPanel, and OTPs with its pattern SMS
+type ipPanelProvider struct {
+	config *SMSConfig
+}
+
+// newIPPanelProvider creates the IPPanel provider
+func newIPPanelProvider(config *SMSConfig) (SMSProvider, error) {
+	return &ipPanelProvider{config: config}, nil
+}
+
+// SendOTP sends an OTP using IPPanel's pattern SMS API with SDK
+func (p *ipPanelProvider) SendOTP(phone, code string) error {
+	config := p.config
+	log.Printf("Sending pattern SMS via IPPanel to %s with code %s", phone, code)
+
+	// Format phone number (ensure it starts with country code)
+	formattedPhone := formatPhoneNumber(phone)
+	log.Printf("Formatted phone number: %s", formattedPhone)
+
+	// Create IPPanel client
+	smsClient := ippanel.New(config.APIKey)
+
+	// Prepare pattern values - note the spelling "verfication-code" as per the pattern
+	patternValues := map[string]string{
+		"verfication-code": code,
+	}
+
+	log.Printf("Using pattern code: %s, sender: %s", config.PatternCode, config.SenderID)
+
+	// Send pattern SMS
+	messageID, err := smsClient.SendPattern(
+		config.PatternCode,
+		config.SenderID,
+		formattedPhone,
+		patternValues,
+	)
+
+	if err != nil {
+		log.Printf("IPPanel SDK error: %v", err)
+		// If it's an IPPanel specific error, log more details
+		if ipErr, ok := err.(ippanel.Error); ok {
+			log.Printf("IPPanel error code: %d, message: %v", ipErr.Code, ipErr.Message)
+		}
+		return ErrSMSFailed
+	}
+
+	log.Printf("Pattern SMS sent successfully, message ID: %v", messageID)
+	return nil
+}
+
+// Send sends a regular SMS using IPPanel API with SDK
+func (p *ipPanelProvider) Send(phone, message string) error {
+	config := p.config
+	log.Printf("Sending regular SMS via IPPanel to %s", phone)
+
+	// Format phone number (ensure it starts with country code)
+	formattedPhone := formatPhoneNumber(phone)
+	log.Printf("Formatted phone number: %s", formattedPhone)
+
+	// Create IPPanel client
+	smsClient := ippanel.New(config.APIKey)
+
+	// Send SMS
+	messageID, err := smsClient.Send(
+		config.SenderID,
+		[]string{formattedPhone},
+		message,
+		"", // Empty summary parameter
+	)
+
+	if err != nil {
+		log.Printf("IPPanel SDK error: %v", err)
+		return ErrSMSFailed
+	}
+
+	log.Printf("SMS sent successfully, message ID: %v", messageID)
+	return nil
+}
+
+// formatPhoneNumber ensures the phone number is in the correct format for
+// IPPanel and Kavenegar
+func formatPhoneNumber(phone string) string {
+	// Remove any spaces
+	phone = strings.ReplaceAll(phone, " ", "")
+
+	// Remove any plus sign
+	phone = strings.ReplaceAll(phone, "+", "")
+
+	// If the number starts with 0, remove it and add country code
+	if strings.HasPrefix(phone, "0") {
+		phone = "98" + phone[1:]
+	}
+
+	// If the number doesn't start with country code, add it
+	if !strings.HasPrefix(phone, "98") {
+		phone = "98" + phone
+	}
+
+	return phone
+}
@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+func init() {
+	RegisterSMSProvider("nexmo", newNexmoProvider)
+}
+
+// nexmoProvider sends SMS through Nexmo/Vonage
+type nexmoProvider struct {
+	config *SMSConfig
+}
+
+// newNexmoProvider creates the Nexmo provider
+func newNexmoProvider(config *SMSConfig) (SMSProvider, error) {
+	return &nexmoProvider{config: config}, nil
+}
+
+// Send sends an SMS using Nexmo/Vonage
+func (p *nexmoProvider) Send(phone, message string) error {
+	config := p.config
+
+	// Prepare the form data
+	formData := url.Values{}
+	formData.Set("to", phone)
+	formData.Set("from", config.SenderID)
+	formData.Set("text", message)
+	formData.Set("api_key", config.APIKey)
+	formData.Set("api_secret", config.APIKey)
+
+	// Create the request
+	req, err := http.NewRequest("POST", config.BaseURL+"/sms/json", strings.NewReader(formData.Encode()))
+	if err != nil {
+		return err
+	}
+
+	// Set headers
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	// Send the request
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Check the response
+	if resp.StatusCode != http.StatusOK {
+		return ErrSMSFailed
+	}
+
+	return nil
+}
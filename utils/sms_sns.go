@@ -0,0 +1,146 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterSMSProvider("sns", newSNSProvider)
+}
+
+// snsProvider sends SMS through Amazon SNS, signing requests with
+// signature v4
+type snsProvider struct {
+	config   *SMSConfig
+	endpoint string
+	client   *http.Client
+}
+
+// newSNSProvider creates the SNS provider. baseUrl overrides the regional
+// endpoint, for VPC endpoints and local stand-ins.
+func newSNSProvider(config *SMSConfig) (SMSProvider, error) {
+	sns := config.SNS
+	if sns.Region == "" || sns.AccessKeyID == "" || sns.SecretAccessKey == "" {
+		return nil, errors.New("sns: region and credentials are required")
+	}
+	endpoint := config.BaseURL
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://sns.%s.amazonaws.com", sns.Region)
+	}
+	return &snsProvider{
+		config:   config,
+		endpoint: strings.TrimSuffix(endpoint, "/") + "/",
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// Send publishes an SMS to a phone number
+func (p *snsProvider) Send(phone, message string) error {
+	formData := url.Values{}
+	formData.Set("Action", "Publish")
+	formData.Set("Version", "2010-03-31")
+	formData.Set("PhoneNumber", CanonicalPhone(strings.ReplaceAll(phone, " ", "")))
+	formData.Set("Message", message)
+
+	entry := 1
+	setAttribute := func(name, value string) {
+		prefix := fmt.Sprintf("MessageAttributes.entry.%d.", entry)
+		formData.Set(prefix+"Name", name)
+		formData.Set(prefix+"Value.DataType", "String")
+		formData.Set(prefix+"Value.StringValue", value)
+		entry++
+	}
+	if p.config.SNS.SMSType != "" {
+		setAttribute("AWS.SNS.SMS.SMSType", p.config.SNS.SMSType)
+	}
+	if p.config.SenderID != "" {
+		setAttribute("AWS.SNS.SMS.SenderID", p.config.SenderID)
+	}
+
+	body := formData.Encode()
+	req, err := http.NewRequest("POST", p.endpoint, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	p.sign(req, body)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		log.Printf("SNS error %d: %s", resp.StatusCode, detail)
+		return ErrSMSFailed
+	}
+	return nil
+}
+
+// sign adds AWS signature version 4 headers to a request
+func (p *snsProvider) sign(req *http.Request, body string) {
+	sns := p.config.SNS
+	now := time.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := snsHashHex([]byte(body))
+
+	req.Header.Set("Host", req.URL.Host)
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	signedHeaders := "content-type;host;x-amz-date"
+	canonicalHeaders := "content-type:" + req.Header.Get("Content-Type") + "\n" +
+		"host:" + req.URL.Host + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + sns.Region + "/sns/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		snsHashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := snsHMAC([]byte("AWS4"+sns.SecretAccessKey), date)
+	key = snsHMAC(key, sns.Region)
+	key = snsHMAC(key, "sns")
+	key = snsHMAC(key, "aws4_request")
+	signature := hex.EncodeToString(snsHMAC(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sns.AccessKeyID, scope, signedHeaders, signature,
+	))
+}
+
+func snsHMAC(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func snsHashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
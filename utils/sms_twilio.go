@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+func init() {
+	RegisterSMSProvider("twilio", newTwilioProvider)
+}
+
+// twilioProvider sends SMS through Twilio
+type twilioProvider struct {
+	config *SMSConfig
+}
+
+// newTwilioProvider creates the Twilio provider
+func newTwilioProvider(config *SMSConfig) (SMSProvider, error) {
+	return &twilioProvider{config: config}, nil
+}
+
+// Send sends an SMS using Twilio
+func (p *twilioProvider) Send(phone, message string) error {
+	config := p.config
+
+	// Prepare the form data
+	formData := url.Values{}
+	formData.Set("To", phone)
+	formData.Set("From", config.SenderID)
+	formData.Set("Body", message)
+
+	// Create the request
+	req, err := http.NewRequest("POST", config.BaseURL+"/2010-04-01/Accounts/"+config.APIKey+"/Messages.json", strings.NewReader(formData.Encode()))
+	if err != nil {
+		return err
+	}
+
+	// Set headers
+	req.SetBasicAuth(config.APIKey, config.APIKey)
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	// Send the request
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Check the response
+	if resp.StatusCode != http.StatusCreated {
+		return ErrSMSFailed
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	fastws "github.com/fasthttp/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/websocket/v2"
+	"github.com/piko/piko/database/dbtest"
+	"github.com/piko/piko/models"
+)
+
+// connectBenchClients adds a loopback WebSocket connection to pool for each
+// address. The remote ends read and discard everything sent to them.
+func connectBenchClients(b *testing.B, pool *Pool, addresses []string) {
+	b.Helper()
+
+	conns := make(chan *websocket.Conn)
+	done := make(chan struct{})
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Get("/ws", websocket.New(func(c *websocket.Conn) {
+		conns <- c
+		<-done
+	}))
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		b.Fatal(err)
+	}
+	go app.Listener(ln)
+	b.Cleanup(func() {
+		close(done)
+		app.Shutdown()
+	})
+
+	url := "ws://" + ln.Addr().String() + "/ws"
+	for _, address := range addresses {
+		remote, _, err := fastws.DefaultDialer.Dial(url, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Cleanup(func() { remote.Close() })
+		go func() {
+			for {
+				if _, _, err := remote.NextReader(); err != nil {
+					return
+				}
+			}
+		}()
+
+		pool.Clients[address] = &Client{
+			ID:          address,
+			Address:     address,
+			Conn:        <-conns,
+			Pool:        pool,
+			ConnectedAt: time.Now(),
+		}
+	}
+}
+
+// quietLogs discards log output for the rest of a benchmark
+func quietLogs(b *testing.B) {
+	log.SetOutput(io.Discard)
+	b.Cleanup(func() { log.SetOutput(os.Stderr) })
+}
+
+// BenchmarkMessageInsertNotify measures sending a direct message to an
+// online recipient: storing it, pushing it, marking it delivered and
+// telling the sender
+func BenchmarkMessageInsertNotify(b *testing.B) {
+	dbtest.Open(b)
+	quietLogs(b)
+	ctx := context.Background()
+
+	sender, recipient := "PikoBenchSender", "PikoBenchRecipient"
+	pool := NewPool()
+	connectBenchClients(b, pool, []string{sender, recipient})
+
+	prefix := fmt.Sprintf("bench-%d", time.Now().UnixNano())
+	content := make([]byte, 256)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		message := &models.Message{
+			ID:               fmt.Sprintf("%s-%d", prefix, i),
+			SenderAddress:    sender,
+			RecipientAddress: recipient,
+			EncryptedContent: content,
+			Status:           models.MessageStatusPending,
+		}
+		if err := models.CreateMessage(ctx, message); err != nil {
+			b.Fatal(err)
+		}
+		NotifyNewMessage(pool, message)
+	}
+}
+
+// BenchmarkChannelFanOut measures notifying every online member of a
+// channel about a new message
+func BenchmarkChannelFanOut(b *testing.B) {
+	dbtest.Open(b)
+	quietLogs(b)
+	ctx := context.Background()
+
+	for _, n := range []int{10, 100} {
+		addresses := make([]string, n)
+		for i := range addresses {
+			addresses[i] = fmt.Sprintf("PikoBenchMember%06d", i)
+		}
+
+		channel := &models.Channel{
+			ID:           fmt.Sprintf("bench-channel-%d", time.Now().UnixNano()),
+			Name:         "Benchmark",
+			AdminAddress: addresses[0],
+		}
+		if err := models.CreateChannel(ctx, channel); err != nil {
+			b.Fatal(err)
+		}
+		for _, address := range addresses[1:] {
+			if err := models.JoinChannel(ctx, channel.ID, address, 0); err != nil {
+				b.Fatal(err)
+			}
+		}
+
+		pool := NewPool()
+		connectBenchClients(b, pool, addresses)
+
+		message := &models.ChannelMessage{
+			ID:            "bench-channel-message",
+			ChannelID:     channel.ID,
+			SenderAddress: addresses[0],
+		}
+		b.Run(fmt.Sprintf("members=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				NotifyNewChannelMessage(pool, message)
+			}
+		})
+	}
+}
@@ -0,0 +1,346 @@
+package websocket
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/piko/piko/config"
+	"github.com/piko/piko/crypto"
+	"github.com/piko/piko/metrics"
+	"github.com/piko/piko/privacy"
+	"github.com/piko/piko/redis"
+)
+
+var (
+	relayedMessages = metrics.NewCounterVec(
+		"piko_websocket_relayed_messages_total",
+		"WebSocket messages relayed between server instances, by direction.",
+		"direction",
+	)
+	relayErrors = metrics.NewCounterVec(
+		"piko_websocket_relay_errors_total",
+		"WebSocket messages that could not be relayed to other server instances.",
+	)
+)
+
+// Broker relays messages between the pools of server instances, so clients
+// connected to different instances reach each other
+type Broker interface {
+	// Publish sends data to every instance subscribed to the broker,
+	// including this one
+	Publish(data []byte) error
+	// Subscribe passes everything published to the broker to receive
+	Subscribe(receive func(data []byte)) error
+	Close() error
+}
+
+// PresenceStore shares which users are connected to which instance, so
+// presence lookups see the clients of every instance. Brokers that can hold
+// state implement it.
+type PresenceStore interface {
+	// MarkOnline records that an instance serves a user until expires
+	MarkOnline(instanceID, address string, audience privacy.Audience, expires time.Time) error
+	// MarkOffline removes an instance's record of a user
+	MarkOffline(instanceID, address string) error
+	// Online returns the presence audiences of those of addresses connected
+	// to any instance
+	Online(addresses []string) (map[string]privacy.Audience, error)
+}
+
+// NewBroker creates the broker selected in configuration, or nil when each
+// instance only serves its own clients. Pools sharing a broker must use
+// different channels.
+func NewBroker(backend string, redisCfg config.RedisConfig, channel string) (Broker, error) {
+	switch backend {
+	case "", "local":
+		return nil, nil
+	case "redis":
+		return NewRedisBroker(redisCfg, channel), nil
+	default:
+		return nil, fmt.Errorf("unsupported WebSocket broker: %s", backend)
+	}
+}
+
+// RedisBroker relays messages over a Redis pub/sub channel
+type RedisBroker struct {
+	cfg        config.RedisConfig
+	channel    string
+	client     *redis.Client
+	subscriber *redis.Subscriber
+}
+
+// NewRedisBroker creates a broker publishing to a Redis channel
+func NewRedisBroker(cfg config.RedisConfig, channel string) *RedisBroker {
+	return &RedisBroker{
+		cfg:     cfg,
+		channel: channel,
+		client:  redis.NewClient(cfg),
+	}
+}
+
+// Publish sends data to the channel
+func (b *RedisBroker) Publish(data []byte) error {
+	return b.client.Publish(b.channel, string(data))
+}
+
+// Subscribe starts receiving the channel's messages
+func (b *RedisBroker) Subscribe(receive func(data []byte)) error {
+	b.subscriber = redis.Subscribe(b.cfg, b.channel, func(message string) {
+		receive([]byte(message))
+	})
+	return nil
+}
+
+// Close stops receiving and closes the connections
+func (b *RedisBroker) Close() error {
+	if b.subscriber != nil {
+		b.subscriber.Close()
+	}
+	return b.client.Close()
+}
+
+// markOnlineScript records an instance in a user's presence set, dropping
+// the records of instances that stopped refreshing theirs, and stores the
+// user's presence audience alongside
+const markOnlineScript = `
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[3])
+redis.call('ZADD', KEYS[1], ARGV[2], ARGV[1])
+redis.call('PEXPIRE', KEYS[1], ARGV[4])
+redis.call('SET', KEYS[2], ARGV[5], 'PX', ARGV[4])
+return 1
+`
+
+// onlineScript returns, for each presence set, the user's presence audience
+// if an instance still serves them, otherwise an empty string
+const onlineScript = `
+local result = {}
+for i, key in ipairs(KEYS) do
+	result[i] = ''
+	if redis.call('ZCOUNT', key, '(' .. ARGV[1], '+inf') > 0 then
+		result[i] = redis.call('GET', key .. ':audience') or ''
+	end
+end
+return result
+`
+
+// presenceKey is the Redis key of the set of instances serving a user
+func (b *RedisBroker) presenceKey(address string) string {
+	return b.channel + ":presence:" + address
+}
+
+// MarkOnline records that an instance serves a user until expires
+func (b *RedisBroker) MarkOnline(instanceID, address string, audience privacy.Audience, expires time.Time) error {
+	data, err := json.Marshal(audience)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	key := b.presenceKey(address)
+	_, err = b.client.Do(
+		"EVAL", markOnlineScript, "2", key, key+":audience",
+		instanceID,
+		strconv.FormatInt(expires.UnixMilli(), 10),
+		strconv.FormatInt(now.UnixMilli(), 10),
+		strconv.FormatInt(expires.Sub(now).Milliseconds(), 10),
+		string(data),
+	)
+	return err
+}
+
+// MarkOffline removes an instance's record of a user
+func (b *RedisBroker) MarkOffline(instanceID, address string) error {
+	_, err := b.client.Do("ZREM", b.presenceKey(address), instanceID)
+	return err
+}
+
+// Online returns the presence audiences of those of addresses connected to
+// any instance
+func (b *RedisBroker) Online(addresses []string) (map[string]privacy.Audience, error) {
+	online := make(map[string]privacy.Audience)
+	if len(addresses) == 0 {
+		return online, nil
+	}
+
+	args := make([]string, 0, len(addresses)+4)
+	args = append(args, "EVAL", onlineScript, strconv.Itoa(len(addresses)))
+	for _, address := range addresses {
+		args = append(args, b.presenceKey(address))
+	}
+	args = append(args, strconv.FormatInt(time.Now().UnixMilli(), 10))
+
+	reply, err := b.client.Do(args...)
+	if err != nil {
+		return nil, err
+	}
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != len(addresses) {
+		return nil, redis.ErrUnexpectedReply
+	}
+	for i, value := range values {
+		data, _ := value.(string)
+		if data == "" {
+			continue
+		}
+		var audience privacy.Audience
+		if err := json.Unmarshal([]byte(data), &audience); err != nil {
+			return nil, err
+		}
+		online[addresses[i]] = audience
+	}
+	return online, nil
+}
+
+// relayedMessage is a message on its way to the clients of other instances.
+// It goes to the members of Room if set, otherwise to Addresses, otherwise
+// to every client but those in Except. Session narrows any of these to the
+// clients connected with that session's token.
+type relayedMessage struct {
+	Origin    string   `json:"origin"`
+	Addresses []string `json:"addresses,omitempty"`
+	Room      string   `json:"room,omitempty"`
+	Except    []string `json:"except,omitempty"`
+	Session   string   `json:"session,omitempty"`
+	Message   Message  `json:"message"`
+	// Encoded are binary payload fields, added to the payload in each
+	// client's encoding when delivered
+	Encoded map[string][]byte `json:"encoded,omitempty"`
+	// Close disconnects the clients once the message is written
+	Close bool `json:"close,omitempty"`
+}
+
+// sendTo queues the message for a client, if it is connected with the
+// message's session
+func (relayed relayedMessage) sendTo(client *Client) {
+	if relayed.Session != "" && client.SessionID != relayed.Session {
+		return
+	}
+
+	message := relayed.Message
+	if len(relayed.Encoded) > 0 {
+		message.Payload = make(map[string]interface{}, len(relayed.Message.Payload)+len(relayed.Encoded))
+		for key, value := range relayed.Message.Payload {
+			message.Payload[key] = value
+		}
+		for key, value := range relayed.Encoded {
+			message.Payload[key] = client.Encoding.Encode(value)
+		}
+	}
+
+	if relayed.Close {
+		client.sendLast(message)
+		return
+	}
+	client.SendMessage(message)
+}
+
+// excludes reports whether a message sent to every client skips a user
+func (relayed relayedMessage) excludes(address string) bool {
+	for _, excluded := range relayed.Except {
+		if excluded == address {
+			return true
+		}
+	}
+	return false
+}
+
+// UseBroker relays the pool's broadcasts, presence updates and receipts to
+// and from the other instances sharing the broker. Call it before clients
+// connect.
+func (pool *Pool) UseBroker(broker Broker) error {
+	id, err := crypto.GenerateRandomBytes(16)
+	if err != nil {
+		return err
+	}
+	pool.instanceID = hex.EncodeToString(id)
+	pool.broker = broker
+	if presence, ok := broker.(PresenceStore); ok {
+		pool.presence = presence
+		go pool.refreshPresence()
+	}
+	return broker.Subscribe(pool.receive)
+}
+
+// publish relays a message to the other instances, if the pool has a broker
+func (pool *Pool) publish(relayed relayedMessage) {
+	if pool.broker == nil {
+		return
+	}
+
+	relayed.Origin = pool.instanceID
+	data, err := json.Marshal(relayed)
+	if err == nil {
+		err = pool.broker.Publish(data)
+	}
+	if err != nil {
+		relayErrors.Inc()
+		log.Printf("Error relaying %s message: %v", relayed.Message.Type, err)
+		return
+	}
+	relayedMessages.Inc("sent")
+}
+
+// receive delivers a message relayed by another instance to the clients
+// connected to this one
+func (pool *Pool) receive(data []byte) {
+	var relayed relayedMessage
+	if err := json.Unmarshal(data, &relayed); err != nil {
+		log.Printf("Error decoding relayed message: %v", err)
+		return
+	}
+	// Messages from this instance were delivered before they were published
+	if relayed.Origin == pool.instanceID {
+		return
+	}
+	relayedMessages.Inc("received")
+	pool.deliver(relayed)
+}
+
+// deliver queues a message for the clients connected to this instance
+func (pool *Pool) deliver(relayed relayedMessage) {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	switch {
+	case relayed.Room != "":
+		for client := range pool.rooms[relayed.Room] {
+			relayed.sendTo(client)
+		}
+	case len(relayed.Addresses) > 0:
+		for _, address := range relayed.Addresses {
+			if client, ok := pool.Clients[address]; ok {
+				relayed.sendTo(client)
+			}
+		}
+	default:
+		for address, client := range pool.Clients {
+			if !relayed.excludes(address) {
+				relayed.sendTo(client)
+			}
+		}
+	}
+}
+
+// sendTo queues a message for users on whichever instance they are
+// connected to
+func (pool *Pool) sendTo(message Message, addresses ...string) {
+	if len(addresses) == 0 {
+		return
+	}
+	pool.relay(relayedMessage{Addresses: addresses, Message: message})
+}
+
+// sendToRoom queues a message for the members of a room on every instance
+func (pool *Pool) sendToRoom(room string, message Message) {
+	pool.relay(relayedMessage{Room: room, Message: message})
+}
+
+// relay delivers a message to the clients of this instance and publishes it
+// to the others
+func (pool *Pool) relay(relayed relayedMessage) {
+	pool.deliver(relayed)
+	pool.publish(relayed)
+}
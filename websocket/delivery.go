@@ -0,0 +1,64 @@
+package websocket
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/piko/piko/metrics"
+	"github.com/piko/piko/models"
+)
+
+// maxTrackedDeliveries caps how many unacknowledged messages are tracked
+// per client, so clients that never acknowledge don't grow without bound
+const maxTrackedDeliveries = 1000
+
+var deliverySeconds = metrics.NewHistogramVec(
+	"piko_message_delivery_seconds",
+	"Time from a message being stored to a connected recipient acknowledging it.",
+	[]float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+	"kind",
+)
+
+// TrackDeliveries turns delivery time measurement on or off. Only messages
+// pushed to connected recipients are measured, so recipients coming back
+// online don't count as slow deliveries.
+func (pool *Pool) TrackDeliveries(enabled bool) {
+	pool.trackDeliveries.Store(enabled)
+}
+
+// trackDelivery remembers when a message pushed to the client was stored
+func (client *Client) trackDelivery(messageID string, storedAt time.Time) {
+	if !client.Pool.trackDeliveries.Load() {
+		return
+	}
+
+	client.deliveryMu.Lock()
+	defer client.deliveryMu.Unlock()
+	if client.deliveries == nil {
+		client.deliveries = make(map[string]time.Time)
+	}
+	if len(client.deliveries) >= maxTrackedDeliveries {
+		return
+	}
+	client.deliveries[messageID] = storedAt
+}
+
+// completeDelivery records the delivery time of a message the client
+// acknowledged, if it was pushed to this connection
+func (client *Client) completeDelivery(messageID string, kind models.DeliveryKind) {
+	client.deliveryMu.Lock()
+	storedAt, ok := client.deliveries[messageID]
+	delete(client.deliveries, messageID)
+	client.deliveryMu.Unlock()
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	latency := max(now.Sub(storedAt), 0)
+	deliverySeconds.Observe(latency.Seconds(), string(kind))
+	if err := models.RecordDeliverySample(context.Background(), kind, latency, now); err != nil {
+		log.Printf("Error recording delivery time: %v", err)
+	}
+}
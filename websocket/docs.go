@@ -0,0 +1,40 @@
+package websocket
+
+import (
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/types"
+)
+
+// MessageTypeDocUpdate is sent to a document's online collaborators when
+// one of them appends an update
+const MessageTypeDocUpdate = "doc_update"
+
+// NotifyDocUpdate replays an update to the document's online collaborators,
+// except its sender, encoded for each of them
+func NotifyDocUpdate(pool *Pool, doc *models.CollabDoc, update *models.CollabDocUpdate, collaborators []string) {
+	recipients := make([]string, 0, len(collaborators))
+	for _, address := range collaborators {
+		if address != update.SenderAddress {
+			recipients = append(recipients, address)
+		}
+	}
+	if len(recipients) == 0 {
+		return
+	}
+
+	pool.relay(relayedMessage{
+		Addresses: recipients,
+		Message: Message{
+			Type: MessageTypeDocUpdate,
+			Payload: map[string]interface{}{
+				"doc_id":            doc.ID,
+				"conversation_type": doc.ConversationType,
+				"conversation_id":   doc.ConversationID,
+				"version":           update.Version,
+				"sender_address":    update.SenderAddress,
+				"created_at":        types.FormatTime(update.CreatedAt.Time),
+			},
+		},
+		Encoded: map[string][]byte{"update": update.Content},
+	})
+}
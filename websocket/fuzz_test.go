@@ -0,0 +1,42 @@
+package websocket
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// FuzzDecodeMessage checks that any text frame a client sends is either
+// rejected or decodes to a message whose payload can be read and sent
+// back out, as the read loop does
+func FuzzDecodeMessage(f *testing.F) {
+	f.Add([]byte(`{"type":"ping"}`))
+	f.Add([]byte(`{"type":"typing","payload":{"to":"PikoAddress"}}`))
+	f.Add([]byte(`{"type":"typing","payload":{"group_id":"g1"}}`))
+	f.Add([]byte(`{"type":"presence","payload":{"channel_id":""}}`))
+	f.Add([]byte(`{"type":"inbox_ack","payload":{"page":1e308}}`))
+	f.Add([]byte(`{"type":"read","payload":{"message_id":["not","a","string"]}}`))
+	f.Add([]byte(`{"type":"received","payload":null}`))
+	f.Add([]byte(`{"payload":"not an object"}`))
+	f.Add([]byte(`[]`))
+	f.Add([]byte{0xff, 0x00})
+
+	f.Fuzz(func(t *testing.T, frame []byte) {
+		message, err := decodeMessage(frame)
+		if err != nil {
+			return
+		}
+
+		if s, ok := scopeFromPayload(message.Payload); ok && s.id == "" {
+			t.Fatalf("scope with no id from %q", frame)
+		}
+		if page, ok := message.Payload["page"].(float64); ok {
+			_ = int(page)
+		}
+		_, _ = message.Payload["to"].(string)
+		_, _ = message.Payload["message_id"].(string)
+
+		if _, err := json.Marshal(message); err != nil {
+			t.Fatalf("decoded message from %q doesn't encode: %v", frame, err)
+		}
+	})
+}
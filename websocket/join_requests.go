@@ -0,0 +1,25 @@
+package websocket
+
+import (
+	"time"
+
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/types"
+)
+
+// MessageTypeGroupJoinRequestDecided is sent to a user when an admin
+// approves or rejects their request to join a group
+const MessageTypeGroupJoinRequestDecided = "group_join_request_decided"
+
+// NotifyGroupJoinRequestDecided tells a requester whether they were let
+// into the group
+func NotifyGroupJoinRequestDecided(pool *Pool, groupID, userAddress string, status models.JoinRequestStatus) {
+	pool.sendTo(Message{
+		Type: MessageTypeGroupJoinRequestDecided,
+		Payload: map[string]interface{}{
+			"group_id":  groupID,
+			"status":    status,
+			"timestamp": types.FormatTime(time.Now()),
+		},
+	}, userAddress)
+}
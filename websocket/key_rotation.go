@@ -0,0 +1,55 @@
+package websocket
+
+import (
+	"time"
+
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/types"
+)
+
+const (
+	// MessageTypeKeyRotated is sent to a user's conversation partners when
+	// they publish a new identity key
+	MessageTypeKeyRotated = "key_rotated"
+
+	// MessageTypeKeyRotationAcknowledged is sent to a rotating user when a
+	// peer acknowledges their new key
+	MessageTypeKeyRotationAcknowledged = "key_rotation_acknowledged"
+)
+
+// NotifyKeyRotated tells the online peers affected by a key rotation to
+// start a new session with the rotating user
+func NotifyKeyRotated(pool *Pool, rotation *models.KeyRotation) {
+	if len(rotation.Peers) == 0 {
+		return
+	}
+	peers := make([]string, len(rotation.Peers))
+	for i, peer := range rotation.Peers {
+		peers[i] = peer.PeerAddress
+	}
+
+	pool.sendTo(Message{
+		Type: MessageTypeKeyRotated,
+		Payload: map[string]interface{}{
+			"rotation_id":          rotation.ID,
+			"user_address":         rotation.UserAddress,
+			"identity_fingerprint": rotation.IdentityFingerprint,
+			"created_at":           types.FormatTime(rotation.CreatedAt.Time),
+		},
+	}, peers...)
+}
+
+// NotifyKeyRotationAcknowledged tells the rotating user which peer
+// acknowledged their new key and how far the rotation has come
+func NotifyKeyRotationAcknowledged(pool *Pool, rotation *models.KeyRotation, peerAddress string) {
+	pool.sendTo(Message{
+		Type: MessageTypeKeyRotationAcknowledged,
+		Payload: map[string]interface{}{
+			"rotation_id":  rotation.ID,
+			"peer_address": peerAddress,
+			"total":        rotation.Total,
+			"acknowledged": rotation.Acknowledged,
+			"timestamp":    types.FormatTime(time.Now()),
+		},
+	}, rotation.UserAddress)
+}
@@ -0,0 +1,35 @@
+package websocket
+
+import (
+	"time"
+
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/types"
+)
+
+// MessageTypeStatusUpdates is sent to a sender when a recipient marks
+// several of their messages delivered or read at once, in place of one
+// status_update per message
+const MessageTypeStatusUpdates = "status_updates"
+
+// NotifyStatusChanges tells each sender which of their messages a recipient
+// moved to status, in one event per sender
+func NotifyStatusChanges(pool *Pool, recipientAddress string, status models.MessageStatus, changes []models.StatusChange) {
+	bySender := map[string][]string{}
+	for _, change := range changes {
+		bySender[change.SenderAddress] = append(bySender[change.SenderAddress], change.MessageID)
+	}
+
+	timestamp := types.FormatTime(time.Now())
+	for sender, messageIDs := range bySender {
+		pool.sendTo(Message{
+			Type: MessageTypeStatusUpdates,
+			Payload: map[string]interface{}{
+				"message_ids": messageIDs,
+				"status":      string(status),
+				"recipient":   recipientAddress,
+				"timestamp":   timestamp,
+			},
+		}, sender)
+	}
+}
@@ -0,0 +1,20 @@
+package websocket
+
+import (
+	"context"
+	"log"
+
+	"github.com/piko/piko/models"
+)
+
+// mutedAddresses loads which recipients silenced a conversation. Their
+// messages are still sent, marked "muted" so clients deliver them quietly.
+// Failures are logged and nobody counts as muted.
+func mutedAddresses(conversationType models.ConversationType, conversationID string, addresses []string) map[string]bool {
+	muted, err := models.GetMutedUsers(context.Background(), conversationType, conversationID, addresses)
+	if err != nil {
+		log.Printf("Error loading mutes of %s %s: %v", conversationType, conversationID, err)
+		return map[string]bool{}
+	}
+	return muted
+}
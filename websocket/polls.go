@@ -0,0 +1,26 @@
+package websocket
+
+import (
+	"github.com/piko/piko/models"
+)
+
+// MessageTypePollUpdated is sent to the connected members of a poll's group
+// or channel when a vote is cast or retracted, or the poll is closed
+const MessageTypePollUpdated = "poll_updated"
+
+// NotifyPollUpdated sends a poll's results to the connected members of its
+// conversation. Group polls go to the group's room, so members are only
+// needed for channel polls. Anonymous polls carry counts without voters.
+func NotifyPollUpdated(pool *Pool, poll *models.Poll, members []string) {
+	message := Message{
+		Type: MessageTypePollUpdated,
+		Payload: map[string]interface{}{
+			"poll": poll,
+		},
+	}
+	if poll.ConversationType == models.ConversationGroup {
+		pool.sendToRoom(GroupRoom(poll.ConversationID), message)
+		return
+	}
+	pool.sendTo(message, members...)
+}
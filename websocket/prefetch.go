@@ -0,0 +1,150 @@
+package websocket
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/piko/piko/crypto"
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/types"
+)
+
+const (
+	// MessageTypeInboxPage carries one page of conversation summaries
+	MessageTypeInboxPage = "inbox_page"
+
+	// MessageTypeInboxAck is sent by the client after it has processed a page
+	MessageTypeInboxAck = "inbox_ack"
+
+	// MessageTypeInboxDone is sent once the prefetch has finished or stopped
+	MessageTypeInboxDone = "inbox_done"
+
+	// MaxPrefetchConversations caps how many conversations a client may prefetch
+	MaxPrefetchConversations = 200
+
+	// DefaultPrefetchPageSize is used when the client doesn't ask for a page size
+	DefaultPrefetchPageSize = 20
+
+	// prefetchAckTimeout is how long we wait for the client to ack a page
+	prefetchAckTimeout = 30 * time.Second
+)
+
+// EnablePrefetch makes the client receive its most recent conversation
+// summaries over the socket right after it connects. Must be called before
+// the client is registered with the pool.
+func (client *Client) EnablePrefetch(limit, pageSize int) {
+	if limit <= 0 {
+		return
+	}
+	if limit > MaxPrefetchConversations {
+		limit = MaxPrefetchConversations
+	}
+	if pageSize <= 0 || pageSize > limit {
+		pageSize = DefaultPrefetchPageSize
+	}
+
+	client.prefetchLimit = limit
+	client.prefetchPageSize = pageSize
+	client.prefetchAcks = make(chan int, 1)
+}
+
+// ackPrefetchPage records that the client has processed a prefetched page
+func (client *Client) ackPrefetchPage(page int) {
+	if client.prefetchAcks == nil {
+		return
+	}
+	select {
+	case client.prefetchAcks <- page:
+	default:
+	}
+}
+
+// streamInbox sends conversation summaries to the client one page at a time,
+// waiting for the client to ack each page before sending the next so slow
+// devices are never flooded
+func (client *Client) streamInbox() {
+	summaries, err := models.GetConversationSummaries(context.Background(), client.Address, client.prefetchLimit)
+	if err != nil {
+		log.Printf("Error prefetching inbox for %s: %v", client.Address, err)
+		client.SendMessage(Message{
+			Type:    MessageTypeInboxDone,
+			Payload: map[string]interface{}{"error": "prefetch failed"},
+		})
+		return
+	}
+
+	pageSize := client.prefetchPageSize
+	page := 0
+	for start := 0; start < len(summaries); start += pageSize {
+		end := start + pageSize
+		if end > len(summaries) {
+			end = len(summaries)
+		}
+		page++
+
+		client.SendMessage(Message{
+			Type: MessageTypeInboxPage,
+			Payload: map[string]interface{}{
+				"page":          page,
+				"conversations": conversationPayloads(summaries[start:end], client.Encoding),
+				"has_more":      end < len(summaries),
+			},
+		})
+
+		if end == len(summaries) {
+			break
+		}
+
+		// Wait for the client to catch up before sending more
+		if !client.waitForPrefetchAck(page) {
+			log.Printf("Inbox prefetch for %s stopped at page %d: no ack", client.Address, page)
+			return
+		}
+	}
+
+	client.SendMessage(Message{
+		Type:    MessageTypeInboxDone,
+		Payload: map[string]interface{}{"pages": page},
+	})
+}
+
+// waitForPrefetchAck blocks until the client acks the given page
+func (client *Client) waitForPrefetchAck(page int) bool {
+	timeout := time.NewTimer(prefetchAckTimeout)
+	defer timeout.Stop()
+
+	for {
+		select {
+		case acked := <-client.prefetchAcks:
+			if acked >= page {
+				return true
+			}
+		case <-timeout.C:
+			return false
+		}
+	}
+}
+
+// conversationPayloads converts summaries to their wire format
+func conversationPayloads(summaries []*models.ConversationSummary, encoding crypto.Encoding) []map[string]interface{} {
+	payloads := make([]map[string]interface{}, len(summaries))
+	for i, summary := range summaries {
+		payload := map[string]interface{}{
+			"peer_address":    summary.PeerAddress,
+			"last_message_at": types.FormatTime(summary.LastMessageAt.Time),
+			"unread_count":    summary.UnreadCount,
+			"key_verified":    summary.KeyVerified,
+		}
+		if summary.LastMessage != nil {
+			payload["last_message"] = map[string]interface{}{
+				"id":                summary.LastMessage.ID,
+				"sender_address":    summary.LastMessage.SenderAddress,
+				"encrypted_content": encoding.Encode(summary.LastMessage.EncryptedContent),
+				"status":            string(summary.LastMessage.Status),
+			}
+		}
+		payloads[i] = payload
+	}
+	return payloads
+}
@@ -0,0 +1,127 @@
+package websocket
+
+import (
+	"log"
+	"time"
+
+	"github.com/piko/piko/privacy"
+)
+
+// Presence statuses
+const (
+	presenceOnline  = "online"
+	presenceOffline = "offline"
+)
+
+// presenceTTL is how long an instance's record of a connected user lasts in
+// the shared presence store. Instances refresh their records three times
+// per TTL, so those of a crashed instance lapse within it.
+const presenceTTL = 90 * time.Second
+
+// SetPresenceAudience sets who is told when the client comes online or goes
+// offline, from its user's privacy_status setting. Must be called before
+// the client is registered with the pool; a client without an audience
+// only tells itself.
+func (client *Client) SetPresenceAudience(audience privacy.Audience) {
+	client.presenceAudience = audience
+}
+
+// UpdatePresenceAudience changes who sees a connected user's presence,
+// after their privacy settings or contacts change
+func (pool *Pool) UpdatePresenceAudience(address string, audience privacy.Audience) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	if client, ok := pool.Clients[address]; ok {
+		client.presenceAudience = audience
+		go pool.markOnline(client)
+	}
+}
+
+// markOnline records a connected client in the shared presence store, with
+// who may see it, unless it disconnected in the meantime
+func (pool *Pool) markOnline(client *Client) {
+	if pool.presence == nil {
+		return
+	}
+	pool.mu.RLock()
+	connected := pool.Clients[client.Address] == client
+	audience := client.presenceAudience
+	pool.mu.RUnlock()
+	if !connected {
+		return
+	}
+	if err := pool.presence.MarkOnline(pool.instanceID, client.Address, audience, time.Now().Add(presenceTTL)); err != nil {
+		log.Printf("Error sharing presence of %s: %v", client.Address, err)
+	}
+}
+
+// markOffline removes a disconnected client from the shared presence store,
+// unless the user connected again in the meantime
+func (pool *Pool) markOffline(client *Client) {
+	if pool.presence == nil {
+		return
+	}
+	pool.mu.RLock()
+	_, reconnected := pool.Clients[client.Address]
+	pool.mu.RUnlock()
+	if reconnected {
+		return
+	}
+	if err := pool.presence.MarkOffline(pool.instanceID, client.Address); err != nil {
+		log.Printf("Error sharing presence of %s: %v", client.Address, err)
+	}
+}
+
+// refreshPresence renews the shared presence records of the clients
+// connected to this instance before they lapse
+func (pool *Pool) refreshPresence() {
+	ticker := time.NewTicker(presenceTTL / 3)
+	defer ticker.Stop()
+	for range ticker.C {
+		pool.mu.RLock()
+		clients := make([]*Client, 0, len(pool.Clients))
+		for _, client := range pool.Clients {
+			clients = append(clients, client)
+		}
+		pool.mu.RUnlock()
+
+		for _, client := range clients {
+			pool.markOnline(client)
+		}
+	}
+}
+
+// onlineElsewhere returns the presence audiences of those of addresses the
+// shared presence store reports connected, or nothing without a store
+func (pool *Pool) onlineElsewhere(addresses []string) map[string]privacy.Audience {
+	if pool.presence == nil || len(addresses) == 0 {
+		return nil
+	}
+	online, err := pool.presence.Online(addresses)
+	if err != nil {
+		log.Printf("Error looking up shared presence: %v", err)
+		return nil
+	}
+	return online
+}
+
+// sendPresence tells the users allowed to see it that a client came online
+// or went offline
+func (pool *Pool) sendPresence(client *Client, status string) {
+	pool.mu.RLock()
+	audience := client.presenceAudience
+	pool.mu.RUnlock()
+
+	message := Message{
+		Type: MessageTypePresence,
+		Payload: map[string]interface{}{
+			"address": client.Address,
+			"status":  status,
+		},
+	}
+	if audience.Everyone {
+		pool.relay(relayedMessage{Except: audience.Except, Message: message})
+		return
+	}
+	pool.sendTo(message, append([]string{client.Address}, audience.Only...)...)
+}
@@ -0,0 +1,50 @@
+package websocket
+
+import (
+	"time"
+
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/types"
+)
+
+const (
+	// MessageTypeGroupRead is sent to a group's connected members when one
+	// of them reads up to a message
+	MessageTypeGroupRead = "group_read"
+
+	// MessageTypeChannelRead is sent to a channel message's sender when a
+	// member reads up to it
+	MessageTypeChannelRead = "channel_read"
+)
+
+// NotifyGroupRead tells the group's connected members, including the
+// reader's own client, that a member read up to a message
+func NotifyGroupRead(pool *Pool, groupID, readerAddress, messageID string) {
+	pool.sendToRoom(GroupRoom(groupID), Message{
+		Type: MessageTypeGroupRead,
+		Payload: map[string]interface{}{
+			"group_id":   groupID,
+			"reader":     readerAddress,
+			"message_id": messageID,
+			"timestamp":  types.FormatTime(time.Now()),
+		},
+	})
+}
+
+// NotifyChannelRead tells the sender of a channel message that a member
+// read up to it. Channels can be large, so other members aren't told.
+func NotifyChannelRead(pool *Pool, message *models.ChannelMessage, readerAddress string) {
+	if message.SenderAddress == readerAddress {
+		return
+	}
+
+	pool.sendTo(Message{
+		Type: MessageTypeChannelRead,
+		Payload: map[string]interface{}{
+			"channel_id": message.ChannelID,
+			"reader":     readerAddress,
+			"message_id": message.ID,
+			"timestamp":  types.FormatTime(time.Now()),
+		},
+	}, message.SenderAddress)
+}
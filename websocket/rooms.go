@@ -0,0 +1,258 @@
+package websocket
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/types"
+)
+
+const (
+	// MessageTypeGroupReceived is sent by a client to acknowledge a group
+	// message, and relayed to the sender as a delivery receipt
+	MessageTypeGroupReceived = "group_received"
+
+	// MessageTypeGroupMessageEdited is sent to a group's connected members
+	// when the sender edits a group message
+	MessageTypeGroupMessageEdited = "group_message_edited"
+
+	// MessageTypeGroupOwnerChanged is sent to a group's connected members
+	// when the owner transfers ownership
+	MessageTypeGroupOwnerChanged = "group_owner_changed"
+)
+
+// GroupRoom returns the name of the room a group's messages are fanned out to
+func GroupRoom(groupID string) string {
+	return "group:" + groupID
+}
+
+// joinGroupRooms subscribes a newly connected client to the rooms of every
+// group its authenticated address belongs to
+func (pool *Pool) joinGroupRooms(client *Client) {
+	groups, err := models.GetUserGroups(context.Background(), client.Address)
+	if err != nil {
+		log.Printf("Error getting groups for %s: %v", client.Address, err)
+		return
+	}
+
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	// The client may have disconnected while its groups were loading
+	if pool.Clients[client.Address] != client {
+		return
+	}
+	for _, group := range groups {
+		pool.joinLocked(GroupRoom(group.ID), client)
+	}
+}
+
+// joinLocked adds a client to a room. pool.mu must be held for writing.
+func (pool *Pool) joinLocked(room string, client *Client) {
+	if pool.rooms == nil {
+		pool.rooms = make(map[string]map[*Client]bool)
+	}
+	if pool.rooms[room] == nil {
+		pool.rooms[room] = make(map[*Client]bool)
+	}
+	pool.rooms[room][client] = true
+}
+
+// leaveLocked removes a client from a room. pool.mu must be held for writing.
+func (pool *Pool) leaveLocked(room string, client *Client) {
+	delete(pool.rooms[room], client)
+	if len(pool.rooms[room]) == 0 {
+		delete(pool.rooms, room)
+	}
+}
+
+// leaveAllLocked removes a disconnecting client from every room. pool.mu
+// must be held for writing.
+func (pool *Pool) leaveAllLocked(client *Client) {
+	for room := range pool.rooms {
+		pool.leaveLocked(room, client)
+	}
+}
+
+// JoinRoom subscribes a user's connected client to a room, e.g. after they
+// were added to a group
+func (pool *Pool) JoinRoom(room, address string) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	if client, ok := pool.Clients[address]; ok {
+		pool.joinLocked(room, client)
+	}
+}
+
+// LeaveRoom unsubscribes a user's connected client from a room, e.g. after
+// they left a group
+func (pool *Pool) LeaveRoom(room, address string) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	if client, ok := pool.Clients[address]; ok {
+		pool.leaveLocked(room, client)
+	}
+}
+
+// CloseRoom unsubscribes everyone from a room, e.g. after a group was deleted
+func (pool *Pool) CloseRoom(room string) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	delete(pool.rooms, room)
+}
+
+// inRoom reports whether a client is subscribed to a room
+func (pool *Pool) inRoom(room string, client *Client) bool {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+	return pool.rooms[room][client]
+}
+
+// RoomMembers returns the addresses of the clients subscribed to a room
+func (pool *Pool) RoomMembers(room string) []string {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	addresses := make([]string, 0, len(pool.rooms[room]))
+	for client := range pool.rooms[room] {
+		addresses = append(addresses, client.Address)
+	}
+	return addresses
+}
+
+// NotifyNewGroupMessage fans a group message out to the members connected to
+// the group's room, with its content encoded for each of them. It returns
+// the addresses it was sent to.
+func NotifyNewGroupMessage(pool *Pool, message *models.GroupMessage, attachmentIDs []string) []string {
+	pool.mu.RLock()
+	clients := make([]*Client, 0, len(pool.rooms[GroupRoom(message.GroupID)]))
+	for client := range pool.rooms[GroupRoom(message.GroupID)] {
+		if client.Address != message.SenderAddress {
+			clients = append(clients, client)
+		}
+	}
+	pool.mu.RUnlock()
+
+	if attachmentIDs == nil {
+		attachmentIDs = []string{}
+	}
+
+	addresses := make([]string, 0, len(clients))
+	for _, client := range clients {
+		addresses = append(addresses, client.Address)
+	}
+	muted := mutedAddresses(models.ConversationGroup, message.GroupID, addresses)
+
+	sent := make([]string, 0, len(clients))
+	for _, client := range clients {
+		payload := map[string]interface{}{
+			"id":             message.ID,
+			"group_id":       message.GroupID,
+			"sender_address": message.SenderAddress,
+			"content":        client.Encoding.Encode(message.Content),
+			"timestamp":      types.FormatTime(message.Timestamp.Time),
+			"attachment_ids": attachmentIDs,
+		}
+		if message.ReplyToMessageID != nil {
+			payload["reply_to_message_id"] = *message.ReplyToMessageID
+		}
+		if message.ForwardedFrom != nil {
+			payload["forwarded_from"] = *message.ForwardedFrom
+		}
+		if message.System {
+			payload["system"] = true
+		}
+		if message.TopicID != nil {
+			payload["topic_id"] = *message.TopicID
+		}
+		if message.StickerID != nil {
+			payload["sticker_id"] = *message.StickerID
+		}
+		if muted[client.Address] {
+			payload["muted"] = true
+		}
+		client.SendMessage(Message{
+			Type:    MessageTypeNewGroupMessage,
+			Payload: payload,
+		})
+		client.trackDelivery(message.ID, message.Timestamp.Time)
+		sent = append(sent, client.Address)
+	}
+	return sent
+}
+
+// ackGroupMessage records a client's delivery acknowledgement of a group
+// message and sends the sender a receipt
+func (client *Client) ackGroupMessage(messageID string) {
+	client.completeDelivery(messageID, models.DeliveryGroup)
+
+	message, err := models.GetGroupMessageByID(context.Background(), messageID)
+	if err != nil {
+		return
+	}
+
+	// Only members subscribed to the group may acknowledge its messages
+	if message.SenderAddress == client.Address || !client.Pool.inRoom(GroupRoom(message.GroupID), client) {
+		return
+	}
+
+	recorded, err := models.MarkGroupMessageDelivered(context.Background(), messageID, client.Address)
+	if err != nil {
+		log.Printf("Error recording group message delivery: %v", err)
+		return
+	}
+	if !recorded {
+		return
+	}
+
+	client.Pool.Broadcast <- Message{
+		Type: "status_update",
+		Payload: map[string]interface{}{
+			"message_id": messageID,
+			"group_id":   message.GroupID,
+			"status":     "delivered",
+			"recipient":  client.Address,
+			"timestamp":  types.FormatTime(time.Now()),
+		},
+		To: message.SenderAddress,
+	}
+}
+
+// NotifyGroupMessageEdited pushes the new content of an edited group message
+// to the members subscribed to the group's room on every instance, encoded
+// for each of them
+func NotifyGroupMessageEdited(pool *Pool, message *models.GroupMessage) {
+	payload := map[string]interface{}{
+		"id":             message.ID,
+		"group_id":       message.GroupID,
+		"sender_address": message.SenderAddress,
+	}
+	if message.EditedAt != nil {
+		payload["edited_at"] = types.FormatTime(message.EditedAt.Time)
+	}
+
+	pool.relay(relayedMessage{
+		Room: GroupRoom(message.GroupID),
+		Message: Message{
+			Type:    MessageTypeGroupMessageEdited,
+			Payload: payload,
+		},
+		Encoded: map[string][]byte{"content": message.Content},
+	})
+}
+
+// NotifyGroupOwnerChanged tells the group's connected members that its
+// owner handed it over to another member
+func NotifyGroupOwnerChanged(pool *Pool, groupID, previousOwner, owner string) {
+	pool.sendToRoom(GroupRoom(groupID), Message{
+		Type: MessageTypeGroupOwnerChanged,
+		Payload: map[string]interface{}{
+			"group_id":       groupID,
+			"previous_owner": previousOwner,
+			"owner_address":  owner,
+			"timestamp":      types.FormatTime(time.Now()),
+		},
+	})
+}
@@ -0,0 +1,163 @@
+package websocket
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/piko/piko/models"
+)
+
+const (
+	// MessageTypeTyping is relayed when a user is typing, either to a single
+	// recipient or to the members of a group or channel
+	MessageTypeTyping = "typing"
+
+	// MessageTypePresence is sent when a user comes online or goes offline,
+	// and in reply to a client asking who in a group or channel is online
+	MessageTypePresence = "presence"
+
+	// typingThrottle is the minimum interval between typing events a client
+	// may fan out to the same group or channel
+	typingThrottle = 3 * time.Second
+)
+
+// scope identifies the group or channel a typing or presence event targets
+type scope struct {
+	key   string // payload key, "group_id" or "channel_id"
+	id    string
+	group bool
+}
+
+// scopeFromPayload returns the group or channel an event is addressed to
+func scopeFromPayload(payload map[string]interface{}) (scope, bool) {
+	if id, ok := payload["group_id"].(string); ok && id != "" {
+		return scope{key: "group_id", id: id, group: true}, true
+	}
+	if id, ok := payload["channel_id"].(string); ok && id != "" {
+		return scope{key: "channel_id", id: id}, true
+	}
+	return scope{}, false
+}
+
+// members returns the addresses of everyone in the scope
+func (s scope) members() ([]string, error) {
+	var addresses []string
+	if s.group {
+		members, err := models.GetGroupMembers(context.Background(), s.id)
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range members {
+			addresses = append(addresses, member.UserAddress)
+		}
+		return addresses, nil
+	}
+
+	members, err := models.GetChannelMembers(context.Background(), s.id)
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range members {
+		addresses = append(addresses, member.UserAddress)
+	}
+	return addresses, nil
+}
+
+// scopedMembers returns the members of a scope, or false if the client
+// isn't one of them
+func (client *Client) scopedMembers(s scope) ([]string, bool) {
+	members, err := s.members()
+	if err != nil {
+		log.Printf("Error getting members of %s %s: %v", s.key, s.id, err)
+		return nil, false
+	}
+	for _, address := range members {
+		if address == client.Address {
+			return members, true
+		}
+	}
+	return nil, false
+}
+
+// allowTyping reports whether the client may send another typing event to
+// the scope yet. Only called from the client's read loop.
+func (client *Client) allowTyping(s scope) bool {
+	if client.typingSentAt == nil {
+		client.typingSentAt = make(map[string]time.Time)
+	}
+
+	key := s.key + ":" + s.id
+	now := time.Now()
+	if last, ok := client.typingSentAt[key]; ok && now.Sub(last) < typingThrottle {
+		return false
+	}
+	client.typingSentAt[key] = now
+	return true
+}
+
+// sendScopedTyping fans a typing event out to the online members of a group
+// or channel, skipping the sender
+func (client *Client) sendScopedTyping(s scope) {
+	if !client.allowTyping(s) {
+		return
+	}
+
+	members, ok := client.scopedMembers(s)
+	if !ok {
+		return
+	}
+
+	recipients := make([]string, 0, len(members))
+	for _, address := range members {
+		if address != client.Address {
+			recipients = append(recipients, address)
+		}
+	}
+	client.Pool.sendTo(Message{
+		Type: MessageTypeTyping,
+		Payload: map[string]interface{}{
+			"from": client.Address,
+			s.key:  s.id,
+		},
+	}, recipients...)
+}
+
+// sendScopedPresence replies with the members of a group or channel that
+// are currently online, leaving out those hiding it from the client
+func (client *Client) sendScopedPresence(s scope) {
+	members, ok := client.scopedMembers(s)
+	if !ok {
+		return
+	}
+
+	online := []string{}
+	remote := []string{}
+	client.Pool.mu.RLock()
+	for _, address := range members {
+		member, ok := client.Pool.Clients[address]
+		switch {
+		case !ok:
+			remote = append(remote, address)
+		case member.presenceAudience.Includes(client.Address):
+			online = append(online, address)
+		}
+	}
+	client.Pool.mu.RUnlock()
+
+	// Members connected to other instances, if presence is shared
+	elsewhere := client.Pool.onlineElsewhere(remote)
+	for _, address := range remote {
+		if audience, ok := elsewhere[address]; ok && audience.Includes(client.Address) {
+			online = append(online, address)
+		}
+	}
+
+	client.SendMessage(Message{
+		Type: MessageTypePresence,
+		Payload: map[string]interface{}{
+			s.key:    s.id,
+			"online": online,
+		},
+	})
+}
@@ -0,0 +1,60 @@
+package websocket
+
+import (
+	"time"
+
+	"github.com/piko/piko/models"
+	"github.com/piko/piko/types"
+)
+
+const (
+	// MessageTypeGroupTopicCreated is sent to a group's connected members
+	// when an admin creates a topic
+	MessageTypeGroupTopicCreated = "group_topic_created"
+
+	// MessageTypeGroupTopicClosed is sent to a group's connected members
+	// when an admin closes a topic
+	MessageTypeGroupTopicClosed = "group_topic_closed"
+
+	// MessageTypeGroupTopicReopened is sent to a group's connected members
+	// when an admin reopens a topic
+	MessageTypeGroupTopicReopened = "group_topic_reopened"
+
+	// MessageTypeGroupTopicRead is sent to a group's connected members when
+	// one of them reads up to a message in a topic
+	MessageTypeGroupTopicRead = "group_topic_read"
+)
+
+// NotifyGroupTopicChanged tells the group's connected members that a topic
+// was created, closed or reopened
+func NotifyGroupTopicChanged(pool *Pool, messageType string, topic *models.GroupTopic) {
+	payload := map[string]interface{}{
+		"id":              topic.ID,
+		"group_id":        topic.GroupID,
+		"name":            topic.Name,
+		"creator_address": topic.CreatorAddress,
+		"created_at":      types.FormatTime(topic.CreatedAt.Time),
+	}
+	if topic.ClosedAt != nil {
+		payload["closed_at"] = types.FormatTime(topic.ClosedAt.Time)
+	}
+	pool.sendToRoom(GroupRoom(topic.GroupID), Message{
+		Type:    messageType,
+		Payload: payload,
+	})
+}
+
+// NotifyGroupTopicRead tells the group's connected members, including the
+// reader's own client, that a member read up to a message in a topic
+func NotifyGroupTopicRead(pool *Pool, message *models.GroupMessage, readerAddress string) {
+	pool.sendToRoom(GroupRoom(message.GroupID), Message{
+		Type: MessageTypeGroupTopicRead,
+		Payload: map[string]interface{}{
+			"group_id":   message.GroupID,
+			"topic_id":   *message.TopicID,
+			"reader":     readerAddress,
+			"message_id": message.ID,
+			"timestamp":  types.FormatTime(time.Now()),
+		},
+	})
+}
@@ -1,13 +1,20 @@
 package websocket
 
 import (
+	"context"
 	"encoding/json"
 	"log"
+	"math/rand"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gofiber/websocket/v2"
+	"github.com/piko/piko/crypto"
 	"github.com/piko/piko/models"
+	"github.com/piko/piko/privacy"
+	"github.com/piko/piko/types"
 )
 
 // Client represents a WebSocket client
@@ -16,7 +23,40 @@ type Client struct {
 	Address string
 	Conn    *websocket.Conn
 	Pool    *Pool
-	mu      sync.Mutex
+
+	// SessionID is the login session of the token the client connected
+	// with, if it has one, see CloseSession
+	SessionID string
+
+	// send queues messages for writeLoop until done is closed, and
+	// stopped is closed once writeLoop returns, see SendMessage
+	writerOnce sync.Once
+	closeOnce  sync.Once
+	send       chan Message
+	done       chan struct{}
+	stopped    chan struct{}
+
+	// Encoding is used for encrypted content sent to this client
+	Encoding crypto.Encoding
+
+	// ConnectedAt is when the client's WebSocket was accepted
+	ConnectedAt time.Time
+
+	prefetchLimit    int
+	prefetchPageSize int
+	prefetchAcks     chan int
+
+	// typingSentAt throttles group and channel typing events per scope
+	typingSentAt map[string]time.Time
+
+	// deliveries holds when the messages pushed to the client were stored,
+	// until it acknowledges them, see trackDelivery
+	deliveryMu sync.Mutex
+	deliveries map[string]time.Time
+
+	// presenceAudience is who sees the client come online and go offline,
+	// guarded by the pool's mutex, see SetPresenceAudience
+	presenceAudience privacy.Audience
 }
 
 // Pool represents a pool of WebSocket clients
@@ -26,6 +66,23 @@ type Pool struct {
 	Clients    map[string]*Client
 	Broadcast  chan Message
 	mu         sync.RWMutex
+	draining   atomic.Bool
+
+	// trackDeliveries measures delivery times, see TrackDeliveries
+	trackDeliveries atomic.Bool
+
+	// options are the send queue and keepalive settings, see Configure
+	options Options
+
+	// rooms holds the clients subscribed to each room, see GroupRoom
+	rooms map[string]map[*Client]bool
+
+	// broker relays messages to the pools of other instances, see UseBroker
+	broker     Broker
+	instanceID string
+	// presence shares the users connected to each instance, if the broker
+	// can hold it
+	presence PresenceStore
 }
 
 // Message represents a WebSocket message
@@ -34,6 +91,9 @@ type Message struct {
 	Payload map[string]interface{} `json:"payload"`
 	From    string                 `json:"from,omitempty"`
 	To      string                 `json:"to,omitempty"`
+
+	// last disconnects the client once the message is written, see sendLast
+	last bool
 }
 
 // MessageStatus represents the status of a message
@@ -61,16 +121,56 @@ const (
 
 	// MessageTypeNewGroupMessage is sent when a new group message is received
 	MessageTypeNewGroupMessage = "new_group_message"
+
+	// MessageTypeMessageEdited is sent when the sender edits a direct message
+	MessageTypeMessageEdited = "message_edited"
+
+	// MessageTypeChannelMessageEdited is sent to a channel's members when the
+	// sender edits a channel message
+	MessageTypeChannelMessageEdited = "channel_message_edited"
+
+	// MessageTypeMessageExpired is sent when direct messages pass their
+	// expiration time and are purged
+	MessageTypeMessageExpired = "message_expired"
+
+	// MessageTypeRemoteWipe tells a device its session was wiped from another device
+	MessageTypeRemoteWipe = "remote_wipe"
+
+	// MessageTypeSessionRevoked tells a device its session was signed out
+	MessageTypeSessionRevoked = "session_revoked"
+
+	// MessageTypeAccountFrozen tells a user's devices they were signed out
+	// by an emergency freeze
+	MessageTypeAccountFrozen = "account_frozen"
+
+	// MessageTypeAccountSuspended tells a user's devices they were signed
+	// out because an operator suspended the account
+	MessageTypeAccountSuspended = "account_suspended"
+
+	// MessageTypeSafetyNumberChanged is sent when a peer's key no longer matches the one on record
+	MessageTypeSafetyNumberChanged = "safety_number_changed"
+
+	// MessageTypePrekeysLow is sent when a user is running out of one-time prekeys
+	MessageTypePrekeysLow = "prekeys_low"
+
+	// MessageTypeGroupEventReminder is sent shortly before a group event starts
+	MessageTypeGroupEventReminder = "group_event_reminder"
+
+	// MessageTypeReconnectSoon is sent before the server shuts down so clients
+	// can reconnect to another instance after the suggested delay
+	MessageTypeReconnectSoon = "reconnect_soon"
 )
 
 // NewPool creates a new WebSocket pool
 func NewPool() *Pool {
-	return &Pool{
+	pool := &Pool{
 		Register:   make(chan *Client),
 		Unregister: make(chan *Client),
 		Clients:    make(map[string]*Client),
 		Broadcast:  make(chan Message),
 	}
+	pool.Configure(Options{})
+	return pool
 }
 
 // Start starts the WebSocket pool
@@ -84,13 +184,8 @@ func (pool *Pool) Start() {
 			log.Printf("Client connected: %s", client.Address)
 
 			// Send presence update to all clients
-			pool.Broadcast <- Message{
-				Type: "presence",
-				Payload: map[string]interface{}{
-					"address": client.Address,
-					"status":  "online",
-				},
-			}
+			pool.sendPresence(client, presenceOnline)
+			go pool.markOnline(client)
 
 			// Send welcome message to client
 			client.SendMessage(Message{
@@ -100,10 +195,20 @@ func (pool *Pool) Start() {
 				},
 			})
 
+			// Subscribe to the rooms of the client's groups. Clients are
+			// only registered once their token is verified for their
+			// address, so rooms never carry content to another account.
+			go pool.joinGroupRooms(client)
+
+			// Stream recent conversations if the client asked for them
+			if client.prefetchLimit > 0 {
+				go client.streamInbox()
+			}
+
 			// Mark all pending messages as delivered
 			go func() {
 				// Get all pending messages for this client
-				messages, err := models.GetMessagesByRecipient(client.Address)
+				messages, err := models.GetMessagesByRecipient(context.Background(), client.Address)
 				if err != nil {
 					log.Printf("Error getting messages for %s: %v", client.Address, err)
 					return
@@ -112,27 +217,21 @@ func (pool *Pool) Start() {
 				for _, msg := range messages {
 					if msg.Status == models.MessageStatusPending {
 						// Update status to delivered
-						if err := models.UpdateMessageStatus(msg.ID, models.MessageStatusDelivered); err != nil {
+						if err := models.UpdateMessageStatus(context.Background(), msg.ID, models.MessageStatusDelivered); err != nil {
 							log.Printf("Error updating message status: %v", err)
 							continue
 						}
 
 						// Notify sender about delivery
-						pool.mu.RLock()
-						senderClient, senderOnline := pool.Clients[msg.SenderAddress]
-						pool.mu.RUnlock()
-
-						if senderOnline {
-							senderClient.SendMessage(Message{
-								Type: "status_update",
-								Payload: map[string]interface{}{
-									"message_id": msg.ID,
-									"status":     "delivered",
-									"recipient":  client.Address,
-									"timestamp":  time.Now().Format(time.RFC3339),
-								},
-							})
-						}
+						pool.sendTo(Message{
+							Type: "status_update",
+							Payload: map[string]interface{}{
+								"message_id": msg.ID,
+								"status":     "delivered",
+								"recipient":  client.Address,
+								"timestamp":  types.FormatTime(time.Now()),
+							},
+						}, msg.SenderAddress)
 					}
 				}
 			}()
@@ -140,66 +239,127 @@ func (pool *Pool) Start() {
 		case client := <-pool.Unregister:
 			pool.mu.Lock()
 			delete(pool.Clients, client.Address)
+			pool.leaveAllLocked(client)
 			pool.mu.Unlock()
 			log.Printf("Client disconnected: %s", client.Address)
 
 			// Send presence update to all clients
-			pool.Broadcast <- Message{
-				Type: "presence",
-				Payload: map[string]interface{}{
-					"address": client.Address,
-					"status":  "offline",
-				},
-			}
+			pool.sendPresence(client, presenceOffline)
+			go pool.markOffline(client)
 
 		case message := <-pool.Broadcast:
-			// If message has a specific recipient, send only to that client
-			if message.To != "" {
-				pool.mu.RLock()
-				client, ok := pool.Clients[message.To]
-				pool.mu.RUnlock()
-				if ok {
-					client.SendMessage(message)
-				}
-			} else {
-				// Otherwise, broadcast to all clients
-				pool.mu.RLock()
-				for _, client := range pool.Clients {
-					client.SendMessage(message)
-				}
-				pool.mu.RUnlock()
-			}
+			pool.broadcast(message)
 		}
 	}
 }
 
-// SendMessage sends a message to a client
+// broadcast queues a message for its recipient, or for every client if it
+// has none, on every instance. Start calls it directly for its own
+// messages, since sending them to Broadcast would wait on itself.
+func (pool *Pool) broadcast(message Message) {
+	if message.To != "" {
+		pool.sendTo(message, message.To)
+		return
+	}
+
+	relayed := relayedMessage{Message: message}
+	pool.deliver(relayed)
+	pool.publish(relayed)
+}
+
+// Drain stops the pool from accepting new clients and asks every connected
+// client to reconnect. Each client gets its own random retry_after within
+// the jitter window so a rolling deploy doesn't cause a reconnect stampede.
+func (pool *Pool) Drain(jitter time.Duration) int {
+	pool.draining.Store(true)
+
+	pool.mu.RLock()
+	clients := make([]*Client, 0, len(pool.Clients))
+	for _, client := range pool.Clients {
+		clients = append(clients, client)
+	}
+	pool.mu.RUnlock()
+
+	for _, client := range clients {
+		retryAfter := time.Second
+		if jitter > 0 {
+			retryAfter += time.Duration(rand.Int63n(int64(jitter)))
+		}
+		client.SendMessage(Message{
+			Type: MessageTypeReconnectSoon,
+			Payload: map[string]interface{}{
+				"retry_after_ms": retryAfter.Milliseconds(),
+			},
+		})
+	}
+
+	return len(clients)
+}
+
+// IsDraining reports whether the pool is refusing new clients
+func (pool *Pool) IsDraining() bool {
+	return pool.draining.Load()
+}
+
+// SendMessage queues a message for the client without waiting for it to be
+// written, so a slow client can't hold up the sender. A client whose queue
+// is full can't keep up: the message is dropped and the client is
+// disconnected, to catch up when it reconnects.
 func (client *Client) SendMessage(message Message) {
-	client.mu.Lock()
-	defer client.mu.Unlock()
-	if err := client.Conn.WriteJSON(message); err != nil {
-		log.Printf("Error sending message to client %s: %v", client.Address, err)
+	client.startWriter()
+	select {
+	case <-client.done:
+		return
+	default:
+	}
+
+	select {
+	case client.send <- message:
+	default:
+		droppedMessages.Inc()
+		slowConsumers.Inc()
+		log.Printf("Disconnecting client %s: send queue full", client.Address)
+		client.close()
 	}
 }
 
+// decodeMessage parses a text frame sent by a client
+func decodeMessage(p []byte) (Message, error) {
+	var message Message
+	if err := json.Unmarshal(p, &message); err != nil {
+		return Message{}, err
+	}
+	return message, nil
+}
+
 // Read reads messages from a client
 func (client *Client) Read() {
 	defer func() {
 		client.Pool.Unregister <- client
-		client.Conn.Close()
+		client.close()
+		<-client.stopped
 	}()
 
+	// Clients that stop answering pings are disconnected
+	client.startWriter()
+	client.keepAlive()
+	client.Conn.SetPongHandler(func(string) error {
+		client.keepAlive()
+		return nil
+	})
+
 	for {
 		messageType, p, err := client.Conn.ReadMessage()
 		if err != nil {
 			log.Printf("Error reading message from client %s: %v", client.Address, err)
 			return
 		}
+		client.keepAlive()
 
 		// Handle different message types
 		if messageType == websocket.TextMessage {
-			var message Message
-			if err := json.Unmarshal(p, &message); err != nil {
+			message, err := decodeMessage(p)
+			if err != nil {
 				log.Printf("Error unmarshaling message from client %s: %v", client.Address, err)
 				continue
 			}
@@ -210,12 +370,21 @@ func (client *Client) Read() {
 				// Respond with pong
 				client.SendMessage(Message{
 					Type:    "pong",
-					Payload: map[string]interface{}{"time": time.Now().Format(time.RFC3339)},
+					Payload: map[string]interface{}{"time": types.FormatTime(time.Now())},
 				})
 
-			case "typing":
+			case MessageTypeInboxAck:
+				// Client finished processing a prefetched inbox page
+				if page, ok := message.Payload["page"].(float64); ok {
+					client.ackPrefetchPage(int(page))
+				}
+
+			case MessageTypeTyping:
 				// Handle typing indicator
-				if to, ok := message.Payload["to"].(string); ok {
+				if s, ok := scopeFromPayload(message.Payload); ok {
+					// Fan out to the group or channel
+					client.sendScopedTyping(s)
+				} else if to, ok := message.Payload["to"].(string); ok {
 					// Forward typing indicator to recipient
 					client.Pool.Broadcast <- Message{
 						Type: "typing",
@@ -226,15 +395,21 @@ func (client *Client) Read() {
 					}
 				}
 
+			case MessageTypePresence:
+				// Report which members of a group or channel are online
+				if s, ok := scopeFromPayload(message.Payload); ok {
+					client.sendScopedPresence(s)
+				}
+
 			case "read":
 				// Handle message read status
 				if messageID, ok := message.Payload["message_id"].(string); ok {
 					// Update message status in database
-					if err := models.UpdateMessageStatus(messageID, models.MessageStatusRead); err != nil {
+					if err := models.UpdateMessageStatus(context.Background(), messageID, models.MessageStatusRead); err != nil {
 						log.Printf("Error updating message status: %v", err)
 					} else {
 						// Get message to find sender
-						msg, err := models.GetMessageByID(messageID)
+						msg, err := models.GetMessageByID(context.Background(), messageID)
 						if err == nil {
 							// Notify sender that message was read
 							client.Pool.Broadcast <- Message{
@@ -243,7 +418,7 @@ func (client *Client) Read() {
 									"message_id": messageID,
 									"status":     "read",
 									"recipient":  client.Address,
-									"timestamp":  time.Now().Format(time.RFC3339),
+									"timestamp":  types.FormatTime(time.Now()),
 								},
 								To: msg.SenderAddress,
 							}
@@ -251,15 +426,23 @@ func (client *Client) Read() {
 					}
 				}
 
+			case MessageTypeGroupReceived:
+				// Handle group message delivery acknowledgement
+				if messageID, ok := message.Payload["message_id"].(string); ok {
+					go client.ackGroupMessage(messageID)
+				}
+
 			case "received":
 				// Handle message received status (client acknowledges receipt)
 				if messageID, ok := message.Payload["message_id"].(string); ok {
+					client.completeDelivery(messageID, models.DeliveryDirect)
+
 					// Update message status in database
-					if err := models.UpdateMessageStatus(messageID, models.MessageStatusDelivered); err != nil {
+					if err := models.UpdateMessageStatus(context.Background(), messageID, models.MessageStatusDelivered); err != nil {
 						log.Printf("Error updating message status: %v", err)
 					} else {
 						// Get message to find sender
-						msg, err := models.GetMessageByID(messageID)
+						msg, err := models.GetMessageByID(context.Background(), messageID)
 						if err == nil {
 							// Notify sender that message was delivered
 							client.Pool.Broadcast <- Message{
@@ -268,7 +451,7 @@ func (client *Client) Read() {
 									"message_id": messageID,
 									"status":     "delivered",
 									"recipient":  client.Address,
-									"timestamp":  time.Now().Format(time.RFC3339),
+									"timestamp":  types.FormatTime(time.Now()),
 								},
 								To: msg.SenderAddress,
 							}
@@ -291,72 +474,290 @@ func NotifyNewMessage(pool *Pool, message *models.Message) {
 	client, ok := pool.Clients[message.RecipientAddress]
 	pool.mu.RUnlock()
 
+	payload := map[string]interface{}{
+		"id":             message.ID,
+		"sender_address": message.SenderAddress,
+	}
+	if message.ReplyToMessageID != nil {
+		payload["reply_to_message_id"] = *message.ReplyToMessageID
+	}
+	if message.ForwardedFrom != nil {
+		payload["forwarded_from"] = *message.ForwardedFrom
+	}
+	if message.StickerID != nil {
+		payload["sticker_id"] = *message.StickerID
+	}
+	if mutedAddresses(models.ConversationDirect, message.SenderAddress, []string{message.RecipientAddress})[message.RecipientAddress] {
+		payload["muted"] = true
+	}
+	notification := Message{
+		Type:    "new_message",
+		Payload: payload,
+	}
+
 	if ok {
 		// Send notification to recipient
-		client.SendMessage(Message{
-			Type: "new_message",
-			Payload: map[string]interface{}{
-				"id":             message.ID,
-				"sender_address": message.SenderAddress,
-			},
-		})
+		client.SendMessage(notification)
+		client.trackDelivery(message.ID, message.Timestamp.Time)
 
 		// Update message status to delivered
-		if err := models.UpdateMessageStatus(message.ID, models.MessageStatusDelivered); err != nil {
+		if err := models.UpdateMessageStatus(context.Background(), message.ID, models.MessageStatusDelivered); err != nil {
 			log.Printf("Error updating message status: %v", err)
 		} else {
 			// Notify sender about delivery
-			pool.mu.RLock()
-			senderClient, senderOnline := pool.Clients[message.SenderAddress]
-			pool.mu.RUnlock()
-
-			if senderOnline {
-				senderClient.SendMessage(Message{
-					Type: "status_update",
-					Payload: map[string]interface{}{
-						"message_id": message.ID,
-						"status":     "delivered",
-						"recipient":  message.RecipientAddress,
-						"timestamp":  time.Now().Format(time.RFC3339),
-					},
-				})
-			}
+			pool.sendTo(Message{
+				Type: "status_update",
+				Payload: map[string]interface{}{
+					"message_id": message.ID,
+					"status":     "delivered",
+					"recipient":  message.RecipientAddress,
+					"timestamp":  types.FormatTime(time.Now()),
+				},
+			}, message.SenderAddress)
 		}
+	} else if pool.broker != nil {
+		// The recipient may be connected to another instance. Its client
+		// acknowledges the message, which marks it delivered.
+		pool.publish(relayedMessage{Addresses: []string{message.RecipientAddress}, Message: notification})
 	} else {
 		// Recipient is offline, message stays in pending state
 		log.Printf("Recipient %s is offline, message %s is pending", message.RecipientAddress, message.ID)
 	}
 }
 
+// NotifyMessageEdited pushes the new content of an edited message to its
+// recipient, on whichever instance they are connected to
+func NotifyMessageEdited(pool *Pool, message *models.Message) {
+	payload := map[string]interface{}{
+		"id":             message.ID,
+		"sender_address": message.SenderAddress,
+	}
+	if message.EditedAt != nil {
+		payload["edited_at"] = types.FormatTime(message.EditedAt.Time)
+	}
+
+	pool.relay(relayedMessage{
+		Addresses: []string{message.RecipientAddress},
+		Message: Message{
+			Type:    MessageTypeMessageEdited,
+			Payload: payload,
+		},
+		Encoded: map[string][]byte{"encrypted_content": message.EncryptedContent},
+	})
+}
+
+// NotifyMessagesExpired tells a user which of their direct messages were
+// purged, so clients can drop their local copies
+func NotifyMessagesExpired(pool *Pool, address string, messageIDs []string) {
+	pool.sendTo(Message{
+		Type: MessageTypeMessageExpired,
+		Payload: map[string]interface{}{
+			"message_ids": messageIDs,
+			"timestamp":   types.FormatTime(time.Now()),
+		},
+	}, address)
+}
+
+// NotifyRemoteWipe tells the device signed in with a session that it was
+// wiped from another device, then disconnects it
+func NotifyRemoteWipe(pool *Pool, sessionID string) {
+	pool.CloseSession(sessionID, Message{
+		Type: MessageTypeRemoteWipe,
+		Payload: map[string]interface{}{
+			"session_id": sessionID,
+			"timestamp":  types.FormatTime(time.Now()),
+		},
+	})
+}
+
+// closeUser sends a last message to a user's client, on whichever instance
+// it is connected to, and disconnects it
+func (pool *Pool) closeUser(address string, message Message) {
+	pool.relay(relayedMessage{Addresses: []string{address}, Message: message, Close: true})
+}
+
+// NotifySessionRevoked tells the device signed in with a session that it
+// was signed out, then disconnects it
+func NotifySessionRevoked(pool *Pool, sessionID string) {
+	pool.CloseSession(sessionID, Message{
+		Type: MessageTypeSessionRevoked,
+		Payload: map[string]interface{}{
+			"session_id": sessionID,
+			"timestamp":  types.FormatTime(time.Now()),
+		},
+	})
+}
+
+// CloseSession sends a last message to the clients connected with a
+// session's token, on whichever instance they are connected to, and
+// disconnects them. Tokens are only checked when a client connects, so
+// clients of ended sessions would otherwise keep receiving messages.
+func (pool *Pool) CloseSession(sessionID string, message Message) {
+	if sessionID == "" {
+		return
+	}
+	pool.relay(relayedMessage{Session: sessionID, Message: message, Close: true})
+}
+
+// NotifyAccountFrozen tells a user's connected device that their account
+// was frozen and its session signed out, then disconnects it
+func NotifyAccountFrozen(pool *Pool, address string, frozenUntil time.Time) {
+	pool.closeUser(address, Message{
+		Type: MessageTypeAccountFrozen,
+		Payload: map[string]interface{}{
+			"frozen_until": types.FormatTime(frozenUntil),
+			"timestamp":    types.FormatTime(time.Now()),
+		},
+	})
+}
+
+// NotifyAccountSuspended tells a user's connected device that their account
+// was suspended and its session signed out, then disconnects it
+func NotifyAccountSuspended(pool *Pool, address, reason string) {
+	pool.closeUser(address, Message{
+		Type: MessageTypeAccountSuspended,
+		Payload: map[string]interface{}{
+			"reason":    reason,
+			"timestamp": types.FormatTime(time.Now()),
+		},
+	})
+}
+
+// NotifySafetyNumberChanged tells a user that a peer's key has changed and
+// their safety number must be verified again
+func NotifySafetyNumberChanged(pool *Pool, ownerAddress, peerAddress string) {
+	pool.sendTo(Message{
+		Type: MessageTypeSafetyNumberChanged,
+		Payload: map[string]interface{}{
+			"peer_address": peerAddress,
+			"timestamp":    types.FormatTime(time.Now()),
+		},
+	}, ownerAddress)
+}
+
+// NotifyPrekeysLow tells a user how many one-time prekeys they have left, so
+// a connected device can upload more before they run out
+func NotifyPrekeysLow(pool *Pool, address string, remaining int) {
+	pool.sendTo(Message{
+		Type: MessageTypePrekeysLow,
+		Payload: map[string]interface{}{
+			"remaining": remaining,
+			"timestamp": types.FormatTime(time.Now()),
+		},
+	}, address)
+}
+
+// NotifyGroupEventReminder reminds a user that a group event is starting
+// soon. It returns false if the user isn't connected.
+func NotifyGroupEventReminder(pool *Pool, address string, event *models.GroupEvent) bool {
+	pool.mu.RLock()
+	client, ok := pool.Clients[address]
+	pool.mu.RUnlock()
+	if !ok {
+		return false
+	}
+
+	client.SendMessage(Message{
+		Type: MessageTypeGroupEventReminder,
+		Payload: map[string]interface{}{
+			"event_id":  event.ID,
+			"group_id":  event.GroupID,
+			"title":     event.Title,
+			"starts_at": types.FormatTime(event.StartsAt.Time),
+		},
+	})
+	return true
+}
+
 // NotifyNewChannelMessage notifies clients about a new channel message
 func NotifyNewChannelMessage(pool *Pool, message *models.ChannelMessage) {
 	// Get channel members
-	members, err := models.GetChannelMembers(message.ChannelID)
+	members, err := models.GetChannelMembers(context.Background(), message.ChannelID)
 	if err != nil {
 		log.Printf("Error getting channel members: %v", err)
 		return
 	}
 
-	// Notify all online members except the sender
+	payload := map[string]interface{}{
+		"id":             message.ID,
+		"channel_id":     message.ChannelID,
+		"sender_address": message.SenderAddress,
+	}
+	if message.ReplyToMessageID != nil {
+		payload["reply_to_message_id"] = *message.ReplyToMessageID
+	}
+	if message.ForwardedFrom != nil {
+		payload["forwarded_from"] = *message.ForwardedFrom
+	}
+	if message.ForwardedFromChannel != nil {
+		payload["forwarded_from_channel"] = *message.ForwardedFromChannel
+	}
+	if message.StickerID != nil {
+		payload["sticker_id"] = *message.StickerID
+	}
+
+	// Notify all online members except the sender, marking the message for
+	// those who muted the channel
+	recipients := make([]string, 0, len(members))
 	for _, member := range members {
-		if member.UserAddress == message.SenderAddress {
-			continue
+		if member.UserAddress != message.SenderAddress {
+			recipients = append(recipients, member.UserAddress)
 		}
+	}
+	muted := mutedAddresses(models.ConversationChannel, message.ChannelID, recipients)
+	notified, silenced := make([]string, 0, len(recipients)), []string{}
+	for _, address := range recipients {
+		if muted[address] {
+			silenced = append(silenced, address)
+		} else {
+			notified = append(notified, address)
+		}
+	}
+	pool.sendTo(Message{
+		Type:    "new_channel_message",
+		Payload: payload,
+	}, notified...)
+
+	mutedPayload := make(map[string]interface{}, len(payload)+1)
+	for key, value := range payload {
+		mutedPayload[key] = value
+	}
+	mutedPayload["muted"] = true
+	pool.sendTo(Message{
+		Type:    "new_channel_message",
+		Payload: mutedPayload,
+	}, silenced...)
+}
 
-		pool.mu.RLock()
-		client, ok := pool.Clients[member.UserAddress]
-		pool.mu.RUnlock()
-		if ok {
-			client.SendMessage(Message{
-				Type: "new_channel_message",
-				Payload: map[string]interface{}{
-					"id":             message.ID,
-					"channel_id":     message.ChannelID,
-					"sender_address": message.SenderAddress,
-				},
-			})
+// NotifyChannelMessageEdited tells a channel's online members, except the
+// sender, that a message was edited. Like new channel messages, the content
+// is fetched separately.
+func NotifyChannelMessageEdited(pool *Pool, message *models.ChannelMessage) {
+	members, err := models.GetChannelMembers(context.Background(), message.ChannelID)
+	if err != nil {
+		log.Printf("Error getting channel members: %v", err)
+		return
+	}
+
+	payload := map[string]interface{}{
+		"id":             message.ID,
+		"channel_id":     message.ChannelID,
+		"sender_address": message.SenderAddress,
+	}
+	if message.EditedAt != nil {
+		payload["edited_at"] = types.FormatTime(message.EditedAt.Time)
+	}
+
+	recipients := make([]string, 0, len(members))
+	for _, member := range members {
+		if member.UserAddress != message.SenderAddress {
+			recipients = append(recipients, member.UserAddress)
 		}
 	}
+	pool.sendTo(Message{
+		Type:    MessageTypeChannelMessageEdited,
+		Payload: payload,
+	}, recipients...)
 }
 
 // GetOnlineUsers returns a list of online users
@@ -371,10 +772,48 @@ func GetOnlineUsers(pool *Pool) []string {
 	return users
 }
 
-// IsUserOnline checks if a user is online
+// IsUserOnline checks if a user is connected to this or, with a shared
+// presence store, any other instance
 func IsUserOnline(pool *Pool, address string) bool {
 	pool.mu.RLock()
-	defer pool.mu.RUnlock()
 	_, ok := pool.Clients[address]
+	pool.mu.RUnlock()
+	if ok {
+		return true
+	}
+	_, ok = pool.onlineElsewhere([]string{address})[address]
 	return ok
 }
+
+// ClientInfo describes a connected client for operators
+type ClientInfo struct {
+	Address     string     `json:"address"`
+	Encoding    string     `json:"encoding"`
+	ConnectedAt types.Time `json:"connected_at"`
+}
+
+// ConnectedClients lists the clients connected to a pool, longest connected first
+func ConnectedClients(pool *Pool) []ClientInfo {
+	pool.mu.RLock()
+	clients := make([]ClientInfo, 0, len(pool.Clients))
+	for _, client := range pool.Clients {
+		clients = append(clients, ClientInfo{
+			Address:     client.Address,
+			Encoding:    string(client.Encoding),
+			ConnectedAt: types.NewTime(client.ConnectedAt),
+		})
+	}
+	pool.mu.RUnlock()
+
+	sort.Slice(clients, func(i, j int) bool {
+		return clients[i].ConnectedAt.Before(clients[j].ConnectedAt.Time)
+	})
+	return clients
+}
+
+// ClientCount returns the number of clients connected to a pool
+func ClientCount(pool *Pool) int {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+	return len(pool.Clients)
+}
@@ -0,0 +1,133 @@
+package websocket
+
+import (
+	"log"
+	"time"
+
+	"github.com/gofiber/websocket/v2"
+	"github.com/piko/piko/metrics"
+)
+
+// Default send queue and keepalive settings, see Pool.Configure
+const (
+	DefaultSendBuffer   = 256
+	DefaultWriteTimeout = 10 * time.Second
+	DefaultPingInterval = 30 * time.Second
+	DefaultPongTimeout  = 60 * time.Second
+)
+
+var (
+	slowConsumers = metrics.NewCounterVec(
+		"piko_websocket_slow_consumers_total",
+		"WebSocket clients disconnected because their send queue was full.",
+	)
+	droppedMessages = metrics.NewCounterVec(
+		"piko_websocket_dropped_messages_total",
+		"WebSocket messages dropped because the client's send queue was full.",
+	)
+)
+
+// Options tune how the clients of a pool are written to and kept alive
+type Options struct {
+	// SendBuffer is how many messages can wait to be written to a client.
+	// Clients that fall further behind are disconnected.
+	SendBuffer int
+	// WriteTimeout bounds each write to a client
+	WriteTimeout time.Duration
+	// PingInterval is how often clients are pinged
+	PingInterval time.Duration
+	// PongTimeout is how long a client may go without sending anything,
+	// pongs included, before it is disconnected. It must be longer than
+	// PingInterval.
+	PongTimeout time.Duration
+}
+
+// Configure sets the pool's send queue and keepalive settings. Zero fields
+// keep their defaults. Call it before clients connect.
+func (pool *Pool) Configure(opts Options) {
+	if opts.SendBuffer <= 0 {
+		opts.SendBuffer = DefaultSendBuffer
+	}
+	if opts.WriteTimeout <= 0 {
+		opts.WriteTimeout = DefaultWriteTimeout
+	}
+	if opts.PingInterval <= 0 {
+		opts.PingInterval = DefaultPingInterval
+	}
+	if opts.PongTimeout <= opts.PingInterval {
+		opts.PongTimeout = 2 * opts.PingInterval
+	}
+	pool.options = opts
+}
+
+// startWriter creates the client's send queue and starts writing it to the
+// connection, the first time it is called
+func (client *Client) startWriter() {
+	client.writerOnce.Do(func() {
+		client.send = make(chan Message, client.Pool.options.SendBuffer)
+		client.done = make(chan struct{})
+		client.stopped = make(chan struct{})
+		go client.writeLoop()
+	})
+}
+
+// writeLoop is the only writer to the client's connection. It writes queued
+// messages and pings the client until the connection is closed.
+func (client *Client) writeLoop() {
+	defer close(client.stopped)
+	opts := client.Pool.options
+	ticker := time.NewTicker(opts.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case message := <-client.send:
+			client.Conn.SetWriteDeadline(time.Now().Add(opts.WriteTimeout))
+			if err := client.Conn.WriteJSON(message); err != nil {
+				log.Printf("Error sending message to client %s: %v", client.Address, err)
+				client.close()
+				return
+			}
+			if message.last {
+				reason := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, message.Type)
+				client.Conn.WriteControl(websocket.CloseMessage, reason, time.Now().Add(opts.WriteTimeout))
+				client.close()
+				return
+			}
+
+		case <-ticker.C:
+			if err := client.Conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(opts.WriteTimeout)); err != nil {
+				log.Printf("Error pinging client %s: %v", client.Address, err)
+				client.close()
+				return
+			}
+
+		case <-client.done:
+			return
+		}
+	}
+}
+
+// sendLast queues a message for the client and disconnects it once the
+// message is written. Messages queued after it are dropped.
+func (client *Client) sendLast(message Message) {
+	message.last = true
+	client.SendMessage(message)
+}
+
+// keepAlive disconnects the client if it sends nothing, not even a pong,
+// within the pong timeout. Call it again whenever the client sends something.
+func (client *Client) keepAlive() {
+	client.Conn.SetReadDeadline(time.Now().Add(client.Pool.options.PongTimeout))
+}
+
+// close stops the writer and closes the connection, which ends Read. It is
+// safe to call more than once. The writer may still be finishing a write;
+// Read waits for it, since the connection is reused once Read returns.
+func (client *Client) close() {
+	client.startWriter()
+	client.closeOnce.Do(func() {
+		close(client.done)
+		client.Conn.Close()
+	})
+}